@@ -35,6 +35,12 @@ type VSSize string
 // +kubebuilder:validation:Enum=LARGE;MEDIUM;SMALL
 type PassthroughVSSize string
 
+// +kubebuilder:validation:Enum=hostname;namespace
+type L7ShardScheme string
+
+// +kubebuilder:validation:Enum=Alert;Revert
+type DriftPolicyType string
+
 type NamespaceSelector struct {
 	LabelKey   string `json:"labelKey,omitempty"`
 	LabelValue string `json:"labelValue,omitempty"`
@@ -68,6 +74,47 @@ type AKOSettings struct {
 	ServicesAPI bool `json:"servicesAPI,omitempty"`
 	// VipPerNamespace enables AKO to create Parent VS per Namespace in EVH mode
 	VipPerNamespace bool `json:"vipPerNamespace,omitempty"`
+	// ValidatingWebhook defines the settings for the AKO validating admission webhook
+	ValidatingWebhook ValidatingWebhookSettings `json:"validatingWebhook,omitempty"`
+	// DryRun makes AKO only log and serve the Avi REST operations it would execute, without executing them
+	DryRun bool `json:"dryRun,omitempty"`
+	// EnableEndpointSlice enables AKO to build the pool servers from the EndpointSlices of the services
+	EnableEndpointSlice bool `json:"enableEndpointSlice,omitempty"`
+	// ServerDrainTimeout is the time in seconds for which the pool servers of the terminating or removed endpoints are disabled, before they are removed
+	ServerDrainTimeout int `json:"serverDrainTimeout,omitempty"`
+	// EnablePodReadinessGate enables AKO to set the pool member readiness gate condition of the pods
+	EnablePodReadinessGate bool `json:"enablePodReadinessGate,omitempty"`
+	// EnableProbeHealthMonitor enables AKO to create the health monitors of the pools from the readiness probes of the pods
+	EnableProbeHealthMonitor bool `json:"enableProbeHealthMonitor,omitempty"`
+	// EnableRuntimeStatus enables AKO to set the runtime status of the virtual services and pools as conditions on the Kubernetes objects
+	EnableRuntimeStatus bool `json:"enableRuntimeStatus,omitempty"`
+	// DriftScanInterval is the interval in seconds at which AKO compares the Avi objects created by it with its cache,
+	// to find the changes made out of band. The scan is disabled if set to 0
+	DriftScanInterval int `json:"driftScanInterval,omitempty"`
+	// DriftPolicy specifies whether AKO reverts the Avi objects changed out of band, or only reports them
+	DriftPolicy DriftPolicyType `json:"driftPolicy,omitempty"`
+	// StandbyCacheRefreshInterval is the interval in seconds at which the standby AKO replicas refresh their Avi object cache.
+	// The cache is refreshed only when a replica becomes the leader if set to 0
+	StandbyCacheRefreshInterval int `json:"standbyCacheRefreshInterval,omitempty"`
+	// RuntimeStatusSyncInterval is the interval in seconds at which the runtime status of the virtual services and pools is
+	// fetched when enableRuntimeStatus is set. Defaults to 30 seconds if set to 0
+	RuntimeStatusSyncInterval int `json:"runtimeStatusSyncInterval,omitempty"`
+	// PodReadinessGateSyncInterval is the interval in seconds at which the pool member readiness gate condition of the pods
+	// is synced when enablePodReadinessGate is set. Defaults to 10 seconds if set to 0
+	PodReadinessGateSyncInterval int `json:"podReadinessGateSyncInterval,omitempty"`
+	// PrimaryInstance marks the AKO instance as the primary instance, which configures the vrf and static routes.
+	// Exactly one AKO instance in a cluster should be primary. Defaults to true.
+	PrimaryInstance *bool `json:"primaryInstance,omitempty"`
+}
+
+// ValidatingWebhookSettings defines the settings for the validating admission webhook, which
+// validates HostRule, HTTPRule, AviInfraSetting and MultiClusterIngress objects at admission time.
+// The operator generates the webhook certificates and registers the webhook with the API server.
+type ValidatingWebhookSettings struct {
+	// Enabled enables the validating admission webhook in AKO
+	Enabled bool `json:"enabled,omitempty"`
+	// Port is the port at which AKO serves the validating webhook, defaults to 9443
+	Port int `json:"port,omitempty"`
 }
 
 type NodeNetwork struct {
@@ -108,6 +155,8 @@ type L7Settings struct {
 	ShardVSSize VSSize `json:"shardVSSize,omitempty"`
 	// PassthroughShardSize specifies the number of shard VSs to be created for passthrough routes
 	PassthroughShardSize PassthroughVSSize `json:"passthroughShardSize,omitempty"`
+	// L7ShardingScheme specifies whether the hosts are placed on the shard VSs by hostname or by namespace
+	L7ShardingScheme L7ShardScheme `json:"l7ShardingScheme,omitempty"`
 	// SyncNamespace takes in a namespace from which AKO will sync the objects
 	SyncNamespace string `json:"syncNamespace,omitempty"`
 	// NoPGForSNI removes Avi PoolGroups from SNI VSes
@@ -128,6 +177,8 @@ type L4Settings struct {
 type ControllerSettings struct {
 	// ServiceEngineGroupName is the name of the Serviceengine group in Avi
 	ServiceEngineGroupName string `json:"serviceEngineGroupName,omitempty"`
+	// ServiceEngineZone is the zone in which the Service Engines are placed, used to prefer the pool servers in the same zone
+	ServiceEngineZone string `json:"serviceEngineZone,omitempty"`
 	// ControllerVersion is the Avi controller version
 	ControllerVersion string `json:"controllerVersion,omitempty"`
 	// CloudName is the name of the cloud to be used in Avi
@@ -187,15 +238,51 @@ type AKOConfigSpec struct {
 	PersistentVolumeClaim string `json:"pvc,omitempty"`
 	MountPath             string `json:"mountPath,omitempty"`
 	LogFile               string `json:"logFile,omitempty"`
+	// ReplicaCount is the number of AKO replicas. If more than one replica is run, leader election
+	// is enabled, and the replicas other than the leader run as hot standby.
+	ReplicaCount int `json:"replicaCount,omitempty"`
 }
 
+// Condition types reported in the AKOConfig status.
+const (
+	// ConditionReconciled is true when all the AKO artifacts were reconciled successfully.
+	ConditionReconciled = "Reconciled"
+	// ConditionAKOPodReady is true when all the AKO replicas are ready.
+	ConditionAKOPodReady = "AKOPodReady"
+	// ConditionControllerConnected is true when AKO is connected to the Avi controller.
+	ConditionControllerConnected = "ControllerConnected"
+	// ConditionSyncEnabled is true when AKO is syncing the Kubernetes objects to the Avi controller.
+	ConditionSyncEnabled = "SyncEnabled"
+	// ConditionDeleteConfigInProgress is true when deleteConfig is set and AKO is removing the Avi objects.
+	ConditionDeleteConfigInProgress = "DeleteConfigInProgress"
+)
+
 // AKOConfigStatus defines the observed state of AKOConfig
 type AKOConfigStatus struct {
 	State string `json:"state,omitempty"`
+	// ObservedGeneration is the generation of the AKOConfig last reconciled by the operator.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe the state of the AKO instance.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// LastError is the last error seen while reconciling the AKOConfig or reported by AKO.
+	LastError string `json:"lastError,omitempty"`
+	// AKOVersion is the version of the running AKO controller.
+	AKOVersion string `json:"akoVersion,omitempty"`
+	// ControllerVersion is the Avi controller version used by AKO.
+	ControllerVersion string `json:"controllerVersion,omitempty"`
+	// VirtualServices is the number of virtual services managed by AKO.
+	VirtualServices int `json:"virtualServices,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="AKOPodReady")].status`
+// +kubebuilder:printcolumn:name="Connected",type=string,JSONPath=`.status.conditions[?(@.type=="ControllerConnected")].status`
+// +kubebuilder:printcolumn:name="Sync",type=string,JSONPath=`.status.conditions[?(@.type=="SyncEnabled")].status`
+// +kubebuilder:printcolumn:name="AKO Version",type=string,JSONPath=`.status.akoVersion`
+// +kubebuilder:printcolumn:name="VSes",type=integer,JSONPath=`.status.virtualServices`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // AKOConfig is the Schema for the akoconfigs API
 type AKOConfig struct {
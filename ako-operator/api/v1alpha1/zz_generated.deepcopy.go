@@ -22,6 +22,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -31,7 +32,7 @@ func (in *AKOConfig) DeepCopyInto(out *AKOConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AKOConfig.
@@ -87,7 +88,7 @@ func (in *AKOConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AKOConfigSpec) DeepCopyInto(out *AKOConfigSpec) {
 	*out = *in
-	out.AKOSettings = in.AKOSettings
+	in.AKOSettings.DeepCopyInto(&out.AKOSettings)
 	in.NetworkSettings.DeepCopyInto(&out.NetworkSettings)
 	out.L7Settings = in.L7Settings
 	out.L4Settings = in.L4Settings
@@ -110,6 +111,13 @@ func (in *AKOConfigSpec) DeepCopy() *AKOConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AKOConfigStatus) DeepCopyInto(out *AKOConfigStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AKOConfigStatus.
@@ -126,6 +134,12 @@ func (in *AKOConfigStatus) DeepCopy() *AKOConfigStatus {
 func (in *AKOSettings) DeepCopyInto(out *AKOSettings) {
 	*out = *in
 	out.NSSelector = in.NSSelector
+	out.ValidatingWebhook = in.ValidatingWebhook
+	if in.PrimaryInstance != nil {
+		in, out := &in.PrimaryInstance, &out.PrimaryInstance
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AKOSettings.
@@ -327,6 +341,21 @@ func (in *Resources) DeepCopy() *Resources {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidatingWebhookSettings) DeepCopyInto(out *ValidatingWebhookSettings) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidatingWebhookSettings.
+func (in *ValidatingWebhookSettings) DeepCopy() *ValidatingWebhookSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidatingWebhookSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VipNetwork) DeepCopyInto(out *VipNetwork) {
 	*out = *in
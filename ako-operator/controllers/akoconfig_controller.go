@@ -22,17 +22,20 @@ import (
 	"sync"
 
 	logr "github.com/go-logr/logr"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/ako-operator/api/v1alpha1"
 )
@@ -41,9 +44,9 @@ const (
 	CleanupFinalizer = "ako.vmware.com/cleanup"
 )
 
-var (
-	rebootRequired = false
-)
+// rebootRequired is set per AKO instance namespace, when the AKO statefulset has to be restarted
+// for a configmap change to take effect.
+var rebootRequired = make(map[string]bool)
 
 // AKOConfigReconciler reconciles a AKOConfig object
 type AKOConfigReconciler struct {
@@ -53,15 +56,27 @@ type AKOConfigReconciler struct {
 	Scheme *runtime.Scheme
 }
 
-var objectList map[types.NamespacedName]client.Object
+// objectList holds the artifacts created for each AKO instance, keyed by the AKOConfig.
+var objectList map[types.NamespacedName]map[types.NamespacedName]client.Object
 
 var objListOnce sync.Once
 
-func getObjectList() map[types.NamespacedName]client.Object {
+func getObjectList(ako akov1alpha1.AKOConfig) map[types.NamespacedName]client.Object {
 	objListOnce.Do(func() {
-		objectList = make(map[types.NamespacedName]client.Object)
+		objectList = make(map[types.NamespacedName]map[types.NamespacedName]client.Object)
 	})
-	return objectList
+	akoKey := types.NamespacedName{Namespace: ako.GetNamespace(), Name: ako.GetName()}
+	if _, ok := objectList[akoKey]; !ok {
+		objectList[akoKey] = make(map[types.NamespacedName]client.Object)
+	}
+	return objectList[akoKey]
+}
+
+func deleteObjectList(ako akov1alpha1.AKOConfig) {
+	objListOnce.Do(func() {
+		objectList = make(map[types.NamespacedName]map[types.NamespacedName]client.Object)
+	})
+	delete(objectList, types.NamespacedName{Namespace: ako.GetNamespace(), Name: ako.GetName()})
 }
 
 func finalizerInList(finalizers []string, key string) bool {
@@ -96,6 +111,10 @@ func removeFinalizer(finalizers []string, key string) (result []string) {
 // +kubebuilder:rbac:groups=ako.vmware.com,resources=httprules;httprules/status;httprules/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ako.vmware.com,resources=hostrules;hostrules/status;hostrules/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=crd.projectcalico.org,resources=blockaffinities;blockaffinities/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=validatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="coordination.k8s.io",resources=leases,verbs=get;create;update
+// +kubebuilder:rbac:groups="discovery.k8s.io",resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods/status,verbs=update;patch
 // +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions;customresourcedefinitions/status;customresourcedefinitions/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="apps",resources=statefulsets;statefulsets/status;statefulsets/finalizers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=extensions,resources=ingresses; ingresses/status,verbs=get;list;watch;create;update;patch;delete
@@ -123,7 +142,7 @@ func (r *AKOConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	if !ako.GetDeletionTimestamp().IsZero() {
 		if finalizerInList(ako.GetFinalizers(), CleanupFinalizer) {
-			if err := r.CleanupArtifacts(ctx, log); err != nil {
+			if err := r.CleanupArtifacts(ctx, ako, log); err != nil {
 				return ctrl.Result{}, err
 			}
 
@@ -137,17 +156,32 @@ func (r *AKOConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	// reconcile all objects
-	err = r.ReconcileAllArtifacts(ctx, ako, log)
+	// an AKOConfig conflicting with the other AKO instances is not reconciled, it is retried
+	// periodically, as the conflict goes away when the other AKOConfig is removed or updated.
+	err = r.validateAKOInstance(ctx, ako)
+	if _, ok := err.(*instanceConflictError); ok {
+		log.Error(err, "AKOConfig conflicts with another AKO instance, won't reconcile")
+		if statusErr := r.updateStatus(ctx, ako, err, log); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{RequeueAfter: StatusSyncInterval}, nil
+	} else if err == nil {
+		// reconcile all objects
+		err = r.ReconcileAllArtifacts(ctx, ako, log)
+	}
+	if statusErr := r.updateStatus(ctx, ako, err, log); statusErr != nil && err == nil {
+		return ctrl.Result{}, statusErr
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// the status is kept in sync with the running AKO by refreshStatuses, without reconciling again
 	return ctrl.Result{}, nil
 }
 
 func (r *AKOConfigReconciler) ReconcileAllArtifacts(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger) error {
-	secretNamespacedName := types.NamespacedName{Namespace: AviSystemNS, Name: AviSecretName}
+	secretNamespacedName := types.NamespacedName{Namespace: getInstanceNamespace(ako), Name: AviSecretName}
 	var aviSecret v1.Secret
 	err := r.Get(ctx, secretNamespacedName, &aviSecret)
 	if err != nil {
@@ -183,6 +217,11 @@ func (r *AKOConfigReconciler) ReconcileAllArtifacts(ctx context.Context, ako ako
 		return err
 	}
 
+	err = createOrUpdateValidatingWebhook(ctx, ako, log, r)
+	if err != nil {
+		return err
+	}
+
 	err = createOrUpdateStatefulSet(ctx, ako, log, r, aviSecret)
 	if err != nil {
 		return err
@@ -196,54 +235,89 @@ func (r *AKOConfigReconciler) ReconcileAllArtifacts(ctx context.Context, ako ako
 	return nil
 }
 
-func (r *AKOConfigReconciler) CleanupArtifacts(ctx context.Context, log logr.Logger) error {
+func (r *AKOConfigReconciler) CleanupArtifacts(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger) error {
 	log.V(0).Info("cleaning up all the artifacts")
-	objList := getObjectList()
+	objList := getObjectList(ako)
 	if len(objList) == 0 {
 		// AKOConfig was deleted, but during the same time, the operator was restarted
 		var cm corev1.ConfigMap
-		if err := r.Get(ctx, getConfigMapName(), &cm); err != nil {
+		if err := r.Get(ctx, getConfigMapName(ako), &cm); err != nil {
 			log.V(0).Info("error getting configmap", "error", err)
 		} else {
-			objList[getConfigMapName()] = &cm
+			objList[getConfigMapName(ako)] = &cm
 		}
 		var sf appsv1.StatefulSet
-		if err := r.Get(ctx, getSFNamespacedName(), &sf); err != nil {
+		if err := r.Get(ctx, getSFNamespacedName(ako), &sf); err != nil {
 			log.V(0).Info("error getting statefulset", "error", err)
 		} else {
-			objList[getSFNamespacedName()] = &sf
+			objList[getSFNamespacedName(ako)] = &sf
 		}
 		var cr rbacv1.ClusterRole
-		if err := r.Get(ctx, getCRName(), &cr); err != nil {
+		if err := r.Get(ctx, getCRName(ako), &cr); err != nil {
 			log.V(0).Info("error getting clusterrole", "error", err)
 		} else {
-			objList[getCRName()] = &cr
+			objList[getCRName(ako)] = &cr
 		}
 		var crb rbacv1.ClusterRoleBinding
-		if err := r.Get(ctx, getCRBName(), &crb); err != nil {
+		if err := r.Get(ctx, getCRBName(ako), &crb); err != nil {
 			log.V(0).Info("error getting clusterrolebinding", "error", err)
 		} else {
-			objList[getCRBName()] = &crb
+			objList[getCRBName(ako)] = &crb
 		}
 		var sa v1.ServiceAccount
-		if err := r.Get(ctx, getSAName(), &sa); err != nil {
+		if err := r.Get(ctx, getSAName(ako), &sa); err != nil {
 			log.V(0).Info("error getting serviceaccount", "error", err)
 		} else {
-			objList[getSAName()] = &sa
+			objList[getSAName(ako)] = &sa
 		}
 		var psp policyv1beta1.PodSecurityPolicy
-		if err := r.Get(ctx, getPSPName(), &psp); err != nil {
+		if err := r.Get(ctx, getPSPName(ako), &psp); err != nil {
 			log.V(0).Info("error getting podsecuritypolicy", "error", err)
 		} else {
-			objList[getPSPName()] = &psp
+			objList[getPSPName(ako)] = &psp
+		}
+		var webhookSecret v1.Secret
+		if err := r.Get(ctx, getWebhookSecretName(ako), &webhookSecret); err == nil {
+			objList[getWebhookSecretName(ako)] = &webhookSecret
+		}
+		var webhookSvc v1.Service
+		if err := r.Get(ctx, getWebhookServiceName(ako), &webhookSvc); err == nil {
+			objList[getWebhookServiceName(ako)] = &webhookSvc
+		}
+		var vwc admissionregistrationv1.ValidatingWebhookConfiguration
+		if err := r.Get(ctx, getWebhookConfigName(ako), &vwc); err == nil {
+			objList[getWebhookConfigName(ako)] = &vwc
 		}
 	}
+	// the artifacts of a rejected AKOConfig share their names with the artifacts of the accepted AKOConfig
+	// in the same namespace, so only the objects controlled by the rejected AKOConfig are deleted
+	rejected := isInstanceConflict(ako)
 	for objName, obj := range objList {
+		if rejected {
+			if err := r.Get(ctx, objName, obj); err != nil || !metav1.IsControlledBy(obj, &ako) {
+				log.V(0).Info("AKOConfig was rejected, won't delete the object not controlled by it", "object", objName)
+				continue
+			}
+		}
 		if err := r.deleteIfExists(ctx, objName, obj); err != nil {
 			log.Error(err, "error while deleting object")
 			return err
 		}
 	}
+	deleteObjectList(ako)
+
+	// the CRDs are shared by all the AKO instances, so these are deleted along with the last instance
+	var akoConfigList akov1alpha1.AKOConfigList
+	if err := r.List(ctx, &akoConfigList); err != nil {
+		log.Error(err, "error while listing AKOConfigs")
+		return err
+	}
+	for _, akoConfig := range akoConfigList.Items {
+		if akoConfig.GetDeletionTimestamp().IsZero() {
+			log.V(0).Info("other AKO instances exist, won't delete the crds")
+			return nil
+		}
+	}
 	err := deleteCRDs(r.Config)
 	if err != nil {
 		log.Error(err, "error while deleting crds")
@@ -266,6 +340,9 @@ func (r *AKOConfigReconciler) deleteIfExists(ctx context.Context, objNsName type
 }
 
 func (r *AKOConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(manager.RunnableFunc(r.refreshStatuses)); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&akov1alpha1.AKOConfig{}).
 		Owns(&corev1.ConfigMap{}).
@@ -39,7 +39,7 @@ func SetIfRebootRequired(oldCm corev1.ConfigMap, newCm corev1.ConfigMap) {
 
 	if oldCksum != newCksum {
 		// reboot is required
-		rebootRequired = true
+		rebootRequired[newCm.GetNamespace()] = true
 	}
 }
 
@@ -48,7 +48,7 @@ func createOrUpdateConfigMap(ctx context.Context, ako akov1alpha1.AKOConfig, log
 
 	var oldCM corev1.ConfigMap
 
-	if err := r.Get(ctx, getConfigMapName(), &oldCM); err != nil {
+	if err := r.Get(ctx, getConfigMapName(ako), &oldCM); err != nil {
 		log.V(0).Info("error getting a configmap with name", "name", ConfigMapName, "err", err)
 	} else {
 		log.V(1).Info("old configmap", "old cm", oldCM)
@@ -93,13 +93,13 @@ func createOrUpdateConfigMap(ctx context.Context, ako akov1alpha1.AKOConfig, log
 	}
 
 	var newCM corev1.ConfigMap
-	err = r.Get(ctx, getConfigMapName(), &newCM)
+	err = r.Get(ctx, getConfigMapName(ako), &newCM)
 	if err != nil {
 		log.V(0).Info("error getting a configmap with name", "name", ConfigMapName, "err", err)
 		return err
 	}
 	// update this object in the global list
-	objList := getObjectList()
+	objList := getObjectList(ako)
 	objList[types.NamespacedName{
 		Name:      cm.GetName(),
 		Namespace: cm.GetNamespace(),
@@ -111,7 +111,7 @@ func createOrUpdateConfigMap(ctx context.Context, ako akov1alpha1.AKOConfig, log
 func BuildConfigMap(ako akov1alpha1.AKOConfig) (corev1.ConfigMap, error) {
 	cm := corev1.ConfigMap{ObjectMeta: v1.ObjectMeta{
 		Name:      ConfigMapName,
-		Namespace: AviSystemNS,
+		Namespace: getInstanceNamespace(ako),
 	}}
 
 	cm.Data = make(map[string]string)
@@ -145,6 +145,7 @@ func BuildConfigMap(ako akov1alpha1.AKOConfig) (corev1.ConfigMap, error) {
 
 	cm.Data[ShardVSSize] = string(ako.Spec.L7Settings.ShardVSSize)
 	cm.Data[PassthroughShardSize] = string(ako.Spec.L7Settings.PassthroughShardSize)
+	cm.Data[L7ShardingScheme] = string(ako.Spec.L7Settings.L7ShardingScheme)
 	fullSyncFreq := ako.Spec.AKOSettings.FullSyncFrequency
 	cm.Data[FullSyncFrequency] = fullSyncFreq
 	cm.Data[CloudName] = ako.Spec.ControllerSettings.CloudName
@@ -249,6 +250,56 @@ func BuildConfigMap(ako akov1alpha1.AKOConfig) (corev1.ConfigMap, error) {
 	cm.Data[TenantName] = ako.Spec.ControllerSettings.TenantName
 	cm.Data[AutoFQDN] = ako.Spec.L4Settings.AutoFQDN
 
+	enableWebhook := "false"
+	if ako.Spec.AKOSettings.ValidatingWebhook.Enabled {
+		enableWebhook = "true"
+	}
+	cm.Data[EnableWebhook] = enableWebhook
+	cm.Data[WebhookPort] = strconv.Itoa(getWebhookPort(ako))
+
+	enableLeaderElection := "false"
+	if getReplicaCount(ako) > 1 {
+		enableLeaderElection = "true"
+	}
+	cm.Data[EnableLeaderElection] = enableLeaderElection
+
+	dryRun := "false"
+	if ako.Spec.AKOSettings.DryRun {
+		dryRun = "true"
+	}
+	cm.Data[DryRun] = dryRun
+
+	enableEndpointSlice := "false"
+	if ako.Spec.AKOSettings.EnableEndpointSlice {
+		enableEndpointSlice = "true"
+	}
+	cm.Data[EnableEndpointSlice] = enableEndpointSlice
+	cm.Data[ServerDrainTimeout] = strconv.Itoa(ako.Spec.AKOSettings.ServerDrainTimeout)
+	enablePodReadinessGate := "false"
+	if ako.Spec.AKOSettings.EnablePodReadinessGate {
+		enablePodReadinessGate = "true"
+	}
+	cm.Data[EnablePodReadinessGate] = enablePodReadinessGate
+
+	enableProbeHealthMonitor := "false"
+	if ako.Spec.AKOSettings.EnableProbeHealthMonitor {
+		enableProbeHealthMonitor = "true"
+	}
+	cm.Data[EnableProbeHealthMonitor] = enableProbeHealthMonitor
+
+	enableRuntimeStatus := "false"
+	if ako.Spec.AKOSettings.EnableRuntimeStatus {
+		enableRuntimeStatus = "true"
+	}
+	cm.Data[EnableRuntimeStatus] = enableRuntimeStatus
+	cm.Data[DriftScanInterval] = strconv.Itoa(ako.Spec.AKOSettings.DriftScanInterval)
+	cm.Data[DriftPolicy] = string(ako.Spec.AKOSettings.DriftPolicy)
+	cm.Data[StandbyCacheRefreshInterval] = strconv.Itoa(ako.Spec.AKOSettings.StandbyCacheRefreshInterval)
+	cm.Data[RuntimeStatusSyncInterval] = strconv.Itoa(ako.Spec.AKOSettings.RuntimeStatusSyncInterval)
+	cm.Data[PodReadinessGateSyncInterval] = strconv.Itoa(ako.Spec.AKOSettings.PodReadinessGateSyncInterval)
+	cm.Data[ServiceEngineZone] = ako.Spec.ControllerSettings.ServiceEngineZone
+	cm.Data[PrimaryInstance] = strconv.FormatBool(isPrimaryInstance(ako))
+
 	return cm, nil
 }
 
@@ -89,10 +89,8 @@ func buildConfigMapAndVerify(existingCm corev1.ConfigMap, akoConfig akov1alpha1.
 
 	g.Expect(err).To(gomega.BeNil())
 	SetIfRebootRequired(newCm, existingCm)
-	g.Expect(rebootRequired).To(gomega.Equal(rebootRequiredValue))
-	if rebootRequired {
-		// reset the reboot required value
-		rebootRequired = false
-	}
+	g.Expect(rebootRequired[newCm.GetNamespace()]).To(gomega.Equal(rebootRequiredValue))
+	// reset the reboot required value
+	delete(rebootRequired, newCm.GetNamespace())
 	return newCm
 }
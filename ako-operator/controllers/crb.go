@@ -33,8 +33,8 @@ import (
 func createOrUpdateClusterroleBinding(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
 	var oldCRB rbacv1.ClusterRoleBinding
 
-	if err := r.Get(ctx, getCRBName(), &oldCRB); err != nil {
-		log.V(0).Info("no existing clusterrolebinding with name", "name", CRBName)
+	if err := r.Get(ctx, getCRBName(ako), &oldCRB); err != nil {
+		log.V(0).Info("no existing clusterrolebinding with name", "name", getCRBName(ako).Name)
 	} else {
 		if oldCRB.GetName() != "" {
 			log.V(0).Info("a clusterrolebinding with name already exists, will update", "name",
@@ -47,7 +47,7 @@ func createOrUpdateClusterroleBinding(ctx context.Context, ako akov1alpha1.AKOCo
 		if reflect.DeepEqual(oldCRB.Subjects, crb.Subjects) {
 			log.V(0).Info("no updates required for clusterrolebinding")
 			// add this object in the global list
-			objList := getObjectList()
+			objList := getObjectList(ako)
 			objList[types.NamespacedName{
 				Name: oldCRB.GetName(),
 			}] = &oldCRB
@@ -68,12 +68,12 @@ func createOrUpdateClusterroleBinding(ctx context.Context, ako akov1alpha1.AKOCo
 		}
 	}
 	var newCRB rbacv1.ClusterRoleBinding
-	err := r.Get(ctx, getCRBName(), &newCRB)
+	err := r.Get(ctx, getCRBName(ako), &newCRB)
 	if err != nil {
-		log.V(0).Info("error getting a clusterrole with name", "name", getCRName().Name, "err", err)
+		log.V(0).Info("error getting a clusterrole with name", "name", getCRName(ako).Name, "err", err)
 	}
 	// update this object in the global list
-	objList := getObjectList()
+	objList := getObjectList(ako)
 	objList[types.NamespacedName{
 		Name: newCRB.GetName(),
 	}] = &newCRB
@@ -84,19 +84,18 @@ func createOrUpdateClusterroleBinding(ctx context.Context, ako akov1alpha1.AKOCo
 func BuildClusterroleBinding(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, log logr.Logger) rbacv1.ClusterRoleBinding {
 	crb := rbacv1.ClusterRoleBinding{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      CRBName,
-			Namespace: AviSystemNS,
+			Name: getCRBName(ako).Name,
 		},
 		RoleRef: rbacv1.RoleRef{
 			APIGroup: "rbac.authorization.k8s.io",
 			Kind:     "ClusterRole",
-			Name:     AKOCR,
+			Name:     getCRName(ako).Name,
 		},
 		Subjects: []rbacv1.Subject{
 			{
 				Kind:      "ServiceAccount",
 				Name:      AKOServiceAccount,
-				Namespace: AviSystemNS,
+				Namespace: getInstanceNamespace(ako),
 			},
 		},
 	}
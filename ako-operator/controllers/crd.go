@@ -92,6 +92,14 @@ func createHostRuleCRD(clientset *apiextension.ApiextensionsV1Client, log logr.L
 										},
 										Type: "array",
 									},
+									"datascriptConfigMaps": {
+										Items: &apiextensionv1.JSONSchemaPropsOrArray{
+											Schema: &apiextensionv1.JSONSchemaProps{
+												Type: "string",
+											},
+										},
+										Type: "array",
+									},
 									"httpPolicy": {
 										Type: "object",
 										Properties: map[string]apiextensionv1.JSONSchemaProps{
@@ -106,6 +114,162 @@ func createHostRuleCRD(clientset *apiextension.ApiextensionsV1Client, log logr.L
 												},
 												Type: "array",
 											},
+											"rules": {
+												Type: "array",
+												Items: &apiextensionv1.JSONSchemaPropsOrArray{
+													Schema: &apiextensionv1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]apiextensionv1.JSONSchemaProps{
+															"name": {
+																Type: "string",
+															},
+															"path": {
+																Type: "string",
+															},
+															"redirect": {
+																Type: "object",
+																Properties: map[string]apiextensionv1.JSONSchemaProps{
+																	"protocol": {
+																		Type: "string",
+																		Enum: []apiextensionv1.JSON{
+																			{
+																				Raw: []byte("\"HTTP\""),
+																			},
+																			{
+																				Raw: []byte("\"HTTPS\""),
+																			},
+																		},
+																	},
+																	"host": {
+																		Type: "string",
+																	},
+																	"path": {
+																		Type: "string",
+																	},
+																	"port": {
+																		Type:    "integer",
+																		Minimum: proto.Float64(1),
+																		Maximum: proto.Float64(65535),
+																	},
+																	"statusCode": {
+																		Type: "integer",
+																		Enum: []apiextensionv1.JSON{
+																			{
+																				Raw: []byte("301"),
+																			},
+																			{
+																				Raw: []byte("302"),
+																			},
+																			{
+																				Raw: []byte("307"),
+																			},
+																		},
+																	},
+																},
+															},
+															"rewritePath": {
+																Type: "string",
+															},
+															"requestHeaders": {
+																Type: "array",
+																Items: &apiextensionv1.JSONSchemaPropsOrArray{
+																	Schema: &apiextensionv1.JSONSchemaProps{
+																		Type:     "object",
+																		Required: []string{"action", "name"},
+																		Properties: map[string]apiextensionv1.JSONSchemaProps{
+																			"action": {
+																				Type: "string",
+																				Enum: []apiextensionv1.JSON{
+																					{
+																						Raw: []byte("\"Add\""),
+																					},
+																					{
+																						Raw: []byte("\"Replace\""),
+																					},
+																					{
+																						Raw: []byte("\"Remove\""),
+																					},
+																				},
+																			},
+																			"name": {
+																				Type: "string",
+																			},
+																			"value": {
+																				Type: "string",
+																			},
+																		},
+																	},
+																},
+															},
+															"responseHeaders": {
+																Type: "array",
+																Items: &apiextensionv1.JSONSchemaPropsOrArray{
+																	Schema: &apiextensionv1.JSONSchemaProps{
+																		Type:     "object",
+																		Required: []string{"action", "name"},
+																		Properties: map[string]apiextensionv1.JSONSchemaProps{
+																			"action": {
+																				Type: "string",
+																				Enum: []apiextensionv1.JSON{
+																					{
+																						Raw: []byte("\"Add\""),
+																					},
+																					{
+																						Raw: []byte("\"Replace\""),
+																					},
+																					{
+																						Raw: []byte("\"Remove\""),
+																					},
+																				},
+																			},
+																			"name": {
+																				Type: "string",
+																			},
+																			"value": {
+																				Type: "string",
+																			},
+																		},
+																	},
+																},
+															},
+															"allowedClientIPs": {
+																Items: &apiextensionv1.JSONSchemaPropsOrArray{
+																	Schema: &apiextensionv1.JSONSchemaProps{
+																		Type: "string",
+																	},
+																},
+																Type: "array",
+															},
+															"deniedClientIPs": {
+																Items: &apiextensionv1.JSONSchemaPropsOrArray{
+																	Schema: &apiextensionv1.JSONSchemaProps{
+																		Type: "string",
+																	},
+																},
+																Type: "array",
+															},
+															"rateLimit": {
+																Type:     "object",
+																Required: []string{"count", "period"},
+																Properties: map[string]apiextensionv1.JSONSchemaProps{
+																	"count": {
+																		Type:    "integer",
+																		Minimum: proto.Float64(1),
+																	},
+																	"period": {
+																		Type:    "integer",
+																		Minimum: proto.Float64(1),
+																	},
+																	"burstSize": {
+																		Type:    "integer",
+																		Minimum: proto.Float64(0),
+																	},
+																},
+															},
+														},
+													},
+												},
+											},
 										},
 									},
 									"gslb": {
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 VMware, Inc.
+All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/ako-operator/api/v1alpha1"
+)
+
+// instanceConflictError is returned for an AKOConfig whose settings conflict with another AKO instance.
+type instanceConflictError struct {
+	msg string
+}
+
+func (e *instanceConflictError) Error() string {
+	return e.msg
+}
+
+// validateAKOInstance checks the AKOConfig against the other AKOConfigs in the cluster. The AKOConfigs
+// are accepted in the order of their creation, so an AKOConfig is rejected if it conflicts with an
+// older AKOConfig which was accepted. A non primary AKOConfig is also rejected until a primary
+// AKOConfig is accepted.
+func (r *AKOConfigReconciler) validateAKOInstance(ctx context.Context, ako akov1alpha1.AKOConfig) error {
+	var akoConfigList akov1alpha1.AKOConfigList
+	if err := r.List(ctx, &akoConfigList); err != nil {
+		return err
+	}
+	return validateAKOInstances(ako, akoConfigList.Items)
+}
+
+func validateAKOInstances(ako akov1alpha1.AKOConfig, akoConfigs []akov1alpha1.AKOConfig) error {
+	var instances []akov1alpha1.AKOConfig
+	for _, akoConfig := range akoConfigs {
+		if akoConfig.GetDeletionTimestamp().IsZero() && !isSameAKOConfig(akoConfig, ako) {
+			instances = append(instances, akoConfig)
+		}
+	}
+	instances = append(instances, ako)
+	sort.SliceStable(instances, func(i, j int) bool {
+		iTime, jTime := instances[i].GetCreationTimestamp(), instances[j].GetCreationTimestamp()
+		if !iTime.Equal(&jTime) {
+			return iTime.Before(&jTime)
+		}
+		if instances[i].GetNamespace() != instances[j].GetNamespace() {
+			return instances[i].GetNamespace() < instances[j].GetNamespace()
+		}
+		return instances[i].GetName() < instances[j].GetName()
+	})
+
+	var accepted []akov1alpha1.AKOConfig
+	var akoConflict error
+	for _, instance := range instances {
+		var conflict error
+		for _, acceptedInstance := range accepted {
+			if conflict = getInstanceConflict(instance, acceptedInstance); conflict != nil {
+				break
+			}
+		}
+		if isSameAKOConfig(instance, ako) {
+			akoConflict = conflict
+		}
+		if conflict == nil {
+			accepted = append(accepted, instance)
+		}
+	}
+	if akoConflict != nil || isPrimaryInstance(ako) {
+		return akoConflict
+	}
+	for _, instance := range accepted {
+		if isPrimaryInstance(instance) {
+			return nil
+		}
+	}
+	return &instanceConflictError{
+		msg: "no primary AKO instance exists, set primaryInstance to true for exactly one AKOConfig",
+	}
+}
+
+// getInstanceConflict returns an error if the AKO instances of the two AKOConfigs can't run together.
+func getInstanceConflict(ako, other akov1alpha1.AKOConfig) error {
+	otherName := other.GetNamespace() + "/" + other.GetName()
+	if getInstanceNamespace(ako) == getInstanceNamespace(other) {
+		return &instanceConflictError{
+			msg: fmt.Sprintf("AKOConfig %s already deploys AKO in namespace %s", otherName, getInstanceNamespace(ako)),
+		}
+	}
+	if isPrimaryInstance(ako) && isPrimaryInstance(other) {
+		return &instanceConflictError{
+			msg: fmt.Sprintf("AKOConfig %s is already the primary AKO instance, set primaryInstance to false", otherName),
+		}
+	}
+	if isNSSelectorOverlapping(ako.Spec.AKOSettings.NSSelector, other.Spec.AKOSettings.NSSelector) {
+		return &instanceConflictError{
+			msg: fmt.Sprintf("namespace selector overlaps with the namespace selector of AKOConfig %s", otherName),
+		}
+	}
+	return nil
+}
+
+// isNSSelectorOverlapping returns true if the two namespace selectors can select the same namespace. An
+// empty namespace selector selects all the namespaces, and a namespace can carry the labels of two selectors
+// with different label keys, so only the selectors with the same label key and different values are disjoint.
+func isNSSelectorOverlapping(a, b akov1alpha1.NamespaceSelector) bool {
+	if a.LabelKey == "" || b.LabelKey == "" {
+		return true
+	}
+	return a.LabelKey != b.LabelKey || a.LabelValue == b.LabelValue
+}
+
+// isInstanceConflict returns true if the AKOConfig was rejected for conflicting with another AKO instance.
+func isInstanceConflict(ako akov1alpha1.AKOConfig) bool {
+	reconciled := meta.FindStatusCondition(ako.Status.Conditions, akov1alpha1.ConditionReconciled)
+	return reconciled != nil && reconciled.Reason == reasonInstanceConflict
+}
+
+func isSameAKOConfig(a, b akov1alpha1.AKOConfig) bool {
+	return a.GetNamespace() == b.GetNamespace() && a.GetName() == b.GetName()
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2021 VMware, Inc.
+All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/ako-operator/api/v1alpha1"
+)
+
+func getTestAKOInstance(namespace, name string, primary bool, labelKey, labelValue string, age time.Duration) akov1alpha1.AKOConfig {
+	akoConfig := getTestDefaultAKOConfig()
+	akoConfig.ObjectMeta = v1.ObjectMeta{
+		Name:              name,
+		Namespace:         namespace,
+		CreationTimestamp: v1.NewTime(time.Now().Add(-age)),
+	}
+	akoConfig.Spec.AKOSettings.PrimaryInstance = &primary
+	akoConfig.Spec.AKOSettings.NSSelector = akov1alpha1.NamespaceSelector{LabelKey: labelKey, LabelValue: labelValue}
+	return akoConfig
+}
+
+func TestAKOInstanceNames(t *testing.T) {
+	// Test for:
+	// 1. Whether the AKO instance in avi-system keeps the default names
+	// 2. Whether the artifacts of the other instances are created in their namespace, with instance scoped names
+	g := gomega.NewGomegaWithT(t)
+
+	primary := getTestAKOInstance(AviSystemNS, "ako-primary", true, "app", "red", time.Hour)
+	g.Expect(getCRName(primary).Name).To(gomega.Equal(AKOCR))
+	g.Expect(getSFNamespacedName(primary).Namespace).To(gomega.Equal(AviSystemNS))
+
+	blue := getTestAKOInstance("blue", "ako-blue", false, "app", "blue", time.Minute)
+	g.Expect(getCRName(blue).Name).To(gomega.Equal(AKOCR + "-blue"))
+	g.Expect(getCRBName(blue).Name).To(gomega.Equal(CRBName + "-blue"))
+	g.Expect(getPSPName(blue).Name).To(gomega.Equal(PSPName + "-blue"))
+	g.Expect(getWebhookConfigName(blue).Name).To(gomega.Equal(WebhookConfigName + "-blue"))
+	g.Expect(getConfigMapName(blue).Namespace).To(gomega.Equal("blue"))
+	g.Expect(getSAName(blue).Namespace).To(gomega.Equal("blue"))
+
+	cm, err := BuildConfigMap(blue)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cm.Namespace).To(gomega.Equal("blue"))
+	g.Expect(cm.Data[PrimaryInstance]).To(gomega.Equal("false"))
+
+	sf, err := BuildStatefulSet(blue, corev1.Secret{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sf.Namespace).To(gomega.Equal("blue"))
+	envVars := getListOfEnvVars(sf.Spec.Template.Spec.Containers[0])
+	g.Expect(envVars["POD_NAMESPACE"].Value).To(gomega.Equal("blue"))
+	g.Expect(envVars["PRIMARY_AKO_FLAG"].ValueFrom.ConfigMapKeyRef.Key).To(gomega.Equal(PrimaryInstance))
+
+	blue.Spec.AKOSettings.ValidatingWebhook.Enabled = true
+	vwc := BuildValidatingWebhookConfiguration(blue, []byte("ca"))
+	g.Expect(vwc.Name).To(gomega.Equal(WebhookConfigName + "-blue"))
+	g.Expect(vwc.Webhooks[0].ClientConfig.Service.Namespace).To(gomega.Equal("blue"))
+	g.Expect(vwc.Webhooks[0].NamespaceSelector.MatchLabels).To(gomega.Equal(map[string]string{"app": "blue"}))
+}
+
+func TestAKOInstanceConflicts(t *testing.T) {
+	// Test for:
+	// 1. Whether AKO instances with disjoint namespace selectors and a single primary are accepted
+	// 2. Whether a second primary, an overlapping namespace selector or a second instance in a namespace is rejected
+	// 3. Whether a non primary instance is rejected when there is no primary instance
+	// 4. Whether the older AKOConfig is accepted in case of a conflict
+	g := gomega.NewGomegaWithT(t)
+
+	primary := getTestAKOInstance(AviSystemNS, "ako-primary", true, "app", "red", time.Hour)
+	blue := getTestAKOInstance("blue", "ako-blue", false, "app", "blue", time.Minute)
+	instances := []akov1alpha1.AKOConfig{primary, blue}
+	g.Expect(validateAKOInstances(primary, instances)).To(gomega.BeNil())
+	g.Expect(validateAKOInstances(blue, instances)).To(gomega.BeNil())
+
+	t.Log("verifying that a second primary instance is rejected")
+	green := getTestAKOInstance("green", "ako-green", true, "app", "green", time.Second)
+	err := validateAKOInstances(green, append(instances, green))
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("primary"))
+	g.Expect(validateAKOInstances(primary, append(instances, green))).To(gomega.BeNil())
+
+	t.Log("verifying that an overlapping namespace selector is rejected")
+	green = getTestAKOInstance("green", "ako-green", false, "app", "blue", time.Second)
+	err = validateAKOInstances(green, append(instances, green))
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("blue/ako-blue"))
+	green = getTestAKOInstance("green", "ako-green", false, "", "", time.Second)
+	g.Expect(validateAKOInstances(green, append(instances, green))).NotTo(gomega.BeNil())
+	green = getTestAKOInstance("green", "ako-green", false, "env", "green", time.Second)
+	g.Expect(validateAKOInstances(green, append(instances, green))).NotTo(gomega.BeNil())
+
+	t.Log("verifying that a second instance in the same namespace is rejected")
+	blue2 := getTestAKOInstance("blue", "ako-blue2", false, "app", "purple", time.Second)
+	err = validateAKOInstances(blue2, append(instances, blue2))
+	g.Expect(err).NotTo(gomega.BeNil())
+	_, ok := err.(*instanceConflictError)
+	g.Expect(ok).To(gomega.BeTrue())
+
+	t.Log("verifying that a rejected instance does not block the newer instances")
+	green = getTestAKOInstance("green", "ako-green", false, "app", "green", 0)
+	g.Expect(validateAKOInstances(green, append(instances, blue2, green))).To(gomega.BeNil())
+
+	t.Log("verifying that a non primary instance is rejected without a primary instance")
+	err = validateAKOInstances(blue, []akov1alpha1.AKOConfig{blue})
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("no primary"))
+	g.Expect(validateAKOInstances(blue, []akov1alpha1.AKOConfig{blue, primary})).To(gomega.BeNil())
+
+	t.Log("verifying that the instances being deleted are ignored")
+	now := v1.Now()
+	blue.DeletionTimestamp = &now
+	green = getTestAKOInstance("green", "ako-green", false, "app", "blue", time.Second)
+	g.Expect(validateAKOInstances(green, []akov1alpha1.AKOConfig{primary, blue, green})).To(gomega.BeNil())
+}
+
+// testClient is an in-memory client, which serves only the calls made while cleaning up the artifacts.
+type testClient struct {
+	client.Client
+	objects    map[string]client.Object
+	akoConfigs []akov1alpha1.AKOConfig
+}
+
+func getTestObjectKey(obj client.Object, key types.NamespacedName) string {
+	return fmt.Sprintf("%T/%s", obj, key)
+}
+
+func (c *testClient) add(obj client.Object) {
+	c.objects[getTestObjectKey(obj, client.ObjectKeyFromObject(obj))] = obj
+}
+
+func (c *testClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	stored, ok := c.objects[getTestObjectKey(obj, key)]
+	if !ok {
+		return errors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(stored.DeepCopyObject()).Elem())
+	return nil
+}
+
+func (c *testClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	delete(c.objects, getTestObjectKey(obj, client.ObjectKeyFromObject(obj)))
+	return nil
+}
+
+func (c *testClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	list.(*akov1alpha1.AKOConfigList).Items = c.akoConfigs
+	return nil
+}
+
+func TestRejectedAKOInstanceCleanup(t *testing.T) {
+	// Test for:
+	// 1. Whether deleting a rejected AKOConfig keeps the artifacts of the accepted AKOConfig in the same namespace
+	// 2. Whether deleting the accepted AKOConfig deletes its artifacts
+	g := gomega.NewGomegaWithT(t)
+
+	primary := getTestAKOInstance(AviSystemNS, "ako-primary", true, "app", "red", time.Hour)
+	blue := getTestAKOInstance("blue", "ako-blue", false, "app", "blue", time.Minute)
+	blue.UID = "ako-blue-uid"
+	blue2 := getTestAKOInstance("blue", "ako-blue2", false, "app", "purple", time.Second)
+	blue2.UID = "ako-blue2-uid"
+	now := v1.Now()
+	blue2.DeletionTimestamp = &now
+	buildStatus(&blue2.Status, blue2.Generation, validateAKOInstances(blue2, []akov1alpha1.AKOConfig{primary, blue, blue2}),
+		nil, nil, nil)
+	g.Expect(isInstanceConflict(blue2)).To(gomega.BeTrue())
+
+	ownerRefs := []v1.OwnerReference{*v1.NewControllerRef(&blue, akov1alpha1.GroupVersion.WithKind("AKOConfig"))}
+	c := &testClient{
+		objects:    make(map[string]client.Object),
+		akoConfigs: []akov1alpha1.AKOConfig{primary, blue, blue2},
+	}
+	cmName, sfName, saName, crName := getConfigMapName(blue), getSFNamespacedName(blue), getSAName(blue), getCRName(blue)
+	c.add(&corev1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: cmName.Name, Namespace: cmName.Namespace, OwnerReferences: ownerRefs}})
+	c.add(&appsv1.StatefulSet{ObjectMeta: v1.ObjectMeta{Name: sfName.Name, Namespace: sfName.Namespace, OwnerReferences: ownerRefs}})
+	c.add(&corev1.ServiceAccount{ObjectMeta: v1.ObjectMeta{Name: saName.Name, Namespace: saName.Namespace, OwnerReferences: ownerRefs}})
+	c.add(&rbacv1.ClusterRole{ObjectMeta: v1.ObjectMeta{Name: crName.Name}})
+	r := &AKOConfigReconciler{Client: c}
+
+	t.Log("deleting the rejected AKOConfig and verifying that the artifacts of the accepted AKOConfig are retained")
+	g.Expect(r.CleanupArtifacts(context.TODO(), blue2, logr.Discard())).To(gomega.BeNil())
+	g.Expect(c.objects).To(gomega.HaveLen(4))
+
+	t.Log("deleting the accepted AKOConfig and verifying that its artifacts are deleted")
+	blue.DeletionTimestamp = &now
+	c.akoConfigs = []akov1alpha1.AKOConfig{primary, blue}
+	g.Expect(r.CleanupArtifacts(context.TODO(), blue, logr.Discard())).To(gomega.BeNil())
+	g.Expect(c.objects).To(gomega.BeEmpty())
+}
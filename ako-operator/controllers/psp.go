@@ -31,8 +31,8 @@ import (
 func createOrUpdatePodSecurityPolicy(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
 	var oldPSP policyv1beta1.PodSecurityPolicy
 
-	if err := r.Get(ctx, getPSPName(), &oldPSP); err != nil {
-		log.V(0).Info("no pre-existing podsecuritypolicy with name", "name", PSPName)
+	if err := r.Get(ctx, getPSPName(ako), &oldPSP); err != nil {
+		log.V(0).Info("no pre-existing podsecuritypolicy with name", "name", getPSPName(ako).Name)
 	} else {
 		if oldPSP.GetName() != "" {
 			log.V(0).Info("pre-existing podsecuritypolicy, will be updated", "name", oldPSP.GetName())
@@ -41,12 +41,12 @@ func createOrUpdatePodSecurityPolicy(ctx context.Context, ako akov1alpha1.AKOCon
 
 	if !ako.Spec.Rbac.PSPEnable {
 		// PSP not required anymore, delete any existing psp
-		objList := getObjectList()
-		pspObj, ok := objList[getPSPName()]
+		objList := getObjectList(ako)
+		pspObj, ok := objList[getPSPName(ako)]
 		if !ok {
 			return nil
 		}
-		r.deleteIfExists(ctx, getPSPName(), pspObj)
+		r.deleteIfExists(ctx, getPSPName(ako), pspObj)
 		return nil
 	}
 
@@ -55,7 +55,7 @@ func createOrUpdatePodSecurityPolicy(ctx context.Context, ako akov1alpha1.AKOCon
 		if reflect.DeepEqual(oldPSP.Spec, psp.Spec) {
 			log.V(0).Info("no updates required for podsecuritypolicy")
 			// add this object in the global list
-			objList := getObjectList()
+			objList := getObjectList(ako)
 			objList[types.NamespacedName{
 				Name: oldPSP.GetName(),
 			}] = &oldPSP
@@ -77,12 +77,12 @@ func createOrUpdatePodSecurityPolicy(ctx context.Context, ako akov1alpha1.AKOCon
 	}
 
 	var newPSP policyv1beta1.PodSecurityPolicy
-	err := r.Get(ctx, getPSPName(), &newPSP)
+	err := r.Get(ctx, getPSPName(ako), &newPSP)
 	if err != nil {
-		log.V(0).Info("error getting a clusterrole with name", "name", getCRName().Name, "err", err)
+		log.V(0).Info("error getting a clusterrole with name", "name", getCRName(ako).Name, "err", err)
 	}
 	// update this object in the global list
-	objList := getObjectList()
+	objList := getObjectList(ako)
 	objList[types.NamespacedName{
 		Name: newPSP.GetName(),
 	}] = &newPSP
@@ -95,7 +95,7 @@ func BuildPodSecurityPolicy(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, l
 	// conditionally add the api version
 	psp := policyv1beta1.PodSecurityPolicy{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: PSPName,
+			Name: getPSPName(ako).Name,
 		},
 		Spec: policyv1beta1.PodSecurityPolicySpec{
 			Privileged:               false,
@@ -31,8 +31,8 @@ import (
 func createOrUpdateClusterRole(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
 	var oldCR rbacv1.ClusterRole
 
-	if err := r.Get(ctx, getCRName(), &oldCR); err != nil {
-		log.V(0).Info("no existing clusterrole with name", "name", getCRName())
+	if err := r.Get(ctx, getCRName(ako), &oldCR); err != nil {
+		log.V(0).Info("no existing clusterrole with name", "name", getCRName(ako))
 	} else {
 		if oldCR.GetName() != "" {
 			log.V(0).Info("a clusterrole with name already exists, it will be updated", "name",
@@ -45,7 +45,7 @@ func createOrUpdateClusterRole(ctx context.Context, ako akov1alpha1.AKOConfig, l
 		if reflect.DeepEqual(oldCR.Rules, cr.Rules) {
 			log.V(0).Info("no updates required for clusterrole")
 			// add this object in the global list
-			objList := getObjectList()
+			objList := getObjectList(ako)
 			objList[types.NamespacedName{
 				Name: oldCR.GetName(),
 			}] = &oldCR
@@ -67,13 +67,13 @@ func createOrUpdateClusterRole(ctx context.Context, ako akov1alpha1.AKOConfig, l
 	}
 
 	var newCR rbacv1.ClusterRole
-	err := r.Get(ctx, getCRName(), &newCR)
+	err := r.Get(ctx, getCRName(ako), &newCR)
 	if err != nil {
-		log.V(0).Info("error getting a clusterrole with name", "name", getCRName().Name, "err", err)
+		log.V(0).Info("error getting a clusterrole with name", "name", getCRName(ako).Name, "err", err)
 		return err
 	}
 	// update this object in the global list
-	objList := getObjectList()
+	objList := getObjectList(ako)
 	objList[types.NamespacedName{
 		Name: newCR.GetName(),
 	}] = &newCR
@@ -84,7 +84,7 @@ func createOrUpdateClusterRole(ctx context.Context, ako akov1alpha1.AKOConfig, l
 func BuildClusterrole(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, log logr.Logger) rbacv1.ClusterRole {
 	cr := rbacv1.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: AKOCR,
+			Name: getCRName(ako).Name,
 		},
 		Rules: []rbacv1.PolicyRule{
 			{
@@ -117,6 +117,21 @@ func BuildClusterrole(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, log log
 				Resources: []string{"events"},
 				Verbs:     []string{"create", "patch", "update"},
 			},
+			{
+				APIGroups: []string{"coordination.k8s.io"},
+				Resources: []string{"leases"},
+				Verbs:     []string{"get", "create", "update"},
+			},
+			{
+				APIGroups: []string{"discovery.k8s.io"},
+				Resources: []string{"endpointslices"},
+				Verbs:     []string{"get", "watch", "list"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods/status"},
+				Verbs:     []string{"patch", "update"},
+			},
 			{
 				APIGroups: []string{"crd.projectcalico.org"},
 				Resources: []string{"blockaffinities"},
@@ -139,7 +154,7 @@ func BuildClusterrole(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, log log
 			},
 			{
 				APIGroups: []string{"networking.x-k8s.io"},
-				Resources: []string{"gateways", "gateways/status", "gatewayclasses", "gatewayclasses/status"},
+				Resources: []string{"gateways", "gateways/status", "gatewayclasses", "gatewayclasses/status", "httproutes", "httproutes/status", "tcproutes", "tcproutes/status"},
 				Verbs:     []string{"get", "watch", "list", "patch", "update"},
 			},
 		},
@@ -150,7 +165,7 @@ func BuildClusterrole(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, log log
 			APIGroups:     []string{"policy", "extensions"},
 			Resources:     []string{"podsecuritypolicies"},
 			Verbs:         []string{"use"},
-			ResourceNames: []string{"ako"},
+			ResourceNames: []string{getPSPName(ako).Name},
 		})
 	}
 
@@ -32,14 +32,14 @@ import (
 func createOrUpdateServiceAccount(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
 	var oldSA v1.ServiceAccount
 
-	if err := r.Get(ctx, getSAName(), &oldSA); err != nil {
+	if err := r.Get(ctx, getSAName(ako), &oldSA); err != nil {
 		log.V(0).Info("no existing serviceaccount with name", "name", AKOServiceAccount)
 	} else {
 		if oldSA.GetName() != "" {
 			log.V(0).Info("a serviceaccount with name already exists, won't update", "name",
 				oldSA.GetName())
 			// add this object in the global list
-			objList := getObjectList()
+			objList := getObjectList(ako)
 			objList[types.NamespacedName{
 				Name:      oldSA.GetName(),
 				Namespace: oldSA.GetNamespace(),
@@ -57,12 +57,12 @@ func createOrUpdateServiceAccount(ctx context.Context, ako akov1alpha1.AKOConfig
 	}
 
 	var newSA v1.ServiceAccount
-	err = r.Get(ctx, getSAName(), &newSA)
+	err = r.Get(ctx, getSAName(ako), &newSA)
 	if err != nil {
-		log.V(0).Info("error getting a clusterrole with name", "name", getCRName().Name, "err", err)
+		log.V(0).Info("error getting a clusterrole with name", "name", getCRName(ako).Name, "err", err)
 	}
 	// update this object in the global list
-	objList := getObjectList()
+	objList := getObjectList(ako)
 	objList[types.NamespacedName{
 		Name:      newSA.GetName(),
 		Namespace: newSA.GetNamespace(),
@@ -76,7 +76,7 @@ func BuildServiceAccount(ako akov1alpha1.AKOConfig, r *AKOConfigReconciler, log
 	sa := v1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      AKOServiceAccount,
-			Namespace: AviSystemNS,
+			Namespace: getInstanceNamespace(ako),
 		},
 	}
 
@@ -26,7 +26,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -38,7 +37,7 @@ func createOrUpdateStatefulSet(ctx context.Context, ako akov1alpha1.AKOConfig, l
 
 	var oldSf appsv1.StatefulSet
 
-	if err := r.Get(ctx, getSFNamespacedName(), &oldSf); err != nil {
+	if err := r.Get(ctx, getSFNamespacedName(ako), &oldSf); err != nil {
 		log.V(0).Info("no pre-existing statefulset with name", "name", StatefulSetName)
 	} else {
 		if oldSf.GetName() != "" {
@@ -46,7 +45,7 @@ func createOrUpdateStatefulSet(ctx context.Context, ako akov1alpha1.AKOConfig, l
 		}
 	}
 
-	if oldSf.GetName() != "" && rebootRequired {
+	if oldSf.GetName() != "" && rebootRequired[getInstanceNamespace(ako)] {
 		log.V(0).Info("rebooting AKO as configmap has been changed")
 		err := r.Client.Delete(ctx, &oldSf)
 		if err != nil {
@@ -56,7 +55,7 @@ func createOrUpdateStatefulSet(ctx context.Context, ako akov1alpha1.AKOConfig, l
 				"namespace", oldSf.GetNamespace())
 			return err
 		}
-		rebootRequired = false
+		delete(rebootRequired, getInstanceNamespace(ako))
 		oldSf = appsv1.StatefulSet{}
 	}
 
@@ -66,7 +65,7 @@ func createOrUpdateStatefulSet(ctx context.Context, ako akov1alpha1.AKOConfig, l
 		return nil
 	}
 	var cm corev1.ConfigMap
-	if err := r.Get(context.TODO(), types.NamespacedName{Name: ConfigMapName, Namespace: AviSystemNS}, &cm); err != nil {
+	if err := r.Get(context.TODO(), getConfigMapName(ako), &cm); err != nil {
 		log.V(0).Info("error getting a configmap", "err", err)
 	}
 
@@ -75,7 +74,7 @@ func createOrUpdateStatefulSet(ctx context.Context, ako akov1alpha1.AKOConfig, l
 		log.Error(err, "error in setting controller reference to statefulset, statefulset changes would be ignored")
 	}
 
-	if oldSf.GetName() != "" && !rebootRequired {
+	if oldSf.GetName() != "" && !rebootRequired[getInstanceNamespace(ako)] {
 		if !isSfUpdateRequired(oldSf, sf) {
 			log.V(0).Info("no updates required to the statefulset")
 			return nil
@@ -96,14 +95,14 @@ func createOrUpdateStatefulSet(ctx context.Context, ako akov1alpha1.AKOConfig, l
 	}
 
 	var newSf appsv1.StatefulSet
-	err = r.Get(ctx, getSFNamespacedName(), &newSf)
+	err = r.Get(ctx, getSFNamespacedName(ako), &newSf)
 	if err != nil {
 		log.V(0).Info("error getting a statefulset with name", "name", StatefulSetName, "err", err)
 		return err
 	}
 	// update this object in the global list
-	objList := getObjectList()
-	objList[getSFNamespacedName()] = &newSf
+	objList := getObjectList(ako)
+	objList[getSFNamespacedName(ako)] = &newSf
 	log.V(0).Info("statefulset created/updated", "resource version", newSf.GetResourceVersion())
 	return nil
 }
@@ -161,7 +160,7 @@ func BuildStatefulSet(ako akov1alpha1.AKOConfig, aviSecret corev1.Secret) (appsv
 
 	sf.ObjectMeta = metav1.ObjectMeta{
 		Name:      StatefulSetName,
-		Namespace: AviSystemNS,
+		Namespace: getInstanceNamespace(ako),
 	}
 
 	image := ako.Spec.ImageRepository
@@ -169,7 +168,7 @@ func BuildStatefulSet(ako akov1alpha1.AKOConfig, aviSecret corev1.Secret) (appsv
 	if err != nil {
 		return sf, err
 	}
-	var replicas int32 = 1
+	replicas := getReplicaCount(ako)
 	sf.Spec.Replicas = &replicas
 	sf.Spec.ServiceName = ServiceName
 	akoLabels := map[string]string{
@@ -199,6 +198,22 @@ func BuildStatefulSet(ako akov1alpha1.AKOConfig, aviSecret corev1.Secret) (appsv
 		})
 	}
 
+	if ako.Spec.AKOSettings.ValidatingWebhook.Enabled {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "ako-webhook-certs",
+			MountPath: webhookCertMountPath,
+			ReadOnly:  true,
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "ako-webhook-certs",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: WebhookSecretName,
+				},
+			},
+		})
+	}
+
 	apiServerPort := ako.Spec.APIServerPort
 	if apiServerPort == 0 {
 		apiServerPort = 8080
@@ -371,3 +371,29 @@ func buildStatefulSetAndVerify(existingSf appsv1.StatefulSet, akoConfig akov1alp
 	g.Expect(isSfUpdateRequired(existingSf, newSf)).To(gomega.Equal(update))
 	return newSf
 }
+
+func TestStatefulSetReplicaCount(t *testing.T) {
+	// Test for:
+	// 1. Whether the statefulset is built with the replica count in akoConfig, and leader election is enabled for multiple replicas
+	// 2. Whether a change in the replica count requires an update to the statefulset
+	g := gomega.NewGomegaWithT(t)
+	akoConfig := getTestDefaultAKOConfig()
+
+	sf, err := BuildStatefulSet(akoConfig, v1.Secret{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(*sf.Spec.Replicas).To(gomega.Equal(int32(1)))
+	cm, err := BuildConfigMap(akoConfig)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cm.Data[EnableLeaderElection]).To(gomega.Equal("false"))
+
+	akoConfig.Spec.ReplicaCount = 3
+	newSf, err := BuildStatefulSet(akoConfig, v1.Secret{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(*newSf.Spec.Replicas).To(gomega.Equal(int32(3)))
+	cm, err = BuildConfigMap(akoConfig)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cm.Data[EnableLeaderElection]).To(gomega.Equal("true"))
+
+	g.Expect(isSfUpdateRequired(sf, newSf)).To(gomega.BeTrue())
+	g.Expect(isSfUpdateRequired(newSf, newSf)).To(gomega.BeFalse())
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2021 VMware, Inc.
+All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	logr "github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/ako-operator/api/v1alpha1"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+// StatusSyncInterval is the interval at which the status of the AKOConfig is refreshed from AKO.
+const StatusSyncInterval = 1 * time.Minute
+
+const (
+	defaultAPIServerPort = 8080
+	akoStatusTimeout     = 5 * time.Second
+)
+
+// AKOConfig states, summarizing the conditions.
+const (
+	StateFailed         = "Failed"
+	StateConflict       = "Conflict"
+	StatePending        = "Pending"
+	StateDisconnected   = "Disconnected"
+	StateDeletingConfig = "DeletingConfig"
+	StateSyncDisabled   = "SyncDisabled"
+	StateRunning        = "Running"
+)
+
+// reasons for the AKOConfig conditions
+const (
+	reasonReconcileSucceeded  = "ReconcileSucceeded"
+	reasonReconcileFailed     = "ReconcileFailed"
+	reasonInstanceConflict    = "InstanceConflict"
+	reasonPodsReady           = "PodsReady"
+	reasonPodsNotReady        = "PodsNotReady"
+	reasonStatefulSetNotFound = "StatefulSetNotFound"
+	reasonStatusUnavailable   = "StatusUnavailable"
+	reasonConnected           = "Connected"
+	reasonInitiating          = "Initiating"
+	reasonDisconnected        = "Disconnected"
+	reasonSyncEnabled         = "SyncEnabled"
+	reasonSyncDisabled        = "SyncDisabled"
+	reasonDeleteConfigSet     = "DeleteConfigSet"
+	reasonDeleteConfigUnset   = "DeleteConfigNotSet"
+)
+
+var akoStatusClient = &http.Client{Timeout: akoStatusTimeout}
+
+// fetchAKOStatusFunc fetches the status served by AKO at /api/status, it is overridden in the unit tests.
+var fetchAKOStatusFunc = fetchAKOStatus
+
+// updateStatus sets the Reconciled condition from the result of the reconciliation, and refreshes the other
+// conditions from the AKO statefulset and the running AKO.
+func (r *AKOConfigReconciler) updateStatus(ctx context.Context, ako akov1alpha1.AKOConfig, reconcileErr error,
+	log logr.Logger) error {
+	status := ako.Status.DeepCopy()
+	setReconciledCondition(status, ako.Generation, reconcileErr)
+	return r.syncStatus(ctx, ako, status, log)
+}
+
+// refreshStatus refreshes the conditions of the AKOConfig from the AKO statefulset and the running AKO,
+// retaining the Reconciled condition of the last reconciliation.
+func (r *AKOConfigReconciler) refreshStatus(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger) error {
+	return r.syncStatus(ctx, ako, ako.Status.DeepCopy(), log)
+}
+
+func (r *AKOConfigReconciler) syncStatus(ctx context.Context, ako akov1alpha1.AKOConfig, status *akov1alpha1.AKOConfigStatus,
+	log logr.Logger) error {
+	var sf *appsv1.StatefulSet
+	var oldSf appsv1.StatefulSet
+	if err := r.Get(ctx, getSFNamespacedName(ako), &oldSf); err == nil {
+		sf = &oldSf
+	}
+
+	var akoStatus *models.StatusModel
+	var statusErr error
+	if sf != nil && sf.Status.ReadyReplicas > 0 {
+		var podList corev1.PodList
+		if statusErr = r.List(ctx, &podList, client.InNamespace(getInstanceNamespace(ako)),
+			client.MatchingLabels(sf.Spec.Selector.MatchLabels)); statusErr == nil {
+			akoStatus, statusErr = getAKOStatus(podList.Items, getAPIServerPort(ako))
+		}
+		if statusErr != nil {
+			log.V(1).Info("unable to fetch AKO status", "err", statusErr)
+		}
+	}
+
+	buildAKOStatus(status, sf, akoStatus, statusErr)
+	if equality.Semantic.DeepEqual(*status, ako.Status) {
+		return nil
+	}
+	ako.Status = *status
+	if err := r.Status().Update(ctx, &ako); err != nil {
+		log.Error(err, "unable to update the AKOConfig status")
+		return err
+	}
+	log.V(0).Info("AKOConfig status updated", "state", status.State)
+	return nil
+}
+
+// refreshStatuses refreshes the status of the reconciled AKOConfigs every StatusSyncInterval, to keep it in sync
+// with the running AKO, without reconciling the AKO artifacts again.
+func (r *AKOConfigReconciler) refreshStatuses(ctx context.Context) error {
+	ticker := time.NewTicker(StatusSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		var akoList akov1alpha1.AKOConfigList
+		if err := r.List(ctx, &akoList); err != nil {
+			r.Log.Error(err, "unable to list the AKOConfigs for the status refresh")
+			continue
+		}
+		for _, ako := range akoList.Items {
+			// the conflicting AKOConfigs are requeued by the reconciler, and the status of an AKOConfig
+			// is refreshed only after it is reconciled once.
+			if !ako.GetDeletionTimestamp().IsZero() || isInstanceConflict(ako) ||
+				meta.FindStatusCondition(ako.Status.Conditions, akov1alpha1.ConditionReconciled) == nil {
+				continue
+			}
+			log := r.Log.WithValues("ako-operator", types.NamespacedName{Namespace: ako.Namespace, Name: ako.Name})
+			r.refreshStatus(ctx, ako, log)
+		}
+	}
+}
+
+// buildStatus sets the conditions and the AKO details in the status, from the result of the reconciliation,
+// the AKO statefulset and the status fetched from the running AKO.
+func buildStatus(status *akov1alpha1.AKOConfigStatus, generation int64, reconcileErr error,
+	sf *appsv1.StatefulSet, akoStatus *models.StatusModel, statusErr error) {
+	setReconciledCondition(status, generation, reconcileErr)
+	buildAKOStatus(status, sf, akoStatus, statusErr)
+}
+
+func setReconciledCondition(status *akov1alpha1.AKOConfigStatus, generation int64, reconcileErr error) {
+	status.ObservedGeneration = generation
+	if reconcileErr != nil {
+		reason := reasonReconcileFailed
+		if _, ok := reconcileErr.(*instanceConflictError); ok {
+			reason = reasonInstanceConflict
+		}
+		setCondition(status, akov1alpha1.ConditionReconciled, metav1.ConditionFalse, reason,
+			reconcileErr.Error(), generation)
+	} else {
+		setCondition(status, akov1alpha1.ConditionReconciled, metav1.ConditionTrue, reasonReconcileSucceeded,
+			"all AKO artifacts are reconciled", generation)
+	}
+}
+
+// buildAKOStatus sets the conditions other than Reconciled and the AKO details in the status, from the AKO
+// statefulset and the status fetched from the running AKO. The error of a failed reconciliation takes precedence
+// over the AKO errors in the last error.
+func buildAKOStatus(status *akov1alpha1.AKOConfigStatus, sf *appsv1.StatefulSet, akoStatus *models.StatusModel,
+	statusErr error) {
+	generation := status.ObservedGeneration
+	status.LastError = ""
+	if reconciled := meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionReconciled); reconciled != nil &&
+		reconciled.Status == metav1.ConditionFalse {
+		status.LastError = reconciled.Message
+	}
+
+	if sf == nil {
+		setCondition(status, akov1alpha1.ConditionAKOPodReady, metav1.ConditionFalse, reasonStatefulSetNotFound,
+			"AKO statefulset not found", generation)
+	} else {
+		replicas := int32(1)
+		if sf.Spec.Replicas != nil {
+			replicas = *sf.Spec.Replicas
+		}
+		message := fmt.Sprintf("%d/%d AKO replicas ready", sf.Status.ReadyReplicas, replicas)
+		if sf.Status.ReadyReplicas >= replicas {
+			setCondition(status, akov1alpha1.ConditionAKOPodReady, metav1.ConditionTrue, reasonPodsReady, message, generation)
+		} else {
+			setCondition(status, akov1alpha1.ConditionAKOPodReady, metav1.ConditionFalse, reasonPodsNotReady, message, generation)
+		}
+	}
+
+	if akoStatus == nil || akoStatus.AKO == nil {
+		message := "AKO status is not available"
+		if statusErr != nil {
+			message = statusErr.Error()
+		}
+		for _, condType := range []string{akov1alpha1.ConditionControllerConnected, akov1alpha1.ConditionSyncEnabled,
+			akov1alpha1.ConditionDeleteConfigInProgress} {
+			setCondition(status, condType, metav1.ConditionUnknown, reasonStatusUnavailable, message, generation)
+		}
+	} else {
+		setAKOConditions(status, akoStatus, generation)
+	}
+
+	status.State = getState(status)
+}
+
+func setAKOConditions(status *akov1alpha1.AKOConfigStatus, akoStatus *models.StatusModel, generation int64) {
+	status.AKOVersion = akoStatus.AKO.Version
+	status.ControllerVersion = akoStatus.AKO.ControllerVersion
+	status.VirtualServices = akoStatus.AKO.VirtualServices
+
+	var lastAKOError string
+	if len(akoStatus.AviApi.Errors) > 0 {
+		lastAKOError = akoStatus.AviApi.Errors[len(akoStatus.AviApi.Errors)-1].Error
+		if status.LastError == "" {
+			status.LastError = lastAKOError
+		}
+	}
+
+	switch akoStatus.AviApi.ConnectionStatus {
+	case utils.AVIAPI_CONNECTED:
+		setCondition(status, akov1alpha1.ConditionControllerConnected, metav1.ConditionTrue, reasonConnected,
+			"AKO is connected to the Avi controller", generation)
+	case utils.AVIAPI_DISCONNECTED:
+		message := "AKO is disconnected from the Avi controller"
+		if lastAKOError != "" {
+			message = lastAKOError
+		}
+		setCondition(status, akov1alpha1.ConditionControllerConnected, metav1.ConditionFalse, reasonDisconnected,
+			message, generation)
+	default:
+		setCondition(status, akov1alpha1.ConditionControllerConnected, metav1.ConditionUnknown, reasonInitiating,
+			"AKO is connecting to the Avi controller", generation)
+	}
+
+	if akoStatus.AKO.SyncEnabled {
+		setCondition(status, akov1alpha1.ConditionSyncEnabled, metav1.ConditionTrue, reasonSyncEnabled,
+			"AKO is syncing objects to the Avi controller", generation)
+	} else {
+		setCondition(status, akov1alpha1.ConditionSyncEnabled, metav1.ConditionFalse, reasonSyncDisabled,
+			"AKO sync is disabled", generation)
+	}
+
+	if akoStatus.AKO.DeleteConfigInProgress {
+		setCondition(status, akov1alpha1.ConditionDeleteConfigInProgress, metav1.ConditionTrue, reasonDeleteConfigSet,
+			"deleteConfig is set, AKO is removing the Avi objects", generation)
+	} else {
+		setCondition(status, akov1alpha1.ConditionDeleteConfigInProgress, metav1.ConditionFalse, reasonDeleteConfigUnset,
+			"deleteConfig is not set", generation)
+	}
+}
+
+func setCondition(status *akov1alpha1.AKOConfigStatus, condType string, condStatus metav1.ConditionStatus,
+	reason, message string, generation int64) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             condStatus,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// getState summarizes the conditions into the State shown for the AKOConfig.
+func getState(status *akov1alpha1.AKOConfigStatus) string {
+	reconciled := meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionReconciled)
+	switch {
+	case reconciled != nil && reconciled.Reason == reasonInstanceConflict:
+		return StateConflict
+	case !meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionReconciled):
+		return StateFailed
+	case !meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionAKOPodReady):
+		return StatePending
+	case meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionDeleteConfigInProgress):
+		return StateDeletingConfig
+	case meta.IsStatusConditionFalse(status.Conditions, akov1alpha1.ConditionControllerConnected):
+		return StateDisconnected
+	case meta.IsStatusConditionFalse(status.Conditions, akov1alpha1.ConditionSyncEnabled):
+		return StateSyncDisabled
+	case !meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionControllerConnected):
+		return StatePending
+	}
+	return StateRunning
+}
+
+func getAPIServerPort(ako akov1alpha1.AKOConfig) int {
+	if ako.Spec.AKOSettings.APIServerPort != 0 {
+		return ako.Spec.AKOSettings.APIServerPort
+	}
+	return defaultAPIServerPort
+}
+
+// getAKOStatus fetches the status from the ready AKO pods. With leader election, the status of the
+// leader is returned, as the standby replicas don't sync any objects.
+func getAKOStatus(pods []corev1.Pod, port int) (*models.StatusModel, error) {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	var akoStatus *models.StatusModel
+	var lastErr error
+	for _, pod := range pods {
+		if !isPodReady(pod) || pod.Status.PodIP == "" {
+			continue
+		}
+		podStatus, err := fetchAKOStatusFunc(pod.Status.PodIP, port)
+		if err != nil {
+			lastErr = fmt.Errorf("error fetching status from pod %s: %v", pod.Name, err)
+			continue
+		}
+		if podStatus.AKO != nil && podStatus.AKO.Leader {
+			return podStatus, nil
+		}
+		if akoStatus == nil {
+			akoStatus = podStatus
+		}
+	}
+	if akoStatus == nil {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no ready AKO pod found")
+		}
+		return nil, lastErr
+	}
+	return akoStatus, nil
+}
+
+func fetchAKOStatus(podIP string, port int) (*models.StatusModel, error) {
+	url := "http://" + podIP + ":" + strconv.Itoa(port) + "/api/status"
+	resp, err := akoStatusClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code %d from %s", resp.StatusCode, url)
+	}
+	akoStatus := &models.StatusModel{}
+	if err := json.NewDecoder(resp.Body).Decode(akoStatus); err != nil {
+		return nil, err
+	}
+	return akoStatus, nil
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
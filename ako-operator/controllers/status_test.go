@@ -0,0 +1,189 @@
+/*
+Copyright 2021 VMware, Inc.
+All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/ako-operator/api/v1alpha1"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+func getTestStatefulSet(replicas, readyReplicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		Spec:   appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: readyReplicas},
+	}
+}
+
+func getTestAKOStatus(connectionStatus string, syncEnabled, deleteConfig bool) *models.StatusModel {
+	return &models.StatusModel{
+		AviApi: models.AviApiRestStatus{
+			ConnectionStatus: connectionStatus,
+			Errors:           []models.RestStatusError{},
+		},
+		AKO: &models.AKOStatus{
+			Version:                "1.5.1",
+			ControllerVersion:      "20.1.6",
+			SyncEnabled:            syncEnabled,
+			DeleteConfigInProgress: deleteConfig,
+			VirtualServices:        5,
+		},
+	}
+}
+
+func getTestReadyPod(name, ip string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: AviSystemNS},
+		Status: corev1.PodStatus{
+			PodIP: ip,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+}
+
+func TestAKOConfigStatusRunning(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &akov1alpha1.AKOConfigStatus{}
+	buildStatus(status, 2, nil, getTestStatefulSet(1, 1), getTestAKOStatus(utils.AVIAPI_CONNECTED, true, false), nil)
+
+	g.Expect(status.State).To(gomega.Equal(StateRunning))
+	g.Expect(status.ObservedGeneration).To(gomega.Equal(int64(2)))
+	g.Expect(status.AKOVersion).To(gomega.Equal("1.5.1"))
+	g.Expect(status.ControllerVersion).To(gomega.Equal("20.1.6"))
+	g.Expect(status.VirtualServices).To(gomega.Equal(5))
+	g.Expect(status.LastError).To(gomega.BeEmpty())
+	g.Expect(status.Conditions).To(gomega.HaveLen(5))
+	g.Expect(meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionReconciled)).To(gomega.BeTrue())
+	g.Expect(meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionAKOPodReady)).To(gomega.BeTrue())
+	g.Expect(meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionControllerConnected)).To(gomega.BeTrue())
+	g.Expect(meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionSyncEnabled)).To(gomega.BeTrue())
+	g.Expect(meta.IsStatusConditionFalse(status.Conditions, akov1alpha1.ConditionDeleteConfigInProgress)).To(gomega.BeTrue())
+}
+
+func TestAKOConfigStatusReconcileFailed(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &akov1alpha1.AKOConfigStatus{}
+	buildStatus(status, 1, errors.New("secret avi-secret not found"), nil, nil, nil)
+
+	g.Expect(status.State).To(gomega.Equal(StateFailed))
+	g.Expect(status.LastError).To(gomega.Equal("secret avi-secret not found"))
+	g.Expect(meta.IsStatusConditionFalse(status.Conditions, akov1alpha1.ConditionReconciled)).To(gomega.BeTrue())
+	cond := meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionAKOPodReady)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Reason).To(gomega.Equal(reasonStatefulSetNotFound))
+	cond = meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionControllerConnected)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(metav1.ConditionUnknown))
+}
+
+func TestAKOConfigStatusTransitions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &akov1alpha1.AKOConfigStatus{}
+	buildStatus(status, 1, nil, getTestStatefulSet(2, 1), nil, nil)
+	g.Expect(status.State).To(gomega.Equal(StatePending))
+	g.Expect(meta.IsStatusConditionFalse(status.Conditions, akov1alpha1.ConditionAKOPodReady)).To(gomega.BeTrue())
+
+	akoStatus := getTestAKOStatus(utils.AVIAPI_DISCONNECTED, true, false)
+	akoStatus.AviApi.Errors = append(akoStatus.AviApi.Errors, models.RestStatusError{Error: "Client.Timeout exceeded"})
+	buildStatus(status, 1, nil, getTestStatefulSet(2, 2), akoStatus, nil)
+	g.Expect(status.State).To(gomega.Equal(StateDisconnected))
+	g.Expect(status.LastError).To(gomega.Equal("Client.Timeout exceeded"))
+	cond := meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionControllerConnected)
+	g.Expect(cond.Message).To(gomega.Equal("Client.Timeout exceeded"))
+
+	buildStatus(status, 1, nil, getTestStatefulSet(2, 2), getTestAKOStatus(utils.AVIAPI_CONNECTED, false, true), nil)
+	g.Expect(status.State).To(gomega.Equal(StateDeletingConfig))
+	g.Expect(status.LastError).To(gomega.BeEmpty())
+	g.Expect(meta.IsStatusConditionFalse(status.Conditions, akov1alpha1.ConditionSyncEnabled)).To(gomega.BeTrue())
+	g.Expect(meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionDeleteConfigInProgress)).To(gomega.BeTrue())
+
+	buildStatus(status, 1, nil, getTestStatefulSet(2, 2), getTestAKOStatus(utils.AVIAPI_CONNECTED, false, false), nil)
+	g.Expect(status.State).To(gomega.Equal(StateSyncDisabled))
+}
+
+func TestAKOConfigStatusRefresh(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the refresh retains the Reconciled condition and the error of the last reconciliation.
+	status := &akov1alpha1.AKOConfigStatus{}
+	buildStatus(status, 1, errors.New("secret avi-secret not found"), nil, nil, nil)
+	reconciled := *meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionReconciled)
+	buildAKOStatus(status, getTestStatefulSet(1, 1), getTestAKOStatus(utils.AVIAPI_CONNECTED, true, false), nil)
+	g.Expect(status.State).To(gomega.Equal(StateFailed))
+	g.Expect(status.LastError).To(gomega.Equal("secret avi-secret not found"))
+	g.Expect(*meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionReconciled)).To(gomega.Equal(reconciled))
+	g.Expect(meta.IsStatusConditionTrue(status.Conditions, akov1alpha1.ConditionAKOPodReady)).To(gomega.BeTrue())
+
+	// the conditions refreshed from AKO keep the generation of the last reconciliation.
+	buildStatus(status, 2, nil, getTestStatefulSet(1, 0), nil, nil)
+	g.Expect(status.State).To(gomega.Equal(StatePending))
+	buildAKOStatus(status, getTestStatefulSet(1, 1), getTestAKOStatus(utils.AVIAPI_CONNECTED, true, false), nil)
+	g.Expect(status.State).To(gomega.Equal(StateRunning))
+	g.Expect(status.LastError).To(gomega.BeEmpty())
+	g.Expect(status.ObservedGeneration).To(gomega.Equal(int64(2)))
+	g.Expect(meta.FindStatusCondition(status.Conditions, akov1alpha1.ConditionControllerConnected).ObservedGeneration).To(gomega.Equal(int64(2)))
+}
+
+func TestGetAKOStatusFromLeader(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() { fetchAKOStatusFunc = fetchAKOStatus }()
+	fetchAKOStatusFunc = func(podIP string, port int) (*models.StatusModel, error) {
+		g.Expect(port).To(gomega.Equal(8080))
+		akoStatus := getTestAKOStatus(utils.AVIAPI_CONNECTED, true, false)
+		switch podIP {
+		case "10.0.0.1":
+			return nil, errors.New("connection refused")
+		case "10.0.0.3":
+			akoStatus.AKO.Leader = true
+			akoStatus.AKO.VirtualServices = 10
+		}
+		return akoStatus, nil
+	}
+
+	notReadyPod := getTestReadyPod("ako-3", "10.0.0.4")
+	notReadyPod.Status.Conditions[0].Status = corev1.ConditionFalse
+	pods := []corev1.Pod{
+		getTestReadyPod("ako-2", "10.0.0.3"),
+		getTestReadyPod("ako-0", "10.0.0.1"),
+		getTestReadyPod("ako-1", "10.0.0.2"),
+		notReadyPod,
+	}
+	akoStatus, err := getAKOStatus(pods, getAPIServerPort(getTestDefaultAKOConfig()))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(akoStatus.AKO.Leader).To(gomega.BeTrue())
+	g.Expect(akoStatus.AKO.VirtualServices).To(gomega.Equal(10))
+
+	_, err = getAKOStatus([]corev1.Pod{getTestReadyPod("ako-0", "10.0.0.1")}, 8080)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("ako-0"))
+}
@@ -24,43 +24,63 @@ const (
 	AKOServiceAccount  = "ako-sa"
 	PSPName            = "ako"
 	AviSecretName      = "avi-secret"
+	WebhookSecretName  = "ako-webhook-certs"
+	WebhookServiceName = "ako-webhook"
+	WebhookConfigName  = "ako-validating-webhook"
 )
 
 // below properties are applicable to a configmap object for AKO controller
 const (
-	ControllerIP           = "controllerIP"
-	ControllerVersion      = "controllerVersion"
-	CniPlugin              = "cniPlugin"
-	EnableEVH              = "enableEVH"
-	Layer7Only             = "layer7Only"
-	ServicesAPI            = "servicesAPI"
-	VipPerNamespace        = "vipPerNamespace"
-	ShardVSSize            = "shardVSSize"
-	PassthroughShardSize   = "passhtroughShardSize"
-	FullSyncFrequency      = "fullSyncFrequency"
-	CloudName              = "cloudName"
-	ClusterName            = "clusterName"
-	EnableRHI              = "enableRHI"
-	DefaultDomain          = "defaultDomain"
-	DisableStaticRouteSync = "disableStaticRouteSync"
-	DefaultIngController   = "defaultIngController"
-	VipNetworkList         = "vipNetworkList"
-	BgpPeerLabels          = "bgpPeerLabels"
-	EnableEvents           = "enableEvents"
-	LogLevel               = "logLevel"
-	DeleteConfig           = "deleteConfig"
-	AutoFQDN               = "autoFQDN"
-	ServiceType            = "serviceType"
-	NodeKey                = "nodeKey"
-	NodeValue              = "nodeValue"
-	ServiceEngineGroupName = "serviceEngineGroupName"
-	NodeNetworkList        = "nodeNetworkList"
-	APIServerPort          = "apiServerPort"
-	NSSyncLabelKey         = "nsSyncLabelKey"
-	NSSyncLabelValue       = "nsSyncLabelValue"
-	TenantName             = "tenantName"
-	NoPGForSni             = "noPGForSni"
-	NsxtT1LR               = "nsxtT1LR"
+	ControllerIP                 = "controllerIP"
+	ControllerVersion            = "controllerVersion"
+	CniPlugin                    = "cniPlugin"
+	EnableEVH                    = "enableEVH"
+	Layer7Only                   = "layer7Only"
+	ServicesAPI                  = "servicesAPI"
+	VipPerNamespace              = "vipPerNamespace"
+	ShardVSSize                  = "shardVSSize"
+	PassthroughShardSize         = "passhtroughShardSize"
+	L7ShardingScheme             = "l7ShardingScheme"
+	FullSyncFrequency            = "fullSyncFrequency"
+	CloudName                    = "cloudName"
+	ClusterName                  = "clusterName"
+	EnableRHI                    = "enableRHI"
+	DefaultDomain                = "defaultDomain"
+	DisableStaticRouteSync       = "disableStaticRouteSync"
+	DefaultIngController         = "defaultIngController"
+	VipNetworkList               = "vipNetworkList"
+	BgpPeerLabels                = "bgpPeerLabels"
+	EnableEvents                 = "enableEvents"
+	LogLevel                     = "logLevel"
+	DeleteConfig                 = "deleteConfig"
+	AutoFQDN                     = "autoFQDN"
+	ServiceType                  = "serviceType"
+	NodeKey                      = "nodeKey"
+	NodeValue                    = "nodeValue"
+	ServiceEngineGroupName       = "serviceEngineGroupName"
+	NodeNetworkList              = "nodeNetworkList"
+	APIServerPort                = "apiServerPort"
+	NSSyncLabelKey               = "nsSyncLabelKey"
+	NSSyncLabelValue             = "nsSyncLabelValue"
+	TenantName                   = "tenantName"
+	NoPGForSni                   = "noPGForSni"
+	NsxtT1LR                     = "nsxtT1LR"
+	EnableWebhook                = "enableValidatingWebhook"
+	WebhookPort                  = "validatingWebhookPort"
+	EnableLeaderElection         = "enableLeaderElection"
+	DryRun                       = "dryRun"
+	EnableEndpointSlice          = "enableEndpointSlice"
+	ServiceEngineZone            = "serviceEngineZone"
+	ServerDrainTimeout           = "serverDrainTimeout"
+	EnablePodReadinessGate       = "enablePodReadinessGate"
+	EnableProbeHealthMonitor     = "enableProbeHealthMonitor"
+	EnableRuntimeStatus          = "enableRuntimeStatus"
+	DriftScanInterval            = "driftScanInterval"
+	DriftPolicy                  = "driftPolicy"
+	StandbyCacheRefreshInterval  = "standbyCacheRefreshInterval"
+	RuntimeStatusSyncInterval    = "runtimeStatusSyncInterval"
+	PodReadinessGateSyncInterval = "podReadinessGateSyncInterval"
+	PrimaryInstance              = "primaryInstance"
 )
 
 var SecretEnvVars = map[string]string{
@@ -70,69 +90,140 @@ var SecretEnvVars = map[string]string{
 }
 
 var ConfigMapEnvVars = map[string]string{
-	"CTRL_IPADDRESS":             ControllerIP,
-	"CTRL_VERSION":               ControllerVersion,
-	"CNI_PLUGIN":                 CniPlugin,
-	"ENABLE_EVH":                 EnableEVH,
-	"SERVICES_API":               ServicesAPI,
-	"SHARD_VS_SIZE":              ShardVSSize,
-	"PASSTHROUGH_SHARD_SIZE":     PassthroughShardSize,
-	"FULL_SYNC_INTERVAL":         FullSyncFrequency,
-	"CLOUD_NAME":                 CloudName,
-	"CLUSTER_NAME":               ClusterName,
-	"ENABLE_RHI":                 EnableRHI,
-	"BGP_PEER_LABELS":            BgpPeerLabels,
-	"DEFAULT_DOMAIN":             DefaultDomain,
-	"DISABLE_STATIC_ROUTE_SYNC":  DisableStaticRouteSync,
-	"DEFAULT_ING_CONTROLLER":     DefaultIngController,
-	"VIP_NETWORK_LIST":           VipNetworkList,
-	"AUTO_L4_FQDN":               AutoFQDN,
-	"SERVICE_TYPE":               ServiceType,
-	"NODE_KEY":                   NodeKey,
-	"NODE_VALUE":                 NodeValue,
-	"SEG_NAME":                   ServiceEngineGroupName,
-	"NODE_NETWORK_LIST":          NodeNetworkList,
-	"AKO_API_PORT":               APIServerPort,
-	"TENANT_NAME":                TenantName,
-	"NAMESPACE_SYNC_LABEL_KEY":   NSSyncLabelKey,
-	"NAMESPACE_SYNC_LABEL_VALUE": NSSyncLabelValue,
-	"NSXT_T1_LR":                 NsxtT1LR,
+	"CTRL_IPADDRESS":                   ControllerIP,
+	"CTRL_VERSION":                     ControllerVersion,
+	"CNI_PLUGIN":                       CniPlugin,
+	"ENABLE_EVH":                       EnableEVH,
+	"SERVICES_API":                     ServicesAPI,
+	"SHARD_VS_SIZE":                    ShardVSSize,
+	"PASSTHROUGH_SHARD_SIZE":           PassthroughShardSize,
+	"L7_SHARD_SCHEME":                  L7ShardingScheme,
+	"FULL_SYNC_INTERVAL":               FullSyncFrequency,
+	"CLOUD_NAME":                       CloudName,
+	"CLUSTER_NAME":                     ClusterName,
+	"ENABLE_RHI":                       EnableRHI,
+	"BGP_PEER_LABELS":                  BgpPeerLabels,
+	"DEFAULT_DOMAIN":                   DefaultDomain,
+	"DISABLE_STATIC_ROUTE_SYNC":        DisableStaticRouteSync,
+	"DEFAULT_ING_CONTROLLER":           DefaultIngController,
+	"VIP_NETWORK_LIST":                 VipNetworkList,
+	"AUTO_L4_FQDN":                     AutoFQDN,
+	"SERVICE_TYPE":                     ServiceType,
+	"NODE_KEY":                         NodeKey,
+	"NODE_VALUE":                       NodeValue,
+	"SEG_NAME":                         ServiceEngineGroupName,
+	"NODE_NETWORK_LIST":                NodeNetworkList,
+	"AKO_API_PORT":                     APIServerPort,
+	"TENANT_NAME":                      TenantName,
+	"NAMESPACE_SYNC_LABEL_KEY":         NSSyncLabelKey,
+	"NAMESPACE_SYNC_LABEL_VALUE":       NSSyncLabelValue,
+	"NSXT_T1_LR":                       NsxtT1LR,
+	"ENABLE_VALIDATING_WEBHOOK":        EnableWebhook,
+	"VALIDATING_WEBHOOK_PORT":          WebhookPort,
+	"ENABLE_LEADER_ELECTION":           EnableLeaderElection,
+	"DRY_RUN":                          DryRun,
+	"ENABLE_ENDPOINTSLICE":             EnableEndpointSlice,
+	"SE_ZONE":                          ServiceEngineZone,
+	"SERVER_DRAIN_TIMEOUT":             ServerDrainTimeout,
+	"ENABLE_POD_READINESS_GATE":        EnablePodReadinessGate,
+	"ENABLE_PROBE_HEALTH_MONITOR":      EnableProbeHealthMonitor,
+	"ENABLE_RUNTIME_STATUS":            EnableRuntimeStatus,
+	"DRIFT_SCAN_INTERVAL":              DriftScanInterval,
+	"DRIFT_POLICY":                     DriftPolicy,
+	"STANDBY_CACHE_REFRESH_INTERVAL":   StandbyCacheRefreshInterval,
+	"RUNTIME_STATUS_SYNC_INTERVAL":     RuntimeStatusSyncInterval,
+	"POD_READINESS_GATE_SYNC_INTERVAL": PodReadinessGateSyncInterval,
+	"PRIMARY_AKO_FLAG":                 PrimaryInstance,
 }
 
-func getSFNamespacedName() types.NamespacedName {
+// getReplicaCount returns the number of AKO replicas, which defaults to 1.
+func getReplicaCount(ako akov1alpha1.AKOConfig) int32 {
+	if ako.Spec.ReplicaCount > 1 {
+		return int32(ako.Spec.ReplicaCount)
+	}
+	return 1
+}
+
+// getInstanceNamespace returns the namespace in which the AKO instance of the AKOConfig is deployed.
+func getInstanceNamespace(ako akov1alpha1.AKOConfig) string {
+	if ako.GetNamespace() != "" {
+		return ako.GetNamespace()
+	}
+	return AviSystemNS
+}
+
+// getInstanceName returns the name of a cluster scoped artifact of the AKO instance. The AKO instance in
+// avi-system keeps the default names, the names for the other instances are suffixed with their namespace.
+func getInstanceName(ako akov1alpha1.AKOConfig, name string) string {
+	if namespace := getInstanceNamespace(ako); namespace != AviSystemNS {
+		return name + "-" + namespace
+	}
+	return name
+}
+
+// isPrimaryInstance returns true if the AKO instance of the AKOConfig is the primary instance, which
+// is the default when primaryInstance is not set.
+func isPrimaryInstance(ako akov1alpha1.AKOConfig) bool {
+	if ako.Spec.AKOSettings.PrimaryInstance == nil {
+		return true
+	}
+	return *ako.Spec.AKOSettings.PrimaryInstance
+}
+
+func getSFNamespacedName(ako akov1alpha1.AKOConfig) types.NamespacedName {
 	return types.NamespacedName{
-		Namespace: AviSystemNS,
+		Namespace: getInstanceNamespace(ako),
 		Name:      StatefulSetName,
 	}
 }
 
-func getCRName() types.NamespacedName {
+func getCRName(ako akov1alpha1.AKOConfig) types.NamespacedName {
 	return types.NamespacedName{
-		Name: AKOCR,
+		Name: getInstanceName(ako, AKOCR),
 	}
 }
 
-func getCRBName() types.NamespacedName {
+func getCRBName(ako akov1alpha1.AKOConfig) types.NamespacedName {
 	return types.NamespacedName{
-		Name: CRBName,
+		Name: getInstanceName(ako, CRBName),
 	}
 }
-func getSAName() types.NamespacedName {
+func getSAName(ako akov1alpha1.AKOConfig) types.NamespacedName {
 	return types.NamespacedName{
-		Namespace: AviSystemNS,
+		Namespace: getInstanceNamespace(ako),
 		Name:      AKOServiceAccount,
 	}
 }
 
-func getPSPName() types.NamespacedName {
+func getPSPName(ako akov1alpha1.AKOConfig) types.NamespacedName {
+	return types.NamespacedName{
+		Name: getInstanceName(ako, PSPName),
+	}
+}
+
+func getWebhookSecretName(ako akov1alpha1.AKOConfig) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: getInstanceNamespace(ako),
+		Name:      WebhookSecretName,
+	}
+}
+
+func getWebhookServiceName(ako akov1alpha1.AKOConfig) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: getInstanceNamespace(ako),
+		Name:      WebhookServiceName,
+	}
+}
+
+func getWebhookConfigName(ako akov1alpha1.AKOConfig) types.NamespacedName {
 	return types.NamespacedName{
-		Name: PSPName,
+		Name: getInstanceName(ako, WebhookConfigName),
 	}
 }
 
-func getConfigMapName() types.NamespacedName {
+func getConfigMapName(ako akov1alpha1.AKOConfig) types.NamespacedName {
 	return types.NamespacedName{
-		Namespace: AviSystemNS,
+		Namespace: getInstanceNamespace(ako),
 		Name:      ConfigMapName,
 	}
 }
@@ -179,7 +270,7 @@ func isSfUpdateRequired(existingSf appsv1.StatefulSet, newSf appsv1.StatefulSet)
 	newContainer := newSf.Spec.Template.Spec.Containers[0]
 
 	// update to the statefulset required?
-	if existingSf.Spec.Replicas != nil && *existingSf.Spec.Replicas == 1 {
+	if existingSf.Spec.Replicas != nil && newSf.Spec.Replicas != nil && *existingSf.Spec.Replicas == *newSf.Spec.Replicas {
 		if len(existingSf.Spec.Template.Spec.Containers) != 1 {
 			return true
 		}
@@ -244,7 +335,7 @@ func getEnvVars(ako akov1alpha1.AKOConfig, aviSecret v1.Secret) []v1.EnvVar {
 			ValueFrom: &v1.EnvVarSource{
 				SecretKeyRef: &v1.SecretKeySelector{
 					LocalObjectReference: v1.LocalObjectReference{
-						Name: "avi-secret",
+						Name: AviSecretName,
 					},
 					Key: v,
 				},
@@ -282,7 +373,7 @@ func getEnvVars(ako akov1alpha1.AKOConfig, aviSecret v1.Secret) []v1.EnvVar {
 	})
 	envVars = append(envVars, v1.EnvVar{
 		Name:  "POD_NAMESPACE",
-		Value: AviSystemNS,
+		Value: getInstanceNamespace(ako),
 	})
 	return envVars
 }
@@ -0,0 +1,365 @@
+/*
+Copyright 2021 VMware, Inc.
+All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/ako-operator/api/v1alpha1"
+)
+
+const (
+	webhookCACertKey     = "ca.crt"
+	webhookPath          = "/validate"
+	webhookCertValidity  = 365 * 24 * time.Hour
+	webhookCertRenewal   = 30 * 24 * time.Hour
+	defaultWebhookPort   = 9443
+	webhookCertMountPath = "/etc/ako/webhook-certs/"
+)
+
+func getWebhookPort(ako akov1alpha1.AKOConfig) int {
+	if ako.Spec.AKOSettings.ValidatingWebhook.Port > 0 {
+		return ako.Spec.AKOSettings.ValidatingWebhook.Port
+	}
+	return defaultWebhookPort
+}
+
+// createOrUpdateValidatingWebhook wires up the AKO validating webhook: the serving certificates
+// are generated and stored in a secret mounted by the AKO pod, a service fronts the webhook server
+// and the webhook is registered with the CA bundle that signed the serving certificate.
+func createOrUpdateValidatingWebhook(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
+	if !ako.Spec.AKOSettings.ValidatingWebhook.Enabled {
+		return deleteValidatingWebhook(ctx, ako, log, r)
+	}
+
+	caCert, err := createOrUpdateWebhookSecret(ctx, ako, log, r)
+	if err != nil {
+		return err
+	}
+
+	err = createOrUpdateWebhookService(ctx, ako, log, r)
+	if err != nil {
+		return err
+	}
+
+	return createOrUpdateWebhookConfig(ctx, ako, log, r, caCert)
+}
+
+func createOrUpdateWebhookSecret(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) ([]byte, error) {
+	var oldSecret corev1.Secret
+	if err := r.Get(ctx, getWebhookSecretName(ako), &oldSecret); err != nil {
+		log.V(0).Info("no pre-existing webhook secret with name", "name", WebhookSecretName)
+	} else if isWebhookCertValid(oldSecret) {
+		log.V(0).Info("no updates required for the webhook secret")
+		objList := getObjectList(ako)
+		objList[getWebhookSecretName(ako)] = &oldSecret
+		return oldSecret.Data[webhookCACertKey], nil
+	}
+
+	caCert, cert, key, err := generateWebhookCerts(WebhookServiceName, getInstanceNamespace(ako))
+	if err != nil {
+		log.Error(err, "unable to generate the webhook certificates")
+		return nil, err
+	}
+	secret := corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      WebhookSecretName,
+			Namespace: getInstanceNamespace(ako),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			webhookCACertKey:        caCert,
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: key,
+		},
+	}
+	err = ctrl.SetControllerReference(&ako, &secret, r.Scheme)
+	if err != nil {
+		log.Error(err, "error in setting controller reference to webhook secret, secret changes would be ignored")
+	}
+
+	if oldSecret.GetName() != "" {
+		secret.ResourceVersion = oldSecret.ResourceVersion
+		err = r.Update(ctx, &secret)
+	} else {
+		err = r.Create(ctx, &secret)
+	}
+	if err != nil {
+		log.Error(err, "unable to create/update webhook secret", "namespace", secret.GetNamespace(),
+			"name", secret.GetName())
+		return nil, err
+	}
+
+	// update this object in the global list
+	objList := getObjectList(ako)
+	objList[getWebhookSecretName(ako)] = &secret
+	log.V(0).Info("webhook secret created/updated", "name", WebhookSecretName)
+	return caCert, nil
+}
+
+func createOrUpdateWebhookService(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
+	svc := BuildWebhookService(ako)
+	err := ctrl.SetControllerReference(&ako, &svc, r.Scheme)
+	if err != nil {
+		log.Error(err, "error in setting controller reference to webhook service, service changes would be ignored")
+	}
+
+	var oldSvc corev1.Service
+	if err := r.Get(ctx, getWebhookServiceName(ako), &oldSvc); err != nil {
+		log.V(0).Info("no pre-existing webhook service with name", "name", WebhookServiceName)
+		if err := r.Create(ctx, &svc); err != nil {
+			log.Error(err, "unable to create webhook service", "namespace", svc.GetNamespace(),
+				"name", svc.GetName())
+			return err
+		}
+	} else if len(oldSvc.Spec.Ports) != 1 || oldSvc.Spec.Ports[0].TargetPort != svc.Spec.Ports[0].TargetPort {
+		oldSvc.Spec.Ports = svc.Spec.Ports
+		oldSvc.Spec.Selector = svc.Spec.Selector
+		if err := r.Update(ctx, &oldSvc); err != nil {
+			log.Error(err, "unable to update webhook service", "namespace", svc.GetNamespace(),
+				"name", svc.GetName())
+			return err
+		}
+		svc = oldSvc
+	} else {
+		log.V(0).Info("no updates required for the webhook service")
+		svc = oldSvc
+	}
+
+	// update this object in the global list
+	objList := getObjectList(ako)
+	objList[getWebhookServiceName(ako)] = &svc
+	return nil
+}
+
+func createOrUpdateWebhookConfig(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler,
+	caCert []byte) error {
+	// the validating webhook configuration is cluster scoped, so it can't be owned by the AKOConfig
+	// object, it is removed along with the other artifacts during the cleanup.
+	vwc := BuildValidatingWebhookConfiguration(ako, caCert)
+
+	var oldVwc admissionregistrationv1.ValidatingWebhookConfiguration
+	if err := r.Get(ctx, getWebhookConfigName(ako), &oldVwc); err != nil {
+		log.V(0).Info("no pre-existing validating webhook configuration with name", "name", getWebhookConfigName(ako).Name)
+		if err := r.Create(ctx, &vwc); err != nil {
+			log.Error(err, "unable to create validating webhook configuration", "name", vwc.GetName())
+			return err
+		}
+	} else if len(oldVwc.Webhooks) != 1 || !bytes.Equal(oldVwc.Webhooks[0].ClientConfig.CABundle, caCert) ||
+		!reflect.DeepEqual(oldVwc.Webhooks[0].NamespaceSelector, vwc.Webhooks[0].NamespaceSelector) {
+		oldVwc.Webhooks = vwc.Webhooks
+		if err := r.Update(ctx, &oldVwc); err != nil {
+			log.Error(err, "unable to update validating webhook configuration", "name", vwc.GetName())
+			return err
+		}
+		vwc = oldVwc
+	} else {
+		log.V(0).Info("no updates required for the validating webhook configuration")
+		vwc = oldVwc
+	}
+
+	// update this object in the global list
+	objList := getObjectList(ako)
+	objList[getWebhookConfigName(ako)] = &vwc
+	return nil
+}
+
+func deleteValidatingWebhook(ctx context.Context, ako akov1alpha1.AKOConfig, log logr.Logger, r *AKOConfigReconciler) error {
+	webhookObjects := map[types.NamespacedName]client.Object{
+		getWebhookConfigName(ako):  &admissionregistrationv1.ValidatingWebhookConfiguration{},
+		getWebhookServiceName(ako): &corev1.Service{},
+		getWebhookSecretName(ako):  &corev1.Secret{},
+	}
+	objList := getObjectList(ako)
+	for objName, obj := range webhookObjects {
+		if err := r.deleteIfExists(ctx, objName, obj); err != nil && !k8serrors.IsNotFound(err) {
+			log.Error(err, "unable to delete validating webhook artifact", "name", objName.Name)
+			return err
+		}
+		delete(objList, objName)
+	}
+	return nil
+}
+
+func BuildWebhookService(ako akov1alpha1.AKOConfig) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      WebhookServiceName,
+			Namespace: getInstanceNamespace(ako),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				"app": "ako",
+			},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "webhook",
+					Port:       443,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromInt(getWebhookPort(ako)),
+				},
+			},
+		},
+	}
+}
+
+// BuildValidatingWebhookConfiguration builds the webhook registration of an AKO instance. If the instance syncs
+// only the namespaces matching its namespace selector, only the objects in these namespaces are validated.
+func BuildValidatingWebhookConfiguration(ako akov1alpha1.AKOConfig, caCert []byte) admissionregistrationv1.ValidatingWebhookConfiguration {
+	path := webhookPath
+	failurePolicy := admissionregistrationv1.Ignore
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	scope := admissionregistrationv1.AllScopes
+	var timeout int32 = 10
+	var namespaceSelector *v1.LabelSelector
+	if nsSelector := ako.Spec.AKOSettings.NSSelector; nsSelector.LabelKey != "" {
+		namespaceSelector = &v1.LabelSelector{
+			MatchLabels: map[string]string{nsSelector.LabelKey: nsSelector.LabelValue},
+		}
+	}
+	return admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: v1.ObjectMeta{
+			Name: getWebhookConfigName(ako).Name,
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name:                    "crd.ako.vmware.com",
+				AdmissionReviewVersions: []string{"v1"},
+				SideEffects:             &sideEffects,
+				FailurePolicy:           &failurePolicy,
+				TimeoutSeconds:          &timeout,
+				NamespaceSelector:       namespaceSelector,
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					CABundle: caCert,
+					Service: &admissionregistrationv1.ServiceReference{
+						Name:      WebhookServiceName,
+						Namespace: getInstanceNamespace(ako),
+						Path:      &path,
+					},
+				},
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{"ako.vmware.com"},
+							APIVersions: []string{"v1alpha1"},
+							Resources:   []string{"hostrules", "httprules", "aviinfrasettings", "multiclusteringresses"},
+							Scope:       &scope,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// isWebhookCertValid checks whether the secret has the CA and the serving certificate, and that the
+// serving certificate is not due for renewal.
+func isWebhookCertValid(secret corev1.Secret) bool {
+	if len(secret.Data[webhookCACertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return false
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return time.Now().Add(webhookCertRenewal).Before(cert.NotAfter)
+}
+
+// generateWebhookCerts generates a self signed CA, and a serving certificate signed by it for the
+// webhook service DNS names. The CA certificate, serving certificate and key are returned PEM encoded.
+func generateWebhookCerts(serviceName, namespace string) ([]byte, []byte, []byte, error) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(webhookCertValidity)
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: serviceName + "-ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dnsName := serviceName + "." + namespace + ".svc"
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{serviceName, serviceName + "." + namespace, dnsName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if caPEM == nil || certPEM == nil || keyPEM == nil {
+		return nil, nil, nil, errors.New("unable to encode the webhook certificates")
+	}
+	return caPEM, certPEM, keyPEM, nil
+}
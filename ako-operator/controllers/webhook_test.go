@@ -0,0 +1,87 @@
+/*
+Copyright 2021 VMware, Inc.
+All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestValidatingWebhook(t *testing.T) {
+	// Test for:
+	// 1. Whether the generated serving certificate is signed by the generated CA for the webhook service
+	// 2. Whether the webhook service, configuration and the statefulset are built from akoConfig
+	g := gomega.NewGomegaWithT(t)
+	akoConfig := getTestDefaultAKOConfig()
+
+	t.Log("verifying the generated webhook certificates")
+	caCert, cert, key, err := generateWebhookCerts(WebhookServiceName, AviSystemNS)
+	g.Expect(err).To(gomega.BeNil())
+	roots := x509.NewCertPool()
+	g.Expect(roots.AppendCertsFromPEM(caCert)).To(gomega.BeTrue())
+	block, _ := pem.Decode(cert)
+	g.Expect(block).NotTo(gomega.BeNil())
+	servingCert, err := x509.ParseCertificate(block.Bytes)
+	g.Expect(err).To(gomega.BeNil())
+	_, err = servingCert.Verify(x509.VerifyOptions{
+		DNSName: WebhookServiceName + "." + AviSystemNS + ".svc",
+		Roots:   roots,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	secret := corev1.Secret{Data: map[string][]byte{
+		webhookCACertKey:        caCert,
+		corev1.TLSCertKey:       cert,
+		corev1.TLSPrivateKeyKey: key,
+	}}
+	g.Expect(isWebhookCertValid(secret)).To(gomega.BeTrue())
+	delete(secret.Data, webhookCACertKey)
+	g.Expect(isWebhookCertValid(secret)).To(gomega.BeFalse())
+
+	t.Log("verifying the webhook service and configuration")
+	akoConfig.Spec.AKOSettings.ValidatingWebhook.Enabled = true
+	svc := BuildWebhookService(akoConfig)
+	g.Expect(svc.Spec.Ports[0].TargetPort).To(gomega.Equal(intstr.FromInt(defaultWebhookPort)))
+	akoConfig.Spec.AKOSettings.ValidatingWebhook.Port = 9444
+	svc = BuildWebhookService(akoConfig)
+	g.Expect(svc.Spec.Ports[0].TargetPort).To(gomega.Equal(intstr.FromInt(9444)))
+
+	vwc := BuildValidatingWebhookConfiguration(akoConfig, caCert)
+	g.Expect(vwc.Webhooks).To(gomega.HaveLen(1))
+	g.Expect(vwc.Webhooks[0].ClientConfig.CABundle).To(gomega.Equal(caCert))
+	g.Expect(vwc.Webhooks[0].ClientConfig.Service.Name).To(gomega.Equal(WebhookServiceName))
+	g.Expect(*vwc.Webhooks[0].ClientConfig.Service.Path).To(gomega.Equal(webhookPath))
+
+	t.Log("verifying the webhook settings in the configmap and statefulset")
+	cm, err := BuildConfigMap(akoConfig)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cm.Data[EnableWebhook]).To(gomega.Equal("true"))
+	g.Expect(cm.Data[WebhookPort]).To(gomega.Equal("9444"))
+
+	sf, err := BuildStatefulSet(akoConfig, corev1.Secret{})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(sf.Spec.Template.Spec.Volumes).To(gomega.HaveLen(1))
+	g.Expect(sf.Spec.Template.Spec.Volumes[0].Secret.SecretName).To(gomega.Equal(WebhookSecretName))
+	container := sf.Spec.Template.Spec.Containers[0]
+	g.Expect(container.VolumeMounts[0].MountPath).To(gomega.Equal(webhookCertMountPath))
+}
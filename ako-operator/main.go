@@ -46,9 +46,10 @@ func init() {
 func main() {
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	// the AKOConfigs and the AKO instances can be in any namespace, so the cache is not restricted
+	// to a namespace
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:    scheme,
-		Namespace: controllers.AviSystemNS,
+		Scheme: scheme,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -34,6 +34,7 @@ import (
 	advl4 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/third_party/service-apis/client/clientset/versioned"
 
 	oshiftclient "github.com/openshift/client-go/route/clientset/versioned"
+	istiocrd "istio.io/client-go/pkg/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -59,15 +60,39 @@ func main() {
 }
 
 func InitializeAKOApi() {
-	akoApi := api.NewServer(lib.GetAkoApiServerPort(), []models.ApiModel{})
+	apiModels := []models.ApiModel{&models.MetricsModel{}, &k8s.DebugModel{}}
+	models.SetAKOStatusFunc(getAKOStatus)
+	if lib.IsDryRunEnabled() {
+		apiModels = append(apiModels, &models.DryRunModel{})
+	}
+	if lib.GetDriftScanInterval() > 0 {
+		apiModels = append(apiModels, &k8s.DriftModel{})
+	}
+	akoApi := api.NewServer(lib.GetAkoApiServerPort(), apiModels)
 	akoApi.InitApi()
 	lib.SetApiServerInstance(akoApi)
 }
 
+// getAKOStatus returns the state of this AKO instance, which is polled by the ako-operator.
+func getAKOStatus() models.AKOStatus {
+	return models.AKOStatus{
+		Version:                version,
+		ControllerVersion:      utils.CtrlVersion,
+		SyncEnabled:            !lib.DisableSync,
+		DeleteConfigInProgress: lib.GetDeleteConfigMap(),
+		VirtualServices:        avicache.SharedAviObjCache().VsCacheMeta.AviCacheLen(),
+		Leader:                 lib.AKOControlConfig().IsLeader(),
+	}
+}
+
 func InitializeAKC() {
 	var err error
 	kubeCluster := false
 	utils.AviLog.Info("AKO is running with version: ", version)
+	if lib.IsDryRunEnabled() {
+		utils.AviLog.Warnf("AKO is running in dry run mode, the Avi REST operations would only be logged, and served at /api/dryrun")
+	}
+	lib.WarnUnsupportedPodFeatures()
 
 	// set the logger for k8s as AviLogger.
 	klog.SetLogger(utils.AviLog)
@@ -101,6 +126,7 @@ func InitializeAKC() {
 	var crdClient *crd.Clientset
 	var advl4Client *advl4.Clientset
 	var svcAPIClient *svcapi.Clientset
+	var istioClient *istiocrd.Clientset
 
 	if lib.GetAdvancedL4() {
 		advl4Client, err = advl4.NewForConfig(cfg)
@@ -122,6 +148,14 @@ func InitializeAKC() {
 			utils.AviLog.Fatalf("Error building AKO CRD clientset: %s", err.Error())
 		}
 		akoControlConfig.SetCRDClientset(crdClient)
+
+		if lib.IsIstioEnabled() {
+			istioClient, err = istiocrd.NewForConfig(cfg)
+			if err != nil {
+				utils.AviLog.Fatalf("Error building Istio CRD clientset: %s", err.Error())
+			}
+			akoControlConfig.SetIstioClientset(istioClient)
+		}
 	}
 
 	dynamicClient, err := lib.NewDynamicClientSet(cfg)
@@ -181,6 +215,9 @@ func InitializeAKC() {
 		if lib.UseServicesAPI() {
 			k8s.NewSvcApiInformers(svcAPIClient)
 		}
+		if lib.IsIstioEnabled() {
+			k8s.NewIstioCRDInformers(istioClient)
+		}
 	}
 	istioUpdateCh := make(chan struct{})
 	if lib.IsIstioEnabled() {
@@ -331,6 +368,15 @@ func InitializeAKC() {
 		<-istioUpdateCh
 	}
 
+	if lib.IsLeaderElectionEnabled() {
+		k8s.RunLeaderElection(kubeClient, stopCh)
+	}
+
+	if lib.IsValidatingWebhookEnabled() {
+		webhookServer := k8s.NewValidatingWebhookServer(lib.GetValidatingWebhookPort(), lib.ValidatingWebhookCertDir)
+		webhookServer.Run(stopCh)
+	}
+
 	go c.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
 	<-stopCh
 	close(ctrlCh)
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var aviObjCacheSizeDesc = prometheus.NewDesc(
+	"ako_avi_cache_objects",
+	"Current number of objects in the Avi object caches.",
+	[]string{"cache"}, nil,
+)
+
+// aviObjCacheCollector reports the size of each of the caches in the AviObjCache at scrape time.
+type aviObjCacheCollector struct {
+	objCache *AviObjCache
+}
+
+func newAviObjCacheCollector(objCache *AviObjCache) prometheus.Collector {
+	return &aviObjCacheCollector{objCache: objCache}
+}
+
+func (c *aviObjCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- aviObjCacheSizeDesc
+}
+
+func (c *aviObjCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	caches := map[string]*AviCache{
+		"virtualservice": c.objCache.VsCacheMeta,
+		"vsvip":          c.objCache.VSVIPCache,
+		"pool":           c.objCache.PoolCache,
+		"poolgroup":      c.objCache.PgCache,
+		"datascript":     c.objCache.DSCache,
+		"httppolicyset":  c.objCache.HTTPPolicyCache,
+		"l4policyset":    c.objCache.L4PolicyCache,
+		"nspolicy":       c.objCache.NSPolicyCache,
+		"persistence":    c.objCache.PersistenceCache,
+		"healthmonitor":  c.objCache.HealthMonitorCache,
+		"sslkeyandcert":  c.objCache.SSLKeyCache,
+		"pkiprofile":     c.objCache.PKIProfileCache,
+		"vrfcontext":     c.objCache.VrfCache,
+		"cloud":          c.objCache.CloudKeyCache,
+		"cluster_status": c.objCache.ClusterStatusCache,
+	}
+	for name, cache := range caches {
+		if cache == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(aviObjCacheSizeDesc, prometheus.GaugeValue, float64(cache.AviCacheLen()), name)
+	}
+}
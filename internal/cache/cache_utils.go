@@ -15,9 +15,13 @@ package cache
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 
+	"github.com/vmware/alb-sdk/go/models"
+
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
 )
@@ -32,15 +36,23 @@ type NamespaceName struct {
  */
 
 type AviPoolCache struct {
-	Name                 string
-	Tenant               string
-	Uuid                 string
-	CloudConfigCksum     string
-	ServiceMetadataObj   lib.ServiceMetadataObj
-	PkiProfileCollection NamespaceName
-	LastModified         string
-	InvalidData          bool
-	HasReference         bool
+	Name                         string
+	Tenant                       string
+	Uuid                         string
+	CloudConfigCksum             string
+	ServiceMetadataObj           lib.ServiceMetadataObj
+	PkiProfileCollection         NamespaceName
+	PersistenceProfileCollection NamespaceName
+	HealthMonitorCollection      NamespaceName
+	LastModified                 string
+	InvalidData                  bool
+	HasReference                 bool
+	// Servers maps the ip:port of the pool servers to the checksum of their attributes. It is used
+	// to find the servers that changed, so that only those are patched in the pool.
+	Servers map[string]uint32
+	// NonServerCksum is the checksum of the pool fields other than the servers, as last sent in a POST
+	// or PUT call. It is empty when the pool is populated from the controller, which forces a PUT.
+	NonServerCksum string
 }
 
 type AviDSCache struct {
@@ -78,6 +90,7 @@ type AviVsCache struct {
 	HTTPKeyCollection    []NamespaceName
 	SSLKeyCertCollection []NamespaceName
 	L4PolicyCollection   []NamespaceName
+	NSPolicyCollection   []NamespaceName
 	SNIChildCollection   []string
 	ParentVSRef          NamespaceName
 	PassthroughParentRef NamespaceName
@@ -119,6 +132,47 @@ func (c *AviCache) AviCacheAddPool(k NamespaceName) *AviPoolCache {
 	return &poolObj
 }
 
+// PoolServerKey returns the ip:port of the pool server, which identifies the server in the pool.
+func PoolServerKey(server *models.Server) string {
+	var addr string
+	var port int32
+	if server.IP != nil && server.IP.Addr != nil {
+		addr = *server.IP.Addr
+	}
+	if server.Port != nil {
+		port = *server.Port
+	}
+	return addr + ":" + strconv.Itoa(int(port))
+}
+
+// PoolServerCksum returns the checksum of the pool server attributes set by AKO. The controller
+// defaults are applied to the unset attributes, so that the servers read from the controller and
+// the servers built by AKO have the same checksum.
+func PoolServerCksum(server *models.Server) uint32 {
+	var serverNode string
+	if server.ServerNode != nil {
+		serverNode = *server.ServerNode
+	}
+	ratio := int32(1)
+	if server.Ratio != nil {
+		ratio = *server.Ratio
+	}
+	enabled := true
+	if server.Enabled != nil {
+		enabled = *server.Enabled
+	}
+	return utils.Hash(fmt.Sprintf("%s|%s|%d|%t", PoolServerKey(server), serverNode, ratio, enabled))
+}
+
+// GetPoolServers returns the pool servers keyed by their ip:port, along with their checksums.
+func GetPoolServers(servers []*models.Server) map[string]uint32 {
+	poolServers := make(map[string]uint32, len(servers))
+	for _, server := range servers {
+		poolServers[PoolServerKey(server)] = PoolServerCksum(server)
+	}
+	return poolServers
+}
+
 func (v *AviVsCache) SetPGKeyCollection(keyCollection []NamespaceName) {
 	v.VSCacheLock.Lock()
 	defer v.VSCacheLock.Unlock()
@@ -151,6 +205,8 @@ func (v *AviVsCache) RemoveFromPGKeyCollection(k NamespaceName) {
 }
 
 func (v *AviVsCache) AddToVSVipKeyCollection(k NamespaceName) {
+	v.VSCacheLock.Lock()
+	defer v.VSCacheLock.Unlock()
 	if v.VSVipKeyCollection == nil {
 		v.VSVipKeyCollection = []NamespaceName{k}
 	}
@@ -160,6 +216,8 @@ func (v *AviVsCache) AddToVSVipKeyCollection(k NamespaceName) {
 }
 
 func (v *AviVsCache) RemoveFromVSVipKeyCollection(k NamespaceName) {
+	v.VSCacheLock.Lock()
+	defer v.VSCacheLock.Unlock()
 	if v.VSVipKeyCollection == nil {
 		return
 	}
@@ -247,6 +305,22 @@ func (v *AviVsCache) RemoveFromL4PolicyCollection(k NamespaceName) {
 	v.L4PolicyCollection = RemoveNamespaceName(v.L4PolicyCollection, k)
 }
 
+func (v *AviVsCache) AddToNSPolicyCollection(k NamespaceName) {
+	if v.NSPolicyCollection == nil {
+		v.NSPolicyCollection = []NamespaceName{k}
+	}
+	if !utils.HasElem(v.NSPolicyCollection, k) {
+		v.NSPolicyCollection = append(v.NSPolicyCollection, k)
+	}
+}
+
+func (v *AviVsCache) RemoveFromNSPolicyCollection(k NamespaceName) {
+	if v.NSPolicyCollection == nil {
+		return
+	}
+	v.NSPolicyCollection = RemoveNamespaceName(v.NSPolicyCollection, k)
+}
+
 func (v *AviVsCache) AddToSNIChildCollection(k string) {
 	if v.SNIChildCollection == nil {
 		v.SNIChildCollection = []string{k}
@@ -343,10 +417,40 @@ type AviL4PolicyCache struct {
 	HasReference     bool
 }
 
+type AviNSPolicyCache struct {
+	Name             string
+	Tenant           string
+	Uuid             string
+	CloudConfigCksum uint32
+	LastModified     string
+	HasReference     bool
+}
+
+type AviPersistenceProfileCache struct {
+	Name             string
+	Tenant           string
+	Uuid             string
+	CloudConfigCksum uint32
+	LastModified     string
+}
+
+type AviHealthMonitorCache struct {
+	Name             string
+	Tenant           string
+	Uuid             string
+	CloudConfigCksum uint32
+	LastModified     string
+}
+
 type AviVrfCache struct {
 	Name             string
 	Uuid             string
 	CloudConfigCksum uint32
+	// StaticRoutes holds the checksums of the static routes of the cluster, keyed by their route ids.
+	StaticRoutes map[string]uint32
+	// LegacyStaticRoutes holds the legacy route ids of the static routes of the cluster, keyed by their
+	// prefixes. The routes of the pod CIDRs with these prefixes keep the legacy route ids.
+	LegacyStaticRoutes map[string]string
 }
 
 func (v *AviVsCache) GetVSCopy() (*AviVsCache, bool) {
@@ -396,7 +500,8 @@ func (c *AviCache) AviCacheGetAllParentVSKeys() []NamespaceName {
 	var keys []NamespaceName
 	for k, val := range c.cache {
 		vsCache := val.(*AviVsCache)
-		if vsCache.ParentVSRef == (NamespaceName{}) && vsCache.ServiceMetadataObj.PassthroughParentRef == "" {
+		if vsCache.ParentVSRef == (NamespaceName{}) && vsCache.ServiceMetadataObj.PassthroughParentRef == "" &&
+			vsCache.ServiceMetadataObj.L4ParentRef == "" {
 			keys = append(keys, k.(NamespaceName))
 		}
 	}
@@ -482,6 +587,24 @@ func (c *AviCache) AviCacheGetNameByUuid(uuid string) (interface{}, bool) {
 			} else if value.(*AviL4PolicyCache).Uuid == uuid {
 				return value.(*AviL4PolicyCache).Name, true
 			}
+		case *AviNSPolicyCache:
+			if value.(*AviNSPolicyCache) == nil {
+				utils.AviLog.Warnf("Got nil value in cache for network security policy key %v", reflect.ValueOf(key))
+			} else if value.(*AviNSPolicyCache).Uuid == uuid {
+				return value.(*AviNSPolicyCache).Name, true
+			}
+		case *AviPersistenceProfileCache:
+			if value.(*AviPersistenceProfileCache) == nil {
+				utils.AviLog.Warnf("Got nil value in cache for persistence profile key %v", reflect.ValueOf(key))
+			} else if value.(*AviPersistenceProfileCache).Uuid == uuid {
+				return value.(*AviPersistenceProfileCache).Name, true
+			}
+		case *AviHealthMonitorCache:
+			if value.(*AviHealthMonitorCache) == nil {
+				utils.AviLog.Warnf("Got nil value in cache for health monitor key %v", reflect.ValueOf(key))
+			} else if value.(*AviHealthMonitorCache).Uuid == uuid {
+				return value.(*AviHealthMonitorCache).Name, true
+			}
 		case *AviHTTPPolicyCache:
 			if value.(*AviHTTPPolicyCache) == nil {
 				utils.AviLog.Warnf("Got nil value in cache for http policy key %v", reflect.ValueOf(key))
@@ -517,6 +640,12 @@ func (c *AviCache) AviCacheDelete(k interface{}) {
 	delete(c.cache, k)
 }
 
+func (c *AviCache) AviCacheLen() int {
+	c.cache_lock.RLock()
+	defer c.cache_lock.RUnlock()
+	return len(c.cache)
+}
+
 func (c *AviCache) ShallowCopy() map[interface{}]interface{} {
 	// Shallow copy, does not dereference the pointers.
 	c.cache_lock.Lock()
@@ -45,8 +45,11 @@ type AviObjCache struct {
 	CloudKeyCache      *AviCache
 	HTTPPolicyCache    *AviCache
 	L4PolicyCache      *AviCache
+	NSPolicyCache      *AviCache
 	SSLKeyCache        *AviCache
 	PKIProfileCache    *AviCache
+	PersistenceCache   *AviCache
+	HealthMonitorCache *AviCache
 	VSVIPCache         *AviCache
 	VrfCache           *AviCache
 	VsCacheMeta        *AviCache
@@ -65,9 +68,12 @@ func NewAviObjCache() *AviObjCache {
 	c.CloudKeyCache = NewAviCache()
 	c.HTTPPolicyCache = NewAviCache()
 	c.L4PolicyCache = NewAviCache()
+	c.NSPolicyCache = NewAviCache()
 	c.VSVIPCache = NewAviCache()
 	c.VrfCache = NewAviCache()
 	c.PKIProfileCache = NewAviCache()
+	c.PersistenceCache = NewAviCache()
+	c.HealthMonitorCache = NewAviCache()
 	c.ClusterStatusCache = NewAviCache()
 	return &c
 }
@@ -78,6 +84,7 @@ var cacheOnce sync.Once
 func SharedAviObjCache() *AviObjCache {
 	cacheOnce.Do(func() {
 		cacheInstance = NewAviObjCache()
+		utils.RegisterMetrics(newAviObjCacheCollector(cacheInstance))
 	})
 	return cacheInstance
 }
@@ -85,7 +92,7 @@ func SharedAviObjCache() *AviObjCache {
 func (c *AviObjCache) AviRefreshObjectCache(client []*clients.AviClient, cloud string) {
 	var wg sync.WaitGroup
 	// We want to run 8 go routines which will simultanesouly fetch objects from the controller.
-	wg.Add(5)
+	wg.Add(6)
 	go func() {
 		defer wg.Done()
 		c.PopulateSSLKeyToCache(client[4], cloud)
@@ -95,6 +102,8 @@ func (c *AviObjCache) AviRefreshObjectCache(client []*clients.AviClient, cloud s
 		c.PopulateVsVipDataToCache(client[7], cloud)
 	}()
 	c.PopulatePkiProfilesToCache(client[0])
+	c.PopulatePersistenceProfilesToCache(client[0])
+	c.PopulateHealthMonitorsToCache(client[0])
 	c.PopulatePoolsToCache(client[1], cloud)
 	c.PopulatePgDataToCache(client[2], cloud)
 
@@ -111,6 +120,10 @@ func (c *AviObjCache) AviRefreshObjectCache(client []*clients.AviClient, cloud s
 		defer wg.Done()
 		c.PopulateL4PolicySetToCache(client[6], cloud)
 	}()
+	go func() {
+		defer wg.Done()
+		c.PopulateNSPolicyToCache(client[8])
+	}()
 
 	wg.Wait()
 	utils.AviLog.Infof("Finished syncing all objects except virtualservices")
@@ -262,6 +275,14 @@ func (c *AviObjCache) MarkReference(vsCacheObj *AviVsCache) {
 		}
 	}
 
+	for _, objKey := range vsCacheObj.NSPolicyCollection {
+		if intf, found := c.NSPolicyCache.AviCacheGet(objKey); found {
+			if obj, ok := intf.(*AviNSPolicyCache); ok {
+				obj.HasReference = true
+			}
+		}
+	}
+
 	for _, objKey := range vsCacheObj.PGKeyCollection {
 		if intf, found := c.PgCache.AviCacheGet(objKey); found {
 			if obj, ok := intf.(*AviPGCache); ok {
@@ -300,7 +321,7 @@ func (c *AviObjCache) MarkReference(vsCacheObj *AviVsCache) {
 func (c *AviObjCache) DeleteUnmarked(childCollection []string) {
 
 	var dsKeys, vsVipKeys, httpKeys, sslKeys []NamespaceName
-	var pgKeys, poolKeys, l4Keys []NamespaceName
+	var pgKeys, poolKeys, l4Keys, nspKeys []NamespaceName
 	for _, objkey := range c.DSCache.AviGetAllKeys() {
 		intf, _ := c.DSCache.AviCacheGet(objkey)
 		if obj, ok := intf.(*AviDSCache); ok {
@@ -331,6 +352,16 @@ func (c *AviObjCache) DeleteUnmarked(childCollection []string) {
 		}
 	}
 
+	for _, objkey := range c.NSPolicyCache.AviGetAllKeys() {
+		intf, _ := c.NSPolicyCache.AviCacheGet(objkey)
+		if obj, ok := intf.(*AviNSPolicyCache); ok {
+			if obj.HasReference == false {
+				utils.AviLog.Infof("Reference Not found for network security policy: %s", objkey)
+				nspKeys = append(nspKeys, objkey)
+			}
+		}
+	}
+
 	for _, objkey := range c.PgCache.AviGetAllKeys() {
 		intf, _ := c.PgCache.AviCacheGet(objkey)
 		if obj, ok := intf.(*AviPGCache); ok {
@@ -386,6 +417,7 @@ func (c *AviObjCache) DeleteUnmarked(childCollection []string) {
 		PGKeyCollection:      pgKeys,
 		PoolKeyCollection:    poolKeys,
 		L4PolicyCollection:   l4Keys,
+		NSPolicyCollection:   nspKeys,
 		SNIChildCollection:   childCollection,
 	}
 	vsKey := NamespaceName{
@@ -604,14 +636,32 @@ func (c *AviObjCache) AviPopulateAllPools(client *clients.AviClient, cloud strin
 				pkiKey = NamespaceName{Namespace: lib.GetTenant(), Name: pkiName.(string)}
 			}
 		}
+		var persistenceKey NamespaceName
+		if pool.ApplicationPersistenceProfileRef != nil {
+			persistenceUuid := ExtractUuid(*pool.ApplicationPersistenceProfileRef, "applicationpersistenceprofile-.*.#")
+			persistenceName, found := c.PersistenceCache.AviCacheGetNameByUuid(persistenceUuid)
+			if found {
+				persistenceKey = NamespaceName{Namespace: lib.GetTenant(), Name: persistenceName.(string)}
+			}
+		}
+		var hmKey NamespaceName
+		for _, hmRef := range pool.HealthMonitorRefs {
+			hmUuid := ExtractUuid(hmRef, "healthmonitor-.*.#")
+			if hmName, found := c.HealthMonitorCache.AviCacheGetNameByUuid(hmUuid); found {
+				hmKey = NamespaceName{Namespace: lib.GetTenant(), Name: hmName.(string)}
+			}
+		}
 
 		poolCacheObj := AviPoolCache{
-			Name:                 *pool.Name,
-			Uuid:                 *pool.UUID,
-			CloudConfigCksum:     *pool.CloudConfigCksum,
-			PkiProfileCollection: pkiKey,
-			ServiceMetadataObj:   svc_mdata_obj,
-			LastModified:         *pool.LastModified,
+			Name:                         *pool.Name,
+			Uuid:                         *pool.UUID,
+			CloudConfigCksum:             *pool.CloudConfigCksum,
+			PkiProfileCollection:         pkiKey,
+			PersistenceProfileCollection: persistenceKey,
+			HealthMonitorCollection:      hmKey,
+			ServiceMetadataObj:           svc_mdata_obj,
+			LastModified:                 *pool.LastModified,
+			Servers:                      GetPoolServers(pool.Servers),
 		}
 		*poolData = append(*poolData, poolCacheObj)
 	}
@@ -734,7 +784,10 @@ func (c *AviObjCache) AviPopulateAllVSVips(client *clients.AviClient, cloud stri
 		var v6ip string
 		var networkNames []string
 		for _, vip := range vsvip.Vip {
-			vips = append(vips, *vip.IPAddress.Addr)
+			// V6 only vips do not have a V4 address
+			if vip.IPAddress != nil {
+				vips = append(vips, *vip.IPAddress.Addr)
+			}
 			if vip.FloatingIP != nil {
 				fips = append(fips, *vip.FloatingIP.Addr)
 			}
@@ -865,11 +918,7 @@ func (c *AviObjCache) AviPopulateAllDSs(client *clients.AviClient, cloud string,
 			Uuid:       *ds.UUID,
 			PoolGroups: pgs,
 		}
-		checksum := lib.DSChecksum(dsCacheObj.PoolGroups, ds.Markers, true)
-		if len(ds.Datascript) == 1 {
-			checksum += utils.Hash(*ds.Datascript[0].Script)
-		}
-		dsCacheObj.CloudConfigCksum = checksum
+		dsCacheObj.CloudConfigCksum = lib.DataScriptSetChecksum(dsCacheObj.PoolGroups, ds.Markers, ds.Datascript)
 		*DsData = append(*DsData, dsCacheObj)
 	}
 	if result.Next != "" {
@@ -1145,14 +1194,32 @@ func (c *AviObjCache) AviPopulateOnePoolCache(client *clients.AviClient,
 				pkiKey = NamespaceName{Namespace: lib.GetTenant(), Name: pkiName.(string)}
 			}
 		}
+		var persistenceKey NamespaceName
+		if pool.ApplicationPersistenceProfileRef != nil {
+			persistenceUuid := ExtractUuid(*pool.ApplicationPersistenceProfileRef, "applicationpersistenceprofile-.*.#")
+			persistenceName, found := c.PersistenceCache.AviCacheGetNameByUuid(persistenceUuid)
+			if found {
+				persistenceKey = NamespaceName{Namespace: lib.GetTenant(), Name: persistenceName.(string)}
+			}
+		}
+		var hmKey NamespaceName
+		for _, hmRef := range pool.HealthMonitorRefs {
+			hmUuid := ExtractUuid(hmRef, "healthmonitor-.*.#")
+			if hmName, found := c.HealthMonitorCache.AviCacheGetNameByUuid(hmUuid); found {
+				hmKey = NamespaceName{Namespace: lib.GetTenant(), Name: hmName.(string)}
+			}
+		}
 
 		poolCacheObj := AviPoolCache{
-			Name:                 *pool.Name,
-			Uuid:                 *pool.UUID,
-			CloudConfigCksum:     *pool.CloudConfigCksum,
-			PkiProfileCollection: pkiKey,
-			ServiceMetadataObj:   svc_mdata_obj,
-			LastModified:         *pool.LastModified,
+			Name:                         *pool.Name,
+			Uuid:                         *pool.UUID,
+			CloudConfigCksum:             *pool.CloudConfigCksum,
+			PkiProfileCollection:         pkiKey,
+			PersistenceProfileCollection: persistenceKey,
+			HealthMonitorCollection:      hmKey,
+			ServiceMetadataObj:           svc_mdata_obj,
+			LastModified:                 *pool.LastModified,
+			Servers:                      GetPoolServers(pool.Servers),
 		}
 		k := NamespaceName{Namespace: lib.GetTenant(), Name: *pool.Name}
 		c.PoolCache.AviCacheAdd(k, &poolCacheObj)
@@ -1210,11 +1277,7 @@ func (c *AviObjCache) AviPopulateOneVsDSCache(client *clients.AviClient,
 			Uuid:       *ds.UUID,
 			PoolGroups: pgs,
 		}
-		checksum := lib.DSChecksum(dsCacheObj.PoolGroups, ds.Markers, true)
-		if len(ds.Datascript) == 1 {
-			checksum += utils.Hash(*ds.Datascript[0].Script)
-		}
-		dsCacheObj.CloudConfigCksum = checksum
+		dsCacheObj.CloudConfigCksum = lib.DataScriptSetChecksum(dsCacheObj.PoolGroups, ds.Markers, ds.Datascript)
 		k := NamespaceName{Namespace: lib.GetTenant(), Name: *ds.Name}
 		c.DSCache.AviCacheAdd(k, &dsCacheObj)
 		utils.AviLog.Debugf("Adding ds to Cache during refresh %s", k)
@@ -1324,7 +1387,10 @@ func (c *AviObjCache) AviPopulateOneVsVipCache(client *clients.AviClient,
 		var v6ip string
 		var networkNames []string
 		for _, vip := range vsvip.Vip {
-			vips = append(vips, *vip.IPAddress.Addr)
+			// V6 only vips do not have a V4 address
+			if vip.IPAddress != nil {
+				vips = append(vips, *vip.IPAddress.Addr)
+			}
 			if vip.FloatingIP != nil {
 				fips = append(fips, *vip.FloatingIP.Addr)
 			}
@@ -1756,6 +1822,429 @@ func (c *AviObjCache) PopulateL4PolicySetToCache(client *clients.AviClient, clou
 	}
 }
 
+func (c *AviObjCache) AviPopulateAllNSPolicies(client *clients.AviClient, nspData *[]AviNSPolicyCache, nextPage ...NextPage) (*[]AviNSPolicyCache, int, error) {
+	var uri string
+	akoUser := lib.AKOUser
+
+	if len(nextPage) == 1 {
+		uri = nextPage[0].Next_uri
+	} else {
+		uri = "/api/networksecuritypolicy/?" + "&include_name=true" + "&created_by=" + akoUser + "&page_size=100"
+	}
+
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		utils.AviLog.Warnf("Get uri %v returned err for networksecuritypolicy %v", uri, err)
+		return nil, 0, err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	err = json.Unmarshal(result.Results, &elems)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to unmarshal networksecuritypolicy data, err: %v", err)
+		return nil, 0, err
+	}
+	for i := 0; i < len(elems); i++ {
+		nsp := models.NetworkSecurityPolicy{}
+		err = json.Unmarshal(elems[i], &nsp)
+		if err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal networksecuritypolicy data, err: %v", err)
+			continue
+		}
+		if nsp.Name == nil || nsp.UUID == nil {
+			utils.AviLog.Warnf("Incomplete network security policy data unmarshalled, %s", utils.Stringify(nsp))
+			continue
+		}
+		*nspData = append(*nspData, newNSPolicyCache(nsp))
+	}
+
+	if result.Next != "" {
+		// It has a next page, let's recursively call the same method.
+		next_uri := strings.Split(result.Next, "/api/networksecuritypolicy")
+		if len(next_uri) > 1 {
+			overrideUri := "/api/networksecuritypolicy" + next_uri[1]
+			nextPage := NextPage{Next_uri: overrideUri}
+			_, _, err := c.AviPopulateAllNSPolicies(client, nspData, nextPage)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return nspData, result.Count, nil
+}
+
+func (c *AviObjCache) PopulateNSPolicyToCache(client *clients.AviClient) {
+	var nspData []AviNSPolicyCache
+	_, count, err := c.AviPopulateAllNSPolicies(client, &nspData)
+	if err != nil || len(nspData) != count {
+		return
+	}
+	nspCacheData := c.NSPolicyCache.ShallowCopy()
+	for i, nspCacheObj := range nspData {
+		k := NamespaceName{Namespace: lib.GetTenant(), Name: nspCacheObj.Name}
+		utils.AviLog.Debugf("Adding key to network security policy cache :%s", utils.Stringify(nspCacheObj))
+		c.NSPolicyCache.AviCacheAdd(k, &nspData[i])
+		delete(nspCacheData, k)
+	}
+	// The data that is left in nspCacheData should be explicitly removed
+	for key := range nspCacheData {
+		utils.AviLog.Debugf("Deleting key from network security policy cache :%s", key)
+		c.NSPolicyCache.AviCacheDelete(key)
+	}
+}
+
+func (c *AviObjCache) AviPopulateOneNSPolicyCache(client *clients.AviClient,
+	cloud string, objName string) error {
+	uri := "/api/networksecuritypolicy?name=" + objName + "&created_by=" + lib.AKOUser
+
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		utils.AviLog.Warnf("Get uri %v returned err for networksecuritypolicy %v", uri, err)
+		return err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	err = json.Unmarshal(result.Results, &elems)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to unmarshal networksecuritypolicy data, err: %v", err)
+		return err
+	}
+	for i := 0; i < len(elems); i++ {
+		nsp := models.NetworkSecurityPolicy{}
+		err = json.Unmarshal(elems[i], &nsp)
+		if err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal networksecuritypolicy data, err: %v", err)
+			continue
+		}
+		if nsp.Name == nil || nsp.UUID == nil {
+			utils.AviLog.Warnf("Incomplete network security policy data unmarshalled, %s", utils.Stringify(nsp))
+			continue
+		}
+		//Only cache the network security policies that belong to this AKO.
+		if !strings.HasPrefix(*nsp.Name, lib.GetNamePrefix()) {
+			continue
+		}
+		nspCacheObj := newNSPolicyCache(nsp)
+		k := NamespaceName{Namespace: lib.GetTenant(), Name: *nsp.Name}
+		c.NSPolicyCache.AviCacheAdd(k, &nspCacheObj)
+		utils.AviLog.Infof("Adding network security policy to Cache during refresh %s", utils.Stringify(nspCacheObj))
+	}
+	return nil
+}
+
+func newNSPolicyCache(nsp models.NetworkSecurityPolicy) AviNSPolicyCache {
+	emptyIngestionMarkers := utils.AviObjectMarkers{}
+	nspCacheObj := AviNSPolicyCache{
+		Name:             *nsp.Name,
+		Tenant:           lib.GetTenant(),
+		Uuid:             *nsp.UUID,
+		CloudConfigCksum: lib.NetworkSecurityPolicyChecksum(GetNSPolicyAllowedCIDRs(nsp), emptyIngestionMarkers, nsp.Markers, true),
+	}
+	if nsp.LastModified != nil {
+		nspCacheObj.LastModified = *nsp.LastModified
+	}
+	return nspCacheObj
+}
+
+// GetNSPolicyAllowedCIDRs returns the CIDRs allowed by a network security policy managed by AKO, which
+// are the client IP prefixes outside of which the clients are denied.
+func GetNSPolicyAllowedCIDRs(nsp models.NetworkSecurityPolicy) []string {
+	var cidrs []string
+	for _, rule := range nsp.Rules {
+		if rule.Match == nil || rule.Match.ClientIP == nil {
+			continue
+		}
+		for _, prefix := range rule.Match.ClientIP.Prefixes {
+			if prefix.IPAddr == nil || prefix.IPAddr.Addr == nil || prefix.Mask == nil {
+				continue
+			}
+			cidrs = append(cidrs, *prefix.IPAddr.Addr+"/"+strconv.Itoa(int(*prefix.Mask)))
+		}
+	}
+	return cidrs
+}
+
+func (c *AviObjCache) AviPopulateAllPersistenceProfiles(client *clients.AviClient, persistenceData *[]AviPersistenceProfileCache, nextPage ...NextPage) (*[]AviPersistenceProfileCache, int, error) {
+	var uri string
+
+	if len(nextPage) == 1 {
+		uri = nextPage[0].Next_uri
+	} else {
+		// The persistence profiles do not have the created_by field, so the profiles are filtered by the name prefix.
+		uri = "/api/applicationpersistenceprofile/?" + "name.contains=" + lib.GetNamePrefix() + "&include_name=true" + "&page_size=100"
+	}
+
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		utils.AviLog.Warnf("Get uri %v returned err for applicationpersistenceprofile %v", uri, err)
+		return nil, 0, err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	err = json.Unmarshal(result.Results, &elems)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to unmarshal applicationpersistenceprofile data, err: %v", err)
+		return nil, 0, err
+	}
+	for i := 0; i < len(elems); i++ {
+		profile := models.ApplicationPersistenceProfile{}
+		err = json.Unmarshal(elems[i], &profile)
+		if err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal applicationpersistenceprofile data, err: %v", err)
+			continue
+		}
+		if profile.Name == nil || profile.UUID == nil {
+			utils.AviLog.Warnf("Incomplete persistence profile data unmarshalled, %s", utils.Stringify(profile))
+			continue
+		}
+		if !strings.HasPrefix(*profile.Name, lib.GetNamePrefix()) {
+			continue
+		}
+		*persistenceData = append(*persistenceData, newPersistenceProfileCache(profile))
+	}
+
+	if result.Next != "" {
+		// It has a next page, let's recursively call the same method.
+		next_uri := strings.Split(result.Next, "/api/applicationpersistenceprofile")
+		if len(next_uri) > 1 {
+			overrideUri := "/api/applicationpersistenceprofile" + next_uri[1]
+			nextPage := NextPage{Next_uri: overrideUri}
+			_, _, err := c.AviPopulateAllPersistenceProfiles(client, persistenceData, nextPage)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return persistenceData, result.Count, nil
+}
+
+func (c *AviObjCache) PopulatePersistenceProfilesToCache(client *clients.AviClient) {
+	var persistenceData []AviPersistenceProfileCache
+	if _, _, err := c.AviPopulateAllPersistenceProfiles(client, &persistenceData); err != nil {
+		return
+	}
+	persistenceCacheData := c.PersistenceCache.ShallowCopy()
+	for i, persistenceCacheObj := range persistenceData {
+		k := NamespaceName{Namespace: lib.GetTenant(), Name: persistenceCacheObj.Name}
+		utils.AviLog.Debugf("Adding key to persistence profile cache :%s", utils.Stringify(persistenceCacheObj))
+		c.PersistenceCache.AviCacheAdd(k, &persistenceData[i])
+		delete(persistenceCacheData, k)
+	}
+	// The data that is left in persistenceCacheData should be explicitly removed
+	for key := range persistenceCacheData {
+		utils.AviLog.Debugf("Deleting key from persistence profile cache :%s", key)
+		c.PersistenceCache.AviCacheDelete(key)
+	}
+}
+
+func (c *AviObjCache) AviPopulateOnePersistenceProfileCache(client *clients.AviClient,
+	cloud string, objName string) error {
+	uri := "/api/applicationpersistenceprofile?name=" + objName
+
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		utils.AviLog.Warnf("Get uri %v returned err for applicationpersistenceprofile %v", uri, err)
+		return err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	err = json.Unmarshal(result.Results, &elems)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to unmarshal applicationpersistenceprofile data, err: %v", err)
+		return err
+	}
+	for i := 0; i < len(elems); i++ {
+		profile := models.ApplicationPersistenceProfile{}
+		err = json.Unmarshal(elems[i], &profile)
+		if err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal applicationpersistenceprofile data, err: %v", err)
+			continue
+		}
+		if profile.Name == nil || profile.UUID == nil {
+			utils.AviLog.Warnf("Incomplete persistence profile data unmarshalled, %s", utils.Stringify(profile))
+			continue
+		}
+		//Only cache the persistence profiles that belong to this AKO.
+		if !strings.HasPrefix(*profile.Name, lib.GetNamePrefix()) {
+			continue
+		}
+		persistenceCacheObj := newPersistenceProfileCache(profile)
+		k := NamespaceName{Namespace: lib.GetTenant(), Name: *profile.Name}
+		c.PersistenceCache.AviCacheAdd(k, &persistenceCacheObj)
+		utils.AviLog.Infof("Adding persistence profile to Cache during refresh %s", utils.Stringify(persistenceCacheObj))
+	}
+	return nil
+}
+
+func newPersistenceProfileCache(profile models.ApplicationPersistenceProfile) AviPersistenceProfileCache {
+	var timeout int32
+	if profile.IPPersistenceProfile != nil && profile.IPPersistenceProfile.IPPersistentTimeout != nil {
+		timeout = *profile.IPPersistenceProfile.IPPersistentTimeout
+	}
+	emptyIngestionMarkers := utils.AviObjectMarkers{}
+	persistenceCacheObj := AviPersistenceProfileCache{
+		Name:             *profile.Name,
+		Tenant:           lib.GetTenant(),
+		Uuid:             *profile.UUID,
+		CloudConfigCksum: lib.PersistenceProfileChecksum(timeout, emptyIngestionMarkers, profile.Markers, true),
+	}
+	if profile.LastModified != nil {
+		persistenceCacheObj.LastModified = *profile.LastModified
+	}
+	return persistenceCacheObj
+}
+
+func (c *AviObjCache) AviPopulateAllHealthMonitors(client *clients.AviClient, hmData *[]AviHealthMonitorCache, nextPage ...NextPage) (*[]AviHealthMonitorCache, int, error) {
+	var uri string
+
+	if len(nextPage) == 1 {
+		uri = nextPage[0].Next_uri
+	} else {
+		// The health monitors do not have the created_by field, so the health monitors are filtered by the name prefix.
+		uri = "/api/healthmonitor/?" + "name.contains=" + lib.GetNamePrefix() + "&include_name=true" + "&page_size=100"
+	}
+
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		utils.AviLog.Warnf("Get uri %v returned err for healthmonitor %v", uri, err)
+		return nil, 0, err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	err = json.Unmarshal(result.Results, &elems)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to unmarshal healthmonitor data, err: %v", err)
+		return nil, 0, err
+	}
+	for i := 0; i < len(elems); i++ {
+		hm := models.HealthMonitor{}
+		err = json.Unmarshal(elems[i], &hm)
+		if err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal healthmonitor data, err: %v", err)
+			continue
+		}
+		if hm.Name == nil || hm.UUID == nil {
+			utils.AviLog.Warnf("Incomplete health monitor data unmarshalled, %s", utils.Stringify(hm))
+			continue
+		}
+		if !strings.HasPrefix(*hm.Name, lib.GetNamePrefix()) {
+			continue
+		}
+		*hmData = append(*hmData, newHealthMonitorCache(hm))
+	}
+
+	if result.Next != "" {
+		// It has a next page, let's recursively call the same method.
+		next_uri := strings.Split(result.Next, "/api/healthmonitor")
+		if len(next_uri) > 1 {
+			overrideUri := "/api/healthmonitor" + next_uri[1]
+			nextPage := NextPage{Next_uri: overrideUri}
+			_, _, err := c.AviPopulateAllHealthMonitors(client, hmData, nextPage)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return hmData, result.Count, nil
+}
+
+func (c *AviObjCache) PopulateHealthMonitorsToCache(client *clients.AviClient) {
+	var hmData []AviHealthMonitorCache
+	if _, _, err := c.AviPopulateAllHealthMonitors(client, &hmData); err != nil {
+		return
+	}
+	hmCacheData := c.HealthMonitorCache.ShallowCopy()
+	for i, hmCacheObj := range hmData {
+		k := NamespaceName{Namespace: lib.GetTenant(), Name: hmCacheObj.Name}
+		utils.AviLog.Debugf("Adding key to health monitor cache :%s", utils.Stringify(hmCacheObj))
+		c.HealthMonitorCache.AviCacheAdd(k, &hmData[i])
+		delete(hmCacheData, k)
+	}
+	// The data that is left in hmCacheData should be explicitly removed
+	for key := range hmCacheData {
+		utils.AviLog.Debugf("Deleting key from health monitor cache :%s", key)
+		c.HealthMonitorCache.AviCacheDelete(key)
+	}
+}
+
+func (c *AviObjCache) AviPopulateOneHealthMonitorCache(client *clients.AviClient,
+	cloud string, objName string) error {
+	uri := "/api/healthmonitor?name=" + objName
+
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		utils.AviLog.Warnf("Get uri %v returned err for healthmonitor %v", uri, err)
+		return err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	err = json.Unmarshal(result.Results, &elems)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to unmarshal healthmonitor data, err: %v", err)
+		return err
+	}
+	for i := 0; i < len(elems); i++ {
+		hm := models.HealthMonitor{}
+		err = json.Unmarshal(elems[i], &hm)
+		if err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal healthmonitor data, err: %v", err)
+			continue
+		}
+		if hm.Name == nil || hm.UUID == nil {
+			utils.AviLog.Warnf("Incomplete health monitor data unmarshalled, %s", utils.Stringify(hm))
+			continue
+		}
+		//Only cache the health monitors that belong to this AKO.
+		if !strings.HasPrefix(*hm.Name, lib.GetNamePrefix()) {
+			continue
+		}
+		hmCacheObj := newHealthMonitorCache(hm)
+		k := NamespaceName{Namespace: lib.GetTenant(), Name: *hm.Name}
+		c.HealthMonitorCache.AviCacheAdd(k, &hmCacheObj)
+		utils.AviLog.Infof("Adding health monitor to Cache during refresh %s", utils.Stringify(hmCacheObj))
+	}
+	return nil
+}
+
+func newHealthMonitorCache(hm models.HealthMonitor) AviHealthMonitorCache {
+	hmCacheObj := AviHealthMonitorCache{
+		Name:             *hm.Name,
+		Tenant:           lib.GetTenant(),
+		Uuid:             *hm.UUID,
+		CloudConfigCksum: GetHealthMonitorChecksum(hm),
+	}
+	if hm.LastModified != nil {
+		hmCacheObj.LastModified = *hm.LastModified
+	}
+	return hmCacheObj
+}
+
+// GetHealthMonitorChecksum returns the checksum of a health monitor created by AKO from a readiness probe.
+func GetHealthMonitorChecksum(hm models.HealthMonitor) uint32 {
+	var hmType, httpRequest string
+	var monitorPort, sendInterval, receiveTimeout, successfulChecks, failedChecks int32
+	if hm.Type != nil {
+		hmType = *hm.Type
+	}
+	if hm.HTTPMonitor != nil && hm.HTTPMonitor.HTTPRequest != nil {
+		httpRequest = *hm.HTTPMonitor.HTTPRequest
+	} else if hm.HTTPSMonitor != nil && hm.HTTPSMonitor.HTTPRequest != nil {
+		httpRequest = *hm.HTTPSMonitor.HTTPRequest
+	}
+	if hm.MonitorPort != nil {
+		monitorPort = *hm.MonitorPort
+	}
+	if hm.SendInterval != nil {
+		sendInterval = *hm.SendInterval
+	}
+	if hm.ReceiveTimeout != nil {
+		receiveTimeout = *hm.ReceiveTimeout
+	}
+	if hm.SuccessfulChecks != nil {
+		successfulChecks = *hm.SuccessfulChecks
+	}
+	if hm.FailedChecks != nil {
+		failedChecks = *hm.FailedChecks
+	}
+	emptyIngestionMarkers := utils.AviObjectMarkers{}
+	return lib.HealthMonitorChecksum(hmType, httpRequest, monitorPort, sendInterval, receiveTimeout, successfulChecks, failedChecks,
+		emptyIngestionMarkers, hm.Markers, true)
+}
+
 func (c *AviObjCache) AviObjVrfCachePopulate(client *clients.AviClient, cloud string) error {
 	if lib.GetDisableStaticRoute() {
 		utils.AviLog.Debugf("Static route sync disabled, skipping vrf cache population")
@@ -1792,11 +2281,13 @@ func (c *AviObjCache) AviObjVrfCachePopulate(client *clients.AviClient, cloud st
 		}
 
 		vrfName := *vrf.Name
-		checksum := lib.VrfChecksum(vrfName, vrf.StaticRoutes)
+		staticRoutes := lib.GetStaticRouteChecksums(vrf.StaticRoutes)
 		vrfCacheObj := AviVrfCache{
 			Name:             vrfName,
 			Uuid:             *vrf.UUID,
-			CloudConfigCksum: checksum,
+			CloudConfigCksum:   lib.StaticRoutesChecksum(staticRoutes),
+			StaticRoutes:       staticRoutes,
+			LegacyStaticRoutes: lib.GetLegacyStaticRouteIDs(vrf.StaticRoutes),
 		}
 		// set the vrf context. The result shouldn't be more than 1.
 		lib.SetVrfUuid(*vrf.UUID)
@@ -1878,6 +2369,7 @@ func (c *AviObjCache) AviObjVSCachePopulate(client *clients.AviClient, cloud str
 				var dsKeys []NamespaceName
 				var httpKeys []NamespaceName
 				var l4Keys []NamespaceName
+				var nspKeys []NamespaceName
 				var poolgroupKeys []NamespaceName
 				var poolKeys []NamespaceName
 				var sharedVsOrL4 bool
@@ -1984,6 +2476,13 @@ func (c *AviObjCache) AviObjVSCachePopulate(client *clients.AviClient, cloud str
 						}
 					}
 				}
+				if vs["network_security_policy_ref"] != nil {
+					nspUuid := ExtractUuid(vs["network_security_policy_ref"].(string), "networksecuritypolicy-.*.#")
+					nspName, foundNsp := c.NSPolicyCache.AviCacheGetNameByUuid(nspUuid)
+					if foundNsp {
+						nspKeys = append(nspKeys, NamespaceName{Namespace: lib.GetTenant(), Name: nspName.(string)})
+					}
+				}
 				if vs["http_policies"] != nil {
 					for _, http_intf := range vs["http_policies"].([]interface{}) {
 						httpmap, ok := http_intf.(map[string]interface{})
@@ -2031,6 +2530,7 @@ func (c *AviObjCache) AviObjVSCachePopulate(client *clients.AviClient, cloud str
 					ParentVSRef:          parentVSKey,
 					ServiceMetadataObj:   svc_mdata_obj,
 					L4PolicyCollection:   l4Keys,
+					NSPolicyCollection:   nspKeys,
 					LastModified:         vs["_last_modified"].(string),
 				}
 				if val, ok := vs["enable_rhi"]; ok {
@@ -2129,6 +2629,7 @@ func (c *AviObjCache) AviObjOneVSCachePopulate(client *clients.AviClient, cloud
 				var poolgroupKeys []NamespaceName
 				var poolKeys []NamespaceName
 				var l4Keys []NamespaceName
+				var nspKeys []NamespaceName
 
 				// Populate the VSVIP cache
 				if vs["vsvip_ref"] != nil {
@@ -2227,6 +2728,13 @@ func (c *AviObjCache) AviObjOneVSCachePopulate(client *clients.AviClient, cloud
 						}
 					}
 				}
+				if vs["network_security_policy_ref"] != nil {
+					nspUuid := ExtractUuid(vs["network_security_policy_ref"].(string), "networksecuritypolicy-.*.#")
+					nspName, foundNsp := c.NSPolicyCache.AviCacheGetNameByUuid(nspUuid)
+					if foundNsp {
+						nspKeys = append(nspKeys, NamespaceName{Namespace: lib.GetTenant(), Name: nspName.(string)})
+					}
+				}
 				if vs["http_policies"] != nil {
 					for _, http_intf := range vs["http_policies"].([]interface{}) {
 						// find the sslkey name from the ssl key cache
@@ -2264,6 +2772,7 @@ func (c *AviObjCache) AviObjOneVSCachePopulate(client *clients.AviClient, cloud
 					SNIChildCollection:   sni_child_collection,
 					ParentVSRef:          parentVSKey,
 					L4PolicyCollection:   l4Keys,
+					NSPolicyCollection:   nspKeys,
 					ServiceMetadataObj:   svc_mdata_obj,
 				}
 				if val, ok := vs["enable_rhi"]; ok {
@@ -2720,6 +3229,10 @@ func ConfigureSeGroupLabels(client *clients.AviClient, seGroup *models.ServiceEn
 	SetAdminTenant := session.SetTenant(lib.GetAdminTenant())
 	SetTenant := session.SetTenant(lib.GetTenant())
 	if len(labels) == 0 {
+		if lib.IsDryRunEnabled() {
+			utils.AviLog.Infof("Dry run, skipping setting labels: %v on Service Engine Group :%v", utils.Stringify(lib.GetLabels()), segName)
+			return nil
+		}
 		uri := "/api/serviceenginegroup/" + *seGroup.UUID
 		seGroup.Labels = lib.GetLabels()
 		response := models.ServiceEngineGroupAPIResponse{}
@@ -2756,6 +3269,10 @@ func DeConfigureSeGroupLabels() {
 	if len(lib.GetLabels()) == 0 {
 		return
 	}
+	if lib.IsDryRunEnabled() {
+		utils.AviLog.Infof("Dry run, skipping removing labels: %v from the Service Engine Group", utils.Stringify(lib.GetLabels()))
+		return
+	}
 	segName := lib.GetSEGName()
 	clients := SharedAVIClients()
 	aviClientLen := lib.GetshardSize()
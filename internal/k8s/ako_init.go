@@ -49,33 +49,49 @@ import (
 )
 
 func PopulateCache() error {
-	var parentKeys []avicache.NamespaceName
 	var err error
 	avi_rest_client_pool := avicache.SharedAVIClients()
 	avi_obj_cache := avicache.SharedAviObjCache()
 	// Randomly pickup a client.
 	if avi_rest_client_pool != nil && len(avi_rest_client_pool.AviClient) > 0 {
-		_, parentKeys, err = avi_obj_cache.AviObjCachePopulate(avi_rest_client_pool.AviClient, utils.CtrlVersion, utils.CloudName)
+		_, _, err = avi_obj_cache.AviObjCachePopulate(avi_rest_client_pool.AviClient, utils.CtrlVersion, utils.CloudName)
 		if err != nil {
 			utils.AviLog.Warnf("failed to populate avi cache with error: %v", err.Error())
 			return err
 		}
-		if lib.GetDeleteConfigMap() {
-			go SetDeleteSyncChannel()
-			deleteAviObjects(parentKeys, avi_obj_cache, avi_rest_client_pool)
-		}
 		if err = avicache.SetControllerClusterUUID(avi_rest_client_pool); err != nil {
 			utils.AviLog.Warnf("Failed to set the controller cluster uuid with error: %v", err)
 		}
 	}
 
+	// A standby AKO replica does not make any changes on the controller, the clean up is done
+	// once it becomes the leader.
+	if !lib.AKOControlConfig().IsLeader() {
+		utils.AviLog.Infof("AKO is not the leader, skipping clean up of stale objects")
+		return nil
+	}
+	CleanupAviObjects()
+	return nil
+}
+
+// CleanupAviObjects deletes all the AKO created objects if the deleteConfig flag is set, and
+// the stale objects otherwise.
+func CleanupAviObjects() {
+	avi_rest_client_pool := avicache.SharedAVIClients()
+	avi_obj_cache := avicache.SharedAviObjCache()
+	if lib.GetDeleteConfigMap() && avi_rest_client_pool != nil && len(avi_rest_client_pool.AviClient) > 0 {
+		go SetDeleteSyncChannel()
+		parentKeys := avi_obj_cache.VsCacheMeta.AviCacheGetAllParentVSKeys()
+		deleteAviObjects(parentKeys, avi_obj_cache, avi_rest_client_pool)
+	}
+
 	// Delete Stale objects by deleting model for dummy VS
 	aviclient := avicache.SharedAVIClients()
 	restlayer := rest.NewRestOperations(avi_obj_cache, aviclient)
 	staleVSKey := lib.GetTenant() + "/" + lib.DummyVSForStaleData
 	if _, err := lib.IsClusterNameValid(); err != nil {
 		utils.AviLog.Errorf("AKO cluster name is invalid.")
-		return nil
+		return
 	}
 	if aviclient != nil && len(aviclient.AviClient) > 0 {
 		utils.AviLog.Infof("Starting clean up of stale objects")
@@ -97,8 +113,6 @@ func PopulateCache() error {
 		close(lib.ConfigDeleteSyncChan)
 		lib.ConfigDeleteSyncChan = nil
 	}
-
-	return nil
 }
 
 func deleteAviObjects(parentVSKeys []avicache.NamespaceName, avi_obj_cache *avicache.AviObjCache, avi_rest_client_pool *utils.AviRestClientPool) {
@@ -467,6 +481,9 @@ func (c *AviController) InitController(informers K8sinformers, registeredInforme
 	// set up signals so we handle the first shutdown signal gracefully
 	var worker *utils.FullSyncThread
 	var tokenWorker *utils.FullSyncThread
+	var podReadinessWorker *utils.FullSyncThread
+	var runtimeStatusWorker *utils.FullSyncThread
+	var driftWorker *utils.FullSyncThread
 	informersArg := make(map[string]interface{})
 	informersArg[utils.INFORMERS_OPENSHIFT_CLIENT] = informers.OshiftClient
 	if lib.GetNamespaceToSync() != "" {
@@ -530,6 +547,12 @@ func (c *AviController) InitController(informers K8sinformers, registeredInforme
 	}
 	c.Start(stopCh)
 
+	// A standby AKO replica keeps the informers and the Avi object cache warm, and proceeds
+	// with the sync only after it becomes the leader.
+	if !c.waitForLeadership(ctrlCh) {
+		return
+	}
+
 	// once the l3 cache is populated, we can call the updatestatus functions from here
 	restlayer := rest.NewRestOperations(avicache.SharedAviObjCache(), avicache.SharedAVIClients())
 	restlayer.SyncObjectStatuses()
@@ -573,6 +596,24 @@ func (c *AviController) InitController(informers K8sinformers, registeredInforme
 			tokenWorker.SyncFunction = c.RefreshAuthToken
 			go tokenWorker.Run()
 		}
+
+		if lib.IsPodReadinessGateEnabled() {
+			podReadinessWorker = utils.NewFullSyncThread(lib.GetPodReadinessGateSyncInterval())
+			podReadinessWorker.SyncFunction = status.SyncPodReadinessGates
+			go podReadinessWorker.Run()
+		}
+
+		if lib.IsRuntimeStatusEnabled() {
+			runtimeStatusWorker = utils.NewFullSyncThread(lib.GetRuntimeStatusSyncInterval())
+			runtimeStatusWorker.SyncFunction = status.SyncRuntimeStatuses
+			go runtimeStatusWorker.Run()
+		}
+
+		if driftScanInterval := lib.GetDriftScanInterval(); driftScanInterval > 0 {
+			driftWorker = utils.NewFullSyncThread(driftScanInterval)
+			driftWorker.SyncFunction = ScanDrift
+			go driftWorker.Run()
+		}
 	}
 	c.SetupEventHandlers(informers)
 	if lib.DisableSync {
@@ -609,6 +650,15 @@ LABEL:
 	if worker != nil {
 		worker.Shutdown()
 	}
+	if podReadinessWorker != nil {
+		podReadinessWorker.Shutdown()
+	}
+	if runtimeStatusWorker != nil {
+		runtimeStatusWorker.Shutdown()
+	}
+	if driftWorker != nil {
+		driftWorker.Shutdown()
+	}
 
 	ingestionQueue.StopWorkers(stopCh)
 	graphQueue.StopWorkers(stopCh)
@@ -647,7 +697,7 @@ func (c *AviController) addIndexers() {
 				if !ok {
 					return []string{}, nil
 				}
-				if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+				if service.Spec.Type == corev1.ServiceTypeLoadBalancer && lib.IsAviLoadBalancerClass(service) {
 					if val, ok := service.Annotations[lib.InfraSettingNameAnnotation]; ok && val != "" {
 						return []string{val}, nil
 					}
@@ -676,6 +726,7 @@ func (c *AviController) addIndexers() {
 }
 
 func (c *AviController) FullSync() {
+	defer utils.ObserveFullSyncDuration(utils.FullSyncAvi, time.Now())
 
 	avi_rest_client_pool := avicache.SharedAVIClients()
 	avi_obj_cache := avicache.SharedAviObjCache()
@@ -715,6 +766,7 @@ func (c *AviController) FullSyncK8s() error {
 		utils.AviLog.Infof("Sync disabled, skipping full sync")
 		return nil
 	}
+	defer utils.ObserveFullSyncDuration(utils.FullSyncK8s, time.Now())
 	sharedQueue := utils.SharedWorkQueue().GetQueueByName(utils.GraphLayer)
 	var vrfModelName string
 	if lib.GetDisableStaticRoute() && !lib.IsNodePortMode() {
@@ -752,7 +804,7 @@ func (c *AviController) FullSyncK8s() error {
 				timeout <- true
 			}()
 			select {
-			case <-lib.StaticRouteSyncChan:
+			case <-lib.GetStaticRouteSyncChan():
 				utils.AviLog.Infof("Processing done for VRF")
 			case <-timeout:
 				utils.AviLog.Warnf("Timed out while waiting for rest layer to respond, moving on with bootup")
@@ -988,6 +1040,39 @@ func (c *AviController) FullSyncK8s() error {
 				nodes.DequeueIngestion(key, true)
 			}
 		}
+		if lib.IsIstioEnabled() && lib.AKOControlConfig().IstioCRDInformers() != nil {
+			istioInformers := lib.AKOControlConfig().IstioCRDInformers()
+			// VirtualServices and DestinationRules are processed as part of the Gateways.
+			vsObjs, err := istioInformers.VirtualServiceInformer.Lister().List(labels.Set(nil).AsSelector())
+			if err != nil {
+				utils.AviLog.Errorf("Unable to retrieve the istio virtualservices during full sync: %s", err)
+				return err
+			}
+			for _, vsObj := range vsObjs {
+				objects.SharedResourceVerInstanceLister().Save(lib.IstioVirtualService+"/"+utils.ObjKey(vsObj), vsObj.ResourceVersion)
+			}
+			drObjs, err := istioInformers.DestinationRuleInformer.Lister().List(labels.Set(nil).AsSelector())
+			if err != nil {
+				utils.AviLog.Errorf("Unable to retrieve the istio destinationrules during full sync: %s", err)
+				return err
+			}
+			for _, drObj := range drObjs {
+				objects.SharedResourceVerInstanceLister().Save(lib.IstioDestinationRule+"/"+utils.ObjKey(drObj), drObj.ResourceVersion)
+			}
+			gatewayObjs, err := istioInformers.GatewayInformer.Lister().List(labels.Set(nil).AsSelector())
+			if err != nil {
+				utils.AviLog.Errorf("Unable to retrieve the istio gateways during full sync: %s", err)
+				return err
+			}
+			for _, gatewayObj := range gatewayObjs {
+				if lib.IsNamespaceBlocked(gatewayObj.Namespace) || !utils.CheckIfNamespaceAccepted(gatewayObj.Namespace) {
+					continue
+				}
+				key := lib.IstioGateway + "/" + utils.ObjKey(gatewayObj)
+				objects.SharedResourceVerInstanceLister().Save(key, gatewayObj.ResourceVersion)
+				nodes.DequeueIngestion(key, true)
+			}
+		}
 		if utils.IsMultiClusterIngressEnabled() {
 			mciObjs, err := utils.GetInformers().MultiClusterIngressInformer.Lister().MultiClusterIngresses(metav1.NamespaceAll).List(labels.Set(nil).AsSelector())
 			if err != nil {
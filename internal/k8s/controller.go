@@ -20,6 +20,7 @@ import (
 	"sync"
 
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/status"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
@@ -27,6 +28,7 @@ import (
 	routev1 "github.com/openshift/api/route/v1"
 	oshiftclient "github.com/openshift/client-go/route/clientset/versioned"
 	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -46,9 +48,12 @@ var ctrlonce sync.Once
 // +kubebuilder:rbac:groups=nsx.vmware.com,resources=namespacenetworkinfos;namespacenetworkinfos/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=networking.x-k8s.io,resources=gateways;gateways/status,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=networking.x-k8s.io,resources=gatewayclasses;gatewayclasses/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=networking.x-k8s.io,resources=httproutes;httproutes/status;tcproutes;tcproutes/status,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups=core,resources=services;services/status,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods/status,verbs=update;patch
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
 
@@ -143,9 +148,11 @@ func isIngressUpdated(oldIngress, newIngress *networkingv1.Ingress) bool {
 	oldAnnotation := oldIngress.DeepCopy().Annotations
 	delete(oldAnnotation, lib.VSAnnotation)
 	delete(oldAnnotation, lib.ControllerAnnotation)
+	delete(oldAnnotation, lib.RuntimeConditionsAnnotation)
 	newAnnotation := newIngress.DeepCopy().Annotations
 	delete(newAnnotation, lib.VSAnnotation)
 	delete(newAnnotation, lib.ControllerAnnotation)
+	delete(newAnnotation, lib.RuntimeConditionsAnnotation)
 
 	oldAnnotationHash := utils.Hash(utils.Stringify(oldAnnotation))
 	newAnnotationHash := utils.Hash(utils.Stringify(newAnnotation))
@@ -537,6 +544,42 @@ func AddPodEventHandler(numWorkers uint32, c *AviController) cache.ResourceEvent
 	return podEventHandler
 }
 
+// AddDrainPodEventHandler syncs the services of the pool servers being drained for a pod once the pod is deleted,
+// so that the servers are removed from the pools without waiting for the drain timeout.
+func AddDrainPodEventHandler(numWorkers uint32, c *AviController) cache.ResourceEventHandler {
+	podEventHandler := cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					utils.AviLog.Errorf("couldn't get object from tombstone %#v", obj)
+					return
+				}
+				pod, ok = tombstone.Obj.(*corev1.Pod)
+				if !ok {
+					utils.AviLog.Errorf("Tombstone contained object that is not an Pod: %#v", obj)
+					return
+				}
+			}
+			namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(pod))
+			if lib.IsNamespaceBlocked(namespace) {
+				return
+			}
+			for _, svc := range nodes.GetDrainingPodServices(utils.ObjKey(pod)) {
+				key := utils.Endpoints + "/" + svc
+				bkt := utils.Bkt(namespace, numWorkers)
+				c.workqueue[bkt].AddRateLimited(key)
+				utils.AviLog.Debugf("key: %s, msg: pod %s of the drained servers is deleted", key, utils.ObjKey(pod))
+			}
+		},
+	}
+	return podEventHandler
+}
+
 func (c *AviController) SetupEventHandlers(k8sinfo K8sinformers) {
 	mcpQueue := utils.SharedWorkQueue().GetQueueByName(utils.ObjectIngestionLayer)
 	c.workqueue = mcpQueue.Workqueue
@@ -606,6 +649,82 @@ func (c *AviController) SetupEventHandlers(k8sinfo K8sinformers) {
 		},
 	}
 
+	// EndpointSlice events are published with the Endpoints key of the owning service, so that the
+	// service is processed the same way as for an Endpoints event.
+	epSliceEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			epSlice := obj.(*discovery.EndpointSlice)
+			svcName, ok := epSlice.Labels[discovery.LabelServiceName]
+			if !ok {
+				return
+			}
+			key := utils.Endpoints + "/" + epSlice.Namespace + "/" + svcName
+			if lib.IsNamespaceBlocked(epSlice.Namespace) {
+				utils.AviLog.Debugf("key: %s, msg: EndpointSlice Add event: Namespace: %s didn't qualify filter", key, epSlice.Namespace)
+				return
+			}
+			bkt := utils.Bkt(epSlice.Namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+			utils.AviLog.Debugf("key: %s, msg: ADD", key)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			epSlice, ok := obj.(*discovery.EndpointSlice)
+			if !ok {
+				// endpointslice was deleted but its final state is unrecorded.
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					utils.AviLog.Errorf("couldn't get object from tombstone %#v", obj)
+					return
+				}
+				epSlice, ok = tombstone.Obj.(*discovery.EndpointSlice)
+				if !ok {
+					utils.AviLog.Errorf("Tombstone contained object that is not an EndpointSlice: %#v", obj)
+					return
+				}
+			}
+			svcName, ok := epSlice.Labels[discovery.LabelServiceName]
+			if !ok {
+				return
+			}
+			key := utils.Endpoints + "/" + epSlice.Namespace + "/" + svcName
+			if lib.IsNamespaceBlocked(epSlice.Namespace) {
+				utils.AviLog.Debugf("key: %s, msg: EndpointSlice Delete event: Namespace: %s didn't qualify filter", key, epSlice.Namespace)
+				return
+			}
+			bkt := utils.Bkt(epSlice.Namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+			utils.AviLog.Debugf("key: %s, msg: DELETE", key)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if c.DisableSync {
+				return
+			}
+			oepSlice := old.(*discovery.EndpointSlice)
+			cepSlice := cur.(*discovery.EndpointSlice)
+			if reflect.DeepEqual(cepSlice.Endpoints, oepSlice.Endpoints) && reflect.DeepEqual(cepSlice.Ports, oepSlice.Ports) {
+				return
+			}
+			svcName, ok := cepSlice.Labels[discovery.LabelServiceName]
+			if !ok {
+				return
+			}
+			key := utils.Endpoints + "/" + cepSlice.Namespace + "/" + svcName
+			if lib.IsNamespaceBlocked(cepSlice.Namespace) {
+				utils.AviLog.Debugf("key: %s, msg: EndpointSlice Update event: Namespace: %s didn't qualify filter", key, cepSlice.Namespace)
+				return
+			}
+			bkt := utils.Bkt(cepSlice.Namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+			utils.AviLog.Debugf("key: %s, msg: UPDATE", key)
+		},
+	}
+
 	svcEventHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if c.DisableSync {
@@ -750,7 +869,11 @@ func (c *AviController) SetupEventHandlers(k8sinfo K8sinformers) {
 		},
 	}
 
-	c.informers.EpInformer.Informer().AddEventHandler(epEventHandler)
+	if c.informers.EpSlicesInformer != nil {
+		c.informers.EpSlicesInformer.Informer().AddEventHandler(epSliceEventHandler)
+	} else {
+		c.informers.EpInformer.Informer().AddEventHandler(epEventHandler)
+	}
 
 	c.informers.ServiceInformer.Informer().AddEventHandler(svcEventHandler)
 
@@ -1172,6 +1295,11 @@ func (c *AviController) SetupEventHandlers(k8sinfo K8sinformers) {
 	// Add CRD handlers HostRule/HTTPRule/AviInfraSettings
 	c.SetupAKOCRDEventHandlers(numWorkers)
 
+	// Add Istio Gateway/VirtualService/DestinationRule handlers
+	if lib.IsIstioEnabled() && !lib.GetAdvancedL4() && lib.AKOControlConfig().IstioCRDInformers() != nil {
+		c.SetupIstioCRDEventHandlers(numWorkers)
+	}
+
 	// Add MultiClusterIngress and ServiceImport CRD event handlers
 	if utils.IsMultiClusterIngressEnabled() {
 		c.SetupMultiClusterIngressEventHandlers(numWorkers)
@@ -1189,6 +1317,9 @@ func (c *AviController) SetupEventHandlers(k8sinfo K8sinformers) {
 	if lib.GetServiceType() == lib.NodePortLocal {
 		podEventHandler := AddPodEventHandler(numWorkers, c)
 		c.informers.PodInformer.Informer().AddEventHandler(podEventHandler)
+	} else if lib.IsServerDrainEnabled() && c.informers.PodInformer != nil {
+		podEventHandler := AddDrainPodEventHandler(numWorkers, c)
+		c.informers.PodInformer.Informer().AddEventHandler(podEventHandler)
 	}
 }
 
@@ -1217,19 +1348,25 @@ func checkAviSecretUpdateAndShutdown(secret *corev1.Secret) bool {
 
 func (c *AviController) Start(stopCh <-chan struct{}) {
 	go c.informers.ServiceInformer.Informer().Run(stopCh)
-	go c.informers.EpInformer.Informer().Run(stopCh)
 
 	informersList := []cache.InformerSynced{
-		c.informers.EpInformer.Informer().HasSynced,
 		c.informers.ServiceInformer.Informer().HasSynced,
 	}
 
+	if c.informers.EpSlicesInformer != nil {
+		go c.informers.EpSlicesInformer.Informer().Run(stopCh)
+		informersList = append(informersList, c.informers.EpSlicesInformer.Informer().HasSynced)
+	} else {
+		go c.informers.EpInformer.Informer().Run(stopCh)
+		informersList = append(informersList, c.informers.EpInformer.Informer().HasSynced)
+	}
+
 	if !lib.AviSecretInitialized {
 		go c.informers.SecretInformer.Informer().Run(stopCh)
 		informersList = append(informersList, c.informers.SecretInformer.Informer().HasSynced)
 	}
 
-	if lib.GetServiceType() == lib.NodePortLocal {
+	if lib.GetServiceType() == lib.NodePortLocal || ((lib.IsIstioEnabled() || lib.IsServerDrainEnabled() || lib.IsPodReadinessGateEnabled() || lib.IsProbeHealthMonitorEnabled()) && c.informers.PodInformer != nil) {
 		go c.informers.PodInformer.Informer().Run(stopCh)
 		informersList = append(informersList, c.informers.PodInformer.Informer().HasSynced)
 	}
@@ -1254,6 +1391,10 @@ func (c *AviController) Start(stopCh <-chan struct{}) {
 			informersList = append(informersList, lib.AKOControlConfig().SvcAPIInformers().GatewayClassInformer.Informer().HasSynced)
 			go lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Informer().Run(stopCh)
 			informersList = append(informersList, lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Informer().HasSynced)
+			go lib.AKOControlConfig().SvcAPIInformers().HTTPRouteInformer.Informer().Run(stopCh)
+			informersList = append(informersList, lib.AKOControlConfig().SvcAPIInformers().HTTPRouteInformer.Informer().HasSynced)
+			go lib.AKOControlConfig().SvcAPIInformers().TCPRouteInformer.Informer().Run(stopCh)
+			informersList = append(informersList, lib.AKOControlConfig().SvcAPIInformers().TCPRouteInformer.Informer().HasSynced)
 		}
 		if c.informers.IngressInformer != nil {
 			go c.informers.IngressInformer.Informer().Run(stopCh)
@@ -1286,6 +1427,10 @@ func (c *AviController) Start(stopCh <-chan struct{}) {
 		if lib.AKOControlConfig().HostRuleEnabled() {
 			go lib.AKOControlConfig().CRDInformers().HostRuleInformer.Informer().Run(stopCh)
 			informersList = append(informersList, lib.AKOControlConfig().CRDInformers().HostRuleInformer.Informer().HasSynced)
+			if c.informers.DataScriptConfigMapInformer != nil {
+				go c.informers.DataScriptConfigMapInformer.Informer().Run(stopCh)
+				informersList = append(informersList, c.informers.DataScriptConfigMapInformer.Informer().HasSynced)
+			}
 		}
 
 		if lib.AKOControlConfig().HttpRuleEnabled() {
@@ -1293,6 +1438,16 @@ func (c *AviController) Start(stopCh <-chan struct{}) {
 			informersList = append(informersList, lib.AKOControlConfig().CRDInformers().HTTPRuleInformer.Informer().HasSynced)
 		}
 
+		if lib.IsIstioEnabled() && lib.AKOControlConfig().IstioCRDInformers() != nil {
+			istioInformers := lib.AKOControlConfig().IstioCRDInformers()
+			go istioInformers.GatewayInformer.Informer().Run(stopCh)
+			informersList = append(informersList, istioInformers.GatewayInformer.Informer().HasSynced)
+			go istioInformers.VirtualServiceInformer.Informer().Run(stopCh)
+			informersList = append(informersList, istioInformers.VirtualServiceInformer.Informer().HasSynced)
+			go istioInformers.DestinationRuleInformer.Informer().Run(stopCh)
+			informersList = append(informersList, istioInformers.DestinationRuleInformer.Informer().HasSynced)
+		}
+
 		if utils.IsMultiClusterIngressEnabled() {
 			go c.informers.MultiClusterIngressInformer.Informer().Run(stopCh)
 			informersList = append(informersList, c.informers.MultiClusterIngressInformer.Informer().HasSynced)
@@ -1310,7 +1465,7 @@ func (c *AviController) Start(stopCh <-chan struct{}) {
 
 func isServiceLBType(svcObj *corev1.Service) bool {
 	// If we don't find a service or it is not of type loadbalancer - return false.
-	if svcObj.Spec.Type == "LoadBalancer" {
+	if svcObj.Spec.Type == "LoadBalancer" && lib.IsAviLoadBalancerClass(svcObj) {
 		return true
 	}
 	return false
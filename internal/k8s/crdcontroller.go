@@ -16,9 +16,13 @@ package k8s
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
@@ -36,6 +40,8 @@ import (
 
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -171,6 +177,51 @@ func (c *AviController) SetupAKOCRDEventHandlers(numWorkers uint32) {
 		}
 
 		informer.HostRuleInformer.Informer().AddEventHandler(hostRuleEventHandler)
+
+		if c.informers.DataScriptConfigMapInformer != nil {
+			dataScriptConfigMapEventHandler := cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					if c.DisableSync {
+						return
+					}
+					configMap := obj.(*corev1.ConfigMap)
+					utils.AviLog.Debugf("key: %s/%s, msg: datascript configmap ADD", configMap.Namespace, configMap.Name)
+					c.enqueueHostRulesForDataScriptConfigMap(configMap, numWorkers)
+				},
+				UpdateFunc: func(old, new interface{}) {
+					if c.DisableSync {
+						return
+					}
+					oldObj := old.(*corev1.ConfigMap)
+					configMap := new.(*corev1.ConfigMap)
+					if oldObj.ResourceVersion != configMap.ResourceVersion && !reflect.DeepEqual(oldObj.Data, configMap.Data) {
+						utils.AviLog.Debugf("key: %s/%s, msg: datascript configmap UPDATE", configMap.Namespace, configMap.Name)
+						c.enqueueHostRulesForDataScriptConfigMap(configMap, numWorkers)
+					}
+				},
+				DeleteFunc: func(obj interface{}) {
+					if c.DisableSync {
+						return
+					}
+					configMap, ok := obj.(*corev1.ConfigMap)
+					if !ok {
+						tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+						if !ok {
+							utils.AviLog.Errorf("couldn't get object from tombstone %#v", obj)
+							return
+						}
+						configMap, ok = tombstone.Obj.(*corev1.ConfigMap)
+						if !ok {
+							utils.AviLog.Errorf("Tombstone contained object that is not a ConfigMap: %#v", obj)
+							return
+						}
+					}
+					utils.AviLog.Debugf("key: %s/%s, msg: datascript configmap DELETE", configMap.Namespace, configMap.Name)
+					c.enqueueHostRulesForDataScriptConfigMap(configMap, numWorkers)
+				},
+			}
+			c.informers.DataScriptConfigMapInformer.Informer().AddEventHandler(dataScriptConfigMapEventHandler)
+		}
 	}
 
 	if lib.AKOControlConfig().HttpRuleEnabled() {
@@ -663,12 +714,28 @@ func (c *AviController) SetupServiceImportEventHandlers(numWorkers uint32) {
 // validateHostRuleObj would do validation checks
 // update internal CRD caches, and push relevant ingresses to ingestion
 func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
+	if err := checkHostRuleObj(key, hostrule, true); err != nil {
+		status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
+		return err
+	}
+
+	// No need to update status of hostrule object as accepted since it was accepted before.
+	if hostrule.Status.Status == lib.StatusAccepted {
+		return nil
+	}
+
+	status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusAccepted, Error: ""})
+	return nil
+}
+
+// checkHostRuleObj runs the hostrule validation checks without updating the hostrule status.
+// The checks for object refs on the controller are carried out only if checkRefs is set.
+func checkHostRuleObj(key string, hostrule *akov1alpha1.HostRule, checkRefs bool) error {
 	var err error
 	fqdn := hostrule.Spec.VirtualHost.Fqdn
 	foundHost, foundHR := objects.SharedCRDLister().GetFQDNToHostruleMapping(fqdn)
 	if foundHost && foundHR != hostrule.Namespace+"/"+hostrule.Name {
 		err = fmt.Errorf("duplicate fqdn %s found in %s", fqdn, foundHR)
-		status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 		return err
 	}
 
@@ -685,7 +752,6 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 		re := regexp.MustCompile(lib.IPRegex)
 		if !re.MatchString(hostrule.Spec.VirtualHost.TCPSettings.LoadBalancerIP) {
 			err = fmt.Errorf("loadBalancerIP %s is not a valid IP", hostrule.Spec.VirtualHost.TCPSettings.LoadBalancerIP)
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 	}
@@ -693,7 +759,6 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 	if hostrule.Spec.VirtualHost.Gslb.Fqdn != "" {
 		if fqdn == hostrule.Spec.VirtualHost.Gslb.Fqdn {
 			err = fmt.Errorf("GSLB FQDN and local FQDN are same")
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 	}
@@ -708,7 +773,6 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 		}
 		if !sslEnabled {
 			err = fmt.Errorf("Hosting parent virtualservice must have SSL enabled")
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 	}
@@ -716,26 +780,22 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 	if hostrule.Spec.VirtualHost.Aliases != nil {
 		if hostrule.Spec.VirtualHost.FqdnType != akov1alpha1.Exact {
 			err = fmt.Errorf("Aliases is supported only when FQDN type is set as Exact")
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 
 		if utils.HasElem(hostrule.Spec.VirtualHost.Aliases, fqdn) {
 			err = fmt.Errorf("Duplicate entry found. Aliases field has same entry as the FQDN field")
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 
 		if utils.ContainsDuplicate(hostrule.Spec.VirtualHost.Aliases) {
 			err = fmt.Errorf("Aliases must be unique")
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 
 		if hostrule.Spec.VirtualHost.Gslb.Fqdn != "" &&
 			utils.HasElem(hostrule.Spec.VirtualHost.Aliases, hostrule.Spec.VirtualHost.Gslb.Fqdn) {
 			err = fmt.Errorf("Aliases must not contain GSLB FQDN")
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 
@@ -747,13 +807,20 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 			for _, alias := range hostrule.Spec.VirtualHost.Aliases {
 				if utils.HasElem(aliases, alias) {
 					err = fmt.Errorf("%s is already in use by hostrule %s", alias, cachedFQDN)
-					status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 					return err
 				}
 			}
 		}
 	}
 
+	if err = checkHostRuleHTTPPolicyRules(hostrule.Spec.VirtualHost.HTTPPolicy.Rules); err != nil {
+		return err
+	}
+
+	if err = checkHostRuleDataScriptConfigMaps(hostrule.Namespace, hostrule.Spec.VirtualHost.DatascriptConfigMaps); err != nil {
+		return err
+	}
+
 	refData := map[string]string{
 		hostrule.Spec.VirtualHost.WAFPolicy:          "WafPolicy",
 		hostrule.Spec.VirtualHost.ApplicationProfile: "AppProfile",
@@ -768,7 +835,6 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 	if hostrule.Spec.VirtualHost.TLS.SSLKeyCertificate.Type == akov1alpha1.HostRuleSecretTypeSecretReference {
 		_, err := utils.GetInformers().SecretInformer.Lister().Secrets(hostrule.Namespace).Get(hostrule.Spec.VirtualHost.TLS.SSLKeyCertificate.Name)
 		if err != nil {
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 	}
@@ -779,7 +845,6 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 	if hostrule.Spec.VirtualHost.TLS.SSLKeyCertificate.AlternateCertificate.Type == akov1alpha1.HostRuleSecretTypeSecretReference {
 		_, err := utils.GetInformers().SecretInformer.Lister().Secrets(hostrule.Namespace).Get(hostrule.Spec.VirtualHost.TLS.SSLKeyCertificate.AlternateCertificate.Name)
 		if err != nil {
-			status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
 			return err
 		}
 	}
@@ -792,51 +857,156 @@ func validateHostRuleObj(key string, hostrule *akov1alpha1.HostRule) error {
 		refData[script] = "VsDatascript"
 	}
 
-	if err := checkRefsOnController(key, refData); err != nil {
-		status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusRejected, Error: err.Error()})
-		return err
+	if checkRefs {
+		if err := checkRefsOnController(key, refData); err != nil {
+			return err
+		}
 	}
 
-	// No need to update status of hostrule object as accepted since it was accepted before.
-	if hostrule.Status.Status == lib.StatusAccepted {
+	return nil
+}
+
+// enqueueHostRulesForDataScriptConfigMap re-validates and enqueues the hostrules which refer to the datascript
+// ConfigMap, so that the vsdatascriptsets built from the ConfigMap are synced.
+func (c *AviController) enqueueHostRulesForDataScriptConfigMap(configMap *corev1.ConfigMap, numWorkers uint32) {
+	hostrules, err := lib.AKOControlConfig().CRDInformers().HostRuleInformer.Lister().HostRules(configMap.Namespace).List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("Unable to list hostrules in namespace %s: %v", configMap.Namespace, err)
+		return
+	}
+	for _, hostrule := range hostrules {
+		if !utils.HasElem(hostrule.Spec.VirtualHost.DatascriptConfigMaps, configMap.Name) {
+			continue
+		}
+		key := lib.HostRule + "/" + utils.ObjKey(hostrule)
+		if err := validateHostRuleObj(key, hostrule); err != nil {
+			utils.AviLog.Warnf("key: %s, msg: Error retrieved during validation of HostRule: %v", key, err)
+		}
+		bkt := utils.Bkt(configMap.Namespace, numWorkers)
+		c.workqueue[bkt].AddRateLimited(key)
+	}
+}
+
+// checkHostRuleDataScriptConfigMaps validates the datascript ConfigMaps referred in a hostrule. The ConfigMaps must
+// carry the datascript label, and the keys of the ConfigMaps must be valid datascript events.
+func checkHostRuleDataScriptConfigMaps(namespace string, configMapNames []string) error {
+	if len(configMapNames) == 0 {
 		return nil
 	}
+	if utils.GetInformers().DataScriptConfigMapInformer == nil {
+		return fmt.Errorf("datascriptConfigMaps are not supported")
+	}
+	for _, configMapName := range configMapNames {
+		configMap, err := utils.GetInformers().DataScriptConfigMapInformer.Lister().ConfigMaps(namespace).Get(configMapName)
+		if err != nil {
+			return fmt.Errorf("datascript configmap %s/%s with label %s=true not found", namespace, configMapName, utils.DataScriptConfigMapLabel)
+		}
+		if len(configMap.Data) == 0 {
+			return fmt.Errorf("datascript configmap %s/%s has no datascripts", namespace, configMapName)
+		}
+		for evt, script := range configMap.Data {
+			if !lib.IsValidDataScriptEvent(evt) {
+				return fmt.Errorf("datascript configmap %s/%s: invalid datascript event %s", namespace, configMapName, evt)
+			}
+			if strings.TrimSpace(script) == "" {
+				return fmt.Errorf("datascript configmap %s/%s: empty datascript for event %s", namespace, configMapName, evt)
+			}
+		}
+	}
+	return nil
+}
 
-	status.UpdateHostRuleStatus(key, hostrule, status.UpdateCRDStatusOptions{Status: lib.StatusAccepted, Error: ""})
+// checkHostRuleHTTPPolicyRules validates the inline http policy rules of a hostrule.
+func checkHostRuleHTTPPolicyRules(rules []akov1alpha1.HostRuleHTTPPolicyRule) error {
+	for i, rule := range rules {
+		ruleName := rule.Name
+		if ruleName == "" {
+			ruleName = strconv.Itoa(i)
+		}
+		if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+			return fmt.Errorf("httpPolicy rule %s: path %s must start with /", ruleName, rule.Path)
+		}
+		if rule.Redirect == nil && rule.RewritePath == "" && len(rule.RequestHeaders) == 0 && len(rule.ResponseHeaders) == 0 &&
+			len(rule.AllowedClientIPs) == 0 && len(rule.DeniedClientIPs) == 0 && rule.RateLimit == nil {
+			return fmt.Errorf("httpPolicy rule %s: no action is specified", ruleName)
+		}
+
+		if rule.Redirect != nil {
+			if rule.RewritePath != "" || len(rule.RequestHeaders) > 0 {
+				return fmt.Errorf("httpPolicy rule %s: redirect cannot be combined with rewritePath or requestHeaders", ruleName)
+			}
+			if protocol := strings.ToUpper(rule.Redirect.Protocol); protocol != "" && protocol != utils.HTTP && protocol != utils.HTTPS {
+				return fmt.Errorf("httpPolicy rule %s: redirect protocol %s must be HTTP or HTTPS", ruleName, rule.Redirect.Protocol)
+			}
+			if code := rule.Redirect.StatusCode; code != 0 && code != 301 && code != 302 && code != 307 {
+				return fmt.Errorf("httpPolicy rule %s: redirect statusCode %d must be one of 301, 302 or 307", ruleName, code)
+			}
+			if rule.Redirect.Port < 0 || rule.Redirect.Port > 65535 {
+				return fmt.Errorf("httpPolicy rule %s: redirect port %d is not valid", ruleName, rule.Redirect.Port)
+			}
+		}
+
+		if rule.RewritePath != "" && !strings.HasPrefix(rule.RewritePath, "/") {
+			return fmt.Errorf("httpPolicy rule %s: rewritePath %s must start with /", ruleName, rule.RewritePath)
+		}
+
+		for _, header := range append(append([]akov1alpha1.HostRuleHTTPHeaderAction{}, rule.RequestHeaders...), rule.ResponseHeaders...) {
+			if _, ok := lib.GetHTTPHeaderAction(header.Action); !ok {
+				return fmt.Errorf("httpPolicy rule %s: header action %s must be one of Add, Replace or Remove", ruleName, header.Action)
+			}
+			if header.Name == "" {
+				return fmt.Errorf("httpPolicy rule %s: header name must not be empty", ruleName)
+			}
+			if header.Action != "Remove" && header.Value == "" {
+				return fmt.Errorf("httpPolicy rule %s: value of header %s must not be empty", ruleName, header.Name)
+			}
+		}
+
+		for _, clientIP := range append(append([]string{}, rule.AllowedClientIPs...), rule.DeniedClientIPs...) {
+			if _, _, err := net.ParseCIDR(clientIP); err != nil && net.ParseIP(clientIP) == nil {
+				return fmt.Errorf("httpPolicy rule %s: %s is not a valid IP or CIDR", ruleName, clientIP)
+			}
+		}
+
+		if rule.RateLimit != nil {
+			if rule.RateLimit.Count < 1 || rule.RateLimit.Period < 1 || rule.RateLimit.BurstSize < 0 {
+				return fmt.Errorf("httpPolicy rule %s: rateLimit count and period must be positive, and burstSize must not be negative", ruleName)
+			}
+		}
+	}
 	return nil
 }
 
 // validateMultiClusterIngressObj validates the MCI CRD changes before pushing it to ingestion
 func validateMultiClusterIngressObj(key string, multiClusterIngress *akov1alpha1.MultiClusterIngress) error {
 
-	var err error
 	statusToUpdate := &akov1alpha1.MultiClusterIngressStatus{}
-	defer func() {
-		if err == nil {
-			statusToUpdate.Status.Accepted = true
-			status.UpdateMultiClusterIngressStatus(key, multiClusterIngress, statusToUpdate)
-			return
-		}
-		statusToUpdate.Status.Accepted = false
-		statusToUpdate.Status.Reason = err.Error()
+	err := checkMultiClusterIngressObj(key, multiClusterIngress)
+	if err == nil {
+		statusToUpdate.Status.Accepted = true
 		status.UpdateMultiClusterIngressStatus(key, multiClusterIngress, statusToUpdate)
-	}()
+		return nil
+	}
+	statusToUpdate.Status.Accepted = false
+	statusToUpdate.Status.Reason = err.Error()
+	status.UpdateMultiClusterIngressStatus(key, multiClusterIngress, statusToUpdate)
+	return err
+}
 
+// checkMultiClusterIngressObj runs the MCI validation checks without updating its status.
+func checkMultiClusterIngressObj(key string, multiClusterIngress *akov1alpha1.MultiClusterIngress) error {
 	// Currently, we support only NodePort ServiceType.
 	if !lib.IsNodePortMode() {
-		err = fmt.Errorf("ServiceType must be of type NodePort")
-		return err
+		return fmt.Errorf("ServiceType must be of type NodePort")
 	}
 
 	// Currently, we support EVH mode only.
 	if !lib.IsEvhEnabled() {
-		err = fmt.Errorf("AKO must be in EVH mode")
-		return err
+		return fmt.Errorf("AKO must be in EVH mode")
 	}
 
 	if len(multiClusterIngress.Spec.Config) == 0 {
-		err = fmt.Errorf("config must not be empty")
-		return err
+		return fmt.Errorf("config must not be empty")
 	}
 
 	return nil
@@ -966,15 +1136,34 @@ func checkRefOnController(key, refKey, refValue string) error {
 // validateHTTPRuleObj would do validation checks
 // update internal CRD caches, and push relevant ingresses to ingestion
 func validateHTTPRuleObj(key string, httprule *akov1alpha1.HTTPRule) error {
+	if err := checkHTTPRuleObj(key, httprule, true); err != nil {
+		status.UpdateHTTPRuleStatus(key, httprule, status.UpdateCRDStatusOptions{
+			Status: lib.StatusRejected,
+			Error:  err.Error(),
+		})
+		return err
+	}
+
+	// No need to update status of httprule object as accepted since it was accepted before.
+	if httprule.Status.Status == lib.StatusAccepted {
+		return nil
+	}
+
+	status.UpdateHTTPRuleStatus(key, httprule, status.UpdateCRDStatusOptions{
+		Status: lib.StatusAccepted,
+		Error:  "",
+	})
+	return nil
+}
+
+// checkHTTPRuleObj runs the httprule validation checks without updating the httprule status.
+// The checks for object refs on the controller are carried out only if checkRefs is set.
+func checkHTTPRuleObj(key string, httprule *akov1alpha1.HTTPRule, checkRefs bool) error {
 	refData := make(map[string]string)
 	for _, path := range httprule.Spec.Paths {
 		if path.TLS.PKIProfile != "" && path.TLS.DestinationCA != "" {
 			//if both pkiProfile and destCA set, reject httprule
-			status.UpdateHTTPRuleStatus(key, httprule, status.UpdateCRDStatusOptions{
-				Status: lib.StatusRejected,
-				Error:  lib.HttpRulePkiAndDestCASetErr,
-			})
-			return fmt.Errorf("key: %s, msg: %s", key, lib.HttpRulePkiAndDestCASetErr)
+			return errors.New(lib.HttpRulePkiAndDestCASetErr)
 		}
 		refData[path.TLS.SSLProfile] = "SslProfile"
 		refData[path.ApplicationPersistence] = "ApplicationPersistence"
@@ -985,39 +1174,105 @@ func validateHTTPRuleObj(key string, httprule *akov1alpha1.HTTPRule) error {
 		for _, hm := range path.HealthMonitors {
 			refData[hm] = "HealthMonitor"
 		}
+
+		for _, match := range path.Matches {
+			if err := checkHTTPRuleMatch(path.Target, match); err != nil {
+				return err
+			}
+		}
+
+		for _, backend := range path.Backends {
+			if backend.ServiceName == "" {
+				return fmt.Errorf("serviceName is not set for a backend of target %s", path.Target)
+			}
+			if backend.Weight != nil && (*backend.Weight < 0 || *backend.Weight > 256) {
+				return fmt.Errorf("invalid weight %d for backend %s of target %s, weight should be between 0 and 256", *backend.Weight, backend.ServiceName, path.Target)
+			}
+		}
 	}
 
-	if err := checkRefsOnController(key, refData); err != nil {
-		status.UpdateHTTPRuleStatus(key, httprule, status.UpdateCRDStatusOptions{
+	if checkRefs {
+		return checkRefsOnController(key, refData)
+	}
+	return nil
+}
+
+// checkHTTPRuleMatch checks that a httprule match has a backend service and at least one valid condition.
+func checkHTTPRuleMatch(target string, match akov1alpha1.HTTPRuleMatch) error {
+	if match.Backend.ServiceName == "" {
+		return fmt.Errorf("backend serviceName is not set for a match of target %s", target)
+	}
+	if len(match.Headers) == 0 && len(match.QueryParams) == 0 && len(match.Methods) == 0 && match.Cookie == nil {
+		return fmt.Errorf("no header, queryParam, method or cookie condition is set for a match of target %s", target)
+	}
+
+	headers := append([]akov1alpha1.HTTPRuleHeaderMatch{}, match.Headers...)
+	if match.Cookie != nil {
+		headers = append(headers, *match.Cookie)
+	}
+	for _, header := range headers {
+		if header.Name == "" {
+			return fmt.Errorf("header/cookie name is not set for a match of target %s", target)
+		}
+		if header.MatchType != "" && header.MatchType != lib.MatchTypeExact && header.MatchType != lib.MatchTypeContains {
+			return fmt.Errorf("invalid matchType %s for a match of target %s, supported values are exact and contains", header.MatchType, target)
+		}
+	}
+	for _, queryParam := range match.QueryParams {
+		if queryParam.Name == "" {
+			return fmt.Errorf("queryParam name is not set for a match of target %s", target)
+		}
+	}
+	for _, method := range match.Methods {
+		if !utils.HasElem(lib.HTTPRuleMatchMethods, method) {
+			return fmt.Errorf("invalid method %s for a match of target %s", method, target)
+		}
+	}
+	return nil
+}
+
+// validateAviInfraSetting would do validaion checks on the
+// ingested AviInfraSetting objects
+func validateAviInfraSetting(key string, infraSetting *akov1alpha1.AviInfraSetting) error {
+	if err := checkAviInfraSettingObj(key, infraSetting, true); err != nil {
+		status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
 			Status: lib.StatusRejected,
 			Error:  err.Error(),
 		})
 		return err
 	}
 
-	// No need to update status of httprule object as accepted since it was accepted before.
-	if httprule.Status.Status == lib.StatusAccepted {
+	// This would add SEG labels only if they are not configured yet. In case there is a label mismatch
+	// to any pre-existing SEG labels, the AviInfraSettig CR will get Rejected from the checkRefsOnController
+	// step before this.
+	if infraSetting.Spec.SeGroup.Name != "" {
+		addSeGroupLabel(key, infraSetting.Spec.SeGroup.Name)
+	}
+
+	// No need to update status of infra setting object as accepted since it was accepted before.
+	if infraSetting.Status.Status == lib.StatusAccepted {
 		return nil
 	}
 
-	status.UpdateHTTPRuleStatus(key, httprule, status.UpdateCRDStatusOptions{
+	status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
 		Status: lib.StatusAccepted,
 		Error:  "",
 	})
 	return nil
 }
 
-// validateAviInfraSetting would do validaion checks on the
-// ingested AviInfraSetting objects
-func validateAviInfraSetting(key string, infraSetting *akov1alpha1.AviInfraSetting) error {
+// checkAviInfraSettingObj runs the AviInfraSetting validation checks without updating its status.
+// The checks for object refs on the controller are carried out only if checkRefs is set.
+func checkAviInfraSettingObj(key string, infraSetting *akov1alpha1.AviInfraSetting, checkRefs bool) error {
 	if ((infraSetting.Spec.Network.EnableRhi != nil && !*infraSetting.Spec.Network.EnableRhi) || infraSetting.Spec.Network.EnableRhi == nil) &&
 		len(infraSetting.Spec.Network.BgpPeerLabels) > 0 {
-		err := fmt.Errorf("BGPPeerLabels cannot be set if EnableRhi is false.")
-		status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
-			Status: lib.StatusRejected,
-			Error:  err.Error(),
-		})
-		return err
+		return fmt.Errorf("BGPPeerLabels cannot be set if EnableRhi is false.")
+	}
+
+	if shardScheme := infraSetting.Spec.L7Settings.ShardScheme; shardScheme != "" {
+		if _, ok := lib.ShardSchemeMap[shardScheme]; !ok {
+			return fmt.Errorf("invalid shardScheme %s, supported values are hostname and namespace", shardScheme)
+		}
 	}
 
 	refData := make(map[string]string)
@@ -1025,23 +1280,13 @@ func validateAviInfraSetting(key string, infraSetting *akov1alpha1.AviInfraSetti
 		if vipNetwork.Cidr != "" {
 			re := regexp.MustCompile(lib.IPCIDRRegex)
 			if !re.MatchString(vipNetwork.Cidr) {
-				err := fmt.Errorf("invalid CIDR configuration %s detected for networkName %s in vipNetworkList", vipNetwork.Cidr, vipNetwork.NetworkName)
-				status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
-					Status: lib.StatusRejected,
-					Error:  err.Error(),
-				})
-				return err
+				return fmt.Errorf("invalid CIDR configuration %s detected for networkName %s in vipNetworkList", vipNetwork.Cidr, vipNetwork.NetworkName)
 			}
 		}
 		if vipNetwork.V6Cidr != "" {
 			re := regexp.MustCompile(lib.IPV6CIDRRegex)
 			if !re.MatchString(vipNetwork.V6Cidr) {
-				err := fmt.Errorf("invalid IPv6 CIDR configuration %s detected for networkName %s in vipNetworkList", vipNetwork.V6Cidr, vipNetwork.NetworkName)
-				status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
-					Status: lib.StatusRejected,
-					Error:  err.Error(),
-				})
-				return err
+				return fmt.Errorf("invalid IPv6 CIDR configuration %s detected for networkName %s in vipNetworkList", vipNetwork.V6Cidr, vipNetwork.NetworkName)
 			}
 		}
 		refData[vipNetwork.NetworkName] = "Network"
@@ -1051,30 +1296,9 @@ func validateAviInfraSetting(key string, infraSetting *akov1alpha1.AviInfraSetti
 		refData[infraSetting.Spec.SeGroup.Name] = "ServiceEngineGroup"
 	}
 
-	if err := checkRefsOnController(key, refData); err != nil {
-		status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
-			Status: lib.StatusRejected,
-			Error:  err.Error(),
-		})
-		return err
-	}
-
-	// This would add SEG labels only if they are not configured yet. In case there is a label mismatch
-	// to any pre-existing SEG labels, the AviInfraSettig CR will get Rejected from the checkRefsOnController
-	// step before this.
-	if infraSetting.Spec.SeGroup.Name != "" {
-		addSeGroupLabel(key, infraSetting.Spec.SeGroup.Name)
-	}
-
-	// No need to update status of infra setting object as accepted since it was accepted before.
-	if infraSetting.Status.Status == lib.StatusAccepted {
-		return nil
+	if checkRefs {
+		return checkRefsOnController(key, refData)
 	}
-
-	status.UpdateAviInfraSettingStatus(key, infraSetting, status.UpdateCRDStatusOptions{
-		Status: lib.StatusAccepted,
-		Error:  "",
-	})
 	return nil
 }
 
@@ -0,0 +1,510 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+// Avi object types, as used in the rest operations, of the objects listed by the debug api.
+const (
+	debugTypeVS          = "VirtualService"
+	debugTypeVSVIP       = "VsVip"
+	debugTypePool        = "Pool"
+	debugTypePG          = "PoolGroup"
+	debugTypeHTTPPS      = "HTTPPolicySet"
+	debugTypeDS          = "VSDataScriptSet"
+	debugTypeSSL         = "SSLKeyAndCertificate"
+	debugTypePKI         = "PKIprofile"
+	debugTypeL4PS        = "L4PolicySet"
+	debugTypeNSP         = "NetworkSecurityPolicy"
+	debugTypePersistence = "ApplicationPersistenceProfile"
+	debugTypeHM          = "HealthMonitor"
+	debugTypeVrf         = "VrfContext"
+)
+
+// Status of an object in the comparison of a model with the cache.
+const (
+	debugStatusInSync = "InSync"
+	debugStatusDiff   = "ChecksumMismatch"
+	debugStatusNoObj  = "NotInCache"
+	debugStatusStale  = "NotInModel"
+)
+
+// debugObject is an Avi object in a model built by the graph layer.
+type debugObject struct {
+	Type     string `json:"type"`
+	Tenant   string `json:"tenant,omitempty"`
+	Name     string `json:"name"`
+	Parent   string `json:"parent,omitempty"`
+	Checksum string `json:"checksum"`
+}
+
+type debugModelNode struct {
+	Type string             `json:"type"`
+	Node nodes.AviModelNode `json:"node"`
+}
+
+type debugModelResponse struct {
+	Name          string           `json:"name"`
+	GraphChecksum uint32           `json:"graph_checksum"`
+	IsVrf         bool             `json:"is_vrf"`
+	Objects       []debugObject    `json:"objects"`
+	Nodes         []debugModelNode `json:"nodes"`
+}
+
+// debugCacheObject is an Avi object in the AviObjCache.
+type debugCacheObject struct {
+	Type     string `json:"type"`
+	Tenant   string `json:"tenant,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Uuid     string `json:"uuid"`
+	Checksum string `json:"checksum,omitempty"`
+	Found    bool   `json:"found"`
+}
+
+type debugCacheResponse struct {
+	VirtualService *avicache.AviVsCache `json:"virtualservice"`
+	Objects        []debugCacheObject   `json:"objects"`
+}
+
+type debugCompareObject struct {
+	Type          string `json:"type"`
+	Tenant        string `json:"tenant,omitempty"`
+	Name          string `json:"name"`
+	Uuid          string `json:"uuid,omitempty"`
+	ModelChecksum string `json:"model_checksum,omitempty"`
+	CacheChecksum string `json:"cache_checksum,omitempty"`
+	Status        string `json:"status"`
+}
+
+type debugCompareResponse struct {
+	Model   string               `json:"model"`
+	InSync  bool                 `json:"in_sync"`
+	Objects []debugCompareObject `json:"objects"`
+}
+
+// DebugModel implements models.ApiModel, and serves read only routes to inspect the models built by
+// the graph layer, the Avi object cache, and the checksum mismatches between the two.
+type DebugModel struct{}
+
+func (a *DebugModel) InitModel() {}
+
+func (a *DebugModel) ApiOperationMap() []models.OperationMap {
+	var operationMapList []models.OperationMap
+
+	listModels := models.OperationMap{
+		Route:  "/api/debug/models",
+		Method: "GET",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			modelNames := objects.SharedAviGraphLister().AviGraphStore.GetAllKeys()
+			sort.Strings(modelNames)
+			utils.Respond(w, map[string][]string{"models": modelNames})
+		},
+	}
+
+	getModel := models.OperationMap{
+		Route:  "/api/debug/model",
+		Method: "GET",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			modelName := r.URL.Query().Get("name")
+			aviModel, err := getDebugAviModel(modelName)
+			if err != nil {
+				respondDebugError(w, http.StatusNotFound, err)
+				return
+			}
+			response := &debugModelResponse{
+				Name:          modelName,
+				GraphChecksum: aviModel.GraphChecksum,
+				IsVrf:         aviModel.IsVrf,
+			}
+			response.Nodes, response.Objects = debugModelObjects(aviModel)
+			utils.Respond(w, response)
+		},
+	}
+
+	getCache := models.OperationMap{
+		Route:  "/api/debug/cache",
+		Method: "GET",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			vsName := r.URL.Query().Get("vs")
+			tenant, name := utils.ExtractNamespaceObjectName(vsName)
+			vsCache, found := getDebugVSCache(avicache.NamespaceName{Namespace: tenant, Name: name})
+			if !found {
+				respondDebugError(w, http.StatusNotFound, fmt.Errorf("virtualservice %s not found in the cache", vsName))
+				return
+			}
+			response := &debugCacheResponse{
+				VirtualService: vsCache,
+				Objects:        debugVSCacheObjects(vsCache),
+			}
+			utils.Respond(w, response)
+		},
+	}
+
+	compare := models.OperationMap{
+		Route:  "/api/debug/compare",
+		Method: "GET",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			modelName := r.URL.Query().Get("name")
+			aviModel, err := getDebugAviModel(modelName)
+			if err != nil {
+				respondDebugError(w, http.StatusNotFound, err)
+				return
+			}
+			utils.Respond(w, compareModelWithCache(modelName, aviModel))
+		},
+	}
+
+	operationMapList = append(operationMapList, listModels, getModel, getCache, compare)
+	return operationMapList
+}
+
+func respondDebugError(w http.ResponseWriter, code int, err error) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func getDebugAviModel(modelName string) (*nodes.AviObjectGraph, error) {
+	found, aviModelIntf := objects.SharedAviGraphLister().Get(modelName)
+	if !found || aviModelIntf == nil {
+		return nil, fmt.Errorf("model %s not found", modelName)
+	}
+	aviModel, ok := aviModelIntf.(*nodes.AviObjectGraph)
+	if !ok || aviModel == nil {
+		return nil, fmt.Errorf("model %s not found", modelName)
+	}
+	return aviModel, nil
+}
+
+func getDebugVSCache(vsKey avicache.NamespaceName) (*avicache.AviVsCache, bool) {
+	vsCacheIntf, found := avicache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+	if !found {
+		return nil, false
+	}
+	vsCache, ok := vsCacheIntf.(*avicache.AviVsCache)
+	if !ok || vsCache == nil {
+		return nil, false
+	}
+	return vsCache.GetVSCopy()
+}
+
+func formatChecksum(checksum uint32) string {
+	return strconv.FormatUint(uint64(checksum), 10)
+}
+
+// debugModelObjects returns a copy of the nodes in the model, along with the Avi objects in these
+// nodes and their checksums. The private keys of the certificates are not returned.
+func debugModelObjects(aviModel *nodes.AviObjectGraph) ([]debugModelNode, []debugObject) {
+	var modelNodes []debugModelNode
+	aviModel.Lock.RLock()
+	for _, node := range aviModel.GetOrderedNodes() {
+		modelNodes = append(modelNodes, debugModelNode{Type: node.GetNodeType(), Node: node.CopyNode()})
+	}
+	aviModel.Lock.RUnlock()
+
+	var objs []debugObject
+	for _, modelNode := range modelNodes {
+		switch node := modelNode.Node.(type) {
+		case *nodes.AviVsNode:
+			objs = append(objs, debugVsNodeObjects(node, "")...)
+		case *nodes.AviEvhVsNode:
+			objs = append(objs, debugEvhNodeObjects(node, "")...)
+		case *nodes.AviVrfNode:
+			objs = append(objs, debugObject{Type: debugTypeVrf, Name: node.Name, Checksum: formatChecksum(node.GetCheckSum())})
+		case *nodes.AviTLSKeyCertNode:
+			node.Key = nil
+		}
+	}
+	return modelNodes, objs
+}
+
+func debugVsNodeObjects(vsNode *nodes.AviVsNode, parent string) []debugObject {
+	objs := []debugObject{{
+		Type:     debugTypeVS,
+		Tenant:   vsNode.Tenant,
+		Name:     vsNode.Name,
+		Parent:   parent,
+		Checksum: formatChecksum(vsNode.GetCheckSum()),
+	}}
+	objs = append(objs, debugChildObjects(vsNode.VSVIPRefs, vsNode.PoolRefs, vsNode.PoolGroupRefs, vsNode.HttpPolicyRefs,
+		vsNode.HTTPDSrefs, vsNode.Name, vsNode.SSLKeyCertRefs, vsNode.CACertRefs)...)
+	for _, l4Policy := range vsNode.L4PolicyRefs {
+		objs = append(objs, debugObject{Type: debugTypeL4PS, Tenant: l4Policy.Tenant, Name: l4Policy.Name, Parent: vsNode.Name, Checksum: formatChecksum(l4Policy.GetCheckSum())})
+	}
+	for _, nsPolicy := range vsNode.NSPolicyRefs {
+		objs = append(objs, debugObject{Type: debugTypeNSP, Tenant: nsPolicy.Tenant, Name: nsPolicy.Name, Parent: vsNode.Name, Checksum: formatChecksum(nsPolicy.GetCheckSum())})
+	}
+	for _, childNode := range vsNode.SniNodes {
+		objs = append(objs, debugVsNodeObjects(childNode, vsNode.Name)...)
+	}
+	for _, childNode := range vsNode.PassthroughChildNodes {
+		objs = append(objs, debugVsNodeObjects(childNode, vsNode.Name)...)
+	}
+	for _, childNode := range vsNode.L4ChildNodes {
+		objs = append(objs, debugVsNodeObjects(childNode, vsNode.Name)...)
+	}
+	return objs
+}
+
+func debugEvhNodeObjects(evhNode *nodes.AviEvhVsNode, parent string) []debugObject {
+	objs := []debugObject{{
+		Type:     debugTypeVS,
+		Tenant:   evhNode.Tenant,
+		Name:     evhNode.Name,
+		Parent:   parent,
+		Checksum: formatChecksum(evhNode.GetCheckSum()),
+	}}
+	objs = append(objs, debugChildObjects(evhNode.VSVIPRefs, evhNode.PoolRefs, evhNode.PoolGroupRefs, evhNode.HttpPolicyRefs,
+		evhNode.HTTPDSrefs, evhNode.Name, evhNode.SSLKeyCertRefs, evhNode.CACertRefs)...)
+	for _, childNode := range evhNode.EvhNodes {
+		objs = append(objs, debugEvhNodeObjects(childNode, evhNode.Name)...)
+	}
+	return objs
+}
+
+func debugChildObjects(vsvips []*nodes.AviVSVIPNode, pools []*nodes.AviPoolNode, pgs []*nodes.AviPoolGroupNode,
+	httpPolicies []*nodes.AviHttpPolicySetNode, dataScripts []*nodes.AviHTTPDataScriptNode, parent string, certLists ...[]*nodes.AviTLSKeyCertNode) []debugObject {
+	var objs []debugObject
+	for _, vsvip := range vsvips {
+		objs = append(objs, debugObject{Type: debugTypeVSVIP, Tenant: vsvip.Tenant, Name: vsvip.Name, Parent: parent, Checksum: formatChecksum(vsvip.GetCheckSum())})
+	}
+	for _, pool := range pools {
+		objs = append(objs, debugObject{Type: debugTypePool, Tenant: pool.Tenant, Name: pool.Name, Parent: parent, Checksum: formatChecksum(pool.GetCheckSum())})
+		if pool.PkiProfile != nil {
+			objs = append(objs, debugObject{Type: debugTypePKI, Tenant: pool.PkiProfile.Tenant, Name: pool.PkiProfile.Name, Parent: parent, Checksum: formatChecksum(pool.PkiProfile.GetCheckSum())})
+		}
+		if pool.PersistenceProfile != nil {
+			objs = append(objs, debugObject{Type: debugTypePersistence, Tenant: pool.PersistenceProfile.Tenant, Name: pool.PersistenceProfile.Name, Parent: parent, Checksum: formatChecksum(pool.PersistenceProfile.GetCheckSum())})
+		}
+		if pool.ProbeHealthMonitor != nil {
+			objs = append(objs, debugObject{Type: debugTypeHM, Tenant: pool.ProbeHealthMonitor.Tenant, Name: pool.ProbeHealthMonitor.Name, Parent: parent, Checksum: formatChecksum(pool.ProbeHealthMonitor.GetCheckSum())})
+		}
+	}
+	for _, pg := range pgs {
+		objs = append(objs, debugObject{Type: debugTypePG, Tenant: pg.Tenant, Name: pg.Name, Parent: parent, Checksum: formatChecksum(pg.GetCheckSum())})
+	}
+	for _, httpPolicy := range httpPolicies {
+		objs = append(objs, debugObject{Type: debugTypeHTTPPS, Tenant: httpPolicy.Tenant, Name: httpPolicy.Name, Parent: parent, Checksum: formatChecksum(httpPolicy.GetCheckSum())})
+	}
+	for _, ds := range dataScripts {
+		objs = append(objs, debugObject{Type: debugTypeDS, Tenant: ds.Tenant, Name: ds.Name, Parent: parent, Checksum: formatChecksum(ds.GetCheckSum())})
+	}
+	for _, certs := range certLists {
+		for _, cert := range certs {
+			objs = append(objs, debugObject{Type: debugTypeSSL, Tenant: cert.Tenant, Name: cert.Name, Parent: parent, Checksum: formatChecksum(cert.GetCheckSum())})
+			cert.Key = nil
+		}
+	}
+	return objs
+}
+
+// getDebugCacheObject returns the uuid and the checksum of an Avi object from the AviObjCache.
+func getDebugCacheObject(objType, tenant, name string) (string, string, bool) {
+	objCache := avicache.SharedAviObjCache()
+	key := avicache.NamespaceName{Namespace: tenant, Name: name}
+	switch objType {
+	case debugTypeVS:
+		if obj, ok := getDebugVSCache(key); ok {
+			return obj.Uuid, obj.CloudConfigCksum, true
+		}
+	case debugTypeVSVIP:
+		if obj, ok := objCache.VSVIPCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviVSVIPCache); ok {
+				return cacheObj.Uuid, cacheObj.CloudConfigCksum, true
+			}
+		}
+	case debugTypePool:
+		if obj, ok := objCache.PoolCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviPoolCache); ok {
+				return cacheObj.Uuid, cacheObj.CloudConfigCksum, true
+			}
+		}
+	case debugTypePG:
+		if obj, ok := objCache.PgCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviPGCache); ok {
+				return cacheObj.Uuid, cacheObj.CloudConfigCksum, true
+			}
+		}
+	case debugTypeHTTPPS:
+		if obj, ok := objCache.HTTPPolicyCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviHTTPPolicyCache); ok {
+				return cacheObj.Uuid, cacheObj.CloudConfigCksum, true
+			}
+		}
+	case debugTypeDS:
+		if obj, ok := objCache.DSCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviDSCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypeSSL:
+		if obj, ok := objCache.SSLKeyCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviSSLCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypePKI:
+		if obj, ok := objCache.PKIProfileCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviPkiProfileCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypeL4PS:
+		if obj, ok := objCache.L4PolicyCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviL4PolicyCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypeNSP:
+		if obj, ok := objCache.NSPolicyCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviNSPolicyCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypePersistence:
+		if obj, ok := objCache.PersistenceCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviPersistenceProfileCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypeHM:
+		if obj, ok := objCache.HealthMonitorCache.AviCacheGet(key); ok {
+			if cacheObj, ok := obj.(*avicache.AviHealthMonitorCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	case debugTypeVrf:
+		if obj, ok := objCache.VrfCache.AviCacheGet(name); ok {
+			if cacheObj, ok := obj.(*avicache.AviVrfCache); ok {
+				return cacheObj.Uuid, formatChecksum(cacheObj.CloudConfigCksum), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// debugVSCacheObjects returns the objects referred by the virtualservice in the cache, including the
+// SNI children, which are stored by their uuids.
+func debugVSCacheObjects(vsCache *avicache.AviVsCache) []debugCacheObject {
+	var objs []debugCacheObject
+	collections := []struct {
+		objType string
+		keys    []avicache.NamespaceName
+	}{
+		{debugTypeVSVIP, vsCache.VSVipKeyCollection},
+		{debugTypePool, vsCache.PoolKeyCollection},
+		{debugTypePG, vsCache.PGKeyCollection},
+		{debugTypeHTTPPS, vsCache.HTTPKeyCollection},
+		{debugTypeDS, vsCache.DSKeyCollection},
+		{debugTypeSSL, vsCache.SSLKeyCertCollection},
+		{debugTypeL4PS, vsCache.L4PolicyCollection},
+		{debugTypeNSP, vsCache.NSPolicyCollection},
+	}
+	for _, collection := range collections {
+		for _, key := range collection.keys {
+			uuid, checksum, found := getDebugCacheObject(collection.objType, key.Namespace, key.Name)
+			objs = append(objs, debugCacheObject{
+				Type:     collection.objType,
+				Tenant:   key.Namespace,
+				Name:     key.Name,
+				Uuid:     uuid,
+				Checksum: checksum,
+				Found:    found,
+			})
+		}
+	}
+	for _, childUuid := range vsCache.SNIChildCollection {
+		obj := debugCacheObject{Type: debugTypeVS, Uuid: childUuid}
+		if key, ok := avicache.SharedAviObjCache().VsCacheMeta.AviCacheGetKeyByUuid(childUuid); ok {
+			childKey := key.(avicache.NamespaceName)
+			obj.Tenant, obj.Name = childKey.Namespace, childKey.Name
+			_, obj.Checksum, obj.Found = getDebugCacheObject(debugTypeVS, childKey.Namespace, childKey.Name)
+		}
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// compareModelWithCache compares the checksum of each object in the model with the one in the cache,
+// which is what the rest layer uses to decide whether the object has to be updated. The objects
+// referred by the virtualservices in the cache, which are not in the model, are flagged as well.
+func compareModelWithCache(modelName string, aviModel *nodes.AviObjectGraph) *debugCompareResponse {
+	response := &debugCompareResponse{Model: modelName, InSync: true, Objects: []debugCompareObject{}}
+	_, modelObjs := debugModelObjects(aviModel)
+	inModel := make(map[string]bool)
+	for _, obj := range modelObjs {
+		inModel[obj.Type+"/"+obj.Tenant+"/"+obj.Name] = true
+	}
+
+	for _, obj := range modelObjs {
+		compareObj := debugCompareObject{
+			Type:          obj.Type,
+			Tenant:        obj.Tenant,
+			Name:          obj.Name,
+			ModelChecksum: obj.Checksum,
+			Status:        debugStatusInSync,
+		}
+		uuid, checksum, found := getDebugCacheObject(obj.Type, obj.Tenant, obj.Name)
+		compareObj.Uuid, compareObj.CacheChecksum = uuid, checksum
+		if !found {
+			compareObj.Status = debugStatusNoObj
+		} else if checksum != obj.Checksum {
+			compareObj.Status = debugStatusDiff
+		}
+		response.Objects = append(response.Objects, compareObj)
+
+		if obj.Type != debugTypeVS || !found {
+			continue
+		}
+		vsCache, ok := getDebugVSCache(avicache.NamespaceName{Namespace: obj.Tenant, Name: obj.Name})
+		if !ok {
+			continue
+		}
+		for _, cacheObj := range debugVSCacheObjects(vsCache) {
+			if !cacheObj.Found || inModel[cacheObj.Type+"/"+cacheObj.Tenant+"/"+cacheObj.Name] {
+				continue
+			}
+			inModel[cacheObj.Type+"/"+cacheObj.Tenant+"/"+cacheObj.Name] = true
+			response.Objects = append(response.Objects, debugCompareObject{
+				Type:          cacheObj.Type,
+				Tenant:        cacheObj.Tenant,
+				Name:          cacheObj.Name,
+				Uuid:          cacheObj.Uuid,
+				CacheChecksum: cacheObj.Checksum,
+				Status:        debugStatusStale,
+			})
+		}
+	}
+
+	for _, obj := range response.Objects {
+		if obj.Status != debugStatusInSync {
+			response.InSync = false
+			break
+		}
+	}
+	return response
+}
@@ -0,0 +1,594 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	avimodels "github.com/vmware/alb-sdk/go/models"
+	corev1 "k8s.io/api/core/v1"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/rest"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/third_party/github.com/vmware/alb-sdk/go/clients"
+)
+
+// Reasons for which an Avi object is reported by the drift scanner.
+const (
+	driftReasonModified = "ModifiedOutOfBand"
+	driftReasonDeleted  = "DeletedOutOfBand"
+	driftReasonOrphan   = "Orphan"
+)
+
+// Fields of the Avi objects compared by the drift scanner.
+const (
+	driftFieldUuid         = "uuid"
+	driftFieldChecksum     = "cloud_config_cksum"
+	driftFieldLastModified = "last_modified"
+	driftFieldServers      = "servers"
+	driftFieldFqdns        = "fqdns"
+	driftFieldVips         = "vips"
+	driftFieldEnableRhi    = "enable_rhi"
+	driftFieldVsVipRef     = "vsvip_ref"
+)
+
+// driftObject is an AKO created Avi object, which does not match the Avi object cache, or is not known to AKO.
+type driftObject struct {
+	Type               string    `json:"type"`
+	Tenant             string    `json:"tenant"`
+	Name               string    `json:"name"`
+	Uuid               string    `json:"uuid,omitempty"`
+	Model              string    `json:"model,omitempty"`
+	Reason             string    `json:"reason"`
+	Fields             []string  `json:"fields,omitempty"`
+	ControllerChecksum string    `json:"controller_checksum,omitempty"`
+	CacheChecksum      string    `json:"cache_checksum,omitempty"`
+	ModelChecksum      string    `json:"model_checksum,omitempty"`
+	LastModified       string    `json:"last_modified,omitempty"`
+	Reverted           bool      `json:"reverted"`
+	DetectedAt         time.Time `json:"detected_at"`
+}
+
+func (o *driftObject) key() string {
+	return o.Type + "/" + o.Tenant + "/" + o.Name
+}
+
+// isSameDrift returns true if the object was found in the same state by both the scans. The objects
+// being updated by AKO while a scan runs may not match the cache for that scan only.
+func (o *driftObject) isSameDrift(other *driftObject) bool {
+	return o.Reason == other.Reason && o.Uuid == other.Uuid && o.LastModified == other.LastModified
+}
+
+type driftReport struct {
+	Policy   string        `json:"policy"`
+	LastScan *time.Time    `json:"last_scan,omitempty"`
+	Objects  []driftObject `json:"objects"`
+}
+
+// driftAviObject holds the fields of an Avi object compared by the drift scanner, as fetched from the
+// controller or as stored in the cache. Only the key fields present in the cache object are compared.
+type driftAviObject struct {
+	uuid         string
+	checksum     string
+	lastModified string
+	fields       map[string]string
+}
+
+// driftModelObject is an Avi object in a model built by the graph layer.
+type driftModelObject struct {
+	model    string
+	checksum string
+}
+
+// driftScanner keeps the objects found by the last drift scans. An object is reported only after it is
+// found in the same state in two consecutive scans, so that the objects being synced by AKO are skipped.
+type driftScanner struct {
+	lock     sync.RWMutex
+	lastScan time.Time
+	pending  map[string]*driftObject
+	objects  map[string]*driftObject
+}
+
+var driftScannerInstance *driftScanner
+var driftScannerOnce sync.Once
+
+func sharedDriftScanner() *driftScanner {
+	driftScannerOnce.Do(func() {
+		driftScannerInstance = &driftScanner{
+			pending: make(map[string]*driftObject),
+			objects: make(map[string]*driftObject),
+		}
+	})
+	return driftScannerInstance
+}
+
+// DriftModel implements models.ApiModel, and serves the AKO created Avi objects which were found to be
+// changed out of band, or not known to AKO, by the drift scanner.
+type DriftModel struct{}
+
+func (a *DriftModel) InitModel() {}
+
+func (a *DriftModel) ApiOperationMap() []models.OperationMap {
+	var operationMapList []models.OperationMap
+
+	get := models.OperationMap{
+		Route:  "/api/drift",
+		Method: "GET",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			utils.Respond(w, sharedDriftScanner().getReport(r.URL.Query().Get("reason")))
+		},
+	}
+
+	operationMapList = append(operationMapList, get)
+	return operationMapList
+}
+
+func (s *driftScanner) getReport(reason string) *driftReport {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	report := &driftReport{Policy: lib.GetDriftPolicy(), Objects: []driftObject{}}
+	if !s.lastScan.IsZero() {
+		lastScan := s.lastScan
+		report.LastScan = &lastScan
+	}
+	for _, obj := range s.objects {
+		if reason == "" || reason == obj.Reason {
+			report.Objects = append(report.Objects, *obj)
+		}
+	}
+	sort.Slice(report.Objects, func(i, j int) bool {
+		return report.Objects[i].key() < report.Objects[j].key()
+	})
+	return report
+}
+
+// ScanDrift fetches the virtualservices and pools created by AKO, and the vsvips with the AKO name prefix,
+// from the controller, and compares them with the Avi object cache. The objects which were changed or
+// deleted out of band are reported as events, and are reverted by syncing their models again if the
+// drift policy is Revert. The objects which are neither in the cache nor in any model are reported as
+// orphans, and are never deleted by AKO.
+func ScanDrift() {
+	if lib.DisableSync || lib.GetDeleteConfigMap() {
+		return
+	}
+	aviClients := avicache.SharedAVIClients()
+	if len(aviClients.AviClient) == 0 {
+		utils.AviLog.Warnf("No avi clients found, skipping the drift scan")
+		return
+	}
+	detected, err := findDriftObjects(aviClients.AviClient[0])
+	if err != nil {
+		utils.AviLog.Warnf("Unable to fetch the Avi objects for the drift scan, err: %v", err)
+		return
+	}
+	sharedDriftScanner().update(detected, lib.GetDriftPolicy())
+}
+
+// update confirms the objects found in the scan against the previous scan, raises the events for the
+// newly confirmed objects, and reverts the confirmed objects if the policy is Revert.
+func (s *driftScanner) update(detected map[string]*driftObject, policy string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	confirmed := make(map[string]*driftObject)
+	revertModels := make(map[string]bool)
+	for objKey, obj := range detected {
+		previous, ok := s.pending[objKey]
+		if !ok || !previous.isSameDrift(obj) {
+			continue
+		}
+		obj.DetectedAt = previous.DetectedAt
+		if policy == lib.DriftPolicyRevert && obj.Model != "" && obj.Reason != driftReasonOrphan {
+			revertDriftObject(obj)
+			obj.Reverted = true
+			revertModels[obj.Model] = true
+		}
+		if reported, ok := s.objects[objKey]; !ok || !reported.isSameDrift(obj) {
+			raiseDriftEvent(obj)
+		}
+		confirmed[objKey] = obj
+	}
+
+	if len(revertModels) > 0 {
+		sharedQueue := utils.SharedWorkQueue().GetQueueByName(utils.GraphLayer)
+		for modelName := range revertModels {
+			nodes.PublishKeyToRestLayer(modelName, "drift", sharedQueue)
+		}
+	}
+	s.pending = detected
+	s.objects = confirmed
+	s.lastScan = time.Now()
+}
+
+func raiseDriftEvent(obj *driftObject) {
+	action := "not reverted as the drift policy is " + lib.DriftPolicyAlert
+	if obj.Reverted {
+		action = "reverting it by syncing the model " + obj.Model
+	} else if obj.Model == "" {
+		action = "not reverted as it is not part of any model"
+	}
+	switch obj.Reason {
+	case driftReasonModified:
+		utils.AviLog.Warnf("%s %s/%s was modified out of band, fields: %s, %s", obj.Type, obj.Tenant, obj.Name, strings.Join(obj.Fields, ","), action)
+		lib.AKOControlConfig().PodEventf(corev1.EventTypeWarning, lib.DriftDetected, "%s %s/%s was modified out of band in %s, %s",
+			obj.Type, obj.Tenant, obj.Name, strings.Join(obj.Fields, ","), action)
+	case driftReasonDeleted:
+		utils.AviLog.Warnf("%s %s/%s was deleted out of band, %s", obj.Type, obj.Tenant, obj.Name, action)
+		lib.AKOControlConfig().PodEventf(corev1.EventTypeWarning, lib.DriftDetected, "%s %s/%s was deleted out of band, %s",
+			obj.Type, obj.Tenant, obj.Name, action)
+	case driftReasonOrphan:
+		utils.AviLog.Warnf("%s %s/%s with uuid %s is not known to AKO", obj.Type, obj.Tenant, obj.Name, obj.Uuid)
+		lib.AKOControlConfig().PodEventf(corev1.EventTypeWarning, lib.OrphanDetected, "%s %s/%s with uuid %s is not known to AKO, it has to be deleted manually",
+			obj.Type, obj.Tenant, obj.Name, obj.Uuid)
+	}
+}
+
+// revertDriftObject queues the object to be reverted by the rest worker of its model, which updates the
+// cache so that the object is updated from the model, or created again if it was deleted.
+func revertDriftObject(obj *driftObject) {
+	rest.AddDriftRevert(obj.Model, rest.DriftRevert{
+		Model:   obj.Type,
+		Tenant:  obj.Tenant,
+		Name:    obj.Name,
+		Uuid:    obj.Uuid,
+		Deleted: obj.Reason == driftReasonDeleted,
+	})
+}
+
+// findDriftObjects returns the objects in the controller which do not match the cache, the objects in the
+// cache which are not in the controller, and the objects in the controller which are not known to AKO.
+func findDriftObjects(client *clients.AviClient) (map[string]*driftObject, error) {
+	objCache := avicache.SharedAviObjCache()
+	tenant := lib.GetTenant()
+
+	var vses []avimodels.VirtualService
+	uri := "/api/virtualservice/?include_name=true&cloud_ref.name=" + utils.CloudName + "&created_by=" + lib.AKOUser + "&page_size=100"
+	if err := fetchDriftVirtualServices(client, uri, &vses); err != nil {
+		return nil, err
+	}
+	var pools []avicache.AviPoolCache
+	if _, _, err := objCache.AviPopulateAllPools(client, utils.CloudName, &pools); err != nil {
+		return nil, err
+	}
+	var vsvips []avicache.AviVSVIPCache
+	if _, err := objCache.AviPopulateAllVSVips(client, utils.CloudName, &vsvips); err != nil {
+		return nil, err
+	}
+
+	modelObjs := getDriftModelObjects()
+	detected := make(map[string]*driftObject)
+	scan := &driftScan{tenant: tenant, modelObjs: modelObjs, detected: detected}
+
+	controllerVSes := make(map[string]bool, len(vses))
+	referredVsVips := make(map[string]bool)
+	for _, vs := range vses {
+		controllerVSes[*vs.Name] = true
+		if vs.VsvipRef != nil {
+			referredVsVips[avicache.ExtractUuid(*vs.VsvipRef, "vsvip-.*.#")] = true
+		}
+		ctrlObj := getDriftVS(vs)
+		isVHParent := vs.Type != nil && *vs.Type == utils.VS_TYPE_VH_PARENT
+		if cacheObj, ok := getDriftVSCache(avicache.NamespaceName{Namespace: tenant, Name: *vs.Name}); ok {
+			// The controller updates the virtual hosting parents when the children are added or removed.
+			scan.compare(debugTypeVS, *vs.Name, ctrlObj, cacheObj, isVHParent)
+		} else {
+			scan.checkOrphan(debugTypeVS, *vs.Name, ctrlObj)
+		}
+	}
+	for _, vsKey := range objCache.VsCacheMeta.AviGetAllKeys() {
+		if cacheObj, ok := getDriftVSCache(vsKey); ok && !controllerVSes[vsKey.Name] {
+			scan.checkDeleted(debugTypeVS, vsKey.Name, cacheObj)
+		}
+	}
+
+	controllerPools := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		controllerPools[pool.Name] = true
+		ctrlObj := getDriftPool(&pool, true)
+		if cacheObj, ok := getDriftPoolCache(avicache.NamespaceName{Namespace: tenant, Name: pool.Name}); ok {
+			scan.compare(debugTypePool, pool.Name, ctrlObj, cacheObj, false)
+		} else {
+			scan.checkOrphan(debugTypePool, pool.Name, ctrlObj)
+		}
+	}
+	for _, poolKey := range objCache.PoolCache.AviGetAllKeys() {
+		if cacheObj, ok := getDriftPoolCache(poolKey); ok && !controllerPools[poolKey.Name] {
+			scan.checkDeleted(debugTypePool, poolKey.Name, cacheObj)
+		}
+	}
+
+	// The vsvips do not have the created_by field, and are fetched by the name prefix of the cluster,
+	// so the vsvips referred by the virtualservices of the other AKO instances in the cluster are skipped.
+	var orphanVsVips []string
+	controllerVsVips := make(map[string]bool, len(vsvips))
+	for _, vsvip := range vsvips {
+		controllerVsVips[vsvip.Name] = true
+		ctrlObj := getDriftVsVip(&vsvip)
+		if cacheObj, ok := getDriftVsVipCache(avicache.NamespaceName{Namespace: tenant, Name: vsvip.Name}); ok {
+			scan.compare(debugTypeVSVIP, vsvip.Name, ctrlObj, cacheObj, false)
+		} else if !referredVsVips[vsvip.Uuid] && scan.checkOrphan(debugTypeVSVIP, vsvip.Name, ctrlObj) {
+			orphanVsVips = append(orphanVsVips, vsvip.Name)
+		}
+	}
+	for _, vsvipKey := range objCache.VSVIPCache.AviGetAllKeys() {
+		if cacheObj, ok := getDriftVsVipCache(vsvipKey); ok && !controllerVsVips[vsvipKey.Name] {
+			scan.checkDeleted(debugTypeVSVIP, vsvipKey.Name, cacheObj)
+		}
+	}
+	if len(orphanVsVips) > 0 {
+		var clusterVSes []avimodels.VirtualService
+		uri := "/api/virtualservice/?include_name=true&cloud_ref.name=" + utils.CloudName + "&name.contains=" + lib.GetNamePrefix() + "&fields=vsvip_ref&page_size=100"
+		if err := fetchDriftVirtualServices(client, uri, &clusterVSes); err != nil {
+			return nil, err
+		}
+		for _, vs := range clusterVSes {
+			if vs.VsvipRef != nil {
+				referredVsVips[avicache.ExtractUuid(*vs.VsvipRef, "vsvip-.*.#")] = true
+			}
+		}
+		for _, vsvipName := range orphanVsVips {
+			objKey := debugTypeVSVIP + "/" + tenant + "/" + vsvipName
+			if referredVsVips[detected[objKey].Uuid] {
+				delete(detected, objKey)
+			}
+		}
+	}
+	return detected, nil
+}
+
+// driftScan collects the objects found in a scan.
+type driftScan struct {
+	tenant    string
+	modelObjs map[string]driftModelObject
+	detected  map[string]*driftObject
+}
+
+func (s *driftScan) newDriftObject(objType, name, reason string) *driftObject {
+	obj := &driftObject{
+		Type:       objType,
+		Tenant:     s.tenant,
+		Name:       name,
+		Reason:     reason,
+		DetectedAt: time.Now(),
+	}
+	if modelObj, ok := s.modelObjs[obj.key()]; ok {
+		obj.Model, obj.ModelChecksum = modelObj.model, modelObj.checksum
+	}
+	return obj
+}
+
+func (s *driftScan) compare(objType, name string, ctrlObj, cacheObj driftAviObject, skipLastModified bool) {
+	var fields []string
+	if ctrlObj.uuid != cacheObj.uuid {
+		fields = append(fields, driftFieldUuid)
+	}
+	if ctrlObj.checksum != cacheObj.checksum {
+		fields = append(fields, driftFieldChecksum)
+	}
+	// The last modified time is not known for the objects synced before the controller returned it.
+	if !skipLastModified && cacheObj.lastModified != "" && ctrlObj.lastModified != cacheObj.lastModified {
+		fields = append(fields, driftFieldLastModified)
+	}
+	fieldNames := make([]string, 0, len(cacheObj.fields))
+	for field := range cacheObj.fields {
+		fieldNames = append(fieldNames, field)
+	}
+	sort.Strings(fieldNames)
+	for _, field := range fieldNames {
+		if ctrlObj.fields[field] != cacheObj.fields[field] {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	obj := s.newDriftObject(objType, name, driftReasonModified)
+	obj.Uuid = ctrlObj.uuid
+	obj.Fields = fields
+	obj.ControllerChecksum = ctrlObj.checksum
+	obj.CacheChecksum = cacheObj.checksum
+	obj.LastModified = ctrlObj.lastModified
+	s.detected[obj.key()] = obj
+}
+
+// checkOrphan flags an object which is neither in the cache nor in any model. The objects only in the
+// models are being created by AKO.
+func (s *driftScan) checkOrphan(objType, name string, ctrlObj driftAviObject) bool {
+	obj := s.newDriftObject(objType, name, driftReasonOrphan)
+	if obj.Model != "" {
+		return false
+	}
+	obj.Uuid = ctrlObj.uuid
+	obj.ControllerChecksum = ctrlObj.checksum
+	obj.LastModified = ctrlObj.lastModified
+	s.detected[obj.key()] = obj
+	return true
+}
+
+// checkDeleted flags an object in the cache which is not in the controller. The objects which are not in
+// any model are being deleted by AKO.
+func (s *driftScan) checkDeleted(objType, name string, cacheObj driftAviObject) {
+	obj := s.newDriftObject(objType, name, driftReasonDeleted)
+	if obj.Model == "" {
+		return
+	}
+	obj.Uuid = cacheObj.uuid
+	obj.CacheChecksum = cacheObj.checksum
+	s.detected[obj.key()] = obj
+}
+
+// getDriftModelObjects returns the virtualservices, pools and vsvips in the models, along with their models.
+func getDriftModelObjects() map[string]driftModelObject {
+	modelObjs := make(map[string]driftModelObject)
+	for _, modelName := range objects.SharedAviGraphLister().AviGraphStore.GetAllKeys() {
+		aviModel, err := getDebugAviModel(modelName)
+		if err != nil || aviModel.IsVrf {
+			continue
+		}
+		_, objs := debugModelObjects(aviModel)
+		for _, obj := range objs {
+			if obj.Type != debugTypeVS && obj.Type != debugTypePool && obj.Type != debugTypeVSVIP {
+				continue
+			}
+			modelObjs[obj.Type+"/"+obj.Tenant+"/"+obj.Name] = driftModelObject{model: modelName, checksum: obj.Checksum}
+		}
+	}
+	return modelObjs
+}
+
+func fetchDriftVirtualServices(client *clients.AviClient, uri string, vses *[]avimodels.VirtualService) error {
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		return err
+	}
+	elems := make([]json.RawMessage, result.Count)
+	if err := json.Unmarshal(result.Results, &elems); err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		vs := avimodels.VirtualService{}
+		if err := json.Unmarshal(elem, &vs); err != nil {
+			utils.AviLog.Warnf("Failed to unmarshal virtualservice data, err: %v", err)
+			continue
+		}
+		if vs.Name == nil || vs.UUID == nil {
+			continue
+		}
+		*vses = append(*vses, vs)
+	}
+	if result.Next != "" {
+		nextURI := strings.Split(result.Next, "/api/virtualservice")
+		if len(nextURI) > 1 {
+			return fetchDriftVirtualServices(client, "/api/virtualservice"+nextURI[1], vses)
+		}
+	}
+	return nil
+}
+
+func getDriftVS(vs avimodels.VirtualService) driftAviObject {
+	obj := driftAviObject{uuid: *vs.UUID, fields: make(map[string]string)}
+	if vs.CloudConfigCksum != nil {
+		obj.checksum = *vs.CloudConfigCksum
+	}
+	if vs.LastModified != nil {
+		obj.lastModified = *vs.LastModified
+	}
+	obj.fields[driftFieldEnableRhi] = strconv.FormatBool(vs.EnableRhi != nil && *vs.EnableRhi)
+	if vs.VsvipRef != nil {
+		obj.fields[driftFieldVsVipRef] = avicache.ExtractUuid(*vs.VsvipRef, "vsvip-.*.#")
+	}
+	return obj
+}
+
+func getDriftVSCache(vsKey avicache.NamespaceName) (driftAviObject, bool) {
+	vsCacheIntf, ok := avicache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+	if !ok {
+		return driftAviObject{}, false
+	}
+	vsCache, ok := vsCacheIntf.(*avicache.AviVsCache)
+	if !ok || vsCache == nil {
+		return driftAviObject{}, false
+	}
+	vsCache.VSCacheLock.RLock()
+	obj := driftAviObject{
+		uuid:         vsCache.Uuid,
+		checksum:     vsCache.CloudConfigCksum,
+		lastModified: vsCache.LastModified,
+		fields:       map[string]string{driftFieldEnableRhi: strconv.FormatBool(vsCache.EnableRhi)},
+	}
+	vsvipKeys := append([]avicache.NamespaceName{}, vsCache.VSVipKeyCollection...)
+	vsCache.VSCacheLock.RUnlock()
+	if obj.uuid == "" {
+		return driftAviObject{}, false
+	}
+	for _, vsvipKey := range vsvipKeys {
+		if vsvipUuid, _, found := getDebugCacheObject(debugTypeVSVIP, vsvipKey.Namespace, vsvipKey.Name); found {
+			obj.fields[driftFieldVsVipRef] = vsvipUuid
+		}
+	}
+	return obj, true
+}
+
+// getDriftPool returns the fields of a pool. The servers are compared only if they are known for the pool
+// in the cache.
+func getDriftPool(pool *avicache.AviPoolCache, withServers bool) driftAviObject {
+	obj := driftAviObject{
+		uuid:         pool.Uuid,
+		checksum:     pool.CloudConfigCksum,
+		lastModified: pool.LastModified,
+		fields:       make(map[string]string),
+	}
+	if withServers {
+		servers := make([]string, 0, len(pool.Servers))
+		for server, cksum := range pool.Servers {
+			servers = append(servers, fmt.Sprintf("%s/%d", server, cksum))
+		}
+		sort.Strings(servers)
+		obj.fields[driftFieldServers] = strings.Join(servers, ",")
+	}
+	return obj
+}
+
+func getDriftPoolCache(poolKey avicache.NamespaceName) (driftAviObject, bool) {
+	poolCacheIntf, ok := avicache.SharedAviObjCache().PoolCache.AviCacheGet(poolKey)
+	if !ok {
+		return driftAviObject{}, false
+	}
+	poolCache, ok := poolCacheIntf.(*avicache.AviPoolCache)
+	if !ok || poolCache.Uuid == "" {
+		return driftAviObject{}, false
+	}
+	return getDriftPool(poolCache, poolCache.Servers != nil), true
+}
+
+func getDriftVsVip(vsvip *avicache.AviVSVIPCache) driftAviObject {
+	fqdns := append([]string{}, vsvip.FQDNs...)
+	sort.Strings(fqdns)
+	vips := append([]string{}, vsvip.Vips...)
+	sort.Strings(vips)
+	return driftAviObject{
+		uuid:         vsvip.Uuid,
+		checksum:     vsvip.CloudConfigCksum,
+		lastModified: vsvip.LastModified,
+		fields: map[string]string{
+			driftFieldFqdns: strings.Join(fqdns, ","),
+			driftFieldVips:  strings.Join(vips, ","),
+		},
+	}
+}
+
+func getDriftVsVipCache(vsvipKey avicache.NamespaceName) (driftAviObject, bool) {
+	vsvipCacheIntf, ok := avicache.SharedAviObjCache().VSVIPCache.AviCacheGet(vsvipKey)
+	if !ok {
+		return driftAviObject{}, false
+	}
+	vsvipCache, ok := vsvipCacheIntf.(*avicache.AviVSVIPCache)
+	if !ok || vsvipCache.Uuid == "" {
+		return driftAviObject{}, false
+	}
+	return getDriftVsVip(vsvipCache), true
+}
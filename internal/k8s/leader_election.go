@@ -0,0 +1,143 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// RunLeaderElection starts contending for the AKO leader lease in the AKO namespace. Until the lease
+// is acquired, the replica runs as a hot standby, that is, the informers and the Avi object cache are
+// kept in sync, but the graph and the rest layers are not run, and no status is written. A replica
+// which loses the lease shuts down, and comes back up as a standby.
+func RunLeaderElection(kubeClient kubernetes.Interface, stopCh <-chan struct{}) {
+	identity := os.Getenv("POD_NAME")
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      lib.LeaderElectionLeaseName,
+			Namespace: utils.GetAKONamespace(),
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	leaderElectedCh := make(chan struct{})
+	lib.AKOControlConfig().SetLeaderElectedCh(leaderElectedCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	go leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            lib.LeaderElectionLeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				utils.AviLog.Infof("AKO %s acquired the leader lease", identity)
+				lib.AKOControlConfig().PodEventf(corev1.EventTypeNormal, lib.AKOLeader, "AKO is now the leader")
+				close(leaderElectedCh)
+			},
+			OnStoppedLeading: func() {
+				select {
+				case <-ctx.Done():
+					utils.AviLog.Infof("AKO %s released the leader lease", identity)
+				default:
+					lib.AKOControlConfig().PodEventf(corev1.EventTypeWarning, lib.AKOShutdown, "AKO lost the leader lease")
+					utils.AviLog.Fatalf("AKO %s lost the leader lease, shutting down AKO", identity)
+				}
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					utils.AviLog.Infof("AKO %s is the leader, running as standby", leader)
+				}
+			},
+		},
+	})
+}
+
+// waitForLeadership blocks until this AKO replica becomes the leader, and returns false if AKO is
+// shut down before that. The Avi object cache is refreshed when the replica becomes the leader, and
+// meanwhile at the configured standby refresh interval, so that the replica can take over from the
+// cache state instead of going through a full bootstrap.
+func (c *AviController) waitForLeadership(ctrlCh <-chan struct{}) bool {
+	if lib.AKOControlConfig().IsLeader() {
+		return true
+	}
+	utils.AviLog.Infof("AKO is running as standby, waiting to acquire the leader lease")
+	lib.AKOControlConfig().PodEventf(corev1.EventTypeNormal, lib.AKOStandby, "AKO is running as standby")
+
+	var refreshCh <-chan time.Time
+	if interval := lib.GetStandbyCacheRefreshInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		refreshCh = ticker.C
+	}
+	for {
+		select {
+		case <-lib.AKOControlConfig().LeaderElected():
+			utils.AviLog.Infof("AKO is now the leader, starting the sync")
+			refreshAviObjCache()
+			if !c.DisableSync {
+				CleanupAviObjects()
+			}
+			return true
+		case <-refreshCh:
+			refreshAviObjCache()
+		case <-ctrlCh:
+			return false
+		}
+	}
+}
+
+func refreshAviObjCache() {
+	aviRestClientPool := avicache.SharedAVIClients()
+	if aviRestClientPool == nil || len(aviRestClientPool.AviClient) == 0 {
+		return
+	}
+	utils.AviLog.Debugf("Refreshing the Avi object cache")
+	_, _, err := avicache.SharedAviObjCache().AviObjCachePopulate(aviRestClientPool.AviClient, utils.CtrlVersion, utils.CloudName)
+	if err != nil {
+		utils.AviLog.Warnf("Failed to refresh the Avi object cache, error: %v", err)
+	}
+}
@@ -42,9 +42,13 @@ func NewSvcApiInformers(cs svccrd.Interface) {
 	svcApiInfomerFactory := svcapiinformers.NewSharedInformerFactory(cs, time.Second*30)
 	gwClassInformer := svcApiInfomerFactory.Networking().V1alpha1().GatewayClasses()
 	gwInformer := svcApiInfomerFactory.Networking().V1alpha1().Gateways()
+	httpRouteInformer := svcApiInfomerFactory.Networking().V1alpha1().HTTPRoutes()
+	tcpRouteInformer := svcApiInfomerFactory.Networking().V1alpha1().TCPRoutes()
 	lib.AKOControlConfig().SetSvcAPIsInformers(&lib.ServicesAPIInformers{
 		GatewayInformer:      gwInformer,
 		GatewayClassInformer: gwClassInformer,
+		HTTPRouteInformer:    httpRouteInformer,
+		TCPRouteInformer:     tcpRouteInformer,
 	})
 }
 
@@ -66,6 +70,10 @@ func InformerStatusUpdatesForSvcApiGateway(key string, gateway *servicesapi.Gate
 	}
 
 	for _, listener := range gateway.Spec.Listeners {
+		if nodes.IsSvcApiRouteListener(listener) {
+			// listeners selecting HTTPRoutes/TCPRoutes do not select the services by gateway labels.
+			continue
+		}
 		gwName, nameOk := listener.Routes.Selector.MatchLabels[lib.SvcApiGatewayNameLabelKey]
 		gwNamespace, nsOk := listener.Routes.Selector.MatchLabels[lib.SvcApiGatewayNamespaceLabelKey]
 		if !nameOk || !nsOk ||
@@ -310,8 +318,136 @@ func (c *AviController) SetupSvcApiEventHandlers(numWorkers uint32) {
 		},
 	}
 
+	httpRouteEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			route := obj.(*servicesapi.HTTPRoute)
+			namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(route))
+			key := lib.HTTPRoute + "/" + utils.ObjKey(route)
+			if lib.IsNamespaceBlocked(namespace) || !utils.CheckIfNamespaceAccepted(namespace) {
+				utils.AviLog.Debugf("key: %s, msg: HTTPRoute add event. Namespace %s didn't qualify filter. Not adding route.", key, namespace)
+				return
+			}
+			utils.AviLog.Infof("key: %s, msg: ADD", key)
+			bkt := utils.Bkt(namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if c.DisableSync {
+				return
+			}
+			oldObj := old.(*servicesapi.HTTPRoute)
+			route := new.(*servicesapi.HTTPRoute)
+			if !reflect.DeepEqual(oldObj.Spec, route.Spec) || !reflect.DeepEqual(oldObj.Labels, route.Labels) {
+				namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(route))
+				key := lib.HTTPRoute + "/" + utils.ObjKey(route)
+				if lib.IsNamespaceBlocked(namespace) || !utils.CheckIfNamespaceAccepted(namespace) {
+					utils.AviLog.Debugf("key: %s, msg: HTTPRoute update event. Namespace %s didn't qualify filter. Not updating route.", key, namespace)
+					return
+				}
+				utils.AviLog.Infof("key: %s, msg: UPDATE", key)
+				bkt := utils.Bkt(namespace, numWorkers)
+				c.workqueue[bkt].AddRateLimited(key)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			route, ok := obj.(*servicesapi.HTTPRoute)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					utils.AviLog.Errorf("couldn't get object from tombstone %#v", obj)
+					return
+				}
+				route, ok = tombstone.Obj.(*servicesapi.HTTPRoute)
+				if !ok {
+					utils.AviLog.Errorf("Tombstone contained object that is not an HTTPRoute: %#v", obj)
+					return
+				}
+			}
+			namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(route))
+			key := lib.HTTPRoute + "/" + utils.ObjKey(route)
+			if lib.IsNamespaceBlocked(namespace) || !utils.CheckIfNamespaceAccepted(namespace) {
+				utils.AviLog.Debugf("key: %s, msg: HTTPRoute delete event. Namespace %s didn't qualify filter. Not deleting route.", key, namespace)
+				return
+			}
+			utils.AviLog.Infof("key: %s, msg: DELETE", key)
+			bkt := utils.Bkt(namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+		},
+	}
+
+	tcpRouteEventHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			route := obj.(*servicesapi.TCPRoute)
+			namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(route))
+			key := lib.TCPRoute + "/" + utils.ObjKey(route)
+			if lib.IsNamespaceBlocked(namespace) || !utils.CheckIfNamespaceAccepted(namespace) {
+				utils.AviLog.Debugf("key: %s, msg: TCPRoute add event. Namespace %s didn't qualify filter. Not adding route.", key, namespace)
+				return
+			}
+			utils.AviLog.Infof("key: %s, msg: ADD", key)
+			bkt := utils.Bkt(namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			if c.DisableSync {
+				return
+			}
+			oldObj := old.(*servicesapi.TCPRoute)
+			route := new.(*servicesapi.TCPRoute)
+			if !reflect.DeepEqual(oldObj.Spec, route.Spec) || !reflect.DeepEqual(oldObj.Labels, route.Labels) {
+				namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(route))
+				key := lib.TCPRoute + "/" + utils.ObjKey(route)
+				if lib.IsNamespaceBlocked(namespace) || !utils.CheckIfNamespaceAccepted(namespace) {
+					utils.AviLog.Debugf("key: %s, msg: TCPRoute update event. Namespace %s didn't qualify filter. Not updating route.", key, namespace)
+					return
+				}
+				utils.AviLog.Infof("key: %s, msg: UPDATE", key)
+				bkt := utils.Bkt(namespace, numWorkers)
+				c.workqueue[bkt].AddRateLimited(key)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if c.DisableSync {
+				return
+			}
+			route, ok := obj.(*servicesapi.TCPRoute)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					utils.AviLog.Errorf("couldn't get object from tombstone %#v", obj)
+					return
+				}
+				route, ok = tombstone.Obj.(*servicesapi.TCPRoute)
+				if !ok {
+					utils.AviLog.Errorf("Tombstone contained object that is not an TCPRoute: %#v", obj)
+					return
+				}
+			}
+			namespace, _, _ := cache.SplitMetaNamespaceKey(utils.ObjKey(route))
+			key := lib.TCPRoute + "/" + utils.ObjKey(route)
+			if lib.IsNamespaceBlocked(namespace) || !utils.CheckIfNamespaceAccepted(namespace) {
+				utils.AviLog.Debugf("key: %s, msg: TCPRoute delete event. Namespace %s didn't qualify filter. Not deleting route.", key, namespace)
+				return
+			}
+			utils.AviLog.Infof("key: %s, msg: DELETE", key)
+			bkt := utils.Bkt(namespace, numWorkers)
+			c.workqueue[bkt].AddRateLimited(key)
+		},
+	}
+
 	informer.GatewayInformer.Informer().AddEventHandler(gatewayEventHandler)
 	informer.GatewayClassInformer.Informer().AddEventHandler(gatewayClassEventHandler)
+	informer.HTTPRouteInformer.Informer().AddEventHandler(httpRouteEventHandler)
+	informer.TCPRouteInformer.Informer().AddEventHandler(tcpRouteEventHandler)
 	return
 }
 
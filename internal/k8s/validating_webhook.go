@@ -0,0 +1,192 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/apis/ako/v1alpha1"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+// ValidatingWebhookServer serves the admission reviews for the AKO CRDs over HTTPS. The same
+// checks that are run on the CRD objects during ingestion are run at admission time, so that
+// invalid objects are rejected before they are stored.
+type ValidatingWebhookServer struct {
+	http.Server
+	CertFile string
+	KeyFile  string
+}
+
+// NewValidatingWebhookServer returns a webhook server listening on the given port, using the
+// tls.crt and tls.key files present in certDir.
+func NewValidatingWebhookServer(port, certDir string) *ValidatingWebhookServer {
+	mux := http.NewServeMux()
+	mux.HandleFunc(lib.ValidatingWebhookPath, ServeValidatingWebhook)
+	return &ValidatingWebhookServer{
+		Server: http.Server{
+			Addr:         ":" + port,
+			Handler:      mux,
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		CertFile: filepath.Join(certDir, "tls.crt"),
+		KeyFile:  filepath.Join(certDir, "tls.key"),
+	}
+}
+
+// Run starts the webhook server, and shuts it down once the stopCh is closed.
+func (w *ValidatingWebhookServer) Run(stopCh <-chan struct{}) {
+	go func() {
+		utils.AviLog.Infof("Starting validating webhook server at %s", w.Addr)
+		err := w.ListenAndServeTLS(w.CertFile, w.KeyFile)
+		if err != nil && err != http.ErrServerClosed {
+			utils.AviLog.Errorf("Validating webhook server shutdown: %v", err)
+		}
+	}()
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		utils.AviLog.Infof("Shutting down the validating webhook server")
+		if err := w.Shutdown(ctx); err != nil {
+			utils.AviLog.Warnf("Error shutting down the validating webhook server: %v", err)
+		}
+	}()
+}
+
+// ServeValidatingWebhook decodes the AdmissionReview in the request and responds with the
+// validation result for the object under review.
+func ServeValidatingWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		utils.AviLog.Warnf("Unable to decode admission review: %v", err)
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = ValidateAdmissionRequest(review.Request)
+	resp, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// ValidateAdmissionRequest runs the validation checks for the HostRule, HTTPRule, AviInfraSetting
+// and MultiClusterIngress objects in the admission request. Objects of other kinds, and delete
+// requests are always allowed.
+func ValidateAdmissionRequest(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{
+		UID:     req.UID,
+		Allowed: true,
+	}
+	if req.Operation == admissionv1.Delete {
+		return response
+	}
+
+	if err := validateAdmissionObject(req); err != nil {
+		utils.AviLog.Warnf("key: %s/%s/%s, msg: admission denied: %v", req.Kind.Kind, req.Namespace, req.Name, err)
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+		}
+	}
+	return response
+}
+
+func validateAdmissionObject(req *admissionv1.AdmissionRequest) error {
+	// Refs on the controller are checked only once AKO has established the connection to the controller.
+	checkRefs := isAviControllerReachable()
+
+	switch req.Kind.Kind {
+	case lib.HostRule:
+		hostrule := &akov1alpha1.HostRule{}
+		if err := json.Unmarshal(req.Object.Raw, hostrule); err != nil {
+			return fmt.Errorf("unable to decode HostRule: %v", err)
+		}
+		setAdmissionObjectMeta(req, &hostrule.ObjectMeta)
+		key := lib.HostRule + "/" + utils.ObjKey(hostrule)
+		return checkHostRuleObj(key, hostrule, checkRefs)
+	case lib.HTTPRule:
+		httprule := &akov1alpha1.HTTPRule{}
+		if err := json.Unmarshal(req.Object.Raw, httprule); err != nil {
+			return fmt.Errorf("unable to decode HTTPRule: %v", err)
+		}
+		setAdmissionObjectMeta(req, &httprule.ObjectMeta)
+		key := lib.HTTPRule + "/" + utils.ObjKey(httprule)
+		return checkHTTPRuleObj(key, httprule, checkRefs)
+	case lib.AviInfraSetting:
+		infraSetting := &akov1alpha1.AviInfraSetting{}
+		if err := json.Unmarshal(req.Object.Raw, infraSetting); err != nil {
+			return fmt.Errorf("unable to decode AviInfraSetting: %v", err)
+		}
+		setAdmissionObjectMeta(req, &infraSetting.ObjectMeta)
+		key := lib.AviInfraSetting + "/" + utils.ObjKey(infraSetting)
+		return checkAviInfraSettingObj(key, infraSetting, checkRefs)
+	case lib.MultiClusterIngress:
+		mci := &akov1alpha1.MultiClusterIngress{}
+		if err := json.Unmarshal(req.Object.Raw, mci); err != nil {
+			return fmt.Errorf("unable to decode MultiClusterIngress: %v", err)
+		}
+		setAdmissionObjectMeta(req, &mci.ObjectMeta)
+		key := lib.MultiClusterIngress + "/" + utils.ObjKey(mci)
+		return checkMultiClusterIngressObj(key, mci)
+	}
+	return nil
+}
+
+// setAdmissionObjectMeta fills in the namespace and name from the admission request, since these
+// are not always set in the object on creation, e.g. when generateName is used.
+func setAdmissionObjectMeta(req *admissionv1.AdmissionRequest, meta *metav1.ObjectMeta) {
+	if meta.Namespace == "" {
+		meta.Namespace = req.Namespace
+	}
+	if meta.Name == "" {
+		meta.Name = req.Name
+	}
+}
+
+// isAviControllerReachable checks whether the avi clients used for the ref checks are initialized.
+func isAviControllerReachable() bool {
+	clients := avicache.AviClientInstance
+	return clients != nil && len(clients.AviClient) > int(lib.GetshardSize())
+}
@@ -101,11 +101,15 @@ const (
 	StatusAccepted                             = "Accepted"
 	AllowedApplicationProfile                  = "APPLICATION_PROFILE_TYPE_HTTP"
 	TypeTLSReencrypt                           = "reencrypt"
+	MatchTypeExact                             = "exact"
+	MatchTypeContains                          = "contains"
 	DefaultPoolSSLProfile                      = "System-Standard"
 	LB_ALGORITHM_CONSISTENT_HASH_CUSTOM_HEADER = "LB_ALGORITHM_CONSISTENT_HASH_CUSTOM_HEADER"
 	LB_ALGORITHM_CONSISTENT_HASH               = "LB_ALGORITHM_CONSISTENT_HASH"
 	Gateway                                    = "Gateway"
 	GatewayClass                               = "GatewayClass"
+	HTTPRoute                                  = "HTTPRoute"
+	TCPRoute                                   = "TCPRoute"
 	DuplicateBackends                          = "MultipleBackendsWithSameServiceError"
 	DummyVSForStaleData                        = "DummyVSForStaleData"
 	ControllerReqWaitTime                      = 300
@@ -123,7 +127,9 @@ const (
 	ClusterNameLabelKey                        = "clustername"
 	UpdateStatus                               = "UpdateStatus"
 	DeleteStatus                               = "DeleteStatus"
+	UpdateRuntimeStatus                        = "UpdateRuntimeStatus"
 	NPLService                                 = "NPLService"
+	SvcApiRoute                                = "SvcApiRoute"
 	SyncStatusKey                              = "syncstatus"
 	NoFreeIPError                              = "No available free IPs"
 	ConfigDisallowedDuringUpgradeError         = "Configuration is disallowed during upgrade"
@@ -138,11 +144,15 @@ const (
 	HTTPRedirectPolicy                         = "HTTP Redirect Policy"
 	HeaderRewritePolicy                        = "Header Rewrite Policy"
 	L4VS                                       = "L4 Virtual Service"
+	L4ChildVS                                  = "L4 Child Virtual Service"
 	L4VIP                                      = "L4 VIP"
 	L4Pool                                     = "L4 Pool"
 	L4AdvPool                                  = "L4 Advance Pool"
 	L4PS                                       = "L4 Policyset"
 	L4PSRule                                   = "L4 Policyset Rule"
+	L4NSP                                      = "L4 Network Security Policy"
+	L4PersistenceProfile                       = "L4 Persistence Profile"
+	ProbeHealthMonitor                         = "Probe Health Monitor"
 	SNIVS                                      = "SNI VirtualService"
 	VIP                                        = "VS VIP"
 	PG                                         = "Poolgroup"
@@ -180,6 +190,32 @@ const (
 	IstioCertOutputPath                        = "/etc/istio-output-certs"
 	IstioSecret                                = "istio-secret"
 	IstioModel                                 = "istioModel"
+	ValidatingWebhookCertDir                   = "/etc/ako/webhook-certs"
+	ValidatingWebhookPath                      = "/validate"
+	LeaderElectionLeaseName                    = "ako-leader-election"
+	MinStandbyCacheRefreshInterval             = 60   // seconds
+	LocalZoneServerRatio                       = 20   // ratio of the pool servers in the SE zone
+	RemoteZoneServerRatio                      = 1    // ratio of the pool servers in the other zones
+	MaxGracefulDisableTimeout                  = 7200 // minutes
+	PodReadinessGateSyncInterval               = 10   // seconds
+	MinPodReadinessGateSyncInterval            = 5    // seconds
+	RuntimeStatusSyncInterval                  = 30   // seconds
+	MinRuntimeStatusSyncInterval               = 10   // seconds
+	MinDriftScanInterval                       = 60   // seconds
+	MaxClientIPPersistenceTimeout              = 720  // minutes
+
+	// Types of the health monitors created from the readiness probes
+	HealthMonitorTypeHTTP  = "HEALTH_MONITOR_HTTP"
+	HealthMonitorTypeHTTPS = "HEALTH_MONITOR_HTTPS"
+	HealthMonitorTypeTCP   = "HEALTH_MONITOR_TCP"
+
+	// Policies for the Avi objects found to be changed out of band by the drift scanner
+	DriftPolicyAlert  = "Alert"
+	DriftPolicyRevert = "Revert"
+
+	// Types of the conditions set from the runtime status of the virtual services and pools
+	VirtualServiceReadyConditionType = "AviVirtualServiceReady"
+	BackendsHealthyConditionType     = "BackendsHealthy"
 
 	// AKO Event constants
 	AKOEventComponent      = "avi-kubernetes-operator"
@@ -188,6 +224,8 @@ const (
 	ValidatedUserInput     = "ValidatedUserInput"
 	StatusSync             = "StatusSync"
 	AKOReady               = "AKOReady"
+	AKOStandby             = "AKOStandby"
+	AKOLeader              = "AKOLeader"
 	AKOPause               = "AKOPause"
 	DuplicateHostPath      = "DuplicateHostPath"
 	DuplicateHost          = "DuplicateHost"
@@ -199,6 +237,8 @@ const (
 	AKODeleteConfigUnset   = "AKODeleteConfigUnset"
 	AKODeleteConfigDone    = "AKODeleteConfigDone"
 	AKODeleteConfigTimeout = "AKODeleteConfigTimeout"
+	DriftDetected          = "DriftDetected"
+	OrphanDetected         = "OrphanDetected"
 
 	DefaultIngressClassAnnotation  = "ingressclass.kubernetes.io/is-default-class"
 	ExternalDNSAnnotation          = "external-dns.alpha.kubernetes.io/hostname"
@@ -206,7 +246,9 @@ const (
 	IngressFinalizer               = "ingress.ako.vmware.com/finalizer"
 	AkoGroup                       = "ako.vmware.com"
 	AviIngressController           = "ako.vmware.com/avi-lb"
+	AviLoadBalancerClass           = "ako.vmware.com/avi-lb"
 	AKOConditionType               = "ako.vmware.com/ObjectDeletionInProgress"
+	PoolMemberReadyConditionType   = "ako.vmware.com/pool-member-ready"
 	DefaultSecretEnabled           = "ako.vmware.com/enable-tls"
 	GatewayNameLabelKey            = "service.route.lbapi.run.tanzu.vmware.com/gateway-name"
 	GatewayNamespaceLabelKey       = "service.route.lbapi.run.tanzu.vmware.com/gateway-namespace"
@@ -225,6 +267,7 @@ const (
 	WCPCloud                       = "ako.vmware.com/wcp-cloud-name"
 	VSAnnotation                   = "ako.vmware.com/host-fqdn-vs-uuid-map"
 	ControllerAnnotation           = "ako.vmware.com/controller-cluster-uuid"
+	RuntimeConditionsAnnotation    = "ako.vmware.com/runtime-conditions"
 	SharedVipSvcLBAnnotation       = "ako.vmware.com/enable-shared-vip"
 
 	// Specifies command used in namespace event handler
@@ -234,6 +277,17 @@ const (
 	AVI_OBJ_NAME_MAX_LENGTH        = 255
 )
 
+// HTTP methods supported in the HTTPRule path matches.
+var HTTPRuleMatchMethods = []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS", "TRACE", "CONNECT", "PATCH"}
+
+// Pool load balancing algorithms.
+const (
+	LB_ALGORITHM_ROUND_ROBIN                       = "LB_ALGORITHM_ROUND_ROBIN"
+	LB_ALGORITHM_LEAST_CONNECTIONS                 = "LB_ALGORITHM_LEAST_CONNECTIONS"
+	LB_ALGORITHM_RANDOM                            = "LB_ALGORITHM_RANDOM"
+	LB_ALGORITHM_CONSISTENT_HASH_SOURCE_IP_ADDRESS = "LB_ALGORITHM_CONSISTENT_HASH_SOURCE_IP_ADDRESS"
+)
+
 // Cache Indexer constants.
 const (
 	// AviSettingGWClassIndex maintains a map of AviInfraSetting Name to
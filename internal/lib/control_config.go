@@ -47,6 +47,8 @@ type AdvL4Informers struct {
 type ServicesAPIInformers struct {
 	GatewayInformer      svcInformer.GatewayInformer
 	GatewayClassInformer svcInformer.GatewayClassInformer
+	HTTPRouteInformer    svcInformer.HTTPRouteInformer
+	TCPRouteInformer     svcInformer.TCPRouteInformer
 }
 
 type AKOCrdInformers struct {
@@ -114,6 +116,10 @@ type akoControlConfig struct {
 
 	//blockedNS contains map of blocked namespaces and checksum of it
 	blockedNS BlockedNamespaces
+
+	// leaderElectedCh is closed once this AKO replica acquires the leader lease.
+	// It is nil if leader election is not enabled, in which case AKO is always the leader.
+	leaderElectedCh chan struct{}
 }
 
 var akoControlConfigInstance *akoControlConfig
@@ -272,6 +278,30 @@ func (c *akoControlConfig) EventRecorder() *utils.EventRecorder {
 	return c.akoEventRecorder
 }
 
+func (c *akoControlConfig) SetLeaderElectedCh(ch chan struct{}) {
+	c.leaderElectedCh = ch
+}
+
+// LeaderElected returns a channel which is closed once this AKO replica becomes the leader.
+func (c *akoControlConfig) LeaderElected() <-chan struct{} {
+	if c.leaderElectedCh == nil {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	return c.leaderElectedCh
+}
+
+// IsLeader returns true if this AKO replica holds the leader lease, or if leader election is not enabled.
+func (c *akoControlConfig) IsLeader() bool {
+	select {
+	case <-c.LeaderElected():
+		return true
+	default:
+		return false
+	}
+}
+
 func (c *akoControlConfig) SaveAKOPodObjectMeta(pod *v1.Pod) {
 	c.akoPodObjectMeta = &pod.ObjectMeta
 }
@@ -22,12 +22,15 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api"
@@ -83,6 +86,8 @@ type ServiceMetadataObj struct {
 	PoolRatio             int32       `json:"pool_ratio"`
 	PassthroughParentRef  string      `json:"passthrough_parent_ref"`
 	PassthroughChildRef   string      `json:"passthrough_child_ref"`
+	L4ParentRef           string      `json:"l4_parent_ref,omitempty"`
+	L4ChildRefs           []string    `json:"l4_child_refs,omitempty"`
 	Gateway               string      `json:"gateway"` // ns/name
 	InsecureEdgeTermAllow bool        `json:"insecureedgetermallow"`
 	IsMCIIngress          bool        `json:"is_mci_ingress"`
@@ -308,6 +313,15 @@ func GetshardSize() uint32 {
 	}
 }
 
+// GetL7ShardScheme returns the scheme used to place the hosts on the shared L7 VSes, hostname
+// by default. With the namespace scheme, all the hosts of a namespace are placed on the same VS.
+func GetL7ShardScheme() string {
+	if shardScheme, ok := ShardSchemeMap[os.Getenv("L7_SHARD_SCHEME")]; ok {
+		return shardScheme
+	}
+	return DEFAULT_SHARD_SCHEME
+}
+
 func GetL4FqdnFormat() string {
 	if GetAdvancedL4() {
 		// disable for advancedL4
@@ -343,6 +357,100 @@ func GetL4PoolName(svcName, namespace, protocol string, port int32) string {
 	return Encode(poolName, L4Pool)
 }
 
+var httpHeaderActions = map[string]string{
+	"Add":     "HTTP_ADD_HDR",
+	"Replace": "HTTP_REPLACE_HDR",
+	"Remove":  "HTTP_REMOVE_HDR",
+}
+
+// GetHTTPHeaderAction returns the Avi header action for the given HostRule header action.
+func GetHTTPHeaderAction(action string) (string, bool) {
+	aviAction, ok := httpHeaderActions[action]
+	return aviAction, ok
+}
+
+// GetHostRuleHTTPPolicySetName returns the name of the httppolicyset created for the inline http policy
+// rules of the HostRule applied to the given virtualservice.
+func GetHostRuleHTTPPolicySetName(vsName string) string {
+	return Encode(vsName+"-hostrule", HTTPPS)
+}
+
+// dataScriptEvents are the datascript events that can be used in the datascript ConfigMaps of the HostRule.
+var dataScriptEvents = map[string]bool{
+	"VS_DATASCRIPT_EVT_HTTP_REQ":           true,
+	"VS_DATASCRIPT_EVT_HTTP_RESP":          true,
+	"VS_DATASCRIPT_EVT_HTTP_RESP_DATA":     true,
+	"VS_DATASCRIPT_EVT_HTTP_LB_FAILED":     true,
+	"VS_DATASCRIPT_EVT_HTTP_REQ_DATA":      true,
+	"VS_DATASCRIPT_EVT_HTTP_RESP_FAILED":   true,
+	"VS_DATASCRIPT_EVT_HTTP_LB_DONE":       true,
+	"VS_DATASCRIPT_EVT_HTTP_AUTH":          true,
+	"VS_DATASCRIPT_EVT_HTTP_POST_AUTH":     true,
+	"VS_DATASCRIPT_EVT_TCP_CLIENT_ACCEPT":  true,
+	"VS_DATASCRIPT_EVT_SSL_HANDSHAKE_DONE": true,
+}
+
+// IsValidDataScriptEvent checks if the key of a datascript ConfigMap is a valid datascript event.
+func IsValidDataScriptEvent(evt string) bool {
+	return dataScriptEvents[evt]
+}
+
+// GetHostRuleDataScriptName returns the name of the vsdatascriptset created from a datascript ConfigMap
+// of the HostRule applied to the given virtualservice.
+func GetHostRuleDataScriptName(vsName, namespace, configMapName string) string {
+	return Encode(vsName+"-"+namespace+"-"+configMapName, DataScript)
+}
+
+// DataScriptSetChecksum returns the checksum of a vsdatascriptset from its poolgroups, markers and datascripts.
+// It is used for the vsdatascriptsets built by AKO as well as for the ones read from the controller, so that
+// the checksums of the unchanged vsdatascriptsets match across the restarts.
+func DataScriptSetChecksum(pgrefs []string, markers []*models.RoleFilterMatchLabel, datascripts []*models.VSDataScript) uint32 {
+	var evts, scripts []string
+	for _, ds := range datascripts {
+		var evt, script string
+		if ds.Evt != nil {
+			evt = *ds.Evt
+		}
+		if ds.Script != nil {
+			script = *ds.Script
+		}
+		evts = append(evts, evt)
+		scripts = append(scripts, script)
+	}
+	return DSChecksum(pgrefs, markers, true) + utils.Hash(strings.Join(evts, ":")+utils.Stringify(scripts))
+}
+
+// GetL4ChildVSName returns the name of the virtual service created for a listener of an L4 service,
+// which cannot share the virtual service of the other listeners.
+func GetL4ChildVSName(svcName, namespace, protocol string, port int32) string {
+	vsName := NamePrefix + namespace + "-" + svcName + "-" + protocol + "-" + strconv.Itoa(int(port))
+	return Encode(vsName, L4ChildVS)
+}
+
+// GetL4NetworkProfile returns the network profile for a listener of an L4 virtual service with the given protocol.
+func GetL4NetworkProfile(protocol string) string {
+	switch protocol {
+	case utils.UDP:
+		return utils.SYSTEM_UDP_FAST_PATH
+	case utils.SCTP:
+		return utils.SYSTEM_SCTP_PROXY
+	}
+	if AKOControlConfig().GetLicenseType() == "ENTERPRISE" {
+		return utils.DEFAULT_TCP_NW_PROFILE
+	}
+	return utils.TCP_NW_FAST_PATH
+}
+
+// GetProbeHealthMonitorName returns the name of the health monitor of a pool, created from the readiness probe of its pods.
+func GetProbeHealthMonitorName(poolName string) string {
+	return Encode(poolName, ProbeHealthMonitor)
+}
+
+// GetL4PersistenceProfileName returns the name of the client IP persistence profile of an L4 pool.
+func GetL4PersistenceProfileName(poolName string) string {
+	return Encode(poolName, L4PersistenceProfile)
+}
+
 func GetAdvL4PoolName(svcName, namespace, gwName string, port int32) string {
 	poolName := NamePrefix + namespace + "-" + svcName + "-" + gwName + "--" + strconv.Itoa(int(port))
 	return Encode(poolName, L4AdvPool)
@@ -467,6 +575,18 @@ func GetSniHppMapName(ingName, namespace, host, path, infrasetting string, dedic
 	return Encode(hppmap, HPPMAP)
 }
 
+// GetHTTPRuleMatchName returns the name of the object built for the match at index idx of
+// a HTTPRule path, derived from the name of the corresponding object of the path.
+func GetHTTPRuleMatchName(name string, idx int, objType string) string {
+	return Encode(fmt.Sprintf("%s--match-%d", name, idx), objType)
+}
+
+// GetHTTPRuleBackendName returns the name of the pool at index idx, among the pools built for the
+// weighted backends of a HTTPRule path, derived from the name of the poolgroup/pool of the path.
+func GetHTTPRuleBackendName(name string, idx int) string {
+	return Encode(fmt.Sprintf("%s--backend-%d", name, idx), Pool)
+}
+
 func GetSniPGName(ingName, namespace, host, path, infrasetting string, dedicatedVS bool) string {
 	path = strings.ReplaceAll(path, "/", "_")
 	var sniPGName string
@@ -657,6 +777,204 @@ func GetAkoApiServerPort() string {
 	return "8080"
 }
 
+// IsValidatingWebhookEnabled returns true if AKO should serve the validating admission
+// webhook for the HostRule, HTTPRule, AviInfraSetting and MultiClusterIngress CRDs.
+func IsValidatingWebhookEnabled() bool {
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_VALIDATING_WEBHOOK")); ok {
+		return true
+	}
+	return false
+}
+
+// The port to run the AKO validating webhook server on
+func GetValidatingWebhookPort() string {
+	port := os.Getenv("VALIDATING_WEBHOOK_PORT")
+	if port != "" {
+		return port
+	}
+	// Default case, if not specified.
+	return "9443"
+}
+
+// IsLeaderElectionEnabled returns true if multiple AKO replicas are run, out of which only the
+// replica holding the leader lease syncs the objects to the Avi controller.
+func IsLeaderElectionEnabled() bool {
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_LEADER_ELECTION")); ok {
+		return true
+	}
+	return false
+}
+
+// IsDryRunEnabled returns true if AKO should only plan the Avi REST operations for the models, and
+// expose them through the dry run api, without executing them on the Avi controller.
+func IsDryRunEnabled() bool {
+	if ok, _ := strconv.ParseBool(os.Getenv("DRY_RUN")); ok {
+		return true
+	}
+	return false
+}
+
+// IsEndpointSliceEnabled returns true if AKO should build the pool servers from the discovery.k8s.io
+// EndpointSlices of the services, instead of the core Endpoints objects.
+func IsEndpointSliceEnabled() bool {
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_ENDPOINTSLICE")); ok {
+		return true
+	}
+	return false
+}
+
+// GetServerDrainTimeout returns the time for which the pool servers of the terminating or removed endpoints
+// are kept disabled in the pool, to drain their connections, before they are removed. The servers are removed
+// right away if it is not set.
+func GetServerDrainTimeout() time.Duration {
+	drainTimeout, err := strconv.Atoi(os.Getenv("SERVER_DRAIN_TIMEOUT"))
+	if err != nil || drainTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(drainTimeout) * time.Second
+}
+
+// IsServerDrainEnabled returns true if the pool servers should be drained before they are removed. The pool
+// servers are mapped to the endpoints of the services, so the drain is supported only in ClusterIP mode.
+func IsServerDrainEnabled() bool {
+	if GetServiceType() == NODE_PORT || GetServiceType() == NodePortLocal {
+		return false
+	}
+	return GetServerDrainTimeout() > 0
+}
+
+// GetGracefulDisableTimeout returns the graceful disable timeout of the pools in minutes, for which the Service
+// Engines keep the existing connections to the disabled servers.
+func GetGracefulDisableTimeout() int32 {
+	timeout := int32(math.Ceil(GetServerDrainTimeout().Minutes()))
+	if timeout > MaxGracefulDisableTimeout {
+		timeout = MaxGracefulDisableTimeout
+	}
+	return timeout
+}
+
+// IsPodReadinessGateEnabled returns true if AKO should set the pool member readiness gate condition of the pods,
+// based on the runtime status of their pool servers. The pool servers are mapped to the pods with the pod IPs,
+// so the readiness gate is supported only in ClusterIP mode.
+func IsPodReadinessGateEnabled() bool {
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_POD_READINESS_GATE")); !ok {
+		return false
+	}
+	if GetServiceType() == NODE_PORT || GetServiceType() == NodePortLocal {
+		return false
+	}
+	return true
+}
+
+// IsProbeHealthMonitorEnabled returns true if AKO should create the health monitors of the pools from the
+// readiness probes of the pods. The health monitors probe the pods directly, so this is supported only in
+// ClusterIP mode.
+func IsProbeHealthMonitorEnabled() bool {
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_PROBE_HEALTH_MONITOR")); !ok {
+		return false
+	}
+	if GetServiceType() == NODE_PORT || GetServiceType() == NodePortLocal {
+		return false
+	}
+	return true
+}
+
+// WarnUnsupportedPodFeatures logs a warning at startup for each of the features based on the pods of the pool
+// servers which is enabled, but is not supported with the service type, and is thus ignored.
+func WarnUnsupportedPodFeatures() {
+	if GetServiceType() != NODE_PORT && GetServiceType() != NodePortLocal {
+		return
+	}
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_POD_READINESS_GATE")); ok {
+		utils.AviLog.Warnf("Pod readiness gate is not supported with service type %s", GetServiceType())
+	}
+	if ok, _ := strconv.ParseBool(os.Getenv("ENABLE_PROBE_HEALTH_MONITOR")); ok {
+		utils.AviLog.Warnf("Health monitors from readiness probes are not supported with service type %s", GetServiceType())
+	}
+	if GetServerDrainTimeout() > 0 {
+		utils.AviLog.Warnf("Draining the pool servers is not supported with service type %s", GetServiceType())
+	}
+}
+
+// IsRuntimeStatusEnabled returns true if AKO should set the runtime status of the virtual services and pools
+// as conditions on the Kubernetes objects, and raise events when they are not ready.
+func IsRuntimeStatusEnabled() bool {
+	ok, _ := strconv.ParseBool(os.Getenv("ENABLE_RUNTIME_STATUS"))
+	return ok
+}
+
+// GetDriftScanInterval returns the interval at which the AKO created virtual services, pools and vsvips in the
+// Avi Controller are compared with the cache, to find the changes made to them out of band. The drift scanner is
+// disabled if it is not set.
+func GetDriftScanInterval() time.Duration {
+	interval, err := strconv.Atoi(os.Getenv("DRIFT_SCAN_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 0
+	}
+	if interval < MinDriftScanInterval {
+		utils.AviLog.Warnf("Drift scan interval %d is less than %d seconds, using %d seconds", interval, MinDriftScanInterval, MinDriftScanInterval)
+		interval = MinDriftScanInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// GetStandbyCacheRefreshInterval returns the interval at which the standby AKO replicas refresh their Avi object
+// cache. The periodic refresh is disabled if it is not set, and the cache is then refreshed only when the replica
+// becomes the leader.
+func GetStandbyCacheRefreshInterval() time.Duration {
+	interval, err := strconv.Atoi(os.Getenv("STANDBY_CACHE_REFRESH_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 0
+	}
+	if interval < MinStandbyCacheRefreshInterval {
+		utils.AviLog.Warnf("Standby cache refresh interval %d is less than %d seconds, using %d seconds", interval, MinStandbyCacheRefreshInterval, MinStandbyCacheRefreshInterval)
+		interval = MinStandbyCacheRefreshInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// GetPodReadinessGateSyncInterval returns the interval at which the pool member readiness gate condition of the
+// pods is synced, when the pod readiness gate is enabled.
+func GetPodReadinessGateSyncInterval() time.Duration {
+	interval, err := strconv.Atoi(os.Getenv("POD_READINESS_GATE_SYNC_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = PodReadinessGateSyncInterval
+	}
+	if interval < MinPodReadinessGateSyncInterval {
+		utils.AviLog.Warnf("Pod readiness gate sync interval %d is less than %d seconds, using %d seconds", interval, MinPodReadinessGateSyncInterval, MinPodReadinessGateSyncInterval)
+		interval = MinPodReadinessGateSyncInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// GetRuntimeStatusSyncInterval returns the interval at which the runtime status of the virtual services and pools
+// is fetched from the Avi Controller, when the runtime status is enabled.
+func GetRuntimeStatusSyncInterval() time.Duration {
+	interval, err := strconv.Atoi(os.Getenv("RUNTIME_STATUS_SYNC_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = RuntimeStatusSyncInterval
+	}
+	if interval < MinRuntimeStatusSyncInterval {
+		utils.AviLog.Warnf("Runtime status sync interval %d is less than %d seconds, using %d seconds", interval, MinRuntimeStatusSyncInterval, MinRuntimeStatusSyncInterval)
+		interval = MinRuntimeStatusSyncInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// GetDriftPolicy returns whether the objects changed out of band should be reverted by AKO, or only reported.
+func GetDriftPolicy() string {
+	if strings.EqualFold(os.Getenv("DRIFT_POLICY"), DriftPolicyRevert) {
+		return DriftPolicyRevert
+	}
+	return DriftPolicyAlert
+}
+
+// GetSEZone returns the zone in which the Service Engines are placed. If set, the pool servers in the
+// same zone are preferred over the servers in the other zones.
+func GetSEZone() string {
+	return os.Getenv("SE_ZONE")
+}
+
 var VipNetworkList []akov1alpha1.AviInfraSettingVipNetwork
 
 func SetVipNetworkList(vipNetworks []akov1alpha1.AviInfraSettingVipNetwork) {
@@ -959,13 +1277,34 @@ func IsClusterNameValid() (bool, error) {
 }
 
 var StaticRouteSyncChan chan struct{}
+var staticRouteSyncLock sync.Mutex
 var ConfigDeleteSyncChan chan struct{}
 
 var akoApi api.ApiServerInterface
 
 func SetStaticRouteSyncHandler() {
+	staticRouteSyncLock.Lock()
+	defer staticRouteSyncLock.Unlock()
 	StaticRouteSyncChan = make(chan struct{})
 }
+
+// GetStaticRouteSyncChan returns the channel which is closed once the vrf model is processed by the rest layer.
+func GetStaticRouteSyncChan() chan struct{} {
+	staticRouteSyncLock.Lock()
+	defer staticRouteSyncLock.Unlock()
+	return StaticRouteSyncChan
+}
+
+// CloseStaticRouteSyncChan signals that the vrf model is processed by the rest layer. The rest workers
+// call it concurrently, so the channel is closed only once.
+func CloseStaticRouteSyncChan() {
+	staticRouteSyncLock.Lock()
+	defer staticRouteSyncLock.Unlock()
+	if StaticRouteSyncChan != nil {
+		close(StaticRouteSyncChan)
+		StaticRouteSyncChan = nil
+	}
+}
 func SetConfigDeleteSyncChan() {
 	ConfigDeleteSyncChan = make(chan struct{})
 }
@@ -1057,15 +1396,71 @@ func ObjectLabelChecksum(objectLabels []*models.RoleFilterMatchLabel) uint32 {
 	return objChecksum
 }
 
-func VrfChecksum(vrfName string, staticRoutes []*models.StaticRoute) uint32 {
+// GetStaticRouteID returns the route id of the static route for a pod CIDR of a node. The route id
+// depends only on the node and the pod CIDR, so that adding or removing a node does not change the
+// route ids of the other nodes.
+func GetStaticRouteID(nodeName, podCIDR string) string {
+	return GetClusterName() + "-" + nodeName + "-" + strings.ReplaceAll(podCIDR, "/", "-")
+}
+
+// IsLegacyStaticRouteID checks if the route id is of the format <cluster>-<n>, which was used by the earlier
+// releases of AKO, where the routes were numbered in the order of the nodes.
+func IsLegacyStaticRouteID(routeID string) bool {
+	routeNum := strings.TrimPrefix(routeID, GetClusterName()+"-")
+	if routeNum == routeID {
+		return false
+	}
+	_, err := strconv.Atoi(routeNum)
+	return err == nil
+}
+
+// GetStaticRoutePrefix returns the prefix of the static route in the CIDR notation.
+func GetStaticRoutePrefix(staticRoute *models.StaticRoute) string {
+	if staticRoute.Prefix == nil || staticRoute.Prefix.IPAddr == nil || staticRoute.Prefix.IPAddr.Addr == nil || staticRoute.Prefix.Mask == nil {
+		return ""
+	}
+	return *staticRoute.Prefix.IPAddr.Addr + "/" + strconv.Itoa(int(*staticRoute.Prefix.Mask))
+}
+
+// GetLegacyStaticRouteIDs returns the legacy route ids of the static routes of the cluster, keyed by their prefixes.
+func GetLegacyStaticRouteIDs(staticRoutes []*models.StaticRoute) map[string]string {
+	legacyRouteIDs := make(map[string]string)
+	for _, staticRoute := range staticRoutes {
+		if staticRoute.RouteID != nil && IsLegacyStaticRouteID(*staticRoute.RouteID) {
+			legacyRouteIDs[GetStaticRoutePrefix(staticRoute)] = *staticRoute.RouteID
+		}
+	}
+	return legacyRouteIDs
+}
+
+func StaticRouteChecksum(staticRoute *models.StaticRoute) uint32 {
+	return utils.Hash(utils.Stringify(staticRoute))
+}
+
+// GetStaticRouteChecksums returns the checksums of the static routes of the cluster, keyed by their route ids.
+func GetStaticRouteChecksums(staticRoutes []*models.StaticRoute) map[string]uint32 {
 	clusterName := GetClusterName()
-	filteredStaticRoutes := []*models.StaticRoute{}
+	checksums := make(map[string]uint32)
 	for _, staticRoute := range staticRoutes {
-		if strings.HasPrefix(*staticRoute.RouteID, clusterName) {
-			filteredStaticRoutes = append(filteredStaticRoutes, staticRoute)
+		if staticRoute.RouteID != nil && strings.HasPrefix(*staticRoute.RouteID, clusterName) {
+			checksums[*staticRoute.RouteID] = StaticRouteChecksum(staticRoute)
 		}
 	}
-	return utils.Hash(utils.Stringify(filteredStaticRoutes))
+	return checksums
+}
+
+// StaticRoutesChecksum is the sum of the checksums of the static routes, so it does not depend on
+// the order of the routes in the vrf.
+func StaticRoutesChecksum(checksums map[string]uint32) uint32 {
+	var checksum uint32
+	for _, routeChecksum := range checksums {
+		checksum += routeChecksum
+	}
+	return checksum
+}
+
+func VrfChecksum(vrfName string, staticRoutes []*models.StaticRoute) uint32 {
+	return StaticRoutesChecksum(GetStaticRouteChecksums(staticRoutes))
 }
 
 func DSChecksum(pgrefs []string, markers []*models.RoleFilterMatchLabel, populateCache bool) uint32 {
@@ -1154,6 +1549,45 @@ func PopulateSvcApiL4PoolNodeMarkers(namespace, svcName, gatewayName, protocol s
 	return markers
 }
 
+func PopulateIstioGatewayVSNodeMarkers(namespace, gatewayName string) utils.AviObjectMarkers {
+	var markers utils.AviObjectMarkers
+	markers.Namespace = namespace
+	markers.IstioGatewayName = gatewayName
+	return markers
+}
+
+func PopulateIstioVirtualServiceMarkers(namespace, virtualServiceName string) utils.AviObjectMarkers {
+	var markers utils.AviObjectMarkers
+	markers.Namespace = namespace
+	markers.IstioVirtualServiceName = virtualServiceName
+	return markers
+}
+
+func PopulateIstioPoolNodeMarkers(namespace, svcName, virtualServiceName string, port int) utils.AviObjectMarkers {
+	var markers utils.AviObjectMarkers
+	markers.Namespace = namespace
+	markers.IstioVirtualServiceName = virtualServiceName
+	markers.ServiceName = svcName
+	markers.Port = strconv.Itoa(port)
+	return markers
+}
+
+func PopulateSvcApiHTTPRouteMarkers(namespace, routeName string) utils.AviObjectMarkers {
+	var markers utils.AviObjectMarkers
+	markers.Namespace = namespace
+	markers.HTTPRouteName = routeName
+	return markers
+}
+
+func PopulateSvcApiHTTPRoutePoolNodeMarkers(namespace, svcName, routeName string, port int) utils.AviObjectMarkers {
+	var markers utils.AviObjectMarkers
+	markers.Namespace = namespace
+	markers.HTTPRouteName = routeName
+	markers.ServiceName = svcName
+	markers.Port = strconv.Itoa(port)
+	return markers
+}
+
 func PopulatePGNodeMarkers(namespace, host, infraSettingName string, ingName, path []string) utils.AviObjectMarkers {
 	var markers utils.AviObjectMarkers
 	markers.Namespace = namespace
@@ -1198,18 +1632,28 @@ func InformersToRegister(kclient *kubernetes.Clientset, oclient *oshiftclient.Cl
 	var isOshift bool
 	allInformers := []string{
 		utils.ServiceInformer,
-		utils.EndpointInformer,
 		utils.SecretInformer,
 		utils.ConfigMapInformer,
 	}
 
-	if GetServiceType() == NodePortLocal {
+	// EndpointSlices are used for the pool servers if enabled and served by the cluster, else AKO falls back to Endpoints.
+	if IsEndpointSliceEnabled() && isEndpointSliceAPIAvailable(kclient) {
+		allInformers = append(allInformers, utils.EndpointSlicesInformer)
+	} else {
+		allInformers = append(allInformers, utils.EndpointInformer)
+	}
+
+	// Pods are required for NPL, for the Istio DestinationRule subsets, to stop draining the servers of deleted pods,
+	// to set the readiness gate condition of the pods and to build the health monitors from the readiness probes.
+	if GetServiceType() == NodePortLocal || IsIstioEnabled() || IsServerDrainEnabled() || IsPodReadinessGateEnabled() || IsProbeHealthMonitorEnabled() {
 		allInformers = append(allInformers, utils.PodInformer)
 	}
 
 	if !GetAdvancedL4() {
 		allInformers = append(allInformers, utils.NSInformer)
 		allInformers = append(allInformers, utils.NodeInformer)
+		// ConfigMaps carrying the datascripts referred by the HostRules.
+		allInformers = append(allInformers, utils.DataScriptConfigMapInformer)
 
 		informerTimeout := int64(120)
 		_, err := kclient.CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{TimeoutSeconds: &informerTimeout})
@@ -1243,6 +1687,16 @@ func InformersToRegister(kclient *kubernetes.Clientset, oclient *oshiftclient.Cl
 	return allInformers, nil
 }
 
+func isEndpointSliceAPIAvailable(kclient *kubernetes.Clientset) bool {
+	informerTimeout := int64(120)
+	_, err := kclient.DiscoveryV1().EndpointSlices(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{Limit: 1, TimeoutSeconds: &informerTimeout})
+	if err != nil {
+		utils.AviLog.Warnf("EndpointSlices are not available in the cluster, falling back to Endpoints: %v", err)
+		return false
+	}
+	return true
+}
+
 func GetDiffPath(storedPathSvc map[string][]string, currentPathSvc map[string][]string) map[string][]string {
 	pathSvcCopy := make(map[string][]string)
 	for k, v := range storedPathSvc {
@@ -1292,6 +1746,68 @@ func L4PolicyChecksum(ports []int64, protocols []string, ingestionMarkers utils.
 	return checksum
 }
 
+// NetworkSecurityPolicyChecksum returns the checksum of a network security policy which allows only the given CIDRs.
+func NetworkSecurityPolicyChecksum(allowedCIDRs []string, ingestionMarkers utils.AviObjectMarkers, markers []*models.RoleFilterMatchLabel, populateCache bool) uint32 {
+	cidrs := make([]string, len(allowedCIDRs))
+	copy(cidrs, allowedCIDRs)
+	sort.Strings(cidrs)
+	checksum := utils.Hash(utils.Stringify(cidrs))
+	if populateCache {
+		if markers != nil {
+			checksum += ObjectLabelChecksum(markers)
+		}
+		return checksum
+	}
+	checksum += GetMarkersChecksum(ingestionMarkers)
+	return checksum
+}
+
+// HealthMonitorChecksum returns the checksum of a health monitor created from a readiness probe.
+func HealthMonitorChecksum(hmType, httpRequest string, monitorPort, sendInterval, receiveTimeout, successfulChecks, failedChecks int32,
+	ingestionMarkers utils.AviObjectMarkers, markers []*models.RoleFilterMatchLabel, populateCache bool) uint32 {
+	checksum := utils.Hash(hmType + httpRequest + utils.Stringify([]int32{monitorPort, sendInterval, receiveTimeout, successfulChecks, failedChecks}))
+	if populateCache {
+		if markers != nil {
+			checksum += ObjectLabelChecksum(markers)
+		}
+		return checksum
+	}
+	checksum += GetMarkersChecksum(ingestionMarkers)
+	return checksum
+}
+
+// PersistenceProfileChecksum returns the checksum of a client IP persistence profile with the given timeout.
+func PersistenceProfileChecksum(timeout int32, ingestionMarkers utils.AviObjectMarkers, markers []*models.RoleFilterMatchLabel, populateCache bool) uint32 {
+	checksum := utils.Hash(strconv.Itoa(int(timeout)))
+	if populateCache {
+		if markers != nil {
+			checksum += ObjectLabelChecksum(markers)
+		}
+		return checksum
+	}
+	checksum += GetMarkersChecksum(ingestionMarkers)
+	return checksum
+}
+
+// GetClientIPPersistenceTimeout returns the persistence timeout in minutes for a service with the ClientIP
+// session affinity. The timeout of the service is rounded up to minutes, and capped to the maximum allowed
+// persistence timeout of the controller.
+func GetClientIPPersistenceTimeout(svcObj *corev1.Service) int32 {
+	timeoutSeconds := int32(corev1.DefaultClientIPServiceAffinitySeconds)
+	if svcObj.Spec.SessionAffinityConfig != nil && svcObj.Spec.SessionAffinityConfig.ClientIP != nil &&
+		svcObj.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds != nil {
+		timeoutSeconds = *svcObj.Spec.SessionAffinityConfig.ClientIP.TimeoutSeconds
+	}
+	timeout := (timeoutSeconds + 59) / 60
+	if timeout < 1 {
+		timeout = 1
+	}
+	if timeout > MaxClientIPPersistenceTimeout {
+		timeout = MaxClientIPPersistenceTimeout
+	}
+	return timeout
+}
+
 func IsNodePortMode() bool {
 	nodePortType := os.Getenv(SERVICE_TYPE)
 	if nodePortType == NODE_PORT {
@@ -1807,10 +2323,43 @@ func GetIPFamily() string {
 	return IPfamily
 }
 
+// IsDualStack returns true if ipFamily is set to V4_V6, in which case both the V4 and the V6
+// addresses are used for the pool servers and the vips.
+func IsDualStack() bool {
+	return GetIPFamily() == IPTypeV4V6
+}
+
+// GetServerIPType returns the type of the address of a pool server, and whether the address
+// belongs to one of the families enabled by ipFamily.
+func GetServerIPType(ip string) (string, bool) {
+	ipType := "V4"
+	if !utils.IsV4(ip) {
+		ipType = "V6"
+	}
+	ipFamily := GetIPFamily()
+	return ipType, ipFamily == ipType || ipFamily == IPTypeV4V6
+}
+
+// GetServiceVipIPType returns the ip type of the vip of a Service in dual-stack, as per the ipFamilies
+// and the ipFamilyPolicy of the Service. In single-stack, the ip type is decided by the vip network.
+func GetServiceVipIPType(svc *corev1.Service) string {
+	if !IsDualStack() || len(svc.Spec.IPFamilies) == 0 {
+		return ""
+	}
+	if len(svc.Spec.IPFamilies) > 1 ||
+		(svc.Spec.IPFamilyPolicy != nil && *svc.Spec.IPFamilyPolicy == corev1.IPFamilyPolicyRequireDualStack) {
+		return IPTypeV4V6
+	}
+	if svc.Spec.IPFamilies[0] == corev1.IPv6Protocol {
+		return IPTypeV6Only
+	}
+	return IPTypeV4Only
+}
+
 func IsValidV6Config(returnErr *error) bool {
 	ipFamily := GetIPFamily()
-	if !(ipFamily == "V4" || ipFamily == "V6") {
-		*returnErr = fmt.Errorf("ipFamily is not one of (V4, V6)")
+	if !(ipFamily == "V4" || ipFamily == "V6" || ipFamily == IPTypeV4V6) {
+		*returnErr = fmt.Errorf("ipFamily is not one of (V4, V6, V4_V6)")
 		return false
 	}
 
@@ -1885,3 +2434,49 @@ func GetIstioPKIProfileName() string {
 func GetIstioWorkloadCertificateName() string {
 	return "istio-workload-" + GetClusterName() + "-" + utils.GetAKONamespace()
 }
+
+// All Istio Gateway object names.
+func GetIstioGatewayVSName(gwName, namespace string) string {
+	return Encode(NamePrefix+"istio-"+namespace+"-"+gwName, SNIVS)
+}
+
+func GetIstioGatewaySniNodeName(vsName, serverName string) string {
+	return Encode(vsName+"-"+serverName, SNIVS)
+}
+
+func GetIstioHTTPPolicyName(vsName, namespace, istioVSName string) string {
+	return Encode(vsName+"-"+namespace+"-"+istioVSName, HTTPPS)
+}
+
+func GetIstioPGName(vsName, namespace, istioVSName string, routeIndex int) string {
+	return Encode(vsName+"-"+namespace+"-"+istioVSName+"-"+strconv.Itoa(routeIndex), PG)
+}
+
+func GetIstioPoolName(vsName, namespace, svcName, subset string, port int32) string {
+	poolName := vsName + "-" + namespace + "-" + svcName
+	if subset != "" {
+		poolName = poolName + "-" + subset
+	}
+	return Encode(poolName+"-"+strconv.Itoa(int(port)), Pool)
+}
+
+// All services API HTTP Gateway object names.
+func GetSvcApiL7VSName(gwName, namespace string) string {
+	return Encode(NamePrefix+namespace+"-"+gwName+"-L7", SNIVS)
+}
+
+func GetSvcApiSniNodeName(vsName string, port int32) string {
+	return Encode(vsName+"-"+strconv.Itoa(int(port)), SNIVS)
+}
+
+func GetSvcApiHTTPPolicyName(vsName, namespace, routeName string) string {
+	return Encode(vsName+"-"+namespace+"-"+routeName, HTTPPS)
+}
+
+func GetSvcApiPGName(vsName, namespace, routeName string, ruleIndex int) string {
+	return Encode(vsName+"-"+namespace+"-"+routeName+"-"+strconv.Itoa(ruleIndex), PG)
+}
+
+func GetSvcApiPoolName(vsName, namespace, svcName string, port int32) string {
+	return Encode(vsName+"-"+namespace+"-"+svcName+"-"+strconv.Itoa(int(port)), Pool)
+}
@@ -53,12 +53,21 @@ func ExtractTypeNameNamespace(key string) (string, string, string) {
 
 func isServiceLBType(svcObj *corev1.Service) bool {
 	// If we don't find a service or it is not of type loadbalancer - return false.
-	if svcObj.Spec.Type == "LoadBalancer" {
+	if svcObj.Spec.Type == "LoadBalancer" && IsAviLoadBalancerClass(svcObj) {
 		return true
 	}
 	return false
 }
 
+// IsAviLoadBalancerClass returns true if the service of type LoadBalancer is to be handled by AKO, that is
+// if the service has no loadBalancerClass, or if the loadBalancerClass is the one of AKO.
+func IsAviLoadBalancerClass(svcObj *corev1.Service) bool {
+	if svcObj.Spec.LoadBalancerClass == nil {
+		return true
+	}
+	return *svcObj.Spec.LoadBalancerClass == AviLoadBalancerClass
+}
+
 func IsServiceNodPortType(svcObj *corev1.Service) bool {
 	if svcObj.Spec.Type == NodePort {
 		return true
@@ -164,7 +173,7 @@ func GetServicesForPod(pod *corev1.Pod) ([]string, []string) {
 			continue
 		}
 		svcKey := svc.Namespace + "/" + svc.Name
-		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer && IsAviLoadBalancerClass(svc) {
 			lbList = append(lbList, svcKey)
 		}
 		if svc.Spec.Type != corev1.ServiceTypeNodePort {
@@ -263,12 +263,14 @@ func (o *AviObjectGraph) ConstructAdvL4PolPoolNodes(vsNode *AviVsNode, gwName, n
 	var l4Policies []*AviL4PolicyNode
 	found, svcListeners := objects.ServiceGWLister().GetGwToSvcs(namespace + "/" + gwName)
 	foundGW, gwListeners := objects.ServiceGWLister().GetGWListeners(namespace + "/" + gwName)
-	if !found || !foundGW {
+	if !foundGW || (!found && !lib.UseServicesAPI()) {
 		return
 	}
 
 	// create a mapping of portProto to hostname
 	gwListenerHostNameMapping := make(map[string]string)
+	// listeners that select TCPRoutes instead of labelled services.
+	var routeListeners []string
 	if lib.UseServicesAPI() {
 		// enable fqdn for gateway services only for non-advancedl4 usecases.
 		gw, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().Gateways(namespace).Get(gwName)
@@ -280,16 +282,21 @@ func (o *AviObjectGraph) ConstructAdvL4PolPoolNodes(vsNode *AviVsNode, gwName, n
 			if gwlistener.Hostname != nil && string(*gwlistener.Hostname) != "" {
 				gwListenerHostNameMapping[fmt.Sprintf("%s/%d", gwlistener.Protocol, gwlistener.Port)] = string(*gwlistener.Hostname)
 			}
+			if IsSvcApiRouteListener(gwlistener) {
+				routeListeners = append(routeListeners, fmt.Sprintf("%s/%d", gwlistener.Protocol, gwlistener.Port))
+			}
 		}
 	}
 
 	var infraSetting *v1alpha1.AviInfraSetting
+	var svcApiGateway *svcapiv1alpha1.Gateway
 	if lib.UseServicesAPI() {
 		gw, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().Gateways(namespace).Get(gwName)
 		if err != nil {
 			utils.AviLog.Warnf("key: %s, msg: GatewayLister returned error for services APIs : %s", err)
 			return
 		}
+		svcApiGateway = gw
 		// configures VS and VsVip nodes using infraSetting object (via CRD).
 		infraSetting, err = getL4InfraSetting(key, nil, &gw.Spec.GatewayClassName)
 		if err != nil {
@@ -300,7 +307,7 @@ func (o *AviObjectGraph) ConstructAdvL4PolPoolNodes(vsNode *AviVsNode, gwName, n
 
 	var portPoolSet []AviHostPathPortPoolPG
 	for listener, svc := range svcListeners {
-		if !utils.HasElem(gwListeners, listener) || len(svc) != 1 {
+		if !utils.HasElem(gwListeners, listener) || utils.HasElem(routeListeners, listener) || len(svc) != 1 {
 			continue
 		}
 		portProto := strings.Split(listener, "/") // format: protocol/port
@@ -389,6 +396,10 @@ func (o *AviObjectGraph) ConstructAdvL4PolPoolNodes(vsNode *AviVsNode, gwName, n
 		utils.AviLog.Infof("key: %s, msg: evaluated L4 pool values :%v", key, utils.Stringify(poolNode))
 	}
 
+	if svcApiGateway != nil {
+		portPoolSet = append(portPoolSet, o.buildSvcApiTCPRoutePools(vsNode, svcApiGateway, infraSetting, key)...)
+	}
+
 	l4policyNode := &AviL4PolicyNode{
 		Name:       vsNode.Name,
 		Tenant:     lib.GetTenant(),
@@ -436,7 +447,7 @@ func (o *AviObjectGraph) ConstructSharedVipSvcLBNode(sharedVipKey, namespace, ke
 	isTCP, isUDP := false, false
 	avi_vs_meta.AviMarkers = lib.PopulateAdvL4VSNodeMarkers(namespace, sharedVipKey)
 	var portProtocols []AviPortHostProtocol
-	var sharedPreferredVIP string
+	var sharedPreferredVIP, sharedVipIPType string
 	var serviceObject *v1.Service
 	for i, serviceNSName := range serviceNSNames {
 		svcNSName := strings.Split(serviceNSName, "/")
@@ -448,6 +459,7 @@ func (o *AviObjectGraph) ConstructSharedVipSvcLBNode(sharedVipKey, namespace, ke
 
 		if i == 0 {
 			sharedPreferredVIP = svcObj.Spec.LoadBalancerIP
+			sharedVipIPType = lib.GetServiceVipIPType(svcObj)
 			if infraSettingAnnotation, ok := svcObj.GetAnnotations()[lib.InfraSettingNameAnnotation]; ok && infraSettingAnnotation != "" {
 				serviceObject = svcObj.DeepCopy()
 			}
@@ -487,6 +499,7 @@ func (o *AviObjectGraph) ConstructSharedVipSvcLBNode(sharedVipKey, namespace, ke
 	if sharedPreferredVIP != "" {
 		vsVipNode.IPAddress = sharedPreferredVIP
 	}
+	vsVipNode.IPType = sharedVipIPType
 
 	if avi_vs_meta.EnableRhi != nil && *avi_vs_meta.EnableRhi {
 		vsVipNode.BGPPeerLabels = lib.GetGlobalBgpPeerLabels()
@@ -81,6 +81,9 @@ type AviVsEvhSniModel interface {
 	GetSSLProfileRef() string
 	SetSSLProfileRef(string)
 
+	GetHTTPDSrefs() []*AviHTTPDataScriptNode
+	SetHTTPDSrefs([]*AviHTTPDataScriptNode)
+
 	GetVsDatascriptRefs() []string
 	SetVsDatascriptRefs([]string)
 
@@ -264,6 +267,14 @@ func (v *AviEvhVsNode) SetSSLProfileRef(SSLProfileRef string) {
 	v.SSLProfileRef = SSLProfileRef
 }
 
+func (v *AviEvhVsNode) GetHTTPDSrefs() []*AviHTTPDataScriptNode {
+	return v.HTTPDSrefs
+}
+
+func (v *AviEvhVsNode) SetHTTPDSrefs(httpDSrefs []*AviHTTPDataScriptNode) {
+	v.HTTPDSrefs = httpDSrefs
+}
+
 func (v *AviEvhVsNode) GetVsDatascriptRefs() []string {
 	return v.VsDatascriptRefs
 }
@@ -897,6 +908,9 @@ func (o *AviObjectGraph) BuildPolicyPGPoolsForEVH(vsNode []*AviEvhVsNode, childN
 		pool_ref := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
 		ratio := path.weight
 		pgNode.Members = append(pgNode.Members, &avimodels.PoolGroupMember{PoolRef: &pool_ref, Ratio: &ratio})
+		if modelType == utils.Ingress {
+			BuildHTTPRuleBackends(hosts[0], path, ingName, namespace, key, infraSetting, childNode, pgNode, poolNode.Name, pgNode.Name)
+		}
 
 		if childNode.CheckPGNameNChecksum(pgNode.Name, pgNode.GetCheckSum()) {
 			childNode.ReplaceEvhPGInEVHNode(pgNode, key)
@@ -916,6 +930,7 @@ func (o *AviObjectGraph) BuildPolicyPGPoolsForEVH(vsNode []*AviEvhVsNode, childN
 			if childNode.CheckHttpPolNameNChecksumForEvh(httppolname, hppMapName, httpPGPath.Checksum) {
 				childNode.ReplaceHTTPRefInNodeForEvh(httpPGPath, httppolname, key)
 			}
+			BuildHTTPRuleMatches(hosts[0], path.Path, ingName, namespace, key, infraSetting, childNode, httppolname, hppMapName, pgName, modelType != utils.OshiftRoute)
 		}
 	}
 	childNode.Paths = pathSet.List()
@@ -1645,6 +1660,7 @@ func DeriveShardVSForEvh(hostname, key string, routeIgrObj RouteIngressModel) (l
 	utils.AviLog.Debugf("key: %s, msg: hostname for sharding: %s", key, hostname)
 	var newInfraPrefix, oldInfraPrefix string
 	oldShardSize, newShardSize := lib.GetshardSize(), lib.GetshardSize()
+	oldShardScheme, newShardScheme := lib.GetL7ShardScheme(), lib.GetL7ShardScheme()
 	var oldVSNameMeta lib.VSNameMetadata
 	var newVSNameMeta lib.VSNameMetadata
 	// get stored infrasetting from ingress/route
@@ -1655,6 +1671,9 @@ func DeriveShardVSForEvh(hostname, key string, routeIgrObj RouteIngressModel) (l
 		if found, shardSize := objects.InfraSettingL7Lister().GetInfraSettingToShardSize(oldSettingName); found && shardSize != "" {
 			oldShardSize = lib.ShardSizeMap[shardSize]
 		}
+		if found, shardScheme := objects.InfraSettingL7Lister().GetInfraSettingToShardScheme(oldSettingName); found && shardScheme != "" {
+			oldShardScheme = shardScheme
+		}
 		oldInfraPrefix = oldSettingName
 	} else {
 		utils.AviLog.Debugf("AviInfraSetting %s not found in cache", oldSettingName)
@@ -1664,11 +1683,15 @@ func DeriveShardVSForEvh(hostname, key string, routeIgrObj RouteIngressModel) (l
 	if !routeIgrObj.Exists() {
 		// get the old ones.
 		newShardSize = oldShardSize
+		newShardScheme = oldShardScheme
 		newInfraPrefix = oldInfraPrefix
 	} else if newSetting != nil {
-		if newSetting.Spec.L7Settings != (akov1alpha1.AviInfraL7Settings{}) {
+		if newSetting.Spec.L7Settings.ShardSize != "" {
 			newShardSize = lib.ShardSizeMap[newSetting.Spec.L7Settings.ShardSize]
 		}
+		if newSetting.Spec.L7Settings.ShardScheme != "" {
+			newShardScheme = newSetting.Spec.L7Settings.ShardScheme
+		}
 		newInfraPrefix = newSetting.Name
 	}
 	shardVsPrefix := lib.GetNamePrefix() + lib.GetAKOIDPrefix() + lib.ShardEVHVSPrefix
@@ -1685,14 +1708,14 @@ func DeriveShardVSForEvh(hostname, key string, routeIgrObj RouteIngressModel) (l
 		newVsName += "NS-" + routeIgrObj.GetNamespace()
 	} else {
 		if oldShardSize != 0 {
-			oldVsName += strconv.Itoa(int(utils.Bkt(hostname, oldShardSize)))
+			oldVsName += strconv.Itoa(int(utils.Bkt(GetShardKey(hostname, oldShardSize, oldShardScheme, routeIgrObj), oldShardSize)))
 		} else {
 			//Dedicated VS
 			oldVsName = GetDedicatedVSName(hostname, oldInfraPrefix)
 			oldVSNameMeta.Dedicated = true
 		}
 		if newShardSize != 0 {
-			newVsName += strconv.Itoa(int(utils.Bkt(hostname, newShardSize)))
+			newVsName += strconv.Itoa(int(utils.Bkt(GetShardKey(hostname, newShardSize, newShardScheme, routeIgrObj), newShardSize)))
 		} else {
 			//Dedicated VS
 			newVsName = GetDedicatedVSName(hostname, newInfraPrefix)
@@ -1775,6 +1798,9 @@ func (o *AviObjectGraph) manipulateEVHVsNode(vsNode *AviEvhVsNode, ingName, name
 	for path, services := range pathSvc {
 		pgName := lib.GetEvhPGName(ingName, namespace, hostname, path, infraSettingName, vsNode.Dedicated)
 		pgNode := vsNode.GetPGForVSByName(pgName)
+		RemoveHTTPRuleMatches(vsNode, lib.GetSniHttpPolName(namespace, hostname, infraSettingName),
+			lib.GetSniHppMapName(ingName, namespace, hostname, path, infraSettingName, vsNode.Dedicated), pgName)
+		RemoveHTTPRuleBackends(vsNode, pgNode, pgName)
 		for _, svc := range services {
 			evhPool := lib.GetEvhPoolName(ingName, namespace, hostname, path, infraSettingName, svc, vsNode.Dedicated)
 			o.RemovePoolNodeRefsFromEvh(evhPool, vsNode)
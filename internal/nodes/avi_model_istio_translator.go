@@ -0,0 +1,885 @@
+/*
+ * Copyright 2019-2020 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package nodes
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	"github.com/golang/protobuf/proto"
+	avimodels "github.com/vmware/alb-sdk/go/models"
+	istionetworking "istio.io/api/networking/v1alpha3"
+	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// An Istio Gateway is translated into a parent VS which listens on all the gateway server ports.
+// Routes of the VirtualServices bound to plain HTTP servers are attached as http policies to the
+// parent VS, while each HTTPS server gets its own SNI child VS carrying the server certificate
+// and the routes of the VirtualServices bound to it. Each route becomes a poolgroup, with one pool
+// per destination, weighted as per the route. The DestinationRule of a destination service sets
+// the load balancing algorithm and the TLS settings of the pool.
+//
+// Avi evaluates the request rules of a policy with the longest path first, rules with the same
+// path retain the order of the routes in the VirtualService.
+
+const (
+	istioMeshGateway     = "mesh"
+	istioServiceSuffix   = ".svc.cluster.local"
+	istioDefaultRedirect = "HTTP_REDIRECT_STATUS_CODE_301"
+)
+
+// BuildIstioGatewayGraph builds the model for the Istio Gateway namespace/gwName.
+// The model is left empty if the gateway does not exist.
+func (o *AviObjectGraph) BuildIstioGatewayGraph(namespace, gwName, key string) {
+	o.Lock.Lock()
+	defer o.Lock.Unlock()
+
+	gateway, err := lib.AKOControlConfig().IstioCRDInformers().GatewayInformer.Lister().Gateways(namespace).Get(gwName)
+	if err != nil {
+		utils.AviLog.Infof("key: %s, msg: unable to find istio gateway %s/%s: %v", key, namespace, gwName, err)
+		return
+	}
+
+	gwKey := namespace + "/" + gwName
+	vsName := lib.GetIstioGatewayVSName(gwName, namespace)
+	vsNode := &AviVsNode{
+		Name:               vsName,
+		Tenant:             lib.GetTenant(),
+		ServiceEngineGroup: lib.GetSEGName(),
+		EnableRhi:          proto.Bool(lib.GetEnableRHI()),
+		NetworkProfile:     utils.DEFAULT_TCP_NW_PROFILE,
+		ApplicationProfile: utils.DEFAULT_L7_APP_PROFILE,
+		SNIParent:          true,
+		ServiceMetadata: lib.ServiceMetadataObj{
+			Namespace: namespace,
+		},
+		AviMarkers: lib.PopulateIstioGatewayVSNodeMarkers(namespace, gwName),
+	}
+
+	var vrfcontext string
+	if lib.GetT1LRPath() == "" {
+		vrfcontext = lib.GetVrf()
+		vsNode.VrfContext = vrfcontext
+	}
+
+	vsVipNode := &AviVSVIPNode{
+		Name:        lib.GetVsVipName(vsName),
+		Tenant:      lib.GetTenant(),
+		VrfContext:  vrfcontext,
+		VipNetworks: lib.GetVipNetworkList(),
+	}
+	if lib.GetT1LRPath() != "" {
+		vsVipNode.T1Lr = lib.GetT1LRPath()
+	}
+	if vsNode.EnableRhi != nil && *vsNode.EnableRhi {
+		vsVipNode.BGPPeerLabels = lib.GetGlobalBgpPeerLabels()
+	}
+	vsNode.VSVIPRefs = append(vsNode.VSVIPRefs, vsVipNode)
+
+	virtualServices := getIstioVirtualServicesForGateway(gwKey)
+	for _, vs := range virtualServices {
+		vsKey := vs.Namespace + "/" + vs.Name
+		objects.SharedIstioLister().UpdateVSToGateways(vsKey, GetIstioGatewaysForVS(vs))
+		objects.SharedIstioLister().UpdateVSSvcMappings(vsKey, GetIstioServicesForVS(vs))
+	}
+	httpsPort := getIstioGatewayHTTPSPort(gateway)
+	for i, server := range gateway.Spec.Servers {
+		if server == nil || server.Port == nil {
+			continue
+		}
+		port := int32(server.Port.Number)
+		serverHosts := getIstioServerHosts(server)
+		for _, host := range serverHosts {
+			if !strings.Contains(host, "*") && !utils.HasElem(vsVipNode.FQDNs, host) {
+				vsVipNode.FQDNs = append(vsVipNode.FQDNs, host)
+			}
+		}
+
+		switch strings.ToUpper(server.Port.Protocol) {
+		case "HTTP", "HTTP2", "GRPC":
+			addIstioListener(vsNode, port, false)
+			if server.Tls != nil && server.Tls.HttpsRedirect {
+				o.buildIstioHTTPSRedirect(vsNode, serverHosts, port, httpsPort)
+				continue
+			}
+			o.buildIstioRoutes(vsNode, vsNode, virtualServices, serverHosts, port, false, key)
+		case "HTTPS":
+			if server.Tls == nil || server.Tls.Mode == istionetworking.ServerTLSSettings_PASSTHROUGH ||
+				server.Tls.Mode == istionetworking.ServerTLSSettings_AUTO_PASSTHROUGH ||
+				server.Tls.Mode == istionetworking.ServerTLSSettings_ISTIO_MUTUAL {
+				utils.AviLog.Warnf("key: %s, msg: TLS mode of HTTPS server on port %d of istio gateway %s is not supported, skipping server", key, port, gwKey)
+				continue
+			}
+			if server.Tls.Mode == istionetworking.ServerTLSSettings_MUTUAL {
+				utils.AviLog.Warnf("key: %s, msg: client certificates are not validated for MUTUAL TLS server on port %d of istio gateway %s", key, port, gwKey)
+			}
+			addIstioListener(vsNode, port, true)
+			serverName := server.Name
+			if serverName == "" {
+				serverName = strconv.Itoa(i)
+			}
+			sniNode := &AviVsNode{
+				Name:               lib.GetIstioGatewaySniNodeName(vsName, serverName),
+				VHParentName:       vsName,
+				Tenant:             lib.GetTenant(),
+				IsSNIChild:         true,
+				ServiceEngineGroup: lib.GetSEGName(),
+				VrfContext:         vrfcontext,
+				VHDomainNames:      serverHosts,
+				ServiceMetadata: lib.ServiceMetadataObj{
+					Namespace: namespace,
+					HostNames: serverHosts,
+				},
+				AviMarkers: lib.PopulateIstioGatewayVSNodeMarkers(namespace, gwName),
+			}
+			if !buildIstioServerCert(sniNode, namespace, server.Tls.CredentialName, key) {
+				continue
+			}
+			o.buildIstioRoutes(vsNode, sniNode, virtualServices, serverHosts, 0, true, key)
+			vsNode.SniNodes = append(vsNode.SniNodes, sniNode)
+		default:
+			utils.AviLog.Warnf("key: %s, msg: protocol %s of server on port %d of istio gateway %s is not supported, skipping server", key, server.Port.Protocol, port, gwKey)
+		}
+	}
+
+	o.AddModelNode(vsNode)
+	utils.AviLog.Infof("key: %s, msg: computed istio gateway VS: %s", key, utils.Stringify(vsNode))
+}
+
+func addIstioListener(vsNode *AviVsNode, port int32, enableSSL bool) {
+	for _, pp := range vsNode.PortProto {
+		if pp.Port == port {
+			return
+		}
+	}
+	vsNode.PortProto = append(vsNode.PortProto, AviPortHostProtocol{Port: port, Protocol: utils.HTTP, EnableSSL: enableSSL})
+}
+
+// getIstioGatewayHTTPSPort returns the first HTTPS port of the gateway, used as the target for https redirects.
+func getIstioGatewayHTTPSPort(gateway *istiov1alpha3.Gateway) int32 {
+	for _, server := range gateway.Spec.Servers {
+		if server != nil && server.Port != nil && strings.ToUpper(server.Port.Protocol) == "HTTPS" {
+			return int32(server.Port.Number)
+		}
+	}
+	return lib.SSLPort
+}
+
+// getIstioServerHosts strips the optional namespace prefix of the gateway server hosts.
+func getIstioServerHosts(server *istionetworking.Server) []string {
+	var hosts []string
+	for _, host := range server.Hosts {
+		if idx := strings.Index(host, "/"); idx >= 0 {
+			host = host[idx+1:]
+		}
+		if !utils.HasElem(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// hostMatches checks if a route host, such as a VirtualService or HTTPRoute host, is selected
+// by a gateway host. Either of the hosts may be a wildcard.
+func hostMatches(serverHost, vsHost string) bool {
+	if serverHost == "*" || vsHost == "*" || serverHost == vsHost {
+		return true
+	}
+	if strings.HasPrefix(serverHost, "*.") && strings.HasSuffix(strings.TrimPrefix(vsHost, "*"), serverHost[1:]) {
+		return true
+	}
+	if strings.HasPrefix(vsHost, "*.") && strings.HasSuffix(serverHost, vsHost[1:]) {
+		return true
+	}
+	return false
+}
+
+func hostsMatch(serverHosts, vsHosts []string) bool {
+	for _, serverHost := range serverHosts {
+		for _, vsHost := range vsHosts {
+			if hostMatches(serverHost, vsHost) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetIstioGatewaysForVS returns the gateways, as namespace/name, the VirtualService is bound to.
+func GetIstioGatewaysForVS(vs *istiov1alpha3.VirtualService) []string {
+	var gateways []string
+	for _, gateway := range vs.Spec.Gateways {
+		if gateway == istioMeshGateway {
+			continue
+		}
+		if !strings.Contains(gateway, "/") {
+			gateway = vs.Namespace + "/" + gateway
+		}
+		if !utils.HasElem(gateways, gateway) {
+			gateways = append(gateways, gateway)
+		}
+	}
+	return gateways
+}
+
+// GetIstioServicesForVS returns the services, as namespace/name, the VirtualService routes to.
+func GetIstioServicesForVS(vs *istiov1alpha3.VirtualService) []string {
+	var svcs []string
+	for _, route := range vs.Spec.Http {
+		if route == nil {
+			continue
+		}
+		for _, dest := range route.Route {
+			if dest == nil || dest.Destination == nil {
+				continue
+			}
+			svcNamespace, svcName := getIstioDestinationService(dest.Destination.Host, vs.Namespace)
+			svc := svcNamespace + "/" + svcName
+			if !utils.HasElem(svcs, svc) {
+				svcs = append(svcs, svc)
+			}
+		}
+	}
+	return svcs
+}
+
+// getIstioDestinationService resolves a short or fully qualified service host to the service namespace and name.
+func getIstioDestinationService(host, namespace string) (string, string) {
+	host = strings.TrimSuffix(host, istioServiceSuffix)
+	parts := strings.Split(host, ".")
+	if len(parts) > 1 {
+		return parts[1], parts[0]
+	}
+	return namespace, parts[0]
+}
+
+func getIstioVirtualServicesForGateway(gwKey string) []*istiov1alpha3.VirtualService {
+	var virtualServices []*istiov1alpha3.VirtualService
+	vsList, err := lib.AKOControlConfig().IstioCRDInformers().VirtualServiceInformer.Lister().List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("Unable to list istio virtualservices: %v", err)
+		return virtualServices
+	}
+	for _, vs := range vsList {
+		if lib.IsNamespaceBlocked(vs.Namespace) || !utils.CheckIfNamespaceAccepted(vs.Namespace) {
+			continue
+		}
+		if utils.HasElem(GetIstioGatewaysForVS(vs), gwKey) {
+			virtualServices = append(virtualServices, vs)
+		}
+	}
+	sort.Slice(virtualServices, func(i, j int) bool {
+		return virtualServices[i].Namespace+"/"+virtualServices[i].Name < virtualServices[j].Namespace+"/"+virtualServices[j].Name
+	})
+	return virtualServices
+}
+
+// getIstioDestinationRule returns the DestinationRule for the service, giving preference to
+// a DestinationRule in the namespace of the service.
+func getIstioDestinationRule(svcNamespace, svcName string) *istiov1alpha3.DestinationRule {
+	drList, err := lib.AKOControlConfig().IstioCRDInformers().DestinationRuleInformer.Lister().List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("Unable to list istio destinationrules: %v", err)
+		return nil
+	}
+	var destinationRule *istiov1alpha3.DestinationRule
+	for _, dr := range drList {
+		drSvcNamespace, drSvcName := getIstioDestinationService(dr.Spec.Host, dr.Namespace)
+		if drSvcNamespace != svcNamespace || drSvcName != svcName {
+			continue
+		}
+		if destinationRule != nil && destinationRule.Namespace == svcNamespace {
+			continue
+		}
+		if destinationRule == nil || dr.Namespace == svcNamespace ||
+			dr.Namespace+"/"+dr.Name < destinationRule.Namespace+"/"+destinationRule.Name {
+			destinationRule = dr
+		}
+	}
+	return destinationRule
+}
+
+func buildIstioServerCert(sniNode *AviVsNode, namespace, credentialName, key string) bool {
+	if credentialName == "" {
+		utils.AviLog.Warnf("key: %s, msg: credentialName not specified for HTTPS server %s, skipping server", key, sniNode.Name)
+		return false
+	}
+	secretObj, err := utils.GetInformers().SecretInformer.Lister().Secrets(namespace).Get(credentialName)
+	if err != nil || secretObj == nil {
+		utils.AviLog.Warnf("key: %s, msg: secret %s/%s for HTTPS server %s not found: %v", key, namespace, credentialName, sniNode.Name, err)
+		return false
+	}
+	cert, certFound := secretObj.Data[utils.K8S_TLS_SECRET_CERT]
+	tlsKey, keyFound := secretObj.Data[utils.K8S_TLS_SECRET_KEY]
+	if !certFound || !keyFound {
+		utils.AviLog.Warnf("key: %s, msg: certificate or key not found in secret %s/%s", key, namespace, credentialName)
+		return false
+	}
+	certNode := &AviTLSKeyCertNode{
+		Name:       sniNode.Name,
+		Tenant:     lib.GetTenant(),
+		Type:       lib.CertTypeVS,
+		Cert:       cert,
+		Key:        tlsKey,
+		AviMarkers: sniNode.AviMarkers,
+	}
+	sniNode.SSLKeyCertRefs = append(sniNode.SSLKeyCertRefs, certNode)
+	return true
+}
+
+func (o *AviObjectGraph) buildIstioHTTPSRedirect(vsNode *AviVsNode, serverHosts []string, port, httpsPort int32) {
+	var hosts []string
+	for _, host := range serverHosts {
+		if host != "*" {
+			hosts = append(hosts, host)
+		}
+	}
+	policyName := lib.GetL7HttpRedirPolicy(vsNode.Name)
+	var policyNode *AviHttpPolicySetNode
+	for _, policy := range vsNode.HttpPolicyRefs {
+		if policy.Name == policyName {
+			policyNode = policy
+		}
+	}
+	if policyNode == nil {
+		policyNode = &AviHttpPolicySetNode{Name: policyName, Tenant: lib.GetTenant(), AviMarkers: vsNode.AviMarkers}
+		vsNode.HttpPolicyRefs = append(vsNode.HttpPolicyRefs, policyNode)
+	}
+	policyNode.RedirectPorts = append(policyNode.RedirectPorts, AviRedirectPort{
+		Hosts:        hosts,
+		RedirectPort: httpsPort,
+		StatusCode:   istioDefaultRedirect,
+		VsPort:       port,
+	})
+}
+
+// buildIstioRoutes attaches the http routes of the VirtualServices selected by the gateway server
+// hosts to the target node, which is either the parent VS or an SNI child.
+func (o *AviObjectGraph) buildIstioRoutes(vsNode, targetNode *AviVsNode, virtualServices []*istiov1alpha3.VirtualService, serverHosts []string, port int32, secure bool, key string) {
+	for _, vs := range virtualServices {
+		if !hostsMatch(serverHosts, vs.Spec.Hosts) {
+			continue
+		}
+		policyName := lib.GetIstioHTTPPolicyName(targetNode.Name, vs.Namespace, vs.Name)
+		var policyNode *AviHttpPolicySetNode
+		for _, policy := range targetNode.HttpPolicyRefs {
+			if policy.Name == policyName {
+				policyNode = policy
+			}
+		}
+		if policyNode == nil {
+			policyNode = &AviHttpPolicySetNode{
+				Name:       policyName,
+				Tenant:     lib.GetTenant(),
+				AviMarkers: lib.PopulateIstioVirtualServiceMarkers(vs.Namespace, vs.Name),
+			}
+			targetNode.HttpPolicyRefs = append(targetNode.HttpPolicyRefs, policyNode)
+		}
+		hostHeaders := getHostHeaderMatches(vs.Spec.Hosts)
+
+		for routeIdx, route := range vs.Spec.Http {
+			if route == nil {
+				continue
+			}
+			if route.Delegate != nil {
+				utils.AviLog.Warnf("key: %s, msg: delegate in route %d of virtualservice %s/%s is not supported", key, routeIdx, vs.Namespace, vs.Name)
+				continue
+			}
+
+			var pgName string
+			if route.Redirect == nil {
+				pgNode := o.buildIstioRoutePG(targetNode, vs, routeIdx, route, key)
+				if pgNode == nil {
+					continue
+				}
+				pgName = pgNode.Name
+			}
+
+			matches := route.Match
+			if len(matches) == 0 {
+				matches = []*istionetworking.HTTPMatchRequest{{}}
+			}
+			for matchIdx, match := range matches {
+				if match == nil {
+					continue
+				}
+				if port != 0 && match.Port != 0 && match.Port != uint32(port) {
+					continue
+				}
+				hppMap, ok := buildIstioMatch(match, key)
+				if !ok {
+					utils.AviLog.Warnf("key: %s, msg: skipping match %d of route %d of virtualservice %s/%s", key, matchIdx, routeIdx, vs.Namespace, vs.Name)
+					continue
+				}
+				hppMap.Port = uint32(port)
+				hppMap.IngName = vs.Name
+				if route.Redirect != nil {
+					hppMap.Redirect = buildIstioRedirect(route.Redirect, port, secure)
+				} else {
+					hppMap.PoolGroup = pgName
+					hppMap.Rewrite = buildIstioRewrite(route.Rewrite, hppMap)
+				}
+				for hostIdx := range hostHeaders {
+					hostHppMap := hppMap
+					hostHppMap.HostHeader = hostHeaders[hostIdx]
+					hostHppMap.Name = fmt.Sprintf("%s-%d-%d-%d-%d", policyName, port, routeIdx, matchIdx, hostIdx)
+					hostHppMap.CalculateCheckSum()
+					policyNode.HppMap = append(policyNode.HppMap, hostHppMap)
+				}
+			}
+		}
+	}
+}
+
+// getHostHeaderMatches groups the route hosts into host header matches. A nil
+// match is returned if the route matches all hosts.
+func getHostHeaderMatches(vsHosts []string) []*AviHTTPHeaderMatch {
+	var exactHosts, wildcardHosts []string
+	for _, host := range vsHosts {
+		if host == "*" {
+			return []*AviHTTPHeaderMatch{nil}
+		}
+		if strings.HasPrefix(host, "*.") {
+			wildcardHosts = append(wildcardHosts, host[1:])
+		} else {
+			exactHosts = append(exactHosts, host)
+		}
+	}
+	var hostHeaders []*AviHTTPHeaderMatch
+	if len(exactHosts) > 0 {
+		hostHeaders = append(hostHeaders, &AviHTTPHeaderMatch{MatchCriteria: "HDR_EQUALS", Values: exactHosts})
+	}
+	if len(wildcardHosts) > 0 {
+		hostHeaders = append(hostHeaders, &AviHTTPHeaderMatch{MatchCriteria: "HDR_ENDS_WITH", Values: wildcardHosts})
+	}
+	if len(hostHeaders) == 0 {
+		return []*AviHTTPHeaderMatch{nil}
+	}
+	return hostHeaders
+}
+
+// buildIstioMatch translates an Istio HTTPMatchRequest. Regex matches cannot be expressed in the
+// policy rules, and such matches are not translated.
+func buildIstioMatch(match *istionetworking.HTTPMatchRequest, key string) (AviHostPathPortPoolPG, bool) {
+	hppMap := AviHostPathPortPoolPG{
+		Path:          []string{"/"},
+		MatchCriteria: "BEGINS_WITH",
+		IgnoreCase:    match.IgnoreUriCase,
+	}
+	if match.Uri != nil {
+		switch uri := match.Uri.MatchType.(type) {
+		case *istionetworking.StringMatch_Exact:
+			hppMap.Path = []string{uri.Exact}
+			hppMap.MatchCriteria = "EQUALS"
+		case *istionetworking.StringMatch_Prefix:
+			hppMap.Path = []string{uri.Prefix}
+		default:
+			utils.AviLog.Warnf("key: %s, msg: regex uri match is not supported", key)
+			return hppMap, false
+		}
+	}
+
+	headerNames := make([]string, 0, len(match.Headers))
+	for name := range match.Headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		header, ok := buildIstioHeaderMatch(name, match.Headers[name], false)
+		if !ok {
+			utils.AviLog.Warnf("key: %s, msg: regex match for header %s is not supported", key, name)
+			return hppMap, false
+		}
+		hppMap.Headers = append(hppMap.Headers, header)
+	}
+
+	headerNames = headerNames[:0]
+	for name := range match.WithoutHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		header, ok := buildIstioHeaderMatch(name, match.WithoutHeaders[name], true)
+		if !ok {
+			utils.AviLog.Warnf("key: %s, msg: regex match for header %s is not supported", key, name)
+			return hppMap, false
+		}
+		hppMap.Headers = append(hppMap.Headers, header)
+	}
+
+	queryNames := make([]string, 0, len(match.QueryParams))
+	for name := range match.QueryParams {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		queryMatch := match.QueryParams[name]
+		var value string
+		switch query := queryMatch.GetMatchType().(type) {
+		case *istionetworking.StringMatch_Exact:
+			value = query.Exact
+		case *istionetworking.StringMatch_Prefix:
+			value = query.Prefix
+		default:
+			utils.AviLog.Warnf("key: %s, msg: regex match for query parameter %s is not supported", key, name)
+			return hppMap, false
+		}
+		hppMap.Query = append(hppMap.Query, name+"="+value)
+	}
+
+	if match.Method != nil {
+		method, ok := match.Method.MatchType.(*istionetworking.StringMatch_Exact)
+		if !ok {
+			utils.AviLog.Warnf("key: %s, msg: only exact method match is supported", key)
+			return hppMap, false
+		}
+		hppMap.Methods = []string{"HTTP_METHOD_" + strings.ToUpper(method.Exact)}
+	}
+	return hppMap, true
+}
+
+func buildIstioHeaderMatch(name string, stringMatch *istionetworking.StringMatch, negate bool) (AviHTTPHeaderMatch, bool) {
+	header := AviHTTPHeaderMatch{Name: name}
+	switch value := stringMatch.GetMatchType().(type) {
+	case *istionetworking.StringMatch_Exact:
+		header.MatchCriteria = "HDR_EQUALS"
+		if negate {
+			header.MatchCriteria = "HDR_DOES_NOT_EQUAL"
+		}
+		header.Values = []string{value.Exact}
+	case *istionetworking.StringMatch_Prefix:
+		header.MatchCriteria = "HDR_BEGINS_WITH"
+		if negate {
+			header.MatchCriteria = "HDR_DOES_NOT_BEGIN_WITH"
+		}
+		header.Values = []string{value.Prefix}
+	case nil:
+		// An empty match checks for the presence of the header.
+		header.MatchCriteria = "HDR_EXISTS"
+		if negate {
+			header.MatchCriteria = "HDR_DOES_NOT_EXIST"
+		}
+	default:
+		return header, false
+	}
+	return header, true
+}
+
+func buildIstioRedirect(redirect *istionetworking.HTTPRedirect, port int32, secure bool) *AviHTTPRedirect {
+	aviRedirect := &AviHTTPRedirect{
+		Protocol:   "HTTP",
+		Host:       redirect.Authority,
+		Path:       redirect.Uri,
+		Port:       port,
+		StatusCode: istioDefaultRedirect,
+	}
+	if secure {
+		aviRedirect.Protocol = "HTTPS"
+	}
+	switch redirect.RedirectCode {
+	case 302:
+		aviRedirect.StatusCode = "HTTP_REDIRECT_STATUS_CODE_302"
+	case 307:
+		aviRedirect.StatusCode = "HTTP_REDIRECT_STATUS_CODE_307"
+	}
+	return aviRedirect
+}
+
+// buildIstioRewrite translates the rewrite of a route. For a prefix match, the matched prefix is
+// replaced with the rewritten uri and the rest of the path is retained.
+func buildIstioRewrite(rewrite *istionetworking.HTTPRewrite, hppMap AviHostPathPortPoolPG) *AviHTTPRewrite {
+	if rewrite == nil || (rewrite.Uri == "" && rewrite.Authority == "") {
+		return nil
+	}
+	aviRewrite := &AviHTTPRewrite{
+		Host: rewrite.Authority,
+		Path: rewrite.Uri,
+	}
+	if rewrite.Uri != "" && hppMap.MatchCriteria == "BEGINS_WITH" {
+		aviRewrite.KeepPathSuffix = true
+		for _, token := range strings.Split(hppMap.Path[0], "/") {
+			if token != "" {
+				aviRewrite.PathSuffixIndex++
+			}
+		}
+	}
+	return aviRewrite
+}
+
+// buildIstioRoutePG builds the poolgroup for the destinations of a route, and the pools for each destination.
+func (o *AviObjectGraph) buildIstioRoutePG(targetNode *AviVsNode, vs *istiov1alpha3.VirtualService, routeIdx int, route *istionetworking.HTTPRoute, key string) *AviPoolGroupNode {
+	if len(route.Route) == 0 {
+		utils.AviLog.Warnf("key: %s, msg: route %d of virtualservice %s/%s has no destinations", key, routeIdx, vs.Namespace, vs.Name)
+		return nil
+	}
+	pgNode := &AviPoolGroupNode{
+		Name:       lib.GetIstioPGName(targetNode.Name, vs.Namespace, vs.Name, routeIdx),
+		Tenant:     lib.GetTenant(),
+		AviMarkers: lib.PopulateIstioVirtualServiceMarkers(vs.Namespace, vs.Name),
+	}
+
+	// If no weights are specified, the traffic is evenly distributed across the destinations.
+	weighted := false
+	for _, dest := range route.Route {
+		if dest != nil && dest.Weight > 0 {
+			weighted = true
+		}
+	}
+
+	for _, dest := range route.Route {
+		if dest == nil || dest.Destination == nil {
+			continue
+		}
+		ratio := dest.Weight
+		if !weighted {
+			ratio = 1
+		}
+		if ratio == 0 {
+			continue
+		}
+		poolNode := o.buildIstioPool(targetNode, vs, dest.Destination, key)
+		if poolNode == nil {
+			continue
+		}
+		poolRef := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
+		pgNode.Members = append(pgNode.Members, &avimodels.PoolGroupMember{PoolRef: &poolRef, Ratio: &ratio})
+	}
+
+	for _, pg := range targetNode.PoolGroupRefs {
+		if pg.Name == pgNode.Name {
+			return pg
+		}
+	}
+	targetNode.PoolGroupRefs = append(targetNode.PoolGroupRefs, pgNode)
+	return pgNode
+}
+
+func (o *AviObjectGraph) buildIstioPool(targetNode *AviVsNode, vs *istiov1alpha3.VirtualService, dest *istionetworking.Destination, key string) *AviPoolNode {
+	svcNamespace, svcName := getIstioDestinationService(dest.Host, vs.Namespace)
+	svcObj, err := utils.GetInformers().ServiceInformer.Lister().Services(svcNamespace).Get(svcName)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: service %s/%s for destination %s not found: %v", key, svcNamespace, svcName, dest.Host, err)
+		return nil
+	}
+
+	var svcPort *corev1.ServicePort
+	for i, port := range svcObj.Spec.Ports {
+		if (dest.Port != nil && uint32(port.Port) == dest.Port.Number) ||
+			(dest.Port == nil && len(svcObj.Spec.Ports) == 1) {
+			svcPort = &svcObj.Spec.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		utils.AviLog.Warnf("key: %s, msg: unable to find the port for destination %s of virtualservice %s/%s", key, dest.Host, vs.Namespace, vs.Name)
+		return nil
+	}
+
+	poolName := lib.GetIstioPoolName(targetNode.Name, svcNamespace, svcName, dest.Subset, svcPort.Port)
+	for _, pool := range targetNode.PoolRefs {
+		if pool.Name == poolName {
+			return pool
+		}
+	}
+
+	poolNode := &AviPoolNode{
+		Name:       poolName,
+		Tenant:     lib.GetTenant(),
+		PortName:   svcPort.Name,
+		Port:       svcPort.Port,
+		TargetPort: svcPort.TargetPort,
+		ServiceMetadata: lib.ServiceMetadataObj{
+			Namespace: svcNamespace,
+		},
+		VrfContext: lib.GetVrf(),
+	}
+	if svcPort.TargetPort.Type == intstr.Int && svcPort.TargetPort.IntValue() == 0 {
+		poolNode.TargetPort = intstr.FromInt(int(svcPort.Port))
+	}
+	poolNode.NetworkPlacementSettings, _ = lib.GetNodeNetworkMap()
+	if lib.GetT1LRPath() != "" {
+		poolNode.T1Lr = lib.GetT1LRPath()
+		poolNode.VrfContext = ""
+	}
+	poolNode.AviMarkers = lib.PopulateIstioPoolNodeMarkers(svcNamespace, svcName, vs.Name, int(svcPort.Port))
+
+	var subsetLabels map[string]string
+	destinationRule := getIstioDestinationRule(svcNamespace, svcName)
+	if dest.Subset != "" {
+		subsetFound := false
+		if destinationRule != nil {
+			for _, subset := range destinationRule.Spec.Subsets {
+				if subset != nil && subset.Name == dest.Subset {
+					subsetLabels = subset.Labels
+					subsetFound = true
+				}
+			}
+		}
+		if !subsetFound {
+			utils.AviLog.Warnf("key: %s, msg: subset %s for destination %s not found", key, dest.Subset, dest.Host)
+			return nil
+		}
+	}
+
+	serviceType := lib.GetServiceType()
+	if serviceType == lib.NodePortLocal {
+		if servers := PopulateServersForNPL(poolNode, svcNamespace, svcName, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	} else if serviceType == lib.NodePort {
+		if servers := PopulateServersForNodePort(poolNode, svcNamespace, svcName, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	} else {
+		if servers := PopulateServers(poolNode, svcNamespace, svcName, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	}
+	if len(subsetLabels) > 0 {
+		poolNode.Servers = filterIstioSubsetServers(poolNode.Servers, svcNamespace, svcName, subsetLabels, key)
+	}
+
+	poolNode.UpdatePoolNodeForIstio()
+	applyIstioTrafficPolicy(poolNode, destinationRule, dest.Subset, svcPort.Port, key)
+
+	targetNode.PoolRefs = append(targetNode.PoolRefs, poolNode)
+	return poolNode
+}
+
+// filterIstioSubsetServers retains the servers that belong to pods matching the subset labels.
+func filterIstioSubsetServers(servers []AviPoolMetaServer, namespace, svcName string, subsetLabels map[string]string, key string) []AviPoolMetaServer {
+	if lib.GetServiceType() == lib.NodePort || utils.GetInformers().PodInformer == nil {
+		utils.AviLog.Warnf("key: %s, msg: subsets are not supported for service %s/%s, using all the servers", key, namespace, svcName)
+		return servers
+	}
+	addrs, err := getServiceEndpointAddresses(namespace, svcName)
+	if err != nil {
+		return nil
+	}
+	selector := labels.SelectorFromSet(labels.Set(subsetLabels))
+	subsetIPs := make(map[string]bool)
+	for _, addr := range addrs {
+		if addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
+			continue
+		}
+		pod, err := utils.GetInformers().PodInformer.Lister().Pods(namespace).Get(addr.TargetRef.Name)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		subsetIPs[addr.IP] = true
+		if pod.Status.HostIP != "" {
+			// NodePortLocal servers are node IPs.
+			subsetIPs[pod.Status.HostIP] = true
+		}
+	}
+	var subsetServers []AviPoolMetaServer
+	for _, server := range servers {
+		if server.Ip.Addr != nil && subsetIPs[*server.Ip.Addr] {
+			subsetServers = append(subsetServers, server)
+		}
+	}
+	return subsetServers
+}
+
+// applyIstioTrafficPolicy applies the load balancer and TLS settings of the DestinationRule to the
+// pool. Subset and port level settings take precedence over the top level settings.
+func applyIstioTrafficPolicy(poolNode *AviPoolNode, destinationRule *istiov1alpha3.DestinationRule, subsetName string, port int32, key string) {
+	if destinationRule == nil {
+		return
+	}
+	var loadBalancer *istionetworking.LoadBalancerSettings
+	var tls *istionetworking.ClientTLSSettings
+	policies := []*istionetworking.TrafficPolicy{destinationRule.Spec.TrafficPolicy}
+	for _, subset := range destinationRule.Spec.Subsets {
+		if subset != nil && subsetName != "" && subset.Name == subsetName {
+			policies = append(policies, subset.TrafficPolicy)
+		}
+	}
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		if policy.LoadBalancer != nil {
+			loadBalancer = policy.LoadBalancer
+		}
+		if policy.Tls != nil {
+			tls = policy.Tls
+		}
+		for _, portPolicy := range policy.PortLevelSettings {
+			if portPolicy == nil || portPolicy.Port == nil || portPolicy.Port.Number != uint32(port) {
+				continue
+			}
+			if portPolicy.LoadBalancer != nil {
+				loadBalancer = portPolicy.LoadBalancer
+			}
+			if portPolicy.Tls != nil {
+				tls = portPolicy.Tls
+			}
+		}
+	}
+
+	if loadBalancer != nil {
+		switch lbPolicy := loadBalancer.LbPolicy.(type) {
+		case *istionetworking.LoadBalancerSettings_Simple:
+			switch lbPolicy.Simple {
+			case istionetworking.LoadBalancerSettings_ROUND_ROBIN:
+				poolNode.LbAlgorithm = lib.LB_ALGORITHM_ROUND_ROBIN
+			case istionetworking.LoadBalancerSettings_LEAST_CONN:
+				poolNode.LbAlgorithm = lib.LB_ALGORITHM_LEAST_CONNECTIONS
+			case istionetworking.LoadBalancerSettings_RANDOM:
+				poolNode.LbAlgorithm = lib.LB_ALGORITHM_RANDOM
+			default:
+				utils.AviLog.Warnf("key: %s, msg: load balancer %s in destinationrule %s/%s is not supported", key, lbPolicy.Simple.String(), destinationRule.Namespace, destinationRule.Name)
+			}
+		case *istionetworking.LoadBalancerSettings_ConsistentHash:
+			switch hashKey := lbPolicy.ConsistentHash.GetHashKey().(type) {
+			case *istionetworking.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName:
+				poolNode.LbAlgorithm = lib.LB_ALGORITHM_CONSISTENT_HASH
+				poolNode.LbAlgorithmHash = lib.LB_ALGORITHM_CONSISTENT_HASH_CUSTOM_HEADER
+				poolNode.LbAlgoHostHeader = hashKey.HttpHeaderName
+			case *istionetworking.LoadBalancerSettings_ConsistentHashLB_UseSourceIp:
+				poolNode.LbAlgorithm = lib.LB_ALGORITHM_CONSISTENT_HASH
+				poolNode.LbAlgorithmHash = lib.LB_ALGORITHM_CONSISTENT_HASH_SOURCE_IP_ADDRESS
+			default:
+				utils.AviLog.Warnf("key: %s, msg: consistent hash key in destinationrule %s/%s is not supported", key, destinationRule.Namespace, destinationRule.Name)
+			}
+		}
+	}
+
+	if tls != nil {
+		switch tls.Mode {
+		case istionetworking.ClientTLSSettings_DISABLE:
+			poolNode.PkiProfileRef = ""
+			poolNode.SslKeyAndCertificateRef = ""
+		case istionetworking.ClientTLSSettings_SIMPLE, istionetworking.ClientTLSSettings_MUTUAL:
+			if tls.Mode == istionetworking.ClientTLSSettings_MUTUAL {
+				utils.AviLog.Warnf("key: %s, msg: client certificates of destinationrule %s/%s are not supported, using SIMPLE TLS", key, destinationRule.Namespace, destinationRule.Name)
+			}
+			poolNode.PkiProfileRef = ""
+			poolNode.SslKeyAndCertificateRef = ""
+			poolNode.SniEnabled = true
+			poolNode.SslProfileRef = fmt.Sprintf("/api/sslprofile?name=%s", lib.DefaultPoolSSLProfile)
+		}
+	}
+}
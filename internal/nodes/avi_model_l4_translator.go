@@ -16,6 +16,7 @@ package nodes
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,6 +33,8 @@ import (
 	"github.com/vmware/alb-sdk/go/models"
 	avimodels "github.com/vmware/alb-sdk/go/models"
 	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -74,29 +77,24 @@ func (o *AviObjectGraph) ConstructAviL4VsNode(svcObj *corev1.Service, key string
 		avi_vs_meta.VrfContext = vrfcontext
 	}
 	avi_vs_meta.AviMarkers = lib.PopulateL4VSNodeMarkers(svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name)
-	isTCP := false
-	var portProtocols []AviPortHostProtocol
+	// The L4 policy set can only match TCP and UDP listeners, hence each SCTP listener is placed on a child
+	// virtual service, which shares the VsVip of the parent virtual service.
+	var portProtocols, childPortProtocols []AviPortHostProtocol
 	for _, port := range svcObj.Spec.Ports {
 		pp := AviPortHostProtocol{Port: int32(port.Port), Protocol: fmt.Sprint(port.Protocol), Name: port.Name, TargetPort: port.TargetPort}
-		portProtocols = append(portProtocols, pp)
-		if port.Protocol == "" || port.Protocol == utils.TCP {
-			isTCP = true
+		if pp.Protocol == utils.SCTP {
+			childPortProtocols = append(childPortProtocols, pp)
+		} else {
+			portProtocols = append(portProtocols, pp)
 		}
 	}
+	if len(portProtocols) == 0 && len(childPortProtocols) > 0 {
+		portProtocols, childPortProtocols = childPortProtocols[:1], childPortProtocols[1:]
+	}
 	avi_vs_meta.PortProto = portProtocols
 	// Default case.
 	avi_vs_meta.ApplicationProfile = utils.DEFAULT_L4_APP_PROFILE
-	if !isTCP {
-		avi_vs_meta.NetworkProfile = utils.SYSTEM_UDP_FAST_PATH
-	} else {
-		license := lib.AKOControlConfig().GetLicenseType()
-
-		if license == "ENTERPRISE" {
-			avi_vs_meta.NetworkProfile = utils.DEFAULT_TCP_NW_PROFILE
-		} else {
-			avi_vs_meta.NetworkProfile = utils.TCP_NW_FAST_PATH
-		}
-	}
+	avi_vs_meta.NetworkProfile = getL4VSNetworkProfile(portProtocols)
 
 	vsVipName := lib.GetL4VSVipName(svcObj.ObjectMeta.Name, svcObj.ObjectMeta.Namespace)
 	vsVipNode := &AviVSVIPNode{
@@ -122,11 +120,87 @@ func (o *AviObjectGraph) ConstructAviL4VsNode(svcObj *corev1.Service, key string
 	if svcObj.Spec.LoadBalancerIP != "" {
 		vsVipNode.IPAddress = svcObj.Spec.LoadBalancerIP
 	}
+	vsVipNode.IPType = lib.GetServiceVipIPType(svcObj)
 
 	avi_vs_meta.VSVIPRefs = append(avi_vs_meta.VSVIPRefs, vsVipNode)
+
+	// The loadBalancerSourceRanges of the service are allowed by a network security policy, which denies all the
+	// other clients.
+	if allowedCIDRs := getLoadBalancerSourceRanges(svcObj, key); len(allowedCIDRs) != 0 {
+		nspNode := &AviNetworkSecurityPolicyNode{
+			Name:         vsName,
+			Tenant:       lib.GetTenant(),
+			AllowedCIDRs: allowedCIDRs,
+			AviMarkers:   lib.PopulateL4VSNodeMarkers(svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name),
+		}
+		avi_vs_meta.NSPolicyRefs = append(avi_vs_meta.NSPolicyRefs, nspNode)
+	}
+
+	for _, pp := range childPortProtocols {
+		childNode := &AviVsNode{
+			Name:               lib.GetL4ChildVSName(svcObj.ObjectMeta.Name, svcObj.ObjectMeta.Namespace, pp.Protocol, pp.Port),
+			Tenant:             lib.GetTenant(),
+			ServiceEngineGroup: avi_vs_meta.ServiceEngineGroup,
+			EnableRhi:          avi_vs_meta.EnableRhi,
+			VrfContext:         avi_vs_meta.VrfContext,
+			AviMarkers:         avi_vs_meta.AviMarkers,
+			PortProto:          []AviPortHostProtocol{pp},
+			ApplicationProfile: utils.DEFAULT_L4_APP_PROFILE,
+			NetworkProfile:     lib.GetL4NetworkProfile(pp.Protocol),
+			VSVIPRefs:          avi_vs_meta.VSVIPRefs,
+			ServiceMetadata: lib.ServiceMetadataObj{
+				L4ParentRef: vsName,
+			},
+		}
+		for _, nspNode := range avi_vs_meta.NSPolicyRefs {
+			childNode.NSPolicyRefs = append(childNode.NSPolicyRefs, &AviNetworkSecurityPolicyNode{
+				Name:         childNode.Name,
+				Tenant:       nspNode.Tenant,
+				AllowedCIDRs: nspNode.AllowedCIDRs,
+				AviMarkers:   nspNode.AviMarkers,
+			})
+		}
+		avi_vs_meta.L4ChildNodes = append(avi_vs_meta.L4ChildNodes, childNode)
+		avi_vs_meta.ServiceMetadata.L4ChildRefs = append(avi_vs_meta.ServiceMetadata.L4ChildRefs, childNode.Name)
+	}
 	return avi_vs_meta
 }
 
+// getL4VSNetworkProfile returns the network profile of an L4 virtual service with the given listeners. When the
+// listeners have different protocols, the TCP profile is used and the UDP listeners override it with their own profile.
+func getL4VSNetworkProfile(portProtocols []AviPortHostProtocol) string {
+	protocols := sets.NewString()
+	for _, pp := range portProtocols {
+		if pp.Protocol == "" {
+			protocols.Insert(utils.TCP)
+		} else {
+			protocols.Insert(pp.Protocol)
+		}
+	}
+	for _, protocol := range []string{utils.TCP, utils.UDP, utils.SCTP} {
+		if protocols.Has(protocol) {
+			return lib.GetL4NetworkProfile(protocol)
+		}
+	}
+	return lib.GetL4NetworkProfile(utils.UDP)
+}
+
+// getLoadBalancerSourceRanges returns the valid CIDRs of the loadBalancerSourceRanges of the service.
+func getLoadBalancerSourceRanges(svcObj *corev1.Service, key string) []string {
+	var cidrs []string
+	for _, sourceRange := range svcObj.Spec.LoadBalancerSourceRanges {
+		sourceRange = strings.TrimSpace(sourceRange)
+		if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+			utils.AviLog.Warnf("key: %s, msg: invalid loadBalancerSourceRange %s, err: %v", key, sourceRange, err)
+			continue
+		}
+		if !utils.HasElem(cidrs, sourceRange) {
+			cidrs = append(cidrs, sourceRange)
+		}
+	}
+	return cidrs
+}
+
 func (o *AviObjectGraph) ConstructAviL4PolPoolNodes(svcObj *corev1.Service, vsNode *AviVsNode, key string) {
 	var l4Policies []*AviL4PolicyNode
 	var portPoolSet []AviHostPathPortPoolPG
@@ -138,76 +212,102 @@ func (o *AviObjectGraph) ConstructAviL4PolPoolNodes(svcObj *corev1.Service, vsNo
 	}
 	protocolSet := sets.NewString()
 	for _, portProto := range vsNode.PortProto {
-		filterPort := portProto.Port
-		poolNode := &AviPoolNode{
-			Name:       lib.GetL4PoolName(svcObj.ObjectMeta.Name, svcObj.ObjectMeta.Namespace, portProto.Protocol, filterPort),
-			Tenant:     lib.GetTenant(),
-			Protocol:   portProto.Protocol,
-			PortName:   portProto.Name,
-			Port:       portProto.Port,
-			TargetPort: portProto.TargetPort,
-			VrfContext: lib.GetVrf(),
-		}
-		if lib.IsIstioEnabled() {
-			poolNode.UpdatePoolNodeForIstio()
+		poolNode := buildL4PoolNode(svcObj, portProto, infraSetting, key)
+		vsNode.PoolRefs = append(vsNode.PoolRefs, poolNode)
+		if portProto.Protocol == utils.SCTP {
+			// An SCTP listener is alone on its virtual service, and is routed to its pool by default.
+			vsNode.DefaultPool = poolNode.Name
+			continue
 		}
 		protocolSet.Insert(portProto.Protocol)
-		poolNode.NetworkPlacementSettings, _ = lib.GetNodeNetworkMap()
+		pool_ref := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
+		portPool := AviHostPathPortPoolPG{Port: uint32(portProto.Port), Pool: pool_ref, Protocol: portProto.Protocol}
+		portPoolSet = append(portPoolSet, portPool)
+	}
 
-		if lib.GetT1LRPath() != "" {
-			poolNode.T1Lr = lib.GetT1LRPath()
-			// Unset the poolnode's vrfcontext.
-			poolNode.VrfContext = ""
-		}
+	if vsNode.DefaultPool == "" {
+		l4policyNode := &AviL4PolicyNode{Name: vsNode.Name, Tenant: lib.GetTenant(), PortPool: portPoolSet}
+		sort.Strings(protocolSet.List())
+		protocols := strings.Join(protocolSet.List(), ",")
+		l4policyNode.AviMarkers = lib.PopulateL4PolicysetMarkers(svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, protocols)
+		l4Policies = append(l4Policies, l4policyNode)
+	}
+	vsNode.L4PolicyRefs = l4Policies
+	//As pool naming covention changed for L4 pools marking flag, so that cksum will be changed
+	vsNode.IsL4VS = true
+	utils.AviLog.Infof("key: %s, msg: evaluated L4 pool policies :%v", key, utils.Stringify(vsNode.L4PolicyRefs))
 
-		serviceType := lib.GetServiceType()
-		if serviceType == lib.NodePortLocal {
-			if svcObj.Spec.Type == "NodePort" {
-				utils.AviLog.Warnf("key: %s, msg: Service of type NodePort is not supported when `serviceType` is NodePortLocal.", key)
-			} else {
-				if servers := PopulateServersForNPL(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
-					poolNode.Servers = servers
-				}
-			}
-		} else if _, ok := svcObj.GetAnnotations()[lib.SkipNodePortAnnotation]; ok {
-			// This annotation's presence on the svc object means that the node ports should be skipped.
-			if servers := PopulateServers(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
-				poolNode.Servers = servers
-			}
-		} else if serviceType == lib.NodePort {
-			if servers := PopulateServersForNodePort(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
-				poolNode.Servers = servers
-			}
+	for _, childNode := range vsNode.L4ChildNodes {
+		poolNode := buildL4PoolNode(svcObj, childNode.PortProto[0], infraSetting, key)
+		childNode.PoolRefs = []*AviPoolNode{poolNode}
+		childNode.DefaultPool = poolNode.Name
+		childNode.IsL4VS = true
+	}
+}
+
+// buildL4PoolNode builds the pool of a listener of an L4 service.
+func buildL4PoolNode(svcObj *corev1.Service, portProto AviPortHostProtocol, infraSetting *akov1alpha1.AviInfraSetting, key string) *AviPoolNode {
+	filterPort := portProto.Port
+	poolNode := &AviPoolNode{
+		Name:       lib.GetL4PoolName(svcObj.ObjectMeta.Name, svcObj.ObjectMeta.Namespace, portProto.Protocol, filterPort),
+		Tenant:     lib.GetTenant(),
+		Protocol:   portProto.Protocol,
+		PortName:   portProto.Name,
+		Port:       portProto.Port,
+		TargetPort: portProto.TargetPort,
+		VrfContext: lib.GetVrf(),
+	}
+	if lib.IsIstioEnabled() {
+		poolNode.UpdatePoolNodeForIstio()
+	}
+	poolNode.NetworkPlacementSettings, _ = lib.GetNodeNetworkMap()
+
+	if lib.GetT1LRPath() != "" {
+		poolNode.T1Lr = lib.GetT1LRPath()
+		// Unset the poolnode's vrfcontext.
+		poolNode.VrfContext = ""
+	}
+
+	serviceType := lib.GetServiceType()
+	if serviceType == lib.NodePortLocal {
+		if svcObj.Spec.Type == "NodePort" {
+			utils.AviLog.Warnf("key: %s, msg: Service of type NodePort is not supported when `serviceType` is NodePortLocal.", key)
 		} else {
-			if servers := PopulateServers(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
+			if servers := PopulateServersForNPL(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
 				poolNode.Servers = servers
 			}
 		}
+	} else if _, ok := svcObj.GetAnnotations()[lib.SkipNodePortAnnotation]; ok {
+		// This annotation's presence on the svc object means that the node ports should be skipped.
+		if servers := PopulateServers(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	} else if serviceType == lib.NodePort {
+		if servers := PopulateServersForNodePort(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	} else {
+		if servers := PopulateServers(poolNode, svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	}
 
-		poolNode.AviMarkers = lib.PopulateL4PoolNodeMarkers(svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, strconv.Itoa(int(filterPort)))
-		pool_ref := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
-		portPool := AviHostPathPortPoolPG{Port: uint32(filterPort), Pool: pool_ref, Protocol: portProto.Protocol}
-		portPoolSet = append(portPoolSet, portPool)
-
-		buildPoolWithInfraSetting(key, poolNode, infraSetting)
-
-		vsNode.PoolRefs = append(vsNode.PoolRefs, poolNode)
-		utils.AviLog.Infof("key: %s, msg: evaluated L4 pool values :%v", key, utils.Stringify(poolNode))
+	poolNode.AviMarkers = lib.PopulateL4PoolNodeMarkers(svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, strconv.Itoa(int(filterPort)))
+	if svcObj.Spec.SessionAffinity == corev1.ServiceAffinityClientIP {
+		poolNode.PersistenceProfile = &AviPersistenceProfileNode{
+			Name:       lib.GetL4PersistenceProfileName(poolNode.Name),
+			Tenant:     lib.GetTenant(),
+			Timeout:    lib.GetClientIPPersistenceTimeout(svcObj),
+			AviMarkers: poolNode.AviMarkers,
+		}
 	}
 
-	l4policyNode := &AviL4PolicyNode{Name: vsNode.Name, Tenant: lib.GetTenant(), PortPool: portPoolSet}
-	sort.Strings(protocolSet.List())
-	protocols := strings.Join(protocolSet.List(), ",")
-	l4policyNode.AviMarkers = lib.PopulateL4PolicysetMarkers(svcObj.ObjectMeta.Namespace, svcObj.ObjectMeta.Name, protocols)
-	l4Policies = append(l4Policies, l4policyNode)
-	vsNode.L4PolicyRefs = l4Policies
-	//As pool naming covention changed for L4 pools marking flag, so that cksum will be changed
-	vsNode.IsL4VS = true
-	utils.AviLog.Infof("key: %s, msg: evaluated L4 pool policies :%v", key, utils.Stringify(vsNode.L4PolicyRefs))
+	buildPoolWithInfraSetting(key, poolNode, infraSetting)
+	utils.AviLog.Infof("key: %s, msg: evaluated L4 pool values :%v", key, utils.Stringify(poolNode))
+	return poolNode
 }
 
 func PopulateServersForNPL(poolNode *AviPoolNode, ns string, serviceName string, ingress bool, key string) []AviPoolMetaServer {
-	ipFamily := lib.GetIPFamily()
 	if ingress {
 		found, _ := objects.SharedClusterIpLister().Get(ns + "/" + serviceName)
 		if !found {
@@ -231,19 +331,10 @@ func PopulateServersForNPL(poolNode *AviPoolNode, ns string, serviceName string,
 		}
 		annotations = obj.([]lib.NPLAnnotation)
 		for _, a := range annotations {
-			var atype string
-			if utils.IsV4(a.NodeIP) {
-				if ipFamily != "V4" {
-					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", a.NodeIP, ipFamily)
-					continue
-				}
-				atype = "V4"
-			} else {
-				if ipFamily != "V6" {
-					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", a.NodeIP, ipFamily)
-					continue
-				}
-				atype = "V6"
+			atype, ok := lib.GetServerIPType(a.NodeIP)
+			if !ok {
+				utils.AviLog.Infof("Skipping server %s, ipFamily is %s", a.NodeIP, lib.GetIPFamily())
+				continue
 			}
 			if (poolNode.TargetPort.Type == intstr.Int && a.PodPort == poolNode.TargetPort.IntValue()) ||
 				a.PodPort == int(targetPort) {
@@ -263,7 +354,6 @@ func PopulateServersForNPL(poolNode *AviPoolNode, ns string, serviceName string,
 
 func PopulateServersForNodePort(poolNode *AviPoolNode, ns string, serviceName string, ingress bool, key string) []AviPoolMetaServer {
 
-	ipFamily := lib.GetIPFamily()
 	// Get all nodes which match nodePortSelector
 	nodePortSelector := lib.GetNodePortsSelector()
 	nodePortFilter := map[string]string{}
@@ -285,6 +375,12 @@ func PopulateServersForNodePort(poolNode *AviPoolNode, ns string, serviceName st
 		utils.AviLog.Debugf("key: %s, msg: ClusterIP is not processed in NodePort: %s", key, serviceName)
 		return poolMeta
 	}
+	// With the Local external traffic policy, the node ports forward the traffic only to the endpoints on the same
+	// node, so only the nodes which host a ready endpoint of the service are added as servers.
+	var localNodes map[string]bool
+	if svcObj.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal {
+		localNodes = getServiceEndpointNodes(ns, serviceName, key)
+	}
 	for _, port := range svcObj.Spec.Ports {
 		if port.Name != poolNode.PortName && len(svcObj.Spec.Ports) != 1 {
 			// continue only if port name does not match and its multiport svcobj
@@ -309,36 +405,41 @@ func PopulateServersForNodePort(poolNode *AviPoolNode, ns string, serviceName st
 				}
 
 			}
+			if localNodes != nil && !localNodes[node.Name] {
+				continue
+			}
 			addresses := node.Status.Addresses
-			ip := ""
-			var atype string
+			// a dual-stack node has an InternalIP of each family, both are added as servers in dual-stack.
+			var ip, ip6 string
 			for _, address := range addresses {
 				if address.Type == corev1.NodeInternalIP {
-					ip = address.Address
+					if utils.IsV4(address.Address) {
+						ip = address.Address
+					} else {
+						ip6 = address.Address
+					}
 				}
 			}
-			if ip == "" {
+			if ip == "" && ip6 == "" {
 				utils.AviLog.Warnf("key: %s,msg: NodeInternalIP not found for node: %s", key, node.Name)
 				return nil
 			}
 
-			if utils.IsV4(ip) {
-				if ipFamily != "V4" {
-					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", ip, ipFamily)
+			for _, nodeIP := range []string{ip, ip6} {
+				if nodeIP == "" {
 					continue
 				}
-				atype = "V4"
-			} else {
-				if ipFamily != "V6" {
-					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", ip, ipFamily)
+				nodeIP := nodeIP
+				atype, ok := lib.GetServerIPType(nodeIP)
+				if !ok {
+					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", nodeIP, lib.GetIPFamily())
 					continue
 				}
-				atype = "V6"
-			}
 
-			a := avimodels.IPAddr{Type: &atype, Addr: &ip}
-			server := AviPoolMetaServer{Ip: a}
-			poolMeta = append(poolMeta, server)
+				a := avimodels.IPAddr{Type: &atype, Addr: &nodeIP}
+				server := AviPoolMetaServer{Ip: a}
+				poolMeta = append(poolMeta, server)
+			}
 		}
 	}
 
@@ -347,7 +448,6 @@ func PopulateServersForNodePort(poolNode *AviPoolNode, ns string, serviceName st
 
 func PopulateServers(poolNode *AviPoolNode, ns string, serviceName string, ingress bool, key string) []AviPoolMetaServer {
 
-	ipFamily := lib.GetIPFamily()
 	// Find the servers that match the port.
 	if ingress {
 		// If it's an ingress case, check if the service of type clusterIP or not.
@@ -357,12 +457,16 @@ func PopulateServers(poolNode *AviPoolNode, ns string, serviceName string, ingre
 			return nil
 		}
 	}
+	if utils.GetInformers().EpSlicesInformer != nil {
+		return populateServersFromEndpointSlices(poolNode, ns, serviceName, key)
+	}
 	epObj, err := utils.GetInformers().EpInformer.Lister().Endpoints(ns).Get(serviceName)
 	if err != nil {
 		utils.AviLog.Warnf("key: %s, msg: error while retrieving endpoints: %s", key, err)
 		return nil
 	}
 	var pool_meta []AviPoolMetaServer
+	serverPods := make(map[string]string)
 	for _, ss := range epObj.Subsets {
 		port_match := false
 		for _, epp := range ss.Ports {
@@ -378,40 +482,198 @@ func PopulateServers(poolNode *AviPoolNode, ns string, serviceName string, ingre
 			poolNode.Port = ss.Ports[0].Port
 		}
 		if port_match {
-			var atype string
 			utils.AviLog.Infof("key: %s, msg: found port match for port %v", key, poolNode.Port)
 			for _, addr := range ss.Addresses {
 
 				ip := addr.IP
-				if utils.IsV4(addr.IP) {
-					if ipFamily != "V4" {
-						utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr.IP, ipFamily)
-						continue
-					}
-					atype = "V4"
-				} else {
-					if ipFamily != "V6" {
-						utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr.IP, ipFamily)
-						continue
-					}
-					atype = "V6"
+				atype, ok := lib.GetServerIPType(addr.IP)
+				if !ok {
+					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr.IP, lib.GetIPFamily())
+					continue
 				}
 				a := avimodels.IPAddr{Type: &atype, Addr: &ip}
 				server := AviPoolMetaServer{Ip: a}
 				if addr.NodeName != nil {
 					server.ServerNode = *addr.NodeName
 				}
+				serverPods[ip] = endpointPodName(addr.TargetRef)
 				pool_meta = append(pool_meta, server)
 			}
 		}
 	}
+	// The addresses of the terminating pods are removed from the Endpoints, so they are drained as removed servers.
+	pool_meta = drainPoolServers(poolNode.Name, ns, serviceName, pool_meta, nil, serverPods, key)
+	poolNode.ProbeHealthMonitor = buildProbeHealthMonitor(poolNode, ns, serviceName, pool_meta, serverPods, key)
 	utils.AviLog.Infof("key: %s, msg: servers for port: %v, are: %v", key, poolNode.Port, utils.Stringify(pool_meta))
 	return pool_meta
 }
 
+// populateServersFromEndpointSlices builds the servers from the EndpointSlices of the service. The ready
+// endpoints are used as servers, and only if none of them are ready, the serving endpoints are used, so that
+// the terminating endpoints keep serving the traffic. Otherwise the terminating endpoints are drained, if the
+// server drain is enabled. If the SE zone is set, the servers get a ratio based on their zone, so that the
+// servers in the SE zone are preferred.
+func populateServersFromEndpointSlices(poolNode *AviPoolNode, ns string, serviceName string, key string) []AviPoolMetaServer {
+	seZone := lib.GetSEZone()
+	epSlices, err := getServiceEndpointSlices(ns, serviceName)
+	if err != nil || len(epSlices) == 0 {
+		utils.AviLog.Warnf("key: %s, msg: error while retrieving endpointslices for service %s/%s: %v", key, ns, serviceName, err)
+		return nil
+	}
+
+	// The EndpointSlices of a service are split by address type and by size, so a single port across all
+	// the slices is treated the same way as a single port in a single Endpoints subset.
+	singlePort := true
+	for _, epSlice := range epSlices {
+		if len(epSlice.Ports) != 1 || epSlice.Ports[0].Port == nil || *epSlice.Ports[0].Port != *epSlices[0].Ports[0].Port {
+			singlePort = false
+			break
+		}
+	}
+
+	var readyServers, servingServers, terminatingServers []AviPoolMetaServer
+	serverPods := make(map[string]string)
+	for _, epSlice := range epSlices {
+		if epSlice.AddressType == discovery.AddressTypeFQDN {
+			continue
+		}
+		portMatch := false
+		for _, epp := range epSlice.Ports {
+			if epp.Port == nil {
+				continue
+			}
+			if (epp.Name != nil && poolNode.PortName == *epp.Name) || int32(poolNode.TargetPort.IntValue()) == *epp.Port {
+				portMatch = true
+				poolNode.Port = *epp.Port
+				break
+			}
+		}
+		if singlePort {
+			portMatch = true
+			poolNode.Port = *epSlice.Ports[0].Port
+		}
+		if !portMatch {
+			continue
+		}
+		for _, endpoint := range epSlice.Endpoints {
+			ready := endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+			serving := endpoint.Conditions.Serving != nil && *endpoint.Conditions.Serving
+			terminating := endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating
+			if !ready && !serving && !terminating {
+				continue
+			}
+			for _, addr := range endpoint.Addresses {
+				ip := addr
+				atype, ok := lib.GetServerIPType(addr)
+				if !ok {
+					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr, lib.GetIPFamily())
+					continue
+				}
+				server := AviPoolMetaServer{Ip: avimodels.IPAddr{Type: &atype, Addr: &ip}}
+				if endpoint.NodeName != nil {
+					server.ServerNode = *endpoint.NodeName
+				}
+				if seZone != "" {
+					server.Ratio = getEndpointZoneRatio(endpoint, seZone)
+				}
+				serverPods[ip] = endpointPodName(endpoint.TargetRef)
+				if ready {
+					readyServers = append(readyServers, server)
+					continue
+				}
+				if serving {
+					servingServers = append(servingServers, server)
+				}
+				if terminating {
+					terminatingServers = append(terminatingServers, server)
+				}
+			}
+		}
+	}
+	if len(readyServers) == 0 && len(servingServers) != 0 {
+		utils.AviLog.Infof("key: %s, msg: no ready endpoints found for service %s/%s, using the serving endpoints", key, ns, serviceName)
+		readyServers = servingServers
+	}
+	readyServers = drainPoolServers(poolNode.Name, ns, serviceName, readyServers, terminatingServers, serverPods, key)
+	poolNode.ProbeHealthMonitor = buildProbeHealthMonitor(poolNode, ns, serviceName, readyServers, serverPods, key)
+	utils.AviLog.Infof("key: %s, msg: servers for port: %v, are: %v", key, poolNode.Port, utils.Stringify(readyServers))
+	return readyServers
+}
+
+func getServiceEndpointSlices(ns, serviceName string) ([]*discovery.EndpointSlice, error) {
+	selector := labels.SelectorFromSet(labels.Set{discovery.LabelServiceName: serviceName})
+	return utils.GetInformers().EpSlicesInformer.Lister().EndpointSlices(ns).List(selector)
+}
+
+// getServiceEndpointAddresses returns the ready addresses of the service, from the EndpointSlices if they
+// are used, else from the Endpoints.
+func getServiceEndpointAddresses(ns, serviceName string) ([]corev1.EndpointAddress, error) {
+	var addrs []corev1.EndpointAddress
+	if utils.GetInformers().EpSlicesInformer != nil {
+		epSlices, err := getServiceEndpointSlices(ns, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		for _, epSlice := range epSlices {
+			for _, endpoint := range epSlice.Endpoints {
+				if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+					continue
+				}
+				for _, ip := range endpoint.Addresses {
+					addrs = append(addrs, corev1.EndpointAddress{IP: ip, NodeName: endpoint.NodeName, TargetRef: endpoint.TargetRef})
+				}
+			}
+		}
+		return addrs, nil
+	}
+	epObj, err := utils.GetInformers().EpInformer.Lister().Endpoints(ns).Get(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range epObj.Subsets {
+		addrs = append(addrs, ss.Addresses...)
+	}
+	return addrs, nil
+}
+
+// getServiceEndpointNodes returns the names of the nodes which host the ready endpoints of the service.
+func getServiceEndpointNodes(ns, serviceName, key string) map[string]bool {
+	nodes := make(map[string]bool)
+	addrs, err := getServiceEndpointAddresses(ns, serviceName)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: error in obtaining the endpoints for service: %s, err: %v", key, serviceName, err)
+		return nodes
+	}
+	for _, addr := range addrs {
+		if addr.NodeName != nil && *addr.NodeName != "" {
+			nodes[*addr.NodeName] = true
+		}
+	}
+	return nodes
+}
+
+// getEndpointZoneRatio returns the server ratio for an endpoint based on the SE zone. The topology aware hints
+// of the endpoint take precedence over its zone. The ratio is not set if the zone of the endpoint is unknown.
+func getEndpointZoneRatio(endpoint discovery.Endpoint, seZone string) int32 {
+	if endpoint.Hints != nil && len(endpoint.Hints.ForZones) > 0 {
+		for _, zone := range endpoint.Hints.ForZones {
+			if zone.Name == seZone {
+				return lib.LocalZoneServerRatio
+			}
+		}
+		return lib.RemoteZoneServerRatio
+	}
+	if endpoint.Zone == nil {
+		return 0
+	}
+	if *endpoint.Zone == seZone {
+		return lib.LocalZoneServerRatio
+	}
+	return lib.RemoteZoneServerRatio
+}
+
 func PopulateServersForMultiClusterIngress(poolNode *AviPoolNode, ns, cluster, serviceNamespace, serviceName string, key string) []AviPoolMetaServer {
 
-	ipFamily := lib.GetIPFamily()
 	var servers []AviPoolMetaServer
 	svcName := generateMultiClusterKey(cluster, serviceNamespace, serviceName)
 	success, siNames := objects.SharedMultiClusterIngressSvcLister().MultiClusterIngressMappings(ns).GetSvcToSI(svcName)
@@ -429,19 +691,10 @@ func PopulateServersForMultiClusterIngress(poolNode *AviPoolNode, ns, cluster, s
 		for _, backend := range serviceImport.Spec.SvcPorts {
 			for _, ep := range backend.Endpoints {
 				addr := ep.IP
-				var addrType string
-				if utils.IsV4(addr) {
-					if ipFamily != "V4" {
-						utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr, ipFamily)
-						continue
-					}
-					addrType = "V4"
-				} else {
-					if ipFamily != "V6" {
-						utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr, ipFamily)
-						continue
-					}
-					addrType = "V6"
+				addrType, ok := lib.GetServerIPType(addr)
+				if !ok {
+					utils.AviLog.Infof("Skipping server %s, ipFamily is %s", addr, lib.GetIPFamily())
+					continue
 				}
 				Ip := avimodels.IPAddr{
 					Addr: &addr,
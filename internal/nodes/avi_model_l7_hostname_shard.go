@@ -189,6 +189,9 @@ func (o *AviObjectGraph) BuildPoolPGPolicyForDedicatedVS(vsNode []*AviVsNode, na
 			pool_ref := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
 			ratio := obj.weight
 			pgNode.Members = append(pgNode.Members, &avimodels.PoolGroupMember{PoolRef: &pool_ref, Ratio: &ratio})
+			if isIngr {
+				BuildHTTPRuleBackends(hostname, obj, ingName, namespace, key, infraSetting, vsNode[0], pgNode, poolNode.Name, pgNode.Name)
+			}
 			if vsNode[0].CheckPGNameNChecksum(pgNode.Name, pgNode.GetCheckSum()) {
 				vsNode[0].ReplaceSniPGInSNINode(pgNode, key)
 			}
@@ -207,6 +210,8 @@ func (o *AviObjectGraph) BuildPoolPGPolicyForDedicatedVS(vsNode []*AviVsNode, na
 			if vsNode[0].CheckHttpPolNameNChecksum(httpPolName, hppMapName, httpPGPath.Checksum) {
 				vsNode[0].ReplaceSniHTTPRefInSNINode(httpPGPath, httpPolName, key)
 			}
+			pgName := lib.GetSniPGName(ingName, namespace, hostname, obj.Path, infraSettingName, vsNode[0].Dedicated)
+			BuildHTTPRuleMatches(hostname, obj.Path, ingName, namespace, key, infraSetting, vsNode[0], httpPolName, hppMapName, pgName, isIngr)
 		}
 		BuildPoolHTTPRule(hostname, obj.Path, ingName, namespace, infraSettingName, key, vsNode[0], true, vsNode[0].Dedicated)
 	}
@@ -281,6 +286,9 @@ func (o *AviObjectGraph) BuildL7VSGraphHostNameShard(vsName, hostname string, ro
 			}
 			poolNode := buildPoolNode(key, poolName, ingName, namespace, priorityLabel, hostname, infraSetting, serviceName, storedHosts, insecureEdgeTermAllow, obj)
 			vsNode[0].PoolRefs = append(vsNode[0].PoolRefs, poolNode)
+			if routeIgrObj.GetType() == utils.Ingress {
+				BuildHTTPRuleBackends(hostname, obj, ingName, namespace, key, infraSetting, vsNode[0], nil, poolNode.Name, poolNode.Name)
+			}
 			utils.AviLog.Debugf("key: %s, msg: the pools after append are: %v", key, utils.Stringify(vsNode[0].PoolRefs))
 		}
 
@@ -385,6 +393,7 @@ func (o *AviObjectGraph) DeletePoolForHostname(vsName, hostname string, routeIgr
 					}
 					if poolName == pool.Name {
 						o.RemovePoolNodeRefs(poolName)
+						RemoveHTTPRuleBackends(vsNode[0], nil, poolName)
 					}
 				}
 			}
@@ -447,6 +456,9 @@ func (o *AviObjectGraph) manipulateVsNode(vsNode *AviVsNode, ingName, namespace,
 	for path, services := range pathSvc {
 		pgName := lib.GetSniPGName(ingName, namespace, hostname, path, infraSettingName, vsNode.Dedicated)
 		pgNode := vsNode.GetPGForVSByName(pgName)
+		RemoveHTTPRuleMatches(vsNode, lib.GetSniHttpPolName(namespace, hostname, infraSettingName),
+			lib.GetSniHppMapName(ingName, namespace, hostname, path, infraSettingName, vsNode.Dedicated), pgName)
+		RemoveHTTPRuleBackends(vsNode, pgNode, pgName)
 		for _, svc := range services {
 			var sniPool string
 			if isIngr {
@@ -160,6 +160,9 @@ func (v *AviVsNode) CalculateForGraphChecksum() uint32 {
 	for _, passthrough := range v.PassthroughChildNodes {
 		checksumStringSlice = append(checksumStringSlice, fmt.Sprint(passthrough.CalculateForGraphChecksum()))
 	}
+	for _, l4Child := range v.L4ChildNodes {
+		checksumStringSlice = append(checksumStringSlice, fmt.Sprint(l4Child.CalculateForGraphChecksum()))
+	}
 	for _, cacert := range v.CACertRefs {
 		checksumStringSlice = append(checksumStringSlice, fmt.Sprint(cacert.GetCheckSum()))
 	}
@@ -175,6 +178,9 @@ func (v *AviVsNode) CalculateForGraphChecksum() uint32 {
 	for _, l4pol := range v.L4PolicyRefs {
 		checksumStringSlice = append(checksumStringSlice, fmt.Sprint(l4pol.GetCheckSum()))
 	}
+	for _, nsp := range v.NSPolicyRefs {
+		checksumStringSlice = append(checksumStringSlice, fmt.Sprint(nsp.GetCheckSum()))
+	}
 
 	return utils.Hash(strings.Join(checksumStringSlice, ":"))
 }
@@ -379,12 +385,14 @@ type AviVsNode struct {
 	HTTPDSrefs            []*AviHTTPDataScriptNode
 	SniNodes              []*AviVsNode
 	PassthroughChildNodes []*AviVsNode
+	L4ChildNodes          []*AviVsNode
 	SharedVS              bool
 	CACertRefs            []*AviTLSKeyCertNode
 	SSLKeyCertRefs        []*AviTLSKeyCertNode
 	HttpPolicyRefs        []*AviHttpPolicySetNode
 	VSVIPRefs             []*AviVSVIPNode
 	L4PolicyRefs          []*AviL4PolicyNode
+	NSPolicyRefs          []*AviNetworkSecurityPolicyNode
 	VHParentName          string
 	VHDomainNames         []string
 	TLSType               string
@@ -529,6 +537,14 @@ func (v *AviVsNode) SetSSLProfileRef(SSLProfileRef string) {
 	v.SSLProfileRef = SSLProfileRef
 }
 
+func (v *AviVsNode) GetHTTPDSrefs() []*AviHTTPDataScriptNode {
+	return v.HTTPDSrefs
+}
+
+func (v *AviVsNode) SetHTTPDSrefs(httpDSrefs []*AviHTTPDataScriptNode) {
+	v.HTTPDSrefs = httpDSrefs
+}
+
 func (v *AviVsNode) GetVsDatascriptRefs() []string {
 	return v.VsDatascriptRefs
 }
@@ -883,6 +899,10 @@ func (v *AviVsNode) CalculateCheckSum() {
 		checksumStringSlice = append(checksumStringSlice, "L4Policy"+l4policy.Name)
 	}
 
+	for _, nsp := range v.NSPolicyRefs {
+		checksumStringSlice = append(checksumStringSlice, "NSPolicy"+nsp.Name)
+	}
+
 	for _, vhdomain := range v.VHDomainNames {
 		checksumStringSlice = append(checksumStringSlice, "VHDomain"+vhdomain)
 	}
@@ -927,6 +947,10 @@ func (v *AviVsNode) CalculateCheckSum() {
 		checksumStringSlice = append(checksumStringSlice, "PassthroughChild"+passthroughChild.Name)
 	}
 
+	for _, l4Child := range v.L4ChildNodes {
+		checksumStringSlice = append(checksumStringSlice, "L4Child"+l4Child.Name)
+	}
+
 	sort.Strings(checksumStringSlice)
 	checksum := utils.Hash(strings.Join(checksumStringSlice, delim) +
 		v.ApplicationProfile +
@@ -952,9 +976,39 @@ func (v *AviVsNode) CalculateCheckSum() {
 		checksum += lib.GetAnalyticsPolicyChecksum(v.AnalyticsPolicy)
 	}
 
+	// The UDP fast path override of the mixed network profile is already covered by the network profile
+	// and the ports above, so only the other overrides are added, and the checksum of a VS without them
+	// remains the same.
+	var overrideNetworkProfiles []string
+	for _, pp := range portproto {
+		networkProfile := v.GetOverrideNetworkProfile(pp)
+		if networkProfile == "" || (v.NetworkProfile == utils.MIXED_NET_PROFILE && pp.Protocol == utils.UDP) {
+			continue
+		}
+		overrideNetworkProfiles = append(overrideNetworkProfiles, pp.Name+":"+networkProfile)
+	}
+	if len(overrideNetworkProfiles) > 0 {
+		checksum += utils.Hash(utils.Stringify(overrideNetworkProfiles))
+	}
+
 	v.CloudConfigCksum = checksum
 }
 
+// GetOverrideNetworkProfile returns the network profile with which the listener of the port overrides the
+// network profile of the VS, or an empty string if the listener uses the network profile of the VS.
+func (v *AviVsNode) GetOverrideNetworkProfile(pp AviPortHostProtocol) string {
+	if v.NetworkProfile == utils.MIXED_NET_PROFILE && pp.Protocol == utils.UDP {
+		return utils.SYSTEM_UDP_FAST_PATH
+	}
+	if v.IsL4VS && pp.Protocol != "" {
+		// The listeners of an L4 VS, whose protocol does not match the VS network profile, override it.
+		if networkProfile := lib.GetL4NetworkProfile(pp.Protocol); networkProfile != v.NetworkProfile {
+			return networkProfile
+		}
+	}
+	return ""
+}
+
 func (v *AviVsNode) CopyNode() AviModelNode {
 	newNode := AviVsNode{}
 	bytes, err := json.Marshal(v)
@@ -1016,6 +1070,43 @@ func (v *AviL4PolicyNode) CopyNode() AviModelNode {
 	return &newNode
 }
 
+// AviNetworkSecurityPolicyNode is the network security policy of an L4 VS, which denies the clients
+// outside the allowed CIDRs.
+type AviNetworkSecurityPolicyNode struct {
+	Name             string
+	Tenant           string
+	CloudConfigCksum uint32
+	AllowedCIDRs     []string
+	AviMarkers       utils.AviObjectMarkers
+}
+
+func (v *AviNetworkSecurityPolicyNode) GetCheckSum() uint32 {
+	// Calculate checksum and return
+	v.CalculateCheckSum()
+	return v.CloudConfigCksum
+}
+
+func (v *AviNetworkSecurityPolicyNode) CalculateCheckSum() {
+	v.CloudConfigCksum = lib.NetworkSecurityPolicyChecksum(v.AllowedCIDRs, v.AviMarkers, nil, false)
+}
+
+func (v *AviNetworkSecurityPolicyNode) GetNodeType() string {
+	return "AviNetworkSecurityPolicyNode"
+}
+
+func (v *AviNetworkSecurityPolicyNode) CopyNode() AviModelNode {
+	newNode := AviNetworkSecurityPolicyNode{}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		utils.AviLog.Warnf("Unable to marshal AviNetworkSecurityPolicyNode: %s", err)
+	}
+	err = json.Unmarshal(bytes, &newNode)
+	if err != nil {
+		utils.AviLog.Warnf("Unable to unmarshal AviNetworkSecurityPolicyNode: %s", err)
+	}
+	return &newNode
+}
+
 type AviHttpPolicySetNode struct {
 	Name               string
 	Tenant             string
@@ -1024,6 +1115,7 @@ type AviHttpPolicySetNode struct {
 	RedirectPorts      []AviRedirectPort
 	HeaderReWrite      *AviHostHeaderRewrite
 	SecurityRules      []AviHTTPSecurity
+	InlineRules        []AviHTTPPolicyRule
 	AviMarkers         utils.AviObjectMarkers
 	AttachedToSharedVS bool
 }
@@ -1051,6 +1143,9 @@ func (v *AviHttpPolicySetNode) CalculateCheckSum() {
 	if v.HeaderReWrite != nil {
 		checksum = checksum + utils.Hash(utils.Stringify(v.HeaderReWrite))
 	}
+	if len(v.InlineRules) > 0 {
+		checksum = checksum + utils.Hash(utils.Stringify(v.InlineRules))
+	}
 
 	checksum += lib.GetMarkersChecksum(v.AviMarkers)
 
@@ -1086,6 +1181,56 @@ type AviHostPathPortPoolPG struct {
 	MatchCriteria string
 	Protocol      string
 	IngName       string
+
+	// Additional match conditions and actions for the rule. These are used by
+	// Istio VirtualService routes, services API HTTPRoutes and HTTPRule path
+	// matches, and are left empty for Ingresses/Routes.
+	IgnoreCase     bool                  `json:",omitempty"`
+	HostHeader     *AviHTTPHeaderMatch   `json:",omitempty"`
+	Headers        []AviHTTPHeaderMatch  `json:",omitempty"`
+	Cookie         *AviHTTPHeaderMatch   `json:",omitempty"`
+	Query          []string              `json:",omitempty"`
+	Methods        []string              `json:",omitempty"`
+	Rewrite        *AviHTTPRewrite       `json:",omitempty"`
+	Redirect       *AviHTTPRedirect      `json:",omitempty"`
+	RequestHeaders []AviHTTPHeaderAction `json:",omitempty"`
+}
+
+// AviHTTPHeaderMatch matches a request header against a set of values.
+// When used as a HostHeader match, Name is ignored. When used as a Cookie
+// match, Name is the name of the cookie and only its first value is matched.
+type AviHTTPHeaderMatch struct {
+	Name          string
+	MatchCriteria string
+	Values        []string
+}
+
+// AviHTTPRewrite rewrites the Host header and/or the path of a request
+// before it is switched to the pool/poolgroup of the rule.
+type AviHTTPRewrite struct {
+	Host string
+	Path string
+	// If KeepPathSuffix is set, the request path tokens starting at
+	// PathSuffixIndex are appended to Path.
+	KeepPathSuffix  bool
+	PathSuffixIndex int32
+}
+
+// AviHTTPHeaderAction adds or removes a request header before the request
+// is switched to the pool/poolgroup of the rule.
+type AviHTTPHeaderAction struct {
+	Action string
+	Name   string
+	Value  string
+}
+
+// AviHTTPRedirect redirects a request instead of switching it to a pool.
+type AviHTTPRedirect struct {
+	Protocol   string
+	Host       string
+	Path       string
+	Port       int32
+	StatusCode string
 }
 
 func (v *AviHostPathPortPoolPG) GetCheckSum() uint32 {
@@ -1116,6 +1261,29 @@ type AviHTTPSecurity struct {
 	Enable        bool
 	Port          int64
 }
+
+// AviHTTPPolicyRule is an inline http policy rule of a HostRule, which is applied
+// to the requests whose path starts with Path, or to all the requests if Path is empty.
+type AviHTTPPolicyRule struct {
+	Name             string
+	Path             string
+	Redirect         *AviHTTPRedirect      `json:",omitempty"`
+	Rewrite          *AviHTTPRewrite       `json:",omitempty"`
+	RequestHeaders   []AviHTTPHeaderAction `json:",omitempty"`
+	ResponseHeaders  []AviHTTPHeaderAction `json:",omitempty"`
+	AllowedClientIPs []string              `json:",omitempty"`
+	DeniedClientIPs  []string              `json:",omitempty"`
+	RateLimit        *AviHTTPRateLimit     `json:",omitempty"`
+}
+
+// AviHTTPRateLimit limits the rate of the requests of each client to Count requests
+// in Period seconds, allowing bursts of BurstSize requests.
+type AviHTTPRateLimit struct {
+	Count     int32
+	Period    int32
+	BurstSize int32
+}
+
 type AviHostHeaderRewrite struct {
 	Name       string
 	SourceHost string
@@ -1183,6 +1351,7 @@ type AviVSVIPNode struct {
 	FQDNs                   []string
 	VrfContext              string
 	IPAddress               string
+	IPType                  string // ip type of the vip as per the ipFamilies of the object, in dual-stack
 	VipNetworks             []akov1alpha1.AviInfraSettingVipNetwork
 	EnablePublicIP          *bool
 	BGPPeerLabels           []string
@@ -1208,6 +1377,10 @@ func (v *AviVSVIPNode) CalculateCheckSum() {
 		checksum += utils.Hash(v.IPAddress)
 	}
 
+	if v.IPType != "" {
+		checksum += utils.Hash(v.IPType)
+	}
+
 	if len(v.VipNetworks) > 0 {
 		var vipNetworkStringList []string
 		for _, vipNetwork := range v.VipNetworks {
@@ -1321,6 +1494,9 @@ type AviHTTPDataScriptNode struct {
 	PoolGroupRefs    []string
 	ProtocolParsers  []string
 	*DataScript
+	// Scripts are the datascripts sourced from a ConfigMap referred in a HostRule, sorted by the event.
+	Scripts    []DataScript
+	AviMarkers utils.AviObjectMarkers
 }
 
 func (v *AviHTTPDataScriptNode) GetCheckSum() uint32 {
@@ -1331,11 +1507,30 @@ func (v *AviHTTPDataScriptNode) GetCheckSum() uint32 {
 
 func (v *AviHTTPDataScriptNode) CalculateCheckSum() {
 	// A sum of fields for this VS.
-	checksum := lib.DSChecksum(v.PoolGroupRefs, nil, false)
-	if len(v.PoolGroupRefs) == 1 {
-		checksum += utils.Hash(fmt.Sprintf(utils.HTTP_DS_SCRIPT_MODIFIED, v.PoolGroupRefs[0]))
+	v.CloudConfigCksum = lib.DataScriptSetChecksum(v.PoolGroupRefs, v.GetMarkers(), v.GetDataScripts())
+}
+
+// GetDataScripts returns the datascripts of the vsdatascriptset, which are the datascripts sourced from the
+// HostRule ConfigMap if Scripts is set, or the single datascript built by AKO otherwise.
+func (v *AviHTTPDataScriptNode) GetDataScripts() []*avimodels.VSDataScript {
+	var datascripts []*avimodels.VSDataScript
+	if len(v.Scripts) > 0 {
+		for i := range v.Scripts {
+			datascripts = append(datascripts, &avimodels.VSDataScript{Evt: &v.Scripts[i].Evt, Script: &v.Scripts[i].Script})
+		}
+	} else if v.DataScript != nil {
+		datascripts = append(datascripts, &avimodels.VSDataScript{Evt: &v.Evt, Script: &v.Script})
 	}
-	v.CloudConfigCksum = checksum
+	return datascripts
+}
+
+// GetMarkers returns the markers of the vsdatascriptset. The datascripts sourced from the HostRule ConfigMap
+// carry the markers of the HostRule, the other datascripts carry only the cluster name.
+func (v *AviHTTPDataScriptNode) GetMarkers() []*avimodels.RoleFilterMatchLabel {
+	if len(v.Scripts) > 0 {
+		return lib.GetAllMarkers(v.AviMarkers)
+	}
+	return lib.GetMarkers()
 }
 
 func (v *AviHTTPDataScriptNode) GetNodeType() string {
@@ -1406,6 +1601,85 @@ func (v *AviPkiProfileNode) CalculateCheckSum() {
 	v.CloudConfigCksum = checksum
 }
 
+// AviPersistenceProfileNode is the client IP persistence profile of a pool.
+type AviPersistenceProfileNode struct {
+	Name             string
+	Tenant           string
+	CloudConfigCksum uint32
+	Timeout          int32 // minutes
+	AviMarkers       utils.AviObjectMarkers
+}
+
+func (v *AviPersistenceProfileNode) GetNodeType() string {
+	return "PersistenceProfileNode"
+}
+
+func (v *AviPersistenceProfileNode) CopyNode() AviModelNode {
+	newNode := AviPersistenceProfileNode{}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		utils.AviLog.Warnf("Unable to marshal AviPersistenceProfileNode: %s", err)
+	}
+	err = json.Unmarshal(bytes, &newNode)
+	if err != nil {
+		utils.AviLog.Warnf("Unable to unmarshal AviPersistenceProfileNode: %s", err)
+	}
+	return &newNode
+}
+
+func (v *AviPersistenceProfileNode) GetCheckSum() uint32 {
+	// Calculate checksum and return
+	v.CalculateCheckSum()
+	return v.CloudConfigCksum
+}
+
+func (v *AviPersistenceProfileNode) CalculateCheckSum() {
+	v.CloudConfigCksum = lib.PersistenceProfileChecksum(v.Timeout, v.AviMarkers, nil, false)
+}
+
+// AviHealthMonitorNode is the health monitor of a pool, created from the readiness probe of the pods of the pool.
+type AviHealthMonitorNode struct {
+	Name             string
+	Tenant           string
+	CloudConfigCksum uint32
+	Type             string
+	HTTPRequest      string
+	MonitorPort      int32 // 0 if the pods are probed on the pool server port
+	SendInterval     int32 // seconds
+	ReceiveTimeout   int32 // seconds
+	SuccessfulChecks int32
+	FailedChecks     int32
+	AviMarkers       utils.AviObjectMarkers
+}
+
+func (v *AviHealthMonitorNode) GetNodeType() string {
+	return "HealthMonitorNode"
+}
+
+func (v *AviHealthMonitorNode) CopyNode() AviModelNode {
+	newNode := AviHealthMonitorNode{}
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		utils.AviLog.Warnf("Unable to marshal AviHealthMonitorNode: %s", err)
+	}
+	err = json.Unmarshal(bytes, &newNode)
+	if err != nil {
+		utils.AviLog.Warnf("Unable to unmarshal AviHealthMonitorNode: %s", err)
+	}
+	return &newNode
+}
+
+func (v *AviHealthMonitorNode) GetCheckSum() uint32 {
+	// Calculate checksum and return
+	v.CalculateCheckSum()
+	return v.CloudConfigCksum
+}
+
+func (v *AviHealthMonitorNode) CalculateCheckSum() {
+	v.CloudConfigCksum = lib.HealthMonitorChecksum(v.Type, v.HTTPRequest, v.MonitorPort, v.SendInterval, v.ReceiveTimeout,
+		v.SuccessfulChecks, v.FailedChecks, v.AviMarkers, nil, false)
+}
+
 type AviPoolNode struct {
 	Name                     string
 	Tenant                   string
@@ -1428,7 +1702,9 @@ type AviPoolNode struct {
 	PkiProfile               *AviPkiProfileNode
 	NetworkPlacementSettings map[string][]string
 	HealthMonitors           []string
+	ProbeHealthMonitor       *AviHealthMonitorNode
 	ApplicationPersistence   string
+	PersistenceProfile       *AviPersistenceProfileNode
 	VrfContext               string
 	T1Lr                     string // Only applicable to NSX-T cloud, if this value is set, we automatically should unset the VRF context value.
 	AviMarkers               utils.AviObjectMarkers
@@ -1465,6 +1741,9 @@ func (v *AviPoolNode) CalculateCheckSum() {
 	if v.PkiProfileRef != "" {
 		checksumStringSlice = append(checksumStringSlice, v.PkiProfileRef)
 	}
+	if lib.IsServerDrainEnabled() {
+		checksumStringSlice = append(checksumStringSlice, strconv.Itoa(int(lib.GetGracefulDisableTimeout())))
+	}
 	if v.SslKeyAndCertificateRef != "" {
 		checksumStringSlice = append(checksumStringSlice, v.SslKeyAndCertificateRef)
 	}
@@ -1487,6 +1766,10 @@ func (v *AviPoolNode) CalculateCheckSum() {
 		checksum += utils.Hash(utils.Stringify(v.HealthMonitors))
 	}
 
+	if v.ProbeHealthMonitor != nil {
+		checksum += v.ProbeHealthMonitor.GetCheckSum()
+	}
+
 	if v.PkiProfile != nil {
 		checksum += v.PkiProfile.GetCheckSum()
 	}
@@ -1495,6 +1778,10 @@ func (v *AviPoolNode) CalculateCheckSum() {
 		checksum += utils.Hash(v.ApplicationPersistence)
 	}
 
+	if v.PersistenceProfile != nil {
+		checksum += v.PersistenceProfile.GetCheckSum()
+	}
+
 	checksum += lib.GetMarkersChecksum(v.AviMarkers)
 
 	if v.T1Lr != "" {
@@ -1557,6 +1844,11 @@ type AviPoolMetaServer struct {
 	Ip         avimodels.IPAddr
 	ServerNode string
 	Port       int32
+	// Ratio is set based on the zone of the endpoint relative to the SE zone.
+	Ratio int32 `json:"Ratio,omitempty"`
+	// Draining is set for the servers of the terminating or removed endpoints, which are disabled in the pool
+	// until the drain timeout. The drain start time is not part of the server, so it does not change the checksum.
+	Draining bool `json:"Draining,omitempty"`
 }
 
 type IngressHostPathSvc struct {
@@ -54,11 +54,12 @@ func HostNameShardAndPublish(objType, objname, namespace, key string, fullsync b
 
 	defer func(routeIgrObj RouteIngressModel) {
 		if aviInfraSetting := routeIgrObj.GetAviInfraSetting(); aviInfraSetting != nil {
-			var shardSize string
+			var shardSize, shardScheme string
 			if aviInfraSetting.Spec.L7Settings != (akov1alpha1.AviInfraL7Settings{}) {
 				shardSize = aviInfraSetting.Spec.L7Settings.ShardSize
+				shardScheme = aviInfraSetting.Spec.L7Settings.ShardScheme
 			}
-			objects.InfraSettingL7Lister().UpdateIngRouteInfraSettingMappings(namespace+"/"+objname, aviInfraSetting.Name, shardSize)
+			objects.InfraSettingL7Lister().UpdateIngRouteInfraSettingMappings(namespace+"/"+objname, aviInfraSetting.Name, shardSize, shardScheme)
 		} else {
 			objects.InfraSettingL7Lister().RemoveIngRouteInfraSettingMappings(namespace + "/" + objname)
 		}
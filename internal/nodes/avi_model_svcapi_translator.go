@@ -0,0 +1,770 @@
+/*
+ * Copyright 2020-2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package nodes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/status"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	"github.com/golang/protobuf/proto"
+	avimodels "github.com/vmware/alb-sdk/go/models"
+	akov1alpha1 "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/apis/ako/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	svcapiv1alpha1 "sigs.k8s.io/service-apis/apis/v1alpha1"
+)
+
+// Gateway listeners either select the Services labelled with the gateway name and namespace, or
+// select HTTPRoutes/TCPRoutes through the kind of the listener routes.
+//
+// The HTTP and HTTPS listeners that select HTTPRoutes are translated into a dedicated L7 parent VS
+// for the Gateway, separate from the L4 VS that carries the TCP/UDP listeners. Rules of HTTPRoutes
+// selected by plain HTTP listeners are attached as http policies to the parent VS, while each HTTPS
+// listener gets its own SNI child VS carrying the listener certificate and the rules of the
+// HTTPRoutes selected by it. Each rule becomes a poolgroup with one pool per forwardTo backend,
+// weighted as per the rule.
+//
+// The TCP listeners that select TCPRoutes are part of the L4 VS, where the listener port is bound
+// to the backend of the selected TCPRoutes.
+
+// IsSvcApiRouteListener checks if the listener selects HTTPRoutes/TCPRoutes instead of labelled Services.
+func IsSvcApiRouteListener(listener svcapiv1alpha1.Listener) bool {
+	return listener.Routes.Kind == lib.HTTPRoute || listener.Routes.Kind == lib.TCPRoute
+}
+
+func isSvcApiHTTPListener(listener svcapiv1alpha1.Listener) bool {
+	return listener.Routes.Kind == lib.HTTPRoute &&
+		(listener.Protocol == svcapiv1alpha1.HTTPProtocolType || listener.Protocol == svcapiv1alpha1.HTTPSProtocolType)
+}
+
+func hasSvcApiHTTPListeners(gw *svcapiv1alpha1.Gateway) bool {
+	for _, listener := range gw.Spec.Listeners {
+		if isSvcApiHTTPListener(listener) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSvcApiTCPListener(listener svcapiv1alpha1.Listener) bool {
+	return listener.Routes.Kind == lib.TCPRoute && listener.Protocol == svcapiv1alpha1.TCPProtocolType
+}
+
+// BuildSvcApiL7Graph builds the L7 VS for the HTTP/HTTPS listeners of the Gateway namespace/gwName.
+// The model is left empty if the gateway has no such listeners.
+func (o *AviObjectGraph) BuildSvcApiL7Graph(namespace, gwName, key string) {
+	o.Lock.Lock()
+	defer o.Lock.Unlock()
+
+	gw, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().Gateways(namespace).Get(gwName)
+	if err != nil {
+		utils.AviLog.Infof("key: %s, msg: unable to find gateway %s/%s: %v", key, namespace, gwName, err)
+		return
+	}
+
+	var httpListeners []svcapiv1alpha1.Listener
+	for _, listener := range gw.Spec.Listeners {
+		if isSvcApiHTTPListener(listener) {
+			httpListeners = append(httpListeners, listener)
+		}
+	}
+	if len(httpListeners) == 0 {
+		return
+	}
+
+	vsName := lib.GetSvcApiL7VSName(gwName, namespace)
+	vsNode := &AviVsNode{
+		Name:               vsName,
+		Tenant:             lib.GetTenant(),
+		ServiceEngineGroup: lib.GetSEGName(),
+		EnableRhi:          proto.Bool(lib.GetEnableRHI()),
+		NetworkProfile:     utils.DEFAULT_TCP_NW_PROFILE,
+		ApplicationProfile: utils.DEFAULT_L7_APP_PROFILE,
+		SNIParent:          true,
+		ServiceMetadata: lib.ServiceMetadataObj{
+			Gateway: namespace + "/" + gwName,
+		},
+		AviMarkers: lib.PopulateAdvL4VSNodeMarkers(namespace, gwName),
+	}
+
+	var vrfcontext string
+	if lib.GetT1LRPath() == "" {
+		vrfcontext = lib.GetVrf()
+		vsNode.VrfContext = vrfcontext
+	}
+
+	vsVipNode := &AviVSVIPNode{
+		Name:        lib.GetVsVipName(vsName),
+		Tenant:      lib.GetTenant(),
+		VrfContext:  vrfcontext,
+		VipNetworks: lib.GetVipNetworkList(),
+	}
+	if lib.GetT1LRPath() != "" {
+		vsVipNode.T1Lr = lib.GetT1LRPath()
+	}
+	if vsNode.EnableRhi != nil && *vsNode.EnableRhi {
+		vsVipNode.BGPPeerLabels = lib.GetGlobalBgpPeerLabels()
+	}
+
+	infraSetting, err := getL4InfraSetting(key, nil, &gw.Spec.GatewayClassName)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: Error while fetching infrasetting for Gateway %s", key, err.Error())
+		return
+	}
+	buildWithInfraSetting(key, vsNode, vsVipNode, infraSetting)
+
+	// The static address of the gateway is used by the L4 VS if the gateway has L4 listeners.
+	if len(gw.Spec.Addresses) > 0 && gw.Spec.Addresses[0].Type == svcapiv1alpha1.IPAddressType &&
+		len(parseSvcApiGatewayForListeners(gw, key)) == 0 {
+		vsVipNode.IPAddress = gw.Spec.Addresses[0].Value
+	}
+	vsNode.VSVIPRefs = append(vsNode.VSVIPRefs, vsVipNode)
+
+	for _, listener := range httpListeners {
+		port := int32(listener.Port)
+		routes := getSvcApiHTTPRoutes(gw, listener, key)
+		var listenerHosts []string
+		for _, route := range routes {
+			if hosts, ok := getSvcApiRouteHosts(listener, route.Spec.Hostnames); ok {
+				for _, host := range hosts {
+					if !utils.HasElem(listenerHosts, host) {
+						listenerHosts = append(listenerHosts, host)
+					}
+				}
+			}
+		}
+		if listener.Hostname != nil && string(*listener.Hostname) != "" && string(*listener.Hostname) != "*" {
+			listenerHosts = []string{string(*listener.Hostname)}
+		}
+		for _, host := range listenerHosts {
+			if !strings.Contains(host, "*") && !utils.HasElem(vsVipNode.FQDNs, host) {
+				vsVipNode.FQDNs = append(vsVipNode.FQDNs, host)
+			}
+		}
+
+		if listener.Protocol == svcapiv1alpha1.HTTPProtocolType {
+			addSvcApiListener(vsNode, port, false)
+			o.buildSvcApiHTTPRoutes(vsNode, gwName, listener, routes, port, infraSetting, key)
+			continue
+		}
+
+		if listener.TLS == nil || listener.TLS.CertificateRef.Name == "" {
+			utils.AviLog.Warnf("key: %s, msg: certificateRef not specified for HTTPS listener on port %d of gateway %s/%s, skipping listener", key, port, namespace, gwName)
+			continue
+		}
+		if listener.TLS.Mode == svcapiv1alpha1.TLSModePassthrough {
+			utils.AviLog.Warnf("key: %s, msg: TLS passthrough on port %d of gateway %s/%s is not supported, skipping listener", key, port, namespace, gwName)
+			continue
+		}
+		if len(listenerHosts) == 0 {
+			utils.AviLog.Warnf("key: %s, msg: no hostnames found for HTTPS listener on port %d of gateway %s/%s, skipping listener", key, port, namespace, gwName)
+			continue
+		}
+		addSvcApiListener(vsNode, port, true)
+		sniNode := &AviVsNode{
+			Name:               lib.GetSvcApiSniNodeName(vsName, port),
+			VHParentName:       vsName,
+			Tenant:             lib.GetTenant(),
+			IsSNIChild:         true,
+			ServiceEngineGroup: vsNode.ServiceEngineGroup,
+			VrfContext:         vrfcontext,
+			VHDomainNames:      listenerHosts,
+			ServiceMetadata: lib.ServiceMetadataObj{
+				Namespace: namespace,
+				HostNames: listenerHosts,
+			},
+			AviMarkers: lib.PopulateAdvL4VSNodeMarkers(namespace, gwName),
+		}
+		if !buildSvcApiListenerCert(sniNode, namespace, listener.TLS.CertificateRef, key) {
+			continue
+		}
+		o.buildSvcApiHTTPRoutes(sniNode, gwName, listener, routes, 0, infraSetting, key)
+		vsNode.SniNodes = append(vsNode.SniNodes, sniNode)
+	}
+
+	o.AddModelNode(vsNode)
+	utils.AviLog.Infof("key: %s, msg: computed services API L7 VS: %s", key, utils.Stringify(vsNode))
+}
+
+func addSvcApiListener(vsNode *AviVsNode, port int32, enableSSL bool) {
+	for _, pp := range vsNode.PortProto {
+		if pp.Port == port {
+			return
+		}
+	}
+	vsNode.PortProto = append(vsNode.PortProto, AviPortHostProtocol{Port: port, Protocol: utils.HTTP, EnableSSL: enableSSL})
+}
+
+func buildSvcApiListenerCert(sniNode *AviVsNode, namespace string, certRef svcapiv1alpha1.LocalObjectReference, key string) bool {
+	if certRef.Kind != "" && certRef.Kind != utils.Secret {
+		utils.AviLog.Warnf("key: %s, msg: certificateRef of kind %s is not supported for %s", key, certRef.Kind, sniNode.Name)
+		return false
+	}
+	secretObj, err := utils.GetInformers().SecretInformer.Lister().Secrets(namespace).Get(certRef.Name)
+	if err != nil || secretObj == nil {
+		utils.AviLog.Warnf("key: %s, msg: secret %s/%s for %s not found: %v", key, namespace, certRef.Name, sniNode.Name, err)
+		return false
+	}
+	cert, certFound := secretObj.Data[utils.K8S_TLS_SECRET_CERT]
+	tlsKey, keyFound := secretObj.Data[utils.K8S_TLS_SECRET_KEY]
+	if !certFound || !keyFound {
+		utils.AviLog.Warnf("key: %s, msg: certificate or key not found in secret %s/%s", key, namespace, certRef.Name)
+		return false
+	}
+	certNode := &AviTLSKeyCertNode{
+		Name:       sniNode.Name,
+		Tenant:     lib.GetTenant(),
+		Type:       lib.CertTypeVS,
+		Cert:       cert,
+		Key:        tlsKey,
+		AviMarkers: sniNode.AviMarkers,
+	}
+	sniNode.SSLKeyCertRefs = append(sniNode.SSLKeyCertRefs, certNode)
+	return true
+}
+
+// svcApiListenerSelectsRoute checks if the route is selected by the listener, as per the route
+// namespaces and labels selected by the listener, and the gateways allowed by the route.
+func svcApiListenerSelectsRoute(gw *svcapiv1alpha1.Gateway, listener svcapiv1alpha1.Listener, routeMeta metav1.ObjectMeta, routeGateways svcapiv1alpha1.RouteGateways, key string) bool {
+	if lib.IsNamespaceBlocked(routeMeta.Namespace) || !utils.CheckIfNamespaceAccepted(routeMeta.Namespace) {
+		return false
+	}
+
+	from := svcapiv1alpha1.RouteSelectSame
+	if listener.Routes.Namespaces != nil && listener.Routes.Namespaces.From != "" {
+		from = listener.Routes.Namespaces.From
+	}
+	switch from {
+	case svcapiv1alpha1.RouteSelectSame:
+		if routeMeta.Namespace != gw.Namespace {
+			return false
+		}
+	case svcapiv1alpha1.RouteSelectSelector:
+		nsSelector, err := metav1.LabelSelectorAsSelector(&listener.Routes.Namespaces.Selector)
+		if err != nil || utils.GetInformers().NSInformer == nil {
+			utils.AviLog.Warnf("key: %s, msg: unable to evaluate the route namespace selector of gateway %s/%s: %v", key, gw.Namespace, gw.Name, err)
+			return false
+		}
+		nsObj, err := utils.GetInformers().NSInformer.Lister().Get(routeMeta.Namespace)
+		if err != nil || !nsSelector.Matches(labels.Set(nsObj.Labels)) {
+			return false
+		}
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&listener.Routes.Selector)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: invalid route selector in gateway %s/%s: %v", key, gw.Namespace, gw.Name, err)
+		return false
+	}
+	if !selector.Matches(labels.Set(routeMeta.Labels)) {
+		return false
+	}
+
+	switch routeGateways.Allow {
+	case svcapiv1alpha1.GatewayAllowAll:
+		return true
+	case svcapiv1alpha1.GatewayAllowFromList:
+		for _, gwRef := range routeGateways.GatewayRefs {
+			if gwRef.Name == gw.Name && gwRef.Namespace == gw.Namespace {
+				return true
+			}
+		}
+		return false
+	default:
+		return routeMeta.Namespace == gw.Namespace
+	}
+}
+
+// getSvcApiHTTPRoutes returns the HTTPRoutes selected by the listener, sorted by namespace/name.
+func getSvcApiHTTPRoutes(gw *svcapiv1alpha1.Gateway, listener svcapiv1alpha1.Listener, key string) []*svcapiv1alpha1.HTTPRoute {
+	var routes []*svcapiv1alpha1.HTTPRoute
+	routeList, err := lib.AKOControlConfig().SvcAPIInformers().HTTPRouteInformer.Lister().List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: unable to list httproutes: %v", key, err)
+		return routes
+	}
+	for _, route := range routeList {
+		if svcApiListenerSelectsRoute(gw, listener, route.ObjectMeta, route.Spec.Gateways, key) {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Namespace+"/"+routes[i].Name < routes[j].Namespace+"/"+routes[j].Name
+	})
+	return routes
+}
+
+// getSvcApiTCPRoutes returns the TCPRoutes selected by the listener, sorted by namespace/name.
+func getSvcApiTCPRoutes(gw *svcapiv1alpha1.Gateway, listener svcapiv1alpha1.Listener, key string) []*svcapiv1alpha1.TCPRoute {
+	var routes []*svcapiv1alpha1.TCPRoute
+	routeList, err := lib.AKOControlConfig().SvcAPIInformers().TCPRouteInformer.Lister().List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: unable to list tcproutes: %v", key, err)
+		return routes
+	}
+	for _, route := range routeList {
+		if svcApiListenerSelectsRoute(gw, listener, route.ObjectMeta, route.Spec.Gateways, key) {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Namespace+"/"+routes[i].Name < routes[j].Namespace+"/"+routes[j].Name
+	})
+	return routes
+}
+
+// getSvcApiRouteHosts returns the hostnames of the route served by the listener. A nil list is
+// returned if all hosts are served, and false if the route hostnames do not match the listener.
+func getSvcApiRouteHosts(listener svcapiv1alpha1.Listener, routeHostnames []svcapiv1alpha1.Hostname) ([]string, bool) {
+	var listenerHost string
+	if listener.Hostname != nil && string(*listener.Hostname) != "*" {
+		listenerHost = string(*listener.Hostname)
+	}
+	if len(routeHostnames) == 0 {
+		if listenerHost == "" {
+			return nil, true
+		}
+		return []string{listenerHost}, true
+	}
+
+	var hosts []string
+	for _, hostname := range routeHostnames {
+		host := string(hostname)
+		if host == "*" {
+			host = listenerHost
+		} else if listenerHost != "" {
+			if !hostMatches(listenerHost, host) {
+				continue
+			}
+			// A specific listener hostname narrows down a wildcard route hostname.
+			if strings.HasPrefix(host, "*") && !strings.HasPrefix(listenerHost, "*") {
+				host = listenerHost
+			}
+		}
+		if host == "" {
+			return nil, true
+		}
+		if !utils.HasElem(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, len(hosts) > 0
+}
+
+// buildSvcApiHTTPRoutes attaches the rules of the HTTPRoutes selected by the listener to the
+// target node, which is either the parent VS or an SNI child.
+func (o *AviObjectGraph) buildSvcApiHTTPRoutes(targetNode *AviVsNode, gwName string, listener svcapiv1alpha1.Listener, routes []*svcapiv1alpha1.HTTPRoute, port int32, infraSetting *akov1alpha1.AviInfraSetting, key string) {
+	for _, route := range routes {
+		hosts, ok := getSvcApiRouteHosts(listener, route.Spec.Hostnames)
+		if !ok {
+			utils.AviLog.Debugf("key: %s, msg: hostnames of httproute %s/%s do not match the listener on port %d", key, route.Namespace, route.Name, listener.Port)
+			continue
+		}
+		policyName := lib.GetSvcApiHTTPPolicyName(targetNode.Name, route.Namespace, route.Name)
+		var policyNode *AviHttpPolicySetNode
+		for _, policy := range targetNode.HttpPolicyRefs {
+			if policy.Name == policyName {
+				policyNode = policy
+			}
+		}
+		if policyNode == nil {
+			policyNode = &AviHttpPolicySetNode{
+				Name:       policyName,
+				Tenant:     lib.GetTenant(),
+				AviMarkers: lib.PopulateSvcApiHTTPRouteMarkers(route.Namespace, route.Name),
+			}
+			targetNode.HttpPolicyRefs = append(targetNode.HttpPolicyRefs, policyNode)
+		}
+		hostHeaders := getHostHeaderMatches(hosts)
+
+		for ruleIdx := range route.Spec.Rules {
+			rule := &route.Spec.Rules[ruleIdx]
+			pgNode := o.buildSvcApiRulePG(targetNode, gwName, route, ruleIdx, infraSetting, key)
+			if pgNode == nil {
+				continue
+			}
+			requestHeaders := buildSvcApiHeaderActions(rule.Filters, key)
+
+			matches := rule.Matches
+			if len(matches) == 0 {
+				matches = []svcapiv1alpha1.HTTPRouteMatch{{}}
+			}
+			for matchIdx, match := range matches {
+				hppMap, ok := buildSvcApiMatch(match, key)
+				if !ok {
+					utils.AviLog.Warnf("key: %s, msg: skipping match %d of rule %d of httproute %s/%s", key, matchIdx, ruleIdx, route.Namespace, route.Name)
+					continue
+				}
+				hppMap.Port = uint32(port)
+				hppMap.IngName = route.Name
+				hppMap.PoolGroup = pgNode.Name
+				hppMap.RequestHeaders = requestHeaders
+				for hostIdx := range hostHeaders {
+					hostHppMap := hppMap
+					hostHppMap.HostHeader = hostHeaders[hostIdx]
+					hostHppMap.Name = fmt.Sprintf("%s-%d-%d-%d-%d", policyName, port, ruleIdx, matchIdx, hostIdx)
+					hostHppMap.CalculateCheckSum()
+					policyNode.HppMap = append(policyNode.HppMap, hostHppMap)
+				}
+			}
+		}
+	}
+}
+
+// buildSvcApiMatch translates an HTTPRoute match. Regular expression and implementation specific
+// matches cannot be expressed in the policy rules, and such matches are not translated.
+func buildSvcApiMatch(match svcapiv1alpha1.HTTPRouteMatch, key string) (AviHostPathPortPoolPG, bool) {
+	hppMap := AviHostPathPortPoolPG{
+		Path:          []string{"/"},
+		MatchCriteria: "BEGINS_WITH",
+	}
+	if match.ExtensionRef != nil {
+		utils.AviLog.Warnf("key: %s, msg: extensionRef in httproute match is not supported", key)
+		return hppMap, false
+	}
+	if match.Path.Value != "" {
+		switch match.Path.Type {
+		case "", svcapiv1alpha1.PathMatchPrefix:
+			hppMap.Path = []string{match.Path.Value}
+		case svcapiv1alpha1.PathMatchExact:
+			hppMap.Path = []string{match.Path.Value}
+			hppMap.MatchCriteria = "EQUALS"
+		default:
+			utils.AviLog.Warnf("key: %s, msg: path match of type %s is not supported", key, match.Path.Type)
+			return hppMap, false
+		}
+	}
+
+	if match.Headers != nil {
+		if match.Headers.Type != "" && match.Headers.Type != svcapiv1alpha1.HeaderMatchExact {
+			utils.AviLog.Warnf("key: %s, msg: header match of type %s is not supported", key, match.Headers.Type)
+			return hppMap, false
+		}
+		headerNames := make([]string, 0, len(match.Headers.Values))
+		for name := range match.Headers.Values {
+			headerNames = append(headerNames, name)
+		}
+		sort.Strings(headerNames)
+		for _, name := range headerNames {
+			hppMap.Headers = append(hppMap.Headers, AviHTTPHeaderMatch{
+				Name:          name,
+				MatchCriteria: "HDR_EQUALS",
+				Values:        []string{match.Headers.Values[name]},
+			})
+		}
+	}
+	return hppMap, true
+}
+
+// buildSvcApiHeaderActions translates the RequestHeaderModifier filters of a rule.
+func buildSvcApiHeaderActions(filters []svcapiv1alpha1.HTTPRouteFilter, key string) []AviHTTPHeaderAction {
+	var headerActions []AviHTTPHeaderAction
+	for _, filter := range filters {
+		if filter.Type != svcapiv1alpha1.HTTPRouteFilterRequestHeaderModifier || filter.RequestHeaderModifier == nil {
+			utils.AviLog.Warnf("key: %s, msg: httproute filter of type %s is not supported", key, filter.Type)
+			continue
+		}
+		headerNames := make([]string, 0, len(filter.RequestHeaderModifier.Add))
+		for name := range filter.RequestHeaderModifier.Add {
+			headerNames = append(headerNames, name)
+		}
+		sort.Strings(headerNames)
+		for _, name := range headerNames {
+			headerActions = append(headerActions, AviHTTPHeaderAction{
+				Action: "HTTP_ADD_HDR",
+				Name:   name,
+				Value:  filter.RequestHeaderModifier.Add[name],
+			})
+		}
+		for _, name := range filter.RequestHeaderModifier.Remove {
+			headerActions = append(headerActions, AviHTTPHeaderAction{
+				Action: "HTTP_REMOVE_HDR",
+				Name:   name,
+			})
+		}
+	}
+	return headerActions
+}
+
+// buildSvcApiRulePG builds the poolgroup for the forwardTo backends of a rule, and the pools for each backend.
+func (o *AviObjectGraph) buildSvcApiRulePG(targetNode *AviVsNode, gwName string, route *svcapiv1alpha1.HTTPRoute, ruleIdx int, infraSetting *akov1alpha1.AviInfraSetting, key string) *AviPoolGroupNode {
+	pgName := lib.GetSvcApiPGName(targetNode.Name, route.Namespace, route.Name, ruleIdx)
+	for _, pg := range targetNode.PoolGroupRefs {
+		if pg.Name == pgName {
+			return pg
+		}
+	}
+
+	rule := route.Spec.Rules[ruleIdx]
+	if len(rule.ForwardTo) == 0 {
+		utils.AviLog.Warnf("key: %s, msg: rule %d of httproute %s/%s has no forwardTo backends", key, ruleIdx, route.Namespace, route.Name)
+		return nil
+	}
+	pgNode := &AviPoolGroupNode{
+		Name:       pgName,
+		Tenant:     lib.GetTenant(),
+		AviMarkers: lib.PopulateSvcApiHTTPRouteMarkers(route.Namespace, route.Name),
+	}
+
+	// If no weights are specified, the traffic is evenly distributed across the backends.
+	weighted := false
+	for _, forwardTo := range rule.ForwardTo {
+		if forwardTo.Weight > 0 {
+			weighted = true
+		}
+	}
+
+	for _, forwardTo := range rule.ForwardTo {
+		if forwardTo.ServiceName == nil || *forwardTo.ServiceName == "" {
+			utils.AviLog.Warnf("key: %s, msg: only service backends are supported in httproute %s/%s", key, route.Namespace, route.Name)
+			continue
+		}
+		if len(forwardTo.Filters) > 0 {
+			utils.AviLog.Warnf("key: %s, msg: forwardTo filters in httproute %s/%s are not supported", key, route.Namespace, route.Name)
+		}
+		ratio := forwardTo.Weight
+		if !weighted {
+			ratio = 1
+		}
+		if ratio == 0 {
+			continue
+		}
+
+		svcName, port := *forwardTo.ServiceName, int32(forwardTo.Port)
+		poolName := lib.GetSvcApiPoolName(targetNode.Name, route.Namespace, svcName, port)
+		var poolNode *AviPoolNode
+		for _, pool := range targetNode.PoolRefs {
+			if pool.Name == poolName {
+				poolNode = pool
+			}
+		}
+		if poolNode == nil {
+			poolNode = buildSvcApiPool(poolName, route.Namespace, svcName, port, "", key)
+			if poolNode == nil {
+				continue
+			}
+			poolNode.AviMarkers = lib.PopulateSvcApiHTTPRoutePoolNodeMarkers(route.Namespace, svcName, route.Name, int(port))
+			buildPoolWithInfraSetting(key, poolNode, infraSetting)
+			targetNode.PoolRefs = append(targetNode.PoolRefs, poolNode)
+		}
+		poolRef := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
+		pgNode.Members = append(pgNode.Members, &avimodels.PoolGroupMember{PoolRef: &poolRef, Ratio: &ratio})
+	}
+
+	if len(pgNode.Members) == 0 {
+		return nil
+	}
+	targetNode.PoolGroupRefs = append(targetNode.PoolGroupRefs, pgNode)
+	return pgNode
+}
+
+// buildSvcApiPool builds a pool for the port of a route backend service.
+func buildSvcApiPool(poolName, svcNamespace, svcName string, port int32, protocol, key string) *AviPoolNode {
+	svcObj, err := utils.GetInformers().ServiceInformer.Lister().Services(svcNamespace).Get(svcName)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: backend service %s/%s not found: %v", key, svcNamespace, svcName, err)
+		return nil
+	}
+
+	var svcPort *corev1.ServicePort
+	for i := range svcObj.Spec.Ports {
+		if svcObj.Spec.Ports[i].Port == port {
+			svcPort = &svcObj.Spec.Ports[i]
+		}
+	}
+	if svcPort == nil {
+		utils.AviLog.Warnf("key: %s, msg: port %d not found in backend service %s/%s", key, port, svcNamespace, svcName)
+		return nil
+	}
+
+	poolNode := &AviPoolNode{
+		Name:       poolName,
+		Tenant:     lib.GetTenant(),
+		Protocol:   protocol,
+		PortName:   svcPort.Name,
+		Port:       svcPort.Port,
+		TargetPort: svcPort.TargetPort,
+		ServiceMetadata: lib.ServiceMetadataObj{
+			Namespace: svcNamespace,
+		},
+		VrfContext: lib.GetVrf(),
+	}
+	if svcPort.TargetPort.Type == intstr.Int && svcPort.TargetPort.IntValue() == 0 {
+		poolNode.TargetPort = intstr.FromInt(int(svcPort.Port))
+	}
+	poolNode.NetworkPlacementSettings, _ = lib.GetNodeNetworkMap()
+	if lib.GetT1LRPath() != "" {
+		poolNode.T1Lr = lib.GetT1LRPath()
+		poolNode.VrfContext = ""
+	}
+
+	serviceType := lib.GetServiceType()
+	if serviceType == lib.NodePortLocal {
+		if servers := PopulateServersForNPL(poolNode, svcNamespace, svcName, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	} else if serviceType == lib.NodePort {
+		if servers := PopulateServersForNodePort(poolNode, svcNamespace, svcName, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	} else {
+		if servers := PopulateServers(poolNode, svcNamespace, svcName, false, key); servers != nil {
+			poolNode.Servers = servers
+		}
+	}
+	if lib.IsIstioEnabled() {
+		poolNode.UpdatePoolNodeForIstio()
+	}
+	return poolNode
+}
+
+// buildSvcApiTCPRoutePools binds the TCP listeners of the gateway that select TCPRoutes to the
+// backend of the selected TCPRoutes. Like the listeners that select labelled Services, a listener
+// is backed by a single service, and any other backends are ignored.
+func (o *AviObjectGraph) buildSvcApiTCPRoutePools(vsNode *AviVsNode, gw *svcapiv1alpha1.Gateway, infraSetting *akov1alpha1.AviInfraSetting, key string) []AviHostPathPortPoolPG {
+	var portPoolSet []AviHostPathPortPoolPG
+	for _, listener := range gw.Spec.Listeners {
+		if !isSvcApiTCPListener(listener) {
+			continue
+		}
+		var backend *svcapiv1alpha1.RouteForwardTo
+		var backendNamespace string
+		for _, route := range getSvcApiTCPRoutes(gw, listener, key) {
+			for _, rule := range route.Spec.Rules {
+				for i := range rule.ForwardTo {
+					if rule.ForwardTo[i].ServiceName == nil || *rule.ForwardTo[i].ServiceName == "" {
+						utils.AviLog.Warnf("key: %s, msg: only service backends are supported in tcproute %s/%s", key, route.Namespace, route.Name)
+						continue
+					}
+					if backend != nil {
+						utils.AviLog.Warnf("key: %s, msg: listener on port %d of gateway %s/%s already has a backend, ignoring backend %s of tcproute %s/%s",
+							key, listener.Port, gw.Namespace, gw.Name, *rule.ForwardTo[i].ServiceName, route.Namespace, route.Name)
+						continue
+					}
+					backend = &rule.ForwardTo[i]
+					backendNamespace = route.Namespace
+				}
+			}
+		}
+		if backend == nil {
+			continue
+		}
+
+		svcName, port := *backend.ServiceName, int32(listener.Port)
+		poolName := lib.GetSvcApiL4PoolName(svcName, backendNamespace, gw.Name, utils.TCP, port)
+		poolNode := buildSvcApiPool(poolName, backendNamespace, svcName, int32(backend.Port), utils.TCP, key)
+		if poolNode == nil {
+			continue
+		}
+		poolNode.AviMarkers = lib.PopulateSvcApiL4PoolNodeMarkers(gw.Namespace, svcName, gw.Name, utils.TCP, int(port))
+		buildPoolWithInfraSetting(key, poolNode, infraSetting)
+		vsNode.PoolRefs = append(vsNode.PoolRefs, poolNode)
+
+		poolRef := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
+		portPoolSet = append(portPoolSet, AviHostPathPortPoolPG{
+			Port:     uint32(port),
+			Pool:     poolRef,
+			Protocol: utils.TCP,
+		})
+		utils.AviLog.Infof("key: %s, msg: evaluated tcproute pool values :%v", key, utils.Stringify(poolNode))
+	}
+	return portPoolSet
+}
+
+// GetSvcApiGatewayRoutes returns the routes, as kind/namespace/name, selected by the listeners of
+// the gateway, along with the backend services of those routes, as namespace/name.
+func GetSvcApiGatewayRoutes(gw *svcapiv1alpha1.Gateway, key string) ([]string, []string) {
+	var routes, svcs []string
+	addSvc := func(namespace string, serviceName *string) {
+		if serviceName != nil && *serviceName != "" && !utils.HasElem(svcs, namespace+"/"+*serviceName) {
+			svcs = append(svcs, namespace+"/"+*serviceName)
+		}
+	}
+	for _, listener := range gw.Spec.Listeners {
+		if isSvcApiHTTPListener(listener) {
+			for _, route := range getSvcApiHTTPRoutes(gw, listener, key) {
+				if _, ok := getSvcApiRouteHosts(listener, route.Spec.Hostnames); !ok {
+					continue
+				}
+				routeKey := lib.HTTPRoute + "/" + route.Namespace + "/" + route.Name
+				if !utils.HasElem(routes, routeKey) {
+					routes = append(routes, routeKey)
+				}
+				for _, rule := range route.Spec.Rules {
+					for _, forwardTo := range rule.ForwardTo {
+						addSvc(route.Namespace, forwardTo.ServiceName)
+					}
+				}
+			}
+		} else if isSvcApiTCPListener(listener) {
+			for _, route := range getSvcApiTCPRoutes(gw, listener, key) {
+				routeKey := lib.TCPRoute + "/" + route.Namespace + "/" + route.Name
+				if !utils.HasElem(routes, routeKey) {
+					routes = append(routes, routeKey)
+				}
+				for _, rule := range route.Spec.Rules {
+					for _, forwardTo := range rule.ForwardTo {
+						addSvc(route.Namespace, forwardTo.ServiceName)
+					}
+				}
+			}
+		}
+	}
+	return routes, svcs
+}
+
+// updateSvcApiGatewayRoutes updates the route and backend service mappings of the gateway. The gateway
+// is removed from the status of the routes it no longer admits, while the admitted routes are reported
+// by the rest layer once the gateway virtualservice is synced. gw is nil if the gateway is deleted or invalid.
+func updateSvcApiGatewayRoutes(gwNSName string, gw *svcapiv1alpha1.Gateway, key string) {
+	var routes, svcs []string
+	if gw != nil {
+		routes, svcs = GetSvcApiGatewayRoutes(gw, key)
+	}
+	_, mappedRoutes := objects.SharedSvcApiRouteLister().GetGatewayToRoutes(gwNSName)
+	oldRoutes := make([]string, len(mappedRoutes))
+	copy(oldRoutes, mappedRoutes)
+	objects.SharedSvcApiRouteLister().UpdateGatewayRouteMappings(gwNSName, routes)
+	objects.SharedSvcApiRouteLister().UpdateGatewaySvcMappings(gwNSName, svcs)
+
+	var removedRoutes []string
+	for _, route := range oldRoutes {
+		if !utils.HasElem(routes, route) {
+			removedRoutes = append(removedRoutes, route)
+		}
+	}
+	if len(removedRoutes) == 0 {
+		return
+	}
+	statusOption := status.StatusOptions{
+		ObjType: lib.SvcApiRoute,
+		Op:      lib.DeleteStatus,
+		Key:     key,
+		Options: &status.UpdateOptions{
+			Key:             key,
+			ServiceMetadata: lib.ServiceMetadataObj{Gateway: gwNSName},
+			SvcApiRoutes:    removedRoutes,
+		},
+	}
+	status.PublishToStatusQueue(gwNSName, statusOption)
+}
@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"strings"
 
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
 
@@ -47,17 +48,15 @@ func (o *AviObjectGraph) BuildVRFGraph(key string, vrfName string) error {
 	sort.Strings(nodeKeys)
 
 	utils.AviLog.Debugf("key: %s, All Nodes %v", key, allNodes)
-	routeid := 1
 	for _, k := range nodeKeys {
 		node := allNodes[k].(*v1.Node)
-		nodeRoutes, err := o.addRouteForNode(node, vrfName, routeid)
+		nodeRoutes, err := o.addRouteForNode(node, vrfName)
 		if err != nil {
 			utils.AviLog.Errorf("key: %s, Error Adding vrf for node %s: %v", key, node.Name, err)
 			continue
 		}
 		if !findRoutePrefix(nodeRoutes, aviVrfNode.StaticRoutes, key) {
 			aviVrfNode.StaticRoutes = append(aviVrfNode.StaticRoutes, nodeRoutes...)
-			routeid += len(nodeRoutes)
 		}
 	}
 	aviVrfNode.CalculateCheckSum()
@@ -79,7 +78,7 @@ func findRoutePrefix(nodeRoutes, aviRoutes []*models.StaticRoute, key string) bo
 	return false
 }
 
-func (o *AviObjectGraph) addRouteForNode(node *v1.Node, vrfName string, routeid int) ([]*models.StaticRoute, error) {
+func (o *AviObjectGraph) addRouteForNode(node *v1.Node, vrfName string) ([]*models.StaticRoute, error) {
 	var nodeIP, nodeIP6 string
 	var nodeRoutes []*models.StaticRoute
 	ipFamily := lib.GetIPFamily()
@@ -93,18 +92,26 @@ func (o *AviObjectGraph) addRouteForNode(node *v1.Node, vrfName string, routeid
 			break
 		}
 	}
-	if ipFamily == v6Type {
+	if ipFamily == v6Type || lib.IsDualStack() {
 		if lib.GetCNIPlugin() == lib.CALICO_CNI {
 			if ip, ok := node.Annotations["projectcalico.org/IPv6Address"]; ok {
 				nodeIP6 = strings.Split(ip, "/")[0]
 			}
-			if nodeIP6 == "" {
+			if nodeIP6 == "" && ipFamily == v6Type {
 				utils.AviLog.Errorf("Error in fetching nodeIPv6 for %v", node.ObjectMeta.Name)
 				return nil, errors.New("nodeipv6 not found")
 			}
 			if ip, ok := node.Annotations["projectcalico.org/IPv4Address"]; ok {
 				nodeIP = strings.Split(ip, "/")[0]
 			}
+		} else if lib.IsDualStack() {
+			// the nodes of a dual-stack cluster have an InternalIP of each family
+			for _, addr := range nodeAddrs {
+				if addr.Type == "InternalIP" && !utils.IsV4(addr.Address) {
+					nodeIP6 = addr.Address
+					break
+				}
+			}
 		}
 	}
 	if nodeIP == "" {
@@ -131,14 +138,13 @@ func (o *AviObjectGraph) addRouteForNode(node *v1.Node, vrfName string, routeid
 			return nil, err
 		}
 
-		clusterName := lib.GetClusterName()
 		labels := lib.GetLabels()
 		prefixipType := v4Type
 		nextHopIP := nodeIP
 		nextHopIPType := v4Type
 		re := regexp.MustCompile(lib.IPCIDRRegex)
 		if !re.MatchString(podCIDR) {
-			if ipFamily == v6Type {
+			if ipFamily == v6Type || (lib.IsDualStack() && nodeIP6 != "") {
 				prefixipType = v6Type
 				nextHopIP = nodeIP6
 				nextHopIPType = v6Type
@@ -148,7 +154,12 @@ func (o *AviObjectGraph) addRouteForNode(node *v1.Node, vrfName string, routeid
 		}
 
 		mask := int32(m)
-		routeIDString := clusterName + "-" + strconv.Itoa(routeid)
+		routeIDString := lib.GetStaticRouteID(node.Name, podCIDR)
+		if legacyRouteID, ok := getLegacyStaticRouteID(vrfName, podCIDR); ok {
+			// The route created by an earlier release keeps its route id, so that it is not added again
+			// for the same prefix on upgrade.
+			routeIDString = legacyRouteID
+		}
 		nodeRoute := models.StaticRoute{
 			RouteID: &routeIDString,
 			Prefix: &models.IPAddrPrefix{
@@ -166,8 +177,21 @@ func (o *AviObjectGraph) addRouteForNode(node *v1.Node, vrfName string, routeid
 		}
 
 		nodeRoutes = append(nodeRoutes, &nodeRoute)
-		routeid++
 	}
 
 	return nodeRoutes, nil
 }
+
+// getLegacyStaticRouteID returns the legacy route id of the static route of the pod CIDR in the vrf, if any.
+func getLegacyStaticRouteID(vrfName, podCIDR string) (string, bool) {
+	vrfCache, found := avicache.SharedAviObjCache().VrfCache.AviCacheGet(vrfName)
+	if !found {
+		return "", false
+	}
+	vrfCacheObj, ok := vrfCache.(*avicache.AviVrfCache)
+	if !ok {
+		return "", false
+	}
+	routeID, ok := vrfCacheObj.LegacyStaticRoutes[podCIDR]
+	return routeID, ok
+}
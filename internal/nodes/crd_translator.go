@@ -17,9 +17,11 @@ package nodes
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/vmware/alb-sdk/go/models"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
@@ -63,6 +65,8 @@ func BuildL7HostRule(host, key string, vsNode AviVsEvhSniModel) {
 	vsHTTPPolicySets := []string{}
 	vsDatascripts := []string{}
 	var analyticsPolicy *models.AnalyticsPolicy
+	var hostRuleHTTPPolicy *AviHttpPolicySetNode
+	var hostRuleDataScripts []*AviHTTPDataScriptNode
 
 	// Get the existing VH domain names and then manipulate it based on the aliases in Hostrule CRD.
 	VHDomainNames := vsNode.GetVHDomainNames()
@@ -116,12 +120,27 @@ func BuildL7HostRule(host, key string, vsNode AviVsEvhSniModel) {
 			vsNode.SetHttpPolicyRefs([]*AviHttpPolicySetNode{})
 		}
 
+		if len(hostrule.Spec.VirtualHost.HTTPPolicy.Rules) > 0 {
+			hostRuleHTTPPolicy = buildHostRuleHTTPPolicySet(vsNode.GetName(), host, hostrule)
+		}
+
 		for _, script := range hostrule.Spec.VirtualHost.Datascripts {
 			if !utils.HasElem(vsDatascripts, fmt.Sprintf("/api/vsdatascriptset?name=%s", script)) {
 				vsDatascripts = append(vsDatascripts, fmt.Sprintf("/api/vsdatascriptset?name=%s", script))
 			}
 		}
 
+		for _, configMapName := range hostrule.Spec.VirtualHost.DatascriptConfigMaps {
+			dsNode := buildHostRuleDataScript(vsNode.GetName(), host, hostrule.Namespace, configMapName, key)
+			if dsNode == nil {
+				continue
+			}
+			hostRuleDataScripts = append(hostRuleDataScripts, dsNode)
+			if !utils.HasElem(vsDatascripts, fmt.Sprintf("/api/vsdatascriptset?name=%s", dsNode.Name)) {
+				vsDatascripts = append(vsDatascripts, fmt.Sprintf("/api/vsdatascriptset?name=%s", dsNode.Name))
+			}
+		}
+
 		if hostrule.Spec.VirtualHost.TCPSettings != nil {
 			if vsNode.IsSharedVS() || vsNode.IsDedicatedVS() {
 				portProtocols = []AviPortHostProtocol{}
@@ -182,11 +201,13 @@ func BuildL7HostRule(host, key string, vsNode AviVsEvhSniModel) {
 	vsNode.SetSSLKeyCertAviRef(vsSslKeyCertificates)
 	vsNode.SetWafPolicyRef(vsWafPolicy)
 	vsNode.SetHttpPolicySetRefs(vsHTTPPolicySets)
+	setHostRuleHTTPPolicySet(vsNode, hostRuleHTTPPolicy)
 	vsNode.SetAppProfileRef(vsAppProfile)
 	vsNode.SetAnalyticsProfileRef(vsAnalyticsProfile)
 	vsNode.SetErrorPageProfileRef(vsErrorPageProfile)
 	vsNode.SetSSLProfileRef(vsSslProfile)
 	vsNode.SetVsDatascriptRefs(vsDatascripts)
+	setHostRuleDataScripts(vsNode, hostRuleDataScripts)
 	vsNode.SetEnabled(vsEnabled)
 	vsNode.SetAnalyticsPolicy(analyticsPolicy)
 	vsNode.SetPortProtocols(portProtocols)
@@ -198,6 +219,131 @@ func BuildL7HostRule(host, key string, vsNode AviVsEvhSniModel) {
 	vsNode.SetServiceMetadata(serviceMetadataObj)
 }
 
+// buildHostRuleHTTPPolicySet builds the httppolicyset for the inline http policy rules of the hostrule.
+func buildHostRuleHTTPPolicySet(vsName, host string, hostrule *akov1alpha1.HostRule) *AviHttpPolicySetNode {
+	policyNode := &AviHttpPolicySetNode{
+		Name:       lib.GetHostRuleHTTPPolicySetName(vsName),
+		Tenant:     lib.GetTenant(),
+		AviMarkers: lib.PopulateHTTPPolicysetNodeMarkers(hostrule.Namespace, host, "", nil, nil),
+	}
+	for _, rule := range hostrule.Spec.VirtualHost.HTTPPolicy.Rules {
+		inlineRule := AviHTTPPolicyRule{
+			Name:             rule.Name,
+			Path:             rule.Path,
+			AllowedClientIPs: rule.AllowedClientIPs,
+			DeniedClientIPs:  rule.DeniedClientIPs,
+			RequestHeaders:   buildHostRuleHeaderActions(rule.RequestHeaders),
+			ResponseHeaders:  buildHostRuleHeaderActions(rule.ResponseHeaders),
+		}
+		if rule.Redirect != nil {
+			inlineRule.Redirect = &AviHTTPRedirect{
+				Protocol:   "HTTPS",
+				Host:       rule.Redirect.Host,
+				Path:       rule.Redirect.Path,
+				Port:       rule.Redirect.Port,
+				StatusCode: "HTTP_REDIRECT_STATUS_CODE_302",
+			}
+			if rule.Redirect.Protocol != "" {
+				inlineRule.Redirect.Protocol = strings.ToUpper(rule.Redirect.Protocol)
+			}
+			if rule.Redirect.StatusCode != 0 {
+				inlineRule.Redirect.StatusCode = fmt.Sprintf("HTTP_REDIRECT_STATUS_CODE_%d", rule.Redirect.StatusCode)
+			}
+		}
+		if rule.RewritePath != "" {
+			// The matched path prefix is replaced with the rewritten path, and the rest of the path is retained.
+			inlineRule.Rewrite = &AviHTTPRewrite{Path: rule.RewritePath, KeepPathSuffix: true}
+			for _, token := range strings.Split(rule.Path, "/") {
+				if token != "" {
+					inlineRule.Rewrite.PathSuffixIndex++
+				}
+			}
+		}
+		if rule.RateLimit != nil {
+			inlineRule.RateLimit = &AviHTTPRateLimit{
+				Count:     rule.RateLimit.Count,
+				Period:    rule.RateLimit.Period,
+				BurstSize: rule.RateLimit.BurstSize,
+			}
+		}
+		policyNode.InlineRules = append(policyNode.InlineRules, inlineRule)
+	}
+	return policyNode
+}
+
+func buildHostRuleHeaderActions(headers []akov1alpha1.HostRuleHTTPHeaderAction) []AviHTTPHeaderAction {
+	var headerActions []AviHTTPHeaderAction
+	for _, header := range headers {
+		action, _ := lib.GetHTTPHeaderAction(header.Action)
+		headerActions = append(headerActions, AviHTTPHeaderAction{Action: action, Name: header.Name, Value: header.Value})
+	}
+	return headerActions
+}
+
+// setHostRuleHTTPPolicySet replaces the httppolicyset of the inline http policy rules of the hostrule on the
+// virtualservice, and removes it if the hostrule has no inline rules anymore.
+func setHostRuleHTTPPolicySet(vsNode AviVsEvhSniModel, hostRuleHTTPPolicy *AviHttpPolicySetNode) {
+	policyName := lib.GetHostRuleHTTPPolicySetName(vsNode.GetName())
+	var httpPolicyRefs []*AviHttpPolicySetNode
+	for _, policy := range vsNode.GetHttpPolicyRefs() {
+		if policy.Name != policyName {
+			httpPolicyRefs = append(httpPolicyRefs, policy)
+		}
+	}
+	if hostRuleHTTPPolicy == nil && len(httpPolicyRefs) == len(vsNode.GetHttpPolicyRefs()) {
+		return
+	}
+	if hostRuleHTTPPolicy != nil {
+		httpPolicyRefs = append(httpPolicyRefs, hostRuleHTTPPolicy)
+	}
+	vsNode.SetHttpPolicyRefs(httpPolicyRefs)
+}
+
+// buildHostRuleDataScript builds the vsdatascriptset for a datascript ConfigMap of the hostrule, the keys of the
+// ConfigMap are the datascript events and the values are the corresponding Lua scripts.
+func buildHostRuleDataScript(vsName, host, namespace, configMapName, key string) *AviHTTPDataScriptNode {
+	if utils.GetInformers().DataScriptConfigMapInformer == nil {
+		utils.AviLog.Warnf("key: %s, msg: datascript ConfigMap informer is not initialized", key)
+		return nil
+	}
+	configMap, err := utils.GetInformers().DataScriptConfigMapInformer.Lister().ConfigMaps(namespace).Get(configMapName)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: datascript ConfigMap %s/%s not found: %v", key, namespace, configMapName, err)
+		return nil
+	}
+
+	evts := make([]string, 0, len(configMap.Data))
+	for evt := range configMap.Data {
+		evts = append(evts, evt)
+	}
+	sort.Strings(evts)
+	dsNode := &AviHTTPDataScriptNode{
+		Name:       lib.GetHostRuleDataScriptName(vsName, namespace, configMapName),
+		Tenant:     lib.GetTenant(),
+		AviMarkers: lib.PopulateHTTPPolicysetNodeMarkers(namespace, host, "", nil, nil),
+	}
+	for _, evt := range evts {
+		dsNode.Scripts = append(dsNode.Scripts, DataScript{Evt: evt, Script: configMap.Data[evt]})
+	}
+	return dsNode
+}
+
+// setHostRuleDataScripts replaces the vsdatascriptsets built from the datascript ConfigMaps of the hostrule,
+// while retaining the datascripts created by AKO for the virtualservice.
+func setHostRuleDataScripts(vsNode AviVsEvhSniModel, hostRuleDataScripts []*AviHTTPDataScriptNode) {
+	var httpDSrefs []*AviHTTPDataScriptNode
+	for _, ds := range vsNode.GetHTTPDSrefs() {
+		if len(ds.Scripts) == 0 {
+			httpDSrefs = append(httpDSrefs, ds)
+		}
+	}
+	if len(hostRuleDataScripts) == 0 && len(httpDSrefs) == len(vsNode.GetHTTPDSrefs()) {
+		return
+	}
+	httpDSrefs = append(httpDSrefs, hostRuleDataScripts...)
+	vsNode.SetHTTPDSrefs(httpDSrefs)
+}
+
 // BuildPoolHTTPRule notes
 // when we get an ingress update and we are building the corresponding pools of that ingress
 // we need to get all httprules which match ingress's host/path
@@ -319,6 +465,10 @@ func BuildPoolHTTPRule(host, poolPath, ingName, namespace, infraSettingName, key
 				pool.PkiProfileRef = pathPkiProfile
 				pool.PkiProfile = destinationCertNode
 				pool.HealthMonitors = pathHMs
+				if len(pathHMs) > 0 {
+					// The health monitors in the HTTPRule take precedence over the one from the readiness probe.
+					pool.ProbeHealthMonitor = nil
+				}
 				pool.ApplicationPersistence = persistenceProfile
 
 				// from this path, generate refs to this pool node
@@ -351,3 +501,300 @@ func BuildPoolHTTPRule(host, poolPath, ingName, namespace, infraSettingName, key
 	}
 
 }
+
+// BuildHTTPRuleMatches builds the pools and http policy rules for the matches of the httprule
+// target that corresponds to the host/path. The rules for the matches are placed right before
+// the rule of the path, so that the requests satisfying a match are switched to its backend.
+// The matches are rebuilt every time the path is built, the ones built earlier are removed first.
+// The backend services are looked up in the namespace of the Ingress/Route, like in BuildHTTPRuleBackends.
+func BuildHTTPRuleMatches(host, poolPath, ingName, namespace, key string, infraSetting *akov1alpha1.AviInfraSetting, vsNode AviVsEvhSniModel, httpPolName, hppMapName, pgName string, isIngr bool) {
+	RemoveHTTPRuleMatches(vsNode, httpPolName, hppMapName, pgName)
+
+	httpRulePath := getHTTPRulePath(host, poolPath, key)
+	if httpRulePath == nil || len(httpRulePath.Matches) == 0 {
+		return
+	}
+	matches := httpRulePath.Matches
+
+	var policyNode *AviHttpPolicySetNode
+	for _, policy := range vsNode.GetHttpPolicyRefs() {
+		if policy.Name == httpPolName {
+			policyNode = policy
+			break
+		}
+	}
+	if policyNode == nil {
+		return
+	}
+	pathIndex := -1
+	for i, hppMap := range policyNode.HppMap {
+		if hppMap.Name == hppMapName {
+			pathIndex = i
+			break
+		}
+	}
+	if pathIndex == -1 {
+		utils.AviLog.Warnf("key: %s, msg: http policy rule %s not found, not adding the httprule matches", key, hppMapName)
+		return
+	}
+	pathHppMap := policyNode.HppMap[pathIndex]
+
+	svcLister := objects.SharedSvcLister()
+	if !isIngr {
+		svcLister = objects.OshiftRouteSvcLister()
+	}
+	priorityLabel := host + poolPath
+	poolNodes := vsNode.GetPoolRefs()
+	var matchHppMaps []AviHostPathPortPoolPG
+	for i, match := range matches {
+		backend := IngressHostPathSvc{
+			ServiceName: match.Backend.ServiceName,
+			Path:        poolPath,
+			weight:      100,
+		}
+		backend.Port, backend.PortName, backend.TargetPort = getHTTPRuleBackendPort(namespace, match.Backend, key)
+		poolName := lib.GetHTTPRuleMatchName(pgName, i, lib.Pool)
+		poolNode := buildPoolNode(key, poolName, ingName, namespace, priorityLabel, host, infraSetting, backend.ServiceName, []string{host}, false, backend)
+		poolNodes = append(poolNodes, poolNode)
+		// The backend is not referred to by the Ingress/Route, the mapping ensures that the changes
+		// to the backend service and its endpoints are processed for this Ingress/Route as well.
+		svcLister.IngressMappings(namespace).UpdateIngressMappings(ingName, backend.ServiceName)
+
+		hppMap := AviHostPathPortPoolPG{
+			Name:          lib.GetHTTPRuleMatchName(hppMapName, i, lib.HPPMAP),
+			Host:          pathHppMap.Host,
+			Path:          pathHppMap.Path,
+			MatchCriteria: pathHppMap.MatchCriteria,
+			IngName:       ingName,
+			Pool:          poolNode.Name,
+		}
+		for _, header := range match.Headers {
+			hppMap.Headers = append(hppMap.Headers, AviHTTPHeaderMatch{
+				Name:          header.Name,
+				MatchCriteria: getHTTPRuleHeaderMatchCriteria(header.MatchType),
+				Values:        []string{header.Value},
+			})
+		}
+		if match.Cookie != nil {
+			hppMap.Cookie = &AviHTTPHeaderMatch{
+				Name:          match.Cookie.Name,
+				MatchCriteria: getHTTPRuleHeaderMatchCriteria(match.Cookie.MatchType),
+				Values:        []string{match.Cookie.Value},
+			}
+		}
+		for _, queryParam := range match.QueryParams {
+			hppMap.Query = append(hppMap.Query, queryParam.Name+"="+queryParam.Value)
+		}
+		for _, method := range match.Methods {
+			hppMap.Methods = append(hppMap.Methods, "HTTP_METHOD_"+strings.ToUpper(method))
+		}
+		hppMap.CalculateCheckSum()
+		matchHppMaps = append(matchHppMaps, hppMap)
+		utils.AviLog.Infof("key: %s, msg: added httprule match %s with pool %s for host %s, path %s", key, hppMap.Name, poolNode.Name, host, poolPath)
+	}
+	vsNode.SetPoolRefs(poolNodes)
+
+	hppMaps := make([]AviHostPathPortPoolPG, 0, len(policyNode.HppMap)+len(matchHppMaps))
+	hppMaps = append(hppMaps, policyNode.HppMap[:pathIndex]...)
+	hppMaps = append(hppMaps, matchHppMaps...)
+	hppMaps = append(hppMaps, policyNode.HppMap[pathIndex:]...)
+	policyNode.HppMap = hppMaps
+}
+
+// RemoveHTTPRuleMatches removes the pools and http policy rules built for the httprule matches of a path.
+func RemoveHTTPRuleMatches(vsNode AviVsEvhSniModel, httpPolName, hppMapName, pgName string) {
+	for _, policy := range vsNode.GetHttpPolicyRefs() {
+		if policy.Name != httpPolName {
+			continue
+		}
+		for i := 0; ; i++ {
+			matchHppMapName := lib.GetHTTPRuleMatchName(hppMapName, i, lib.HPPMAP)
+			found := false
+			for j, hppMap := range policy.HppMap {
+				if hppMap.Name == matchHppMapName {
+					policy.HppMap = append(policy.HppMap[:j], policy.HppMap[j+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				break
+			}
+		}
+	}
+
+	poolNodes := vsNode.GetPoolRefs()
+	for i := 0; ; i++ {
+		matchPoolName := lib.GetHTTPRuleMatchName(pgName, i, lib.Pool)
+		found := false
+		for j, pool := range poolNodes {
+			if pool.Name == matchPoolName {
+				poolNodes = append(poolNodes[:j], poolNodes[j+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	vsNode.SetPoolRefs(poolNodes)
+}
+
+// BuildHTTPRuleBackends builds the pools for the weighted backends of the httprule target that corresponds
+// to the host/path of an Ingress. The pools are added to the poolgroup of the path, or in case of the shared
+// VS where the poolgroup is built from the pools of the VS, the weight is set as the pool ratio. The backend
+// with the Service of the path sets the weight of the pool of the path. Since the weights are only set on the
+// poolgroup members, updating the weights updates the poolgroup alone. The backend services are looked up in
+// the namespace of the Ingress, as the services are mapped to the Ingress in its namespace.
+func BuildHTTPRuleBackends(host string, path IngressHostPathSvc, ingName, namespace, key string, infraSetting *akov1alpha1.AviInfraSetting, vsNode AviVsEvhSniModel, pgNode *AviPoolGroupNode, poolName, baseName string) {
+	RemoveHTTPRuleBackends(vsNode, pgNode, baseName)
+
+	httpRulePath := getHTTPRulePath(host, path.Path, key)
+	if httpRulePath == nil || len(httpRulePath.Backends) == 0 {
+		return
+	}
+
+	priorityLabel := host + path.Path
+	poolNodes := vsNode.GetPoolRefs()
+	// The pools of the backends are indexed contiguously, so that these can be removed without
+	// the httprule, the backend with the Service of the path does not get a pool of its own.
+	var poolIndex int
+	for _, backend := range httpRulePath.Backends {
+		weight := int32(100)
+		if backend.Weight != nil {
+			weight = *backend.Weight
+		}
+		if backend.ServiceName == path.ServiceName {
+			setPoolWeight(poolNodes, pgNode, poolName, weight)
+			continue
+		}
+		backendPath := IngressHostPathSvc{
+			ServiceName: backend.ServiceName,
+			Path:        path.Path,
+			weight:      weight,
+		}
+		backendPath.Port, backendPath.PortName, backendPath.TargetPort = getHTTPRuleBackendPort(namespace,
+			akov1alpha1.HTTPRuleBackend{ServiceName: backend.ServiceName, Port: backend.Port}, key)
+		backendPoolName := lib.GetHTTPRuleBackendName(baseName, poolIndex)
+		poolIndex++
+		poolNode := buildPoolNode(key, backendPoolName, ingName, namespace, priorityLabel, host, infraSetting, backend.ServiceName, []string{host}, false, backendPath)
+		poolNodes = append(poolNodes, poolNode)
+		if pgNode != nil {
+			poolRef := fmt.Sprintf("/api/pool?name=%s", poolNode.Name)
+			ratio := weight
+			pgNode.Members = append(pgNode.Members, &models.PoolGroupMember{PoolRef: &poolRef, Ratio: &ratio})
+		}
+		// The backend is not referred to by the Ingress, the mapping ensures that the changes
+		// to the backend service and its endpoints are processed for this Ingress as well.
+		objects.SharedSvcLister().IngressMappings(namespace).UpdateIngressMappings(ingName, backend.ServiceName)
+		utils.AviLog.Infof("key: %s, msg: added httprule backend pool %s with weight %d for host %s, path %s", key, poolNode.Name, weight, host, path.Path)
+	}
+	vsNode.SetPoolRefs(poolNodes)
+}
+
+// RemoveHTTPRuleBackends removes the pools built for the httprule weighted backends of a path.
+func RemoveHTTPRuleBackends(vsNode AviVsEvhSniModel, pgNode *AviPoolGroupNode, baseName string) {
+	poolNodes := vsNode.GetPoolRefs()
+	for i := 0; ; i++ {
+		backendPoolName := lib.GetHTTPRuleBackendName(baseName, i)
+		found := false
+		for j, pool := range poolNodes {
+			if pool.Name == backendPoolName {
+				poolNodes = append(poolNodes[:j], poolNodes[j+1:]...)
+				found = true
+				break
+			}
+		}
+		if pgNode != nil {
+			for j, member := range pgNode.Members {
+				if strings.TrimPrefix(*member.PoolRef, "/api/pool?name=") == backendPoolName {
+					pgNode.Members = append(pgNode.Members[:j], pgNode.Members[j+1:]...)
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	vsNode.SetPoolRefs(poolNodes)
+}
+
+func setPoolWeight(poolNodes []*AviPoolNode, pgNode *AviPoolGroupNode, poolName string, weight int32) {
+	for _, pool := range poolNodes {
+		if pool.Name == poolName {
+			pool.ServiceMetadata.PoolRatio = weight
+		}
+	}
+	if pgNode == nil {
+		return
+	}
+	for _, member := range pgNode.Members {
+		if strings.TrimPrefix(*member.PoolRef, "/api/pool?name=") == poolName {
+			ratio := weight
+			member.Ratio = &ratio
+		}
+	}
+}
+
+// getHTTPRulePath returns the accepted httprule target for the host/path.
+func getHTTPRulePath(host, poolPath, key string) *akov1alpha1.HTTPRulePaths {
+	found, pathRules := objects.SharedCRDLister().GetFqdnHTTPRulesMapping(host)
+	if !found {
+		return nil
+	}
+	target := poolPath
+	if target == "" {
+		// In case of openshift Route, the path could be empty, in that case, treat
+		// httprule target path / as that of empty path.
+		target = "/"
+	}
+	rule, ok := pathRules[target]
+	if !ok {
+		return nil
+	}
+	rrNSName := strings.Split(rule, "/")
+	httpRuleObj, err := lib.AKOControlConfig().CRDInformers().HTTPRuleInformer.Lister().HTTPRules(rrNSName[0]).Get(rrNSName[1])
+	if err != nil {
+		utils.AviLog.Debugf("key: %s, msg: httprule not found err: %+v", key, err)
+		return nil
+	} else if httpRuleObj.Status.Status == lib.StatusRejected {
+		return nil
+	}
+	for i, path := range httpRuleObj.Spec.Paths {
+		if path.Target == target {
+			return &httpRuleObj.Spec.Paths[i]
+		}
+	}
+	return nil
+}
+
+// getHTTPRuleBackendPort returns the port, port name and target port of the service port of a httprule
+// match backend. If no port is set in the backend, the service is expected to have a single port.
+func getHTTPRuleBackendPort(namespace string, backend akov1alpha1.HTTPRuleBackend, key string) (int32, string, intstr.IntOrString) {
+	svcObj, err := utils.GetInformers().ServiceInformer.Lister().Services(namespace).Get(backend.ServiceName)
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: error while fetching the httprule backend service %s: %v", key, backend.ServiceName, err)
+		return backend.Port, "", intstr.FromInt(int(backend.Port))
+	}
+	for _, svcPort := range svcObj.Spec.Ports {
+		if svcPort.Port == backend.Port || (backend.Port == 0 && len(svcObj.Spec.Ports) == 1) {
+			targetPort := svcPort.TargetPort
+			if targetPort.Type == intstr.Int && targetPort.IntValue() == 0 {
+				targetPort = intstr.FromInt(int(svcPort.Port))
+			}
+			return svcPort.Port, svcPort.Name, targetPort
+		}
+	}
+	utils.AviLog.Warnf("key: %s, msg: port %d not found in the httprule backend service %s", key, backend.Port, backend.ServiceName)
+	return backend.Port, "", intstr.FromInt(int(backend.Port))
+}
+
+func getHTTPRuleHeaderMatchCriteria(matchType string) string {
+	if matchType == lib.MatchTypeContains {
+		return "HDR_CONTAINS"
+	}
+	return "HDR_EQUALS"
+}
@@ -27,6 +27,8 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	svcapiv1alpha1 "sigs.k8s.io/service-apis/apis/v1alpha1"
 )
 
 func DequeueIngestion(key string, fullsync bool) {
@@ -68,6 +70,12 @@ func DequeueIngestion(key string, fullsync bool) {
 			PublishKeyToRestLayer(lib.IstioModel, key, sharedQueue)
 		}
 	}
+	if lib.IsIstioEnabled() && !lib.GetAdvancedL4() && lib.AKOControlConfig().IstioCRDInformers() != nil {
+		if isIstioObj := handleIstioObjects(objType, namespace, name, key, fullsync); isIstioObj {
+			return
+		}
+	}
+
 	schema, valid := ConfigDescriptor().GetByType(objType)
 	if valid {
 		// If it's an ingress related change, let's process that.
@@ -82,6 +90,14 @@ func DequeueIngestion(key string, fullsync bool) {
 		}
 	}
 
+	// The shard scheme of the AviInfraSetting is read before its ingresses and routes are synced, and stored only
+	// once they are synced, so that each of them is removed from the shared VS of the previous scheme.
+	var infraSettingShardScheme string
+	var infraSettingFound bool
+	if objType == lib.AviInfraSetting && (ingressFound || routeFound) {
+		infraSettingShardScheme, infraSettingFound = getInfraSettingShardScheme(name, key)
+	}
+
 	if objType == lib.HostRule &&
 		((utils.GetInformers().IngressInformer != nil && len(ingressNames) == 0) ||
 			(utils.GetInformers().RouteInformer != nil && len(routeNames) == 0)) {
@@ -177,7 +193,7 @@ func DequeueIngestion(key string, fullsync bool) {
 			}
 
 			// Do not handle service update if it belongs to unaccepted namespace
-			if svcObj.Spec.Type == utils.LoadBalancer && !lib.GetLayer7Only() && utils.CheckIfNamespaceAccepted(namespace) {
+			if svcObj.Spec.Type == utils.LoadBalancer && lib.IsAviLoadBalancerClass(svcObj) && !lib.GetLayer7Only() && utils.CheckIfNamespaceAccepted(namespace) {
 				// This endpoint update affects a LB service.
 				aviModelGraph := NewAviObjectGraph()
 				if sharedVipKey, ok := svcObj.Annotations[lib.SharedVipSvcLBAnnotation]; ok && sharedVipKey != "" {
@@ -201,10 +217,19 @@ func DequeueIngestion(key string, fullsync bool) {
 		handleIngress(key, fullsync, ingressNames)
 	}
 
+	if objType == lib.AviInfraSetting && (ingressFound || routeFound) {
+		if infraSettingFound {
+			objects.InfraSettingL7Lister().UpdateInfraSettingShardScheme(name, infraSettingShardScheme)
+		} else {
+			objects.InfraSettingL7Lister().RemoveInfraSettingMappings(name)
+		}
+	}
+
 	// handle the services APIs
 	if (lib.GetAdvancedL4() && objType == utils.L4LBService) ||
 		(lib.UseServicesAPI() && (objType == utils.Service || objType == utils.L4LBService)) ||
-		((lib.GetAdvancedL4() || lib.UseServicesAPI()) && (objType == lib.Gateway || objType == lib.GatewayClass || objType == utils.Endpoints || objType == lib.AviInfraSetting)) {
+		((lib.GetAdvancedL4() || lib.UseServicesAPI()) && (objType == lib.Gateway || objType == lib.GatewayClass || objType == utils.Endpoints || objType == lib.AviInfraSetting)) ||
+		(lib.UseServicesAPI() && (objType == lib.HTTPRoute || objType == lib.TCPRoute || objType == utils.Secret)) {
 		if !valid && objType == utils.L4LBService {
 			// Required for advl4 schemas.
 			schema, _ = ConfigDescriptor().GetByType(utils.Service)
@@ -234,6 +259,9 @@ func DequeueIngestion(key string, fullsync bool) {
 						PublishKeyToRestLayer(modelName, key, sharedQueue)
 					}
 				}
+				if lib.UseServicesAPI() {
+					handleSvcApiL7Gateway(namespace, gwName, key, fullsync)
+				}
 			}
 		}
 	}
@@ -607,6 +635,157 @@ func handleL4Service(key string, fullsync bool) {
 	}
 }
 
+// handleIstioObjects rebuilds the models of the Istio Gateways affected by the object. Returns true
+// if the object is an Istio object, which requires no further processing.
+func handleIstioObjects(objType, namespace, name, key string, fullsync bool) bool {
+	var gateways []string
+	isIstioObj := true
+	istioInformers := lib.AKOControlConfig().IstioCRDInformers()
+	objKey := namespace + "/" + name
+
+	switch objType {
+	case lib.IstioGateway:
+		gateways = append(gateways, objKey)
+	case lib.IstioVirtualService:
+		_, gateways = objects.SharedIstioLister().GetVSToGateways(objKey)
+		vs, err := istioInformers.VirtualServiceInformer.Lister().VirtualServices(namespace).Get(name)
+		if err == nil && utils.CheckIfNamespaceAccepted(namespace) {
+			newGateways := GetIstioGatewaysForVS(vs)
+			objects.SharedIstioLister().UpdateVSToGateways(objKey, newGateways)
+			objects.SharedIstioLister().UpdateVSSvcMappings(objKey, GetIstioServicesForVS(vs))
+			for _, gateway := range newGateways {
+				if !utils.HasElem(gateways, gateway) {
+					gateways = append(gateways, gateway)
+				}
+			}
+		} else {
+			utils.AviLog.Debugf("key: %s, msg: istio virtualservice not found or not accepted: %v", key, err)
+			objects.SharedIstioLister().DeleteVSToGateways(objKey)
+			objects.SharedIstioLister().DeleteVSSvcMappings(objKey)
+		}
+	case lib.IstioDestinationRule:
+		var svcs []string
+		if found, svc := objects.SharedIstioLister().GetDRToSvc(objKey); found {
+			svcs = append(svcs, svc)
+		}
+		dr, err := istioInformers.DestinationRuleInformer.Lister().DestinationRules(namespace).Get(name)
+		if err == nil {
+			svcNamespace, svcName := getIstioDestinationService(dr.Spec.Host, namespace)
+			svc := svcNamespace + "/" + svcName
+			objects.SharedIstioLister().UpdateDRToSvc(objKey, svc)
+			if !utils.HasElem(svcs, svc) {
+				svcs = append(svcs, svc)
+			}
+		} else {
+			objects.SharedIstioLister().DeleteDRToSvc(objKey)
+		}
+		gateways = getIstioGatewaysForSvcs(svcs)
+	case utils.Service, utils.L4LBService, utils.Endpoints:
+		isIstioObj = false
+		gateways = getIstioGatewaysForSvcs([]string{objKey})
+	case utils.Secret:
+		isIstioObj = false
+		gwObjs, err := istioInformers.GatewayInformer.Lister().Gateways(namespace).List(labels.Everything())
+		if err != nil {
+			break
+		}
+		for _, gwObj := range gwObjs {
+			for _, server := range gwObj.Spec.Servers {
+				if server != nil && server.Tls != nil && server.Tls.CredentialName == name &&
+					!utils.HasElem(gateways, namespace+"/"+gwObj.Name) {
+					gateways = append(gateways, namespace+"/"+gwObj.Name)
+				}
+			}
+		}
+	default:
+		return false
+	}
+
+	if len(gateways) > 0 {
+		utils.AviLog.Infof("key: %s, msg: istio gateways affected: %v", key, gateways)
+	}
+	for _, gateway := range gateways {
+		handleIstioGateway(gateway, key, fullsync)
+	}
+	return isIstioObj
+}
+
+func getIstioGatewaysForSvcs(svcs []string) []string {
+	var gateways []string
+	for _, svc := range svcs {
+		_, vsList := objects.SharedIstioLister().GetSvcToVSes(svc)
+		for _, vs := range vsList {
+			_, vsGateways := objects.SharedIstioLister().GetVSToGateways(vs)
+			for _, gateway := range vsGateways {
+				if !utils.HasElem(gateways, gateway) {
+					gateways = append(gateways, gateway)
+				}
+			}
+		}
+	}
+	return gateways
+}
+
+func handleIstioGateway(gatewayKey, key string, fullsync bool) {
+	sharedQueue := utils.SharedWorkQueue().GetQueueByName(utils.GraphLayer)
+	gwNSName := strings.Split(gatewayKey, "/")
+	namespace, gwName := gwNSName[0], gwNSName[1]
+	modelName := lib.GetModelName(lib.GetTenant(), lib.GetIstioGatewayVSName(gwName, namespace))
+
+	aviModelGraph := NewAviObjectGraph()
+	if !lib.IsNamespaceBlocked(namespace) && utils.CheckIfNamespaceAccepted(namespace) {
+		aviModelGraph.BuildIstioGatewayGraph(namespace, gwName, key)
+	}
+	if len(aviModelGraph.GetOrderedNodes()) == 0 {
+		if found, _ := objects.SharedAviGraphLister().Get(modelName); found {
+			objects.SharedAviGraphLister().Save(modelName, nil)
+			if !fullsync {
+				PublishKeyToRestLayer(modelName, key, sharedQueue)
+			}
+		}
+		return
+	}
+	ok := saveAviModel(modelName, aviModelGraph, key)
+	if ok && !fullsync {
+		PublishKeyToRestLayer(modelName, key, sharedQueue)
+	}
+}
+
+// handleSvcApiL7Gateway builds the L7 model for the HTTP listeners of the services API gateway,
+// and updates the routes admitted by the gateway.
+func handleSvcApiL7Gateway(namespace, gwName, key string, fullsync bool) {
+	sharedQueue := utils.SharedWorkQueue().GetQueueByName(utils.GraphLayer)
+	modelName := lib.GetModelName(lib.GetTenant(), lib.GetSvcApiL7VSName(gwName, namespace))
+
+	var gateway *svcapiv1alpha1.Gateway
+	gw, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().Gateways(namespace).Get(gwName)
+	if err == nil && gw.GetDeletionTimestamp() == nil &&
+		!lib.IsNamespaceBlocked(namespace) && utils.CheckIfNamespaceAccepted(namespace) &&
+		validateSvcApiGatewayForClass(key, gw) == nil {
+		gateway = gw
+	}
+
+	aviModelGraph := NewAviObjectGraph()
+	if gateway != nil {
+		aviModelGraph.BuildSvcApiL7Graph(namespace, gwName, key)
+	}
+	updateSvcApiGatewayRoutes(namespace+"/"+gwName, gateway, key)
+
+	if len(aviModelGraph.GetOrderedNodes()) == 0 {
+		if found, _ := objects.SharedAviGraphLister().Get(modelName); found {
+			objects.SharedAviGraphLister().Save(modelName, nil)
+			if !fullsync {
+				PublishKeyToRestLayer(modelName, key, sharedQueue)
+			}
+		}
+		return
+	}
+	ok := saveAviModel(modelName, aviModelGraph, key)
+	if ok && !fullsync {
+		PublishKeyToRestLayer(modelName, key, sharedQueue)
+	}
+}
+
 func handleIngress(key string, fullsync bool, ingressNames []string) {
 	objType, namespace, _ := lib.ExtractTypeNameNamespace(key)
 	sharedQueue := utils.SharedWorkQueue().GetQueueByName(utils.GraphLayer)
@@ -658,6 +837,7 @@ func saveAviModel(modelName string, aviGraph *AviObjectGraph, key string) bool {
 		utils.AviLog.Debugf("key: %s, msg: the model: %s has a present checksum: %v", key, modelName, presentChecksum)
 		if prevChecksum == presentChecksum {
 			utils.AviLog.Debugf("key: %s, msg: The model: %s has identical checksums, hence not processing. Checksum value: %v", key, modelName, presentChecksum)
+			utils.IncrementChecksumSkips(utils.ChecksumSkipGraphLayer)
 			return false
 		}
 	}
@@ -783,11 +963,30 @@ func GetShardVSName(s string, key string, shardSize uint32, prefix ...string) li
 	return vsNameMeta
 }
 
+func getInfraSettingShardScheme(infraSettingName, key string) (string, bool) {
+	infraSetting, err := lib.AKOControlConfig().CRDInformers().AviInfraSettingInformer.Lister().Get(infraSettingName)
+	if err != nil {
+		utils.AviLog.Debugf("key: %s, msg: AviInfraSetting %s not found, removing its shard size and scheme", key, infraSettingName)
+		return "", false
+	}
+	return infraSetting.Spec.L7Settings.ShardScheme, true
+}
+
+// GetShardKey returns the string that is hashed to pick the shared VS for the host, the hostname or
+// the namespace of the ingress/route as per the shard scheme. Dedicated VSes are always per host.
+func GetShardKey(hostname string, shardSize uint32, shardScheme string, routeIgrObj RouteIngressModel) string {
+	if shardSize != 0 && shardScheme == lib.NAMESPACE_SHARD_SCHEME {
+		return routeIgrObj.GetNamespace()
+	}
+	return hostname
+}
+
 // returns old and new models if changed, else just the current one.
 func DeriveShardVS(hostname string, key string, routeIgrObj RouteIngressModel) (lib.VSNameMetadata, lib.VSNameMetadata) {
 	utils.AviLog.Debugf("key: %s, msg: hostname for sharding: %s", key, hostname)
 	var newInfraPrefix, oldInfraPrefix string
 	oldShardSize, newShardSize := lib.GetshardSize(), lib.GetshardSize()
+	oldShardScheme, newShardScheme := lib.GetL7ShardScheme(), lib.GetL7ShardScheme()
 
 	// get stored infrasetting from ingress/route
 	// figure out the current infrasetting via class/annotation
@@ -797,6 +996,9 @@ func DeriveShardVS(hostname string, key string, routeIgrObj RouteIngressModel) (
 		if found, shardSize := objects.InfraSettingL7Lister().GetInfraSettingToShardSize(oldSettingName); found && shardSize != "" {
 			oldShardSize = lib.ShardSizeMap[shardSize]
 		}
+		if found, shardScheme := objects.InfraSettingL7Lister().GetInfraSettingToShardScheme(oldSettingName); found && shardScheme != "" {
+			oldShardScheme = shardScheme
+		}
 		oldInfraPrefix = oldSettingName
 	} else {
 		utils.AviLog.Debugf("AviInfraSetting %s not found in cache", oldSettingName)
@@ -806,15 +1008,21 @@ func DeriveShardVS(hostname string, key string, routeIgrObj RouteIngressModel) (
 	if !routeIgrObj.Exists() {
 		// get the old ones.
 		newShardSize = oldShardSize
+		newShardScheme = oldShardScheme
 		newInfraPrefix = oldInfraPrefix
 	} else if newSetting != nil {
-		if newSetting.Spec.L7Settings != (akov1alpha1.AviInfraL7Settings{}) {
+		if newSetting.Spec.L7Settings.ShardSize != "" {
 			newShardSize = lib.ShardSizeMap[newSetting.Spec.L7Settings.ShardSize]
 		}
+		if newSetting.Spec.L7Settings.ShardScheme != "" {
+			newShardScheme = newSetting.Spec.L7Settings.ShardScheme
+		}
 		newInfraPrefix = newSetting.Name
 	}
 
-	oldVsName, newVsName := GetShardVSName(hostname, key, oldShardSize, oldInfraPrefix), GetShardVSName(hostname, key, newShardSize, newInfraPrefix)
+	oldShardKey := GetShardKey(hostname, oldShardSize, oldShardScheme, routeIgrObj)
+	newShardKey := GetShardKey(hostname, newShardSize, newShardScheme, routeIgrObj)
+	oldVsName, newVsName := GetShardVSName(oldShardKey, key, oldShardSize, oldInfraPrefix), GetShardVSName(newShardKey, key, newShardSize, newInfraPrefix)
 	utils.AviLog.Infof("key: %s, msg: ShardVSNames: %v %v", key, oldVsName, newVsName)
 	return oldVsName, newVsName
 }
@@ -843,7 +1051,7 @@ func DerivePassthroughVS(hostname string, key string, routeIgrObj RouteIngressMo
 		newShardSize = oldShardSize
 		newInfraPrefix = oldInfraPrefix
 	} else if newSetting != nil {
-		if newSetting.Spec.L7Settings != (akov1alpha1.AviInfraL7Settings{}) {
+		if newSetting.Spec.L7Settings.ShardSize != "" {
 			newShardSize = lib.ShardSizeMap[newSetting.Spec.L7Settings.ShardSize]
 		}
 		newInfraPrefix = newSetting.Name
@@ -33,6 +33,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	servicesapi "sigs.k8s.io/service-apis/apis/v1alpha1"
 	svcapiv1alpha1 "sigs.k8s.io/service-apis/apis/v1alpha1"
 )
@@ -101,6 +102,14 @@ var (
 		Type:              "GatewayClass",
 		GetParentGateways: GWClassToGateway,
 	}
+	HTTPRoute = GraphSchema{
+		Type:              lib.HTTPRoute,
+		GetParentGateways: HTTPRouteToGateway,
+	}
+	TCPRoute = GraphSchema{
+		Type:              lib.TCPRoute,
+		GetParentGateways: TCPRouteToGateway,
+	}
 	AviInfraSetting = GraphSchema{
 		Type:               "AviInfraSetting",
 		GetParentIngresses: AviSettingToIng,
@@ -130,6 +139,8 @@ var (
 		HTTPRule,
 		Gateway,
 		GatewayClass,
+		HTTPRoute,
+		TCPRoute,
 		AviInfraSetting,
 		MultiClusterIngress,
 		ServiceImport,
@@ -241,6 +252,16 @@ func SvcToGateway(svcName string, namespace string, key string) ([]string, bool)
 		}
 	}
 
+	if lib.UseServicesAPI() {
+		// Gateways with HTTPRoutes/TCPRoutes that forward to the service.
+		_, routeGateways := objects.SharedSvcApiRouteLister().GetSvcToGateways(svcNSName)
+		for _, gateway := range routeGateways {
+			if !utils.HasElem(allGateways, gateway) {
+				allGateways = append(allGateways, gateway)
+			}
+		}
+	}
+
 	utils.AviLog.Debugf("key: %s, msg: Gateways retrieved %s", key, allGateways)
 	return allGateways, true
 }
@@ -282,12 +303,17 @@ func GatewayChanges(gwName string, namespace string, key string) ([]string, bool
 			objects.ServiceGWLister().DeleteGWListeners(namespace + "/" + gwName)
 			objects.ServiceGWLister().RemoveGatewayGWclassMappings(namespace + "/" + gwName)
 		} else {
-			if gwListeners := parseSvcApiGatewayForListeners(gateway, key); len(gwListeners) > 0 {
+			gwListeners := parseSvcApiGatewayForListeners(gateway, key)
+			if len(gwListeners) > 0 {
 				objects.ServiceGWLister().UpdateGWListeners(namespace+"/"+gwName, gwListeners)
+			} else {
+				objects.ServiceGWLister().DeleteGWListeners(namespace + "/" + gwName)
+			}
+			// Gateways with only HTTP listeners have no L4 listeners, but are still bound to the class.
+			if len(gwListeners) > 0 || hasSvcApiHTTPListeners(gateway) {
 				objects.ServiceGWLister().UpdateGatewayGWclassMappings(namespace+"/"+gwName, gateway.Spec.GatewayClassName)
 			} else {
 				objects.ServiceGWLister().RemoveGatewayGWclassMappings(namespace + "/" + gwName)
-				objects.ServiceGWLister().DeleteGWListeners(namespace + "/" + gwName)
 			}
 		}
 	}
@@ -300,6 +326,61 @@ func GWClassToGateway(gwClassName string, namespace string, key string) ([]strin
 	return gateways, found
 }
 
+func HTTPRouteToGateway(routeName string, namespace string, key string) ([]string, bool) {
+	gateways := svcApiRouteToGateway(lib.HTTPRoute, routeName, namespace, key)
+	utils.AviLog.Debugf("key: %s, msg: Gateways retrieved %s", key, gateways)
+	return gateways, true
+}
+
+func TCPRouteToGateway(routeName string, namespace string, key string) ([]string, bool) {
+	gateways := svcApiRouteToGateway(lib.TCPRoute, routeName, namespace, key)
+	utils.AviLog.Debugf("key: %s, msg: Gateways retrieved %s", key, gateways)
+	return gateways, true
+}
+
+// svcApiRouteToGateway returns the gateways that admitted the route earlier, along with the
+// gateways that have a listener selecting the route now.
+func svcApiRouteToGateway(routeKind, routeName, namespace, key string) []string {
+	_, allGateways := objects.SharedSvcApiRouteLister().GetRouteToGateways(routeKind + "/" + namespace + "/" + routeName)
+	gateways := make([]string, len(allGateways))
+	copy(gateways, allGateways)
+
+	var routeMeta metav1.ObjectMeta
+	var routeGateways svcapiv1alpha1.RouteGateways
+	if routeKind == lib.HTTPRoute {
+		route, err := lib.AKOControlConfig().SvcAPIInformers().HTTPRouteInformer.Lister().HTTPRoutes(namespace).Get(routeName)
+		if err != nil {
+			return gateways
+		}
+		routeMeta, routeGateways = route.ObjectMeta, route.Spec.Gateways
+	} else {
+		route, err := lib.AKOControlConfig().SvcAPIInformers().TCPRouteInformer.Lister().TCPRoutes(namespace).Get(routeName)
+		if err != nil {
+			return gateways
+		}
+		routeMeta, routeGateways = route.ObjectMeta, route.Spec.Gateways
+	}
+
+	gwObjs, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: unable to list gateways: %v", key, err)
+		return gateways
+	}
+	for _, gw := range gwObjs {
+		gwNSName := gw.Namespace + "/" + gw.Name
+		if utils.HasElem(gateways, gwNSName) {
+			continue
+		}
+		for _, listener := range gw.Spec.Listeners {
+			if listener.Routes.Kind == routeKind && svcApiListenerSelectsRoute(gw, listener, routeMeta, routeGateways, key) {
+				gateways = append(gateways, gwNSName)
+				break
+			}
+		}
+	}
+	return gateways
+}
+
 func IngressChanges(ingName string, namespace string, key string) ([]string, bool) {
 	var ingresses []string
 	ingresses = append(ingresses, ingName)
@@ -540,7 +621,25 @@ func SecretToRoute(secretName string, namespace string, key string) ([]string, b
 }
 
 func SecretToGateway(secretName string, namespace string, key string) ([]string, bool) {
-	return nil, false
+	if !lib.UseServicesAPI() {
+		return nil, false
+	}
+	// Gateways with HTTPS listeners that refer to the secret for the listener certificate.
+	var gateways []string
+	gwObjs, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().Gateways(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, false
+	}
+	for _, gw := range gwObjs {
+		for _, listener := range gw.Spec.Listeners {
+			if isSvcApiHTTPListener(listener) && listener.TLS != nil && listener.TLS.CertificateRef.Name == secretName {
+				gateways = append(gateways, gw.Namespace+"/"+gw.Name)
+				break
+			}
+		}
+	}
+	utils.AviLog.Debugf("key: %s, msg: Gateways retrieved %s", key, gateways)
+	return gateways, len(gateways) > 0
 }
 
 func parseServicesForRoute(routeSpec routev1.RouteSpec, key string) []string {
@@ -723,10 +822,45 @@ func HTTPRuleToIng(rrname string, namespace string, key string) ([]string, bool)
 		}
 	}
 
+	removeHTTPRuleSvcMappings(allIngresses, key)
 	utils.AviLog.Debugf("key: %s, msg: Ingresses retrieved %s", key, allIngresses)
 	return allIngresses, true
 }
 
+// removeHTTPRuleSvcMappings removes the mappings of the Ingresses/Routes to the services which are not in their
+// spec, which are added for the backends of the httprules. The mappings to the backends which are still in the
+// httprules are added again when the models of the Ingresses/Routes are built. The mappings of the deleted
+// Ingresses/Routes are removed along with their other mappings.
+func removeHTTPRuleSvcMappings(ingresses []string, key string) {
+	for _, ing := range ingresses {
+		nsName := strings.Split(ing, "/")
+		if len(nsName) != 2 {
+			continue
+		}
+		namespace, name := nsName[0], nsName[1]
+		var svcLister *objects.SvcLister
+		var specSvcs []string
+		if utils.GetInformers().RouteInformer != nil {
+			routeObj, err := utils.GetInformers().RouteInformer.Lister().Routes(namespace).Get(name)
+			if err != nil {
+				continue
+			}
+			svcLister, specSvcs = objects.OshiftRouteSvcLister(), parseServicesForRoute(routeObj.Spec, key)
+		} else {
+			ingObj, err := utils.GetInformers().IngressInformer.Lister().Ingresses(namespace).Get(name)
+			if err != nil {
+				continue
+			}
+			svcLister, specSvcs = objects.SharedSvcLister(), parseServicesForIngress(ingObj.Spec, key)
+		}
+		_, svcs := svcLister.IngressMappings(namespace).GetIngToSvc(name)
+		for _, svc := range lib.Difference(svcs, specSvcs) {
+			utils.AviLog.Debugf("key: %s, msg: removing the relationship of %s with the httprule backend service %s", key, ing, svc)
+			svcLister.IngressMappings(namespace).RemoveSvcFromIngressMappings(name, svc)
+		}
+	}
+}
+
 func AviSettingToIng(infraSettingName, namespace, key string) ([]string, bool) {
 	allIngresses := make([]string, 0)
 
@@ -762,6 +896,7 @@ func AviSettingToRoute(infraSettingName, namespace, key string) ([]string, bool)
 	for _, route := range routes {
 		if routeObj, isRoute := route.(*routev1.Route); isRoute {
 			RouteChanges(routeObj.Name, routeObj.Namespace, key)
+			allRoutes = append(allRoutes, routeObj.Namespace+"/"+routeObj.Name)
 		}
 	}
 
@@ -922,6 +1057,14 @@ func parseGatewayForListeners(gateway *advl4v1alpha1pre1.Gateway, key string) []
 func parseSvcApiGatewayForListeners(gateway *svcapiv1alpha1.Gateway, key string) []string {
 	var listeners []string
 	for _, listener := range gateway.Spec.Listeners {
+		if listener.Routes.Kind == lib.HTTPRoute {
+			// HTTP listeners are part of the L7 VS of the gateway.
+			continue
+		}
+		if isSvcApiTCPListener(listener) {
+			listeners = append(listeners, fmt.Sprintf("%s/%d", listener.Protocol, listener.Port))
+			continue
+		}
 		gwName, nameOk := listener.Routes.Selector.MatchLabels[lib.SvcApiGatewayNameLabelKey]
 		gwNamespace, nsOk := listener.Routes.Selector.MatchLabels[lib.SvcApiGatewayNamespaceLabelKey]
 		if nameOk && nsOk && gwName == gateway.Name && gwNamespace == gateway.Namespace {
@@ -967,6 +1110,9 @@ func validateSvcApiGatewayForClass(key string, gateway *svcapiv1alpha1.Gateway)
 	}
 
 	for _, listener := range gateway.Spec.Listeners {
+		if IsSvcApiRouteListener(listener) {
+			continue
+		}
 		gwName, nameOk := listener.Routes.Selector.MatchLabels[lib.SvcApiGatewayNameLabelKey]
 		gwNamespace, nsOk := listener.Routes.Selector.MatchLabels[lib.SvcApiGatewayNamespaceLabelKey]
 		if !nameOk || !nsOk ||
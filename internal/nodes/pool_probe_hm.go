@@ -0,0 +1,178 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package nodes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Default values of the readiness probe fields, used if the fields are not set in the pod.
+const (
+	defaultProbePeriod           = 10
+	defaultProbeTimeout          = 1
+	defaultProbeSuccessThreshold = 1
+	defaultProbeFailureThreshold = 3
+)
+
+// buildProbeHealthMonitor returns the health monitor of a pool, built from the readiness probe of the pods of the
+// pool servers. The pods are looked up in the order of their names, and the readiness probe of the first pod
+// which has one for the pool port is used. It returns nil if none of the pods has an HTTP or TCP readiness probe.
+func buildProbeHealthMonitor(poolNode *AviPoolNode, ns, serviceName string, servers []AviPoolMetaServer, serverPods map[string]string, key string) *AviHealthMonitorNode {
+	if !lib.IsProbeHealthMonitorEnabled() || utils.GetInformers().PodInformer == nil {
+		return nil
+	}
+	if poolNode.Protocol == utils.UDP || poolNode.Protocol == utils.SCTP {
+		return nil
+	}
+
+	var pods []string
+	for _, server := range servers {
+		if pod := serverPods[*server.Ip.Addr]; pod != "" && !utils.HasElem(pods, pod) {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Strings(pods)
+
+	for _, pod := range pods {
+		podNSName := strings.Split(pod, "/")
+		podObj, err := utils.GetInformers().PodInformer.Lister().Pods(podNSName[0]).Get(podNSName[1])
+		if err != nil {
+			continue
+		}
+		container, probe := getPodReadinessProbe(podObj, poolNode.Port)
+		if probe == nil {
+			continue
+		}
+		hmNode := probeToHealthMonitor(poolNode, container, probe)
+		if hmNode == nil {
+			continue
+		}
+		hmNode.AviMarkers = utils.AviObjectMarkers{Namespace: ns, ServiceName: serviceName}
+		utils.AviLog.Infof("key: %s, msg: health monitor %s of pool %s built from the readiness probe of pod %s", key, hmNode.Name, poolNode.Name, pod)
+		return hmNode
+	}
+	return nil
+}
+
+// getPodReadinessProbe returns the container serving the given port in the pod, along with its readiness probe.
+// If the port is not declared by any container, the pod should have a single container.
+func getPodReadinessProbe(pod *corev1.Pod, port int32) (*corev1.Container, *corev1.Probe) {
+	for i, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.ContainerPort == port {
+				return &pod.Spec.Containers[i], container.ReadinessProbe
+			}
+		}
+	}
+	if len(pod.Spec.Containers) == 1 {
+		return &pod.Spec.Containers[0], pod.Spec.Containers[0].ReadinessProbe
+	}
+	return nil, nil
+}
+
+// probeToHealthMonitor translates an HTTP or TCP readiness probe to a health monitor. The exec and gRPC probes
+// are not supported.
+func probeToHealthMonitor(poolNode *AviPoolNode, container *corev1.Container, probe *corev1.Probe) *AviHealthMonitorNode {
+	hmNode := &AviHealthMonitorNode{
+		Name:             lib.GetProbeHealthMonitorName(poolNode.Name),
+		Tenant:           lib.GetTenant(),
+		SendInterval:     probe.PeriodSeconds,
+		ReceiveTimeout:   probe.TimeoutSeconds,
+		SuccessfulChecks: probe.SuccessThreshold,
+		FailedChecks:     probe.FailureThreshold,
+	}
+
+	var probePort intstr.IntOrString
+	switch {
+	case probe.HTTPGet != nil:
+		probePort = probe.HTTPGet.Port
+		hmNode.Type = lib.HealthMonitorTypeHTTP
+		if probe.HTTPGet.Scheme == corev1.URISchemeHTTPS {
+			hmNode.Type = lib.HealthMonitorTypeHTTPS
+		}
+		hmNode.HTTPRequest = getProbeHTTPRequest(probe.HTTPGet)
+	case probe.TCPSocket != nil:
+		probePort = probe.TCPSocket.Port
+		hmNode.Type = lib.HealthMonitorTypeTCP
+	default:
+		return nil
+	}
+
+	port := resolveContainerPort(container, probePort)
+	if port == 0 {
+		return nil
+	}
+	if port != poolNode.Port {
+		hmNode.MonitorPort = port
+	}
+
+	if hmNode.SendInterval <= 0 {
+		hmNode.SendInterval = defaultProbePeriod
+	}
+	if hmNode.ReceiveTimeout <= 0 {
+		hmNode.ReceiveTimeout = defaultProbeTimeout
+	}
+	if hmNode.SuccessfulChecks <= 0 {
+		hmNode.SuccessfulChecks = defaultProbeSuccessThreshold
+	}
+	if hmNode.FailedChecks <= 0 {
+		hmNode.FailedChecks = defaultProbeFailureThreshold
+	}
+	// The receive timeout of a health monitor should be less than its send interval.
+	if hmNode.ReceiveTimeout >= hmNode.SendInterval {
+		if hmNode.SendInterval == 1 {
+			hmNode.SendInterval = 2
+		}
+		hmNode.ReceiveTimeout = hmNode.SendInterval - 1
+	}
+	return hmNode
+}
+
+// getProbeHTTPRequest returns the HTTP request of the health monitor for the probe, with the host and the
+// headers of the probe added to the request.
+func getProbeHTTPRequest(httpGet *corev1.HTTPGetAction) string {
+	path := httpGet.Path
+	if path == "" {
+		path = "/"
+	}
+	request := []string{"GET " + path + " HTTP/1.0"}
+	if httpGet.Host != "" {
+		request = append(request, "Host: "+httpGet.Host)
+	}
+	for _, header := range httpGet.HTTPHeaders {
+		request = append(request, header.Name+": "+header.Value)
+	}
+	return strings.Join(request, "\r\n")
+}
+
+// resolveContainerPort returns the port number of a probe port, which can be the name of a container port.
+func resolveContainerPort(container *corev1.Container, port intstr.IntOrString) int32 {
+	if port.Type == intstr.Int {
+		return port.IntVal
+	}
+	for _, containerPort := range container.Ports {
+		if containerPort.Name == port.StrVal {
+			return containerPort.ContainerPort
+		}
+	}
+	return 0
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package nodes
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// drainServer is a pool server from the last graph build of the pool. The drain start is set once the
+// endpoint of the server is terminating or removed.
+type drainServer struct {
+	server     AviPoolMetaServer
+	service    string // namespace/name of the service of the endpoint
+	pod        string // namespace/name of the pod of the endpoint
+	drainStart time.Time
+}
+
+// serverDrainTracker keeps the servers of the pools from the last graph build, so that the servers of the
+// terminating or removed endpoints are disabled in the pools, and removed only after the drain timeout.
+type serverDrainTracker struct {
+	lock    sync.Mutex
+	servers map[string]map[string]drainServer // pool name: {ip:port: server}
+	expiry  map[string]time.Time              // pool name: time at which the pool is synced again
+}
+
+var drainTrackerInstance *serverDrainTracker
+var drainTrackerOnce sync.Once
+
+func sharedServerDrainTracker() *serverDrainTracker {
+	drainTrackerOnce.Do(func() {
+		drainTrackerInstance = &serverDrainTracker{
+			servers: make(map[string]map[string]drainServer),
+			expiry:  make(map[string]time.Time),
+		}
+	})
+	return drainTrackerInstance
+}
+
+func drainServerKey(server AviPoolMetaServer) string {
+	return *server.Ip.Addr + ":" + strconv.Itoa(int(server.Port))
+}
+
+// endpointPodName returns the namespace/name of the pod of an endpoint, if the endpoint belongs to a pod.
+func endpointPodName(targetRef *corev1.ObjectReference) string {
+	if targetRef == nil || targetRef.Kind != "Pod" {
+		return ""
+	}
+	return targetRef.Namespace + "/" + targetRef.Name
+}
+
+// drainPoolServers returns the servers of a pool along with the servers being drained. A server is drained
+// if its endpoint is terminating, or if it was removed while its pod still exists. The drained servers are
+// disabled in the pool, and removed once the drain timeout expires or once their pod is deleted.
+func drainPoolServers(poolName, ns, serviceName string, servers, terminatingServers []AviPoolMetaServer, serverPods map[string]string, key string) []AviPoolMetaServer {
+	if !lib.IsServerDrainEnabled() {
+		return servers
+	}
+
+	tracker := sharedServerDrainTracker()
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	now := time.Now()
+	service := ns + "/" + serviceName
+	lastServers := tracker.servers[poolName]
+	poolServers := make(map[string]drainServer)
+	for _, server := range servers {
+		poolServers[drainServerKey(server)] = drainServer{server: server, service: service, pod: serverPods[*server.Ip.Addr]}
+	}
+
+	var drainServers []drainServer
+	for _, server := range terminatingServers {
+		serverKey := drainServerKey(server)
+		if _, ok := poolServers[serverKey]; ok {
+			continue
+		}
+		ds := drainServer{server: server, service: service, pod: serverPods[*server.Ip.Addr], drainStart: now}
+		if lastServer, ok := lastServers[serverKey]; ok && !lastServer.drainStart.IsZero() {
+			ds.drainStart = lastServer.drainStart
+		}
+		poolServers[serverKey] = ds
+		drainServers = append(drainServers, ds)
+	}
+	for serverKey, lastServer := range lastServers {
+		if _, ok := poolServers[serverKey]; ok {
+			continue
+		}
+		if !isPodPresent(lastServer.pod) {
+			utils.AviLog.Infof("key: %s, msg: pod %s is deleted, removing server %s from pool %s", key, lastServer.pod, serverKey, poolName)
+			continue
+		}
+		if lastServer.drainStart.IsZero() {
+			lastServer.drainStart = now
+		}
+		drainServers = append(drainServers, lastServer)
+	}
+
+	drainTimeout := lib.GetServerDrainTimeout()
+	for _, ds := range drainServers {
+		serverKey := drainServerKey(ds.server)
+		drainEnd := ds.drainStart.Add(drainTimeout)
+		if !now.Before(drainEnd) {
+			utils.AviLog.Infof("key: %s, msg: drain timeout expired, removing server %s from pool %s", key, serverKey, poolName)
+			delete(poolServers, serverKey)
+			continue
+		}
+		ds.server.Draining = true
+		poolServers[serverKey] = ds
+		servers = append(servers, ds.server)
+		tracker.scheduleDrainExpiry(poolName, ns, serviceName, drainEnd)
+		utils.AviLog.Infof("key: %s, msg: draining server %s of pool %s till %s", key, serverKey, poolName, drainEnd.Format(time.RFC3339))
+	}
+
+	if len(poolServers) == 0 {
+		delete(tracker.servers, poolName)
+	} else {
+		tracker.servers[poolName] = poolServers
+	}
+	return servers
+}
+
+// scheduleDrainExpiry syncs the service again when the drain of a server of the pool ends, to remove the server.
+// Only the earliest drain end of a pool is scheduled, the later ones are scheduled when the pool is synced.
+func (t *serverDrainTracker) scheduleDrainExpiry(poolName, ns, serviceName string, drainEnd time.Time) {
+	if expiry, ok := t.expiry[poolName]; ok && !expiry.After(drainEnd) {
+		return
+	}
+	t.expiry[poolName] = drainEnd
+	time.AfterFunc(time.Until(drainEnd), func() {
+		t.lock.Lock()
+		if t.expiry[poolName].Equal(drainEnd) {
+			delete(t.expiry, poolName)
+		}
+		t.lock.Unlock()
+		enqueueServiceEndpoints(ns, serviceName)
+	})
+}
+
+// RemovePoolDrainServers removes the servers of a deleted pool from the drain tracker.
+func RemovePoolDrainServers(poolName string) {
+	tracker := sharedServerDrainTracker()
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	delete(tracker.servers, poolName)
+	delete(tracker.expiry, poolName)
+}
+
+// GetDrainingPodServices returns the services of the servers being drained for a pod, which are synced
+// once the pod is deleted, to remove the servers.
+func GetDrainingPodServices(pod string) []string {
+	tracker := sharedServerDrainTracker()
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	var services []string
+	for _, poolServers := range tracker.servers {
+		for _, ds := range poolServers {
+			if ds.pod == pod && !ds.drainStart.IsZero() && !utils.HasElem(services, ds.service) {
+				services = append(services, ds.service)
+			}
+		}
+	}
+	return services
+}
+
+func isPodPresent(pod string) bool {
+	if pod == "" || utils.GetInformers().PodInformer == nil {
+		return true
+	}
+	podNSName := strings.Split(pod, "/")
+	_, err := utils.GetInformers().PodInformer.Lister().Pods(podNSName[0]).Get(podNSName[1])
+	return !k8serrors.IsNotFound(err)
+}
+
+func enqueueServiceEndpoints(ns, serviceName string) {
+	key := utils.Endpoints + "/" + ns + "/" + serviceName
+	ingestionQueue := utils.SharedWorkQueue().GetQueueByName(utils.ObjectIngestionLayer)
+	bkt := utils.Bkt(ns, ingestionQueue.NumWorkers)
+	ingestionQueue.Workqueue[bkt].AddRateLimited(key)
+	utils.AviLog.Debugf("key: %s, msg: synced for the drained servers", key)
+}
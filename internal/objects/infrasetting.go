@@ -24,8 +24,9 @@ var infraonce sync.Once
 func InfraSettingL7Lister() *AviInfraSettingL7Lister {
 	infraonce.Do(func() {
 		infral7lister = &AviInfraSettingL7Lister{
-			IngRouteInfraSettingStore:  NewObjectMapStore(),
-			InfraSettingShardSizeStore: NewObjectMapStore(),
+			IngRouteInfraSettingStore:    NewObjectMapStore(),
+			InfraSettingShardSizeStore:   NewObjectMapStore(),
+			InfraSettingShardSchemeStore: NewObjectMapStore(),
 		}
 	})
 	return infral7lister
@@ -39,6 +40,9 @@ type AviInfraSettingL7Lister struct {
 
 	// infrasetting -> shardSize
 	InfraSettingShardSizeStore *ObjectMapStore
+
+	// infrasetting -> shardScheme
+	InfraSettingShardSchemeStore *ObjectMapStore
 }
 
 func (v *AviInfraSettingL7Lister) GetIngRouteToInfraSetting(ingrouteNsName string) (bool, string) {
@@ -49,20 +53,39 @@ func (v *AviInfraSettingL7Lister) GetIngRouteToInfraSetting(ingrouteNsName strin
 	return true, infraSettingName.(string)
 }
 
-func (v *AviInfraSettingL7Lister) UpdateIngRouteInfraSettingMappings(ingrouteNsName, infraSettingName, shardSize string) {
+func (v *AviInfraSettingL7Lister) UpdateIngRouteInfraSettingMappings(ingrouteNsName, infraSettingName, shardSize, shardScheme string) {
 	v.InfraSettingIngRouteLock.Lock()
 	defer v.InfraSettingIngRouteLock.Unlock()
 	v.IngRouteInfraSettingStore.AddOrUpdate(ingrouteNsName, infraSettingName)
 	v.InfraSettingShardSizeStore.AddOrUpdate(infraSettingName, shardSize)
+	// The shard scheme of an infrasetting changes only in UpdateInfraSettingShardScheme, once all the
+	// ingresses/routes of the infrasetting are moved to the shard VSes of the new scheme.
+	if found, _ := v.InfraSettingShardSchemeStore.Get(infraSettingName); !found {
+		v.InfraSettingShardSchemeStore.AddOrUpdate(infraSettingName, shardScheme)
+	}
 }
 
+// RemoveIngRouteInfraSettingMappings removes the infrasetting of the ingress/route. The shard size and scheme of the
+// infrasetting are removed along with its last ingress/route, as the others still need them to be synced.
 func (v *AviInfraSettingL7Lister) RemoveIngRouteInfraSettingMappings(ingrouteNsName string) bool {
 	v.InfraSettingIngRouteLock.Lock()
 	defer v.InfraSettingIngRouteLock.Unlock()
-	if found, infraSettingName := v.GetIngRouteToInfraSetting(ingrouteNsName); found {
+	found, infraSettingName := v.GetIngRouteToInfraSetting(ingrouteNsName)
+	deleted := v.IngRouteInfraSettingStore.Delete(ingrouteNsName)
+	if found && !v.isInfraSettingInUse(infraSettingName) {
 		v.InfraSettingShardSizeStore.Delete(infraSettingName)
+		v.InfraSettingShardSchemeStore.Delete(infraSettingName)
+	}
+	return deleted
+}
+
+func (v *AviInfraSettingL7Lister) isInfraSettingInUse(infraSettingName string) bool {
+	for _, name := range v.IngRouteInfraSettingStore.CopyAllObjects() {
+		if name.(string) == infraSettingName {
+			return true
+		}
 	}
-	return v.IngRouteInfraSettingStore.Delete(ingrouteNsName)
+	return false
 }
 
 func (v *AviInfraSettingL7Lister) GetInfraSettingToShardSize(infraSettingName string) (bool, string) {
@@ -72,3 +95,28 @@ func (v *AviInfraSettingL7Lister) GetInfraSettingToShardSize(infraSettingName st
 	}
 	return true, shardSize.(string)
 }
+
+// UpdateInfraSettingShardScheme sets the shard scheme of the infrasetting, after all its ingresses/routes
+// are synced with the scheme.
+func (v *AviInfraSettingL7Lister) UpdateInfraSettingShardScheme(infraSettingName, shardScheme string) {
+	v.InfraSettingIngRouteLock.Lock()
+	defer v.InfraSettingIngRouteLock.Unlock()
+	v.InfraSettingShardSchemeStore.AddOrUpdate(infraSettingName, shardScheme)
+}
+
+// RemoveInfraSettingMappings removes the shard size and scheme of a deleted infrasetting, after the hosts of each of
+// its ingresses/routes are removed from the shard VSes.
+func (v *AviInfraSettingL7Lister) RemoveInfraSettingMappings(infraSettingName string) {
+	v.InfraSettingIngRouteLock.Lock()
+	defer v.InfraSettingIngRouteLock.Unlock()
+	v.InfraSettingShardSizeStore.Delete(infraSettingName)
+	v.InfraSettingShardSchemeStore.Delete(infraSettingName)
+}
+
+func (v *AviInfraSettingL7Lister) GetInfraSettingToShardScheme(infraSettingName string) (bool, string) {
+	found, shardScheme := v.InfraSettingShardSchemeStore.Get(infraSettingName)
+	if !found {
+		return false, ""
+	}
+	return true, shardScheme.(string)
+}
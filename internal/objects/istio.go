@@ -0,0 +1,156 @@
+/*
+ * Copyright 2019-2020 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package objects
+
+import (
+	"sync"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+var istiolister *IstioLister
+var istioonce sync.Once
+
+// This file builds cache relations for the Istio objects.
+// Relationships stored are: virtualservice to gateway, virtualservice to service
+// and destinationrule to service. All keys are of the form namespace/name.
+
+func SharedIstioLister() *IstioLister {
+	istioonce.Do(func() {
+		istiolister = &IstioLister{
+			VSGatewayStore: NewObjectMapStore(),
+			VSSvcStore:     NewObjectMapStore(),
+			SvcVSStore:     NewObjectMapStore(),
+			DRSvcStore:     NewObjectMapStore(),
+		}
+	})
+	return istiolister
+}
+
+type IstioLister struct {
+	IstioLock sync.RWMutex
+
+	// ns1/vs1 -> [ns1/gw1, ns2/gw2]
+	VSGatewayStore *ObjectMapStore
+
+	// ns1/vs1 -> [ns1/svc1, ns2/svc2]
+	VSSvcStore *ObjectMapStore
+
+	// ns1/svc1 -> [ns1/vs1, ns2/vs2]
+	SvcVSStore *ObjectMapStore
+
+	// ns1/dr1 -> ns1/svc1
+	DRSvcStore *ObjectMapStore
+}
+
+// VirtualService <-> Gateway
+func (v *IstioLister) GetVSToGateways(vs string) (bool, []string) {
+	found, gateways := v.VSGatewayStore.Get(vs)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, gateways.([]string)
+}
+
+func (v *IstioLister) UpdateVSToGateways(vs string, gateways []string) {
+	v.VSGatewayStore.AddOrUpdate(vs, gateways)
+}
+
+func (v *IstioLister) DeleteVSToGateways(vs string) bool {
+	return v.VSGatewayStore.Delete(vs)
+}
+
+// VirtualService <-> Service
+func (v *IstioLister) GetVSToSvcs(vs string) (bool, []string) {
+	found, svcs := v.VSSvcStore.Get(vs)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, svcs.([]string)
+}
+
+func (v *IstioLister) GetSvcToVSes(svc string) (bool, []string) {
+	found, vsList := v.SvcVSStore.Get(svc)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, vsList.([]string)
+}
+
+// UpdateVSSvcMappings replaces the services mapped to the virtualservice and
+// updates the reverse service to virtualservice mappings accordingly.
+func (v *IstioLister) UpdateVSSvcMappings(vs string, svcs []string) {
+	v.IstioLock.Lock()
+	defer v.IstioLock.Unlock()
+	_, oldSvcs := v.GetVSToSvcs(vs)
+	for _, svc := range oldSvcs {
+		if utils.HasElem(svcs, svc) {
+			continue
+		}
+		v.removeSvcToVSMapping(svc, vs)
+	}
+	for _, svc := range svcs {
+		_, vsList := v.GetSvcToVSes(svc)
+		if !utils.HasElem(vsList, vs) {
+			vsList = append(vsList, vs)
+		}
+		v.SvcVSStore.AddOrUpdate(svc, vsList)
+	}
+	if len(svcs) == 0 {
+		v.VSSvcStore.Delete(vs)
+		return
+	}
+	v.VSSvcStore.AddOrUpdate(vs, svcs)
+}
+
+func (v *IstioLister) DeleteVSSvcMappings(vs string) {
+	v.IstioLock.Lock()
+	defer v.IstioLock.Unlock()
+	_, oldSvcs := v.GetVSToSvcs(vs)
+	for _, svc := range oldSvcs {
+		v.removeSvcToVSMapping(svc, vs)
+	}
+	v.VSSvcStore.Delete(vs)
+}
+
+func (v *IstioLister) removeSvcToVSMapping(svc, vs string) {
+	found, vsList := v.GetSvcToVSes(svc)
+	if !found || !utils.HasElem(vsList, vs) {
+		return
+	}
+	vsList = utils.Remove(vsList, vs)
+	if len(vsList) == 0 {
+		v.SvcVSStore.Delete(svc)
+		return
+	}
+	v.SvcVSStore.AddOrUpdate(svc, vsList)
+}
+
+// DestinationRule <-> Service
+func (v *IstioLister) GetDRToSvc(dr string) (bool, string) {
+	found, svc := v.DRSvcStore.Get(dr)
+	if !found {
+		return false, ""
+	}
+	return true, svc.(string)
+}
+
+func (v *IstioLister) UpdateDRToSvc(dr, svc string) {
+	v.DRSvcStore.AddOrUpdate(dr, svc)
+}
+
+func (v *IstioLister) DeleteDRToSvc(dr string) bool {
+	return v.DRSvcStore.Delete(dr)
+}
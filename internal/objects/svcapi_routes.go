@@ -0,0 +1,146 @@
+/*
+ * Copyright 2020-2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package objects
+
+import (
+	"sync"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+var svcapiroutelister *SvcApiRouteLister
+var svcapirouteonce sync.Once
+
+// This file builds cache relations for the services API HTTPRoute and TCPRoute objects.
+// Relationships stored are: gateway to admitted routes, gateway to backend services, and
+// the reverse mappings. Routes are keyed as kind/namespace/name, gateways and services
+// as namespace/name.
+
+func SharedSvcApiRouteLister() *SvcApiRouteLister {
+	svcapirouteonce.Do(func() {
+		svcapiroutelister = &SvcApiRouteLister{
+			GwRouteStore: NewObjectMapStore(),
+			RouteGwStore: NewObjectMapStore(),
+			GwSvcStore:   NewObjectMapStore(),
+			SvcGwStore:   NewObjectMapStore(),
+		}
+	})
+	return svcapiroutelister
+}
+
+type SvcApiRouteLister struct {
+	RouteLock sync.RWMutex
+
+	// ns1/gw1 -> [HTTPRoute/ns1/route1, TCPRoute/ns2/route2]
+	GwRouteStore *ObjectMapStore
+
+	// HTTPRoute/ns1/route1 -> [ns1/gw1, ns2/gw2]
+	RouteGwStore *ObjectMapStore
+
+	// ns1/gw1 -> [ns1/svc1, ns2/svc2]
+	GwSvcStore *ObjectMapStore
+
+	// ns1/svc1 -> [ns1/gw1, ns2/gw2]
+	SvcGwStore *ObjectMapStore
+}
+
+// Gateway <-> Route
+func (v *SvcApiRouteLister) GetGatewayToRoutes(gateway string) (bool, []string) {
+	found, routes := v.GwRouteStore.Get(gateway)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, routes.([]string)
+}
+
+func (v *SvcApiRouteLister) GetRouteToGateways(route string) (bool, []string) {
+	found, gateways := v.RouteGwStore.Get(route)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, gateways.([]string)
+}
+
+// UpdateGatewayRouteMappings replaces the routes admitted by the gateway and
+// updates the reverse route to gateway mappings accordingly.
+func (v *SvcApiRouteLister) UpdateGatewayRouteMappings(gateway string, routes []string) {
+	v.RouteLock.Lock()
+	defer v.RouteLock.Unlock()
+	updateReverseMappings(v.GwRouteStore, v.RouteGwStore, gateway, routes)
+}
+
+// Gateway <-> Service
+func (v *SvcApiRouteLister) GetGatewayToSvcs(gateway string) (bool, []string) {
+	found, svcs := v.GwSvcStore.Get(gateway)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, svcs.([]string)
+}
+
+func (v *SvcApiRouteLister) GetSvcToGateways(svc string) (bool, []string) {
+	found, gateways := v.SvcGwStore.Get(svc)
+	if !found {
+		return false, make([]string, 0)
+	}
+	return true, gateways.([]string)
+}
+
+// UpdateGatewaySvcMappings replaces the backend services of the gateway routes and
+// updates the reverse service to gateway mappings accordingly.
+func (v *SvcApiRouteLister) UpdateGatewaySvcMappings(gateway string, svcs []string) {
+	v.RouteLock.Lock()
+	defer v.RouteLock.Unlock()
+	updateReverseMappings(v.GwSvcStore, v.SvcGwStore, gateway, svcs)
+}
+
+// updateReverseMappings sets key -> values in the store, and keeps the value -> keys
+// mappings in the reverse store in sync.
+func updateReverseMappings(store, reverseStore *ObjectMapStore, key string, values []string) {
+	var oldValues []string
+	if found, obj := store.Get(key); found {
+		oldValues = obj.([]string)
+	}
+	for _, value := range oldValues {
+		if utils.HasElem(values, value) {
+			continue
+		}
+		found, obj := reverseStore.Get(value)
+		if !found {
+			continue
+		}
+		keys := utils.Remove(obj.([]string), key)
+		if len(keys) == 0 {
+			reverseStore.Delete(value)
+		} else {
+			reverseStore.AddOrUpdate(value, keys)
+		}
+	}
+	for _, value := range values {
+		var keys []string
+		if found, obj := reverseStore.Get(value); found {
+			keys = obj.([]string)
+		}
+		if !utils.HasElem(keys, key) {
+			keys = append(keys, key)
+		}
+		reverseStore.AddOrUpdate(value, keys)
+	}
+	if len(values) == 0 {
+		store.Delete(key)
+		return
+	}
+	store.AddOrUpdate(key, values)
+}
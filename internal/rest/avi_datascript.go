@@ -15,8 +15,8 @@
 package rest
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
 
 	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
@@ -34,26 +34,23 @@ func (rest *RestOperations) AviDSBuild(ds_meta *nodes.AviHTTPDataScriptNode, cac
 		utils.AviLog.Warnf("key: %s not processing datascript object", key)
 		return nil
 	}
-	var datascriptlist []*avimodels.VSDataScript
 	var poolgroupref []string
 	for _, pgname := range ds_meta.PoolGroupRefs {
 		// Replace the PoolGroup Ref in the DS.
 		pg_ref := "/api/poolgroup/?name=" + pgname
 		poolgroupref = append(poolgroupref, pg_ref)
 	}
-	datascript := avimodels.VSDataScript{Evt: &ds_meta.Evt, Script: &ds_meta.Script}
-	datascriptlist = append(datascriptlist, &datascript)
 	tenant_ref := "/api/tenant/?name=" + ds_meta.Tenant
 	cr := lib.AKOUser
 	vsdatascriptset := avimodels.VSDataScriptSet{
 		CreatedBy:     &cr,
-		Datascript:    datascriptlist,
+		Datascript:    ds_meta.GetDataScripts(),
 		Name:          &ds_meta.Name,
 		TenantRef:     &tenant_ref,
 		PoolGroupRefs: poolgroupref,
 	}
 
-	vsdatascriptset.Markers = lib.GetMarkers()
+	vsdatascriptset.Markers = ds_meta.GetMarkers()
 
 	if len(ds_meta.ProtocolParsers) > 0 {
 		vsdatascriptset.ProtocolParserRefs = ds_meta.ProtocolParsers
@@ -157,11 +154,11 @@ func (rest *RestOperations) AviDSCacheAdd(rest_op *utils.RestOp, vsKey avicache.
 		ds_cache_obj := avicache.AviDSCache{Name: name, Tenant: rest_op.Tenant,
 			Uuid: uuid, PoolGroups: poolgroups}
 
-		// Datascript should not have a checksum
-		checksum := lib.DSChecksum(ds_cache_obj.PoolGroups, nil, false)
-		if len(ds_cache_obj.PoolGroups) == 1 {
-			checksum += utils.Hash(fmt.Sprintf(utils.HTTP_DS_SCRIPT_MODIFIED, ds_cache_obj.PoolGroups[0]))
+		var dsObj avimodels.VSDataScriptSet
+		if rawData, err := json.Marshal(resp); err == nil {
+			json.Unmarshal(rawData, &dsObj)
 		}
+		checksum := lib.DataScriptSetChecksum(ds_cache_obj.PoolGroups, dsObj.Markers, dsObj.Datascript)
 		ds_cache_obj.CloudConfigCksum = checksum
 
 		k := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: name}
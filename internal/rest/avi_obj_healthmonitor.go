@@ -0,0 +1,191 @@
+/*
+ * Copyright 2019-2020 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	avimodels "github.com/vmware/alb-sdk/go/models"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func (rest *RestOperations) AviHealthMonitorBuild(hm_node *nodes.AviHealthMonitorNode, cache_obj *avicache.AviHealthMonitorCache) *utils.RestOp {
+	if lib.CheckObjectNameLength(hm_node.Name, lib.ProbeHealthMonitor) {
+		utils.AviLog.Warnf("Not processing health monitor")
+		return nil
+	}
+	tenant := fmt.Sprintf("/api/tenant/?name=%s", hm_node.Tenant)
+	name := hm_node.Name
+	hmType := hm_node.Type
+	sendInterval := hm_node.SendInterval
+	receiveTimeout := hm_node.ReceiveTimeout
+	successfulChecks := hm_node.SuccessfulChecks
+	failedChecks := hm_node.FailedChecks
+
+	hm := avimodels.HealthMonitor{
+		Name:             &name,
+		TenantRef:        &tenant,
+		Type:             &hmType,
+		SendInterval:     &sendInterval,
+		ReceiveTimeout:   &receiveTimeout,
+		SuccessfulChecks: &successfulChecks,
+		FailedChecks:     &failedChecks,
+	}
+	if hm_node.MonitorPort != 0 {
+		monitorPort := hm_node.MonitorPort
+		hm.MonitorPort = &monitorPort
+	}
+	if hmType == lib.HealthMonitorTypeHTTP || hmType == lib.HealthMonitorTypeHTTPS {
+		httpRequest := hm_node.HTTPRequest
+		// The kubelet considers the status codes from 200 to 399 as a success.
+		httpMonitor := &avimodels.HealthMonitorHTTP{
+			HTTPRequest:      &httpRequest,
+			HTTPResponseCode: []string{"HTTP_2XX", "HTTP_3XX"},
+		}
+		if hmType == lib.HealthMonitorTypeHTTP {
+			hm.HTTPMonitor = httpMonitor
+		} else {
+			hm.HTTPSMonitor = httpMonitor
+		}
+	}
+	hm.Markers = lib.GetAllMarkers(hm_node.AviMarkers)
+
+	var path string
+	var rest_op utils.RestOp
+	if cache_obj != nil {
+		path = "/api/healthmonitor/" + cache_obj.Uuid
+		rest_op = utils.RestOp{
+			ObjName: hm_node.Name,
+			Path:    path,
+			Method:  utils.RestPut,
+			Obj:     hm,
+			Tenant:  hm_node.Tenant,
+			Model:   "HealthMonitor",
+		}
+	} else {
+		path = "/api/healthmonitor/"
+		rest_op = utils.RestOp{
+			ObjName: hm_node.Name,
+			Path:    path,
+			Method:  utils.RestPost,
+			Obj:     hm,
+			Tenant:  hm_node.Tenant,
+			Model:   "HealthMonitor",
+		}
+	}
+	return &rest_op
+}
+
+func (rest *RestOperations) AviHealthMonitorDel(uuid string, tenant string) *utils.RestOp {
+	path := "/api/healthmonitor/" + uuid
+	rest_op := utils.RestOp{
+		Path:   path,
+		Method: "DELETE",
+		Tenant: tenant,
+		Model:  "HealthMonitor",
+	}
+	utils.AviLog.Info(spew.Sprintf("HealthMonitor DELETE Restop %v ",
+		utils.Stringify(rest_op)))
+	return &rest_op
+}
+
+func (rest *RestOperations) AviHealthMonitorAdd(rest_op *utils.RestOp, poolKey avicache.NamespaceName, key string) error {
+	if (rest_op.Err != nil) || (rest_op.Response == nil) {
+		utils.AviLog.Warnf("rest_op has err or no response for HealthMonitor")
+		return errors.New("Errored rest_op")
+	}
+
+	resp_elems := RestRespArrToObjByType(rest_op, "healthmonitor", key)
+	if resp_elems == nil {
+		utils.AviLog.Warnf("Unable to find HealthMonitor obj in resp %v", rest_op.Response)
+		return errors.New("HealthMonitor not found")
+	}
+
+	for _, resp := range resp_elems {
+		name, ok := resp["name"].(string)
+		if !ok {
+			utils.AviLog.Warnf("Name not present in response %v", resp)
+			continue
+		}
+
+		uuid, ok := resp["uuid"].(string)
+		if !ok {
+			utils.AviLog.Warnf("Uuid not present in response %v", resp)
+			continue
+		}
+
+		var hm avimodels.HealthMonitor
+		switch rest_op.Obj.(type) {
+		case utils.AviRestObjMacro:
+			hm = rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.HealthMonitor)
+		case avimodels.HealthMonitor:
+			hm = rest_op.Obj.(avimodels.HealthMonitor)
+		}
+		hm_cache_obj := avicache.AviHealthMonitorCache{
+			Name:             name,
+			Tenant:           rest_op.Tenant,
+			Uuid:             uuid,
+			CloudConfigCksum: avicache.GetHealthMonitorChecksum(hm),
+		}
+
+		k := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: name}
+		rest.cache.HealthMonitorCache.AviCacheAdd(k, &hm_cache_obj)
+
+		// Update the Pool object
+		if poolKey != (avicache.NamespaceName{}) {
+			pool_cache, ok := rest.cache.PoolCache.AviCacheGet(poolKey)
+			if ok {
+				pool_cache_obj, found := pool_cache.(*avicache.AviPoolCache)
+				if found {
+					pool_cache_obj.HealthMonitorCollection = k
+					utils.AviLog.Infof("Modified the Pool cache object for HealthMonitor Collection. The cache now is :%v", utils.Stringify(pool_cache_obj))
+				}
+			} else {
+				pool_cache_obj := rest.cache.PoolCache.AviCacheAddPool(poolKey)
+				pool_cache_obj.HealthMonitorCollection = k
+				utils.AviLog.Info(spew.Sprintf("Added Pool cache key during HealthMonitor update %v val %v", poolKey,
+					pool_cache_obj))
+			}
+			utils.AviLog.Info(spew.Sprintf("Added HealthMonitor cache k %v val %v", k,
+				hm_cache_obj))
+		}
+	}
+
+	return nil
+}
+
+func (rest *RestOperations) AviHealthMonitorCacheDel(rest_op *utils.RestOp, poolKey avicache.NamespaceName, key string) error {
+	hmKey := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: rest_op.ObjName}
+	rest.cache.HealthMonitorCache.AviCacheDelete(hmKey)
+
+	if poolKey != (avicache.NamespaceName{}) {
+		poolCache, ok := rest.cache.PoolCache.AviCacheGet(poolKey)
+		if ok {
+			if poolCacheObj, found := poolCache.(*avicache.AviPoolCache); found {
+				poolCacheObj.HealthMonitorCollection = avicache.NamespaceName{}
+			}
+		}
+	}
+
+	return nil
+}
@@ -17,8 +17,10 @@ package rest
 import (
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
+	"strings"
 
 	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
@@ -62,7 +64,7 @@ func (rest *RestOperations) AviHttpPSBuild(hps_meta *nodes.AviHttpPolicySetNode,
 		}
 		httpPresentIng.Insert(hppmap.IngName)
 	}
-	sort.Slice(hppmapWithPath, func(i, j int) bool {
+	sort.SliceStable(hppmapWithPath, func(i, j int) bool {
 		return len(hppmapWithPath[i].Path[0]) > len(hppmapWithPath[j].Path[0])
 	})
 	hppmapAllPaths = append(hppmapAllPaths, hppmapWithPath...)
@@ -122,8 +124,11 @@ func (rest *RestOperations) AviHttpPSBuild(hps_meta *nodes.AviHttpPolicySetNode,
 
 		if len(hppmap.Path) > 0 {
 			match_crit := hppmap.MatchCriteria
-			// always match case sensitive
+			// match case sensitive, unless the rule explicitly ignores case
 			match_case := "SENSITIVE"
+			if hppmap.IgnoreCase {
+				match_case = "INSENSITIVE"
+			}
 			path_match := avimodels.PathMatch{
 				MatchCriteria: &match_crit,
 				MatchCase:     &match_case,
@@ -140,28 +145,37 @@ func (rest *RestOperations) AviHttpPSBuild(hps_meta *nodes.AviHttpPolicySetNode,
 			}
 			match_target.VsPort = &vsport_match
 		}
-
-		sw_action := avimodels.HttpswitchingAction{}
-		if hppmap.Pool != "" {
-			action := "HTTP_SWITCHING_SELECT_POOL"
-			sw_action.Action = &action
-			pool_ref := fmt.Sprintf("/api/pool/?name=%s", hppmap.Pool)
-			sw_action.PoolRef = &pool_ref
-		} else if hppmap.PoolGroup != "" {
-			action := "HTTP_SWITCHING_SELECT_POOLGROUP"
-			sw_action.Action = &action
-			pg_ref := fmt.Sprintf("/api/poolgroup/?name=%s", hppmap.PoolGroup)
-			sw_action.PoolGroupRef = &pg_ref
-		}
+		buildHppMapMatchTarget(hppmap, &match_target)
 
 		var j int32
 		j = idx
 		rule := avimodels.HTTPRequestRule{
-			Index:           &j,
-			Enable:          &enable,
-			Name:            &name,
-			Match:           &match_target,
-			SwitchingAction: &sw_action,
+			Index:  &j,
+			Enable: &enable,
+			Name:   &name,
+			Match:  &match_target,
+		}
+
+		if hppmap.Redirect != nil {
+			rule.RedirectAction = buildHppMapRedirectAction(hppmap.Redirect)
+		} else {
+			sw_action := avimodels.HttpswitchingAction{}
+			if hppmap.Pool != "" {
+				action := "HTTP_SWITCHING_SELECT_POOL"
+				sw_action.Action = &action
+				pool_ref := fmt.Sprintf("/api/pool/?name=%s", hppmap.Pool)
+				sw_action.PoolRef = &pool_ref
+			} else if hppmap.PoolGroup != "" {
+				action := "HTTP_SWITCHING_SELECT_POOLGROUP"
+				sw_action.Action = &action
+				pg_ref := fmt.Sprintf("/api/poolgroup/?name=%s", hppmap.PoolGroup)
+				sw_action.PoolGroupRef = &pg_ref
+			}
+			rule.SwitchingAction = &sw_action
+			if hppmap.Rewrite != nil {
+				rule.RewriteURLAction = buildHppMapRewriteAction(hppmap.Rewrite)
+			}
+			rule.HdrAction = buildHppMapHdrActions(hppmap.RequestHeaders)
 		}
 		http_req_pol.Rules = append(http_req_pol.Rules, &rule)
 		idx = idx + 1
@@ -232,6 +246,10 @@ func (rest *RestOperations) AviHttpPSBuild(hps_meta *nodes.AviHttpPolicySetNode,
 
 	}
 
+	for _, inlineRule := range hps_meta.InlineRules {
+		idx = buildInlineRules(&hps, inlineRule, idx, key)
+	}
+
 	var path string
 	var rest_op utils.RestOp
 	if cache_obj != nil {
@@ -403,3 +421,280 @@ func (rest *RestOperations) AviHTTPPolicyCacheDel(rest_op *utils.RestOp, vsKey a
 
 	return nil
 }
+
+// buildInlineRules adds the security, request and response rules for an inline http policy rule of a HostRule
+// to the httppolicyset, and returns the index for the next rule.
+func buildInlineRules(hps *avimodels.HTTPPolicySet, inlineRule nodes.AviHTTPPolicyRule, idx int32, key string) int32 {
+	var pathMatch *avimodels.PathMatch
+	if inlineRule.Path != "" {
+		matchCriteria := "BEGINS_WITH"
+		matchCase := "SENSITIVE"
+		pathMatch = &avimodels.PathMatch{
+			MatchCriteria: &matchCriteria,
+			MatchCase:     &matchCase,
+			MatchStr:      []string{inlineRule.Path},
+		}
+	}
+	enable := true
+	nextRule := func(objType string) (*string, *int32, bool) {
+		name := fmt.Sprintf("%s-%d", *hps.Name, idx)
+		if lib.CheckObjectNameLength(name, objType) {
+			utils.AviLog.Warnf("key: %s not adding rule %s to HTTPS object", key, inlineRule.Name)
+			return nil, nil, false
+		}
+		j := idx
+		idx = idx + 1
+		return &name, &j, true
+	}
+
+	// The requests of the clients outside the allowed IPs, or from the denied IPs, are rejected with a 403.
+	clientIPMatches := map[string][]string{
+		"IS_NOT_IN": inlineRule.AllowedClientIPs,
+		"IS_IN":     inlineRule.DeniedClientIPs,
+	}
+	for _, matchCriteria := range []string{"IS_NOT_IN", "IS_IN"} {
+		if len(clientIPMatches[matchCriteria]) == 0 {
+			continue
+		}
+		name, index, ok := nextRule(lib.HTTPSecurityRule)
+		if !ok {
+			continue
+		}
+		criteria := matchCriteria
+		action := "HTTP_SECURITY_ACTION_SEND_RESPONSE"
+		statusCode := "HTTP_LOCAL_RESPONSE_STATUS_CODE_403"
+		hps.HTTPSecurityPolicy.Rules = append(hps.HTTPSecurityPolicy.Rules, &avimodels.HttpsecurityRule{
+			Name:   name,
+			Index:  index,
+			Enable: &enable,
+			Match: &avimodels.MatchTarget{
+				Path:     pathMatch,
+				ClientIP: &avimodels.IPAddrMatch{MatchCriteria: &criteria, Prefixes: buildIPAddrPrefixes(clientIPMatches[matchCriteria], key)},
+			},
+			Action: &avimodels.HttpsecurityAction{Action: &action, StatusCode: &statusCode},
+		})
+	}
+
+	if inlineRule.RateLimit != nil {
+		if name, index, ok := nextRule(lib.HTTPSecurityRule); ok {
+			action := "HTTP_SECURITY_ACTION_RATE_LIMIT"
+			rateLimitAction := "RL_ACTION_LOCAL_RSP"
+			statusCode := "HTTP_LOCAL_RESPONSE_STATUS_CODE_429"
+			perClientIP := true
+			rateLimiter := &avimodels.RateLimiter{
+				Count:  &inlineRule.RateLimit.Count,
+				Period: &inlineRule.RateLimit.Period,
+			}
+			if inlineRule.RateLimit.BurstSize != 0 {
+				rateLimiter.BurstSz = &inlineRule.RateLimit.BurstSize
+			}
+			match := &avimodels.MatchTarget{Path: pathMatch}
+			hps.HTTPSecurityPolicy.Rules = append(hps.HTTPSecurityPolicy.Rules, &avimodels.HttpsecurityRule{
+				Name:   name,
+				Index:  index,
+				Enable: &enable,
+				Match:  match,
+				Action: &avimodels.HttpsecurityAction{
+					Action: &action,
+					RateProfile: &avimodels.HttpsecurityActionRateProfile{
+						Action:      &avimodels.RateLimiterAction{Type: &rateLimitAction, StatusCode: &statusCode},
+						PerClientIP: &perClientIP,
+						RateLimiter: rateLimiter,
+					},
+				},
+			})
+		}
+	}
+
+	if inlineRule.Redirect != nil || inlineRule.Rewrite != nil || len(inlineRule.RequestHeaders) > 0 {
+		if name, index, ok := nextRule(lib.HTTPRequestRule); ok {
+			rule := &avimodels.HTTPRequestRule{
+				Name:   name,
+				Index:  index,
+				Enable: &enable,
+				Match:  &avimodels.MatchTarget{Path: pathMatch},
+			}
+			if inlineRule.Redirect != nil {
+				rule.RedirectAction = buildHppMapRedirectAction(inlineRule.Redirect)
+			} else {
+				if inlineRule.Rewrite != nil {
+					rule.RewriteURLAction = buildHppMapRewriteAction(inlineRule.Rewrite)
+				}
+				rule.HdrAction = buildHppMapHdrActions(inlineRule.RequestHeaders)
+			}
+			hps.HTTPRequestPolicy.Rules = append(hps.HTTPRequestPolicy.Rules, rule)
+		}
+	}
+
+	if len(inlineRule.ResponseHeaders) > 0 {
+		if name, index, ok := nextRule(lib.HTTPRewriteRule); ok {
+			if hps.HTTPResponsePolicy == nil {
+				hps.HTTPResponsePolicy = &avimodels.HTTPResponsePolicy{}
+			}
+			hps.HTTPResponsePolicy.Rules = append(hps.HTTPResponsePolicy.Rules, &avimodels.HTTPResponseRule{
+				Name:      name,
+				Index:     index,
+				Enable:    &enable,
+				Match:     &avimodels.ResponseMatchTarget{Path: pathMatch},
+				HdrAction: buildHppMapHdrActions(inlineRule.ResponseHeaders),
+			})
+		}
+	}
+	return idx
+}
+
+// buildIPAddrPrefixes converts the IPs and CIDRs to ip address prefixes, skipping the invalid ones.
+func buildIPAddrPrefixes(cidrs []string, key string) []*avimodels.IPAddrPrefix {
+	var prefixes []*avimodels.IPAddrPrefix
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr = cidr + "/32"
+			} else {
+				cidr = cidr + "/128"
+			}
+		}
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			utils.AviLog.Warnf("key: %s, msg: skipping invalid CIDR %s", key, cidr)
+			continue
+		}
+		addr := ipNet.IP.String()
+		addrType := "V4"
+		if ip.To4() == nil {
+			addrType = "V6"
+		}
+		mask, _ := ipNet.Mask.Size()
+		maskLen := int32(mask)
+		prefixes = append(prefixes, &avimodels.IPAddrPrefix{
+			IPAddr: &avimodels.IPAddr{Addr: &addr, Type: &addrType},
+			Mask:   &maskLen,
+		})
+	}
+	return prefixes
+}
+
+// buildHppMapMatchTarget adds the host header, header, query and method
+// match conditions of the hppmap to the match target.
+func buildHppMapMatchTarget(hppmap nodes.AviHostPathPortPoolPG, match_target *avimodels.MatchTarget) {
+	if hppmap.HostHeader != nil && len(hppmap.HostHeader.Values) > 0 {
+		match_crit := hppmap.HostHeader.MatchCriteria
+		match_target.HostHdr = &avimodels.HostHdrMatch{
+			MatchCriteria: &match_crit,
+			Value:         hppmap.HostHeader.Values,
+		}
+	}
+
+	for _, header := range hppmap.Headers {
+		hdr := header.Name
+		match_crit := header.MatchCriteria
+		match_case := "SENSITIVE"
+		match_target.Hdrs = append(match_target.Hdrs, &avimodels.HdrMatch{
+			Hdr:           &hdr,
+			MatchCriteria: &match_crit,
+			MatchCase:     &match_case,
+			Value:         header.Values,
+		})
+	}
+
+	if hppmap.Cookie != nil && len(hppmap.Cookie.Values) > 0 {
+		name := hppmap.Cookie.Name
+		match_crit := hppmap.Cookie.MatchCriteria
+		match_case := "SENSITIVE"
+		value := hppmap.Cookie.Values[0]
+		match_target.Cookie = &avimodels.CookieMatch{
+			Name:          &name,
+			MatchCriteria: &match_crit,
+			MatchCase:     &match_case,
+			Value:         &value,
+		}
+	}
+
+	if len(hppmap.Query) > 0 {
+		match_crit := "QUERY_MATCH_CONTAINS"
+		match_target.Query = &avimodels.QueryMatch{
+			MatchCriteria: &match_crit,
+			MatchStr:      hppmap.Query,
+		}
+	}
+
+	if len(hppmap.Methods) > 0 {
+		match_crit := "IS_IN"
+		match_target.Method = &avimodels.MethodMatch{
+			MatchCriteria: &match_crit,
+			Methods:       hppmap.Methods,
+		}
+	}
+}
+
+func buildHppMapRedirectAction(redirect *nodes.AviHTTPRedirect) *avimodels.HTTPRedirectAction {
+	protocol := redirect.Protocol
+	statusCode := redirect.StatusCode
+	keepQuery := true
+	redirect_action := &avimodels.HTTPRedirectAction{
+		Protocol:   &protocol,
+		StatusCode: &statusCode,
+		KeepQuery:  &keepQuery,
+	}
+	if redirect.Port != 0 {
+		port := redirect.Port
+		redirect_action.Port = &port
+	}
+	if redirect.Host != "" {
+		redirect_action.Host = buildStringURIParam(redirect.Host)
+	}
+	if redirect.Path != "" {
+		redirect_action.Path = buildStringURIParam(strings.TrimPrefix(redirect.Path, "/"))
+	}
+	return redirect_action
+}
+
+func buildHppMapRewriteAction(rewrite *nodes.AviHTTPRewrite) *avimodels.HTTPRewriteURLAction {
+	rewrite_action := &avimodels.HTTPRewriteURLAction{}
+	if rewrite.Host != "" {
+		rewrite_action.HostHdr = buildStringURIParam(rewrite.Host)
+	}
+	if rewrite.Path != "" || rewrite.KeepPathSuffix {
+		// Avi path tokens do not carry the leading and trailing slashes.
+		path := strings.Trim(rewrite.Path, "/")
+		paramType := "URI_PARAM_TYPE_TOKENIZED"
+		pathParam := &avimodels.URIParam{Type: &paramType}
+		if path != "" {
+			tokenType := "URI_TOKEN_TYPE_STRING"
+			pathParam.Tokens = append(pathParam.Tokens, &avimodels.URIParamToken{Type: &tokenType, StrValue: &path})
+		}
+		if rewrite.KeepPathSuffix {
+			tokenType := "URI_TOKEN_TYPE_PATH"
+			startIndex := rewrite.PathSuffixIndex
+			endIndex := int32(65535)
+			pathParam.Tokens = append(pathParam.Tokens, &avimodels.URIParamToken{Type: &tokenType, StartIndex: &startIndex, EndIndex: &endIndex})
+		}
+		rewrite_action.Path = pathParam
+	}
+	if rewrite_action.HostHdr == nil && rewrite_action.Path == nil {
+		return nil
+	}
+	return rewrite_action
+}
+
+func buildHppMapHdrActions(headers []nodes.AviHTTPHeaderAction) []*avimodels.HTTPHdrAction {
+	var hdrActions []*avimodels.HTTPHdrAction
+	for i := range headers {
+		action := headers[i].Action
+		hdrData := &avimodels.HTTPHdrData{Name: &headers[i].Name}
+		if headers[i].Value != "" {
+			hdrData.Value = &avimodels.HTTPHdrValue{Val: &headers[i].Value}
+		}
+		hdrActions = append(hdrActions, &avimodels.HTTPHdrAction{Action: &action, Hdr: hdrData})
+	}
+	return hdrActions
+}
+
+func buildStringURIParam(value string) *avimodels.URIParam {
+	paramType := "URI_PARAM_TYPE_TOKENIZED"
+	tokenType := "URI_TOKEN_TYPE_STRING"
+	return &avimodels.URIParam{
+		Type:   &paramType,
+		Tokens: []*avimodels.URIParamToken{{Type: &tokenType, StrValue: &value}},
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2019-2020 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	avimodels "github.com/vmware/alb-sdk/go/models"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func (rest *RestOperations) AviPersistenceProfileBuild(persistence_node *nodes.AviPersistenceProfileNode, cache_obj *avicache.AviPersistenceProfileCache) *utils.RestOp {
+	if lib.CheckObjectNameLength(persistence_node.Name, lib.L4PersistenceProfile) {
+		utils.AviLog.Warnf("Not processing persistence profile")
+		return nil
+	}
+	tenant := fmt.Sprintf("/api/tenant/?name=%s", persistence_node.Tenant)
+	name := persistence_node.Name
+	persistenceType := "PERSISTENCE_TYPE_CLIENT_IP_ADDRESS"
+	timeout := persistence_node.Timeout
+
+	profile := avimodels.ApplicationPersistenceProfile{
+		Name:            &name,
+		TenantRef:       &tenant,
+		PersistenceType: &persistenceType,
+		IPPersistenceProfile: &avimodels.IPPersistenceProfile{
+			IPPersistentTimeout: &timeout,
+		},
+	}
+	profile.Markers = lib.GetAllMarkers(persistence_node.AviMarkers)
+
+	var path string
+	var rest_op utils.RestOp
+	if cache_obj != nil {
+		path = "/api/applicationpersistenceprofile/" + cache_obj.Uuid
+		rest_op = utils.RestOp{
+			ObjName: persistence_node.Name,
+			Path:    path,
+			Method:  utils.RestPut,
+			Obj:     profile,
+			Tenant:  persistence_node.Tenant,
+			Model:   "ApplicationPersistenceProfile",
+		}
+	} else {
+		path = "/api/applicationpersistenceprofile/"
+		rest_op = utils.RestOp{
+			ObjName: persistence_node.Name,
+			Path:    path,
+			Method:  utils.RestPost,
+			Obj:     profile,
+			Tenant:  persistence_node.Tenant,
+			Model:   "ApplicationPersistenceProfile",
+		}
+	}
+	return &rest_op
+}
+
+func (rest *RestOperations) AviPersistenceProfileDel(uuid string, tenant string) *utils.RestOp {
+	path := "/api/applicationpersistenceprofile/" + uuid
+	rest_op := utils.RestOp{
+		Path:   path,
+		Method: "DELETE",
+		Tenant: tenant,
+		Model:  "ApplicationPersistenceProfile",
+	}
+	utils.AviLog.Info(spew.Sprintf("ApplicationPersistenceProfile DELETE Restop %v ",
+		utils.Stringify(rest_op)))
+	return &rest_op
+}
+
+func (rest *RestOperations) AviPersistenceProfileAdd(rest_op *utils.RestOp, poolKey avicache.NamespaceName, key string) error {
+	if (rest_op.Err != nil) || (rest_op.Response == nil) {
+		utils.AviLog.Warnf("rest_op has err or no response for ApplicationPersistenceProfile")
+		return errors.New("Errored rest_op")
+	}
+
+	resp_elems := RestRespArrToObjByType(rest_op, "applicationpersistenceprofile", key)
+	if resp_elems == nil {
+		utils.AviLog.Warnf("Unable to find ApplicationPersistenceProfile obj in resp %v", rest_op.Response)
+		return errors.New("ApplicationPersistenceProfile not found")
+	}
+
+	for _, resp := range resp_elems {
+		name, ok := resp["name"].(string)
+		if !ok {
+			utils.AviLog.Warnf("Name not present in response %v", resp)
+			continue
+		}
+
+		uuid, ok := resp["uuid"].(string)
+		if !ok {
+			utils.AviLog.Warnf("Uuid not present in response %v", resp)
+			continue
+		}
+
+		var profile avimodels.ApplicationPersistenceProfile
+		switch rest_op.Obj.(type) {
+		case utils.AviRestObjMacro:
+			profile = rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.ApplicationPersistenceProfile)
+		case avimodels.ApplicationPersistenceProfile:
+			profile = rest_op.Obj.(avimodels.ApplicationPersistenceProfile)
+		}
+		var timeout int32
+		if profile.IPPersistenceProfile != nil && profile.IPPersistenceProfile.IPPersistentTimeout != nil {
+			timeout = *profile.IPPersistenceProfile.IPPersistentTimeout
+		}
+		emptyIngestionMarkers := utils.AviObjectMarkers{}
+		persistence_cache_obj := avicache.AviPersistenceProfileCache{
+			Name:             name,
+			Tenant:           rest_op.Tenant,
+			Uuid:             uuid,
+			CloudConfigCksum: lib.PersistenceProfileChecksum(timeout, emptyIngestionMarkers, profile.Markers, true),
+		}
+
+		k := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: name}
+		rest.cache.PersistenceCache.AviCacheAdd(k, &persistence_cache_obj)
+
+		// Update the Pool object
+		if poolKey != (avicache.NamespaceName{}) {
+			pool_cache, ok := rest.cache.PoolCache.AviCacheGet(poolKey)
+			if ok {
+				pool_cache_obj, found := pool_cache.(*avicache.AviPoolCache)
+				if found {
+					pool_cache_obj.PersistenceProfileCollection = k
+					utils.AviLog.Infof("Modified the Pool cache object for PersistenceProfile Collection. The cache now is :%v", utils.Stringify(pool_cache_obj))
+				}
+			} else {
+				pool_cache_obj := rest.cache.PoolCache.AviCacheAddPool(poolKey)
+				pool_cache_obj.PersistenceProfileCollection = k
+				utils.AviLog.Info(spew.Sprintf("Added Pool cache key during PersistenceProfile update %v val %v", poolKey,
+					pool_cache_obj))
+			}
+			utils.AviLog.Info(spew.Sprintf("Added PersistenceProfile cache k %v val %v", k,
+				persistence_cache_obj))
+		}
+	}
+
+	return nil
+}
+
+func (rest *RestOperations) AviPersistenceProfileCacheDel(rest_op *utils.RestOp, poolKey avicache.NamespaceName, key string) error {
+	persistenceKey := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: rest_op.ObjName}
+	rest.cache.PersistenceCache.AviCacheDelete(persistenceKey)
+
+	if poolKey != (avicache.NamespaceName{}) {
+		poolCache, ok := rest.cache.PoolCache.AviCacheGet(poolKey)
+		if ok {
+			if poolCacheObj, found := poolCache.(*avicache.AviPoolCache); found {
+				poolCacheObj.PersistenceProfileCollection = avicache.NamespaceName{}
+			}
+		}
+	}
+
+	return nil
+}
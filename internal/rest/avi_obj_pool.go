@@ -136,6 +136,10 @@ func (rest *RestOperations) AviPoolBuild(pool_meta *nodes.AviPoolNode, cache_obj
 	if pool_meta.ApplicationPersistence != "" {
 		pool.ApplicationPersistenceProfileRef = &pool_meta.ApplicationPersistence
 	}
+	if pool_meta.PersistenceProfile != nil {
+		persistenceProfileName := "/api/applicationpersistenceprofile?name=" + pool_meta.PersistenceProfile.Name
+		pool.ApplicationPersistenceProfileRef = &persistenceProfileName
+	}
 
 	for i, server := range pool_meta.Servers {
 		port := pool_meta.Port
@@ -149,13 +153,28 @@ func (rest *RestOperations) AviPoolBuild(pool_meta *nodes.AviPoolNode, cache_obj
 			sn := server.ServerNode
 			s.ServerNode = &sn
 		}
+		if server.Ratio != 0 {
+			ratio := server.Ratio
+			s.Ratio = &ratio
+		}
+		if server.Draining {
+			s.Enabled = proto.Bool(false)
+		}
 		pool.Servers = append(pool.Servers, &s)
 	}
 
+	// the Service Engines keep the connections to the disabled servers till the drain timeout
+	if lib.IsServerDrainEnabled() {
+		pool.GracefulDisableTimeout = proto.Int32(lib.GetGracefulDisableTimeout())
+	}
+
 	// overwrite with healthmonitors provided by CRD
 	if len(pool_meta.HealthMonitors) > 0 {
 		pool.HealthMonitorRefs = pool_meta.HealthMonitors
-	} else {
+	} else if pool_meta.ProbeHealthMonitor != nil {
+		pool.HealthMonitorRefs = []string{"/api/healthmonitor?name=" + pool_meta.ProbeHealthMonitor.Name}
+	} else if pool_meta.Protocol != utils.SCTP {
+		// The servers of the SCTP pools are not health monitored by default, as there is no system SCTP health monitor.
 		var hm string
 		if pool_meta.Protocol == utils.UDP {
 			hm = fmt.Sprintf("/api/healthmonitor/?name=%s", utils.AVI_DEFAULT_UDP_HM)
@@ -211,6 +230,100 @@ func (rest *RestOperations) AviPoolBuild(pool_meta *nodes.AviPoolNode, cache_obj
 	return &rest_op
 }
 
+// AviPoolUpdateBuild returns the rest operations to update a pool present in the cache. When only the servers
+// of the pool changed, the removed servers are deleted and the new or modified servers are added with PATCH calls,
+// as the controller updates the servers already present in the pool in place. Otherwise the pool is updated with a PUT.
+func (rest *RestOperations) AviPoolUpdateBuild(pool_meta *nodes.AviPoolNode, cache_obj *avicache.AviPoolCache, key string) []*utils.RestOp {
+	restOp := rest.AviPoolBuild(pool_meta, cache_obj, key)
+	if restOp == nil {
+		return nil
+	}
+	pool, ok := restOp.Obj.(avimodels.Pool)
+	if !ok || cache_obj.Servers == nil || cache_obj.NonServerCksum == "" ||
+		cache_obj.NonServerCksum != getPoolNonServerCksum(pool) {
+		return []*utils.RestOp{restOp}
+	}
+
+	var addServers, deleteServers []*avimodels.Server
+	poolServers := make(map[string]bool, len(pool.Servers))
+	for _, server := range pool.Servers {
+		serverKey := avicache.PoolServerKey(server)
+		poolServers[serverKey] = true
+		if cksum, found := cache_obj.Servers[serverKey]; !found || cksum != avicache.PoolServerCksum(server) {
+			addServers = append(addServers, server)
+		}
+	}
+	for serverKey := range cache_obj.Servers {
+		if poolServers[serverKey] {
+			continue
+		}
+		server := getPoolServerFromKey(serverKey)
+		if server == nil {
+			utils.AviLog.Warnf("key: %s, msg: invalid server %s in the cache of pool %s, operation: PUT", key, serverKey, pool_meta.Name)
+			return []*utils.RestOp{restOp}
+		}
+		deleteServers = append(deleteServers, server)
+	}
+
+	var restOps []*utils.RestOp
+	if len(deleteServers) > 0 {
+		restOps = append(restOps, &utils.RestOp{
+			ObjName: pool_meta.Name,
+			Path:    restOp.Path,
+			Method:  utils.RestPatch,
+			PatchOp: utils.PatchDeleteOp,
+			Obj:     map[string]interface{}{"servers": deleteServers},
+			Tenant:  pool_meta.Tenant,
+			Model:   "Pool",
+		})
+	}
+	// The checksum is updated along with the added servers, after the removed servers are deleted.
+	patchPayload := map[string]interface{}{"cloud_config_cksum": *pool.CloudConfigCksum}
+	if len(addServers) > 0 {
+		patchPayload["servers"] = addServers
+	}
+	restOps = append(restOps, &utils.RestOp{
+		ObjName: pool_meta.Name,
+		Path:    restOp.Path,
+		Method:  utils.RestPatch,
+		PatchOp: utils.PatchAddOp,
+		Obj:     patchPayload,
+		Tenant:  pool_meta.Tenant,
+		Model:   "Pool",
+	})
+	utils.AviLog.Infof("key: %s, msg: pool %s servers to add: %d, servers to delete: %d, operation: PATCH", key,
+		pool_meta.Name, len(addServers), len(deleteServers))
+	return restOps
+}
+
+// getPoolNonServerCksum returns the checksum of the pool fields other than the servers and the cloud config checksum.
+func getPoolNonServerCksum(pool avimodels.Pool) string {
+	pool.Servers = nil
+	pool.CloudConfigCksum = nil
+	return strconv.Itoa(int(utils.Hash(utils.Stringify(pool))))
+}
+
+// getPoolServerFromKey returns the server with the ip and port in the ip:port key, used to delete the server from the pool.
+func getPoolServerFromKey(serverKey string) *avimodels.Server {
+	idx := strings.LastIndex(serverKey, ":")
+	if idx <= 0 {
+		return nil
+	}
+	addr := serverKey[:idx]
+	port, err := strconv.ParseInt(serverKey[idx+1:], 10, 32)
+	if err != nil {
+		return nil
+	}
+	atype := "V4"
+	if !utils.IsV4(addr) {
+		atype = "V6"
+	}
+	return &avimodels.Server{
+		IP:   &avimodels.IPAddr{Addr: proto.String(addr), Type: proto.String(atype)},
+		Port: proto.Int32(int32(port)),
+	}
+}
+
 func (rest *RestOperations) AviPoolDel(uuid string, tenant string, key string) *utils.RestOp {
 	path := "/api/pool/" + uuid
 	rest_op := utils.RestOp{
@@ -229,6 +342,10 @@ func (rest *RestOperations) AviPoolCacheAdd(rest_op *utils.RestOp, vsKey avicach
 		return errors.New("Errored rest_op")
 	}
 
+	if rest_op.Method == utils.RestPatch {
+		return rest.AviPoolCachePatch(rest_op, vsKey, key)
+	}
+
 	resp_elems := RestRespArrToObjByType(rest_op, "pool", key)
 	utils.AviLog.Debugf("key: %s, msg: the pool object response %v", key, rest_op.Response)
 	if resp_elems == nil {
@@ -278,6 +395,41 @@ func (rest *RestOperations) AviPoolCacheAdd(rest_op *utils.RestOp, vsKey avicach
 			}
 		}
 
+		var persistenceKey avicache.NamespaceName
+		if persistenceProf, ok := resp["application_persistence_profile_ref"]; ok && persistenceProf != "" {
+			persistenceUuid := avicache.ExtractUuid(persistenceProf.(string), "applicationpersistenceprofile-.*.#")
+			persistenceName, foundPersistence := rest.cache.PersistenceCache.AviCacheGetNameByUuid(persistenceUuid)
+			if foundPersistence {
+				persistenceKey = avicache.NamespaceName{Namespace: lib.GetTenant(), Name: persistenceName.(string)}
+			} else if refParts := strings.Split(persistenceProf.(string), "?name="); len(refParts) == 2 {
+				// The profile is referred by its name in the request.
+				k := avicache.NamespaceName{Namespace: lib.GetTenant(), Name: refParts[1]}
+				if _, ok := rest.cache.PersistenceCache.AviCacheGet(k); ok {
+					persistenceKey = k
+				}
+			}
+		}
+
+		var hmKey avicache.NamespaceName
+		if hmRefs, ok := resp["health_monitor_refs"].([]interface{}); ok {
+			for _, hmRef := range hmRefs {
+				hmRefStr, ok := hmRef.(string)
+				if !ok {
+					continue
+				}
+				hmUuid := avicache.ExtractUuid(hmRefStr, "healthmonitor-.*.#")
+				if hmName, foundHM := rest.cache.HealthMonitorCache.AviCacheGetNameByUuid(hmUuid); foundHM {
+					hmKey = avicache.NamespaceName{Namespace: lib.GetTenant(), Name: hmName.(string)}
+				} else if refParts := strings.Split(hmRefStr, "?name="); len(refParts) == 2 {
+					// The health monitor is referred by its name in the request.
+					k := avicache.NamespaceName{Namespace: lib.GetTenant(), Name: refParts[1]}
+					if _, ok := rest.cache.HealthMonitorCache.AviCacheGet(k); ok {
+						hmKey = k
+					}
+				}
+			}
+		}
+
 		k := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: name}
 		oldCacheServiceMetadataCRD := lib.CRDMetadata{}
 		if poolCache, ok := rest.cache.PoolCache.AviCacheGet(k); ok {
@@ -287,13 +439,19 @@ func (rest *RestOperations) AviPoolCacheAdd(rest_op *utils.RestOp, vsKey avicach
 		}
 
 		pool_cache_obj := avicache.AviPoolCache{
-			Name:                 name,
-			Tenant:               rest_op.Tenant,
-			Uuid:                 uuid,
-			CloudConfigCksum:     cksum,
-			ServiceMetadataObj:   svc_mdata_obj,
-			PkiProfileCollection: pkiKey,
-			LastModified:         lastModifiedStr,
+			Name:                         name,
+			Tenant:                       rest_op.Tenant,
+			Uuid:                         uuid,
+			CloudConfigCksum:             cksum,
+			ServiceMetadataObj:           svc_mdata_obj,
+			PkiProfileCollection:         pkiKey,
+			LastModified:                 lastModifiedStr,
+			PersistenceProfileCollection: persistenceKey,
+			HealthMonitorCollection:      hmKey,
+		}
+		if pool, ok := rest_op.Obj.(avimodels.Pool); ok {
+			pool_cache_obj.Servers = avicache.GetPoolServers(pool.Servers)
+			pool_cache_obj.NonServerCksum = getPoolNonServerCksum(pool)
 		}
 		if lastModifiedStr == "" {
 			pool_cache_obj.InvalidData = true
@@ -304,65 +462,120 @@ func (rest *RestOperations) AviPoolCacheAdd(rest_op *utils.RestOp, vsKey avicach
 			status.HttpRuleEventBroadcast(k.Name, oldCacheServiceMetadataCRD, svc_mdata_obj.CRDStatus)
 		}
 
-		// Update the VS object
-		vs_cache, ok := rest.cache.VsCacheMeta.AviCacheGet(vsKey)
-		if ok {
-			vs_cache_obj, found := vs_cache.(*avicache.AviVsCache)
-			if found {
-				vs_cache_obj.AddToPoolKeyCollection(k)
-				utils.AviLog.Debugf("key: %s, msg: modified the VS cache object for Pool Collection. The cache now is :%v", key, utils.Stringify(vs_cache_obj))
-				IPAddrs := rest.GetIPAddrsFromCache(vs_cache_obj)
-				if len(IPAddrs) == 0 {
-					utils.AviLog.Warnf("key: %s, msg: Unable to find VIP corresponding to Pool %s vsCache %v", key, pool_cache_obj.Name, utils.Stringify(vs_cache_obj))
-				} else {
-					switch pool_cache_obj.ServiceMetadataObj.ServiceMetadataMapping("Pool") {
-					case lib.GatewayPool:
-						updateOptions := status.UpdateOptions{
-							Vip:                IPAddrs,
-							ServiceMetadata:    svc_mdata_obj,
-							Key:                key,
-							VirtualServiceUUID: vs_cache_obj.Uuid,
-							VSName:             vs_cache_obj.Name,
-						}
-						statusOption := status.StatusOptions{
-							ObjType: utils.L4LBService,
-							Op:      lib.UpdateStatus,
-							Options: &updateOptions,
-						}
-						utils.AviLog.Infof("key: %s Publishing to status queue, options: %v", updateOptions.ServiceMetadata.NamespaceServiceName[0], utils.Stringify(statusOption))
-						status.PublishToStatusQueue(updateOptions.ServiceMetadata.NamespaceServiceName[0], statusOption)
-					case lib.SNIInsecureOrEVHPool:
-						updateOptions := status.UpdateOptions{
-							Vip:                IPAddrs,
-							ServiceMetadata:    svc_mdata_obj,
-							Key:                key,
-							VirtualServiceUUID: vs_cache_obj.Uuid,
-							VSName:             vs_cache_obj.Name,
-						}
-						statusOption := status.StatusOptions{
-							ObjType: utils.Ingress,
-							Op:      lib.UpdateStatus,
-							Options: &updateOptions,
-						}
-						if utils.GetInformers().RouteInformer != nil {
-							statusOption.ObjType = utils.OshiftRoute
-						}
-						if pool_cache_obj.ServiceMetadataObj.IsMCIIngress {
-							statusOption.ObjType = lib.MultiClusterIngress
-						}
-						utils.AviLog.Debugf("key: %s Publishing to status queue, options: %v", updateOptions.ServiceMetadata.IngressName, utils.Stringify(statusOption))
-						status.PublishToStatusQueue(updateOptions.ServiceMetadata.IngressName, statusOption)
+		rest.updatePoolVsCache(&pool_cache_obj, k, vsKey, key)
+		utils.AviLog.Infof("key: %s, msg: Added Pool cache k %v val %v", key, k, utils.Stringify(pool_cache_obj))
+	}
+
+	return nil
+}
+
+// updatePoolVsCache adds the pool to the pool collection of the VS cache, and publishes the status of the objects of the pool.
+func (rest *RestOperations) updatePoolVsCache(pool_cache_obj *avicache.AviPoolCache, k, vsKey avicache.NamespaceName, key string) {
+	// Update the VS object
+	vs_cache, ok := rest.cache.VsCacheMeta.AviCacheGet(vsKey)
+	if ok {
+		vs_cache_obj, found := vs_cache.(*avicache.AviVsCache)
+		if found {
+			vs_cache_obj.AddToPoolKeyCollection(k)
+			utils.AviLog.Debugf("key: %s, msg: modified the VS cache object for Pool Collection. The cache now is :%v", key, utils.Stringify(vs_cache_obj))
+			IPAddrs := rest.GetIPAddrsFromCache(vs_cache_obj)
+			if len(IPAddrs) == 0 {
+				utils.AviLog.Warnf("key: %s, msg: Unable to find VIP corresponding to Pool %s vsCache %v", key, pool_cache_obj.Name, utils.Stringify(vs_cache_obj))
+			} else {
+				switch pool_cache_obj.ServiceMetadataObj.ServiceMetadataMapping("Pool") {
+				case lib.GatewayPool:
+					updateOptions := status.UpdateOptions{
+						Vip:                IPAddrs,
+						ServiceMetadata:    pool_cache_obj.ServiceMetadataObj,
+						Key:                key,
+						VirtualServiceUUID: vs_cache_obj.Uuid,
+						VSName:             vs_cache_obj.Name,
+					}
+					statusOption := status.StatusOptions{
+						ObjType: utils.L4LBService,
+						Op:      lib.UpdateStatus,
+						Options: &updateOptions,
+					}
+					utils.AviLog.Infof("key: %s Publishing to status queue, options: %v", updateOptions.ServiceMetadata.NamespaceServiceName[0], utils.Stringify(statusOption))
+					status.PublishToStatusQueue(updateOptions.ServiceMetadata.NamespaceServiceName[0], statusOption)
+				case lib.SNIInsecureOrEVHPool:
+					updateOptions := status.UpdateOptions{
+						Vip:                IPAddrs,
+						ServiceMetadata:    pool_cache_obj.ServiceMetadataObj,
+						Key:                key,
+						VirtualServiceUUID: vs_cache_obj.Uuid,
+						VSName:             vs_cache_obj.Name,
+					}
+					statusOption := status.StatusOptions{
+						ObjType: utils.Ingress,
+						Op:      lib.UpdateStatus,
+						Options: &updateOptions,
 					}
+					if utils.GetInformers().RouteInformer != nil {
+						statusOption.ObjType = utils.OshiftRoute
+					}
+					if pool_cache_obj.ServiceMetadataObj.IsMCIIngress {
+						statusOption.ObjType = lib.MultiClusterIngress
+					}
+					utils.AviLog.Debugf("key: %s Publishing to status queue, options: %v", updateOptions.ServiceMetadata.IngressName, utils.Stringify(statusOption))
+					status.PublishToStatusQueue(updateOptions.ServiceMetadata.IngressName, statusOption)
 				}
 			}
-		} else {
-			vs_cache_obj := rest.cache.VsCacheMeta.AviCacheAddVS(vsKey)
-			vs_cache_obj.AddToPoolKeyCollection(k)
-			utils.AviLog.Debugf("key: %s, msg: added VS cache key during pool update %v val %v", key, vsKey, utils.Stringify(vs_cache_obj))
 		}
-		utils.AviLog.Infof("key: %s, msg: Added Pool cache k %v val %v", key, k, utils.Stringify(pool_cache_obj))
+	} else {
+		vs_cache_obj := rest.cache.VsCacheMeta.AviCacheAddVS(vsKey)
+		vs_cache_obj.AddToPoolKeyCollection(k)
+		utils.AviLog.Debugf("key: %s, msg: added VS cache key during pool update %v val %v", key, vsKey, utils.Stringify(vs_cache_obj))
+	}
+}
+
+// AviPoolCachePatch updates the servers and the checksum of the cached pool as per the PATCH call made for the pool.
+func (rest *RestOperations) AviPoolCachePatch(rest_op *utils.RestOp, vsKey avicache.NamespaceName, key string) error {
+	k := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: rest_op.ObjName}
+	poolCache, found := rest.cache.PoolCache.AviCacheGet(k)
+	if !found {
+		utils.AviLog.Warnf("key: %s, msg: pool %s not found in cache after PATCH", key, k.Name)
+		return errors.New("pool not found")
+	}
+	poolCacheObj, ok := poolCache.(*avicache.AviPoolCache)
+	if !ok {
+		utils.AviLog.Warnf("key: %s, msg: pool cache object for %s is of invalid type", key, k.Name)
+		return errors.New("invalid pool cache object")
+	}
+	patchPayload, ok := rest_op.Obj.(map[string]interface{})
+	if !ok {
+		return errors.New("invalid pool patch payload")
 	}
 
+	servers := make(map[string]uint32, len(poolCacheObj.Servers))
+	for serverKey, cksum := range poolCacheObj.Servers {
+		servers[serverKey] = cksum
+	}
+	patchServers, _ := patchPayload["servers"].([]*avimodels.Server)
+	for _, server := range patchServers {
+		if rest_op.PatchOp == utils.PatchDeleteOp {
+			delete(servers, avicache.PoolServerKey(server))
+		} else {
+			servers[avicache.PoolServerKey(server)] = avicache.PoolServerCksum(server)
+		}
+	}
+	// The cache entry is replaced instead of being updated in place, as it is read by the drift scanner.
+	patchedPool := *poolCacheObj
+	patchedPool.Servers = servers
+	if cksum, ok := patchPayload["cloud_config_cksum"].(string); ok {
+		patchedPool.CloudConfigCksum = cksum
+	}
+	if resp, ok := rest_op.Response.(map[string]interface{}); ok {
+		if lastModified, ok := resp["_last_modified"].(string); ok {
+			patchedPool.LastModified = lastModified
+		}
+	}
+	rest.cache.PoolCache.AviCacheAdd(k, &patchedPool)
+	// The add PATCH call is the last call made for the pool.
+	if rest_op.PatchOp == utils.PatchAddOp {
+		rest.updatePoolVsCache(&patchedPool, k, vsKey, key)
+	}
+	utils.AviLog.Infof("key: %s, msg: patched Pool cache k %v val %v", key, k, utils.Stringify(patchedPool))
 	return nil
 }
 
@@ -386,6 +599,7 @@ func (rest *RestOperations) AviPoolCacheDel(rest_op *utils.RestOp, vsKey avicach
 		}
 	}
 	rest.cache.PoolCache.AviCacheDelete(poolKey)
+	nodes.RemovePoolDrainServers(poolKey.Name)
 	if (cacheServiceMetadataCRD != lib.CRDMetadata{}) {
 		status.HttpRuleEventBroadcast(poolKey.Name, cacheServiceMetadataCRD, lib.CRDMetadata{})
 	}
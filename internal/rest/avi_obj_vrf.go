@@ -17,7 +17,6 @@ package rest
 import (
 	"encoding/json"
 	"errors"
-	"strings"
 
 	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
@@ -53,42 +52,60 @@ func (rest *RestOperations) AviVrfGet(key, uuid, name string) *avimodels.VrfCont
 	return &vrf
 }
 
-func (rest *RestOperations) AviVrfBuild(key string, vrfNode *nodes.AviVrfNode, uuid string) *utils.RestOp {
-	vrfCacheObj := rest.getVrfCacheObj(vrfNode.Name)
-	if vrfCacheObj == nil {
-		return nil
-	}
-	vrf := rest.AviVrfGet(key, vrfCacheObj.Uuid, vrfCacheObj.Name)
-	if vrf == nil {
-		return nil
-	}
+// AviVrfBuild builds the PATCH operations for the static routes of the cluster that changed since the
+// last sync. The added and updated routes are added first, an updated route keeps its route id and is
+// replaced in place, and the stale routes are deleted after that, so that there is no window in which a
+// node has no route. The static routes of the other nodes and the routes not owned by the cluster are
+// left untouched. The routes created by the earlier releases keep their legacy route ids in the model, so
+// they are not added again for the same prefix after an upgrade.
+func (rest *RestOperations) AviVrfBuild(key string, vrfNode *nodes.AviVrfNode, vrfCacheObj *avicache.AviVrfCache) []*utils.RestOp {
 	path := "/api/vrfcontext/" + vrfCacheObj.Uuid
 
-	nodeStaticRoutes := vrfNode.StaticRoutes
-	aviStaticRoutes := vrf.StaticRoutes
-	clusterStaticRoutes := []*avimodels.StaticRoute{}
-	mergedStaticRoutes := []*avimodels.StaticRoute{}
-	clusterName := lib.GetClusterName()
-
-	for _, aviStaticRoute := range aviStaticRoutes {
-		if strings.HasPrefix(*aviStaticRoute.RouteID, clusterName) {
-			clusterStaticRoutes = append(clusterStaticRoutes, aviStaticRoute)
-		} else {
-			mergedStaticRoutes = append(mergedStaticRoutes, aviStaticRoute)
+	nodeStaticRoutes := lib.GetStaticRouteChecksums(vrfNode.StaticRoutes)
+	var addStaticRoutes []*avimodels.StaticRoute
+	for _, staticRoute := range vrfNode.StaticRoutes {
+		if checksum, ok := vrfCacheObj.StaticRoutes[*staticRoute.RouteID]; !ok || checksum != nodeStaticRoutes[*staticRoute.RouteID] {
+			addStaticRoutes = append(addStaticRoutes, staticRoute)
+		}
+	}
+	deleteRouteIDs := make(map[string]bool)
+	for routeID := range vrfCacheObj.StaticRoutes {
+		if _, ok := nodeStaticRoutes[routeID]; !ok {
+			deleteRouteIDs[routeID] = true
 		}
 	}
 
-	patchOp := utils.PatchReplaceOp
-	patchPayload := make(map[string]interface{})
-	if len(nodeStaticRoutes) == 0 {
-		// this is the case of deleting all the static routes for the cluster
-		patchOp = utils.PatchDeleteOp
-		patchPayload["static_routes"] = clusterStaticRoutes
-
-	} else {
-		patchOp = utils.PatchReplaceOp
-		mergedStaticRoutes = append(mergedStaticRoutes, nodeStaticRoutes...)
-		patchPayload["static_routes"] = mergedStaticRoutes
+	var deleteStaticRoutes []*avimodels.StaticRoute
+	if len(deleteRouteIDs) > 0 {
+		// The cache holds only the checksums of the routes, so the routes to be deleted are read from the controller.
+		vrf := rest.AviVrfGet(key, vrfCacheObj.Uuid, vrfCacheObj.Name)
+		if vrf == nil {
+			return nil
+		}
+		for _, aviStaticRoute := range vrf.StaticRoutes {
+			if aviStaticRoute.RouteID != nil && deleteRouteIDs[*aviStaticRoute.RouteID] {
+				deleteStaticRoutes = append(deleteStaticRoutes, aviStaticRoute)
+				delete(deleteRouteIDs, *aviStaticRoute.RouteID)
+			}
+		}
+		if len(deleteRouteIDs) > 0 {
+			// These routes are no longer present in the vrf, they are removed from the cache.
+			utils.AviLog.Infof("key: %s, msg: static routes %v not found in vrf %s, removing them from the cache", key, utils.Stringify(deleteRouteIDs), vrfCacheObj.Name)
+			staticRoutes := make(map[string]uint32, len(vrfCacheObj.StaticRoutes))
+			for routeID, checksum := range vrfCacheObj.StaticRoutes {
+				if !deleteRouteIDs[routeID] {
+					staticRoutes[routeID] = checksum
+				}
+			}
+			newVrfCacheObj := avicache.AviVrfCache{
+				Name:               vrfCacheObj.Name,
+				Uuid:               vrfCacheObj.Uuid,
+				CloudConfigCksum:   lib.StaticRoutesChecksum(staticRoutes),
+				StaticRoutes:       staticRoutes,
+				LegacyStaticRoutes: removeLegacyStaticRoutes(vrfCacheObj.LegacyStaticRoutes, deleteRouteIDs),
+			}
+			rest.cache.VrfCache.AviCacheAdd(vrfCacheObj.Name, &newVrfCacheObj)
+		}
 	}
 
 	opTenant := lib.GetAdminTenant()
@@ -97,16 +114,28 @@ func (rest *RestOperations) AviVrfBuild(key string, vrfNode *nodes.AviVrfNode, u
 		opTenant = lib.GetTenant()
 	}
 
-	restOp := utils.RestOp{
-		Path:    path,
-		Method:  utils.RestPatch,
-		PatchOp: patchOp,
-		Obj:     patchPayload,
-		Tenant:  opTenant,
-		Model:   "VrfContext",
+	var restOps []*utils.RestOp
+	if len(addStaticRoutes) > 0 {
+		restOps = append(restOps, &utils.RestOp{
+			Path:    path,
+			Method:  utils.RestPatch,
+			PatchOp: utils.PatchAddOp,
+			Obj:     map[string]interface{}{"static_routes": addStaticRoutes},
+			Tenant:  opTenant,
+			Model:   "VrfContext",
+		})
 	}
-
-	return &restOp
+	if len(deleteStaticRoutes) > 0 {
+		restOps = append(restOps, &utils.RestOp{
+			Path:    path,
+			Method:  utils.RestPatch,
+			PatchOp: utils.PatchDeleteOp,
+			Obj:     map[string]interface{}{"static_routes": deleteStaticRoutes},
+			Tenant:  opTenant,
+			Model:   "VrfContext",
+		})
+	}
+	return restOps
 }
 
 func (rest *RestOperations) getVrfCacheObj(vrfName string) *avicache.AviVrfCache {
@@ -134,6 +163,10 @@ func (rest *RestOperations) AviVrfCacheAdd(restOp *utils.RestOp, vrfKey avicache
 		return errors.New("vrfcontext not found")
 	}
 	vrfName := vrfKey.Name
+	if restOp.Method == utils.RestPatch {
+		rest.AviVrfCachePatch(restOp, vrfName, key)
+		return nil
+	}
 
 	var checksum uint32
 	var staticRoutes []*avimodels.StaticRoute
@@ -162,14 +195,58 @@ func (rest *RestOperations) AviVrfCacheAdd(restOp *utils.RestOp, vrfKey avicache
 				utils.AviLog.Debugf("key: %s, no static routes found for vrf %s", key, vrfName)
 			}
 		}
-		checksum = lib.VrfChecksum(name, staticRoutes)
-		vrfCacheObj := avicache.AviVrfCache{Name: name, Uuid: uuid, CloudConfigCksum: checksum}
+		staticRouteChecksums := lib.GetStaticRouteChecksums(staticRoutes)
+		checksum = lib.StaticRoutesChecksum(staticRouteChecksums)
+		vrfCacheObj := avicache.AviVrfCache{Name: name, Uuid: uuid, CloudConfigCksum: checksum, StaticRoutes: staticRouteChecksums,
+			LegacyStaticRoutes: lib.GetLegacyStaticRouteIDs(staticRoutes)}
 		rest.cache.VrfCache.AviCacheAdd(vrfName, &vrfCacheObj)
 	}
-	if lib.StaticRouteSyncChan != nil {
-		close(lib.StaticRouteSyncChan)
-		lib.StaticRouteSyncChan = nil
-	}
+	lib.CloseStaticRouteSyncChan()
 
 	return nil
 }
+
+// AviVrfCachePatch applies the static routes added or deleted by the PATCH operation to the vrf cache.
+func (rest *RestOperations) AviVrfCachePatch(restOp *utils.RestOp, vrfName, key string) {
+	vrfCacheObj := rest.getVrfCacheObj(vrfName)
+	if vrfCacheObj == nil {
+		utils.AviLog.Warnf("key: %s, msg: vrf %s not found in cache, not applying patch", key, vrfName)
+	} else {
+		staticRoutes := make(map[string]uint32, len(vrfCacheObj.StaticRoutes))
+		for routeID, checksum := range vrfCacheObj.StaticRoutes {
+			staticRoutes[routeID] = checksum
+		}
+		payload, _ := restOp.Obj.(map[string]interface{})
+		patchStaticRoutes, _ := payload["static_routes"].([]*avimodels.StaticRoute)
+		deletedRouteIDs := make(map[string]bool)
+		for _, staticRoute := range patchStaticRoutes {
+			if restOp.PatchOp == utils.PatchDeleteOp {
+				delete(staticRoutes, *staticRoute.RouteID)
+				deletedRouteIDs[*staticRoute.RouteID] = true
+			} else {
+				staticRoutes[*staticRoute.RouteID] = lib.StaticRouteChecksum(staticRoute)
+			}
+		}
+		newVrfCacheObj := avicache.AviVrfCache{
+			Name:               vrfCacheObj.Name,
+			Uuid:               vrfCacheObj.Uuid,
+			CloudConfigCksum:   lib.StaticRoutesChecksum(staticRoutes),
+			StaticRoutes:       staticRoutes,
+			LegacyStaticRoutes: removeLegacyStaticRoutes(vrfCacheObj.LegacyStaticRoutes, deletedRouteIDs),
+		}
+		rest.cache.VrfCache.AviCacheAdd(vrfName, &newVrfCacheObj)
+		utils.AviLog.Debugf("key: %s, msg: patched %d static routes in vrf cache %s", key, len(patchStaticRoutes), vrfName)
+	}
+	lib.CloseStaticRouteSyncChan()
+}
+
+// removeLegacyStaticRoutes returns a copy of the legacy route ids of the static routes, without the deleted routes.
+func removeLegacyStaticRoutes(legacyRouteIDs map[string]string, deletedRouteIDs map[string]bool) map[string]string {
+	newLegacyRouteIDs := make(map[string]string, len(legacyRouteIDs))
+	for prefix, routeID := range legacyRouteIDs {
+		if !deletedRouteIDs[routeID] {
+			newLegacyRouteIDs[prefix] = routeID
+		}
+	}
+	return newLegacyRouteIDs
+}
@@ -25,6 +25,7 @@ import (
 	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/status"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
 
@@ -124,6 +125,10 @@ func (rest *RestOperations) AviVsBuild(vs_meta *nodes.AviVsNode, rest_method uti
 			vs.PoolGroupRef = proto.String("/api/poolgroup/?name=" + vs_meta.DefaultPoolGroup)
 		}
 
+		if vs_meta.DefaultPool != "" {
+			vs.PoolRef = proto.String("/api/pool/?name=" + vs_meta.DefaultPool)
+		}
+
 		if len(vs_meta.VSVIPRefs) > 0 {
 			vs.VsvipRef = proto.String("/api/vsvip/?name=" + vs_meta.VSVIPRefs[0].Name)
 		} else {
@@ -144,8 +149,8 @@ func (rest *RestOperations) AviVsBuild(vs_meta *nodes.AviVsNode, rest_method uti
 				EnableSsl:    &vs_meta.PortProto[i].EnableSSL,
 				PortRangeEnd: &port,
 			}
-			if vs_meta.NetworkProfile == utils.MIXED_NET_PROFILE && pp.Protocol == utils.UDP {
-				svc.OverrideNetworkProfileRef = proto.String("/api/networkprofile/?name=" + utils.SYSTEM_UDP_FAST_PATH)
+			if networkProfile := vs_meta.GetOverrideNetworkProfile(pp); networkProfile != "" {
+				svc.OverrideNetworkProfileRef = proto.String("/api/networkprofile/?name=" + networkProfile)
 			}
 			vs.Services = append(vs.Services, &svc)
 		}
@@ -235,6 +240,10 @@ func (rest *RestOperations) AviVsBuild(vs_meta *nodes.AviVsNode, rest_method uti
 			}
 			vs.L4Policies = l4Policies
 		}
+		if len(vs_meta.NSPolicyRefs) > 0 {
+			nsPolicyRef := fmt.Sprintf("/api/networksecuritypolicy/?name=%s", vs_meta.NSPolicyRefs[0].Name)
+			vs.NetworkSecurityPolicyRef = &nsPolicyRef
+		}
 		vs.AnalyticsPolicy = vs_meta.GetAnalyticsPolicy()
 
 		var rest_ops []*utils.RestOp
@@ -505,6 +514,31 @@ func (rest *RestOperations) StatusUpdateForPool(restMethod utils.RestMethod, vs_
 	}
 }
 
+// publishSvcApiRouteStatus reports the routes admitted by the services API gateway of the virtualservice in
+// the route status, once the virtualservice and its objects are synced to the controller.
+func (rest *RestOperations) publishSvcApiRouteStatus(vsNode *nodes.AviVsNode, key string) {
+	gwNSName := vsNode.ServiceMetadata.Gateway
+	if gwNSName == "" {
+		return
+	}
+	_, routes := objects.SharedSvcApiRouteLister().GetGatewayToRoutes(gwNSName)
+	if len(routes) == 0 {
+		return
+	}
+	statusOption := status.StatusOptions{
+		ObjType: lib.SvcApiRoute,
+		Op:      lib.UpdateStatus,
+		Key:     key,
+		Options: &status.UpdateOptions{
+			Key:             key,
+			ServiceMetadata: vsNode.ServiceMetadata,
+			SvcApiRoutes:    append([]string{}, routes...),
+		},
+	}
+	utils.AviLog.Infof("key: %s Publishing to status queue, options: %v", gwNSName, utils.Stringify(statusOption))
+	status.PublishToStatusQueue(gwNSName, statusOption)
+}
+
 func (rest *RestOperations) StatusUpdateForVS(restMethod utils.RestMethod, vsCacheObj *avicache.AviVsCache, key string) {
 	IPAddrs := rest.GetIPAddrsFromCache(vsCacheObj)
 	serviceMetadataObj := vsCacheObj.ServiceMetadataObj
@@ -639,10 +673,11 @@ func (rest *RestOperations) AviVsCacheAdd(rest_op *utils.RestOp, key string) err
 		if ok {
 			vs_cache_obj, found = vs_cache.(*avicache.AviVsCache)
 			if found {
+				status.HostRuleEventBroadcast(vs_cache_obj.Name, vs_cache_obj.ServiceMetadataObj.CRDStatus, svc_mdata_obj.CRDStatus)
+				// the lock is taken as the fields are read by the drift scanner
+				vs_cache_obj.VSCacheLock.Lock()
 				vs_cache_obj.Uuid = uuid
 				vs_cache_obj.CloudConfigCksum = cksum
-
-				status.HostRuleEventBroadcast(vs_cache_obj.Name, vs_cache_obj.ServiceMetadataObj.CRDStatus, svc_mdata_obj.CRDStatus)
 				vs_cache_obj.ServiceMetadataObj = svc_mdata_obj
 				if val, ok := resp["enable_rhi"].(bool); ok {
 					vs_cache_obj.EnableRhi = val
@@ -657,6 +692,7 @@ func (rest *RestOperations) AviVsCacheAdd(rest_op *utils.RestOp, key string) err
 				} else {
 					vs_cache_obj.InvalidData = false
 				}
+				vs_cache_obj.VSCacheLock.Unlock()
 				utils.AviLog.Debug(spew.Sprintf("key: %s, msg: updated VS cache key %v val %v", key, k,
 					utils.Stringify(vs_cache_obj)))
 
@@ -717,8 +753,8 @@ func (rest *RestOperations) AviVsCacheDel(rest_op *utils.RestOp, vsKey avicache.
 			}
 
 			// try to delete the vsvip from cache only if the vs is not of type insecure passthrough
-			// and if controller version is >= 20.1.1
-			if vs_cache_obj.ServiceMetadataObj.PassthroughParentRef == "" {
+			// or an L4 child vs, which share the vsvip of their parent, and if controller version is >= 20.1.1
+			if vs_cache_obj.ServiceMetadataObj.PassthroughParentRef == "" && vs_cache_obj.ServiceMetadataObj.L4ParentRef == "" {
 				if len(vs_cache_obj.VSVipKeyCollection) > 0 {
 					vsvip := vs_cache_obj.VSVipKeyCollection[0].Name
 					vsvipKey := avicache.NamespaceName{Namespace: vsKey.Namespace, Name: vsvip}
@@ -874,7 +910,11 @@ func (rest *RestOperations) GetIPAddrsFromCache(vsCache *avicache.AviVsCache) []
 			vsvip_cache_obj, found := vsvip_cache.(*avicache.AviVSVIPCache)
 			if found {
 				if len(vsvip_cache_obj.Fips) == 0 {
-					IPAddrs = vsvip_cache_obj.Vips
+					IPAddrs = append([]string{}, vsvip_cache_obj.Vips...)
+					// the V6 vip is allocated along with the V4 vip in dual-stack, or alone for V6 only vips.
+					if vsvip_cache_obj.V6IP != "" {
+						IPAddrs = append(IPAddrs, vsvip_cache_obj.V6IP)
+					}
 				} else {
 					IPAddrs = vsvip_cache_obj.Fips
 				}
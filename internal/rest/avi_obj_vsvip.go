@@ -102,11 +102,6 @@ func (rest *RestOperations) AviVsVipBuild(vsvip_meta *nodes.AviVSVIPNode, vsCach
 				AutoAllocateFloatingIP: vsvip_meta.EnablePublicIP,
 			}
 
-			// This would throw an error for advl4 the error is propagated to the gateway status.
-			if vsvip_meta.IPAddress != "" {
-				vip.IPAddress = &avimodels.IPAddr{Type: &ipType, Addr: &vsvip_meta.IPAddress}
-			}
-
 			if lib.IsPublicCloud() && lib.GetCloudType() != lib.CLOUD_GCP {
 				vips := networkNamesToVips(vsvip_meta.VipNetworks, vsvip_meta.EnablePublicIP)
 				vsvip.Vip = []*avimodels.Vip{}
@@ -128,6 +123,8 @@ func (rest *RestOperations) AviVsVipBuild(vsvip_meta *nodes.AviVSVIPNode, vsCach
 					if vsvip_meta.VipNetworks[0].V6Cidr != "" {
 						lib.UpdateV6(vip, &vsvip_meta.VipNetworks[0])
 					}
+					// This would throw an error for advl4 the error is propagated to the gateway status.
+					setVipIPTypeAndAddress(vip, vsvip_meta)
 					vsvip.Vip = []*avimodels.Vip{vip}
 				}
 			}
@@ -163,11 +160,6 @@ func (rest *RestOperations) AviVsVipBuild(vsvip_meta *nodes.AviVSVIPNode, vsCach
 			AutoAllocateFloatingIP: vsvip_meta.EnablePublicIP,
 		}
 
-		// configuring static IP, from gateway.Addresses (advl4, svcapi) and service.loadBalancerIP (l4)
-		if vsvip_meta.IPAddress != "" {
-			vip.IPAddress = &avimodels.IPAddr{Type: &ipType, Addr: &vsvip_meta.IPAddress}
-		}
-
 		// selecting network with user input, in case user input is not provided AKO relies on
 		// usable network configuration in ipamdnsproviderprofile
 		if lib.IsPublicCloud() && lib.GetCloudType() != lib.CLOUD_GCP {
@@ -219,6 +211,8 @@ func (rest *RestOperations) AviVsVipBuild(vsvip_meta *nodes.AviVSVIPNode, vsCach
 		}
 
 		if len(vips) == 0 {
+			// configuring static IP, from gateway.Addresses (advl4, svcapi) and service.loadBalancerIP (l4)
+			setVipIPTypeAndAddress(&vip, vsvip_meta)
 			vips = append(vips, &vip)
 		}
 
@@ -588,22 +582,22 @@ func (rest *RestOperations) AviVsVipCacheAdd(rest_op *utils.RestOp, vsKey avicac
 		}
 
 		k := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: name}
+		// the vips are compared with the existing cache entry, to update the status only if the vips change
+		var oldVsVips, oldVsFips []string
+		var oldVsV6IP string
+		if oldVsVipCache, oldVsVipFound := rest.cache.VSVIPCache.AviCacheGet(k); oldVsVipFound {
+			if oldVsVipCacheObj, ok := oldVsVipCache.(*avicache.AviVSVIPCache); ok {
+				oldVsVips = oldVsVipCacheObj.Vips
+				oldVsFips = oldVsVipCacheObj.Fips
+				oldVsV6IP = oldVsVipCacheObj.V6IP
+			}
+		}
 		rest.cache.VSVIPCache.AviCacheAdd(k, &vsvip_cache_obj)
 		// Update the VS object
 		vs_cache, ok := rest.cache.VsCacheMeta.AviCacheGet(vsKey)
 		if ok {
 			vs_cache_obj, found := vs_cache.(*avicache.AviVsCache)
 			if found {
-				oldVsVipCache, oldVsVipFound := rest.cache.VSVIPCache.AviCacheGet(k)
-				var oldVsVips, oldVsFips []string
-				if oldVsVipFound {
-					oldVsVipCacheObj, ok := oldVsVipCache.(*avicache.AviVSVIPCache)
-					if ok {
-						oldVsVips = oldVsVipCacheObj.Vips
-						oldVsFips = oldVsVipCacheObj.Fips
-					}
-				}
-
 				vs_cache_obj.AddToVSVipKeyCollection(k)
 				utils.AviLog.Debugf("key: %s, msg: modified the VS cache object for VSVIP collection. The cache now is :%v", key, utils.Stringify(vs_cache_obj))
 				if rest_op.Method == utils.RestPut {
@@ -615,7 +609,8 @@ func (rest *RestOperations) AviVsVipCacheAdd(rest_op *utils.RestOp, vsKey avicac
 								childObj, _ := rest.cache.VsCacheMeta.AviCacheGet(childVSKey)
 								child_cache_obj, vs_found := childObj.(*avicache.AviVsCache)
 								if vs_found {
-									if !reflect.DeepEqual(vsvip_cache_obj.Vips, oldVsVips) || !reflect.DeepEqual(vsvip_cache_obj.Fips, oldVsFips) {
+									if !reflect.DeepEqual(vsvip_cache_obj.Vips, oldVsVips) || !reflect.DeepEqual(vsvip_cache_obj.Fips, oldVsFips) ||
+										vsvip_cache_obj.V6IP != oldVsV6IP {
 										rest.StatusUpdateForPool(rest_op.Method, child_cache_obj, key)
 										// rest.StatusUpdateForVS(child_cache_obj, key)
 									}
@@ -623,7 +618,8 @@ func (rest *RestOperations) AviVsVipCacheAdd(rest_op *utils.RestOp, vsKey avicac
 							}
 						}
 					}
-					if !reflect.DeepEqual(vsvip_cache_obj.Vips, oldVsVips) || !reflect.DeepEqual(vsvip_cache_obj.Fips, oldVsFips) {
+					if !reflect.DeepEqual(vsvip_cache_obj.Vips, oldVsVips) || !reflect.DeepEqual(vsvip_cache_obj.Fips, oldVsFips) ||
+						vsvip_cache_obj.V6IP != oldVsV6IP {
 						rest.StatusUpdateForPool(rest_op.Method, vs_cache_obj, key)
 						// rest.StatusUpdateForVS(vs_cache_obj, key)
 					}
@@ -677,6 +673,26 @@ func (rest *RestOperations) AviVsVipCacheDel(rest_op *utils.RestOp, vsKey avicac
 	return nil
 }
 
+// setVipIPTypeAndAddress sets the ip type requested by the object on the vip, which overrides the one derived
+// from the vip network, and the static ip in the address field of its family.
+func setVipIPTypeAndAddress(vip *avimodels.Vip, vsvipMeta *nodes.AviVSVIPNode) {
+	if vsvipMeta.IPType != "" {
+		vip.AutoAllocateIPType = proto.String(vsvipMeta.IPType)
+	}
+	if vsvipMeta.IPAddress == "" {
+		return
+	}
+	if utils.IsV4(vsvipMeta.IPAddress) {
+		vip.IPAddress = &avimodels.IPAddr{Type: proto.String("V4"), Addr: proto.String(vsvipMeta.IPAddress)}
+		return
+	}
+	vip.Ip6Address = &avimodels.IPAddr{Type: proto.String("V6"), Addr: proto.String(vsvipMeta.IPAddress)}
+	// a static V6 ip needs the V6 allocation, the V4 ip is still auto allocated for a dual-stack vip.
+	if vip.AutoAllocateIPType == nil || *vip.AutoAllocateIPType == lib.IPTypeV4Only {
+		vip.AutoAllocateIPType = proto.String(lib.IPTypeV6Only)
+	}
+}
+
 func networkNamesToVips(vipNetworks []akov1alpha1.AviInfraSettingVipNetwork, enablePublicIP *bool) []*avimodels.Vip {
 	var vipList []*avimodels.Vip
 	autoAllocate := true
@@ -53,6 +53,7 @@ func (rest *RestOperations) CleanupVS(key string, skipVS bool) {
 
 func (rest *RestOperations) DequeueNodes(key string) {
 	utils.AviLog.Infof("key: %s, msg: start rest layer sync.", key)
+	rest.revertDriftObjects(key)
 
 	// Got the key from the Graph Layer - let's fetch the model
 	ok, avimodelIntf := objects.SharedAviGraphLister().Get(key)
@@ -62,6 +63,11 @@ func (rest *RestOperations) DequeueNodes(key string) {
 	namespace, name := utils.ExtractNamespaceObjectName(key)
 	vsKey := avicache.NamespaceName{Namespace: namespace, Name: name}
 	vs_cache_obj := rest.getVsCacheObj(vsKey, key)
+	if lib.IsDryRunEnabled() {
+		// The plan for the model is built afresh, as the objects removed from the model which were never
+		// created on the controller would not go through the rest operations.
+		models.DryRunPlan.ClearModel(key)
+	}
 	if !ok || avimodelIntf == nil {
 		if avimodelIntf != nil {
 			avimodel, ok := avimodelIntf.(*nodes.AviObjectGraph)
@@ -71,10 +77,7 @@ func (rest *RestOperations) DequeueNodes(key string) {
 				return
 			}
 		}
-		if lib.StaticRouteSyncChan != nil {
-			close(lib.StaticRouteSyncChan)
-			lib.StaticRouteSyncChan = nil
-		}
+		lib.CloseStaticRouteSyncChan()
 		if vs_cache_obj != nil {
 			utils.AviLog.Infof("key: %s, msg: nil model found, this is a vs deletion case", key)
 			rest.DeleteVSOper(vsKey, vs_cache_obj, namespace, key, false, false)
@@ -159,10 +162,7 @@ func (rest *RestOperations) IstioCU(key string, avimodel *nodes.AviObjectGraph)
 func (rest *RestOperations) vrfCU(key, vrfName string, avimodel *nodes.AviObjectGraph) {
 	if lib.GetDisableStaticRoute() {
 		utils.AviLog.Debugf("key: %s, msg: static route sync disabled", key)
-		if lib.StaticRouteSyncChan != nil {
-			close(lib.StaticRouteSyncChan)
-			lib.StaticRouteSyncChan = nil
-		}
+		lib.CloseStaticRouteSyncChan()
 		return
 	}
 	// Disable static route sync if ako is in  NodePort mode
@@ -173,44 +173,30 @@ func (rest *RestOperations) vrfCU(key, vrfName string, avimodel *nodes.AviObject
 	vrfNode := avimodel.GetAviVRF()
 	if len(vrfNode) != 1 {
 		utils.AviLog.Warnf("key: %s, msg: Number of vrf nodes is not one", key)
-		if lib.StaticRouteSyncChan != nil {
-			close(lib.StaticRouteSyncChan)
-			lib.StaticRouteSyncChan = nil
-		}
+		lib.CloseStaticRouteSyncChan()
 		return
 	}
 	aviVrfNode := vrfNode[0]
 	vrfCacheObj := rest.getVrfCacheObj(vrfName)
 	if vrfCacheObj == nil {
 		utils.AviLog.Warnf("key: %s, vrf %s not found in cache, exiting", key, vrfName)
-		if lib.StaticRouteSyncChan != nil {
-			close(lib.StaticRouteSyncChan)
-			lib.StaticRouteSyncChan = nil
-		}
+		lib.CloseStaticRouteSyncChan()
 		return
 	}
 	if vrfCacheObj.CloudConfigCksum == aviVrfNode.CloudConfigCksum {
 		utils.AviLog.Debugf("key: %s, msg: checksum for vrf %s has not changed, skipping", key, vrfName)
-		if lib.StaticRouteSyncChan != nil {
-			close(lib.StaticRouteSyncChan)
-			lib.StaticRouteSyncChan = nil
-		}
+		lib.CloseStaticRouteSyncChan()
 		return
 	}
-	var restOps []*utils.RestOp
-	restOp := rest.AviVrfBuild(key, aviVrfNode, vrfCacheObj.Uuid)
-	if restOp == nil {
+	restOps := rest.AviVrfBuild(key, aviVrfNode, vrfCacheObj)
+	if len(restOps) == 0 {
 		utils.AviLog.Debugf("key: %s, no rest operation for vrf %s", key, vrfName)
-		if lib.StaticRouteSyncChan != nil {
-			close(lib.StaticRouteSyncChan)
-			lib.StaticRouteSyncChan = nil
-		}
+		lib.CloseStaticRouteSyncChan()
 		return
 	}
-	restOps = append(restOps, restOp)
 	vrfKey := avicache.NamespaceName{Namespace: lib.GetTenant(), Name: vrfName}
 	utils.AviLog.Debugf("key: %s, msg: Executing rest for vrf %s", key, vrfName)
-	utils.AviLog.Debugf("key: %s, msg: restops %v", key, *restOp)
+	utils.AviLog.Debugf("key: %s, msg: restops %v", key, utils.Stringify(restOps))
 	success, _ := rest.ExecuteRestAndPopulateCache(restOps, vrfKey, avimodel, key, false)
 
 	if success && lib.ConfigDeleteSyncChan != nil {
@@ -276,6 +262,7 @@ func (rest *RestOperations) RestOperation(vsName string, namespace string, avimo
 	var sni_to_delete []avicache.NamespaceName
 	var httppol_to_delete []avicache.NamespaceName
 	var l4pol_to_delete []avicache.NamespaceName
+	var nsp_to_delete []avicache.NamespaceName
 	var sslkey_cert_delete []avicache.NamespaceName
 	var vsvipErr error
 	var publishKey string
@@ -292,6 +279,11 @@ func (rest *RestOperations) RestOperation(vsName string, namespace string, avimo
 			publishKey = splitKeys[1]
 		}
 	}
+	var l4_child_to_delete []string
+	if vs_cache_obj != nil {
+		l4_child_to_delete = make([]string, len(vs_cache_obj.ServiceMetadataObj.L4ChildRefs))
+		copy(l4_child_to_delete, vs_cache_obj.ServiceMetadataObj.L4ChildRefs)
+	}
 	// Order would be this: 1. Pools 2. PGs  3. DS. 4. SSLKeyCert 5. VS
 	if vs_cache_obj != nil {
 		var rest_ops []*utils.RestOp
@@ -313,9 +305,11 @@ func (rest *RestOperations) RestOperation(vsName string, namespace string, avimo
 		httppol_to_delete, rest_ops = rest.HTTPPolicyCU(aviVsNode.HttpPolicyRefs, vs_cache_obj, namespace, rest_ops, key)
 		ds_to_delete, rest_ops = rest.DatascriptCU(aviVsNode.HTTPDSrefs, vs_cache_obj, namespace, rest_ops, key)
 		l4pol_to_delete, rest_ops = rest.L4PolicyCU(aviVsNode.L4PolicyRefs, vs_cache_obj, namespace, rest_ops, key)
+		nsp_to_delete, rest_ops = rest.NSPolicyCU(aviVsNode.NSPolicyRefs, vs_cache_obj, namespace, rest_ops, key)
 		utils.AviLog.Debugf("key: %s, msg: stored checksum for VS: %s, model checksum: %s", key, vs_cache_obj.CloudConfigCksum, strconv.Itoa(int(aviVsNode.GetCheckSum())))
 		if vs_cache_obj.CloudConfigCksum == strconv.Itoa(int(aviVsNode.GetCheckSum())) {
 			utils.AviLog.Debugf("key: %s, msg: the checksums are same for vs %s, not doing anything", key, vs_cache_obj.Name)
+			utils.IncrementChecksumSkips(utils.ChecksumSkipRestLayer)
 		} else {
 			utils.AviLog.Debugf("key: %s, msg: the stored checksum for vs is %v, and the obtained checksum for VS is: %v", key, vs_cache_obj.CloudConfigCksum, strconv.Itoa(int(aviVsNode.GetCheckSum())))
 			// The checksums are different, so it should be a PUT call.
@@ -344,6 +338,7 @@ func (rest *RestOperations) RestOperation(vsName string, namespace string, avimo
 		_, rest_ops = rest.PoolGroupCU(aviVsNode.PoolGroupRefs, nil, namespace, rest_ops, key)
 		_, rest_ops = rest.HTTPPolicyCU(aviVsNode.HttpPolicyRefs, nil, namespace, rest_ops, key)
 		_, rest_ops = rest.L4PolicyCU(aviVsNode.L4PolicyRefs, nil, namespace, rest_ops, key)
+		_, rest_ops = rest.NSPolicyCU(aviVsNode.NSPolicyRefs, nil, namespace, rest_ops, key)
 		_, rest_ops = rest.DatascriptCU(aviVsNode.HTTPDSrefs, nil, namespace, rest_ops, key)
 
 		// The cache was not found - it's a POST call.
@@ -375,12 +370,16 @@ func (rest *RestOperations) RestOperation(vsName string, namespace string, avimo
 	}
 	rest_ops = rest.HTTPPolicyDelete(httppol_to_delete, namespace, rest_ops, key)
 	rest_ops = rest.L4PolicyDelete(l4pol_to_delete, namespace, rest_ops, key)
+	rest_ops = rest.NSPolicyDelete(nsp_to_delete, namespace, rest_ops, key)
 	rest_ops = rest.DSDelete(ds_to_delete, namespace, rest_ops, key)
 	rest_ops = rest.PoolGroupDelete(pgs_to_delete, namespace, rest_ops, key)
 	rest_ops = rest.PoolDelete(pools_to_delete, namespace, rest_ops, key)
 	if success, _ := rest.ExecuteRestAndPopulateCache(rest_ops, vsKey, avimodel, key, false); !success {
 		return
 	}
+	if lib.UseServicesAPI() {
+		rest.publishSvcApiRouteStatus(aviVsNode, key)
+	}
 
 	for _, sni_node := range aviVsNode.SniNodes {
 		utils.AviLog.Debugf("key: %s, msg: processing sni node: %s", key, sni_node.Name)
@@ -424,6 +423,65 @@ func (rest *RestOperations) RestOperation(vsName string, namespace string, avimo
 			return
 		}
 	}
+
+	var l4ChildNames []string
+	for _, l4ChildNode := range aviVsNode.L4ChildNodes {
+		var rest_ops []*utils.RestOp
+		l4ChildNames = append(l4ChildNames, l4ChildNode.Name)
+		vsKey = avicache.NamespaceName{Namespace: namespace, Name: l4ChildNode.Name}
+		l4ChildVSCacheObj := rest.getVsCacheObj(vsKey, key)
+		utils.AviLog.Debugf("key: %s, msg: processing L4 child node: %s", key, l4ChildNode.Name)
+		rest_ops = rest.L4ChildCU(l4ChildNode, l4ChildVSCacheObj, namespace, rest_ops, key)
+		if success, _ := rest.ExecuteRestAndPopulateCache(rest_ops, vsKey, avimodel, key, false); !success {
+			return
+		}
+	}
+
+	// Delete the L4 child VSes, whose listeners are no longer present in the model.
+	for _, l4Child := range l4_child_to_delete {
+		if utils.HasElem(l4ChildNames, l4Child) {
+			continue
+		}
+		utils.AviLog.Infof("key: %s, msg: deleting stale L4 child VS: %s", key, l4Child)
+		l4ChildVSKey := avicache.NamespaceName{Namespace: namespace, Name: l4Child}
+		if l4ChildVSCacheObj := rest.getVsCacheObj(l4ChildVSKey, key); l4ChildVSCacheObj != nil {
+			if success := rest.DeleteVSOper(l4ChildVSKey, l4ChildVSCacheObj, namespace, key, false, true); !success {
+				return
+			}
+		}
+	}
+}
+
+// L4ChildCU creates or updates a child VS of an L4 VS, along with its pool and network security policy,
+// and deletes the pool and network security policy which are no longer referred by the child VS.
+func (rest *RestOperations) L4ChildCU(l4ChildNode *nodes.AviVsNode, vsCacheObj *avicache.AviVsCache, namespace string, restOps []*utils.RestOp, key string) []*utils.RestOp {
+	if vsCacheObj != nil {
+		var poolsToDelete, nspToDelete []avicache.NamespaceName
+		poolsToDelete, restOps = rest.PoolCU(l4ChildNode.PoolRefs, vsCacheObj, namespace, restOps, key)
+		nspToDelete, restOps = rest.NSPolicyCU(l4ChildNode.NSPolicyRefs, vsCacheObj, namespace, restOps, key)
+
+		// The checksums are different, so it should be a PUT call.
+		if vsCacheObj.CloudConfigCksum != strconv.Itoa(int(l4ChildNode.GetCheckSum())) {
+			restOp := rest.AviVsBuild(l4ChildNode, utils.RestPut, vsCacheObj, key)
+			if restOp != nil {
+				restOps = append(restOps, restOp...)
+			}
+			utils.AviLog.Debugf("key: %s, msg: the checksums are different for L4 child %s, operation: PUT", key, l4ChildNode.Name)
+		}
+		restOps = rest.NSPolicyDelete(nspToDelete, namespace, restOps, key)
+		restOps = rest.PoolDelete(poolsToDelete, namespace, restOps, key)
+	} else {
+		utils.AviLog.Infof("key: %s, msg: L4 child %s not found in cache", key, l4ChildNode.Name)
+		_, restOps = rest.PoolCU(l4ChildNode.PoolRefs, nil, namespace, restOps, key)
+		_, restOps = rest.NSPolicyCU(l4ChildNode.NSPolicyRefs, nil, namespace, restOps, key)
+
+		// Not found - it should be a POST call.
+		restOp := rest.AviVsBuild(l4ChildNode, utils.RestPost, nil, key)
+		if restOp != nil {
+			restOps = append(restOps, restOp...)
+		}
+	}
+	return restOps
 }
 
 func (rest *RestOperations) PassthroughChildCU(passChildNode *nodes.AviVsNode, vsCacheObj *avicache.AviVsCache, namespace string, restOps []*utils.RestOp, key string) []*utils.RestOp {
@@ -487,6 +545,19 @@ func (rest *RestOperations) DeleteVSOper(vsKey avicache.NamespaceName, vs_cache_
 				return false
 			}
 		}
+		for _, l4Child := range vs_cache_obj.ServiceMetadataObj.L4ChildRefs {
+			l4ChildKey := avicache.NamespaceName{
+				Namespace: namespace,
+				Name:      l4Child,
+			}
+			l4ChildCache := rest.getVsCacheObj(l4ChildKey, key)
+			if l4ChildCache == nil {
+				continue
+			}
+			if success := rest.DeleteVSOper(l4ChildKey, l4ChildCache, namespace, key, skipVS, true); !success {
+				return false
+			}
+		}
 		for _, sni_uuid := range sni_vs_keys {
 			sniVsKey, ok := rest.cache.VsCacheMeta.AviCacheGetKeyByUuid(sni_uuid)
 			if ok {
@@ -509,6 +580,7 @@ func (rest *RestOperations) DeleteVSOper(vsKey avicache.NamespaceName, vs_cache_
 		rest_ops = rest.SSLKeyCertDelete(vs_cache_obj.SSLKeyCertCollection, namespace, rest_ops, key)
 		rest_ops = rest.HTTPPolicyDelete(vs_cache_obj.HTTPKeyCollection, namespace, rest_ops, key)
 		rest_ops = rest.L4PolicyDelete(vs_cache_obj.L4PolicyCollection, namespace, rest_ops, key)
+		rest_ops = rest.NSPolicyDelete(vs_cache_obj.NSPolicyCollection, namespace, rest_ops, key)
 		rest_ops = rest.PoolGroupDelete(vs_cache_obj.PGKeyCollection, namespace, rest_ops, key)
 		rest_ops = rest.PoolDelete(vs_cache_obj.PoolKeyCollection, namespace, rest_ops, key)
 		success, _ := rest.ExecuteRestAndPopulateCache(rest_ops, vsKey, nil, key, false)
@@ -564,6 +636,15 @@ func (rest *RestOperations) ExecuteRestAndPopulateCache(rest_ops []*utils.RestOp
 		shardSize = 8
 	}
 	var retry, fastRetry, processNextObj bool
+	if lib.IsDryRunEnabled() {
+		// Record the operations in the plan instead of executing these, the cache is not updated either,
+		// so the plan reflects the complete diff between the models and the objects on the controller.
+		for _, rest_op := range rest_ops {
+			utils.AviLog.Infof("key: %s, msg: dry run, skipping %s of %s %s: %s", key, rest_op.Method, rest_op.Model, rest_op.Path, utils.Stringify(rest_op.Obj))
+		}
+		models.DryRunPlan.RecordRestOps(key, aviObjKey.Namespace+"/"+aviObjKey.Name, rest_ops)
+		return true, processNextObj
+	}
 	if shardSize != 0 {
 		bkt := utils.Bkt(key, shardSize)
 		if len(rest.aviRestPoolClient.AviClient) > 0 && len(rest_ops) > 0 {
@@ -710,6 +791,12 @@ func (rest *RestOperations) PopulateOneCache(rest_op *utils.RestOp, aviObjKey av
 			rest.AviSSLKeyCertAdd(rest_op, aviObjKey, key)
 		} else if rest_op.Model == "L4PolicySet" {
 			rest.AviL4PolicyCacheAdd(rest_op, aviObjKey, key)
+		} else if rest_op.Model == "NetworkSecurityPolicy" {
+			rest.AviNSPolicyCacheAdd(rest_op, aviObjKey, key)
+		} else if rest_op.Model == "ApplicationPersistenceProfile" {
+			rest.AviPersistenceProfileAdd(rest_op, aviObjKey, key)
+		} else if rest_op.Model == "HealthMonitor" {
+			rest.AviHealthMonitorAdd(rest_op, aviObjKey, key)
 		} else if rest_op.Model == "VrfContext" {
 			rest.AviVrfCacheAdd(rest_op, aviObjKey, key)
 		} else if rest_op.Model == "VsVip" {
@@ -733,6 +820,12 @@ func (rest *RestOperations) PopulateOneCache(rest_op *utils.RestOp, aviObjKey av
 			rest.AviSSLCacheDel(rest_op, aviObjKey, key)
 		} else if rest_op.Model == "L4PolicySet" {
 			rest.AviL4PolicyCacheDel(rest_op, aviObjKey, key)
+		} else if rest_op.Model == "NetworkSecurityPolicy" {
+			rest.AviNSPolicyCacheDel(rest_op, aviObjKey, key)
+		} else if rest_op.Model == "ApplicationPersistenceProfile" {
+			rest.AviPersistenceProfileCacheDel(rest_op, aviObjKey, key)
+		} else if rest_op.Model == "HealthMonitor" {
+			rest.AviHealthMonitorCacheDel(rest_op, aviObjKey, key)
 		} else if rest_op.Model == "VsVip" {
 			rest.AviVsVipCacheDel(rest_op, aviObjKey, key)
 		} else if rest_op.Model == "VSDataScriptSet" {
@@ -801,6 +894,15 @@ func (rest *RestOperations) RefreshCacheForRetryLayer(parentVsKey string, aviObj
 		processNextObj = false
 	} else if statuscode >= 400 && statuscode < 499 { // Will account for more error codes.*/
 		fastRetry = true
+		if rest_op.Model == "Pool" && rest_op.Method == utils.RestPatch {
+			// The servers in the pool cache could be stale, so the pool is updated with a PUT in the retry.
+			poolKey := avicache.NamespaceName{Namespace: rest_op.Tenant, Name: rest_op.ObjName}
+			if poolCache, ok := rest.cache.PoolCache.AviCacheGet(poolKey); ok {
+				if poolCacheObj, ok := poolCache.(*avicache.AviPoolCache); ok {
+					poolCacheObj.NonServerCksum = ""
+				}
+			}
+		}
 		// 404 means the object exists in our cache but not on the controller.
 		if statuscode == 404 {
 			switch rest_op.Model {
@@ -913,6 +1015,18 @@ func (rest *RestOperations) RefreshCacheForRetryLayer(parentVsKey string, aviObj
 					rest_op.ObjName = L4PolicySet
 				}
 				rest.AviL4PolicyCacheDel(rest_op, aviObjKey, key)
+			case "NetworkSecurityPolicy":
+				var NetworkSecurityPolicy string
+				switch rest_op.Obj.(type) {
+				case utils.AviRestObjMacro:
+					NetworkSecurityPolicy = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.NetworkSecurityPolicy).Name
+				case avimodels.NetworkSecurityPolicy:
+					NetworkSecurityPolicy = *rest_op.Obj.(avimodels.NetworkSecurityPolicy).Name
+				}
+				if NetworkSecurityPolicy != "" {
+					rest_op.ObjName = NetworkSecurityPolicy
+				}
+				rest.AviNSPolicyCacheDel(rest_op, aviObjKey, key)
 			case "SSLKeyAndCertificate":
 				var SSLKeyAndCertificate string
 				switch rest_op.Obj.(type) {
@@ -937,6 +1051,30 @@ func (rest *RestOperations) RefreshCacheForRetryLayer(parentVsKey string, aviObj
 					rest_op.ObjName = PKIprofile
 				}
 				rest.AviPkiProfileCacheDel(rest_op, aviObjKey, key)
+			case "ApplicationPersistenceProfile":
+				var ApplicationPersistenceProfile string
+				switch rest_op.Obj.(type) {
+				case utils.AviRestObjMacro:
+					ApplicationPersistenceProfile = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.ApplicationPersistenceProfile).Name
+				case avimodels.ApplicationPersistenceProfile:
+					ApplicationPersistenceProfile = *rest_op.Obj.(avimodels.ApplicationPersistenceProfile).Name
+				}
+				if ApplicationPersistenceProfile != "" {
+					rest_op.ObjName = ApplicationPersistenceProfile
+				}
+				rest.AviPersistenceProfileCacheDel(rest_op, aviObjKey, key)
+			case "HealthMonitor":
+				var HealthMonitor string
+				switch rest_op.Obj.(type) {
+				case utils.AviRestObjMacro:
+					HealthMonitor = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.HealthMonitor).Name
+				case avimodels.HealthMonitor:
+					HealthMonitor = *rest_op.Obj.(avimodels.HealthMonitor).Name
+				}
+				if HealthMonitor != "" {
+					rest_op.ObjName = HealthMonitor
+				}
+				rest.AviHealthMonitorCacheDel(rest_op, aviObjKey, key)
 			case "VirtualService":
 				rest.AviVsCacheDel(rest_op, aviObjKey, key)
 			case "VSDataScriptSet":
@@ -966,6 +1104,8 @@ func (rest *RestOperations) RefreshCacheForRetryLayer(parentVsKey string, aviObj
 					poolObjName = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.Pool).Name
 				case avimodels.Pool:
 					poolObjName = *rest_op.Obj.(avimodels.Pool).Name
+				default:
+					poolObjName = rest_op.ObjName
 				}
 				aviObjCache.AviPopulateOnePoolCache(c, utils.CloudName, poolObjName)
 			case "PoolGroup":
@@ -1004,6 +1144,15 @@ func (rest *RestOperations) RefreshCacheForRetryLayer(parentVsKey string, aviObj
 					L4PolicySet = *rest_op.Obj.(avimodels.L4PolicySet).Name
 				}
 				aviObjCache.AviPopulateOneVsL4PolCache(c, utils.CloudName, L4PolicySet)
+			case "NetworkSecurityPolicy":
+				var NetworkSecurityPolicy string
+				switch rest_op.Obj.(type) {
+				case utils.AviRestObjMacro:
+					NetworkSecurityPolicy = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.NetworkSecurityPolicy).Name
+				case avimodels.NetworkSecurityPolicy:
+					NetworkSecurityPolicy = *rest_op.Obj.(avimodels.NetworkSecurityPolicy).Name
+				}
+				aviObjCache.AviPopulateOneNSPolicyCache(c, utils.CloudName, NetworkSecurityPolicy)
 			case "SSLKeyAndCertificate":
 				var SSLKeyAndCertificate string
 				switch rest_op.Obj.(type) {
@@ -1022,6 +1171,24 @@ func (rest *RestOperations) RefreshCacheForRetryLayer(parentVsKey string, aviObj
 					PKIprofile = *rest_op.Obj.(avimodels.PKIprofile).Name
 				}
 				aviObjCache.AviPopulateOnePKICache(c, utils.CloudName, PKIprofile)
+			case "ApplicationPersistenceProfile":
+				var ApplicationPersistenceProfile string
+				switch rest_op.Obj.(type) {
+				case utils.AviRestObjMacro:
+					ApplicationPersistenceProfile = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.ApplicationPersistenceProfile).Name
+				case avimodels.ApplicationPersistenceProfile:
+					ApplicationPersistenceProfile = *rest_op.Obj.(avimodels.ApplicationPersistenceProfile).Name
+				}
+				aviObjCache.AviPopulateOnePersistenceProfileCache(c, utils.CloudName, ApplicationPersistenceProfile)
+			case "HealthMonitor":
+				var HealthMonitor string
+				switch rest_op.Obj.(type) {
+				case utils.AviRestObjMacro:
+					HealthMonitor = *rest_op.Obj.(utils.AviRestObjMacro).Data.(avimodels.HealthMonitor).Name
+				case avimodels.HealthMonitor:
+					HealthMonitor = *rest_op.Obj.(avimodels.HealthMonitor).Name
+				}
+				aviObjCache.AviPopulateOneHealthMonitorCache(c, utils.CloudName, HealthMonitor)
 			case "VirtualService":
 				aviObjCache.AviObjOneVSCachePopulate(c, utils.CloudName, aviObjKey.Name)
 				vsObjMeta, ok := rest.cache.VsCacheMeta.AviCacheGet(aviObjKey)
@@ -1093,6 +1260,14 @@ func (rest *RestOperations) PoolDelete(pools_to_delete []avicache.NamespaceName,
 			if pkiProfile.Name != "" {
 				rest_ops = rest.PkiProfileDelete([]avicache.NamespaceName{pkiProfile}, namespace, rest_ops, key)
 			}
+			persistenceProfile := pool_cache_obj.PersistenceProfileCollection
+			if persistenceProfile.Name != "" {
+				rest_ops = rest.PersistenceProfileDelete([]avicache.NamespaceName{persistenceProfile}, namespace, rest_ops, key)
+			}
+			healthMonitor := pool_cache_obj.HealthMonitorCollection
+			if healthMonitor.Name != "" {
+				rest_ops = rest.HealthMonitorDelete([]avicache.NamespaceName{healthMonitor}, namespace, rest_ops, key)
+			}
 		}
 	}
 	return rest_ops
@@ -1168,6 +1343,8 @@ func (rest *RestOperations) DSDelete(ds_to_delete []avicache.NamespaceName, name
 func (rest *RestOperations) PoolCU(pool_nodes []*nodes.AviPoolNode, vs_cache_obj *avicache.AviVsCache, namespace string, rest_ops []*utils.RestOp, key string) ([]avicache.NamespaceName, []*utils.RestOp) {
 	var cache_pool_nodes []avicache.NamespaceName
 	var pool_pkiprofile_delete []avicache.NamespaceName
+	var pool_persistence_delete []avicache.NamespaceName
+	var pool_hm_delete []avicache.NamespaceName
 	if vs_cache_obj != nil {
 		cache_pool_nodes = make([]avicache.NamespaceName, len(vs_cache_obj.PoolKeyCollection))
 		copy(cache_pool_nodes, vs_cache_obj.PoolKeyCollection)
@@ -1185,23 +1362,24 @@ func (rest *RestOperations) PoolCU(pool_nodes []*nodes.AviPoolNode, vs_cache_obj
 					if ok {
 						pool_cache_obj, _ := pool_cache.(*avicache.AviPoolCache)
 						pool_pkiprofile_delete, rest_ops = rest.PkiProfileCU(pool.PkiProfile, pool_cache_obj, namespace, rest_ops, key)
+						pool_persistence_delete, rest_ops = rest.PersistenceProfileCU(pool.PersistenceProfile, pool_cache_obj, namespace, rest_ops, key)
+						pool_hm_delete, rest_ops = rest.HealthMonitorCU(pool.ProbeHealthMonitor, pool_cache_obj, namespace, rest_ops, key)
 
 						// Cache found. Let's compare the checksums
 						utils.AviLog.Debugf("key: %s, msg: poolcache: %v", key, pool_cache_obj)
 						if pool_cache_obj.CloudConfigCksum == strconv.Itoa(int(pool.GetCheckSum())) {
 							utils.AviLog.Debugf("key: %s, msg: the checksums are same for pool %s, not doing anything", key, pool.Name)
 						} else {
-							utils.AviLog.Debugf("key: %s, msg: the checksums are different for pool %s, operation: PUT", key, pool.Name)
-							// The checksums are different, so it should be a PUT call.
-							restOp := rest.AviPoolBuild(pool, pool_cache_obj, key)
-							if restOp != nil {
-								rest_ops = append(rest_ops, restOp)
-							}
+							utils.AviLog.Debugf("key: %s, msg: the checksums are different for pool %s, operation: PUT/PATCH", key, pool.Name)
+							// The checksums are different, so it should be a PUT call, or PATCH calls if only the servers changed.
+							rest_ops = append(rest_ops, rest.AviPoolUpdateBuild(pool, pool_cache_obj, key)...)
 						}
 					}
 				} else {
 					utils.AviLog.Debugf("key: %s, msg: pool %s not found in cache, operation: POST", key, pool.Name)
 					_, rest_ops = rest.PkiProfileCU(pool.PkiProfile, nil, namespace, rest_ops, key)
+					_, rest_ops = rest.PersistenceProfileCU(pool.PersistenceProfile, nil, namespace, rest_ops, key)
+					_, rest_ops = rest.HealthMonitorCU(pool.ProbeHealthMonitor, nil, namespace, rest_ops, key)
 					// Not found - it should be a POST call.
 					restOp := rest.AviPoolBuild(pool, nil, key)
 					if restOp != nil {
@@ -1211,12 +1389,20 @@ func (rest *RestOperations) PoolCU(pool_nodes []*nodes.AviPoolNode, vs_cache_obj
 				if len(pool_pkiprofile_delete) > 0 {
 					rest_ops = rest.PkiProfileDelete(pool_pkiprofile_delete, namespace, rest_ops, key)
 				}
+				if len(pool_persistence_delete) > 0 {
+					rest_ops = rest.PersistenceProfileDelete(pool_persistence_delete, namespace, rest_ops, key)
+				}
+				if len(pool_hm_delete) > 0 {
+					rest_ops = rest.HealthMonitorDelete(pool_hm_delete, namespace, rest_ops, key)
+				}
 			}
 		}
 	} else {
 		// Everything is a POST call
 		for _, pool := range pool_nodes {
 			_, rest_ops = rest.PkiProfileCU(pool.PkiProfile, nil, namespace, rest_ops, key)
+			_, rest_ops = rest.PersistenceProfileCU(pool.PersistenceProfile, nil, namespace, rest_ops, key)
+			_, rest_ops = rest.HealthMonitorCU(pool.ProbeHealthMonitor, nil, namespace, rest_ops, key)
 
 			utils.AviLog.Debugf("key: %s, msg: pool cache does not exist %s, operation: POST", key, pool.Name)
 			restOp := rest.AviPoolBuild(pool, nil, key)
@@ -1277,6 +1463,7 @@ func (rest *RestOperations) SNINodeCU(sni_node *nodes.AviVsNode, vs_cache_obj *a
 	var sni_pools_to_delete []avicache.NamespaceName
 	var sni_pgs_to_delete []avicache.NamespaceName
 	var http_policies_to_delete []avicache.NamespaceName
+	var ds_to_delete []avicache.NamespaceName
 	var sslkey_cert_delete []avicache.NamespaceName
 	if vs_cache_obj != nil {
 		sni_key := avicache.NamespaceName{Namespace: namespace, Name: sni_node.Name}
@@ -1296,6 +1483,7 @@ func (rest *RestOperations) SNINodeCU(sni_node *nodes.AviVsNode, vs_cache_obj *a
 				sni_pools_to_delete, rest_ops = rest.PoolCU(sni_node.PoolRefs, sni_cache_obj, namespace, rest_ops, key)
 				sni_pgs_to_delete, rest_ops = rest.PoolGroupCU(sni_node.PoolGroupRefs, sni_cache_obj, namespace, rest_ops, key)
 				http_policies_to_delete, rest_ops = rest.HTTPPolicyCU(sni_node.HttpPolicyRefs, sni_cache_obj, namespace, rest_ops, key)
+				ds_to_delete, rest_ops = rest.DatascriptCU(sni_node.HTTPDSrefs, sni_cache_obj, namespace, rest_ops, key)
 				// The checksums are different, so it should be a PUT call.
 				if sni_cache_obj.CloudConfigCksum != strconv.Itoa(int(sni_node.GetCheckSum())) {
 					restOp := rest.AviVsBuild(sni_node, utils.RestPut, sni_cache_obj, key)
@@ -1313,6 +1501,7 @@ func (rest *RestOperations) SNINodeCU(sni_node *nodes.AviVsNode, vs_cache_obj *a
 			_, rest_ops = rest.PoolCU(sni_node.PoolRefs, nil, namespace, rest_ops, key)
 			_, rest_ops = rest.PoolGroupCU(sni_node.PoolGroupRefs, nil, namespace, rest_ops, key)
 			_, rest_ops = rest.HTTPPolicyCU(sni_node.HttpPolicyRefs, nil, namespace, rest_ops, key)
+			_, rest_ops = rest.DatascriptCU(sni_node.HTTPDSrefs, nil, namespace, rest_ops, key)
 
 			// Not found - it should be a POST call.
 			restOp := rest.AviVsBuild(sni_node, utils.RestPost, nil, key)
@@ -1322,6 +1511,7 @@ func (rest *RestOperations) SNINodeCU(sni_node *nodes.AviVsNode, vs_cache_obj *a
 		}
 		rest_ops = rest.SSLKeyCertDelete(sslkey_cert_delete, namespace, rest_ops, key)
 		rest_ops = rest.HTTPPolicyDelete(http_policies_to_delete, namespace, rest_ops, key)
+		rest_ops = rest.DataScriptDelete(ds_to_delete, namespace, rest_ops, key)
 		rest_ops = rest.PoolGroupDelete(sni_pgs_to_delete, namespace, rest_ops, key)
 		rest_ops = rest.PoolDelete(sni_pools_to_delete, namespace, rest_ops, key)
 		utils.AviLog.Debugf("key: %s, msg: the SNI VSes to be deleted are: %s", key, cache_sni_nodes)
@@ -1332,6 +1522,7 @@ func (rest *RestOperations) SNINodeCU(sni_node *nodes.AviVsNode, vs_cache_obj *a
 		_, rest_ops = rest.PoolCU(sni_node.PoolRefs, nil, namespace, rest_ops, key)
 		_, rest_ops = rest.PoolGroupCU(sni_node.PoolGroupRefs, nil, namespace, rest_ops, key)
 		_, rest_ops = rest.HTTPPolicyCU(sni_node.HttpPolicyRefs, nil, namespace, rest_ops, key)
+		_, rest_ops = rest.DatascriptCU(sni_node.HTTPDSrefs, nil, namespace, rest_ops, key)
 
 		// Not found - it should be a POST call.
 		restOp := rest.AviVsBuild(sni_node, utils.RestPost, nil, key)
@@ -1426,6 +1617,12 @@ func (rest *RestOperations) DatascriptCU(ds_nodes []*nodes.AviHTTPDataScriptNode
 							}
 						}
 					}
+				} else {
+					// The DS is not associated with this VS yet, for e.g. the datascripts from a HostRule.
+					restOp := rest.AviDSBuild(ds, nil, key)
+					if restOp != nil {
+						rest_ops = append(rest_ops, restOp)
+					}
 				}
 			}
 		}
@@ -1602,6 +1799,52 @@ func (rest *RestOperations) L4PolicyCU(l4_nodes []*nodes.AviL4PolicyNode, vs_cac
 	return cache_l4_nodes, rest_ops
 }
 
+func (rest *RestOperations) NSPolicyCU(nsp_nodes []*nodes.AviNetworkSecurityPolicyNode, vs_cache_obj *avicache.AviVsCache, namespace string, rest_ops []*utils.RestOp, key string) ([]avicache.NamespaceName, []*utils.RestOp) {
+	var cache_nsp_nodes []avicache.NamespaceName
+	// Default is POST
+	if vs_cache_obj != nil {
+		cache_nsp_nodes = make([]avicache.NamespaceName, len(vs_cache_obj.NSPolicyCollection))
+		copy(cache_nsp_nodes, vs_cache_obj.NSPolicyCollection)
+		for _, nsp := range nsp_nodes {
+			nsp_key := avicache.NamespaceName{Namespace: namespace, Name: nsp.Name}
+			found := utils.HasElem(cache_nsp_nodes, nsp_key)
+			if found {
+				nsp_cache, ok := rest.cache.NSPolicyCache.AviCacheGet(nsp_key)
+				if ok {
+					cache_nsp_nodes = avicache.RemoveNamespaceName(cache_nsp_nodes, nsp_key)
+					nsp_cache_obj, _ := nsp_cache.(*avicache.AviNSPolicyCache)
+					// Cache found. Let's compare the checksums
+					if nsp_cache_obj.CloudConfigCksum == nsp.GetCheckSum() {
+						utils.AviLog.Debugf("The checksums are same for network security policy cache obj %s, not doing anything", nsp_cache_obj.Name)
+					} else {
+						// The checksums are different, so it should be a PUT call.
+						restOp := rest.AviNSPolicyBuild(nsp, nsp_cache_obj, key)
+						if restOp != nil {
+							rest_ops = append(rest_ops, restOp)
+						}
+					}
+				}
+			} else {
+				// Not found - it should be a POST call.
+				restOp := rest.AviNSPolicyBuild(nsp, nil, key)
+				if restOp != nil {
+					rest_ops = append(rest_ops, restOp)
+				}
+			}
+		}
+	} else {
+		// Everything is a POST call
+		for _, nsp := range nsp_nodes {
+			restOp := rest.AviNSPolicyBuild(nsp, nil, key)
+			if restOp != nil {
+				rest_ops = append(rest_ops, restOp)
+			}
+		}
+	}
+	utils.AviLog.Debugf("key: %s, msg: the network security policies to be deleted are: %s", key, cache_nsp_nodes)
+	return cache_nsp_nodes, rest_ops
+}
+
 func (rest *RestOperations) HTTPPolicyDelete(https_to_delete []avicache.NamespaceName, namespace string, rest_ops []*utils.RestOp, key string) []*utils.RestOp {
 	for _, del_http := range https_to_delete {
 		// fetch trhe http policyset uuid from cache
@@ -1641,6 +1884,21 @@ func (rest *RestOperations) L4PolicyDelete(l4_to_delete []avicache.NamespaceName
 	return rest_ops
 }
 
+func (rest *RestOperations) NSPolicyDelete(nsp_to_delete []avicache.NamespaceName, namespace string, rest_ops []*utils.RestOp, key string) []*utils.RestOp {
+	utils.AviLog.Infof("key: %s, msg: about to delete network security policies %s", key, utils.Stringify(nsp_to_delete))
+	for _, del_nsp := range nsp_to_delete {
+		nsp_key := avicache.NamespaceName{Namespace: namespace, Name: del_nsp.Name}
+		nsp_cache, ok := rest.cache.NSPolicyCache.AviCacheGet(nsp_key)
+		if ok {
+			nsp_cache_obj, _ := nsp_cache.(*avicache.AviNSPolicyCache)
+			restOp := rest.AviNSPolicyDel(nsp_cache_obj.Uuid, namespace, key)
+			restOp.ObjName = del_nsp.Name
+			rest_ops = append(rest_ops, restOp)
+		}
+	}
+	return rest_ops
+}
+
 func (rest *RestOperations) KeyCertCU(sslkey_nodes []*nodes.AviTLSKeyCertNode, certKeys []avicache.NamespaceName, namespace string, rest_ops []*utils.RestOp, key string) ([]avicache.NamespaceName, []*utils.RestOp) {
 	// Default is POST
 	var cache_ssl_nodes []avicache.NamespaceName
@@ -1769,3 +2027,147 @@ func (rest *RestOperations) PkiProfileDelete(pkiProfileDelete []avicache.Namespa
 	}
 	return rest_ops
 }
+
+func (rest *RestOperations) PersistenceProfileCU(persistence_node *nodes.AviPersistenceProfileNode, pool_cache_obj *avicache.AviPoolCache, namespace string, rest_ops []*utils.RestOp, key string) ([]avicache.NamespaceName, []*utils.RestOp) {
+	// Default is POST
+	var cache_persistence_nodes []avicache.NamespaceName
+	if pool_cache_obj != nil {
+		if pool_cache_obj.PersistenceProfileCollection.Name != "" {
+			cache_persistence_nodes = []avicache.NamespaceName{pool_cache_obj.PersistenceProfileCollection}
+		}
+		if persistence_node != nil {
+			persistence_key := avicache.NamespaceName{Namespace: namespace, Name: persistence_node.Name}
+			found := utils.HasElem(cache_persistence_nodes, persistence_key)
+			if found {
+				persistence_cache, ok := rest.cache.PersistenceCache.AviCacheGet(persistence_key)
+				if ok {
+					cache_persistence_nodes = avicache.RemoveNamespaceName(cache_persistence_nodes, persistence_key)
+					persistence_cache_obj, _ := persistence_cache.(*avicache.AviPersistenceProfileCache)
+					if persistence_cache_obj.CloudConfigCksum == persistence_node.GetCheckSum() {
+						utils.AviLog.Debugf("The checksums are same for persistence profile cache obj %s, not doing anything", persistence_cache_obj.Name)
+					} else {
+						// The checksums are different, so it should be a PUT call.
+						restOp := rest.AviPersistenceProfileBuild(persistence_node, persistence_cache_obj)
+						if restOp != nil {
+							rest_ops = append(rest_ops, restOp)
+						}
+					}
+				}
+			} else {
+				restOp := rest.buildPersistenceProfileForName(persistence_node, namespace)
+				if restOp != nil {
+					rest_ops = append(rest_ops, restOp)
+				}
+			}
+		}
+	} else if persistence_node != nil {
+		// Everything is a POST call, unless the profile is already present for this pool.
+		restOp := rest.buildPersistenceProfileForName(persistence_node, namespace)
+		if restOp != nil {
+			rest_ops = append(rest_ops, restOp)
+		}
+	}
+
+	return cache_persistence_nodes, rest_ops
+}
+
+// buildPersistenceProfileForName updates the persistence profile if one with the same name is already present in the cache,
+// this is the case when the pool is recreated, otherwise the profile is created.
+func (rest *RestOperations) buildPersistenceProfileForName(persistence_node *nodes.AviPersistenceProfileNode, namespace string) *utils.RestOp {
+	persistence_key := avicache.NamespaceName{Namespace: namespace, Name: persistence_node.Name}
+	if persistence_cache, ok := rest.cache.PersistenceCache.AviCacheGet(persistence_key); ok {
+		persistence_cache_obj, _ := persistence_cache.(*avicache.AviPersistenceProfileCache)
+		if persistence_cache_obj.CloudConfigCksum == persistence_node.GetCheckSum() {
+			return nil
+		}
+		return rest.AviPersistenceProfileBuild(persistence_node, persistence_cache_obj)
+	}
+	return rest.AviPersistenceProfileBuild(persistence_node, nil)
+}
+
+func (rest *RestOperations) PersistenceProfileDelete(persistenceProfileDelete []avicache.NamespaceName, namespace string, rest_ops []*utils.RestOp, key string) []*utils.RestOp {
+	utils.AviLog.Debugf("key: %s, msg: about to delete persistence profile %s", key, utils.Stringify(persistenceProfileDelete))
+	for _, delPersistence := range persistenceProfileDelete {
+		persistenceProfile := avicache.NamespaceName{Namespace: namespace, Name: delPersistence.Name}
+		persistenceCache, ok := rest.cache.PersistenceCache.AviCacheGet(persistenceProfile)
+		if ok {
+			persistenceCacheObj, _ := persistenceCache.(*avicache.AviPersistenceProfileCache)
+			restOp := rest.AviPersistenceProfileDel(persistenceCacheObj.Uuid, namespace)
+			restOp.ObjName = delPersistence.Name
+			rest_ops = append(rest_ops, restOp)
+		}
+	}
+	return rest_ops
+}
+
+func (rest *RestOperations) HealthMonitorCU(hm_node *nodes.AviHealthMonitorNode, pool_cache_obj *avicache.AviPoolCache, namespace string, rest_ops []*utils.RestOp, key string) ([]avicache.NamespaceName, []*utils.RestOp) {
+	// Default is POST
+	var cache_hm_nodes []avicache.NamespaceName
+	if pool_cache_obj != nil {
+		if pool_cache_obj.HealthMonitorCollection.Name != "" {
+			cache_hm_nodes = []avicache.NamespaceName{pool_cache_obj.HealthMonitorCollection}
+		}
+		if hm_node != nil {
+			hm_key := avicache.NamespaceName{Namespace: namespace, Name: hm_node.Name}
+			found := utils.HasElem(cache_hm_nodes, hm_key)
+			if found {
+				hm_cache, ok := rest.cache.HealthMonitorCache.AviCacheGet(hm_key)
+				if ok {
+					cache_hm_nodes = avicache.RemoveNamespaceName(cache_hm_nodes, hm_key)
+					hm_cache_obj, _ := hm_cache.(*avicache.AviHealthMonitorCache)
+					if hm_cache_obj.CloudConfigCksum == hm_node.GetCheckSum() {
+						utils.AviLog.Debugf("The checksums are same for health monitor cache obj %s, not doing anything", hm_cache_obj.Name)
+					} else {
+						// The checksums are different, so it should be a PUT call.
+						restOp := rest.AviHealthMonitorBuild(hm_node, hm_cache_obj)
+						if restOp != nil {
+							rest_ops = append(rest_ops, restOp)
+						}
+					}
+				}
+			} else {
+				restOp := rest.buildHealthMonitorForName(hm_node, namespace)
+				if restOp != nil {
+					rest_ops = append(rest_ops, restOp)
+				}
+			}
+		}
+	} else if hm_node != nil {
+		// Everything is a POST call, unless the health monitor is already present for this pool.
+		restOp := rest.buildHealthMonitorForName(hm_node, namespace)
+		if restOp != nil {
+			rest_ops = append(rest_ops, restOp)
+		}
+	}
+
+	return cache_hm_nodes, rest_ops
+}
+
+// buildHealthMonitorForName updates the health monitor if one with the same name is already present in the cache,
+// this is the case when the pool is recreated, otherwise the health monitor is created.
+func (rest *RestOperations) buildHealthMonitorForName(hm_node *nodes.AviHealthMonitorNode, namespace string) *utils.RestOp {
+	hm_key := avicache.NamespaceName{Namespace: namespace, Name: hm_node.Name}
+	if hm_cache, ok := rest.cache.HealthMonitorCache.AviCacheGet(hm_key); ok {
+		hm_cache_obj, _ := hm_cache.(*avicache.AviHealthMonitorCache)
+		if hm_cache_obj.CloudConfigCksum == hm_node.GetCheckSum() {
+			return nil
+		}
+		return rest.AviHealthMonitorBuild(hm_node, hm_cache_obj)
+	}
+	return rest.AviHealthMonitorBuild(hm_node, nil)
+}
+
+func (rest *RestOperations) HealthMonitorDelete(hmDelete []avicache.NamespaceName, namespace string, rest_ops []*utils.RestOp, key string) []*utils.RestOp {
+	utils.AviLog.Debugf("key: %s, msg: about to delete health monitor %s", key, utils.Stringify(hmDelete))
+	for _, delHM := range hmDelete {
+		healthMonitor := avicache.NamespaceName{Namespace: namespace, Name: delHM.Name}
+		hmCache, ok := rest.cache.HealthMonitorCache.AviCacheGet(healthMonitor)
+		if ok {
+			hmCacheObj, _ := hmCache.(*avicache.AviHealthMonitorCache)
+			restOp := rest.AviHealthMonitorDel(hmCacheObj.Uuid, namespace)
+			restOp.ObjName = delHM.Name
+			rest_ops = append(rest_ops, restOp)
+		}
+	}
+	return rest_ops
+}
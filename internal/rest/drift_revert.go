@@ -0,0 +1,101 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+package rest
+
+import (
+	"sync"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+// DriftRevert is an Avi object of a model which was changed or deleted out of band, and has to be
+// synced again from the model.
+type DriftRevert struct {
+	Model   string
+	Tenant  string
+	Name    string
+	Uuid    string
+	Deleted bool
+}
+
+// driftReverts holds the objects to be reverted, keyed by the model name. The cache entries of the
+// objects are invalidated by the rest worker of the model before it syncs the model, so that the cache
+// is not changed behind a sync of the same model.
+var driftReverts = struct {
+	sync.Mutex
+	models map[string][]DriftRevert
+}{models: make(map[string][]DriftRevert)}
+
+// AddDriftRevert queues an object to be reverted by the next rest layer sync of the model.
+func AddDriftRevert(modelName string, revert DriftRevert) {
+	driftReverts.Lock()
+	defer driftReverts.Unlock()
+	driftReverts.models[modelName] = append(driftReverts.models[modelName], revert)
+}
+
+func popDriftReverts(modelName string) []DriftRevert {
+	driftReverts.Lock()
+	defer driftReverts.Unlock()
+	reverts := driftReverts.models[modelName]
+	delete(driftReverts.models, modelName)
+	return reverts
+}
+
+// revertDriftObjects updates the cache entries of the objects queued for the model, so that the sync
+// updates the objects from the model, or creates them again if they were deleted. The pool and vsvip
+// cache entries are replaced instead of being updated in place, as these are read without a lock.
+func (rest *RestOperations) revertDriftObjects(key string) {
+	for _, revert := range popDriftReverts(key) {
+		utils.AviLog.Infof("key: %s, msg: reverting the %s %s/%s changed out of band", key, revert.Model, revert.Tenant, revert.Name)
+		cacheKey := avicache.NamespaceName{Namespace: revert.Tenant, Name: revert.Name}
+		switch revert.Model {
+		case "VirtualService":
+			if revert.Deleted {
+				rest.cache.VsCacheMeta.AviCacheDelete(cacheKey)
+			} else if vsCacheIntf, ok := rest.cache.VsCacheMeta.AviCacheGet(cacheKey); ok {
+				if vsCache, ok := vsCacheIntf.(*avicache.AviVsCache); ok {
+					vsCache.VSCacheLock.Lock()
+					vsCache.Uuid = revert.Uuid
+					vsCache.CloudConfigCksum = ""
+					vsCache.VSCacheLock.Unlock()
+				}
+			}
+		case "Pool":
+			if revert.Deleted {
+				rest.cache.PoolCache.AviCacheDelete(cacheKey)
+			} else if poolCacheIntf, ok := rest.cache.PoolCache.AviCacheGet(cacheKey); ok {
+				if poolCache, ok := poolCacheIntf.(*avicache.AviPoolCache); ok {
+					revertedPool := *poolCache
+					revertedPool.Uuid = revert.Uuid
+					revertedPool.CloudConfigCksum = ""
+					// An empty non server checksum makes the rest layer replace the whole pool.
+					revertedPool.NonServerCksum = ""
+					rest.cache.PoolCache.AviCacheAdd(cacheKey, &revertedPool)
+				}
+			}
+		case "VsVip":
+			if revert.Deleted {
+				rest.cache.VSVIPCache.AviCacheDelete(cacheKey)
+			} else if vsvipCacheIntf, ok := rest.cache.VSVIPCache.AviCacheGet(cacheKey); ok {
+				if vsvipCache, ok := vsvipCacheIntf.(*avicache.AviVSVIPCache); ok {
+					revertedVsVip := *vsvipCache
+					revertedVsVip.Uuid = revert.Uuid
+					revertedVsVip.CloudConfigCksum = ""
+					rest.cache.VSVIPCache.AviCacheAdd(cacheKey, &revertedVsVip)
+				}
+			}
+		}
+	}
+}
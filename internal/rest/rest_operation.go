@@ -17,7 +17,9 @@ package rest
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
@@ -253,6 +255,7 @@ func AviRestOperate(c *clients.AviClient, rest_ops []*utils.RestOp) error {
 			SetVersion := session.SetVersion(op.Version)
 			SetVersion(c.AviSession)
 		}
+		start := time.Now()
 		switch op.Method {
 		case utils.RestPost:
 			op.Err = c.AviSession.Post(op.Path, op.Obj, &op.Response)
@@ -269,6 +272,7 @@ func AviRestOperate(c *clients.AviClient, rest_ops []*utils.RestOp) error {
 			utils.AviLog.Errorf("Unknown RestOp %v", op.Method)
 			op.Err = fmt.Errorf("Unknown RestOp %v", op.Method)
 		}
+		utils.ObserveAviRestCall(string(op.Method), op.Model, restOpStatus(op.Err), time.Since(start))
 		if op.Err != nil {
 			utils.AviLog.Warnf(`RestOp method %v path %v tenant %v Obj %s returned err %s with response %s`,
 				op.Method, op.Path, op.Tenant, utils.Stringify(op.Obj), utils.Stringify(op.Err), utils.Stringify(op.Response))
@@ -302,3 +306,15 @@ func AviRestOperate(c *clients.AviClient, rest_ops []*utils.RestOp) error {
 	}
 	return nil
 }
+
+// restOpStatus returns the HTTP status code of a failed rest operation, as returned by the controller.
+// The successful operations are reported as 2xx, and the errors without a status code as error.
+func restOpStatus(err error) string {
+	if err == nil {
+		return "2xx"
+	}
+	if aviErr, ok := err.(session.AviError); ok && aviErr.HttpStatusCode != 0 {
+		return strconv.Itoa(aviErr.HttpStatusCode)
+	}
+	return "error"
+}
@@ -43,6 +43,10 @@ type UpdateOptions struct {
 	Key                string
 	VirtualServiceUUID string
 	VSName             string
+	// Conditions are the runtime status conditions of the object, set for the runtime status updates
+	Conditions []metav1.Condition
+	// SvcApiRoutes are the services API routes, as kind/namespace/name, whose status is updated for the gateway
+	SvcApiRoutes []string
 }
 
 // VSUuidAnnotation is maps a hostname to the UUID of the virtual service where it is placed.
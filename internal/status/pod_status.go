@@ -0,0 +1,222 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/third_party/github.com/vmware/alb-sdk/go/clients"
+)
+
+const (
+	serverOperUp       = "OPER_UP"
+	poolMemberUp       = "PoolMemberUp"
+	poolMemberDown     = "PoolMemberDown"
+	poolMemberNotFound = "PoolMemberNotFound"
+)
+
+// poolMemberStatus is the runtime status of the pool servers of a pod IP.
+type poolMemberStatus struct {
+	pools     []string
+	downPools []string
+}
+
+// SyncPodReadinessGates sets the pool member readiness gate condition of the pods which have the readiness gate.
+// The condition is true only if the pool servers of the pod are up in all the pools which have the pod.
+func SyncPodReadinessGates() {
+	if lib.DisableSync {
+		return
+	}
+	pods := getReadinessGatePods()
+	if len(pods) == 0 {
+		return
+	}
+	aviClients := avicache.SharedAVIClients()
+	if len(aviClients.AviClient) == 0 {
+		utils.AviLog.Warnf("No avi clients found, skipping the pod readiness gate sync")
+		return
+	}
+
+	podIPs := make(map[string]bool)
+	for _, pod := range pods {
+		for _, podIP := range getPodIPs(pod) {
+			podIPs[podIP] = true
+		}
+	}
+	memberStatus := getPoolMemberStatus(aviClients.AviClient[0], podIPs)
+	for _, pod := range pods {
+		updatePodReadinessCondition(pod, memberStatus)
+	}
+}
+
+// getReadinessGatePods returns the pods which have the pool member readiness gate and a pod IP.
+func getReadinessGatePods() []*corev1.Pod {
+	var pods []*corev1.Pod
+	if utils.GetInformers().PodInformer == nil {
+		return pods
+	}
+	podObjs, err := utils.GetInformers().PodInformer.Lister().Pods(metav1.NamespaceAll).List(labels.Everything())
+	if err != nil {
+		utils.AviLog.Warnf("Error in listing the pods for the readiness gate sync: %v", err)
+		return pods
+	}
+	for _, pod := range podObjs {
+		if pod.DeletionTimestamp != nil || pod.Status.PodIP == "" || lib.IsNamespaceBlocked(pod.Namespace) {
+			continue
+		}
+		for _, gate := range pod.Spec.ReadinessGates {
+			if gate.ConditionType == lib.PoolMemberReadyConditionType {
+				pods = append(pods, pod)
+				break
+			}
+		}
+	}
+	return pods
+}
+
+// getPoolMemberStatus fetches the runtime status of the servers of the pools which have any of the given pod IPs
+// as a server in the cache, and returns the status of the servers by their IP.
+func getPoolMemberStatus(client *clients.AviClient, podIPs map[string]bool) map[string]*poolMemberStatus {
+	memberStatus := make(map[string]*poolMemberStatus)
+	poolCache := avicache.SharedAviObjCache().PoolCache
+	for _, poolKey := range poolCache.AviGetAllKeys() {
+		poolIntf, ok := poolCache.AviCacheGet(poolKey)
+		if !ok {
+			continue
+		}
+		pool, ok := poolIntf.(*avicache.AviPoolCache)
+		if !ok || pool.Uuid == "" || !hasPoolServerIn(pool, podIPs) {
+			continue
+		}
+
+		uri := "/api/pool/" + pool.Uuid + "/runtime/server/"
+		var servers []map[string]interface{}
+		if err := lib.AviGet(client, uri, &servers); err != nil {
+			utils.AviLog.Warnf("Pool runtime Get uri %v returned err %v", uri, err)
+			continue
+		}
+		for _, server := range servers {
+			serverIP, ok := server["server_ip"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			addr, ok := serverIP["addr"].(string)
+			if !ok {
+				continue
+			}
+			if _, ok := memberStatus[addr]; !ok {
+				memberStatus[addr] = &poolMemberStatus{}
+			}
+			status := memberStatus[addr]
+			if !utils.HasElem(status.pools, pool.Name) {
+				status.pools = append(status.pools, pool.Name)
+			}
+			var state string
+			if operStatus, ok := server["oper_status"].(map[string]interface{}); ok {
+				state, _ = operStatus["state"].(string)
+			}
+			if state != serverOperUp && !utils.HasElem(status.downPools, pool.Name) {
+				status.downPools = append(status.downPools, pool.Name)
+			}
+		}
+	}
+	return memberStatus
+}
+
+// hasPoolServerIn returns true if any of the servers of the pool in the cache has one of the given IPs.
+func hasPoolServerIn(pool *avicache.AviPoolCache, ips map[string]bool) bool {
+	for serverKey := range pool.Servers {
+		if i := strings.LastIndex(serverKey, ":"); i != -1 && ips[serverKey[:i]] {
+			return true
+		}
+	}
+	return false
+}
+
+// getPodIPs returns the IPs of the pod.
+func getPodIPs(pod *corev1.Pod) []string {
+	podIPs := []string{pod.Status.PodIP}
+	for _, podIP := range pod.Status.PodIPs {
+		if !utils.HasElem(podIPs, podIP.IP) {
+			podIPs = append(podIPs, podIP.IP)
+		}
+	}
+	return podIPs
+}
+
+func updatePodReadinessCondition(pod *corev1.Pod, memberStatus map[string]*poolMemberStatus) {
+	var pools, downPools []string
+	for _, podIP := range getPodIPs(pod) {
+		if status, ok := memberStatus[podIP]; ok {
+			pools = append(pools, status.pools...)
+			downPools = append(downPools, status.downPools...)
+		}
+	}
+
+	condition := corev1.PodCondition{
+		Type:   lib.PoolMemberReadyConditionType,
+		Status: corev1.ConditionFalse,
+	}
+	if len(pools) == 0 {
+		condition.Reason = poolMemberNotFound
+		condition.Message = "Pod is not a server in any pool"
+	} else if len(downPools) != 0 {
+		sort.Strings(downPools)
+		condition.Reason = poolMemberDown
+		condition.Message = fmt.Sprintf("Pod is not up in the pools %s", strings.Join(downPools, ", "))
+	} else {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = poolMemberUp
+		condition.Message = fmt.Sprintf("Pod is up in %d pools", len(pools))
+	}
+
+	condition.LastTransitionTime = metav1.Now()
+	for _, c := range pod.Status.Conditions {
+		if c.Type != lib.PoolMemberReadyConditionType {
+			continue
+		}
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return
+		}
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+		break
+	}
+
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.PodCondition{condition},
+		},
+	})
+	_, err := utils.GetInformers().ClientSet.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.StrategicMergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	if err != nil {
+		utils.AviLog.Warnf("Error in updating the %s condition of pod %s/%s: %v", lib.PoolMemberReadyConditionType, pod.Namespace, pod.Name, err)
+		return
+	}
+	utils.AviLog.Infof("Updated the %s condition of pod %s/%s to %s: %s", lib.PoolMemberReadyConditionType, pod.Namespace, pod.Name, condition.Status, condition.Message)
+}
@@ -304,8 +304,17 @@ func updateRouteObject(mRoute *routev1.Route, updateOption UpdateOptions, retryN
 	oldRouteStatus := mRoute.Status.DeepCopy()
 
 	// If we find a hostname in the present update, let's first remove it from the existing status.
+	// The runtime status conditions of the hostname are retained for the fresh update.
+	runtimeConditions := make(map[string][]routev1.RouteIngressCondition)
 	for i := len(mRoute.Status.Ingress) - 1; i >= 0; i-- {
 		if utils.HasElem(hostnames, mRoute.Status.Ingress[i].Host) && mRoute.Status.Ingress[i].RouterName == lib.AKOUser {
+			if _, ok := runtimeConditions[mRoute.Status.Ingress[i].Host]; !ok {
+				for _, condition := range mRoute.Status.Ingress[i].Conditions {
+					if isRuntimeCondition(string(condition.Type)) {
+						runtimeConditions[mRoute.Status.Ingress[i].Host] = append(runtimeConditions[mRoute.Status.Ingress[i].Host], condition)
+					}
+				}
+			}
 			mRoute.Status.Ingress = append(mRoute.Status.Ingress[:i], mRoute.Status.Ingress[i+1:]...)
 		}
 	}
@@ -323,9 +332,9 @@ func updateRouteObject(mRoute *routev1.Route, updateOption UpdateOptions, retryN
 			rtIngress := routev1.RouteIngress{
 				Host:       host,
 				RouterName: lib.AKOUser,
-				Conditions: []routev1.RouteIngressCondition{
+				Conditions: append([]routev1.RouteIngressCondition{
 					condition,
-				},
+				}, runtimeConditions[host]...),
 			}
 			mRoute.Status.Ingress = append(mRoute.Status.Ingress, rtIngress)
 		}
@@ -0,0 +1,696 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	advl4v1alpha1pre1 "github.com/vmware-tanzu/service-apis/apis/v1alpha1pre1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	avicache "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/third_party/github.com/vmware/alb-sdk/go/clients"
+)
+
+const (
+	vsOperUp        = "OPER_UP"
+	vsOperDown      = "OPER_DOWN"
+	vsOperResources = "OPER_RESOURCES"
+	vsOperDisabled  = "OPER_DISABLED"
+
+	// Reasons of the runtime status conditions
+	virtualServiceUp             = "VirtualServiceUp"
+	virtualServiceDown           = "VirtualServiceDown"
+	virtualServiceDisabled       = "VirtualServiceDisabled"
+	virtualServiceNotReady       = "VirtualServiceNotReady"
+	serviceEnginePlacementFailed = "ServiceEnginePlacementFailed"
+	backendsUp                   = "BackendsUp"
+	backendsDegraded             = "BackendsDegraded"
+	backendsDown                 = "BackendsDown"
+)
+
+// vsRuntime is the operational status of a virtual service.
+type vsRuntime struct {
+	state   string
+	reasons []string
+}
+
+// poolRuntime is the number of servers of a pool, and the number of them which are up.
+type poolRuntime struct {
+	servers   int
+	serversUp int
+}
+
+// objectRuntime is the runtime status of the virtual services and pools of a Kubernetes object.
+type objectRuntime struct {
+	objType   string
+	namespace string
+	name      string
+	vses      map[string]*vsRuntime
+	pools     map[string]*poolRuntime
+	// incomplete is set if the runtime of any of the virtual services or pools could not be fetched.
+	incomplete bool
+}
+
+// runtimeFetcher holds the runtime of the virtual services and pools created by AKO, fetched from the inventory
+// APIs of the controller once per sync.
+type runtimeFetcher struct {
+	vses  map[string]*vsRuntime
+	pools map[string]*poolRuntime
+}
+
+// SyncRuntimeStatuses fetches the runtime status of the virtual services and pools in the cache, and publishes
+// the AviVirtualServiceReady and BackendsHealthy conditions of the Services, Ingresses, Routes and Gateways
+// which own them to the status queue.
+func SyncRuntimeStatuses() {
+	if lib.DisableSync {
+		return
+	}
+	aviClients := avicache.SharedAVIClients()
+	if len(aviClients.AviClient) == 0 {
+		utils.AviLog.Warnf("No avi clients found, skipping the runtime status sync")
+		return
+	}
+
+	fetcher, err := newRuntimeFetcher(aviClients.AviClient[0])
+	if err != nil {
+		utils.AviLog.Warnf("Error in fetching the runtime of the virtual services and pools, skipping the runtime status sync: %v", err)
+		return
+	}
+	objects := getObjectRuntimes(fetcher)
+
+	objKeys := make([]string, 0, len(objects))
+	for objKey := range objects {
+		objKeys = append(objKeys, objKey)
+	}
+	sort.Strings(objKeys)
+	for _, objKey := range objKeys {
+		obj := objects[objKey]
+		if obj.incomplete {
+			utils.AviLog.Debugf("key: %s, msg: runtime status is not available, skipping the condition update", objKey)
+			continue
+		}
+		conditions := []metav1.Condition{getVirtualServiceReadyCondition(obj.vses)}
+		if len(obj.pools) > 0 {
+			conditions = append(conditions, getBackendsHealthyCondition(obj.pools))
+		}
+
+		statusOption := StatusOptions{
+			ObjType:   obj.objType,
+			Op:        lib.UpdateRuntimeStatus,
+			ObjName:   obj.name,
+			Namespace: obj.namespace,
+			Key:       objKey,
+			Options: &UpdateOptions{
+				Key:        objKey,
+				Conditions: conditions,
+			},
+		}
+		// The ingresses and routes are published with their name, as in their status updates.
+		bktKey := obj.namespace + "/" + obj.name
+		if obj.objType == utils.Ingress || obj.objType == utils.OshiftRoute {
+			bktKey = obj.name
+		}
+		PublishToStatusQueue(bktKey, statusOption)
+	}
+}
+
+// getObjectRuntimes maps the virtual services and pools in the cache to the Kubernetes objects which own them,
+// along with their runtime.
+func getObjectRuntimes(fetcher *runtimeFetcher) map[string]*objectRuntime {
+	objects := make(map[string]*objectRuntime)
+	getObject := func(objType, nsName string) *objectRuntime {
+		objKey := objType + "/" + nsName
+		if obj, ok := objects[objKey]; ok {
+			return obj
+		}
+		nsNameSplit := strings.Split(nsName, "/")
+		if len(nsNameSplit) != 2 {
+			return nil
+		}
+		obj := &objectRuntime{
+			objType:   objType,
+			namespace: nsNameSplit[0],
+			name:      nsNameSplit[1],
+			vses:      make(map[string]*vsRuntime),
+			pools:     make(map[string]*poolRuntime),
+		}
+		objects[objKey] = obj
+		return obj
+	}
+
+	aviObjCache := avicache.SharedAviObjCache()
+	for _, vsKey := range aviObjCache.VsCacheMeta.AviGetAllKeys() {
+		if vsKey.Name == lib.DummyVSForStaleData {
+			continue
+		}
+		vsIntf, ok := aviObjCache.VsCacheMeta.AviCacheGet(vsKey)
+		if !ok {
+			continue
+		}
+		vsCache, ok := vsIntf.(*avicache.AviVsCache)
+		if !ok || vsCache.Uuid == "" {
+			continue
+		}
+
+		// The gateways and the services of type LoadBalancer own their virtual services.
+		vsObjects := make(map[*objectRuntime]bool)
+		vsMetadata := vsCache.ServiceMetadataObj
+		if vsMetadata.Gateway != "" {
+			objType := lib.Gateway
+			if lib.UseServicesAPI() {
+				objType = lib.SERVICES_API
+			}
+			if obj := getObject(objType, vsMetadata.Gateway); obj != nil {
+				vsObjects[obj] = true
+			}
+		} else {
+			for _, svc := range vsMetadata.NamespaceServiceName {
+				if obj := getObject(utils.L4LBService, svc); obj != nil {
+					vsObjects[obj] = true
+				}
+			}
+		}
+
+		vs := fetcher.getVirtualServiceRuntime(vsCache)
+		for _, poolKey := range vsCache.PoolKeyCollection {
+			poolIntf, ok := aviObjCache.PoolCache.AviCacheGet(poolKey)
+			if !ok {
+				continue
+			}
+			poolCache, ok := poolIntf.(*avicache.AviPoolCache)
+			if !ok || poolCache.Uuid == "" {
+				continue
+			}
+
+			// The ingresses, routes and the services behind the gateways own the pools of their backends.
+			poolObjects := make(map[*objectRuntime]bool)
+			for obj := range vsObjects {
+				poolObjects[obj] = true
+			}
+			poolMetadata := poolCache.ServiceMetadataObj
+			switch poolMetadata.ServiceMetadataMapping("Pool") {
+			case lib.GatewayPool:
+				for _, svc := range poolMetadata.NamespaceServiceName {
+					if obj := getObject(utils.L4LBService, svc); obj != nil {
+						poolObjects[obj] = true
+					}
+				}
+			case lib.SNIInsecureOrEVHPool:
+				if poolMetadata.IsMCIIngress {
+					break
+				}
+				objType := utils.Ingress
+				if utils.GetInformers().RouteInformer != nil {
+					objType = utils.OshiftRoute
+				}
+				nsName := poolMetadata.Namespace + "/" + poolMetadata.IngressName
+				if obj := getObject(objType, nsName); obj != nil {
+					poolObjects[obj] = true
+				}
+			}
+
+			pool := fetcher.getPoolRuntime(poolCache)
+			for obj := range poolObjects {
+				obj.addRuntime(vsCache.Name, vs, poolCache.Name, pool)
+			}
+		}
+
+		for obj := range vsObjects {
+			obj.addRuntime(vsCache.Name, vs, "", nil)
+		}
+	}
+	return objects
+}
+
+func (obj *objectRuntime) addRuntime(vsName string, vs *vsRuntime, poolName string, pool *poolRuntime) {
+	if vs == nil {
+		obj.incomplete = true
+	} else {
+		obj.vses[vsName] = vs
+	}
+	if poolName == "" {
+		return
+	}
+	if pool == nil {
+		obj.incomplete = true
+	} else {
+		obj.pools[poolName] = pool
+	}
+}
+
+// newRuntimeFetcher fetches the runtime of all the virtual services and pools created by AKO in the cloud, with
+// one paged request for each of the virtual service and pool inventories.
+func newRuntimeFetcher(client *clients.AviClient) (*runtimeFetcher, error) {
+	f := &runtimeFetcher{
+		vses:  make(map[string]*vsRuntime),
+		pools: make(map[string]*poolRuntime),
+	}
+	query := "/?include_name=true&cloud_ref.name=" + utils.CloudName + "&created_by=" + lib.AKOUser + "&page_size=100"
+	if err := fetchInventory(client, "/api/virtualservice-inventory", "/api/virtualservice-inventory"+query, f.addVirtualServiceRuntime); err != nil {
+		return nil, err
+	}
+	if err := fetchInventory(client, "/api/pool-inventory", "/api/pool-inventory"+query, f.addPoolRuntime); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// fetchInventory calls addRuntime with the uuid and the runtime of each of the objects in the inventory, following
+// the next pages of the inventory.
+func fetchInventory(client *clients.AviClient, inventoryPath, uri string, addRuntime func(uuid string, objRuntime map[string]interface{})) error {
+	result, err := lib.AviGetCollectionRaw(client, uri)
+	if err != nil {
+		return err
+	}
+	var elems []map[string]interface{}
+	if err := json.Unmarshal(result.Results, &elems); err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		config, _ := elem["config"].(map[string]interface{})
+		uuid, _ := config["uuid"].(string)
+		objRuntime, _ := elem["runtime"].(map[string]interface{})
+		if uuid == "" || objRuntime == nil {
+			continue
+		}
+		addRuntime(uuid, objRuntime)
+	}
+	if result.Next != "" {
+		nextURI := strings.Split(result.Next, inventoryPath)
+		if len(nextURI) > 1 {
+			return fetchInventory(client, inventoryPath, inventoryPath+nextURI[1], addRuntime)
+		}
+	}
+	return nil
+}
+
+func (f *runtimeFetcher) addVirtualServiceRuntime(uuid string, objRuntime map[string]interface{}) {
+	operStatus, ok := objRuntime["oper_status"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	vs := &vsRuntime{}
+	vs.state, _ = operStatus["state"].(string)
+	if vs.state == "" {
+		return
+	}
+	reasons, _ := operStatus["reason"].([]interface{})
+	for _, reason := range reasons {
+		if reasonStr, ok := reason.(string); ok {
+			vs.reasons = append(vs.reasons, reasonStr)
+		}
+	}
+	f.vses[uuid] = vs
+}
+
+func (f *runtimeFetcher) addPoolRuntime(uuid string, objRuntime map[string]interface{}) {
+	servers, ok := objRuntime["num_servers"].(float64)
+	if !ok {
+		return
+	}
+	serversUp, _ := objRuntime["num_servers_up"].(float64)
+	f.pools[uuid] = &poolRuntime{servers: int(servers), serversUp: int(serversUp)}
+}
+
+// getVirtualServiceRuntime returns the operational status of the virtual service, or nil if it is not in the inventory.
+func (f *runtimeFetcher) getVirtualServiceRuntime(vsCache *avicache.AviVsCache) *vsRuntime {
+	vs, ok := f.vses[vsCache.Uuid]
+	if !ok {
+		utils.AviLog.Debugf("Runtime of the virtualservice %s not found in the inventory", vsCache.Name)
+	}
+	return vs
+}
+
+// getPoolRuntime returns the number of servers of the pool which are up, or nil if it is not in the inventory.
+func (f *runtimeFetcher) getPoolRuntime(poolCache *avicache.AviPoolCache) *poolRuntime {
+	pool, ok := f.pools[poolCache.Uuid]
+	if !ok {
+		utils.AviLog.Debugf("Runtime of the pool %s not found in the inventory", poolCache.Name)
+	}
+	return pool
+}
+
+// getVirtualServiceReadyCondition returns a true condition if all the virtual services are up, otherwise a false
+// condition with the reason of the first virtual service which is not up.
+func getVirtualServiceReadyCondition(vses map[string]*vsRuntime) metav1.Condition {
+	vsNames := make([]string, 0, len(vses))
+	for vsName := range vses {
+		vsNames = append(vsNames, vsName)
+	}
+	sort.Strings(vsNames)
+
+	condition := metav1.Condition{
+		Type:    lib.VirtualServiceReadyConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  virtualServiceUp,
+		Message: fmt.Sprintf("Virtual service %s is up", strings.Join(vsNames, ", ")),
+	}
+	if len(vsNames) > 1 {
+		condition.Message = fmt.Sprintf("All the %d virtual services are up", len(vsNames))
+	}
+	for _, vsName := range vsNames {
+		vs := vses[vsName]
+		if vs.state == vsOperUp {
+			continue
+		}
+		condition.Status = metav1.ConditionFalse
+		switch vs.state {
+		case vsOperDown:
+			condition.Reason = virtualServiceDown
+		case vsOperResources:
+			condition.Reason = serviceEnginePlacementFailed
+		case vsOperDisabled:
+			condition.Reason = virtualServiceDisabled
+		default:
+			condition.Reason = virtualServiceNotReady
+		}
+		condition.Message = fmt.Sprintf("Virtual service %s is %s", vsName, vs.state)
+		if len(vs.reasons) > 0 {
+			condition.Message += ": " + strings.Join(vs.reasons, ", ")
+		}
+		break
+	}
+	return condition
+}
+
+// getBackendsHealthyCondition returns a false condition if any of the pools has no servers which are up,
+// otherwise a true condition. The message has no server counts, so that the status is not patched on every scale
+// of the backends.
+func getBackendsHealthyCondition(pools map[string]*poolRuntime) metav1.Condition {
+	var downPools, degradedPools []string
+	for poolName, pool := range pools {
+		if pool.serversUp == 0 {
+			downPools = append(downPools, poolName)
+		} else if pool.serversUp < pool.servers {
+			degradedPools = append(degradedPools, poolName)
+		}
+	}
+	sort.Strings(downPools)
+	sort.Strings(degradedPools)
+
+	condition := metav1.Condition{
+		Type:    lib.BackendsHealthyConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  backendsUp,
+		Message: "All the pool servers are up",
+	}
+	if len(downPools) != 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = backendsDown
+		condition.Message = fmt.Sprintf("No servers are up in the pools %s", strings.Join(downPools, ", "))
+	} else if len(degradedPools) != 0 {
+		condition.Reason = backendsDegraded
+		condition.Message = fmt.Sprintf("Servers are down in the pools %s", strings.Join(degradedPools, ", "))
+	}
+	return condition
+}
+
+// UpdateRuntimeConditions sets the runtime status conditions on the object, and raises events for the conditions
+// which have transitioned.
+func UpdateRuntimeConditions(obj StatusOptions) {
+	key, conditions := obj.Options.Key, obj.Options.Conditions
+	var err error
+	switch obj.ObjType {
+	case utils.L4LBService:
+		err = updateServiceRuntimeConditions(obj.Namespace, obj.ObjName, conditions)
+	case utils.Ingress:
+		err = updateIngressRuntimeConditions(obj.Namespace, obj.ObjName, conditions)
+	case utils.OshiftRoute:
+		err = updateRouteRuntimeConditions(obj.Namespace, obj.ObjName, conditions)
+	case lib.Gateway:
+		err = updateGatewayRuntimeConditions(obj.Namespace, obj.ObjName, conditions)
+	case lib.SERVICES_API:
+		err = updateSvcApiGatewayRuntimeConditions(obj.Namespace, obj.ObjName, conditions)
+	}
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: error in updating the runtime status conditions: %v", key, err)
+	}
+}
+
+func updateServiceRuntimeConditions(namespace, name string, conditions []metav1.Condition) error {
+	svc, err := utils.GetInformers().ServiceInformer.Lister().Services(namespace).Get(name)
+	if err != nil {
+		utils.AviLog.Debugf("Service %s/%s not found for the runtime status update: %v", namespace, name, err)
+		return nil
+	}
+	svcConditions := svc.Status.DeepCopy().Conditions
+	updated, transitions := setRuntimeConditions(&svcConditions, conditions)
+	if !updated {
+		return nil
+	}
+
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": svcConditions,
+		},
+	})
+	updatedSvc, err := utils.GetInformers().ClientSet.CoreV1().Services(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return err
+	}
+	raiseRuntimeEvents(updatedSvc, transitions)
+	utils.AviLog.Infof("Updated the runtime status conditions of service %s/%s: %s", namespace, name, utils.Stringify(svcConditions))
+	return nil
+}
+
+// updateIngressRuntimeConditions sets the runtime status conditions in an annotation of the ingress,
+// as the ingress status has no conditions.
+func updateIngressRuntimeConditions(namespace, name string, conditions []metav1.Condition) error {
+	ingress, err := utils.GetInformers().IngressInformer.Lister().Ingresses(namespace).Get(name)
+	if err != nil {
+		utils.AviLog.Debugf("Ingress %s/%s not found for the runtime status update: %v", namespace, name, err)
+		return nil
+	}
+	var ingConditions []metav1.Condition
+	if value, ok := ingress.Annotations[lib.RuntimeConditionsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &ingConditions); err != nil {
+			utils.AviLog.Warnf("Error in unmarshalling the runtime conditions of ingress %s/%s: %v", namespace, name, err)
+			ingConditions = nil
+		}
+	}
+	updated, transitions := setRuntimeConditions(&ingConditions, conditions)
+	if !updated {
+		return nil
+	}
+
+	ingConditionsBytes, _ := json.Marshal(ingConditions)
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				lib.RuntimeConditionsAnnotation: string(ingConditionsBytes),
+			},
+		},
+	})
+	updatedIng, err := utils.GetInformers().ClientSet.NetworkingV1().Ingresses(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	raiseRuntimeEvents(updatedIng, transitions)
+	utils.AviLog.Infof("Updated the runtime status conditions of ingress %s/%s: %s", namespace, name, string(ingConditionsBytes))
+	return nil
+}
+
+// updateRouteRuntimeConditions sets the runtime status conditions in the route ingresses admitted by AKO.
+func updateRouteRuntimeConditions(namespace, name string, conditions []metav1.Condition) error {
+	route, err := utils.GetInformers().RouteInformer.Lister().Routes(namespace).Get(name)
+	if err != nil {
+		utils.AviLog.Debugf("Route %s/%s not found for the runtime status update: %v", namespace, name, err)
+		return nil
+	}
+	routeStatus := route.Status.DeepCopy()
+	var transitions []metav1.Condition
+	var updated bool
+	for i := range routeStatus.Ingress {
+		if routeStatus.Ingress[i].RouterName != lib.AKOUser {
+			continue
+		}
+		var otherConditions []routev1.RouteIngressCondition
+		var rtConditions []metav1.Condition
+		for _, c := range routeStatus.Ingress[i].Conditions {
+			if !isRuntimeCondition(string(c.Type)) {
+				otherConditions = append(otherConditions, c)
+				continue
+			}
+			rtCondition := metav1.Condition{
+				Type:    string(c.Type),
+				Status:  metav1.ConditionStatus(c.Status),
+				Reason:  c.Reason,
+				Message: c.Message,
+			}
+			if c.LastTransitionTime != nil {
+				rtCondition.LastTransitionTime = *c.LastTransitionTime
+			}
+			rtConditions = append(rtConditions, rtCondition)
+		}
+		// All the route ingresses get the same conditions, so the events are raised only for the first one.
+		ingUpdated, ingTransitions := setRuntimeConditions(&rtConditions, conditions)
+		if !ingUpdated {
+			continue
+		}
+		if !updated {
+			transitions = ingTransitions
+			updated = true
+		}
+		for _, c := range rtConditions {
+			lastTransitionTime := c.LastTransitionTime
+			otherConditions = append(otherConditions, routev1.RouteIngressCondition{
+				Type:               routev1.RouteIngressConditionType(c.Type),
+				Status:             corev1.ConditionStatus(c.Status),
+				Reason:             c.Reason,
+				Message:            c.Message,
+				LastTransitionTime: &lastTransitionTime,
+			})
+		}
+		routeStatus.Ingress[i].Conditions = otherConditions
+	}
+	if !updated {
+		return nil
+	}
+
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"status": routeStatus,
+	})
+	updatedRoute, err := utils.GetInformers().OshiftClient.RouteV1().Routes(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return err
+	}
+	raiseRuntimeEvents(updatedRoute, transitions)
+	utils.AviLog.Infof("Updated the runtime status conditions of route %s/%s: %s", namespace, name, utils.Stringify(routeStatus.Ingress))
+	return nil
+}
+
+func updateGatewayRuntimeConditions(namespace, name string, conditions []metav1.Condition) error {
+	gw, err := lib.AKOControlConfig().AdvL4Informers().GatewayInformer.Lister().Gateways(namespace).Get(name)
+	if err != nil {
+		utils.AviLog.Debugf("Gateway %s/%s not found for the runtime status update: %v", namespace, name, err)
+		return nil
+	}
+	var gwConditions []advl4v1alpha1pre1.GatewayCondition
+	var rtConditions []metav1.Condition
+	for _, c := range gw.Status.Conditions {
+		if !isRuntimeCondition(string(c.Type)) {
+			gwConditions = append(gwConditions, c)
+			continue
+		}
+		rtConditions = append(rtConditions, metav1.Condition{
+			Type:               string(c.Type),
+			Status:             metav1.ConditionStatus(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+		})
+	}
+	updated, transitions := setRuntimeConditions(&rtConditions, conditions)
+	if !updated {
+		return nil
+	}
+	for _, c := range rtConditions {
+		gwConditions = append(gwConditions, advl4v1alpha1pre1.GatewayCondition{
+			Type:               advl4v1alpha1pre1.GatewayConditionType(c.Type),
+			Status:             corev1.ConditionStatus(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+		})
+	}
+
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": gwConditions,
+		},
+	})
+	updatedGW, err := lib.AKOControlConfig().AdvL4Clientset().NetworkingV1alpha1pre1().Gateways(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return err
+	}
+	raiseRuntimeEvents(updatedGW, transitions)
+	utils.AviLog.Infof("Updated the runtime status conditions of gateway %s/%s: %s", namespace, name, utils.Stringify(gwConditions))
+	return nil
+}
+
+func updateSvcApiGatewayRuntimeConditions(namespace, name string, conditions []metav1.Condition) error {
+	gw, err := lib.AKOControlConfig().SvcAPIInformers().GatewayInformer.Lister().Gateways(namespace).Get(name)
+	if err != nil {
+		utils.AviLog.Debugf("Gateway %s/%s not found for the runtime status update: %v", namespace, name, err)
+		return nil
+	}
+	gwConditions := gw.Status.DeepCopy().Conditions
+	updated, transitions := setRuntimeConditions(&gwConditions, conditions)
+	if !updated {
+		return nil
+	}
+
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": gwConditions,
+		},
+	})
+	updatedGW, err := lib.AKOControlConfig().ServicesAPIClientset().NetworkingV1alpha1().Gateways(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return err
+	}
+	raiseRuntimeEvents(updatedGW, transitions)
+	utils.AviLog.Infof("Updated the runtime status conditions of gateway %s/%s: %s", namespace, name, utils.Stringify(gwConditions))
+	return nil
+}
+
+func isRuntimeCondition(conditionType string) bool {
+	return conditionType == lib.VirtualServiceReadyConditionType || conditionType == lib.BackendsHealthyConditionType
+}
+
+// setRuntimeConditions sets the conditions in the existing conditions. It returns true if any of the conditions
+// has changed, along with the conditions which have transitioned to a new status or reason. A new condition is
+// a transition only if it is not true. The last transition time of a condition is retained if its status has not changed.
+func setRuntimeConditions(existing *[]metav1.Condition, conditions []metav1.Condition) (bool, []metav1.Condition) {
+	var updated bool
+	var transitions []metav1.Condition
+	for _, condition := range conditions {
+		c := meta.FindStatusCondition(*existing, condition.Type)
+		if c != nil && c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			continue
+		}
+		if (c == nil && condition.Status != metav1.ConditionTrue) ||
+			(c != nil && (c.Status != condition.Status || c.Reason != condition.Reason)) {
+			transitions = append(transitions, condition)
+		}
+		meta.SetStatusCondition(existing, condition)
+		updated = true
+	}
+	return updated, transitions
+}
+
+// raiseRuntimeEvents raises a warning event for each transitioned condition which is not true, and a normal event
+// for each transitioned condition which is true.
+func raiseRuntimeEvents(obj runtime.Object, transitions []metav1.Condition) {
+	for _, c := range transitions {
+		eventType := corev1.EventTypeNormal
+		if c.Status != metav1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+		lib.AKOControlConfig().EventRecorder().Event(obj, eventType, c.Reason, c.Message)
+	}
+}
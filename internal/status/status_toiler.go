@@ -41,6 +41,10 @@ func DequeueStatus(objIntf interface{}) error {
 		utils.AviLog.Warnf("key: %s, object is not of type StatusOptions, %T", obj.Options.Key, objIntf)
 		return nil
 	}
+	if obj.Op == lib.UpdateRuntimeStatus {
+		UpdateRuntimeConditions(obj)
+		return nil
+	}
 	switch obj.ObjType {
 	case utils.L4LBService:
 		if obj.Op == lib.UpdateStatus {
@@ -72,6 +76,12 @@ func DequeueStatus(objIntf interface{}) error {
 		} else if obj.Op == lib.DeleteStatus {
 			DeleteSvcApiGatewayStatusAddress(obj.Options.Key, obj.Options.ServiceMetadata)
 		}
+	case lib.SvcApiRoute:
+		if obj.Op == lib.UpdateStatus {
+			UpdateSvcApiRoutesStatus(obj.Options, true)
+		} else if obj.Op == lib.DeleteStatus {
+			UpdateSvcApiRoutesStatus(obj.Options, false)
+		}
 	case lib.NPLService:
 		if obj.Op == lib.UpdateStatus {
 			UpdateNPLAnnotation(obj.Key, obj.Namespace, obj.ObjName)
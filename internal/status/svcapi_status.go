@@ -424,3 +424,122 @@ func compareSvcApiGatewayStatuses(old, new *svcapiv1alpha1.GatewayStatus) bool {
 
 	return reflect.DeepEqual(oldStatus, newStatus)
 }
+
+// UpdateSvcApiRoutesStatus reports the routes in the options as admitted by the gateway of the options, or
+// removes the gateway from the status of the routes if they are no longer admitted by the gateway.
+func UpdateSvcApiRoutesStatus(options *UpdateOptions, admitted bool) {
+	var condition *UpdateSvcApiGWStatusConditionOptions
+	if admitted {
+		condition = &UpdateSvcApiGWStatusConditionOptions{
+			Type:   string(svcapiv1alpha1.ConditionRouteAdmitted),
+			Status: metav1.ConditionTrue,
+			Reason: "Admitted",
+		}
+	}
+	for _, route := range options.SvcApiRoutes {
+		routeKindNSName := strings.Split(route, "/")
+		if len(routeKindNSName) != 3 {
+			continue
+		}
+		UpdateSvcApiRouteStatus(options.Key, routeKindNSName[0], routeKindNSName[1], routeKindNSName[2], options.ServiceMetadata.Gateway, condition)
+	}
+}
+
+// UpdateSvcApiRouteStatus sets the condition reported by the gateway gwNSName in the status of the
+// HTTPRoute/TCPRoute namespace/name. The gateway entry is removed from the route status if the
+// condition is nil, which is the case when the gateway no longer admits the route.
+func UpdateSvcApiRouteStatus(key, routeKind, namespace, name, gwNSName string, condition *UpdateSvcApiGWStatusConditionOptions) {
+	var routeStatus *svcapiv1alpha1.RouteStatus
+	switch routeKind {
+	case lib.HTTPRoute:
+		route, err := lib.AKOControlConfig().SvcAPIInformers().HTTPRouteInformer.Lister().HTTPRoutes(namespace).Get(name)
+		if err != nil {
+			utils.AviLog.Debugf("key: %s, msg: httproute %s/%s not found for status update: %v", key, namespace, name, err)
+			return
+		}
+		routeStatus = route.Status.RouteStatus.DeepCopy()
+	case lib.TCPRoute:
+		route, err := lib.AKOControlConfig().SvcAPIInformers().TCPRouteInformer.Lister().TCPRoutes(namespace).Get(name)
+		if err != nil {
+			utils.AviLog.Debugf("key: %s, msg: tcproute %s/%s not found for status update: %v", key, namespace, name, err)
+			return
+		}
+		routeStatus = route.Status.RouteStatus.DeepCopy()
+	default:
+		return
+	}
+
+	gwNSNameArr := strings.Split(gwNSName, "/")
+	oldStatus := routeStatus.DeepCopy()
+	// the status of the gateway is updated in place, so that the order of the gateways in the status
+	// does not change with the updates from the other gateways of the route.
+	gatewayStatus := svcapiv1alpha1.RouteGatewayStatus{
+		GatewayRef: svcapiv1alpha1.GatewayReference{
+			Namespace: gwNSNameArr[0],
+			Name:      gwNSNameArr[1],
+		},
+	}
+	if condition != nil {
+		gatewayStatus.Conditions = []metav1.Condition{{
+			Type:               condition.Type,
+			Status:             condition.Status,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: metav1.Now(),
+		}}
+	}
+	gatewayStatuses := []svcapiv1alpha1.RouteGatewayStatus{}
+	found := false
+	for _, gwStatus := range routeStatus.Gateways {
+		if gwStatus.GatewayRef.Namespace != gwNSNameArr[0] || gwStatus.GatewayRef.Name != gwNSNameArr[1] {
+			gatewayStatuses = append(gatewayStatuses, gwStatus)
+		} else if condition != nil && !found {
+			gatewayStatuses = append(gatewayStatuses, gatewayStatus)
+			found = true
+		}
+	}
+	if condition != nil && !found {
+		gatewayStatuses = append(gatewayStatuses, gatewayStatus)
+	}
+	routeStatus.Gateways = gatewayStatuses
+
+	if compareSvcApiRouteStatuses(oldStatus, routeStatus) {
+		return
+	}
+
+	patchPayload, _ := json.Marshal(map[string]interface{}{
+		"status": routeStatus,
+	})
+	var err error
+	if routeKind == lib.HTTPRoute {
+		_, err = lib.AKOControlConfig().ServicesAPIClientset().NetworkingV1alpha1().HTTPRoutes(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	} else {
+		_, err = lib.AKOControlConfig().ServicesAPIClientset().NetworkingV1alpha1().TCPRoutes(namespace).Patch(context.TODO(), name, types.MergePatchType, patchPayload, metav1.PatchOptions{}, "status")
+	}
+	if err != nil {
+		utils.AviLog.Warnf("key: %s, msg: there was an error in updating the %s %s/%s status: %+v", key, routeKind, namespace, name, err)
+		return
+	}
+
+	utils.AviLog.Infof("key: %s, msg: Successfully updated the %s %s/%s status %+v", key, routeKind, namespace, name, utils.Stringify(routeStatus))
+}
+
+// do not compare lastTransitionTime updates in routes
+func compareSvcApiRouteStatuses(old, new *svcapiv1alpha1.RouteStatus) bool {
+	oldStatus, newStatus := old.DeepCopy(), new.DeepCopy()
+	currentTime := metav1.Now()
+	for _, gwStatus := range oldStatus.Gateways {
+		for i := range gwStatus.Conditions {
+			gwStatus.Conditions[i].LastTransitionTime = currentTime
+		}
+	}
+	for _, gwStatus := range newStatus.Gateways {
+		for i := range gwStatus.Conditions {
+			gwStatus.Conditions[i].LastTransitionTime = currentTime
+		}
+	}
+	if len(oldStatus.Gateways) == 0 && len(newStatus.Gateways) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(oldStatus.Gateways, newStatus.Gateways)
+}
@@ -18,10 +18,14 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
 )
 
 func TestMain(m *testing.M) {
@@ -56,3 +60,120 @@ func TestApiServerStatusModel(t *testing.T) {
 		t.Fail()
 	}
 }
+
+// TestApiServerMetricsModel tests that the metrics for the workqueues and the avi rest calls are served by the MetricsModel
+func TestApiServerMetricsModel(t *testing.T) {
+	akoApi := NewServer("0", []models.ApiModel{&models.MetricsModel{}})
+
+	queue := utils.NewWorkQueue(2, "MetricsTestLayer")
+	queue.Workqueue[0].Add("key1")
+	queue.Workqueue[1].Add("key2")
+	retryQueue := utils.NewWorkQueue(2, "MetricsRetryTestLayer")
+	retryQueue.Workqueue[0].AddRateLimited("key1")
+	retryQueue.Workqueue[1].AddRateLimited("key2")
+	utils.ObserveAviRestCall(string(utils.RestPost), "VirtualService", "2xx", 10*time.Millisecond)
+	utils.ObserveAviRestCall(string(utils.RestPut), "Pool", "409", 10*time.Millisecond)
+	utils.IncrementChecksumSkips(utils.ChecksumSkipGraphLayer)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	akoApi.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code for /metrics: %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	expected := []string{
+		`ako_workqueue_depth{queue="MetricsTestLayer"} 2`,
+		`ako_workqueue_adds_total{queue="MetricsTestLayer"} 2`,
+		`ako_workqueue_retries_total{queue="MetricsRetryTestLayer"} 2`,
+		`ako_avi_rest_requests_total{method="POST",model="VirtualService",status="2xx"} 1`,
+		`ako_avi_rest_requests_total{method="PUT",model="Pool",status="409"} 1`,
+		`ako_avi_rest_request_duration_seconds_count{method="PUT",model="Pool",status="409"} 1`,
+		`ako_model_checksum_skips_total{layer="graph"} 1`,
+		`go_goroutines`,
+	}
+	for _, metric := range expected {
+		if !strings.Contains(body, metric) {
+			t.Errorf("metric %s not found in /metrics response", metric)
+		}
+	}
+}
+
+func TestApiServerDryRunModel(t *testing.T) {
+	akoApi := NewServer("0", []models.ApiModel{&models.DryRunModel{}})
+
+	vsName := "cluster--Shared-L7-0"
+	models.DryRunPlan.RecordRestOps("admin/"+vsName, "admin/"+vsName, []*utils.RestOp{
+		{Method: utils.RestPost, Model: "Pool", Path: "/api/pool/", Tenant: "admin", Obj: map[string]string{"name": "pool1"}},
+		{Method: utils.RestPut, Model: "VirtualService", Path: "/api/virtualservice/vs-uuid", Tenant: "admin", Obj: map[string]string{"name": vsName}},
+	})
+	models.DryRunPlan.RecordRestOps("admin/"+vsName, "admin/"+vsName, []*utils.RestOp{
+		{Method: utils.RestDelete, Model: "Pool", Path: "/api/pool/pool2-uuid", Tenant: "admin", ObjName: "pool2"},
+	})
+	models.DryRunPlan.RecordRestOps("admin/cluster--Shared-L7-1", "admin/cluster--Shared-L7-1", []*utils.RestOp{
+		{Method: utils.RestPost, Model: "VirtualService", Path: "/api/virtualservice/", Tenant: "admin"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dryrun?model=admin/"+vsName, nil)
+	rec := httptest.NewRecorder()
+	akoApi.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code for /api/dryrun: %d", rec.Code)
+	}
+
+	var plan map[string]models.DryRunDiff
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil {
+		t.Fatalf("unable to unmarshal the /api/dryrun response: %v", err)
+	}
+	diff, ok := plan["admin/"+vsName]
+	if len(plan) != 1 || !ok {
+		t.Fatalf("unexpected plan in /api/dryrun response: %v", plan)
+	}
+	if len(diff.Create) != 1 || diff.Create[0].Model != "Pool" || string(diff.Create[0].Object) != `{"name":"pool1"}` {
+		t.Errorf("unexpected create operations in the plan: %v", diff.Create)
+	}
+	if len(diff.Update) != 1 || diff.Update[0].Model != "VirtualService" {
+		t.Errorf("unexpected update operations in the plan: %v", diff.Update)
+	}
+	if len(diff.Delete) != 1 || diff.Delete[0].Name != "pool2" {
+		t.Errorf("unexpected delete operations in the plan: %v", diff.Delete)
+	}
+
+	models.DryRunPlan.ClearModel("admin/" + vsName)
+	if plan := models.DryRunPlan.GetPlan(""); len(plan) != 1 {
+		t.Errorf("unexpected plan after clearing model admin/%s: %v", vsName, plan)
+	}
+}
+
+// TestApiServerAKOStatus tests that the AKO status is served by the StatusModel once the status function is set
+func TestApiServerAKOStatus(t *testing.T) {
+	akoApi := NewServer("0", []models.ApiModel{})
+	models.SetAKOStatusFunc(func() models.AKOStatus {
+		return models.AKOStatus{
+			Version:         "1.5.1",
+			SyncEnabled:     true,
+			VirtualServices: 3,
+			Leader:          true,
+		}
+	})
+	defer models.SetAKOStatusFunc(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	rec := httptest.NewRecorder()
+	akoApi.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code for /api/status: %d", rec.Code)
+	}
+
+	var status models.StatusModel
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("unable to unmarshal the /api/status response: %v", err)
+	}
+	if status.AKO == nil {
+		t.Fatalf("AKO status not found in /api/status response: %s", rec.Body.String())
+	}
+	if status.AKO.Version != "1.5.1" || !status.AKO.SyncEnabled || status.AKO.VirtualServices != 3 || !status.AKO.Leader {
+		t.Errorf("unexpected AKO status in /api/status response: %+v", *status.AKO)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+)
+
+// DryRunOperation is an Avi REST operation that would have been executed, if not for the dry run mode.
+type DryRunOperation struct {
+	Method  string          `json:"method"`
+	Model   string          `json:"model"`
+	Path    string          `json:"path"`
+	Tenant  string          `json:"tenant"`
+	Name    string          `json:"name,omitempty"`
+	PatchOp string          `json:"patch_op,omitempty"`
+	Object  json.RawMessage `json:"object,omitempty"`
+}
+
+// DryRunDiff holds the operations planned for an Avi virtualservice, or another top level object like
+// a vrfcontext, grouped by the change they would make, along with the Avi model they are part of.
+type DryRunDiff struct {
+	Model     string            `json:"model"`
+	Create    []DryRunOperation `json:"create,omitempty"`
+	Update    []DryRunOperation `json:"update,omitempty"`
+	Delete    []DryRunOperation `json:"delete,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+var DryRunPlan *DryRunModel
+var dryrunonce sync.Once
+
+// DryRunModel implements ApiModel, and serves the plan of the Avi REST operations collected in the
+// rest layer in the dry run mode, keyed by the tenant/name of the object the operations are for.
+type DryRunModel struct {
+	plan     map[string]DryRunDiff
+	planLock sync.RWMutex
+}
+
+func (a *DryRunModel) InitModel() {
+	dryrunonce.Do(func() {
+		DryRunPlan = &DryRunModel{
+			plan: make(map[string]DryRunDiff),
+		}
+	})
+}
+
+func (a *DryRunModel) ApiOperationMap() []OperationMap {
+	var operationMapList []OperationMap
+
+	get := OperationMap{
+		Route:  "/api/dryrun",
+		Method: "GET",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			response := DryRunPlan.GetPlan(r.URL.Query().Get("model"))
+			utils.Respond(w, response)
+		},
+	}
+
+	operationMapList = append(operationMapList, get)
+	return operationMapList
+}
+
+// ClearModel removes the planned operations for all the objects in the model, before the model is
+// processed again in the rest layer, so that the objects in sync with the controller are dropped.
+func (a *DryRunModel) ClearModel(modelName string) {
+	if a == nil {
+		return
+	}
+	a.planLock.Lock()
+	defer a.planLock.Unlock()
+	for objName, diff := range a.plan {
+		if diff.Model == modelName {
+			delete(a.plan, objName)
+		}
+	}
+}
+
+// RecordRestOps adds restOps to the planned operations for the object. The rest layer may go over an
+// object more than once while processing a model, e.g. for the creates and the deletes of its children.
+func (a *DryRunModel) RecordRestOps(modelName, objName string, restOps []*utils.RestOp) {
+	// The model is not initialized when AKO is not running in the dry run mode.
+	if a == nil || len(restOps) == 0 {
+		return
+	}
+	a.planLock.Lock()
+	defer a.planLock.Unlock()
+
+	diff, ok := a.plan[objName]
+	if !ok || diff.Model != modelName {
+		diff = DryRunDiff{Model: modelName}
+	}
+	diff.Timestamp = time.Now()
+	for _, restOp := range restOps {
+		op := DryRunOperation{
+			Method:  string(restOp.Method),
+			Model:   restOp.Model,
+			Path:    restOp.Path,
+			Tenant:  restOp.Tenant,
+			Name:    restOp.ObjName,
+			PatchOp: restOp.PatchOp,
+		}
+		if restOp.Obj != nil {
+			obj, err := json.Marshal(restOp.Obj)
+			if err != nil {
+				utils.AviLog.Warnf("Unable to marshal the %s object for the dry run plan of %s, err: %v", restOp.Model, objName, err)
+			} else {
+				op.Object = obj
+			}
+		}
+		switch restOp.Method {
+		case utils.RestPost:
+			diff.Create = append(diff.Create, op)
+		case utils.RestPut, utils.RestPatch:
+			diff.Update = append(diff.Update, op)
+		case utils.RestDelete:
+			diff.Delete = append(diff.Delete, op)
+		}
+	}
+	a.plan[objName] = diff
+}
+
+// GetPlan returns the planned operations for the objects in the model, or for all the objects if
+// modelName is empty.
+func (a *DryRunModel) GetPlan(modelName string) map[string]DryRunDiff {
+	plan := make(map[string]DryRunDiff)
+	if a == nil {
+		return plan
+	}
+	a.planLock.RLock()
+	defer a.planLock.RUnlock()
+	for objName, diff := range a.plan {
+		if modelName == "" || modelName == diff.Model {
+			plan[objName] = diff
+		}
+	}
+	return plan
+}
@@ -34,15 +34,35 @@ type RestStatusError struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// AKOStatus holds the state of the AKO instance, it is read by the ako-operator to report the
+// status of AKO in the AKOConfig.
+type AKOStatus struct {
+	Version                string `json:"version"`
+	ControllerVersion      string `json:"controller_version"`
+	SyncEnabled            bool   `json:"sync_enabled"`
+	DeleteConfigInProgress bool   `json:"delete_config_in_progress"`
+	VirtualServices        int    `json:"virtual_services"`
+	Leader                 bool   `json:"leader"`
+}
+
 var RestStatus *StatusModel
 var reststatusonce sync.Once
 
+// akoStatusFunc returns the current AKOStatus, it is set only by AKO.
+var akoStatusFunc func() AKOStatus
+
 // StatusModel implements ApiModel
 type StatusModel struct {
 	AviApi     AviApiRestStatus `json:"avi_api"`
+	AKO        *AKOStatus       `json:"ako,omitempty"`
 	statusLock sync.RWMutex
 }
 
+// SetAKOStatusFunc sets the function used to fetch the AKOStatus served at /api/status.
+func SetAKOStatusFunc(statusFunc func() AKOStatus) {
+	akoStatusFunc = statusFunc
+}
+
 func (a *StatusModel) InitModel() {
 	reststatusonce.Do(func() {
 		RestStatus = &StatusModel{
@@ -61,7 +81,13 @@ func (a *StatusModel) ApiOperationMap() []OperationMap {
 		Route:  "/api/status",
 		Method: "GET",
 		Handler: func(w http.ResponseWriter, r *http.Request) {
-			response := &RestStatus
+			RestStatus.statusLock.RLock()
+			response := &StatusModel{AviApi: RestStatus.AviApi}
+			RestStatus.statusLock.RUnlock()
+			if akoStatusFunc != nil {
+				akoStatus := akoStatusFunc()
+				response.AKO = &akoStatus
+			}
 			utils.Respond(w, response)
 		},
 	}
@@ -63,7 +63,8 @@ type AviInfraSettingSeGroup struct {
 }
 
 type AviInfraL7Settings struct {
-	ShardSize string `json:"shardSize,omitempty"`
+	ShardSize   string `json:"shardSize,omitempty"`
+	ShardScheme string `json:"shardScheme,omitempty"`
 }
 
 // AviInfraSettingStatus holds the status of the AviInfraSetting
@@ -60,20 +60,21 @@ const (
 
 // HostRuleVirtualHost defines properties for a host
 type HostRuleVirtualHost struct {
-	AnalyticsProfile   string                   `json:"analyticsProfile,omitempty"`
-	ApplicationProfile string                   `json:"applicationProfile,omitempty"`
-	Datascripts        []string                 `json:"datascripts,omitempty"`
-	EnableVirtualHost  *bool                    `json:"enableVirtualHost,omitempty"`
-	ErrorPageProfile   string                   `json:"errorPageProfile,omitempty"`
-	Fqdn               string                   `json:"fqdn,omitempty"`
-	FqdnType           FqdnType                 `json:"fqdnType,omitempty"`
-	HTTPPolicy         HostRuleHTTPPolicy       `json:"httpPolicy,omitempty"`
-	Gslb               HostRuleGSLB             `json:"gslb,omitempty"`
-	TLS                HostRuleTLS              `json:"tls,omitempty"`
-	WAFPolicy          string                   `json:"wafPolicy,omitempty"`
-	AnalyticsPolicy    *HostRuleAnalyticsPolicy `json:"analyticsPolicy,omitempty"`
-	TCPSettings        *HostRuleTCPSettings     `json:"tcpSettings,omitempty"`
-	Aliases            []string                 `json:"aliases,omitempty"`
+	AnalyticsProfile     string                   `json:"analyticsProfile,omitempty"`
+	ApplicationProfile   string                   `json:"applicationProfile,omitempty"`
+	Datascripts          []string                 `json:"datascripts,omitempty"`
+	DatascriptConfigMaps []string                 `json:"datascriptConfigMaps,omitempty"`
+	EnableVirtualHost    *bool                    `json:"enableVirtualHost,omitempty"`
+	ErrorPageProfile     string                   `json:"errorPageProfile,omitempty"`
+	Fqdn                 string                   `json:"fqdn,omitempty"`
+	FqdnType             FqdnType                 `json:"fqdnType,omitempty"`
+	HTTPPolicy           HostRuleHTTPPolicy       `json:"httpPolicy,omitempty"`
+	Gslb                 HostRuleGSLB             `json:"gslb,omitempty"`
+	TLS                  HostRuleTLS              `json:"tls,omitempty"`
+	WAFPolicy            string                   `json:"wafPolicy,omitempty"`
+	AnalyticsPolicy      *HostRuleAnalyticsPolicy `json:"analyticsPolicy,omitempty"`
+	TCPSettings          *HostRuleTCPSettings     `json:"tcpSettings,omitempty"`
+	Aliases              []string                 `json:"aliases,omitempty"`
 }
 
 // HostRuleTCPSettings allows for customizing TCP settings
@@ -117,8 +118,46 @@ const (
 
 // HostRuleHTTPPolicy holds knobs and refs for httpPolicySets
 type HostRuleHTTPPolicy struct {
-	PolicySets []string `json:"policySets,omitempty"`
-	Overwrite  bool     `json:"overwrite,omitempty"`
+	PolicySets []string                 `json:"policySets,omitempty"`
+	Overwrite  bool                     `json:"overwrite,omitempty"`
+	Rules      []HostRuleHTTPPolicyRule `json:"rules,omitempty"`
+}
+
+// HostRuleHTTPPolicyRule holds an inline http policy rule, which is applied
+// to the requests whose path starts with the given path
+type HostRuleHTTPPolicyRule struct {
+	Name             string                     `json:"name,omitempty"`
+	Path             string                     `json:"path,omitempty"`
+	Redirect         *HostRuleHTTPRedirect      `json:"redirect,omitempty"`
+	RewritePath      string                     `json:"rewritePath,omitempty"`
+	RequestHeaders   []HostRuleHTTPHeaderAction `json:"requestHeaders,omitempty"`
+	ResponseHeaders  []HostRuleHTTPHeaderAction `json:"responseHeaders,omitempty"`
+	AllowedClientIPs []string                   `json:"allowedClientIPs,omitempty"`
+	DeniedClientIPs  []string                   `json:"deniedClientIPs,omitempty"`
+	RateLimit        *HostRuleHTTPRateLimit     `json:"rateLimit,omitempty"`
+}
+
+// HostRuleHTTPRedirect redirects the matching requests
+type HostRuleHTTPRedirect struct {
+	Protocol   string `json:"protocol,omitempty"`
+	Host       string `json:"host,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Port       int32  `json:"port,omitempty"`
+	StatusCode int32  `json:"statusCode,omitempty"`
+}
+
+// HostRuleHTTPHeaderAction adds, replaces or removes a request/response header
+type HostRuleHTTPHeaderAction struct {
+	Action string `json:"action,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// HostRuleHTTPRateLimit limits the rate of the matching requests of each client
+type HostRuleHTTPRateLimit struct {
+	Count     int32 `json:"count,omitempty"`
+	Period    int32 `json:"period,omitempty"`
+	BurstSize int32 `json:"burstSize,omitempty"`
 }
 
 // HostRuleHTTPPolicy holds knobs and refs for httpPolicySets
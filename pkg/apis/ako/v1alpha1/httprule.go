@@ -39,11 +39,50 @@ type HTTPRuleSpec struct {
 
 // HTTPRulePaths has settings for a specific target path
 type HTTPRulePaths struct {
-	Target                 string           `json:"target,omitempty"`
-	LoadBalancerPolicy     HTTPRuleLBPolicy `json:"loadBalancerPolicy,omitempty"`
-	TLS                    HTTPRuleTLS      `json:"tls,omitempty"`
-	HealthMonitors         []string         `json:"healthMonitors,omitempty"`
-	ApplicationPersistence string           `json:"applicationPersistence,omitempty"`
+	Target                 string                    `json:"target,omitempty"`
+	LoadBalancerPolicy     HTTPRuleLBPolicy          `json:"loadBalancerPolicy,omitempty"`
+	TLS                    HTTPRuleTLS               `json:"tls,omitempty"`
+	HealthMonitors         []string                  `json:"healthMonitors,omitempty"`
+	ApplicationPersistence string                    `json:"applicationPersistence,omitempty"`
+	Matches                []HTTPRuleMatch           `json:"matches,omitempty"`
+	Backends               []HTTPRuleWeightedBackend `json:"backends,omitempty"`
+}
+
+// HTTPRuleMatch routes the requests for a target path, that satisfy all the
+// conditions of the match, to a backend service
+type HTTPRuleMatch struct {
+	Headers     []HTTPRuleHeaderMatch     `json:"headers,omitempty"`
+	QueryParams []HTTPRuleQueryParamMatch `json:"queryParams,omitempty"`
+	Methods     []string                  `json:"methods,omitempty"`
+	Cookie      *HTTPRuleHeaderMatch      `json:"cookie,omitempty"`
+	Backend     HTTPRuleBackend           `json:"backend,omitempty"`
+}
+
+// HTTPRuleHeaderMatch matches the value of a request header or cookie
+type HTTPRuleHeaderMatch struct {
+	Name      string `json:"name,omitempty"`
+	Value     string `json:"value,omitempty"`
+	MatchType string `json:"matchType,omitempty"`
+}
+
+// HTTPRuleQueryParamMatch matches the value of a query parameter
+type HTTPRuleQueryParamMatch struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// HTTPRuleBackend is the service/port to which the matched requests are routed
+type HTTPRuleBackend struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	Port        int32  `json:"port,omitempty"`
+}
+
+// HTTPRuleWeightedBackend is a service/port which gets a share of the requests
+// for a target path, as per its weight
+type HTTPRuleWeightedBackend struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	Port        int32  `json:"port,omitempty"`
+	Weight      *int32 `json:"weight,omitempty"`
 }
 
 // HTTPRuleLBPolicy holds a path/pool's load balancer policies
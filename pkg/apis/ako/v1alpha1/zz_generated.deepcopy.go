@@ -454,6 +454,38 @@ func (in *HTTPRule) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRuleBackend) DeepCopyInto(out *HTTPRuleBackend) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRuleBackend.
+func (in *HTTPRuleBackend) DeepCopy() *HTTPRuleBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRuleBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRuleHeaderMatch) DeepCopyInto(out *HTTPRuleHeaderMatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRuleHeaderMatch.
+func (in *HTTPRuleHeaderMatch) DeepCopy() *HTTPRuleHeaderMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRuleHeaderMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPRuleLBPolicy) DeepCopyInto(out *HTTPRuleLBPolicy) {
 	*out = *in
@@ -503,6 +535,43 @@ func (in *HTTPRuleList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRuleMatch) DeepCopyInto(out *HTTPRuleMatch) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HTTPRuleHeaderMatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.QueryParams != nil {
+		in, out := &in.QueryParams, &out.QueryParams
+		*out = make([]HTTPRuleQueryParamMatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Cookie != nil {
+		in, out := &in.Cookie, &out.Cookie
+		*out = new(HTTPRuleHeaderMatch)
+		**out = **in
+	}
+	out.Backend = in.Backend
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRuleMatch.
+func (in *HTTPRuleMatch) DeepCopy() *HTTPRuleMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRuleMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPRulePaths) DeepCopyInto(out *HTTPRulePaths) {
 	*out = *in
@@ -513,6 +582,20 @@ func (in *HTTPRulePaths) DeepCopyInto(out *HTTPRulePaths) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]HTTPRuleMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = make([]HTTPRuleWeightedBackend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -526,6 +609,22 @@ func (in *HTTPRulePaths) DeepCopy() *HTTPRulePaths {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRuleQueryParamMatch) DeepCopyInto(out *HTTPRuleQueryParamMatch) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRuleQueryParamMatch.
+func (in *HTTPRuleQueryParamMatch) DeepCopy() *HTTPRuleQueryParamMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRuleQueryParamMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPRuleSpec) DeepCopyInto(out *HTTPRuleSpec) {
 	*out = *in
@@ -581,6 +680,27 @@ func (in *HTTPRuleTLS) DeepCopy() *HTTPRuleTLS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRuleWeightedBackend) DeepCopyInto(out *HTTPRuleWeightedBackend) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRuleWeightedBackend.
+func (in *HTTPRuleWeightedBackend) DeepCopy() *HTTPRuleWeightedBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRuleWeightedBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostRule) DeepCopyInto(out *HostRule) {
 	*out = *in
@@ -651,6 +771,22 @@ func (in *HostRuleGSLB) DeepCopy() *HostRuleGSLB {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostRuleHTTPHeaderAction) DeepCopyInto(out *HostRuleHTTPHeaderAction) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostRuleHTTPHeaderAction.
+func (in *HostRuleHTTPHeaderAction) DeepCopy() *HostRuleHTTPHeaderAction {
+	if in == nil {
+		return nil
+	}
+	out := new(HostRuleHTTPHeaderAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostRuleHTTPPolicy) DeepCopyInto(out *HostRuleHTTPPolicy) {
 	*out = *in
@@ -659,6 +795,13 @@ func (in *HostRuleHTTPPolicy) DeepCopyInto(out *HostRuleHTTPPolicy) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]HostRuleHTTPPolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -672,6 +815,84 @@ func (in *HostRuleHTTPPolicy) DeepCopy() *HostRuleHTTPPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostRuleHTTPPolicyRule) DeepCopyInto(out *HostRuleHTTPPolicyRule) {
+	*out = *in
+	if in.Redirect != nil {
+		in, out := &in.Redirect, &out.Redirect
+		*out = new(HostRuleHTTPRedirect)
+		**out = **in
+	}
+	if in.RequestHeaders != nil {
+		in, out := &in.RequestHeaders, &out.RequestHeaders
+		*out = make([]HostRuleHTTPHeaderAction, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResponseHeaders != nil {
+		in, out := &in.ResponseHeaders, &out.ResponseHeaders
+		*out = make([]HostRuleHTTPHeaderAction, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedClientIPs != nil {
+		in, out := &in.AllowedClientIPs, &out.AllowedClientIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DeniedClientIPs != nil {
+		in, out := &in.DeniedClientIPs, &out.DeniedClientIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(HostRuleHTTPRateLimit)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostRuleHTTPPolicyRule.
+func (in *HostRuleHTTPPolicyRule) DeepCopy() *HostRuleHTTPPolicyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(HostRuleHTTPPolicyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostRuleHTTPRateLimit) DeepCopyInto(out *HostRuleHTTPRateLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostRuleHTTPRateLimit.
+func (in *HostRuleHTTPRateLimit) DeepCopy() *HostRuleHTTPRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(HostRuleHTTPRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostRuleHTTPRedirect) DeepCopyInto(out *HostRuleHTTPRedirect) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostRuleHTTPRedirect.
+func (in *HostRuleHTTPRedirect) DeepCopy() *HostRuleHTTPRedirect {
+	if in == nil {
+		return nil
+	}
+	out := new(HostRuleHTTPRedirect)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostRuleList) DeepCopyInto(out *HostRuleList) {
 	*out = *in
@@ -816,6 +1037,11 @@ func (in *HostRuleVirtualHost) DeepCopyInto(out *HostRuleVirtualHost) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.DatascriptConfigMaps != nil {
+		in, out := &in.DatascriptConfigMaps, &out.DatascriptConfigMaps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.EnableVirtualHost != nil {
 		in, out := &in.EnableVirtualHost, &out.EnableVirtualHost
 		*out = new(bool)
@@ -27,7 +27,10 @@ const (
 	SecretInformer                = "SecretInformer"
 	NodeInformer                  = "NodeInformer"
 	EndpointInformer              = "EndpointInformer"
+	EndpointSlicesInformer        = "EndpointSlicesInformer"
 	ConfigMapInformer             = "ConfigMapInformer"
+	DataScriptConfigMapInformer   = "DataScriptConfigMapInformer"
+	DataScriptConfigMapLabel      = "ako.vmware.com/datascript"
 	MultiClusterIngressInformer   = "MultiClusterIngressInformer"
 	ServiceImportInformer         = "ServiceImportInformer"
 	K8S_TLS_SECRET_CERT           = "tls.crt"
@@ -55,7 +58,9 @@ const (
 	HTTPS                         = "HTTPS"
 	TCP                           = "TCP"
 	UDP                           = "UDP"
+	SCTP                          = "SCTP"
 	SYSTEM_UDP_FAST_PATH          = "System-UDP-Fast-Path"
+	SYSTEM_SCTP_PROXY             = "System-SCTP-Proxy"
 	TCP_NW_FAST_PATH              = "System-TCP-Fast-Path"
 	DEFAULT_TCP_NW_PROFILE        = "System-TCP-Proxy"
 	MIXED_NET_PROFILE             = "Mixed-Network-Profile-Internal"
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package utils
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	metricsNamespace = "ako"
+
+	// Layers at which an unchanged model is skipped, based on its checksum.
+	ChecksumSkipGraphLayer = "graph"
+	ChecksumSkipRestLayer  = "rest"
+
+	// Types of full sync for which the duration is recorded.
+	FullSyncK8s = "k8s"
+	FullSyncAvi = "avi"
+)
+
+// MetricsRegistry holds all the AKO metrics, and is served by the /metrics api.
+var MetricsRegistry = prometheus.NewRegistry()
+
+var (
+	workqueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "workqueue",
+		Name:      "depth",
+		Help:      "Current number of keys waiting in the workqueue, across all workers of the queue.",
+	}, []string{"queue"})
+
+	workqueueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "workqueue",
+		Name:      "adds_total",
+		Help:      "Total number of keys added to the workqueue.",
+	}, []string{"queue"})
+
+	workqueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "workqueue",
+		Name:      "queue_duration_seconds",
+		Help:      "Time in seconds a key stays in the workqueue before being picked up by a worker.",
+		Buckets:   prometheus.ExponentialBuckets(10e-6, 10, 8),
+	}, []string{"queue"})
+
+	workqueueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "workqueue",
+		Name:      "work_duration_seconds",
+		Help:      "Time in seconds taken by a worker to process a key from the workqueue.",
+		Buckets:   prometheus.ExponentialBuckets(10e-6, 10, 8),
+	}, []string{"queue"})
+
+	workqueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "workqueue",
+		Name:      "retries_total",
+		Help:      "Total number of rate limited retries of keys in the workqueue.",
+	}, []string{"queue"})
+
+	aviRestRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "avi_rest",
+		Name:      "requests_total",
+		Help:      "Total number of REST calls made to the Avi controller.",
+	}, []string{"method", "model", "status"})
+
+	aviRestLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "avi_rest",
+		Name:      "request_duration_seconds",
+		Help:      "Time in seconds taken by the REST calls made to the Avi controller.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"method", "model", "status"})
+
+	checksumSkips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "model_checksum_skips_total",
+		Help:      "Total number of times a model was not processed further as its checksum was unchanged.",
+	}, []string{"layer"})
+
+	fullSyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "full_sync_duration_seconds",
+		Help:      "Time in seconds taken by a full sync.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"type"})
+)
+
+func init() {
+	MetricsRegistry.MustRegister(
+		workqueueDepth,
+		workqueueAdds,
+		workqueueLatency,
+		workqueueWorkDuration,
+		workqueueRetries,
+		aviRestRequests,
+		aviRestLatency,
+		checksumSkips,
+		fullSyncDuration,
+	)
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// RegisterMetrics adds collectors owned by other packages, e.g. the avi object cache sizes, to the
+// AKO metrics registry. A collector that is already registered is ignored.
+func RegisterMetrics(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		if err := MetricsRegistry.Register(collector); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				AviLog.Warnf("Unable to register metrics collector: %v", err)
+			}
+		}
+	}
+}
+
+// ObserveAviRestCall records the count and the latency of a REST call made to the Avi controller.
+func ObserveAviRestCall(method, model, status string, duration time.Duration) {
+	aviRestRequests.WithLabelValues(method, model, status).Inc()
+	aviRestLatency.WithLabelValues(method, model, status).Observe(duration.Seconds())
+}
+
+// IncrementChecksumSkips records a model skipped at the given layer due to an unchanged checksum.
+func IncrementChecksumSkips(layer string) {
+	checksumSkips.WithLabelValues(layer).Inc()
+}
+
+// ObserveFullSyncDuration records the time elapsed since start for a full sync of the given type,
+// it is meant to be deferred at the beginning of the full sync.
+func ObserveFullSyncDuration(syncType string, start time.Time) {
+	fullSyncDuration.WithLabelValues(syncType).Observe(time.Since(start).Seconds())
+}
+
+// workqueueMetricsProvider implements workqueue.MetricsProvider. All the workqueues of a WorkerQueue
+// share the same name, hence the metrics are aggregated per WorkerQueue. The unfinished work and the
+// longest running processor gauges are set per workqueue, and cannot be aggregated this way, so these
+// are not exported.
+type workqueueMetricsProvider struct{}
+
+func workqueueLabel(name string) string {
+	return strings.TrimPrefix(name, "avi-")
+}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(workqueueLabel(name))
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(workqueueLabel(name))
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(workqueueLabel(name))
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(workqueueLabel(name))
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return noopSettableGauge{}
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return noopSettableGauge{}
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(workqueueLabel(name))
+}
+
+type noopSettableGauge struct{}
+
+func (noopSettableGauge) Set(float64) {}
@@ -23,6 +23,7 @@ import (
 	oshiftinformers "github.com/openshift/client-go/route/informers/externalversions/route/v1"
 	avimodels "github.com/vmware/alb-sdk/go/models"
 	coreinformers "k8s.io/client-go/informers/core/v1"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
 	netinformers "k8s.io/client-go/informers/networking/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -72,8 +73,10 @@ type KubeClientIntf struct {
 
 type Informers struct {
 	ConfigMapInformer           coreinformers.ConfigMapInformer
+	DataScriptConfigMapInformer coreinformers.ConfigMapInformer
 	ServiceInformer             coreinformers.ServiceInformer
 	EpInformer                  coreinformers.EndpointsInformer
+	EpSlicesInformer            discoveryinformers.EndpointSliceInformer
 	PodInformer                 coreinformers.PodInformer
 	NSInformer                  coreinformers.NamespaceInformer
 	SecretInformer              coreinformers.SecretInformer
@@ -206,15 +209,18 @@ type K8ValidNamespaces struct {
 }
 
 type AviObjectMarkers struct {
-	Namespace        string
-	Host             []string
-	InfrasettingName string
-	ServiceName      string
-	Path             []string
-	Port             string
-	Protocol         string
-	IngressName      []string
-	GatewayName      string
+	Namespace               string
+	Host                    []string
+	InfrasettingName        string
+	ServiceName             string
+	Path                    []string
+	Port                    string
+	Protocol                string
+	IngressName             []string
+	GatewayName             string
+	IstioGatewayName        string
+	IstioVirtualServiceName string
+	HTTPRouteName           string
 }
 
 /*
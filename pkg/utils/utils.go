@@ -32,6 +32,7 @@ import (
 	oshiftinformers "github.com/openshift/client-go/route/informers/externalversions"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
@@ -182,6 +183,8 @@ func instantiateInformers(kubeClient KubeClientIntf, registeredInformers []strin
 			informers.PodInformer = kubeInformerFactory.Core().V1().Pods()
 		case EndpointInformer:
 			informers.EpInformer = kubeInformerFactory.Core().V1().Endpoints()
+		case EndpointSlicesInformer:
+			informers.EpSlicesInformer = kubeInformerFactory.Discovery().V1().EndpointSlices()
 		case SecretInformer:
 			if akoNSBoundInformer {
 				informers.SecretInformer = akoNSInformerFactory.Core().V1().Secrets()
@@ -192,6 +195,13 @@ func instantiateInformers(kubeClient KubeClientIntf, registeredInformers []strin
 			informers.NodeInformer = kubeInformerFactory.Core().V1().Nodes()
 		case ConfigMapInformer:
 			informers.ConfigMapInformer = akoNSInformerFactory.Core().V1().ConfigMaps()
+		case DataScriptConfigMapInformer:
+			// Only the ConfigMaps carrying the datascript label are cached, across all the namespaces.
+			dsInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(cs, InformerDefaultResync,
+				kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.LabelSelector = DataScriptConfigMapLabel + "=true"
+				}))
+			informers.DataScriptConfigMapInformer = dsInformerFactory.Core().V1().ConfigMaps()
 		case IngressInformer:
 			informers.IngressInformer = kubeInformerFactory.Networking().V1().Ingresses()
 		case IngressClassInformer:
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package bootuptests
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+)
+
+func injectMWForDeleteCount(deleteCount *int32) {
+	integrationtest.AddMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		url := r.URL.EscapedPath()
+		if r.Method == "DELETE" {
+			atomic.AddInt32(deleteCount, 1)
+			w.WriteHeader(http.StatusNoContent)
+		} else if r.Method == "GET" && strings.Contains(url, "/api/cloud/") {
+			// The cloud is fetched with include_name, so the refs are expected to have the object names.
+			data, _ := ioutil.ReadFile(mockFilePath + "/cloud_mock.json")
+			data = bytes.Replace(data, []byte(`e0ec41df409e"`), []byte(`e0ec41df409e#ns-ipam"`), -1)
+			data = bytes.Replace(data, []byte(`b23b4e1d9c39"`), []byte(`b23b4e1d9c39#ew-ipam"`), -1)
+			data = bytes.Replace(data, []byte(`74702c2237ca"`), []byte(`74702c2237ca#avi-dns"`), -1)
+			w.WriteHeader(http.StatusOK)
+			w.Write(data)
+		} else if r.Method == "GET" {
+			integrationtest.FeedMockCollectionData(w, r, mockFilePath)
+		} else if strings.Contains(url, "login") {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"success": "true"}`))
+		} else if strings.Contains(url, "initial-data") {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"version": {"Version": "20.1.2"}}`))
+		}
+	})
+}
+
+// TestStandbyPopulateCache verifies that a standby AKO replica populates the cache, but does not
+// delete the stale objects on the controller until it becomes the leader.
+func TestStandbyPopulateCache(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	var deleteCount int32
+
+	leaderElectedCh := make(chan struct{})
+	lib.AKOControlConfig().SetLeaderElectedCh(leaderElectedCh)
+	defer lib.AKOControlConfig().SetLeaderElectedCh(nil)
+	g.Expect(lib.AKOControlConfig().IsLeader()).To(gomega.BeFalse())
+
+	injectMWForDeleteCount(&deleteCount)
+	defer integrationtest.ResetMiddleware()
+	integrationtest.AddConfigMap(KubeClient)
+	k8s.PopulateControllerProperties(KubeClient)
+
+	err := k8s.PopulateCache()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cache.SharedAviObjCache().PoolCache.AviCacheLen()).To(gomega.BeNumerically(">", 0))
+	g.Expect(atomic.LoadInt32(&deleteCount)).To(gomega.Equal(int32(0)))
+
+	close(leaderElectedCh)
+	g.Expect(lib.AKOControlConfig().IsLeader()).To(gomega.BeTrue())
+	k8s.CleanupAviObjects()
+	g.Eventually(func() int32 {
+		return atomic.LoadInt32(&deleteCount)
+	}, 10*time.Second).Should(gomega.BeNumerically(">", 0))
+}
@@ -0,0 +1,505 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package drifttests
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+
+const (
+	driftVSName   = "cluster--red-ns-testsvc"
+	driftPoolName = "cluster--red-ns-testsvc-TCP-8080"
+)
+
+// aviObjects are the virtualservices, pools and vsvips in the fake controller by their type and uuid, as
+// created or updated by AKO.
+var aviObjects map[string]map[string]map[string]interface{}
+var aviObjectsLock sync.RWMutex
+
+var driftObjectTypes = map[string]bool{"virtualservice": true, "pool": true, "vsvip": true}
+
+func TestMain(m *testing.M) {
+	// The flags are parsed before the avi clients are created concurrently, as each of them parses the flags
+	// if these are not parsed yet.
+	flag.Parse()
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	ctrl.SetSEGroupCloudName()
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+// driftControllerServer keeps the virtualservices, pools and vsvips created or updated by AKO in aviObjects,
+// and returns them for the collection requests. The other requests are handed over to the normal controller
+// server.
+func driftControllerServer(w http.ResponseWriter, r *http.Request) {
+	urlSlice := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	if len(urlSlice) < 2 || !driftObjectTypes[urlSlice[1]] {
+		integrationtest.NormalControllerServer(w, r)
+		return
+	}
+	objType := urlSlice[1]
+
+	switch {
+	case r.Method == "GET" && len(urlSlice) == 2:
+		aviObjectsLock.RLock()
+		results := []interface{}{}
+		for _, obj := range aviObjects[objType] {
+			result := make(map[string]interface{}, len(obj))
+			for field, value := range obj {
+				result[field] = value
+			}
+			if _, ok := result["_last_modified"]; !ok {
+				result["_last_modified"] = ""
+			}
+			if _, ok := result["service_metadata"]; !ok && objType == "pool" {
+				result["service_metadata"] = "{}"
+			}
+			results = append(results, result)
+		}
+		aviObjectsLock.RUnlock()
+		finalResponse, _ := json.Marshal(map[string]interface{}{"count": len(results), "results": results})
+		w.WriteHeader(http.StatusOK)
+		w.Write(finalResponse)
+
+	case r.Method == "DELETE" && len(urlSlice) > 2:
+		aviObjectsLock.Lock()
+		delete(aviObjects[objType], urlSlice[2])
+		aviObjectsLock.Unlock()
+		integrationtest.NormalControllerServer(w, r)
+
+	case r.Method == "POST" || r.Method == "PUT":
+		rec := httptest.NewRecorder()
+		integrationtest.NormalControllerServer(rec, r)
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err == nil {
+			if uuid, ok := resp["uuid"].(string); ok {
+				aviObjectsLock.Lock()
+				aviObjects[objType][uuid] = resp
+				aviObjectsLock.Unlock()
+			}
+		}
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+
+	default:
+		integrationtest.NormalControllerServer(w, r)
+	}
+}
+
+func resetAviObjects() {
+	aviObjectsLock.Lock()
+	aviObjects = make(map[string]map[string]map[string]interface{})
+	for objType := range driftObjectTypes {
+		aviObjects[objType] = make(map[string]map[string]interface{})
+	}
+	aviObjectsLock.Unlock()
+}
+
+// getAviObject returns a copy of the object with the name in the fake controller.
+func getAviObject(objType, name string) map[string]interface{} {
+	aviObjectsLock.RLock()
+	defer aviObjectsLock.RUnlock()
+	for _, obj := range aviObjects[objType] {
+		if obj["name"] == name {
+			objCopy := make(map[string]interface{}, len(obj))
+			for field, value := range obj {
+				objCopy[field] = value
+			}
+			return objCopy
+		}
+	}
+	return nil
+}
+
+// updateAviObject changes the object with the name in the fake controller, as done by a user out of band.
+func updateAviObject(objType, name string, update func(obj map[string]interface{})) {
+	aviObjectsLock.Lock()
+	defer aviObjectsLock.Unlock()
+	for uuid, obj := range aviObjects[objType] {
+		if obj["name"] == name {
+			if update == nil {
+				delete(aviObjects[objType], uuid)
+			} else {
+				update(obj)
+			}
+			return
+		}
+	}
+}
+
+func addAviObject(objType string, obj map[string]interface{}) {
+	aviObjectsLock.Lock()
+	aviObjects[objType][obj["uuid"].(string)] = obj
+	aviObjectsLock.Unlock()
+}
+
+type driftObject struct {
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	Uuid     string   `json:"uuid"`
+	Model    string   `json:"model"`
+	Reason   string   `json:"reason"`
+	Fields   []string `json:"fields"`
+	Reverted bool     `json:"reverted"`
+}
+
+type driftReport struct {
+	Policy   string        `json:"policy"`
+	LastScan *time.Time    `json:"last_scan"`
+	Objects  []driftObject `json:"objects"`
+}
+
+func getDriftReport(t *testing.T, uri string) driftReport {
+	akoApi := api.NewServer("0", []models.ApiModel{&k8s.DriftModel{}})
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	rec := httptest.NewRecorder()
+	akoApi.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code for %s: %d", uri, rec.Code)
+	}
+	var report driftReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unable to unmarshal the response for %s: %v", uri, err)
+	}
+	return report
+}
+
+// scanDrift runs two drift scans, so that the objects found in both the scans are reported.
+func scanDrift(t *testing.T) driftReport {
+	k8s.ScanDrift()
+	k8s.ScanDrift()
+	return getDriftReport(t, "/api/drift")
+}
+
+func getPoolCache(g *gomega.WithT) *cache.AviPoolCache {
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: driftPoolName}
+	poolCache, found := cache.SharedAviObjCache().PoolCache.AviCacheGet(poolKey)
+	g.Expect(found).To(gomega.BeTrue())
+	return poolCache.(*cache.AviPoolCache)
+}
+
+// setUpDriftTest creates a service of type LoadBalancer, and waits for its objects to be in sync with the
+// fake controller.
+func setUpDriftTest(t *testing.T, policy string) {
+	g := gomega.NewGomegaWithT(t)
+	os.Setenv("DRIFT_POLICY", policy)
+	resetAviObjects()
+	integrationtest.AddMiddleware(driftControllerServer)
+
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, true, "1.1.1")
+
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: driftVSName}
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+		return found && getAviObject("virtualservice", driftVSName) != nil
+	}, 10*time.Second).Should(gomega.Equal(true))
+	g.Eventually(func() int {
+		return len(scanDrift(t).Objects)
+	}, 10*time.Second).Should(gomega.Equal(0))
+}
+
+func tearDownDriftTest(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: driftVSName}
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+
+	// The objects found by the earlier scans are cleared for the next test.
+	resetAviObjects()
+	scanDrift(t)
+	integrationtest.ResetMiddleware()
+	os.Unsetenv("DRIFT_POLICY")
+}
+
+func TestDriftNotFoundForObjectsInSync(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpDriftTest(t, lib.DriftPolicyAlert)
+	defer tearDownDriftTest(t)
+
+	report := getDriftReport(t, "/api/drift")
+	g.Expect(report.Policy).To(gomega.Equal(lib.DriftPolicyAlert))
+	g.Expect(report.LastScan).NotTo(gomega.BeNil())
+	g.Expect(report.Objects).To(gomega.BeEmpty())
+}
+
+func TestDriftModifiedObjectAlert(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpDriftTest(t, lib.DriftPolicyAlert)
+	defer tearDownDriftTest(t)
+
+	cksum := getPoolCache(g).CloudConfigCksum
+	updateAviObject("pool", driftPoolName, func(obj map[string]interface{}) {
+		obj["cloud_config_cksum"] = "12345"
+	})
+
+	// The object is reported only after it is found in the same state by two scans.
+	k8s.ScanDrift()
+	g.Expect(getDriftReport(t, "/api/drift").Objects).To(gomega.BeEmpty())
+	k8s.ScanDrift()
+	report := getDriftReport(t, "/api/drift")
+	g.Expect(report.Objects).To(gomega.HaveLen(1))
+	g.Expect(report.Objects[0].Type).To(gomega.Equal("Pool"))
+	g.Expect(report.Objects[0].Name).To(gomega.Equal(driftPoolName))
+	g.Expect(report.Objects[0].Reason).To(gomega.Equal("ModifiedOutOfBand"))
+	g.Expect(report.Objects[0].Fields).To(gomega.Equal([]string{"cloud_config_cksum"}))
+	g.Expect(report.Objects[0].Model).To(gomega.Equal("admin/" + driftVSName))
+	g.Expect(report.Objects[0].Reverted).To(gomega.BeFalse())
+	g.Expect(getDriftReport(t, "/api/drift?reason=Orphan").Objects).To(gomega.BeEmpty())
+
+	// The object is not reverted with the Alert policy.
+	k8s.ScanDrift()
+	g.Expect(getDriftReport(t, "/api/drift").Objects).To(gomega.HaveLen(1))
+	g.Expect(getPoolCache(g).CloudConfigCksum).To(gomega.Equal(cksum))
+	g.Expect(getAviObject("pool", driftPoolName)["cloud_config_cksum"]).To(gomega.Equal("12345"))
+}
+
+func TestDriftModifiedObjectRevert(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpDriftTest(t, lib.DriftPolicyRevert)
+	defer tearDownDriftTest(t)
+
+	cksum := getPoolCache(g).CloudConfigCksum
+	g.Expect(getAviObject("pool", driftPoolName)["servers"]).To(gomega.HaveLen(3))
+	updateAviObject("pool", driftPoolName, func(obj map[string]interface{}) {
+		obj["servers"] = obj["servers"].([]interface{})[1:]
+	})
+
+	report := scanDrift(t)
+	g.Expect(report.Policy).To(gomega.Equal(lib.DriftPolicyRevert))
+	g.Expect(report.Objects).To(gomega.HaveLen(1))
+	g.Expect(report.Objects[0].Name).To(gomega.Equal(driftPoolName))
+	g.Expect(report.Objects[0].Fields).To(gomega.Equal([]string{"servers"}))
+	g.Expect(report.Objects[0].Reverted).To(gomega.BeTrue())
+
+	// The pool is updated from the model by AKO.
+	g.Eventually(func() int {
+		return len(getAviObject("pool", driftPoolName)["servers"].([]interface{}))
+	}, 10*time.Second).Should(gomega.Equal(3))
+	g.Eventually(func() string {
+		return getPoolCache(g).CloudConfigCksum
+	}, 10*time.Second).Should(gomega.Equal(cksum))
+	g.Eventually(func() int {
+		return len(scanDrift(t).Objects)
+	}, 10*time.Second).Should(gomega.Equal(0))
+}
+
+func TestDriftDeletedObjectRevert(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpDriftTest(t, lib.DriftPolicyRevert)
+	defer tearDownDriftTest(t)
+
+	updateAviObject("virtualservice", driftVSName, nil)
+
+	report := scanDrift(t)
+	g.Expect(report.Objects).To(gomega.HaveLen(1))
+	g.Expect(report.Objects[0].Type).To(gomega.Equal("VirtualService"))
+	g.Expect(report.Objects[0].Name).To(gomega.Equal(driftVSName))
+	g.Expect(report.Objects[0].Reason).To(gomega.Equal("DeletedOutOfBand"))
+	g.Expect(report.Objects[0].Reverted).To(gomega.BeTrue())
+
+	// The virtualservice is created again by AKO.
+	g.Eventually(func() bool {
+		return getAviObject("virtualservice", driftVSName) != nil
+	}, 10*time.Second).Should(gomega.Equal(true))
+	g.Eventually(func() int {
+		return len(scanDrift(t).Objects)
+	}, 10*time.Second).Should(gomega.Equal(0))
+}
+
+func TestDriftOrphanObject(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpDriftTest(t, lib.DriftPolicyRevert)
+	defer tearDownDriftTest(t)
+
+	addAviObject("pool", map[string]interface{}{
+		"name":               "cluster--orphan-pool",
+		"uuid":               "pool-orphan-" + integrationtest.RANDOMUUID,
+		"cloud_config_cksum": "12345",
+	})
+
+	report := scanDrift(t)
+	g.Expect(report.Objects).To(gomega.HaveLen(1))
+	g.Expect(report.Objects[0].Name).To(gomega.Equal("cluster--orphan-pool"))
+	g.Expect(report.Objects[0].Uuid).To(gomega.Equal("pool-orphan-" + integrationtest.RANDOMUUID))
+	g.Expect(report.Objects[0].Reason).To(gomega.Equal("Orphan"))
+	g.Expect(report.Objects[0].Model).To(gomega.BeEmpty())
+	// The orphans are never deleted or reverted by AKO.
+	g.Expect(report.Objects[0].Reverted).To(gomega.BeFalse())
+	g.Expect(getAviObject("pool", "cluster--orphan-pool")).NotTo(gomega.BeNil())
+	g.Expect(getDriftReport(t, "/api/drift?reason=Orphan").Objects).To(gomega.HaveLen(1))
+	g.Expect(getDriftReport(t, "/api/drift?reason=ModifiedOutOfBand").Objects).To(gomega.BeEmpty())
+}
+
+// updateEndpoints updates the endpoints of the service to the given number of addresses.
+func updateEndpoints(t *testing.T, numAddresses int) {
+	var addresses []corev1.EndpointAddress
+	for i := 1; i <= numAddresses; i++ {
+		addresses = append(addresses, corev1.EndpointAddress{IP: fmt.Sprintf("1.1.1.%d", i)})
+	}
+	ep := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: integrationtest.NAMESPACE, Name: integrationtest.SINGLEPORTSVC},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: addresses,
+			Ports:     []corev1.EndpointPort{{Name: "foo0", Port: 8080, Protocol: "TCP"}},
+		}},
+	}
+	if _, err := KubeClient.CoreV1().Endpoints(integrationtest.NAMESPACE).Update(context.TODO(), ep, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Endpoint: %v", err)
+	}
+}
+
+// TestDriftScanAlongsideModelUpdate runs the scanner while the model is updated, so that the cache accesses of
+// the scanner and the rest layer are checked when the tests are run with -race.
+func TestDriftScanAlongsideModelUpdate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpDriftTest(t, lib.DriftPolicyRevert)
+	defer tearDownDriftTest(t)
+
+	stopCh := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+				k8s.ScanDrift()
+			}
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		updateEndpoints(t, i%3+1)
+		if i == 5 {
+			updateAviObject("pool", driftPoolName, func(obj map[string]interface{}) {
+				obj["cloud_config_cksum"] = "12345"
+			})
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	updateEndpoints(t, 2)
+	g.Eventually(func() int {
+		servers, _ := getAviObject("pool", driftPoolName)["servers"].([]interface{})
+		return len(servers)
+	}, 10*time.Second).Should(gomega.Equal(2))
+	close(stopCh)
+	wg.Wait()
+
+	g.Eventually(func() int {
+		return len(scanDrift(t).Objects)
+	}, 10*time.Second).Should(gomega.Equal(0))
+	g.Expect(getAviObject("pool", driftPoolName)["cloud_config_cksum"]).NotTo(gomega.Equal("12345"))
+	g.Expect(getPoolCache(g).Servers).To(gomega.HaveLen(2))
+}
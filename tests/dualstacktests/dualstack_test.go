@@ -0,0 +1,300 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package dualstacktests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+
+const (
+	v6CIDR    = "2002::1234:abcd:ffff:c0a8:100/120"
+	vipV4Addr = "10.250.250.1"
+	vipV6Addr = "2002::1234:abcd:ffff:c0a8:101"
+)
+
+// vsvipRequest is the vip of the last vsvip POST request, to verify the ip type and the static ip of the vip.
+var vsvipRequest map[string]interface{}
+var vsvipRequestLock sync.RWMutex
+
+func TestMain(m *testing.M) {
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123","cidr":"10.250.250.0/24","v6cidr":"`+v6CIDR+`"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+	os.Setenv("IP_FAMILY", "V4_V6")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	ctrl.SetSEGroupCloudName()
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+// dualStackControllerServer allocates the vips of the requested ip type on vsvip POST, and hands over the
+// other requests to the normal controller server.
+func dualStackControllerServer(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.EscapedPath()
+	if r.Method != "POST" || !strings.Contains(url, "vsvip") {
+		integrationtest.NormalControllerServer(w, r)
+		return
+	}
+
+	var resp, req map[string]interface{}
+	data, _ := ioutil.ReadAll(r.Body)
+	json.Unmarshal(data, &resp)
+	json.Unmarshal(data, &req)
+	vsvipRequestLock.Lock()
+	vsvipRequest = req["vip"].([]interface{})[0].(map[string]interface{})
+	vsvipRequestLock.Unlock()
+
+	rName := resp["name"].(string)
+	resp["url"] = fmt.Sprintf("https://localhost/api/vsvip/vsvip-%s-%s#%s", rName, integrationtest.RANDOMUUID, rName)
+	resp["uuid"] = fmt.Sprintf("vsvip-%s-%s", rName, integrationtest.RANDOMUUID)
+	vip := resp["vip"].([]interface{})[0].(map[string]interface{})
+	ipType := lib.IPTypeV4Only
+	if autoAllocateIPType, ok := vip["auto_allocate_ip_type"].(string); ok {
+		ipType = autoAllocateIPType
+	}
+	if _, ok := vip["ip_address"]; !ok && ipType != lib.IPTypeV6Only {
+		vip["ip_address"] = map[string]string{"addr": vipV4Addr, "type": "V4"}
+	}
+	if _, ok := vip["ip6_address"]; !ok && ipType != lib.IPTypeV4Only {
+		vip["ip6_address"] = map[string]string{"addr": vipV6Addr, "type": "V6"}
+	}
+	finalResponse, _ := json.Marshal(resp)
+	w.WriteHeader(http.StatusOK)
+	w.Write(finalResponse)
+}
+
+func getVsvipRequest() map[string]interface{} {
+	vsvipRequestLock.RLock()
+	defer vsvipRequestLock.RUnlock()
+	return vsvipRequest
+}
+
+func setUpDualStackService(t *testing.T, ipFamilies []corev1.IPFamily, ipFamilyPolicy corev1.IPFamilyPolicyType, loadBalancerIP string) {
+	vsvipRequestLock.Lock()
+	vsvipRequest = nil
+	vsvipRequestLock.Unlock()
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+
+	svc := integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false, map[string]string{})
+	svc.Spec.IPFamilies = ipFamilies
+	svc.Spec.IPFamilyPolicy = &ipFamilyPolicy
+	svc.Spec.LoadBalancerIP = loadBalancerIP
+	if _, err := KubeClient.CoreV1().Services(integrationtest.NAMESPACE).Create(context.TODO(), svc, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Service: %v", err)
+	}
+
+	ep := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: integrationtest.NAMESPACE, Name: integrationtest.SINGLEPORTSVC},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "1.1.1.1"}, {IP: "2002::1:1:1:1"}},
+			Ports:     []corev1.EndpointPort{{Name: "foo0", Port: 8080, Protocol: "TCP"}},
+		}},
+	}
+	if _, err := KubeClient.CoreV1().Endpoints(integrationtest.NAMESPACE).Create(context.TODO(), ep, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in creating Endpoint: %v", err)
+	}
+}
+
+func tearDownDualStackService(t *testing.T, g *gomega.WithT) {
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	mcache := cache.SharedAviObjCache()
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc"}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+}
+
+func getServiceStatusIPs() []string {
+	svc, err := KubeClient.CoreV1().Services(integrationtest.NAMESPACE).Get(context.TODO(), integrationtest.SINGLEPORTSVC, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	var ips []string
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		ips = append(ips, ingress.IP)
+	}
+	return ips
+}
+
+func getVsVipNode() *avinodes.AviVSVIPNode {
+	found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	if !found || aviModel == nil {
+		return nil
+	}
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	if len(nodes) != 1 || len(nodes[0].VSVIPRefs) != 1 {
+		return nil
+	}
+	return nodes[0].VSVIPRefs[0]
+}
+
+func TestDualStackServiceLB(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	integrationtest.AddMiddleware(dualStackControllerServer)
+	defer integrationtest.ResetMiddleware()
+	setUpDualStackService(t, []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}, corev1.IPFamilyPolicyRequireDualStack, "")
+
+	g.Eventually(func() string {
+		if vsVipNode := getVsVipNode(); vsVipNode != nil {
+			return vsVipNode.IPType
+		}
+		return ""
+	}, 10*time.Second).Should(gomega.Equal(lib.IPTypeV4V6))
+
+	// The pool carries the endpoint addresses of both the families.
+	_, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].PoolRefs).To(gomega.HaveLen(1))
+	servers := make(map[string]string)
+	for _, server := range nodes[0].PoolRefs[0].Servers {
+		servers[*server.Ip.Addr] = *server.Ip.Type
+	}
+	g.Expect(servers).To(gomega.Equal(map[string]string{"1.1.1.1": "V4", "2002::1:1:1:1": "V6"}))
+
+	g.Eventually(func() interface{} {
+		if vip := getVsvipRequest(); vip != nil {
+			return vip["auto_allocate_ip_type"]
+		}
+		return nil
+	}, 10*time.Second).Should(gomega.Equal(lib.IPTypeV4V6))
+
+	// Both the V4 and the V6 vips are set in the Service status.
+	g.Eventually(getServiceStatusIPs, 10*time.Second).Should(gomega.Equal([]string{vipV4Addr, vipV6Addr}))
+
+	tearDownDualStackService(t, g)
+}
+
+func TestV6ServiceLBWithStaticIP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	staticV6IP := "2002::1234:abcd:ffff:c0a8:110"
+	integrationtest.AddMiddleware(dualStackControllerServer)
+	defer integrationtest.ResetMiddleware()
+	setUpDualStackService(t, []corev1.IPFamily{corev1.IPv6Protocol}, corev1.IPFamilyPolicySingleStack, staticV6IP)
+
+	g.Eventually(func() string {
+		if vsVipNode := getVsVipNode(); vsVipNode != nil {
+			return vsVipNode.IPType
+		}
+		return ""
+	}, 10*time.Second).Should(gomega.Equal(lib.IPTypeV6Only))
+
+	// The static V6 ip is set as the V6 address of the vip.
+	g.Eventually(func() interface{} {
+		if vip := getVsvipRequest(); vip != nil {
+			return vip["auto_allocate_ip_type"]
+		}
+		return nil
+	}, 10*time.Second).Should(gomega.Equal(lib.IPTypeV6Only))
+	vip := getVsvipRequest()
+	g.Expect(vip).NotTo(gomega.HaveKey("ip_address"))
+	g.Expect(vip["ip6_address"]).To(gomega.Equal(map[string]interface{}{"addr": staticV6IP, "type": "V6"}))
+
+	g.Eventually(getServiceStatusIPs, 10*time.Second).Should(gomega.Equal([]string{staticV6IP}))
+
+	tearDownDualStackService(t, g)
+}
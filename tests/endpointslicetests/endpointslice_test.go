@@ -0,0 +1,177 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package endpointslicetests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+
+const seZone = "zone-a"
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_ENDPOINTSLICE", "true")
+	os.Setenv("SE_ZONE", seZone)
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointSlicesInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	integrationtest.StartClusterIPController(registeredInformers)
+	KubeClient = integrationtest.KubeClient
+	os.Exit(m.Run())
+}
+
+func getPoolServerRatios() map[string]int32 {
+	found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	if !found || aviModel == nil {
+		return nil
+	}
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	if len(nodes) != 1 || len(nodes[0].PoolRefs) != 1 {
+		return nil
+	}
+	ratios := make(map[string]int32)
+	for _, server := range nodes[0].PoolRefs[0].Servers {
+		ratios[*server.Ip.Addr] = server.Ratio
+	}
+	return ratios
+}
+
+func TestEndpointSliceZoneRatio(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	epSlice := integrationtest.BuildEndpointSlice([]integrationtest.FakeEndpoint{
+		{IP: "1.1.1.1", Zone: seZone},
+		{IP: "1.1.1.2", Zone: "zone-b"},
+		{IP: "1.1.1.3", Zone: seZone, NotReady: true},
+		{IP: "1.1.1.4"},
+	})
+	if _, err := KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Create(context.TODO(), epSlice, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in creating EndpointSlice: %v", err)
+	}
+
+	// The ready servers get the ratio based on their zone, and the ratio is not set for the server without a zone.
+	g.Eventually(func() map[string]int32 {
+		return getPoolServerRatios()
+	}, 10*time.Second).Should(gomega.Equal(map[string]int32{
+		"1.1.1.1": lib.LocalZoneServerRatio,
+		"1.1.1.2": lib.RemoteZoneServerRatio,
+		"1.1.1.4": 0,
+	}))
+
+	mcache := cache.SharedAviObjCache()
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc-TCP-8080"}
+	g.Eventually(func() bool {
+		_, found := mcache.PoolCache.AviCacheGet(poolKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(true))
+
+	// The topology aware hints take precedence over the zone of the endpoint.
+	epSlice = integrationtest.BuildEndpointSlice([]integrationtest.FakeEndpoint{
+		{IP: "1.1.1.1", Zone: seZone, HintZone: "zone-b"},
+		{IP: "1.1.1.2", Zone: "zone-b", HintZone: seZone},
+	})
+	epSlice.ResourceVersion = "2"
+	if _, err := KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Update(context.TODO(), epSlice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating EndpointSlice: %v", err)
+	}
+	g.Eventually(func() map[string]int32 {
+		return getPoolServerRatios()
+	}, 10*time.Second).Should(gomega.Equal(map[string]int32{
+		"1.1.1.1": lib.RemoteZoneServerRatio,
+		"1.1.1.2": lib.LocalZoneServerRatio,
+	}))
+
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Delete(context.TODO(), integrationtest.EPSLICENAME, metav1.DeleteOptions{})
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc"}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+}
+
+func TestEndpointSliceServingEndpoints(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	epSlice := integrationtest.BuildEndpointSlice([]integrationtest.FakeEndpoint{
+		{IP: "1.1.1.1", Zone: seZone},
+		{IP: "1.1.1.2", Zone: seZone, NotReady: true, Terminating: true},
+	})
+	if _, err := KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Create(context.TODO(), epSlice, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in creating EndpointSlice: %v", err)
+	}
+	g.Eventually(func() map[string]int32 {
+		return getPoolServerRatios()
+	}, 10*time.Second).Should(gomega.Equal(map[string]int32{"1.1.1.1": lib.LocalZoneServerRatio}))
+
+	// With no ready endpoints, the serving endpoints of the terminating pods are used.
+	epSlice = integrationtest.BuildEndpointSlice([]integrationtest.FakeEndpoint{
+		{IP: "1.1.1.1", Zone: seZone, NotReady: true},
+		{IP: "1.1.1.2", Zone: seZone, NotReady: true, Terminating: true},
+	})
+	epSlice.ResourceVersion = "2"
+	if _, err := KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Update(context.TODO(), epSlice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating EndpointSlice: %v", err)
+	}
+	g.Eventually(func() map[string]int32 {
+		return getPoolServerRatios()
+	}, 10*time.Second).Should(gomega.Equal(map[string]int32{"1.1.1.2": lib.LocalZoneServerRatio}))
+
+	// Deleting the EndpointSlice removes the servers.
+	KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Delete(context.TODO(), integrationtest.EPSLICENAME, metav1.DeleteOptions{})
+	g.Eventually(func() int {
+		ratios := getPoolServerRatios()
+		if ratios == nil {
+			return -1
+		}
+		return len(ratios)
+	}, 10*time.Second).Should(gomega.Equal(0))
+
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	mcache := cache.SharedAviObjCache()
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc"}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+}
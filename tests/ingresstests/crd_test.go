@@ -16,7 +16,9 @@ package ingresstests
 
 import (
 	"context"
+	"encoding/json"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +30,8 @@ import (
 	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
 
 	"github.com/onsi/gomega"
+	avimodels "github.com/vmware/alb-sdk/go/models"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -105,6 +109,236 @@ func TestCreateDeleteHostRule(t *testing.T) {
 	TearDownIngressForCacheSyncCheck(t, modelName)
 }
 
+func TestHostRuleInlineHTTPPolicyRules(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	modelName := "admin/cluster--Shared-L7-0"
+	hrname := "samplehr-foo"
+	SetUpIngressForCacheSyncCheck(t, true, true, modelName)
+
+	hostrule := integrationtest.FakeHostRule{
+		Name:              hrname,
+		Namespace:         "default",
+		Fqdn:              "foo.com",
+		SslKeyCertificate: "thisisaviref-sslkey",
+	}.HostRule()
+	hostrule.Spec.VirtualHost.HTTPPolicy.Rules = []v1alpha1.HostRuleHTTPPolicyRule{
+		{
+			Name:        "rewrite-api",
+			Path:        "/api",
+			RewritePath: "/v1",
+			RequestHeaders: []v1alpha1.HostRuleHTTPHeaderAction{
+				{Action: "Add", Name: "X-Forwarded-Host", Value: "foo.com"},
+			},
+			AllowedClientIPs: []string{"10.10.0.0/16"},
+			RateLimit:        &v1alpha1.HostRuleHTTPRateLimit{Count: 100, Period: 1},
+		},
+		{
+			Name:     "redirect-old",
+			Path:     "/old",
+			Redirect: &v1alpha1.HostRuleHTTPRedirect{Path: "/new", StatusCode: 301},
+		},
+	}
+	if _, err := CRDClient.AkoV1alpha1().HostRules("default").Create(context.TODO(), hostrule, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HostRule: %v", err)
+	}
+
+	g.Eventually(func() string {
+		hostrule, _ := CRDClient.AkoV1alpha1().HostRules("default").Get(context.TODO(), hrname, metav1.GetOptions{})
+		return hostrule.Status.Status
+	}, 10*time.Second).Should(gomega.Equal("Accepted"))
+
+	sniVSKey := cache.NamespaceName{Namespace: "admin", Name: "cluster--foo.com"}
+	integrationtest.VerifyMetadataHostRule(t, g, sniVSKey, "default/samplehr-foo", true)
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	sniNode := nodes[0].SniNodes[0]
+	policyName := lib.GetHostRuleHTTPPolicySetName(sniNode.Name)
+	var inlinePolicy *avinodes.AviHttpPolicySetNode
+	for _, policy := range sniNode.HttpPolicyRefs {
+		if policy.Name == policyName {
+			inlinePolicy = policy
+		}
+	}
+	g.Expect(inlinePolicy).NotTo(gomega.BeNil())
+	g.Expect(inlinePolicy.InlineRules).To(gomega.HaveLen(2))
+	g.Expect(inlinePolicy.InlineRules[0].Rewrite).NotTo(gomega.BeNil())
+	g.Expect(inlinePolicy.InlineRules[0].Rewrite.Path).To(gomega.Equal("/v1"))
+	g.Expect(inlinePolicy.InlineRules[0].Rewrite.PathSuffixIndex).To(gomega.Equal(int32(1)))
+	g.Expect(inlinePolicy.InlineRules[0].RequestHeaders).To(gomega.HaveLen(1))
+	g.Expect(inlinePolicy.InlineRules[0].RateLimit.Count).To(gomega.Equal(int32(100)))
+	g.Expect(inlinePolicy.InlineRules[1].Redirect.Protocol).To(gomega.Equal("HTTPS"))
+	g.Expect(inlinePolicy.InlineRules[1].Redirect.StatusCode).To(gomega.Equal("HTTP_REDIRECT_STATUS_CODE_301"))
+
+	// an invalid rule rejects the hostrule and retains the last applied rules
+	hrUpdate := hostrule.DeepCopy()
+	hrUpdate.Spec.VirtualHost.HTTPPolicy.Rules = []v1alpha1.HostRuleHTTPPolicyRule{
+		{Name: "bad-rule", Path: "/api", DeniedClientIPs: []string{"not-an-ip"}},
+	}
+	hrUpdate.ResourceVersion = "2"
+	if _, err := CRDClient.AkoV1alpha1().HostRules("default").Update(context.TODO(), hrUpdate, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating HostRule: %v", err)
+	}
+	g.Eventually(func() string {
+		hostrule, _ := CRDClient.AkoV1alpha1().HostRules("default").Get(context.TODO(), hrname, metav1.GetOptions{})
+		return hostrule.Status.Status
+	}, 10*time.Second).Should(gomega.Equal("Rejected"))
+	hostrule, _ = CRDClient.AkoV1alpha1().HostRules("default").Get(context.TODO(), hrname, metav1.GetOptions{})
+	g.Expect(hostrule.Status.Error).To(gomega.ContainSubstring("bad-rule"))
+	g.Consistently(func() []string {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		var ruleNames []string
+		for _, policy := range nodes[0].SniNodes[0].HttpPolicyRefs {
+			if policy.Name == policyName {
+				for _, rule := range policy.InlineRules {
+					ruleNames = append(ruleNames, rule.Name)
+				}
+			}
+		}
+		return ruleNames
+	}, 2*time.Second).Should(gomega.Equal([]string{"rewrite-api", "redirect-old"}))
+
+	// removing the rules removes the inline policyset
+	hrUpdate = hostrule.DeepCopy()
+	hrUpdate.Spec.VirtualHost.HTTPPolicy.Rules = nil
+	hrUpdate.ResourceVersion = "3"
+	if _, err := CRDClient.AkoV1alpha1().HostRules("default").Update(context.TODO(), hrUpdate, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating HostRule: %v", err)
+	}
+	g.Eventually(func() int {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		count := 0
+		for _, policy := range nodes[0].SniNodes[0].HttpPolicyRefs {
+			if policy.Name == policyName {
+				count++
+			}
+		}
+		return count
+	}, 10*time.Second).Should(gomega.Equal(0))
+
+	integrationtest.TeardownHostRule(t, g, sniVSKey, hrname)
+	TearDownIngressForCacheSyncCheck(t, modelName)
+}
+
+func TestHostRuleDataScriptConfigMaps(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	modelName := "admin/cluster--Shared-L7-0"
+	hrname := "samplehr-foo"
+	SetUpIngressForCacheSyncCheck(t, true, true, modelName)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo-datascripts",
+			Namespace: "default",
+			Labels:    map[string]string{"ako.vmware.com/datascript": "true"},
+		},
+		Data: map[string]string{
+			"VS_DATASCRIPT_EVT_HTTP_REQ": "avi.http.redirect(\"/new\")",
+		},
+	}
+	if _, err := KubeClient.CoreV1().ConfigMaps("default").Create(context.TODO(), configMap, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding ConfigMap: %v", err)
+	}
+
+	hostrule := integrationtest.FakeHostRule{
+		Name:              hrname,
+		Namespace:         "default",
+		Fqdn:              "foo.com",
+		SslKeyCertificate: "thisisaviref-sslkey",
+	}.HostRule()
+	hostrule.Spec.VirtualHost.DatascriptConfigMaps = []string{"foo-datascripts"}
+	if _, err := CRDClient.AkoV1alpha1().HostRules("default").Create(context.TODO(), hostrule, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HostRule: %v", err)
+	}
+
+	g.Eventually(func() string {
+		hostrule, _ := CRDClient.AkoV1alpha1().HostRules("default").Get(context.TODO(), hrname, metav1.GetOptions{})
+		return hostrule.Status.Status
+	}, 10*time.Second).Should(gomega.Equal("Accepted"))
+
+	sniVSKey := cache.NamespaceName{Namespace: "admin", Name: "cluster--foo.com"}
+	integrationtest.VerifyMetadataHostRule(t, g, sniVSKey, "default/samplehr-foo", true)
+	dsName := lib.GetHostRuleDataScriptName(sniVSKey.Name, "default", "foo-datascripts")
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].SniNodes[0].HTTPDSrefs).To(gomega.HaveLen(1))
+	g.Expect(nodes[0].SniNodes[0].HTTPDSrefs[0].Name).To(gomega.Equal(dsName))
+	g.Expect(nodes[0].SniNodes[0].HTTPDSrefs[0].Scripts).To(gomega.HaveLen(1))
+	g.Expect(nodes[0].SniNodes[0].HTTPDSrefs[0].Scripts[0].Evt).To(gomega.Equal("VS_DATASCRIPT_EVT_HTTP_REQ"))
+	g.Expect(nodes[0].SniNodes[0].VsDatascriptRefs).To(gomega.ContainElement("/api/vsdatascriptset?name=" + dsName))
+
+	mcache := cache.SharedAviObjCache()
+	dsKey := cache.NamespaceName{Namespace: "admin", Name: dsName}
+	g.Eventually(func() bool {
+		_, found := mcache.DSCache.AviCacheGet(dsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(true))
+
+	// the scripts are updated along with the ConfigMap
+	configMap.Data["VS_DATASCRIPT_EVT_HTTP_RESP"] = "avi.http.add_header(\"X-App\", \"foo\")"
+	configMap.ResourceVersion = "2"
+	if _, err := KubeClient.CoreV1().ConfigMaps("default").Update(context.TODO(), configMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating ConfigMap: %v", err)
+	}
+	g.Eventually(func() int {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(nodes[0].SniNodes) == 0 || len(nodes[0].SniNodes[0].HTTPDSrefs) == 0 {
+			return 0
+		}
+		return len(nodes[0].SniNodes[0].HTTPDSrefs[0].Scripts)
+	}, 10*time.Second).Should(gomega.Equal(2))
+	g.Eventually(func() bool {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		dsCache, found := mcache.DSCache.AviCacheGet(dsKey)
+		if !found {
+			return false
+		}
+		return dsCache.(*cache.AviDSCache).CloudConfigCksum == nodes[0].SniNodes[0].HTTPDSrefs[0].GetCheckSum()
+	}, 10*time.Second).Should(gomega.Equal(true))
+
+	// the vsdatascriptset read from the controller during the bootup has the same checksum, so it is not updated
+	// after a restart
+	_, aviModel = objects.SharedAviGraphLister().Get(modelName)
+	dsNode := aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].SniNodes[0].HTTPDSrefs[0]
+	rawData, _ := json.Marshal(avimodels.VSDataScriptSet{Markers: dsNode.GetMarkers(), Datascript: dsNode.GetDataScripts()})
+	var dsObj avimodels.VSDataScriptSet
+	g.Expect(json.Unmarshal(rawData, &dsObj)).To(gomega.Succeed())
+	g.Expect(dsObj.Markers).To(gomega.HaveLen(len(lib.GetAllMarkers(dsNode.AviMarkers))))
+	g.Expect(lib.DataScriptSetChecksum(nil, dsObj.Markers, dsObj.Datascript)).To(gomega.Equal(dsNode.GetCheckSum()))
+
+	// an invalid datascript event rejects the hostrule
+	configMap.Data["VS_DATASCRIPT_EVT_INVALID"] = "avi.vs.log(\"invalid\")"
+	configMap.ResourceVersion = "3"
+	if _, err := KubeClient.CoreV1().ConfigMaps("default").Update(context.TODO(), configMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating ConfigMap: %v", err)
+	}
+	g.Eventually(func() string {
+		hostrule, _ := CRDClient.AkoV1alpha1().HostRules("default").Get(context.TODO(), hrname, metav1.GetOptions{})
+		return hostrule.Status.Status
+	}, 10*time.Second).Should(gomega.Equal("Rejected"))
+
+	integrationtest.TeardownHostRule(t, g, sniVSKey, hrname)
+	g.Eventually(func() int {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		return len(nodes[0].SniNodes[0].HTTPDSrefs)
+	}, 10*time.Second).Should(gomega.Equal(0))
+	g.Eventually(func() bool {
+		_, found := mcache.DSCache.AviCacheGet(dsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+
+	if err := KubeClient.CoreV1().ConfigMaps("default").Delete(context.TODO(), "foo-datascripts", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting ConfigMap: %v", err)
+	}
+	TearDownIngressForCacheSyncCheck(t, modelName)
+}
+
 func TestCreateDeleteSharedVSHostRule(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
@@ -1237,3 +1471,383 @@ func TestHTTPRuleHostSwitch(t *testing.T) {
 	integrationtest.TeardownHTTPRule(t, rrnameFoo)
 	TearDownIngressForCacheSyncCheck(t, modelName)
 }
+
+func TestHTTPRuleMatches(t *testing.T) {
+	// ingress secure foo.com/foo
+	// create httprule /foo with a header match and a query/method/cookie match to the canary service
+	// remove the matches from the httprule
+	g := gomega.NewGomegaWithT(t)
+
+	modelName := "admin/cluster--Shared-L7-0"
+	rrname := "samplerr-foo"
+	canarySvc := "httprulecanarysvc"
+
+	SetupDomain()
+	SetUpTestForIngress(t, modelName)
+	integrationtest.CreateSVC(t, "default", canarySvc, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "default", canarySvc, false, false, "2.2.2")
+	integrationtest.AddSecret("my-secret", "default", "tlsCert", "tlsKey")
+	ingressObject := integrationtest.FakeIngress{
+		Name:        "foo-with-targets",
+		Namespace:   "default",
+		DnsNames:    []string{"foo.com"},
+		Ips:         []string{"8.8.8.8"},
+		HostNames:   []string{"v1"},
+		Paths:       []string{"/foo"},
+		ServiceName: "avisvc",
+		TlsSecretDNS: map[string][]string{
+			"my-secret": {"foo.com"},
+		},
+	}
+	if _, err := KubeClient.NetworkingV1().Ingresses("default").Create(context.TODO(), ingressObject.Ingress(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+	integrationtest.PollForCompletion(t, modelName, 5)
+
+	httprule := integrationtest.FakeHTTPRule{
+		Name:      rrname,
+		Namespace: "default",
+		Fqdn:      "foo.com",
+		PathProperties: []integrationtest.FakeHTTPRulePath{{
+			Path: "/foo",
+			Matches: []v1alpha1.HTTPRuleMatch{
+				{
+					Headers: []v1alpha1.HTTPRuleHeaderMatch{{Name: "x-canary", Value: "true"}},
+					Backend: v1alpha1.HTTPRuleBackend{ServiceName: canarySvc, Port: 8080},
+				},
+				{
+					QueryParams: []v1alpha1.HTTPRuleQueryParamMatch{{Name: "version", Value: "v2"}, {Name: "tier", Value: "gold.1"}},
+					Methods:     []string{"GET"},
+					Cookie:      &v1alpha1.HTTPRuleHeaderMatch{Name: "user", Value: "beta", MatchType: lib.MatchTypeContains},
+					Backend:     v1alpha1.HTTPRuleBackend{ServiceName: canarySvc},
+				},
+			},
+		}},
+	}
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("default").Create(context.TODO(), httprule.HTTPRule(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HTTPRule: %v", err)
+	}
+
+	poolName := "cluster--default-foo.com_foo-foo-with-targets"
+	g.Eventually(func() int {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(nodes[0].SniNodes) != 1 || len(nodes[0].SniNodes[0].HttpPolicyRefs) != 1 {
+			return 0
+		}
+		return len(nodes[0].SniNodes[0].HttpPolicyRefs[0].HppMap)
+	}, 25*time.Second).Should(gomega.Equal(3))
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	hppMaps := nodes[0].SniNodes[0].HttpPolicyRefs[0].HppMap
+	g.Expect(hppMaps[0].Pool).To(gomega.Equal(poolName + "--match-0"))
+	g.Expect(hppMaps[0].Path).To(gomega.Equal([]string{"/foo"}))
+	g.Expect(hppMaps[0].Headers).To(gomega.HaveLen(1))
+	g.Expect(hppMaps[0].Headers[0].Name).To(gomega.Equal("x-canary"))
+	g.Expect(hppMaps[0].Headers[0].MatchCriteria).To(gomega.Equal("HDR_EQUALS"))
+	g.Expect(hppMaps[1].Pool).To(gomega.Equal(poolName + "--match-1"))
+	g.Expect(hppMaps[1].Query).To(gomega.Equal([]string{"version=v2", "tier=gold.1"}))
+	g.Expect(hppMaps[1].Methods).To(gomega.Equal([]string{"HTTP_METHOD_GET"}))
+	g.Expect(hppMaps[1].Cookie.Name).To(gomega.Equal("user"))
+	g.Expect(hppMaps[1].Cookie.MatchCriteria).To(gomega.Equal("HDR_CONTAINS"))
+	g.Expect(hppMaps[2].PoolGroup).To(gomega.Equal(poolName))
+	g.Expect(hppMaps[2].Headers).To(gomega.BeEmpty())
+
+	g.Expect(nodes[0].SniNodes[0].PoolRefs).To(gomega.HaveLen(3))
+	for _, pool := range nodes[0].SniNodes[0].PoolRefs {
+		if pool.Name == poolName {
+			continue
+		}
+		g.Expect(pool.Name).To(gomega.HavePrefix(poolName + "--match-"))
+		g.Expect(pool.Servers).NotTo(gomega.BeEmpty())
+		g.Expect(*pool.Servers[0].Ip.Addr).To(gomega.HavePrefix("2.2.2"))
+	}
+	_, ingresses := objects.SharedSvcLister().IngressMappings("default").GetSvcToIng(canarySvc)
+	g.Expect(ingresses).To(gomega.ContainElement("foo-with-targets"))
+
+	// removing the matches removes the rules and pools of the matches
+	httprule.PathProperties[0].Matches = nil
+	rrUpdate := httprule.HTTPRule()
+	rrUpdate.ResourceVersion = "2"
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("default").Update(context.TODO(), rrUpdate, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating HTTPRule: %v", err)
+	}
+	g.Eventually(func() int {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		return len(nodes[0].SniNodes[0].HttpPolicyRefs[0].HppMap)
+	}, 25*time.Second).Should(gomega.Equal(1))
+	_, aviModel = objects.SharedAviGraphLister().Get(modelName)
+	nodes = aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].SniNodes[0].PoolRefs).To(gomega.HaveLen(1))
+	g.Expect(nodes[0].SniNodes[0].PoolRefs[0].Name).To(gomega.Equal(poolName))
+	// the ingress is no longer mapped to the backend service of the removed matches
+	_, ingresses = objects.SharedSvcLister().IngressMappings("default").GetSvcToIng(canarySvc)
+	g.Expect(ingresses).NotTo(gomega.ContainElement("foo-with-targets"))
+	_, svcs := objects.SharedSvcLister().IngressMappings("default").GetIngToSvc("foo-with-targets")
+	g.Expect(svcs).To(gomega.Equal([]string{"avisvc"}))
+
+	integrationtest.TeardownHTTPRule(t, rrname)
+	integrationtest.DelSVC(t, "default", canarySvc)
+	integrationtest.DelEP(t, "default", canarySvc)
+	TearDownIngressForCacheSyncCheck(t, modelName)
+}
+
+func getPGMemberRatios(pgNode *avinodes.AviPoolGroupNode) map[string]int32 {
+	ratios := make(map[string]int32)
+	for _, member := range pgNode.Members {
+		ratios[strings.TrimPrefix(*member.PoolRef, "/api/pool?name=")] = *member.Ratio
+	}
+	return ratios
+}
+
+func TestHTTPRuleWeightedBackends(t *testing.T) {
+	// ingress secure foo.com/foo
+	// create httprule /foo with avisvc:90 and the backend service:10
+	// update the weights, and then remove the backends
+	g := gomega.NewGomegaWithT(t)
+
+	modelName := "admin/cluster--Shared-L7-0"
+	rrname := "samplerr-foo"
+	backendSvc := "httprulebackendsvc"
+
+	SetupDomain()
+	SetUpTestForIngress(t, modelName)
+	integrationtest.CreateSVC(t, "default", backendSvc, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "default", backendSvc, false, false, "2.2.2")
+	integrationtest.AddSecret("my-secret", "default", "tlsCert", "tlsKey")
+	ingressObject := integrationtest.FakeIngress{
+		Name:        "foo-with-targets",
+		Namespace:   "default",
+		DnsNames:    []string{"foo.com"},
+		Ips:         []string{"8.8.8.8"},
+		HostNames:   []string{"v1"},
+		Paths:       []string{"/foo"},
+		ServiceName: "avisvc",
+		TlsSecretDNS: map[string][]string{
+			"my-secret": {"foo.com"},
+		},
+	}
+	if _, err := KubeClient.NetworkingV1().Ingresses("default").Create(context.TODO(), ingressObject.Ingress(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+	integrationtest.PollForCompletion(t, modelName, 5)
+
+	mainWeight, backendWeight := int32(90), int32(10)
+	httprule := integrationtest.FakeHTTPRule{
+		Name:      rrname,
+		Namespace: "default",
+		Fqdn:      "foo.com",
+		PathProperties: []integrationtest.FakeHTTPRulePath{{
+			Path: "/foo",
+			Backends: []v1alpha1.HTTPRuleWeightedBackend{
+				{ServiceName: "avisvc", Weight: &mainWeight},
+				{ServiceName: backendSvc, Weight: &backendWeight},
+			},
+		}},
+	}
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("default").Create(context.TODO(), httprule.HTTPRule(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HTTPRule: %v", err)
+	}
+
+	poolName := "cluster--default-foo.com_foo-foo-with-targets"
+	backendPoolName := poolName + "--backend-0"
+	g.Eventually(func() map[string]int32 {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(nodes[0].SniNodes) != 1 || len(nodes[0].SniNodes[0].PoolGroupRefs) != 1 {
+			return nil
+		}
+		return getPGMemberRatios(nodes[0].SniNodes[0].PoolGroupRefs[0])
+	}, 25*time.Second).Should(gomega.Equal(map[string]int32{poolName: 90, backendPoolName: 10}))
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].SniNodes[0].PoolRefs).To(gomega.HaveLen(2))
+	for _, pool := range nodes[0].SniNodes[0].PoolRefs {
+		if pool.Name == backendPoolName {
+			g.Expect(pool.Servers).NotTo(gomega.BeEmpty())
+			g.Expect(*pool.Servers[0].Ip.Addr).To(gomega.HavePrefix("2.2.2"))
+		}
+	}
+
+	// updating the weights only updates the poolgroup members
+	mainWeight, backendWeight = 50, 50
+	rrUpdate := httprule.HTTPRule()
+	rrUpdate.ResourceVersion = "2"
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("default").Update(context.TODO(), rrUpdate, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating HTTPRule: %v", err)
+	}
+	g.Eventually(func() map[string]int32 {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		return getPGMemberRatios(nodes[0].SniNodes[0].PoolGroupRefs[0])
+	}, 25*time.Second).Should(gomega.Equal(map[string]int32{poolName: 50, backendPoolName: 50}))
+	_, aviModel = objects.SharedAviGraphLister().Get(modelName)
+	nodes = aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].SniNodes[0].PoolRefs).To(gomega.HaveLen(2))
+
+	// removing the backends removes their pools
+	httprule.PathProperties[0].Backends = nil
+	rrUpdate = httprule.HTTPRule()
+	rrUpdate.ResourceVersion = "3"
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("default").Update(context.TODO(), rrUpdate, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating HTTPRule: %v", err)
+	}
+	g.Eventually(func() map[string]int32 {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		return getPGMemberRatios(nodes[0].SniNodes[0].PoolGroupRefs[0])
+	}, 25*time.Second).Should(gomega.Equal(map[string]int32{poolName: 100}))
+	_, aviModel = objects.SharedAviGraphLister().Get(modelName)
+	nodes = aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].SniNodes[0].PoolRefs).To(gomega.HaveLen(1))
+
+	integrationtest.TeardownHTTPRule(t, rrname)
+	integrationtest.DelSVC(t, "default", backendSvc)
+	integrationtest.DelEP(t, "default", backendSvc)
+	TearDownIngressForCacheSyncCheck(t, modelName)
+}
+
+func TestHTTPRuleBackendInOtherNamespace(t *testing.T) {
+	// ingress secure foo.com/foo in default
+	// create httprule /foo in red with the backend service, which exists in both default and red
+	// the backend service is looked up in the namespace of the ingress
+	g := gomega.NewGomegaWithT(t)
+
+	modelName := "admin/cluster--Shared-L7-0"
+	rrname := "samplerr-foo"
+	backendSvc := "httprulebackendsvc"
+
+	SetupDomain()
+	SetUpTestForIngress(t, modelName)
+	integrationtest.CreateSVC(t, "default", backendSvc, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "default", backendSvc, false, false, "2.2.2")
+	integrationtest.CreateSVC(t, "red", backendSvc, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "red", backendSvc, false, false, "3.3.3")
+	integrationtest.AddSecret("my-secret", "default", "tlsCert", "tlsKey")
+	ingressObject := integrationtest.FakeIngress{
+		Name:        "foo-with-targets",
+		Namespace:   "default",
+		DnsNames:    []string{"foo.com"},
+		Ips:         []string{"8.8.8.8"},
+		HostNames:   []string{"v1"},
+		Paths:       []string{"/foo"},
+		ServiceName: "avisvc",
+		TlsSecretDNS: map[string][]string{
+			"my-secret": {"foo.com"},
+		},
+	}
+	if _, err := KubeClient.NetworkingV1().Ingresses("default").Create(context.TODO(), ingressObject.Ingress(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+	integrationtest.PollForCompletion(t, modelName, 5)
+
+	backendWeight := int32(10)
+	httprule := integrationtest.FakeHTTPRule{
+		Name:      rrname,
+		Namespace: "red",
+		Fqdn:      "foo.com",
+		PathProperties: []integrationtest.FakeHTTPRulePath{{
+			Path:     "/foo",
+			Backends: []v1alpha1.HTTPRuleWeightedBackend{{ServiceName: backendSvc, Weight: &backendWeight}},
+		}},
+	}
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("red").Create(context.TODO(), httprule.HTTPRule(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HTTPRule: %v", err)
+	}
+
+	backendPoolName := "cluster--default-foo.com_foo-foo-with-targets--backend-0"
+	g.Eventually(func() []string {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(nodes[0].SniNodes) != 1 {
+			return nil
+		}
+		var serverIPs []string
+		for _, pool := range nodes[0].SniNodes[0].PoolRefs {
+			if pool.Name != backendPoolName {
+				continue
+			}
+			for _, server := range pool.Servers {
+				serverIPs = append(serverIPs, *server.Ip.Addr)
+			}
+		}
+		return serverIPs
+	}, 25*time.Second).Should(gomega.ConsistOf(gomega.HavePrefix("2.2.2")))
+	_, ingresses := objects.SharedSvcLister().IngressMappings("default").GetSvcToIng(backendSvc)
+	g.Expect(ingresses).To(gomega.ContainElement("foo-with-targets"))
+	_, ingresses = objects.SharedSvcLister().IngressMappings("red").GetSvcToIng(backendSvc)
+	g.Expect(ingresses).NotTo(gomega.ContainElement("foo-with-targets"))
+
+	if err := CRDClient.AkoV1alpha1().HTTPRules("red").Delete(context.TODO(), rrname, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting HTTPRule: %v", err)
+	}
+	integrationtest.DelSVC(t, "default", backendSvc)
+	integrationtest.DelEP(t, "default", backendSvc)
+	integrationtest.DelSVC(t, "red", backendSvc)
+	integrationtest.DelEP(t, "red", backendSvc)
+	TearDownIngressForCacheSyncCheck(t, modelName)
+}
+
+func TestHTTPRuleWeightedBackendsSharedVS(t *testing.T) {
+	// ingress insecure foo.com/foo
+	// create httprule /foo with the backend service:10, avisvc gets the default weight
+	// delete the ingress, the pools of the backends get removed as well
+	g := gomega.NewGomegaWithT(t)
+
+	modelName := "admin/cluster--Shared-L7-0"
+	rrname := "samplerr-foo"
+	backendSvc := "httprulebackendsvc"
+
+	SetUpTestForIngress(t, modelName)
+	integrationtest.CreateSVC(t, "default", backendSvc, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "default", backendSvc, false, false, "2.2.2")
+	ingressObject := integrationtest.FakeIngress{
+		Name:        "foo-with-targets",
+		Namespace:   "default",
+		DnsNames:    []string{"foo.com"},
+		Paths:       []string{"/foo"},
+		ServiceName: "avisvc",
+	}
+	if _, err := KubeClient.NetworkingV1().Ingresses("default").Create(context.TODO(), ingressObject.Ingress(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+	integrationtest.PollForCompletion(t, modelName, 5)
+
+	backendWeight := int32(10)
+	httprule := integrationtest.FakeHTTPRule{
+		Name:      rrname,
+		Namespace: "default",
+		Fqdn:      "foo.com",
+		PathProperties: []integrationtest.FakeHTTPRulePath{{
+			Path:     "/foo",
+			Backends: []v1alpha1.HTTPRuleWeightedBackend{{ServiceName: backendSvc, Weight: &backendWeight}},
+		}},
+	}
+	if _, err := CRDClient.AkoV1alpha1().HTTPRules("default").Create(context.TODO(), httprule.HTTPRule(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HTTPRule: %v", err)
+	}
+
+	poolName := "cluster--foo.com_foo-default-foo-with-targets"
+	g.Eventually(func() map[string]int32 {
+		_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(nodes[0].PoolGroupRefs) != 1 {
+			return nil
+		}
+		return getPGMemberRatios(nodes[0].PoolGroupRefs[0])
+	}, 25*time.Second).Should(gomega.Equal(map[string]int32{poolName: 100, poolName + "--backend-0": 10}))
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].PoolRefs).To(gomega.HaveLen(2))
+
+	if err := KubeClient.NetworkingV1().Ingresses("default").Delete(context.TODO(), "foo-with-targets", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Couldn't DELETE the Ingress %v", err)
+	}
+	VerifyPoolDeletionFromVsNode(g, modelName)
+
+	integrationtest.TeardownHTTPRule(t, rrname)
+	integrationtest.DelSVC(t, "default", backendSvc)
+	integrationtest.DelEP(t, "default", backendSvc)
+	TearDownTestForIngress(t, modelName)
+}
@@ -83,6 +83,7 @@ func TestMain(m *testing.M) {
 		utils.NSInformer,
 		utils.NodeInformer,
 		utils.ConfigMapInformer,
+		utils.DataScriptConfigMapInformer,
 	}
 	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
 	informers := k8s.K8sinformers{Cs: KubeClient}
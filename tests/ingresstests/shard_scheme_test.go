@@ -0,0 +1,212 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package ingresstests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+)
+
+const SHARDSCHEMESVC = "shardschemesvc"
+
+func setUpTestForShardScheme(t *testing.T, modelNames ...string) {
+	for _, model := range modelNames {
+		objects.SharedAviGraphLister().Delete(model)
+	}
+	integrationtest.CreateSVC(t, "default", SHARDSCHEMESVC, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "default", SHARDSCHEMESVC, false, false, "1.1.1")
+}
+
+func tearDownTestForShardScheme(t *testing.T, modelNames ...string) {
+	for _, model := range modelNames {
+		objects.SharedAviGraphLister().Delete(model)
+	}
+	integrationtest.DelSVC(t, "default", SHARDSCHEMESVC)
+	integrationtest.DelEP(t, "default", SHARDSCHEMESVC)
+}
+
+func getShardVSPoolCount(modelName string) int {
+	if found, aviModel := objects.SharedAviGraphLister().Get(modelName); found && aviModel != nil {
+		if nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS(); len(nodes) > 0 {
+			return len(nodes[0].PoolRefs)
+		}
+	}
+	return 0
+}
+
+// TestNamespaceShardScheme verifies that all the hosts of a namespace are placed on the same shared VS,
+// with the namespace sharding scheme set globally.
+func TestNamespaceShardScheme(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	os.Setenv("L7_SHARD_SCHEME", lib.NAMESPACE_SHARD_SCHEME)
+	defer os.Unsetenv("L7_SHARD_SCHEME")
+
+	// foo.com and bar.com are on the shared VSes 0 and 1 as per the hostname scheme, and the
+	// namespace default is on the shared VS 6.
+	ingressName, ns := "foo-with-scheme", "default"
+	modelName := "admin/cluster--Shared-L7-6"
+	hostnameModelNames := []string{"admin/cluster--Shared-L7-0", "admin/cluster--Shared-L7-1"}
+	setUpTestForShardScheme(t, append(hostnameModelNames, modelName)...)
+
+	ingressCreate := (integrationtest.FakeIngress{
+		Name:        ingressName,
+		Namespace:   ns,
+		DnsNames:    []string{"foo.com", "bar.com"},
+		ServiceName: SHARDSCHEMESVC,
+	}).Ingress()
+	if _, err := KubeClient.NetworkingV1().Ingresses(ns).Create(context.TODO(), ingressCreate, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+
+	g.Eventually(func() int {
+		return getShardVSPoolCount(modelName)
+	}, 40*time.Second).Should(gomega.Equal(2))
+	for _, hostnameModelName := range hostnameModelNames {
+		g.Expect(getShardVSPoolCount(hostnameModelName)).To(gomega.Equal(0))
+	}
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].VSVIPRefs[0].FQDNs).To(gomega.ContainElements("foo.com", "bar.com"))
+
+	if err := KubeClient.NetworkingV1().Ingresses(ns).Delete(context.TODO(), ingressName, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Couldn't DELETE the Ingress %v", err)
+	}
+	VerifyPoolDeletionFromVsNode(g, modelName)
+	tearDownTestForShardScheme(t, append(hostnameModelNames, modelName)...)
+}
+
+// TestUpdateShardSchemeInInfraSetting verifies that the hosts of all the ingresses of the AviInfraSetting
+// are moved to their new shared VSes, when the sharding scheme is updated in the AviInfraSetting.
+func TestUpdateShardSchemeInInfraSetting(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ingClassName, ingressName, ns, settingName := "avi-lb-scheme", "foo-with-class", "default", "my-infrasetting"
+	bazIngressName := "baz-with-class"
+	modelName := "admin/cluster--Shared-L7-1"
+	// With the MEDIUM shard size, foo.com is on the shared VS 0 and bar.com and baz.com are on the
+	// shared VS 1 as per the hostname scheme, and the namespace default is on the shared VS 2.
+	fooModelName := "admin/cluster--Shared-L7-my-infrasetting-0"
+	barModelName := "admin/cluster--Shared-L7-my-infrasetting-1"
+	nsModelName := "admin/cluster--Shared-L7-my-infrasetting-2"
+	setUpTestForShardScheme(t, modelName, fooModelName, barModelName, nsModelName)
+
+	setting := integrationtest.FakeAviInfraSetting{
+		Name:          settingName,
+		SeGroupName:   "thisisaviref-" + settingName + "-seGroup",
+		Networks:      []string{"thisisaviref-" + settingName + "-networkName"},
+		EnableRhi:     true,
+		BGPPeerLabels: []string{"peer1", "peer2"},
+		ShardSize:     "MEDIUM",
+		ShardScheme:   lib.HOSTNAME_SHARD_SCHEME,
+	}
+	settingCreate := setting.AviInfraSetting()
+	if _, err := lib.AKOControlConfig().CRDClientset().AkoV1alpha1().AviInfraSettings().Create(context.TODO(), settingCreate, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding AviInfraSetting: %v", err)
+	}
+	integrationtest.SetupIngressClass(t, ingClassName, lib.AviIngressController, settingName)
+	g.Eventually(func() error {
+		_, err := utils.GetInformers().IngressClassInformer.Lister().Get(ingClassName)
+		return err
+	}, 10*time.Second).Should(gomega.BeNil())
+
+	ingressCreate := (integrationtest.FakeIngress{
+		Name:        ingressName,
+		Namespace:   ns,
+		ClassName:   ingClassName,
+		DnsNames:    []string{"foo.com", "bar.com"},
+		ServiceName: SHARDSCHEMESVC,
+	}).Ingress()
+	if _, err := KubeClient.NetworkingV1().Ingresses(ns).Create(context.TODO(), ingressCreate, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+	bazIngressCreate := (integrationtest.FakeIngress{
+		Name:        bazIngressName,
+		Namespace:   ns,
+		ClassName:   ingClassName,
+		DnsNames:    []string{"baz.com"},
+		ServiceName: SHARDSCHEMESVC,
+	}).Ingress()
+	if _, err := KubeClient.NetworkingV1().Ingresses(ns).Create(context.TODO(), bazIngressCreate, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+
+	g.Eventually(func() int {
+		return getShardVSPoolCount(fooModelName)
+	}, 40*time.Second).Should(gomega.Equal(1))
+	g.Eventually(func() int {
+		return getShardVSPoolCount(barModelName)
+	}, 40*time.Second).Should(gomega.Equal(2))
+	g.Expect(getShardVSPoolCount(nsModelName)).To(gomega.Equal(0))
+
+	setting.ShardScheme = lib.NAMESPACE_SHARD_SCHEME
+	settingUpdate := setting.AviInfraSetting()
+	settingUpdate.ResourceVersion = "2"
+	if _, err := lib.AKOControlConfig().CRDClientset().AkoV1alpha1().AviInfraSettings().Update(context.TODO(), settingUpdate, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating AviInfraSetting: %v", err)
+	}
+
+	// The hosts of both the ingresses are moved, and removed from the shared VSes of the hostname scheme.
+	g.Eventually(func() int {
+		return getShardVSPoolCount(nsModelName)
+	}, 40*time.Second).Should(gomega.Equal(3))
+	g.Eventually(func() int {
+		return getShardVSPoolCount(fooModelName)
+	}, 40*time.Second).Should(gomega.Equal(0))
+	g.Eventually(func() int {
+		return getShardVSPoolCount(barModelName)
+	}, 40*time.Second).Should(gomega.Equal(0))
+	_, aviModel := objects.SharedAviGraphLister().Get(nsModelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].VSVIPRefs[0].FQDNs).To(gomega.ContainElements("foo.com", "bar.com", "baz.com"))
+
+	if err := KubeClient.NetworkingV1().Ingresses(ns).Delete(context.TODO(), ingressName, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Couldn't DELETE the Ingress %v", err)
+	}
+	// The shard size and scheme of the AviInfraSetting are kept till its last ingress is removed.
+	g.Eventually(func() int {
+		return getShardVSPoolCount(nsModelName)
+	}, 40*time.Second).Should(gomega.Equal(1))
+	found, _ := objects.InfraSettingL7Lister().GetInfraSettingToShardSize(settingName)
+	g.Expect(found).To(gomega.BeTrue())
+	found, _ = objects.InfraSettingL7Lister().GetInfraSettingToShardScheme(settingName)
+	g.Expect(found).To(gomega.BeTrue())
+
+	if err := KubeClient.NetworkingV1().Ingresses(ns).Delete(context.TODO(), bazIngressName, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Couldn't DELETE the Ingress %v", err)
+	}
+	VerifyPoolDeletionFromVsNode(g, nsModelName)
+	g.Eventually(func() bool {
+		found, _ := objects.InfraSettingL7Lister().GetInfraSettingToShardSize(settingName)
+		return found
+	}, 40*time.Second).Should(gomega.BeFalse())
+	found, _ = objects.InfraSettingL7Lister().GetInfraSettingToShardScheme(settingName)
+	g.Expect(found).To(gomega.BeFalse())
+	integrationtest.TeardownAviInfraSetting(t, settingName)
+	tearDownTestForShardScheme(t, modelName, fooModelName, barModelName, nsModelName)
+	integrationtest.TeardownIngressClass(t, ingClassName)
+}
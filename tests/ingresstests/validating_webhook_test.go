@@ -0,0 +1,248 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package ingresstests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/apis/ako/v1alpha1"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func sendAdmissionReview(t *testing.T, kind string, operation admissionv1.Operation, obj interface{}) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("error in marshalling %s: %v", kind, err)
+	}
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID("test-uid"),
+			Kind:      metav1.GroupVersionKind{Group: lib.AkoGroup, Version: "v1alpha1", Kind: kind},
+			Namespace: "default",
+			Operation: operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, _ := json.Marshal(review)
+	req := httptest.NewRequest(http.MethodPost, lib.ValidatingWebhookPath, bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	k8s.ServeValidatingWebhook(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response code from validating webhook: %d", rec.Code)
+	}
+
+	response := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error in decoding admission review response: %v", err)
+	}
+	if response.Response == nil || response.Response.UID != review.Request.UID {
+		t.Fatalf("admission review response does not match the request")
+	}
+	return response.Response
+}
+
+func verifyAdmissionDenied(g *gomega.WithT, response *admissionv1.AdmissionResponse, message string) {
+	g.Expect(response.Allowed).To(gomega.BeFalse())
+	g.Expect(response.Result).NotTo(gomega.BeNil())
+	g.Expect(response.Result.Message).To(gomega.ContainSubstring(message))
+}
+
+func TestValidatingWebhookHostRule(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hrname := "samplehr-webhook-foo"
+	integrationtest.SetupHostRule(t, hrname, "webhook-foo.com", false)
+	g.Eventually(func() string {
+		hostrule, _ := CRDClient.AkoV1alpha1().HostRules("default").Get(context.TODO(), hrname, metav1.GetOptions{})
+		return hostrule.Status.Status
+	}, 10*time.Second).Should(gomega.Equal("Accepted"))
+
+	// updates to the same hostrule are allowed.
+	hostrule := integrationtest.FakeHostRule{
+		Name:      hrname,
+		Namespace: "default",
+		Fqdn:      "webhook-foo.com",
+	}.HostRule()
+	response := sendAdmissionReview(t, lib.HostRule, admissionv1.Update, hostrule)
+	g.Expect(response.Allowed).To(gomega.BeTrue())
+
+	// another hostrule with the same fqdn is denied.
+	// the fqdn of the accepted hostrule is registered by the graph layer, after its status update.
+	hostrule.Name = "samplehr-webhook-bar"
+	g.Eventually(func() bool {
+		response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+		return response.Allowed
+	}, 10*time.Second).Should(gomega.BeFalse())
+	verifyAdmissionDenied(g, response, "duplicate fqdn webhook-foo.com found in default/"+hrname)
+
+	hostrule = integrationtest.FakeHostRule{
+		Name:      "samplehr-webhook-bar",
+		Namespace: "default",
+		Fqdn:      "webhook-bar.com",
+		GslbFqdn:  "webhook-bar.com",
+	}.HostRule()
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "GSLB FQDN and local FQDN are same")
+
+	hostrule.Spec.VirtualHost.Gslb.Fqdn = ""
+	hostrule.Spec.VirtualHost.TCPSettings = &v1alpha1.HostRuleTCPSettings{LoadBalancerIP: "10.10.10.300"}
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "loadBalancerIP 10.10.10.300 is not a valid IP")
+
+	hostrule.Spec.VirtualHost.TCPSettings = nil
+	hostrule.Spec.VirtualHost.TLS.SSLKeyCertificate = v1alpha1.HostRuleSSLKeyCertificate{
+		Name: "webhook-missing-secret",
+		Type: v1alpha1.HostRuleSecretTypeSecretReference,
+	}
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "webhook-missing-secret")
+
+	hostrule.Spec.VirtualHost.TLS.SSLKeyCertificate = v1alpha1.HostRuleSSLKeyCertificate{}
+	hostrule.Spec.VirtualHost.WAFPolicy = "thisisBADaviref-waf"
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, `wafpolicy "thisisBADaviref-waf" not found on controller`)
+
+	hostrule.Spec.VirtualHost.WAFPolicy = "thisisaviref-waf"
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	g.Expect(response.Allowed).To(gomega.BeTrue())
+
+	// deletes are always allowed.
+	hostrule.Spec.VirtualHost.Fqdn = "webhook-foo.com"
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Delete, hostrule)
+	g.Expect(response.Allowed).To(gomega.BeTrue())
+
+	if err := CRDClient.AkoV1alpha1().HostRules("default").Delete(context.TODO(), hrname, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting HostRule: %v", err)
+	}
+}
+
+func TestValidatingWebhookHostRuleAliases(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	hostrule := integrationtest.FakeHostRule{
+		Name:      "samplehr-webhook-alias",
+		Namespace: "default",
+		Fqdn:      "webhook-alias.com",
+		GslbFqdn:  "webhook-gslb.com",
+	}.HostRule()
+	hostrule.Spec.VirtualHost.Aliases = []string{"alias1.com"}
+	response := sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "Aliases is supported only when FQDN type is set as Exact")
+
+	hostrule.Spec.VirtualHost.FqdnType = v1alpha1.Exact
+	hostrule.Spec.VirtualHost.Aliases = []string{"alias1.com", "alias1.com"}
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "Aliases must be unique")
+
+	hostrule.Spec.VirtualHost.Aliases = []string{"webhook-alias.com"}
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "Aliases field has same entry as the FQDN field")
+
+	hostrule.Spec.VirtualHost.Aliases = []string{"webhook-gslb.com"}
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	verifyAdmissionDenied(g, response, "Aliases must not contain GSLB FQDN")
+
+	hostrule.Spec.VirtualHost.Aliases = []string{"alias1.com"}
+	response = sendAdmissionReview(t, lib.HostRule, admissionv1.Create, hostrule)
+	g.Expect(response.Allowed).To(gomega.BeTrue())
+}
+
+func TestValidatingWebhookHTTPRule(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	httprule := integrationtest.FakeHTTPRule{
+		Name:      "samplerr-webhook",
+		Namespace: "default",
+		Fqdn:      "webhook-foo.com",
+		PathProperties: []integrationtest.FakeHTTPRulePath{{
+			Path:          "/foo",
+			PkiProfile:    "thisisaviref-pkiprofile",
+			DestinationCA: "httprule-destinationCA",
+		}},
+	}.HTTPRule()
+	response := sendAdmissionReview(t, lib.HTTPRule, admissionv1.Create, httprule)
+	verifyAdmissionDenied(g, response, lib.HttpRulePkiAndDestCASetErr)
+
+	httprule.Spec.Paths[0].TLS.DestinationCA = ""
+	httprule.Spec.Paths[0].HealthMonitors = []string{"thisisBADaviref-hm"}
+	response = sendAdmissionReview(t, lib.HTTPRule, admissionv1.Create, httprule)
+	verifyAdmissionDenied(g, response, `healthmonitor "thisisBADaviref-hm" not found on controller`)
+
+	httprule.Spec.Paths[0].HealthMonitors = []string{"thisisaviref-hm"}
+	response = sendAdmissionReview(t, lib.HTTPRule, admissionv1.Create, httprule)
+	g.Expect(response.Allowed).To(gomega.BeTrue())
+}
+
+func TestValidatingWebhookAviInfraSetting(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	infraSetting := integrationtest.FakeAviInfraSetting{
+		Name:          "infra-webhook",
+		SeGroupName:   "thisisaviref-seGroup",
+		Networks:      []string{"thisisaviref-networkName"},
+		BGPPeerLabels: []string{"peer1"},
+	}.AviInfraSetting()
+	response := sendAdmissionReview(t, lib.AviInfraSetting, admissionv1.Create, infraSetting)
+	verifyAdmissionDenied(g, response, "BGPPeerLabels cannot be set if EnableRhi is false.")
+
+	infraSetting.Spec.Network.BgpPeerLabels = nil
+	infraSetting.Spec.Network.VipNetworks[0].Cidr = "10.10.10.0/33"
+	response = sendAdmissionReview(t, lib.AviInfraSetting, admissionv1.Create, infraSetting)
+	verifyAdmissionDenied(g, response, "invalid CIDR configuration 10.10.10.0/33 detected for networkName thisisaviref-networkName")
+
+	infraSetting.Spec.Network.VipNetworks[0].Cidr = ""
+	infraSetting.Spec.SeGroup.Name = "thisisBADaviref-seGroup"
+	response = sendAdmissionReview(t, lib.AviInfraSetting, admissionv1.Create, infraSetting)
+	verifyAdmissionDenied(g, response, `serviceenginegroup "thisisBADaviref-seGroup" not found on controller`)
+}
+
+func TestValidatingWebhookMultiClusterIngress(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the MultiClusterIngress objects are supported only in the NodePort and EVH mode.
+	mci := &v1alpha1.MultiClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mci-webhook",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.MultiClusterIngressSpec{
+			Hostname: "webhook-mci.com",
+		},
+	}
+	response := sendAdmissionReview(t, lib.MultiClusterIngress, admissionv1.Create, mci)
+	verifyAdmissionDenied(g, response, "ServiceType must be of type NodePort")
+
+	// objects of other kinds are allowed.
+	response = sendAdmissionReview(t, lib.ServiceImport, admissionv1.Create, mci)
+	g.Expect(response.Allowed).To(gomega.BeTrue())
+}
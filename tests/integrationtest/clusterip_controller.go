@@ -0,0 +1,168 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package integrationtest
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// EPSLICENAME is the name of the EndpointSlice of the single port service, built by BuildEndpointSlice.
+const EPSLICENAME = "testsvc-abcde"
+
+// StartClusterIPController sets up the fake clients and the Avi fake controller, and starts the AKO controller
+// in ClusterIP mode with the given informers. The feature specific environment variables are set by the callers
+// before starting the controller. The fake clients are set in KubeClient and CRDClient.
+func StartClusterIPController(registeredInformers []string) *k8s.AviController {
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	InitializeFakeAKOAPIServer()
+	NewAviFakeClientInstance(KubeClient)
+
+	aviController := k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	AddConfigMap(KubeClient)
+	aviController.SetSEGroupCloudName()
+	PollForSyncStart(aviController, 10)
+
+	aviController.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	AddDefaultIngressClass()
+
+	go aviController.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	return aviController
+}
+
+// FakePod is a pod in NAMESPACE, with the readiness gate of the pool member condition if ReadinessGate is set.
+type FakePod struct {
+	Name          string
+	IP            string
+	ReadinessGate bool
+	Containers    []corev1.Container
+}
+
+func (pod FakePod) Pod() *corev1.Pod {
+	podObj := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: NAMESPACE, Name: pod.Name},
+		Spec:       corev1.PodSpec{Containers: pod.Containers},
+		Status:     corev1.PodStatus{PodIP: pod.IP, PodIPs: []corev1.PodIP{{IP: pod.IP}}},
+	}
+	if pod.ReadinessGate {
+		podObj.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: lib.PoolMemberReadyConditionType}}
+	}
+	return podObj
+}
+
+func CreatePod(t *testing.T, pod FakePod) {
+	if _, err := KubeClient.CoreV1().Pods(NAMESPACE).Create(context.TODO(), pod.Pod(), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in creating Pod: %v", err)
+	}
+}
+
+// FakeEndpoint is an endpoint of the EndpointSlice built by BuildEndpointSlice. The endpoint belongs to the pod
+// Pod, if it is set.
+type FakeEndpoint struct {
+	IP          string
+	Pod         string
+	Zone        string
+	HintZone    string
+	NotReady    bool
+	Terminating bool
+}
+
+// BuildEndpointSlice builds the EndpointSlice of the single port service with the given endpoints. The endpoints
+// which are not ready are still serving if they are terminating.
+func BuildEndpointSlice(endpoints []FakeEndpoint) *discovery.EndpointSlice {
+	portName, port, protocol := "foo0", int32(8080), corev1.ProtocolTCP
+	epSlice := &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: NAMESPACE,
+			Name:      EPSLICENAME,
+			Labels:    map[string]string{discovery.LabelServiceName: SINGLEPORTSVC},
+		},
+		AddressType: discovery.AddressTypeIPv4,
+		Ports:       []discovery.EndpointPort{{Name: &portName, Port: &port, Protocol: &protocol}},
+	}
+	for _, ep := range endpoints {
+		ready, serving, terminating := !ep.NotReady, !ep.NotReady || ep.Terminating, ep.Terminating
+		endpoint := discovery.Endpoint{
+			Addresses:  []string{ep.IP},
+			Conditions: discovery.EndpointConditions{Ready: &ready, Serving: &serving, Terminating: &terminating},
+		}
+		if ep.Pod != "" {
+			endpoint.TargetRef = &corev1.ObjectReference{Kind: "Pod", Namespace: NAMESPACE, Name: ep.Pod}
+		}
+		if ep.Zone != "" {
+			zone := ep.Zone
+			endpoint.Zone = &zone
+		}
+		if ep.HintZone != "" {
+			endpoint.Hints = &discovery.EndpointHints{ForZones: []discovery.ForZone{{Name: ep.HintZone}}}
+		}
+		epSlice.Endpoints = append(epSlice.Endpoints, endpoint)
+	}
+	return epSlice
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package integrationtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+)
+
+const DEBUGSVC = "debugsvc"
+
+func getDebugApi(t *testing.T, akoApi *api.ApiServer, uri string, code int) map[string]interface{} {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	rec := httptest.NewRecorder()
+	akoApi.Handler.ServeHTTP(rec, req)
+	if rec.Code != code {
+		t.Fatalf("unexpected status code for %s: %d, expected: %d", uri, rec.Code, code)
+	}
+	response := make(map[string]interface{})
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unable to unmarshal the response for %s: %v", uri, err)
+	}
+	return response
+}
+
+func debugObjectStatus(response map[string]interface{}) map[string]string {
+	status := make(map[string]string)
+	for _, obj := range response["objects"].([]interface{}) {
+		o := obj.(map[string]interface{})
+		status[o["type"].(string)+"/"+o["name"].(string)] = o["status"].(string)
+	}
+	return status
+}
+
+func TestDebugApiForSvcLB(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	akoApi := api.NewServer("0", []models.ApiModel{&k8s.DebugModel{}})
+
+	vsName := fmt.Sprintf("cluster--%s-%s", NAMESPACE, DEBUGSVC)
+	modelName := AVINAMESPACE + "/" + vsName
+	poolName := vsName + "-TCP-8080"
+	CreateSVC(t, NAMESPACE, DEBUGSVC, corev1.ServiceTypeLoadBalancer, false)
+	CreateEP(t, NAMESPACE, DEBUGSVC, false, false, "1.1.1")
+	mcache := cache.SharedAviObjCache()
+	vsKey := cache.NamespaceName{Namespace: AVINAMESPACE, Name: vsName}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(true))
+
+	response := getDebugApi(t, akoApi, "/api/debug/models", http.StatusOK)
+	g.Expect(response["models"]).To(gomega.ContainElement(modelName))
+
+	response = getDebugApi(t, akoApi, "/api/debug/model?name="+modelName, http.StatusOK)
+	g.Expect(response["name"]).To(gomega.Equal(modelName))
+	g.Expect(response["nodes"]).To(gomega.HaveLen(1))
+	modelObjs := make(map[string]string)
+	for _, obj := range response["objects"].([]interface{}) {
+		o := obj.(map[string]interface{})
+		modelObjs[o["type"].(string)+"/"+o["name"].(string)] = o["checksum"].(string)
+	}
+	g.Expect(modelObjs).To(gomega.HaveKey("VirtualService/" + vsName))
+	g.Expect(modelObjs).To(gomega.HaveKey("VsVip/" + vsName))
+	g.Expect(modelObjs).To(gomega.HaveKey("L4PolicySet/" + vsName))
+	g.Expect(modelObjs).To(gomega.HaveKey("Pool/" + poolName))
+
+	response = getDebugApi(t, akoApi, "/api/debug/cache?vs="+modelName, http.StatusOK)
+	g.Expect(response["virtualservice"].(map[string]interface{})["Name"]).To(gomega.Equal(vsName))
+	cacheObjs := make(map[string]string)
+	for _, obj := range response["objects"].([]interface{}) {
+		o := obj.(map[string]interface{})
+		g.Expect(o["found"]).To(gomega.BeTrue())
+		g.Expect(o["uuid"]).NotTo(gomega.BeEmpty())
+		cacheObjs[o["type"].(string)+"/"+o["name"].(string)] = o["checksum"].(string)
+	}
+	g.Expect(cacheObjs).To(gomega.HaveKeyWithValue("Pool/"+poolName, modelObjs["Pool/"+poolName]))
+
+	response = getDebugApi(t, akoApi, "/api/debug/compare?name="+modelName, http.StatusOK)
+	g.Expect(debugObjectStatus(response)).To(gomega.HaveKeyWithValue("VirtualService/"+vsName, "InSync"))
+	g.Expect(debugObjectStatus(response)).To(gomega.HaveKeyWithValue("VsVip/"+vsName, "InSync"))
+	g.Expect(debugObjectStatus(response)).To(gomega.HaveKeyWithValue("Pool/"+poolName, "InSync"))
+
+	// A pool with a stale checksum in the cache is flagged.
+	poolCacheIntf, _ := mcache.PoolCache.AviCacheGet(cache.NamespaceName{Namespace: AVINAMESPACE, Name: poolName})
+	poolCache := poolCacheIntf.(*cache.AviPoolCache)
+	poolChecksum := poolCache.CloudConfigCksum
+	poolCache.CloudConfigCksum = "1"
+	response = getDebugApi(t, akoApi, "/api/debug/compare?name="+modelName, http.StatusOK)
+	poolCache.CloudConfigCksum = poolChecksum
+	g.Expect(response["in_sync"]).To(gomega.BeFalse())
+	g.Expect(debugObjectStatus(response)).To(gomega.HaveKeyWithValue("Pool/"+poolName, "ChecksumMismatch"))
+
+	getDebugApi(t, akoApi, "/api/debug/model?name=admin/nonexistent", http.StatusNotFound)
+	getDebugApi(t, akoApi, "/api/debug/cache?vs=admin/nonexistent", http.StatusNotFound)
+
+	DelSVC(t, NAMESPACE, DEBUGSVC)
+	DelEP(t, NAMESPACE, DEBUGSVC)
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(false))
+}
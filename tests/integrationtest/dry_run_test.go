@@ -0,0 +1,85 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package integrationtest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api/models"
+)
+
+const DRYRUNSVC = "dryrunsvc"
+
+func TestDryRunPlanForSvcLB(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	var writeCount int32
+
+	os.Setenv("DRY_RUN", "true")
+	defer os.Unsetenv("DRY_RUN")
+	(&models.DryRunModel{}).InitModel()
+
+	AddMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && !strings.Contains(r.URL.EscapedPath(), "login") {
+			atomic.AddInt32(&writeCount, 1)
+		}
+		NormalControllerServer(w, r)
+	})
+	defer ResetMiddleware()
+
+	modelName := fmt.Sprintf("%s/cluster--%s-%s", AVINAMESPACE, NAMESPACE, DRYRUNSVC)
+	CreateSVC(t, NAMESPACE, DRYRUNSVC, corev1.ServiceTypeLoadBalancer, false)
+	CreateEP(t, NAMESPACE, DRYRUNSVC, false, false, "1.1.1")
+
+	g.Eventually(func() int {
+		return len(models.DryRunPlan.GetPlan(modelName))
+	}, 10*time.Second).Should(gomega.Equal(1))
+	diff := models.DryRunPlan.GetPlan(modelName)[modelName]
+	g.Expect(diff.Model).To(gomega.Equal(modelName))
+	g.Expect(diff.Update).To(gomega.BeEmpty())
+	g.Expect(diff.Delete).To(gomega.BeEmpty())
+	createdModels := make(map[string]bool)
+	for _, op := range diff.Create {
+		g.Expect(op.Method).To(gomega.Equal("POST"))
+		g.Expect(op.Object).NotTo(gomega.BeEmpty())
+		createdModels[op.Model] = true
+	}
+	g.Expect(createdModels).To(gomega.HaveKey("VirtualService"))
+	g.Expect(createdModels).To(gomega.HaveKey("VsVip"))
+	g.Expect(createdModels).To(gomega.HaveKey("Pool"))
+
+	// Nothing is sent to the controller, and the cache is not updated.
+	g.Expect(atomic.LoadInt32(&writeCount)).To(gomega.Equal(int32(0)))
+	vsKey := cache.NamespaceName{Namespace: AVINAMESPACE, Name: fmt.Sprintf("cluster--%s-%s", NAMESPACE, DRYRUNSVC)}
+	_, found := cache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+	g.Expect(found).To(gomega.BeFalse())
+
+	// The plan is removed once the service is deleted, as the virtualservice was never created.
+	DelSVC(t, NAMESPACE, DRYRUNSVC)
+	DelEP(t, NAMESPACE, DRYRUNSVC)
+	g.Eventually(func() int {
+		return len(models.DryRunPlan.GetPlan(modelName))
+	}, 10*time.Second).Should(gomega.Equal(0))
+	g.Expect(atomic.LoadInt32(&writeCount)).To(gomega.Equal(int32(0)))
+}
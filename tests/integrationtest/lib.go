@@ -1162,12 +1162,27 @@ func NormalControllerServer(w http.ResponseWriter, r *http.Request, args ...stri
 		w.Write(finalResponse)
 
 	} else if r.Method == "PATCH" && strings.Contains(url, "vrfcontext") {
-		// This won't help in checking for Cache values, since we are sending back static content
-		// It is only to remove API call warning related to vrfcontext PATCH calls.
+		// The vrf cache is updated from the static routes in the PATCH payload, the static content is sent back
+		// only to remove API call warning related to vrfcontext PATCH calls.
 		w.WriteHeader(http.StatusOK)
 		data, _ := ioutil.ReadFile(fmt.Sprintf("%s/vrfcontext_uuid_mock.json", mockFilePath))
 		w.Write(data)
 
+	} else if r.Method == "PATCH" && strings.Contains(url, "/api/pool/") {
+		// The pool PATCH calls update only the servers and the checksum of the pool, so the pool name and uuid are
+		// sent back along with the patch payload.
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &resp)
+		if resp == nil {
+			resp = make(map[string]interface{})
+		}
+		poolUUID := strings.Split(strings.Trim(url, "/"), "/")[2]
+		resp["uuid"] = poolUUID
+		resp["name"] = strings.TrimSuffix(strings.TrimPrefix(poolUUID, "pool-"), "-"+RANDOMUUID)
+		finalResponse, _ = json.Marshal(resp)
+		w.WriteHeader(http.StatusOK)
+		w.Write(finalResponse)
+
 	} else if r.Method == "GET" && strings.Contains(r.URL.RawQuery, "aviref") {
 		// block to handle
 		if strings.Contains(r.URL.RawQuery, "thisisaviref") {
@@ -1384,6 +1399,8 @@ type FakeHTTPRulePath struct {
 	HealthMonitors []string
 	LbAlgorithm    string
 	Hash           string
+	Matches        []akov1alpha1.HTTPRuleMatch
+	Backends       []akov1alpha1.HTTPRuleWeightedBackend
 }
 
 func (rr FakeHTTPRule) HTTPRule() *akov1alpha1.HTTPRule {
@@ -1400,6 +1417,8 @@ func (rr FakeHTTPRule) HTTPRule() *akov1alpha1.HTTPRule {
 				Algorithm: p.LbAlgorithm,
 				Hash:      p.Hash,
 			},
+			Matches:  p.Matches,
+			Backends: p.Backends,
 		}
 		if p.DestinationCA != "" {
 			rrForPath.TLS.DestinationCA = p.DestinationCA
@@ -1590,6 +1609,7 @@ type FakeAviInfraSetting struct {
 	EnableRhi      bool
 	EnablePublicIP bool
 	ShardSize      string
+	ShardScheme    string
 	BGPPeerLabels  []string
 }
 
@@ -1619,6 +1639,9 @@ func (infraSetting FakeAviInfraSetting) AviInfraSetting() *akov1alpha1.AviInfraS
 	if infraSetting.ShardSize != "" {
 		setting.Spec.L7Settings.ShardSize = infraSetting.ShardSize
 	}
+	if infraSetting.ShardScheme != "" {
+		setting.Spec.L7Settings.ShardScheme = infraSetting.ShardScheme
+	}
 
 	return setting
 }
@@ -0,0 +1,573 @@
+/*
+ * Copyright 2022-2023 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package istiotests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	istionetworking "istio.io/api/networking/v1alpha3"
+	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiocrd "istio.io/client-go/pkg/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/api"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+var akoApiServer *api.FakeApiServer
+
+const (
+	gatewayName = "gw"
+	vsName      = "vs"
+	drName      = "dr"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("INGRESS_API", "extensionv1")
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+	os.Setenv("ISTIO_ENABLED", "true")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+	istioData := map[string][]byte{
+		"root-cert":  []byte("rootCert"),
+		"key":        []byte("key"),
+		"cert-chain": []byte("certChain"),
+	}
+	istioSecret := &corev1.Secret{Data: istioData, ObjectMeta: metav1.ObjectMeta{Name: lib.IstioSecret, Namespace: utils.GetAKONamespace()}}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), istioSecret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+		utils.PodInformer,
+	}
+	args := make(map[string]interface{})
+	args[utils.INFORMERS_AKO_CLIENT] = CRDClient
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers, args)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+	istioClient, _ := istiocrd.NewForConfig(&rest.Config{Host: newFakeIstioServer().URL})
+	akoControlConfig.SetIstioClientset(istioClient)
+	k8s.NewIstioCRDInformers(istioClient)
+
+	mcache := cache.SharedAviObjCache()
+	cloudObj := &cache.AviCloudPropertyCache{Name: "Default-Cloud", VType: "mock"}
+	cloudObj.NSIpamDNS = []string{"avi.internal", ".com"}
+	mcache.CloudKeyCache.AviCacheAdd("Default-Cloud", cloudObj)
+
+	akoApiServer = integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+	ctrl.SetSEGroupCloudName()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+// newFakeIstioServer serves empty lists and idle watches for the Istio informers, as there is no
+// fake Istio clientset. Istio objects are added to the informer caches directly.
+func newFakeIstioServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") == "true" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"metadata":{"resourceVersion":"1"},"items":[]}`))
+	}))
+}
+
+func getModelName() string {
+	return lib.GetModelName(lib.GetTenant(), lib.GetIstioGatewayVSName(gatewayName, "default"))
+}
+
+func buildGateway(servers ...*istionetworking.Server) *istiov1alpha3.Gateway {
+	return &istiov1alpha3.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: gatewayName, ResourceVersion: "1"},
+		Spec: istionetworking.Gateway{
+			Selector: map[string]string{"istio": "ingressgateway"},
+			Servers:  servers,
+		},
+	}
+}
+
+func httpServer(port uint32, hosts ...string) *istionetworking.Server {
+	return &istionetworking.Server{
+		Name:  "http",
+		Port:  &istionetworking.Port{Number: port, Protocol: "HTTP", Name: "http"},
+		Hosts: hosts,
+	}
+}
+
+func httpsServer(port uint32, credentialName string, hosts ...string) *istionetworking.Server {
+	return &istionetworking.Server{
+		Name:  "https",
+		Port:  &istionetworking.Port{Number: port, Protocol: "HTTPS", Name: "https"},
+		Hosts: hosts,
+		Tls: &istionetworking.ServerTLSSettings{
+			Mode:           istionetworking.ServerTLSSettings_SIMPLE,
+			CredentialName: credentialName,
+		},
+	}
+}
+
+func buildVirtualService(hosts []string, routes ...*istionetworking.HTTPRoute) *istiov1alpha3.VirtualService {
+	return &istiov1alpha3.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: vsName, ResourceVersion: "1"},
+		Spec: istionetworking.VirtualService{
+			Hosts:    hosts,
+			Gateways: []string{gatewayName, "mesh"},
+			Http:     routes,
+		},
+	}
+}
+
+func destination(svc string, port uint32, subset string, weight int32) *istionetworking.HTTPRouteDestination {
+	return &istionetworking.HTTPRouteDestination{
+		Destination: &istionetworking.Destination{
+			Host:   svc,
+			Subset: subset,
+			Port:   &istionetworking.PortSelector{Number: port},
+		},
+		Weight: weight,
+	}
+}
+
+func prefixMatch(prefix string) *istionetworking.HTTPMatchRequest {
+	return &istionetworking.HTTPMatchRequest{
+		Uri: &istionetworking.StringMatch{MatchType: &istionetworking.StringMatch_Prefix{Prefix: prefix}},
+	}
+}
+
+func addIstioObjects(objs ...interface{}) {
+	istioInformers := lib.AKOControlConfig().IstioCRDInformers()
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *istiov1alpha3.Gateway:
+			istioInformers.GatewayInformer.Informer().GetIndexer().Add(obj)
+		case *istiov1alpha3.VirtualService:
+			istioInformers.VirtualServiceInformer.Informer().GetIndexer().Add(obj)
+		case *istiov1alpha3.DestinationRule:
+			istioInformers.DestinationRuleInformer.Informer().GetIndexer().Add(obj)
+		}
+	}
+}
+
+func deleteIstioObjects(objs ...interface{}) {
+	istioInformers := lib.AKOControlConfig().IstioCRDInformers()
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *istiov1alpha3.Gateway:
+			istioInformers.GatewayInformer.Informer().GetIndexer().Delete(obj)
+		case *istiov1alpha3.VirtualService:
+			istioInformers.VirtualServiceInformer.Informer().GetIndexer().Delete(obj)
+		case *istiov1alpha3.DestinationRule:
+			istioInformers.DestinationRuleInformer.Informer().GetIndexer().Delete(obj)
+		}
+	}
+}
+
+func setUpServices(t *testing.T, g *gomega.GomegaWithT, svcNames ...string) {
+	for _, svcName := range svcNames {
+		integrationtest.CreateSVC(t, "default", svcName, corev1.ServiceTypeClusterIP, false)
+		integrationtest.CreateEP(t, "default", svcName, false, true, "1.1.1")
+	}
+	g.Eventually(func() bool {
+		for _, svcName := range svcNames {
+			if _, err := utils.GetInformers().EpInformer.Lister().Endpoints("default").Get(svcName); err != nil {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Second).Should(gomega.Equal(true))
+}
+
+func tearDownServices(t *testing.T, svcNames ...string) {
+	for _, svcName := range svcNames {
+		integrationtest.DelSVC(t, "default", svcName)
+		integrationtest.DelEP(t, "default", svcName)
+	}
+}
+
+func getGatewayVS(g *gomega.GomegaWithT) *avinodes.AviVsNode {
+	modelName := getModelName()
+	found, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	g.Expect(found).To(gomega.Equal(true))
+	g.Expect(aviModel).ShouldNot(gomega.BeNil())
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes).To(gomega.HaveLen(1))
+	return nodes[0]
+}
+
+func syncGateway() {
+	avinodes.DequeueIngestion(lib.IstioGateway+"/default/"+gatewayName, true)
+}
+
+func TestIstioGatewayHTTPRoutes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	setUpServices(t, g, "reviews", "ratings")
+	gateway := buildGateway(httpServer(80, "foo.com"))
+	match := prefixMatch("/api")
+	match.Headers = map[string]*istionetworking.StringMatch{
+		"x-user": {MatchType: &istionetworking.StringMatch_Exact{Exact: "tester"}},
+	}
+	vs := buildVirtualService([]string{"foo.com"},
+		&istionetworking.HTTPRoute{
+			Match: []*istionetworking.HTTPMatchRequest{match},
+			Route: []*istionetworking.HTTPRouteDestination{
+				destination("reviews", 8080, "", 80),
+				destination("ratings.default.svc.cluster.local", 8080, "", 20),
+			},
+		},
+		&istionetworking.HTTPRoute{
+			Route: []*istionetworking.HTTPRouteDestination{destination("reviews", 8080, "", 0)},
+		},
+	)
+	addIstioObjects(gateway, vs)
+	syncGateway()
+
+	vsNode := getGatewayVS(g)
+	g.Expect(vsNode.SNIParent).To(gomega.Equal(true))
+	g.Expect(vsNode.PortProto).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PortProto[0].Port).To(gomega.Equal(int32(80)))
+	g.Expect(vsNode.VSVIPRefs[0].FQDNs).To(gomega.ContainElement("foo.com"))
+
+	g.Expect(vsNode.HttpPolicyRefs).To(gomega.HaveLen(1))
+	hppMap := vsNode.HttpPolicyRefs[0].HppMap
+	g.Expect(hppMap).To(gomega.HaveLen(2))
+	g.Expect(hppMap[0].Path).To(gomega.Equal([]string{"/api"}))
+	g.Expect(hppMap[0].MatchCriteria).To(gomega.Equal("BEGINS_WITH"))
+	g.Expect(hppMap[0].Port).To(gomega.Equal(uint32(80)))
+	g.Expect(hppMap[0].HostHeader.Values).To(gomega.Equal([]string{"foo.com"}))
+	g.Expect(hppMap[0].Headers).To(gomega.HaveLen(1))
+	g.Expect(hppMap[0].Headers[0].Name).To(gomega.Equal("x-user"))
+	g.Expect(hppMap[0].Headers[0].MatchCriteria).To(gomega.Equal("HDR_EQUALS"))
+	g.Expect(hppMap[1].Path).To(gomega.Equal([]string{"/"}))
+
+	// The pool for reviews is shared across both the routes.
+	g.Expect(vsNode.PoolRefs).To(gomega.HaveLen(2))
+	g.Expect(vsNode.PoolRefs[0].Servers).To(gomega.HaveLen(3))
+	g.Expect(vsNode.PoolGroupRefs).To(gomega.HaveLen(2))
+	g.Expect(vsNode.PoolGroupRefs[0].Members).To(gomega.HaveLen(2))
+	ratios := make(map[string]int32)
+	for _, member := range vsNode.PoolGroupRefs[0].Members {
+		ratios[*member.PoolRef] = *member.Ratio
+	}
+	g.Expect(ratios).To(gomega.HaveKeyWithValue("/api/pool?name="+lib.GetIstioPoolName(vsNode.Name, "default", "reviews", "", 8080), int32(80)))
+	g.Expect(ratios).To(gomega.HaveKeyWithValue("/api/pool?name="+lib.GetIstioPoolName(vsNode.Name, "default", "ratings", "", 8080), int32(20)))
+	g.Expect(vsNode.PoolGroupRefs[1].Members).To(gomega.HaveLen(1))
+	g.Expect(*vsNode.PoolGroupRefs[1].Members[0].Ratio).To(gomega.Equal(int32(1)))
+
+	// The objects are marked with the istio gateway and virtualservice they are built from.
+	g.Expect(vsNode.AviMarkers.IstioGatewayName).To(gomega.Equal(gatewayName))
+	g.Expect(vsNode.AviMarkers.GatewayName).To(gomega.BeEmpty())
+	g.Expect(vsNode.HttpPolicyRefs[0].AviMarkers.IstioVirtualServiceName).To(gomega.Equal(vsName))
+	g.Expect(vsNode.PoolGroupRefs[0].AviMarkers.IstioVirtualServiceName).To(gomega.Equal(vsName))
+	g.Expect(vsNode.PoolRefs[0].AviMarkers.IstioVirtualServiceName).To(gomega.Equal(vsName))
+	g.Expect(vsNode.PoolRefs[0].AviMarkers.GatewayName).To(gomega.BeEmpty())
+
+	found, svcVSes := objects.SharedIstioLister().GetSvcToVSes("default/ratings")
+	g.Expect(found).To(gomega.Equal(true))
+	g.Expect(svcVSes).To(gomega.Equal([]string{"default/" + vsName}))
+
+	deleteIstioObjects(gateway, vs)
+	syncGateway()
+	_, aviModel := objects.SharedAviGraphLister().Get(getModelName())
+	g.Expect(aviModel).To(gomega.BeNil())
+	tearDownServices(t, "reviews", "ratings")
+}
+
+func TestIstioGatewayHTTPSServer(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	setUpServices(t, g, "reviews")
+	integrationtest.AddSecret("foo-cert", "default", "tlsCert", "tlsKey")
+	g.Eventually(func() error {
+		_, err := utils.GetInformers().SecretInformer.Lister().Secrets("default").Get("foo-cert")
+		return err
+	}, 10*time.Second).Should(gomega.BeNil())
+
+	httpRedirect := httpServer(80, "foo.com")
+	httpRedirect.Tls = &istionetworking.ServerTLSSettings{HttpsRedirect: true}
+	gateway := buildGateway(httpRedirect, httpsServer(443, "foo-cert", "foo.com"))
+	vs := buildVirtualService([]string{"foo.com"},
+		&istionetworking.HTTPRoute{
+			Match: []*istionetworking.HTTPMatchRequest{prefixMatch("/v1")},
+			Route: []*istionetworking.HTTPRouteDestination{destination("reviews", 8080, "", 0)},
+			Rewrite: &istionetworking.HTTPRewrite{
+				Uri: "/v2",
+			},
+		},
+	)
+	addIstioObjects(gateway, vs)
+	syncGateway()
+
+	vsNode := getGatewayVS(g)
+	g.Expect(vsNode.PortProto).To(gomega.HaveLen(2))
+	g.Expect(vsNode.PortProto[1].EnableSSL).To(gomega.Equal(true))
+	g.Expect(vsNode.HttpPolicyRefs).To(gomega.HaveLen(1))
+	g.Expect(vsNode.HttpPolicyRefs[0].RedirectPorts).To(gomega.HaveLen(1))
+	g.Expect(vsNode.HttpPolicyRefs[0].RedirectPorts[0].RedirectPort).To(gomega.Equal(int32(443)))
+	g.Expect(vsNode.HttpPolicyRefs[0].RedirectPorts[0].VsPort).To(gomega.Equal(int32(80)))
+
+	g.Expect(vsNode.SniNodes).To(gomega.HaveLen(1))
+	sniNode := vsNode.SniNodes[0]
+	g.Expect(sniNode.VHParentName).To(gomega.Equal(vsNode.Name))
+	g.Expect(sniNode.VHDomainNames).To(gomega.Equal([]string{"foo.com"}))
+	g.Expect(sniNode.SSLKeyCertRefs).To(gomega.HaveLen(1))
+	g.Expect(string(sniNode.SSLKeyCertRefs[0].Cert)).To(gomega.Equal("tlsCert"))
+	g.Expect(sniNode.HttpPolicyRefs).To(gomega.HaveLen(1))
+	hppMap := sniNode.HttpPolicyRefs[0].HppMap
+	g.Expect(hppMap).To(gomega.HaveLen(1))
+	g.Expect(hppMap[0].Port).To(gomega.Equal(uint32(0)))
+	g.Expect(hppMap[0].Rewrite).ShouldNot(gomega.BeNil())
+	g.Expect(hppMap[0].Rewrite.Path).To(gomega.Equal("/v2"))
+	g.Expect(hppMap[0].Rewrite.KeepPathSuffix).To(gomega.Equal(true))
+	g.Expect(hppMap[0].Rewrite.PathSuffixIndex).To(gomega.Equal(int32(1)))
+	g.Expect(sniNode.PoolRefs).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PoolRefs).To(gomega.HaveLen(0))
+
+	// A secret without the certificate drops the HTTPS server.
+	integrationtest.DeleteSecret("foo-cert", "default")
+	g.Eventually(func() bool {
+		_, err := utils.GetInformers().SecretInformer.Lister().Secrets("default").Get("foo-cert")
+		return err != nil
+	}, 10*time.Second).Should(gomega.Equal(true))
+	avinodes.DequeueIngestion(utils.Secret+"/default/foo-cert", true)
+	vsNode = getGatewayVS(g)
+	g.Expect(vsNode.SniNodes).To(gomega.HaveLen(0))
+
+	deleteIstioObjects(gateway, vs)
+	syncGateway()
+	tearDownServices(t, "reviews")
+}
+
+func TestIstioRedirectRoute(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gateway := buildGateway(httpServer(80, "*.foo.com"))
+	match := prefixMatch("/old")
+	match.Method = &istionetworking.StringMatch{MatchType: &istionetworking.StringMatch_Exact{Exact: "get"}}
+	vs := buildVirtualService([]string{"*.foo.com"},
+		&istionetworking.HTTPRoute{
+			Match: []*istionetworking.HTTPMatchRequest{match},
+			Redirect: &istionetworking.HTTPRedirect{
+				Uri:          "/new",
+				RedirectCode: 302,
+			},
+		},
+	)
+	addIstioObjects(gateway, vs)
+	syncGateway()
+
+	vsNode := getGatewayVS(g)
+	g.Expect(vsNode.VSVIPRefs[0].FQDNs).To(gomega.HaveLen(0))
+	g.Expect(vsNode.PoolGroupRefs).To(gomega.HaveLen(0))
+	hppMap := vsNode.HttpPolicyRefs[0].HppMap
+	g.Expect(hppMap).To(gomega.HaveLen(1))
+	g.Expect(hppMap[0].HostHeader.MatchCriteria).To(gomega.Equal("HDR_ENDS_WITH"))
+	g.Expect(hppMap[0].HostHeader.Values).To(gomega.Equal([]string{".foo.com"}))
+	g.Expect(hppMap[0].Methods).To(gomega.Equal([]string{"HTTP_METHOD_GET"}))
+	g.Expect(hppMap[0].Redirect).ShouldNot(gomega.BeNil())
+	g.Expect(hppMap[0].Redirect.Path).To(gomega.Equal("/new"))
+	g.Expect(hppMap[0].Redirect.Protocol).To(gomega.Equal("HTTP"))
+	g.Expect(hppMap[0].Redirect.StatusCode).To(gomega.Equal("HTTP_REDIRECT_STATUS_CODE_302"))
+
+	// Regex matches cannot be translated.
+	vs = vs.DeepCopy()
+	vs.ResourceVersion = "2"
+	vs.Spec.Http[0].Match[0].Uri = &istionetworking.StringMatch{MatchType: &istionetworking.StringMatch_Regex{Regex: "/o.*"}}
+	addIstioObjects(vs)
+	avinodes.DequeueIngestion(lib.IstioVirtualService+"/default/"+vsName, true)
+	vsNode = getGatewayVS(g)
+	g.Expect(vsNode.HttpPolicyRefs[0].HppMap).To(gomega.HaveLen(0))
+
+	deleteIstioObjects(gateway, vs)
+	syncGateway()
+}
+
+func TestIstioDestinationRule(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	integrationtest.CreateSVC(t, "default", "reviews", corev1.ServiceTypeClusterIP, false)
+	epExample := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "reviews"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{
+				{IP: "1.1.1.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "reviews-v1"}},
+				{IP: "1.1.1.2", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "reviews-v2"}},
+			},
+			Ports: []corev1.EndpointPort{{Name: "foo0", Port: 8080, Protocol: "TCP"}},
+		}},
+	}
+	KubeClient.CoreV1().Endpoints("default").Create(context.TODO(), epExample, metav1.CreateOptions{})
+	for _, version := range []string{"v1", "v2"} {
+		KubeClient.CoreV1().Pods("default").Create(context.TODO(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "reviews-" + version,
+				Labels:    map[string]string{"app": "reviews", "version": version},
+			},
+		}, metav1.CreateOptions{})
+	}
+	g.Eventually(func() bool {
+		ep, err := utils.GetInformers().EpInformer.Lister().Endpoints("default").Get("reviews")
+		if err != nil || len(ep.Subsets) == 0 || ep.Subsets[0].Addresses[0].TargetRef == nil {
+			return false
+		}
+		pods, _ := utils.GetInformers().PodInformer.Lister().Pods("default").List(labels.Everything())
+		return len(pods) == 2
+	}, 10*time.Second).Should(gomega.Equal(true))
+
+	gateway := buildGateway(httpServer(80, "foo.com"))
+	vs := buildVirtualService([]string{"foo.com"},
+		&istionetworking.HTTPRoute{
+			Route: []*istionetworking.HTTPRouteDestination{destination("reviews", 8080, "v1", 0)},
+		},
+	)
+	dr := &istiov1alpha3.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: drName, ResourceVersion: "1"},
+		Spec: istionetworking.DestinationRule{
+			Host: "reviews",
+			TrafficPolicy: &istionetworking.TrafficPolicy{
+				LoadBalancer: &istionetworking.LoadBalancerSettings{
+					LbPolicy: &istionetworking.LoadBalancerSettings_Simple{Simple: istionetworking.LoadBalancerSettings_LEAST_CONN},
+				},
+			},
+			Subsets: []*istionetworking.Subset{{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+			}},
+		},
+	}
+	addIstioObjects(gateway, vs, dr)
+	syncGateway()
+
+	vsNode := getGatewayVS(g)
+	g.Expect(vsNode.PoolRefs).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PoolRefs[0].LbAlgorithm).To(gomega.Equal(lib.LB_ALGORITHM_LEAST_CONNECTIONS))
+	g.Expect(vsNode.PoolRefs[0].Servers).To(gomega.HaveLen(1))
+	g.Expect(*vsNode.PoolRefs[0].Servers[0].Ip.Addr).To(gomega.Equal("1.1.1.1"))
+	g.Expect(vsNode.PoolRefs[0].PkiProfileRef).ShouldNot(gomega.BeEmpty())
+
+	// The subset traffic policy overrides the top level traffic policy.
+	dr = dr.DeepCopy()
+	dr.ResourceVersion = "2"
+	dr.Spec.Subsets[0].TrafficPolicy = &istionetworking.TrafficPolicy{
+		LoadBalancer: &istionetworking.LoadBalancerSettings{
+			LbPolicy: &istionetworking.LoadBalancerSettings_ConsistentHash{
+				ConsistentHash: &istionetworking.LoadBalancerSettings_ConsistentHashLB{
+					HashKey: &istionetworking.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{HttpHeaderName: "x-user"},
+				},
+			},
+		},
+		Tls: &istionetworking.ClientTLSSettings{Mode: istionetworking.ClientTLSSettings_SIMPLE},
+	}
+	addIstioObjects(dr)
+	avinodes.DequeueIngestion(lib.IstioDestinationRule+"/default/"+drName, true)
+	found, svc := objects.SharedIstioLister().GetDRToSvc("default/" + drName)
+	g.Expect(found).To(gomega.BeTrue())
+	g.Expect(svc).To(gomega.Equal("default/reviews"))
+
+	vsNode = getGatewayVS(g)
+	pool := vsNode.PoolRefs[0]
+	g.Expect(pool.LbAlgorithm).To(gomega.Equal(lib.LB_ALGORITHM_CONSISTENT_HASH))
+	g.Expect(pool.LbAlgorithmHash).To(gomega.Equal(lib.LB_ALGORITHM_CONSISTENT_HASH_CUSTOM_HEADER))
+	g.Expect(pool.LbAlgoHostHeader).To(gomega.Equal("x-user"))
+	g.Expect(pool.SniEnabled).To(gomega.Equal(true))
+	g.Expect(pool.PkiProfileRef).To(gomega.BeEmpty())
+
+	deleteIstioObjects(gateway, vs, dr)
+	avinodes.DequeueIngestion(lib.IstioDestinationRule+"/default/"+drName, true)
+	found, _ = objects.SharedIstioLister().GetDRToSvc("default/" + drName)
+	g.Expect(found).To(gomega.BeFalse())
+	syncGateway()
+	tearDownServices(t, "reviews")
+	for _, version := range []string{"v1", "v2"} {
+		KubeClient.CoreV1().Pods("default").Delete(context.TODO(), "reviews-"+version, metav1.DeleteOptions{})
+	}
+}
@@ -0,0 +1,421 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package l4servicespectests
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/rest"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	avimodels "github.com/vmware/alb-sdk/go/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+
+const vsName = "cluster--red-ns-testsvc"
+const poolName = "cluster--red-ns-testsvc-TCP-8080"
+
+func TestMain(m *testing.M) {
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	ctrl.SetSEGroupCloudName()
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+func createService(t *testing.T, svc *corev1.Service) {
+	if _, err := KubeClient.CoreV1().Services(svc.Namespace).Create(context.TODO(), svc, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Service: %v", err)
+	}
+}
+
+func updateService(t *testing.T, svc *corev1.Service) {
+	svc.ResourceVersion = "2"
+	if _, err := KubeClient.CoreV1().Services(svc.Namespace).Update(context.TODO(), svc, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Service: %v", err)
+	}
+}
+
+func tearDownService(t *testing.T, g *gomega.GomegaWithT) {
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(false))
+}
+
+func getVsCache(vsKey cache.NamespaceName) *cache.AviVsCache {
+	vsCache, found := cache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+	if !found {
+		return nil
+	}
+	vsCacheObj, _ := vsCache.(*cache.AviVsCache)
+	return vsCacheObj
+}
+
+func getPoolCache(poolKey cache.NamespaceName) *cache.AviPoolCache {
+	poolCache, found := cache.SharedAviObjCache().PoolCache.AviCacheGet(poolKey)
+	if !found {
+		return nil
+	}
+	poolCacheObj, _ := poolCache.(*cache.AviPoolCache)
+	return poolCacheObj
+}
+
+func TestLoadBalancerClass(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// A service of type LoadBalancer claimed by another load balancer implementation is not handled by AKO.
+	otherClass := "example.com/other-lb"
+	svc := integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false, map[string]string{})
+	svc.Spec.LoadBalancerClass = &otherClass
+	createService(t, svc)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, false, "1.1.1")
+
+	g.Consistently(func() bool {
+		found, _ := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+		return found
+	}, 5*time.Second).Should(gomega.Equal(false))
+	tearDownService(t, g)
+
+	// A service of type LoadBalancer with the AKO load balancer class is handled by AKO.
+	aviClass := lib.AviLoadBalancerClass
+	svc = integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false, map[string]string{})
+	svc.Spec.LoadBalancerClass = &aviClass
+	createService(t, svc)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, false, "1.1.1")
+
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	g.Eventually(func() bool {
+		return getVsCache(vsKey) != nil
+	}, 15*time.Second).Should(gomega.Equal(true))
+	tearDownService(t, g)
+}
+
+func TestLoadBalancerSourceRanges(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	svc := integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false, map[string]string{})
+	svc.Spec.LoadBalancerSourceRanges = []string{"10.10.0.0/16", " 192.168.1.0/24", "10.10.0.0/16", "invalid"}
+	createService(t, svc)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, false, "1.1.1")
+
+	g.Eventually(func() int {
+		if found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL); found && aviModel != nil {
+			return len(aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].NSPolicyRefs)
+		}
+		return 0
+	}, 10*time.Second).Should(gomega.Equal(1))
+	_, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	nsPolicy := aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].NSPolicyRefs[0]
+	g.Expect(nsPolicy.Name).To(gomega.Equal(vsName))
+	g.Expect(nsPolicy.AllowedCIDRs).To(gomega.Equal([]string{"10.10.0.0/16", "192.168.1.0/24"}))
+
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	nspKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	g.Eventually(func() int {
+		if vsCache := getVsCache(vsKey); vsCache != nil {
+			return len(vsCache.NSPolicyCollection)
+		}
+		return 0
+	}, 15*time.Second).Should(gomega.Equal(1))
+	nspCache, found := cache.SharedAviObjCache().NSPolicyCache.AviCacheGet(nspKey)
+	g.Expect(found).To(gomega.Equal(true))
+	g.Expect(nspCache.(*cache.AviNSPolicyCache).CloudConfigCksum).To(gomega.Equal(nsPolicy.GetCheckSum()))
+
+	// Removing the source ranges removes the network security policy.
+	svc.Spec.LoadBalancerSourceRanges = nil
+	updateService(t, svc)
+	g.Eventually(func() int {
+		if vsCache := getVsCache(vsKey); vsCache != nil {
+			return len(vsCache.NSPolicyCollection)
+		}
+		return -1
+	}, 15*time.Second).Should(gomega.Equal(0))
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().NSPolicyCache.AviCacheGet(nspKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(false))
+
+	tearDownService(t, g)
+}
+
+func TestSessionAffinityClientIP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	timeout := int32(3601)
+	svc := integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false, map[string]string{})
+	svc.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+	svc.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: &timeout}}
+	createService(t, svc)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, false, "1.1.1")
+
+	g.Eventually(func() bool {
+		if found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL); found && aviModel != nil {
+			pools := aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].PoolRefs
+			return len(pools) == 1 && pools[0].PersistenceProfile != nil
+		}
+		return false
+	}, 10*time.Second).Should(gomega.Equal(true))
+	_, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	persistenceProfile := aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].PoolRefs[0].PersistenceProfile
+	// The timeout is rounded up to minutes.
+	g.Expect(persistenceProfile.Timeout).To(gomega.Equal(int32(61)))
+	g.Expect(persistenceProfile.Name).To(gomega.Equal(lib.GetL4PersistenceProfileName(poolName)))
+
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: poolName}
+	persistenceKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: persistenceProfile.Name}
+	g.Eventually(func() string {
+		if poolCache := getPoolCache(poolKey); poolCache != nil {
+			return poolCache.PersistenceProfileCollection.Name
+		}
+		return ""
+	}, 15*time.Second).Should(gomega.Equal(persistenceProfile.Name))
+	_, found := cache.SharedAviObjCache().PersistenceCache.AviCacheGet(persistenceKey)
+	g.Expect(found).To(gomega.Equal(true))
+
+	// Turning off the session affinity removes the persistence profile.
+	svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	svc.Spec.SessionAffinityConfig = nil
+	updateService(t, svc)
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().PersistenceCache.AviCacheGet(persistenceKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(false))
+	g.Expect(getPoolCache(poolKey).PersistenceProfileCollection.Name).To(gomega.Equal(""))
+
+	tearDownService(t, g)
+}
+
+func TestExternalTrafficPolicyLocalNodePort(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	integrationtest.SetNodePortMode()
+	defer integrationtest.SetClusterIPMode()
+	integrationtest.CreateNode(t, "testNodeA", "10.1.1.2")
+	defer integrationtest.DeleteNode(t, "testNodeA")
+	integrationtest.CreateNode(t, "testNodeB", "10.1.1.3")
+	defer integrationtest.DeleteNode(t, "testNodeB")
+
+	svc := integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false, map[string]string{})
+	svc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
+	createService(t, svc)
+	nodeName := "testNodeA"
+	epExample := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: integrationtest.NAMESPACE, Name: integrationtest.SINGLEPORTSVC},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "1.1.1.1", NodeName: &nodeName}},
+			Ports:     []corev1.EndpointPort{{Name: "foo0", Port: 8080, Protocol: "TCP"}},
+		}},
+	}
+	if _, err := KubeClient.CoreV1().Endpoints(integrationtest.NAMESPACE).Create(context.TODO(), epExample, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in creating Endpoint: %v", err)
+	}
+
+	// Only the node hosting the endpoint of the service is added as a server.
+	g.Eventually(func() []string {
+		var serverIPs []string
+		if found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL); found && aviModel != nil {
+			for _, pool := range aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].PoolRefs {
+				for _, server := range pool.Servers {
+					serverIPs = append(serverIPs, *server.Ip.Addr)
+				}
+			}
+		}
+		return serverIPs
+	}, 10*time.Second).Should(gomega.Equal([]string{"10.1.1.2"}))
+
+	// With the cluster traffic policy all the nodes are added as servers.
+	svc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	updateService(t, svc)
+	g.Eventually(func() int {
+		if found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL); found && aviModel != nil {
+			return len(aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].PoolRefs[0].Servers)
+		}
+		return 0
+	}, 10*time.Second).Should(gomega.Equal(2))
+
+	tearDownService(t, g)
+}
+
+func TestMixedProtocolNetworkProfiles(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	svc := integrationtest.ConstructService(integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, true, map[string]string{})
+	svc.Spec.Ports[1].Protocol = corev1.ProtocolUDP
+	svc.Spec.Ports[2].Protocol = corev1.ProtocolSCTP
+	createService(t, svc)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, true, false, "1.1.1")
+
+	g.Eventually(func() int {
+		if found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL); found && aviModel != nil {
+			return len(aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].L4ChildNodes)
+		}
+		return 0
+	}, 10*time.Second).Should(gomega.Equal(1))
+	_, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	vsNode := aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0]
+
+	// The TCP and UDP listeners share the parent VS, and are routed to their pools by the L4 policy set.
+	g.Expect(vsNode.PortProto).To(gomega.HaveLen(2))
+	g.Expect(vsNode.NetworkProfile).To(gomega.Equal(lib.GetL4NetworkProfile(utils.TCP)))
+	g.Expect(vsNode.L4PolicyRefs).To(gomega.HaveLen(1))
+	g.Expect(vsNode.L4PolicyRefs[0].PortPool).To(gomega.HaveLen(2))
+	g.Expect(vsNode.PoolRefs).To(gomega.HaveLen(2))
+
+	// The UDP listener overrides the TCP network profile of the VS.
+	aviRestObj := rest.NewRestOperations(cache.SharedAviObjCache(), nil)
+	restOps := aviRestObj.AviVsBuild(vsNode, utils.RestPost, nil, integrationtest.SINGLEPORTMODEL)
+	g.Expect(restOps).To(gomega.HaveLen(1))
+	services := restOps[0].Obj.(avimodels.VirtualService).Services
+	g.Expect(services).To(gomega.HaveLen(2))
+	for _, service := range services {
+		if *service.Port == 8081 {
+			g.Expect(*service.OverrideNetworkProfileRef).To(gomega.ContainSubstring(utils.SYSTEM_UDP_FAST_PATH))
+		} else {
+			g.Expect(service.OverrideNetworkProfileRef).To(gomega.BeNil())
+		}
+	}
+	// The network profiles overridden by the listeners are part of the VS checksum.
+	vsCopy := vsNode.CopyNode().(*avinodes.AviVsNode)
+	vsCopy.IsL4VS = false
+	g.Expect(vsCopy.GetCheckSum()).NotTo(gomega.Equal(vsNode.GetCheckSum()))
+
+	// The SCTP listener is placed on a child VS, which shares the VsVip of the parent VS.
+	childNode := vsNode.L4ChildNodes[0]
+	childName := lib.GetL4ChildVSName(integrationtest.SINGLEPORTSVC, integrationtest.NAMESPACE, utils.SCTP, 8082)
+	g.Expect(childNode.Name).To(gomega.Equal(childName))
+	g.Expect(childNode.NetworkProfile).To(gomega.Equal(utils.SYSTEM_SCTP_PROXY))
+	g.Expect(childNode.PortProto).To(gomega.HaveLen(1))
+	g.Expect(childNode.VSVIPRefs[0].Name).To(gomega.Equal(vsNode.VSVIPRefs[0].Name))
+	g.Expect(childNode.DefaultPool).To(gomega.Equal(lib.GetL4PoolName(integrationtest.SINGLEPORTSVC, integrationtest.NAMESPACE, utils.SCTP, 8082)))
+	g.Expect(vsNode.ServiceMetadata.L4ChildRefs).To(gomega.Equal([]string{childName}))
+
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	childKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: childName}
+	g.Eventually(func() bool {
+		childCache := getVsCache(childKey)
+		return childCache != nil && len(childCache.PoolKeyCollection) == 1
+	}, 15*time.Second).Should(gomega.Equal(true))
+	g.Expect(getVsCache(childKey).ServiceMetadataObj.L4ParentRef).To(gomega.Equal(vsName))
+	g.Expect(getVsCache(vsKey).ServiceMetadataObj.L4ChildRefs).To(gomega.Equal([]string{childName}))
+
+	// Removing the SCTP listener deletes the child VS.
+	svc.Spec.Ports = svc.Spec.Ports[:2]
+	updateService(t, svc)
+	g.Eventually(func() bool {
+		return getVsCache(childKey) != nil
+	}, 15*time.Second).Should(gomega.Equal(false))
+	g.Expect(getVsCache(vsKey)).NotTo(gomega.BeNil())
+
+	// The child VS is deleted along with the parent VS.
+	svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Name: "foo2", Port: 8082, Protocol: corev1.ProtocolSCTP})
+	svc.ResourceVersion = "3"
+	if _, err := KubeClient.CoreV1().Services(svc.Namespace).Update(context.TODO(), svc, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Service: %v", err)
+	}
+	g.Eventually(func() bool {
+		return getVsCache(childKey) != nil
+	}, 15*time.Second).Should(gomega.Equal(true))
+	tearDownService(t, g)
+	g.Eventually(func() bool {
+		return getVsCache(childKey) != nil
+	}, 15*time.Second).Should(gomega.Equal(false))
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package podreadinesstests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/status"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+
+// serverStates is the runtime state of the pool servers by their IP, returned for the pool runtime requests.
+// runtimeRequests has the URLs of the pool runtime requests.
+var serverStates map[string]string
+var runtimeRequests []string
+var serverStatesLock sync.RWMutex
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_POD_READINESS_GATE", "true")
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.PodInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	integrationtest.StartClusterIPController(registeredInformers)
+	KubeClient = integrationtest.KubeClient
+	os.Exit(m.Run())
+}
+
+// poolRuntimeControllerServer returns the runtime of the pool servers from serverStates, and hands over
+// the other requests to the normal controller server.
+func poolRuntimeControllerServer(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.EscapedPath()
+	if r.Method != "GET" || !strings.Contains(url, "/runtime/server") {
+		integrationtest.NormalControllerServer(w, r)
+		return
+	}
+
+	var servers []map[string]interface{}
+	serverStatesLock.Lock()
+	runtimeRequests = append(runtimeRequests, url)
+	for ip, state := range serverStates {
+		servers = append(servers, map[string]interface{}{
+			"server_ip":   map[string]string{"addr": ip, "type": "V4"},
+			"port":        8080,
+			"oper_status": map[string]string{"state": state},
+		})
+	}
+	serverStatesLock.Unlock()
+	finalResponse, _ := json.Marshal(servers)
+	w.WriteHeader(http.StatusOK)
+	w.Write(finalResponse)
+}
+
+func setServerStates(states map[string]string) {
+	serverStatesLock.Lock()
+	serverStates = states
+	serverStatesLock.Unlock()
+}
+
+// getRuntimeRequests returns the URLs of the pool runtime requests, and clears them.
+func getRuntimeRequests() []string {
+	serverStatesLock.Lock()
+	defer serverStatesLock.Unlock()
+	requests := runtimeRequests
+	runtimeRequests = nil
+	return requests
+}
+
+// getPodReadinessCondition syncs the readiness gates and returns the pool member readiness condition of the pod.
+func getPodReadinessCondition(name string) *corev1.PodCondition {
+	status.SyncPodReadinessGates()
+	pod, err := KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == lib.PoolMemberReadyConditionType {
+			return &condition
+		}
+	}
+	return nil
+}
+
+func getPodReadinessConditionReason(name string) string {
+	if condition := getPodReadinessCondition(name); condition != nil {
+		return string(condition.Status) + "/" + condition.Reason
+	}
+	return ""
+}
+
+func TestPodReadinessGate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.AddMiddleware(poolRuntimeControllerServer)
+	defer integrationtest.ResetMiddleware()
+	setServerStates(map[string]string{"1.1.1.1": "OPER_DOWN"})
+
+	integrationtest.CreatePod(t, integrationtest.FakePod{Name: "pod1", IP: "1.1.1.1", ReadinessGate: true})
+	integrationtest.CreatePod(t, integrationtest.FakePod{Name: "pod2", IP: "1.1.1.2", ReadinessGate: true})
+	integrationtest.CreatePod(t, integrationtest.FakePod{Name: "pod3", IP: "1.1.1.3"})
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, false, "1.1.1")
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, "othersvc", corev1.ServiceTypeLoadBalancer, false)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, "othersvc", false, false, "2.2.2")
+
+	mcache := cache.SharedAviObjCache()
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc-TCP-8080"}
+	otherPoolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-othersvc-TCP-8080"}
+	for _, key := range []cache.NamespaceName{poolKey, otherPoolKey} {
+		g.Eventually(func() bool {
+			_, found := mcache.PoolCache.AviCacheGet(key)
+			return found
+		}, 10*time.Second).Should(gomega.Equal(true))
+	}
+
+	// The condition is false while the server of the pod is down.
+	g.Eventually(func() string {
+		return getPodReadinessConditionReason("pod1")
+	}, 15*time.Second).Should(gomega.Equal("False/PoolMemberDown"))
+
+	// The condition is false for a pod which is not a server in any pool.
+	g.Eventually(func() string {
+		return getPodReadinessConditionReason("pod2")
+	}, 15*time.Second).Should(gomega.Equal("False/PoolMemberNotFound"))
+
+	// The condition is not set for a pod without the readiness gate.
+	g.Expect(getPodReadinessCondition("pod3")).To(gomega.BeNil())
+
+	// Only the runtime of the pool which has a pod with the readiness gate as a server is fetched.
+	getRuntimeRequests()
+	status.SyncPodReadinessGates()
+	poolIntf, _ := mcache.PoolCache.AviCacheGet(poolKey)
+	g.Expect(getRuntimeRequests()).To(gomega.ConsistOf(gomega.HaveSuffix("/api/pool/" + poolIntf.(*cache.AviPoolCache).Uuid + "/runtime/server/")))
+
+	// The condition is true once the server of the pod is up.
+	setServerStates(map[string]string{"1.1.1.1": "OPER_UP"})
+	g.Eventually(func() string {
+		return getPodReadinessConditionReason("pod1")
+	}, 15*time.Second).Should(gomega.Equal("True/PoolMemberUp"))
+	condition := getPodReadinessCondition("pod1")
+	g.Expect(condition.Message).To(gomega.Equal("Pod is up in 1 pools"))
+	lastTransitionTime := condition.LastTransitionTime
+
+	// The condition is not updated if the status of the server has not changed.
+	g.Consistently(func() metav1.Time {
+		if condition := getPodReadinessCondition("pod1"); condition != nil {
+			return condition.LastTransitionTime
+		}
+		return metav1.Time{}
+	}, 2*time.Second).Should(gomega.Equal(lastTransitionTime))
+
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, "othersvc")
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, "othersvc")
+	for _, pod := range []string{"pod1", "pod2", "pod3"} {
+		KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Delete(context.TODO(), pod, metav1.DeleteOptions{})
+	}
+}
@@ -0,0 +1,313 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package poolpatchtests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+
+const (
+	vsName   = "cluster--red-ns-testsvc"
+	poolName = "cluster--red-ns-testsvc-TCP-8080"
+)
+
+// poolRequest is a pool request made to the controller, with the addresses of the servers in the request.
+type poolRequest struct {
+	method  string
+	patchOp string
+	servers []string
+	cksum   string
+}
+
+var poolRequests []poolRequest
+var poolRequestsLock sync.RWMutex
+
+func TestMain(m *testing.M) {
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	ctrl.SetSEGroupCloudName()
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+// poolControllerServer records the pool POST, PUT and PATCH requests, and hands over all the requests to the
+// normal controller server.
+func poolControllerServer(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.EscapedPath()
+	if (r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH") && strings.Contains(url, "/api/pool") {
+		var req map[string]interface{}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &req)
+		request := poolRequest{method: r.Method}
+		if r.Method == "PATCH" {
+			for op, payload := range req {
+				request.patchOp = op
+				req, _ = payload.(map[string]interface{})
+			}
+		}
+		request.cksum, _ = req["cloud_config_cksum"].(string)
+		reqServers, _ := req["servers"].([]interface{})
+		for _, reqServer := range reqServers {
+			server := reqServer.(map[string]interface{})
+			request.servers = append(request.servers, server["ip"].(map[string]interface{})["addr"].(string))
+		}
+		sort.Strings(request.servers)
+		poolRequestsLock.Lock()
+		poolRequests = append(poolRequests, request)
+		poolRequestsLock.Unlock()
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+	integrationtest.NormalControllerServer(w, r)
+}
+
+func getPoolRequests() []poolRequest {
+	poolRequestsLock.RLock()
+	defer poolRequestsLock.RUnlock()
+	requests := make([]poolRequest, len(poolRequests))
+	copy(requests, poolRequests)
+	return requests
+}
+
+func resetPoolRequests() {
+	poolRequestsLock.Lock()
+	poolRequests = nil
+	poolRequestsLock.Unlock()
+}
+
+func updateEP(t *testing.T, addresses []string, resourceVersion string) {
+	var epAddresses []corev1.EndpointAddress
+	for _, addr := range addresses {
+		epAddresses = append(epAddresses, corev1.EndpointAddress{IP: addr})
+	}
+	epExample := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: integrationtest.NAMESPACE, Name: integrationtest.SINGLEPORTSVC, ResourceVersion: resourceVersion},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: epAddresses,
+			Ports:     []corev1.EndpointPort{{Name: "foo0", Port: 8080, Protocol: "TCP"}},
+		}},
+	}
+	if _, err := KubeClient.CoreV1().Endpoints(integrationtest.NAMESPACE).Update(context.TODO(), epExample, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Endpoint: %v", err)
+	}
+}
+
+func getPoolCache() *cache.AviPoolCache {
+	mcache := cache.SharedAviObjCache()
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: poolName}
+	poolCache, found := mcache.PoolCache.AviCacheGet(poolKey)
+	if !found {
+		return nil
+	}
+	return poolCache.(*cache.AviPoolCache)
+}
+
+// getPoolCacheServers returns the servers of the pool in the cache, if the cache is in sync with the model.
+func getPoolCacheServers() []string {
+	found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	if !found || aviModel == nil {
+		return nil
+	}
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	if len(nodes) != 1 || len(nodes[0].PoolRefs) != 1 {
+		return nil
+	}
+	poolCacheObj := getPoolCache()
+	if poolCacheObj == nil || poolCacheObj.CloudConfigCksum != strconv.Itoa(int(nodes[0].PoolRefs[0].GetCheckSum())) {
+		return nil
+	}
+	var servers []string
+	for serverKey := range poolCacheObj.Servers {
+		servers = append(servers, serverKey)
+	}
+	sort.Strings(servers)
+	return servers
+}
+
+func setUpPoolService(t *testing.T, g *gomega.WithT) {
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, true, "1.1.1")
+	g.Eventually(getPoolCacheServers, 10*time.Second).Should(gomega.Equal([]string{"1.1.1.1:8080", "1.1.1.2:8080", "1.1.1.3:8080"}))
+}
+
+func tearDownPoolService(t *testing.T, g *gomega.WithT) {
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	mcache := cache.SharedAviObjCache()
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+}
+
+func TestPoolServersPatched(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.AddMiddleware(poolControllerServer)
+	defer integrationtest.ResetMiddleware()
+
+	setUpPoolService(t, g)
+
+	// A new endpoint is added to the pool with a PATCH call carrying only the new server.
+	resetPoolRequests()
+	updateEP(t, []string{"1.1.1.1", "1.1.1.2", "1.1.1.3", "1.1.1.4"}, "2")
+	g.Eventually(getPoolCacheServers, 10*time.Second).Should(gomega.Equal([]string{"1.1.1.1:8080", "1.1.1.2:8080", "1.1.1.3:8080", "1.1.1.4:8080"}))
+	requests := getPoolRequests()
+	g.Expect(requests).To(gomega.HaveLen(1))
+	g.Expect(requests[0].method).To(gomega.Equal("PATCH"))
+	g.Expect(requests[0].patchOp).To(gomega.Equal(utils.PatchAddOp))
+	g.Expect(requests[0].servers).To(gomega.Equal([]string{"1.1.1.4"}))
+	g.Expect(requests[0].cksum).To(gomega.Equal(getPoolCache().CloudConfigCksum))
+
+	// The removed endpoints are deleted from the pool, and the checksum is updated with an add PATCH call.
+	resetPoolRequests()
+	updateEP(t, []string{"1.1.1.1", "1.1.1.4"}, "3")
+	g.Eventually(getPoolCacheServers, 10*time.Second).Should(gomega.Equal([]string{"1.1.1.1:8080", "1.1.1.4:8080"}))
+	requests = getPoolRequests()
+	g.Expect(requests).To(gomega.HaveLen(2))
+	g.Expect(requests[0].method).To(gomega.Equal("PATCH"))
+	g.Expect(requests[0].patchOp).To(gomega.Equal(utils.PatchDeleteOp))
+	g.Expect(requests[0].servers).To(gomega.Equal([]string{"1.1.1.2", "1.1.1.3"}))
+	g.Expect(requests[1].method).To(gomega.Equal("PATCH"))
+	g.Expect(requests[1].patchOp).To(gomega.Equal(utils.PatchAddOp))
+	g.Expect(requests[1].servers).To(gomega.BeEmpty())
+	g.Expect(requests[1].cksum).To(gomega.Equal(getPoolCache().CloudConfigCksum))
+
+	tearDownPoolService(t, g)
+}
+
+func TestPoolUpdatedWithPutWithoutNonServerChecksum(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.AddMiddleware(poolControllerServer)
+	defer integrationtest.ResetMiddleware()
+
+	setUpPoolService(t, g)
+
+	// The pools populated from the controller do not have the checksum of the fields other than the servers,
+	// so they are updated with a PUT call.
+	getPoolCache().NonServerCksum = ""
+	resetPoolRequests()
+	updateEP(t, []string{"1.1.1.1", "1.1.1.2"}, "2")
+	g.Eventually(getPoolCacheServers, 10*time.Second).Should(gomega.Equal([]string{"1.1.1.1:8080", "1.1.1.2:8080"}))
+	requests := getPoolRequests()
+	g.Expect(requests).To(gomega.HaveLen(1))
+	g.Expect(requests[0].method).To(gomega.Equal("PUT"))
+	g.Expect(requests[0].servers).To(gomega.Equal([]string{"1.1.1.1", "1.1.1.2"}))
+	g.Expect(getPoolCache().NonServerCksum).NotTo(gomega.BeEmpty())
+
+	// The next update of the servers is a PATCH call.
+	resetPoolRequests()
+	updateEP(t, []string{"1.1.1.1", "1.1.1.2", "1.1.1.5"}, "3")
+	g.Eventually(getPoolCacheServers, 10*time.Second).Should(gomega.Equal([]string{"1.1.1.1:8080", "1.1.1.2:8080", "1.1.1.5:8080"}))
+	requests = getPoolRequests()
+	g.Expect(requests).To(gomega.HaveLen(1))
+	g.Expect(requests[0].method).To(gomega.Equal("PATCH"))
+	g.Expect(requests[0].servers).To(gomega.Equal([]string{"1.1.1.5"}))
+
+	tearDownPoolService(t, g)
+}
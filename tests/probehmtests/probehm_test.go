@@ -0,0 +1,278 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package probehmtests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+
+const vsName = "cluster--red-ns-testsvc"
+const poolName = "cluster--red-ns-testsvc-TCP-8080"
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_PROBE_HEALTH_MONITOR", "true")
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.PodInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	integrationtest.StartClusterIPController(registeredInformers)
+	KubeClient = integrationtest.KubeClient
+	os.Exit(m.Run())
+}
+
+// createPod creates a pod with a sidecar container and the app container with the readiness probe.
+func createPod(t *testing.T, name, ip string, probe *corev1.Probe) {
+	integrationtest.CreatePod(t, integrationtest.FakePod{
+		Name: name,
+		IP:   ip,
+		Containers: []corev1.Container{
+			{Name: "sidecar", Ports: []corev1.ContainerPort{{Name: "proxy", ContainerPort: 15001}}},
+			{
+				Name:           "app",
+				Ports:          []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}, {Name: "health", ContainerPort: 9090}},
+				ReadinessProbe: probe,
+			},
+		},
+	})
+}
+
+// createEndpoints creates the endpoints of the service with the given pods, by their IPs.
+func createEndpoints(t *testing.T, pods map[string]string, resourceVersion string) {
+	var addresses []corev1.EndpointAddress
+	for ip, pod := range pods {
+		addresses = append(addresses, corev1.EndpointAddress{
+			IP:        ip,
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: integrationtest.NAMESPACE, Name: pod},
+		})
+	}
+	ep := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Namespace: integrationtest.NAMESPACE, Name: integrationtest.SINGLEPORTSVC, ResourceVersion: resourceVersion},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: addresses,
+			Ports:     []corev1.EndpointPort{{Name: "foo0", Port: 8080, Protocol: "TCP"}},
+		}},
+	}
+	var err error
+	if resourceVersion == "" {
+		_, err = KubeClient.CoreV1().Endpoints(integrationtest.NAMESPACE).Create(context.TODO(), ep, metav1.CreateOptions{})
+	} else {
+		_, err = KubeClient.CoreV1().Endpoints(integrationtest.NAMESPACE).Update(context.TODO(), ep, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		t.Fatalf("error in creating Endpoints: %v", err)
+	}
+}
+
+func setUpService(t *testing.T, pods map[string]string) {
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	createEndpoints(t, pods, "")
+}
+
+func tearDownService(t *testing.T, g *gomega.GomegaWithT, pods ...string) {
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: vsName}
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(false))
+	for _, pod := range pods {
+		KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Delete(context.TODO(), pod, metav1.DeleteOptions{})
+	}
+}
+
+// getPoolNode returns the pool of the service in the model, once the servers of the pool are populated.
+func getPoolNode() *avinodes.AviPoolNode {
+	if found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL); found && aviModel != nil {
+		vsNodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(vsNodes) == 1 && len(vsNodes[0].PoolRefs) == 1 && len(vsNodes[0].PoolRefs[0].Servers) > 0 {
+			return vsNodes[0].PoolRefs[0]
+		}
+	}
+	return nil
+}
+
+func getHealthMonitorCache(hmKey cache.NamespaceName) *cache.AviHealthMonitorCache {
+	hmCache, found := cache.SharedAviObjCache().HealthMonitorCache.AviCacheGet(hmKey)
+	if !found {
+		return nil
+	}
+	hmCacheObj, _ := hmCache.(*cache.AviHealthMonitorCache)
+	return hmCacheObj
+}
+
+func getPoolHealthMonitorCollection(poolKey cache.NamespaceName) string {
+	poolCache, found := cache.SharedAviObjCache().PoolCache.AviCacheGet(poolKey)
+	if !found {
+		return ""
+	}
+	return poolCache.(*cache.AviPoolCache).HealthMonitorCollection.Name
+}
+
+func TestProbeHealthMonitorHTTP(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:        "/healthz",
+				Port:        intstr.FromString("http"),
+				Scheme:      corev1.URISchemeHTTP,
+				HTTPHeaders: []corev1.HTTPHeader{{Name: "X-Probe", Value: "ako"}},
+			},
+		},
+		PeriodSeconds:    5,
+		TimeoutSeconds:   2,
+		SuccessThreshold: 1,
+		FailureThreshold: 4,
+	}
+	createPod(t, "pod1", "1.1.1.1", probe)
+	setUpService(t, map[string]string{"1.1.1.1": "pod1"})
+
+	g.Eventually(func() bool {
+		poolNode := getPoolNode()
+		return poolNode != nil && poolNode.ProbeHealthMonitor != nil
+	}, 10*time.Second).Should(gomega.Equal(true))
+	hmNode := getPoolNode().ProbeHealthMonitor
+	g.Expect(hmNode.Name).To(gomega.Equal(poolName))
+	g.Expect(hmNode.Type).To(gomega.Equal(lib.HealthMonitorTypeHTTP))
+	g.Expect(hmNode.HTTPRequest).To(gomega.Equal("GET /healthz HTTP/1.0\r\nX-Probe: ako"))
+	g.Expect(hmNode.MonitorPort).To(gomega.Equal(int32(0)))
+	g.Expect(hmNode.SendInterval).To(gomega.Equal(int32(5)))
+	g.Expect(hmNode.ReceiveTimeout).To(gomega.Equal(int32(2)))
+	g.Expect(hmNode.SuccessfulChecks).To(gomega.Equal(int32(1)))
+	g.Expect(hmNode.FailedChecks).To(gomega.Equal(int32(4)))
+
+	hmKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: poolName}
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: poolName}
+	g.Eventually(func() string {
+		return getPoolHealthMonitorCollection(poolKey)
+	}, 15*time.Second).Should(gomega.Equal(poolName))
+	g.Expect(getHealthMonitorCache(hmKey).CloudConfigCksum).To(gomega.Equal(hmNode.GetCheckSum()))
+
+	// A new pod with a different readiness probe updates the health monitor.
+	probe = probe.DeepCopy()
+	probe.HTTPGet.Path = "/ready"
+	probe.HTTPGet.Scheme = corev1.URISchemeHTTPS
+	probe.TimeoutSeconds = 10
+	createPod(t, "pod0", "1.1.1.2", probe)
+	createEndpoints(t, map[string]string{"1.1.1.2": "pod0"}, "2")
+	g.Eventually(func() string {
+		if poolNode := getPoolNode(); poolNode != nil && poolNode.ProbeHealthMonitor != nil {
+			return poolNode.ProbeHealthMonitor.HTTPRequest
+		}
+		return ""
+	}, 10*time.Second).Should(gomega.Equal("GET /ready HTTP/1.0\r\nX-Probe: ako"))
+	hmNode = getPoolNode().ProbeHealthMonitor
+	g.Expect(hmNode.Type).To(gomega.Equal(lib.HealthMonitorTypeHTTPS))
+	// The receive timeout is reduced to less than the send interval.
+	g.Expect(hmNode.ReceiveTimeout).To(gomega.Equal(int32(4)))
+	g.Eventually(func() uint32 {
+		if hmCache := getHealthMonitorCache(hmKey); hmCache != nil {
+			return hmCache.CloudConfigCksum
+		}
+		return 0
+	}, 15*time.Second).Should(gomega.Equal(hmNode.GetCheckSum()))
+
+	// The health monitor is deleted along with the pool.
+	tearDownService(t, g, "pod0", "pod1")
+	g.Eventually(func() bool {
+		return getHealthMonitorCache(hmKey) != nil
+	}, 15*time.Second).Should(gomega.Equal(false))
+}
+
+func TestProbeHealthMonitorTCPNamedPort(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("health")},
+		},
+	}
+	createPod(t, "pod1", "1.1.1.1", probe)
+	setUpService(t, map[string]string{"1.1.1.1": "pod1"})
+
+	g.Eventually(func() bool {
+		poolNode := getPoolNode()
+		return poolNode != nil && poolNode.ProbeHealthMonitor != nil
+	}, 10*time.Second).Should(gomega.Equal(true))
+	hmNode := getPoolNode().ProbeHealthMonitor
+	g.Expect(hmNode.Type).To(gomega.Equal(lib.HealthMonitorTypeTCP))
+	g.Expect(hmNode.HTTPRequest).To(gomega.Equal(""))
+	// The probe port is not the pool port, so the pods are probed on the monitor port.
+	g.Expect(hmNode.MonitorPort).To(gomega.Equal(int32(9090)))
+	// The defaults of the probe are used for the fields which are not set.
+	g.Expect(hmNode.SendInterval).To(gomega.Equal(int32(10)))
+	g.Expect(hmNode.ReceiveTimeout).To(gomega.Equal(int32(1)))
+	g.Expect(hmNode.SuccessfulChecks).To(gomega.Equal(int32(1)))
+	g.Expect(hmNode.FailedChecks).To(gomega.Equal(int32(3)))
+
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: poolName}
+	g.Eventually(func() string {
+		return getPoolHealthMonitorCollection(poolKey)
+	}, 15*time.Second).Should(gomega.Equal(poolName))
+
+	tearDownService(t, g, "pod1")
+}
+
+func TestProbeHealthMonitorUnsupportedProbe(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/healthy"}},
+		},
+	}
+	createPod(t, "pod1", "1.1.1.1", probe)
+	setUpService(t, map[string]string{"1.1.1.1": "pod1"})
+
+	// The pool keeps the system health monitor for the exec probes.
+	poolKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: poolName}
+	g.Eventually(func() bool {
+		_, found := cache.SharedAviObjCache().PoolCache.AviCacheGet(poolKey)
+		return found
+	}, 15*time.Second).Should(gomega.Equal(true))
+	g.Expect(getPoolNode().ProbeHealthMonitor).To(gomega.BeNil())
+	g.Expect(getPoolHealthMonitorCollection(poolKey)).To(gomega.Equal(""))
+
+	tearDownService(t, g, "pod1")
+}
@@ -0,0 +1,322 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package runtimestatustests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/status"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+
+// vsState, vsReasons and serverStates are the runtime of the virtual services and the runtime states of the
+// pool servers by their IP, returned for the inventory requests.
+var vsState string
+var vsReasons []string
+var serverStates map[string]string
+var runtimeLock sync.RWMutex
+
+func TestMain(m *testing.M) {
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+	os.Setenv("ENABLE_RUNTIME_STATUS", "true")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	ctrl.SetSEGroupCloudName()
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+// runtimeControllerServer returns the virtual services in the cache with the runtime from vsState, and the pools
+// in the cache with the server counts from serverStates, for the inventory requests. The other requests are handed
+// over to the normal controller server.
+func runtimeControllerServer(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.EscapedPath()
+	if r.Method != "GET" || !strings.Contains(url, "-inventory") {
+		integrationtest.NormalControllerServer(w, r)
+		return
+	}
+
+	runtimeLock.RLock()
+	mcache := cache.SharedAviObjCache()
+	results := []interface{}{}
+	if strings.Contains(url, "/api/pool-inventory") {
+		var serversUp int
+		for _, state := range serverStates {
+			if state == "OPER_UP" {
+				serversUp++
+			}
+		}
+		for _, poolKey := range mcache.PoolCache.AviGetAllKeys() {
+			poolIntf, _ := mcache.PoolCache.AviCacheGet(poolKey)
+			if poolCache, ok := poolIntf.(*cache.AviPoolCache); ok {
+				results = append(results, map[string]interface{}{
+					"config":  map[string]string{"name": poolCache.Name, "uuid": poolCache.Uuid},
+					"runtime": map[string]int{"num_servers": len(serverStates), "num_servers_up": serversUp},
+				})
+			}
+		}
+	} else {
+		for _, vsKey := range mcache.VsCacheMeta.AviGetAllKeys() {
+			vsIntf, _ := mcache.VsCacheMeta.AviCacheGet(vsKey)
+			if vsCache, ok := vsIntf.(*cache.AviVsCache); ok {
+				results = append(results, map[string]interface{}{
+					"config": map[string]string{"name": vsCache.Name, "uuid": vsCache.Uuid},
+					"runtime": map[string]interface{}{
+						"oper_status": map[string]interface{}{
+							"state":  vsState,
+							"reason": vsReasons,
+						},
+					},
+				})
+			}
+		}
+	}
+	runtimeLock.RUnlock()
+	finalResponse, _ := json.Marshal(map[string]interface{}{
+		"count":   len(results),
+		"results": results,
+	})
+	w.WriteHeader(http.StatusOK)
+	w.Write(finalResponse)
+}
+
+func setRuntime(state string, reasons []string, states map[string]string) {
+	runtimeLock.Lock()
+	vsState, vsReasons, serverStates = state, reasons, states
+	runtimeLock.Unlock()
+}
+
+func getConditionReason(conditions []metav1.Condition, conditionType string) string {
+	if condition := meta.FindStatusCondition(conditions, conditionType); condition != nil {
+		return string(condition.Status) + "/" + condition.Reason
+	}
+	return ""
+}
+
+// getServiceConditions syncs the runtime statuses and returns the conditions of the service.
+func getServiceConditions(name string) []metav1.Condition {
+	status.SyncRuntimeStatuses()
+	svc, err := KubeClient.CoreV1().Services(integrationtest.NAMESPACE).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	return svc.Status.Conditions
+}
+
+// getIngressConditions syncs the runtime statuses and returns the conditions in the annotation of the ingress.
+func getIngressConditions(name string) []metav1.Condition {
+	status.SyncRuntimeStatuses()
+	ingress, err := KubeClient.NetworkingV1().Ingresses("default").Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	var conditions []metav1.Condition
+	json.Unmarshal([]byte(ingress.Annotations[lib.RuntimeConditionsAnnotation]), &conditions)
+	return conditions
+}
+
+func TestServiceRuntimeConditions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.AddMiddleware(runtimeControllerServer)
+	defer integrationtest.ResetMiddleware()
+	setRuntime("OPER_UP", nil, map[string]string{"1.1.1.1": "OPER_UP", "1.1.1.2": "OPER_UP", "1.1.1.3": "OPER_UP"})
+
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	integrationtest.CreateEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, false, true, "1.1.1")
+
+	mcache := cache.SharedAviObjCache()
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc"}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(true))
+
+	// The conditions are true while the virtual service and all the pool servers are up.
+	g.Eventually(func() string {
+		return getConditionReason(getServiceConditions(integrationtest.SINGLEPORTSVC), lib.VirtualServiceReadyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("True/VirtualServiceUp"))
+	g.Eventually(func() string {
+		return getConditionReason(getServiceConditions(integrationtest.SINGLEPORTSVC), lib.BackendsHealthyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("True/BackendsUp"))
+	conditions := getServiceConditions(integrationtest.SINGLEPORTSVC)
+	g.Expect(meta.FindStatusCondition(conditions, lib.BackendsHealthyConditionType).Message).To(gomega.Equal("All the pool servers are up"))
+	lastTransitionTime := meta.FindStatusCondition(conditions, lib.BackendsHealthyConditionType).LastTransitionTime
+
+	// The backends are degraded if some of the servers of the pool are down.
+	setRuntime("OPER_UP", nil, map[string]string{"1.1.1.1": "OPER_UP", "1.1.1.2": "OPER_DOWN", "1.1.1.3": "OPER_UP"})
+	g.Eventually(func() string {
+		return getConditionReason(getServiceConditions(integrationtest.SINGLEPORTSVC), lib.BackendsHealthyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("True/BackendsDegraded"))
+	condition := meta.FindStatusCondition(getServiceConditions(integrationtest.SINGLEPORTSVC), lib.BackendsHealthyConditionType)
+	g.Expect(condition.Message).To(gomega.Equal("Servers are down in the pools cluster--red-ns-testsvc-TCP-8080"))
+	// The last transition time is retained as the status of the condition has not changed.
+	g.Expect(condition.LastTransitionTime).To(gomega.Equal(lastTransitionTime))
+
+	// The conditions are false if the virtual service could not be placed and all the servers are down.
+	setRuntime("OPER_RESOURCES", []string{"No Service Engine available"}, map[string]string{"1.1.1.1": "OPER_DOWN", "1.1.1.2": "OPER_DOWN", "1.1.1.3": "OPER_DOWN"})
+	g.Eventually(func() string {
+		return getConditionReason(getServiceConditions(integrationtest.SINGLEPORTSVC), lib.VirtualServiceReadyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("False/ServiceEnginePlacementFailed"))
+	g.Eventually(func() string {
+		return getConditionReason(getServiceConditions(integrationtest.SINGLEPORTSVC), lib.BackendsHealthyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("False/BackendsDown"))
+	conditions = getServiceConditions(integrationtest.SINGLEPORTSVC)
+	g.Expect(meta.FindStatusCondition(conditions, lib.VirtualServiceReadyConditionType).Message).To(gomega.Equal("Virtual service cluster--red-ns-testsvc is OPER_RESOURCES: No Service Engine available"))
+	g.Expect(meta.FindStatusCondition(conditions, lib.BackendsHealthyConditionType).Message).To(gomega.Equal("No servers are up in the pools cluster--red-ns-testsvc-TCP-8080"))
+
+	// The load balancer status of the service is retained along with the conditions.
+	svc, _ := KubeClient.CoreV1().Services(integrationtest.NAMESPACE).Get(context.TODO(), integrationtest.SINGLEPORTSVC, metav1.GetOptions{})
+	g.Expect(svc.Status.LoadBalancer.Ingress).To(gomega.HaveLen(1))
+
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	integrationtest.DelEP(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+}
+
+func TestIngressRuntimeConditions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.AddMiddleware(runtimeControllerServer)
+	defer integrationtest.ResetMiddleware()
+	setRuntime("OPER_UP", nil, map[string]string{"1.1.1.1": "OPER_UP"})
+
+	integrationtest.CreateSVC(t, "default", "avisvc", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "default", "avisvc", false, false, "1.1.1")
+	ingrFake := (integrationtest.FakeIngress{
+		Name:        "foo-with-targets",
+		Namespace:   "default",
+		DnsNames:    []string{"foo.com"},
+		Ips:         []string{"8.8.8.8"},
+		HostNames:   []string{"v1"},
+		Paths:       []string{"/foo"},
+		ServiceName: "avisvc",
+	}).Ingress()
+	if _, err := KubeClient.NetworkingV1().Ingresses("default").Create(context.TODO(), ingrFake, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Ingress: %v", err)
+	}
+
+	// The conditions of the ingress are set in its annotation.
+	g.Eventually(func() string {
+		return getConditionReason(getIngressConditions("foo-with-targets"), lib.VirtualServiceReadyConditionType)
+	}, 20*time.Second).Should(gomega.Equal("True/VirtualServiceUp"))
+	g.Eventually(func() string {
+		return getConditionReason(getIngressConditions("foo-with-targets"), lib.BackendsHealthyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("True/BackendsUp"))
+
+	setRuntime("OPER_DOWN", nil, map[string]string{"1.1.1.1": "OPER_DOWN"})
+	g.Eventually(func() string {
+		return getConditionReason(getIngressConditions("foo-with-targets"), lib.VirtualServiceReadyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("False/VirtualServiceDown"))
+	g.Eventually(func() string {
+		return getConditionReason(getIngressConditions("foo-with-targets"), lib.BackendsHealthyConditionType)
+	}, 15*time.Second).Should(gomega.Equal("False/BackendsDown"))
+
+	// The status of the ingress is not updated with the runtime status.
+	ingress, _ := KubeClient.NetworkingV1().Ingresses("default").Get(context.TODO(), "foo-with-targets", metav1.GetOptions{})
+	g.Expect(ingress.Status.LoadBalancer.Ingress).To(gomega.HaveLen(1))
+
+	if err := KubeClient.NetworkingV1().Ingresses("default").Delete(context.TODO(), "foo-with-targets", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Couldn't DELETE the Ingress %v", err)
+	}
+	integrationtest.DelSVC(t, "default", "avisvc")
+	integrationtest.DelEP(t, "default", "avisvc")
+}
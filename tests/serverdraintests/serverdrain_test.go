@@ -0,0 +1,263 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package serverdraintests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+
+const drainTimeout = "3"
+
+// poolRequest is the last pool POST or PUT request, with the servers of the later PATCH requests applied,
+// to verify the servers disabled in the pool.
+var poolRequest map[string]interface{}
+var poolRequestLock sync.RWMutex
+
+func TestMain(m *testing.M) {
+	os.Setenv("ENABLE_ENDPOINTSLICE", "true")
+	os.Setenv("SERVER_DRAIN_TIMEOUT", drainTimeout)
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointSlicesInformer,
+		utils.PodInformer,
+		utils.IngressInformer,
+		utils.IngressClassInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	integrationtest.StartClusterIPController(registeredInformers)
+	KubeClient = integrationtest.KubeClient
+	os.Exit(m.Run())
+}
+
+// drainControllerServer records the pool POST and PUT requests, applies the servers of the pool PATCH requests
+// to the recorded request, and hands over all the requests to the normal controller server.
+func drainControllerServer(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.EscapedPath()
+	if (r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH") && strings.Contains(url, "/api/pool") {
+		var req map[string]interface{}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &req)
+		poolRequestLock.Lock()
+		if r.Method == "PATCH" {
+			applyPoolPatch(req)
+		} else {
+			poolRequest = req
+		}
+		poolRequestLock.Unlock()
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+	integrationtest.NormalControllerServer(w, r)
+}
+
+// applyPoolPatch adds or deletes the servers in the pool PATCH request to the servers of the recorded pool request.
+func applyPoolPatch(req map[string]interface{}) {
+	if poolRequest == nil {
+		return
+	}
+	for op, payload := range req {
+		patch, _ := payload.(map[string]interface{})
+		patchServers, _ := patch["servers"].([]interface{})
+		servers, _ := poolRequest["servers"].([]interface{})
+		for _, patchServer := range patchServers {
+			addr := patchServer.(map[string]interface{})["ip"].(map[string]interface{})["addr"]
+			for i, server := range servers {
+				if server.(map[string]interface{})["ip"].(map[string]interface{})["addr"] == addr {
+					servers = append(servers[:i], servers[i+1:]...)
+					break
+				}
+			}
+			if op == utils.PatchAddOp {
+				servers = append(servers, patchServer)
+			}
+		}
+		poolRequest["servers"] = servers
+	}
+}
+
+// getPoolRequestServers returns the enabled state of the servers of the last pool request.
+func getPoolRequestServers() map[string]bool {
+	poolRequestLock.RLock()
+	defer poolRequestLock.RUnlock()
+	if poolRequest == nil {
+		return nil
+	}
+	servers := make(map[string]bool)
+	reqServers, _ := poolRequest["servers"].([]interface{})
+	for _, reqServer := range reqServers {
+		server := reqServer.(map[string]interface{})
+		enabled := true
+		if serverEnabled, ok := server["enabled"].(bool); ok {
+			enabled = serverEnabled
+		}
+		servers[server["ip"].(map[string]interface{})["addr"].(string)] = enabled
+	}
+	return servers
+}
+
+func updateEndpointSlice(t *testing.T, endpoints []integrationtest.FakeEndpoint, resourceVersion string) {
+	epSlice := integrationtest.BuildEndpointSlice(endpoints)
+	epSlice.ResourceVersion = resourceVersion
+	if _, err := KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Update(context.TODO(), epSlice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating EndpointSlice: %v", err)
+	}
+}
+
+// setUpDrainService creates a service with two ready endpoints, with the pods pod1 and pod2.
+func setUpDrainService(t *testing.T, g *gomega.WithT) {
+	poolRequestLock.Lock()
+	poolRequest = nil
+	poolRequestLock.Unlock()
+	objects.SharedAviGraphLister().Delete(integrationtest.SINGLEPORTMODEL)
+
+	integrationtest.CreatePod(t, integrationtest.FakePod{Name: "pod1", IP: "1.1.1.1"})
+	integrationtest.CreatePod(t, integrationtest.FakePod{Name: "pod2", IP: "1.1.1.2"})
+	integrationtest.CreateSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC, corev1.ServiceTypeLoadBalancer, false)
+	epSlice := integrationtest.BuildEndpointSlice([]integrationtest.FakeEndpoint{{IP: "1.1.1.1", Pod: "pod1"}, {IP: "1.1.1.2", Pod: "pod2"}})
+	if _, err := KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Create(context.TODO(), epSlice, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in creating EndpointSlice: %v", err)
+	}
+	g.Eventually(getPoolServersDraining, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false, "1.1.1.2": false}))
+}
+
+func tearDownDrainService(t *testing.T, g *gomega.WithT) {
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	KubeClient.DiscoveryV1().EndpointSlices(integrationtest.NAMESPACE).Delete(context.TODO(), integrationtest.EPSLICENAME, metav1.DeleteOptions{})
+	KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Delete(context.TODO(), "pod1", metav1.DeleteOptions{})
+	KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Delete(context.TODO(), "pod2", metav1.DeleteOptions{})
+	mcache := cache.SharedAviObjCache()
+	vsKey := cache.NamespaceName{Namespace: integrationtest.AVINAMESPACE, Name: "cluster--red-ns-testsvc"}
+	g.Eventually(func() bool {
+		_, found := mcache.VsCacheMeta.AviCacheGet(vsKey)
+		return found
+	}, 10*time.Second).Should(gomega.Equal(false))
+}
+
+// getPoolServersDraining returns the draining state of the servers of the pool in the model.
+func getPoolServersDraining() map[string]bool {
+	found, aviModel := objects.SharedAviGraphLister().Get(integrationtest.SINGLEPORTMODEL)
+	if !found || aviModel == nil {
+		return nil
+	}
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	if len(nodes) != 1 || len(nodes[0].PoolRefs) != 1 {
+		return nil
+	}
+	servers := make(map[string]bool)
+	for _, server := range nodes[0].PoolRefs[0].Servers {
+		servers[*server.Ip.Addr] = server.Draining
+	}
+	return servers
+}
+
+func TestDrainTerminatingEndpoint(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	integrationtest.AddMiddleware(drainControllerServer)
+	defer integrationtest.ResetMiddleware()
+
+	setUpDrainService(t, g)
+
+	// The server of the terminating endpoint is disabled in the pool.
+	updateEndpointSlice(t, []integrationtest.FakeEndpoint{{IP: "1.1.1.1", Pod: "pod1"}, {IP: "1.1.1.2", Pod: "pod2", NotReady: true, Terminating: true}}, "2")
+	g.Eventually(getPoolServersDraining, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false, "1.1.1.2": true}))
+	g.Eventually(getPoolRequestServers, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": true, "1.1.1.2": false}))
+	poolRequestLock.RLock()
+	g.Expect(poolRequest["graceful_disable_timeout"]).To(gomega.BeEquivalentTo(1))
+	poolRequestLock.RUnlock()
+
+	// The server is removed from the pool once the drain timeout expires.
+	g.Eventually(getPoolServersDraining, 15*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false}))
+	g.Eventually(getPoolRequestServers, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": true}))
+
+	tearDownDrainService(t, g)
+}
+
+func TestDrainRemovedEndpointPodDeleted(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	os.Setenv("SERVER_DRAIN_TIMEOUT", "300")
+	defer os.Setenv("SERVER_DRAIN_TIMEOUT", drainTimeout)
+
+	setUpDrainService(t, g)
+
+	// The server of the removed endpoint is drained, as its pod still exists.
+	updateEndpointSlice(t, []integrationtest.FakeEndpoint{{IP: "1.1.1.1", Pod: "pod1"}}, "2")
+	g.Eventually(getPoolServersDraining, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false, "1.1.1.2": true}))
+	g.Consistently(getPoolServersDraining, 2*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false, "1.1.1.2": true}))
+
+	// The server is removed from the pool before the drain timeout, once its pod is deleted.
+	KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Delete(context.TODO(), "pod2", metav1.DeleteOptions{})
+	g.Eventually(getPoolServersDraining, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false}))
+
+	tearDownDrainService(t, g)
+}
+
+func TestRemovedEndpointWithoutPod(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	setUpDrainService(t, g)
+
+	// The server of a removed endpoint whose pod is already deleted is removed right away.
+	KubeClient.CoreV1().Pods(integrationtest.NAMESPACE).Delete(context.TODO(), "pod2", metav1.DeleteOptions{})
+	time.Sleep(time.Second)
+	updateEndpointSlice(t, []integrationtest.FakeEndpoint{{IP: "1.1.1.1", Pod: "pod1"}}, "2")
+	g.Eventually(getPoolServersDraining, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false}))
+
+	tearDownDrainService(t, g)
+}
+
+func TestDrainServersRemovedOnPoolDelete(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	os.Setenv("SERVER_DRAIN_TIMEOUT", "300")
+	defer os.Setenv("SERVER_DRAIN_TIMEOUT", drainTimeout)
+
+	setUpDrainService(t, g)
+
+	updateEndpointSlice(t, []integrationtest.FakeEndpoint{{IP: "1.1.1.1", Pod: "pod1"}}, "2")
+	g.Eventually(getPoolServersDraining, 10*time.Second).Should(gomega.Equal(map[string]bool{"1.1.1.1": false, "1.1.1.2": true}))
+	g.Expect(avinodes.GetDrainingPodServices(integrationtest.NAMESPACE + "/pod2")).To(gomega.HaveLen(1))
+
+	// The servers being drained are forgotten once the pool is deleted, while the pod still exists.
+	integrationtest.DelSVC(t, integrationtest.NAMESPACE, integrationtest.SINGLEPORTSVC)
+	g.Eventually(func() []string {
+		return avinodes.GetDrainingPodServices(integrationtest.NAMESPACE + "/pod2")
+	}, 10*time.Second).Should(gomega.BeEmpty())
+
+	tearDownDrainService(t, g)
+}
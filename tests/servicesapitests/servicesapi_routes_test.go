@@ -0,0 +1,566 @@
+/*
+ * Copyright 2020-2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package servicesapitests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	avinodes "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/nodes"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/objects"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	servicesapi "sigs.k8s.io/service-apis/apis/v1alpha1"
+)
+
+func SetupRouteGateway(t *testing.T, gwname, namespace, gwclass string, listeners []servicesapi.Listener) {
+	gateway := &servicesapi.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      gwname,
+		},
+		Spec: servicesapi.GatewaySpec{
+			GatewayClassName: gwclass,
+			Listeners:        listeners,
+		},
+	}
+	if _, err := SvcAPIClient.NetworkingV1alpha1().Gateways(namespace).Create(context.TODO(), gateway, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Gateway: %v", err)
+	}
+}
+
+func SetupHTTPRoute(t *testing.T, name, namespace string, hostnames []string, rules []servicesapi.HTTPRouteRule) {
+	route := &servicesapi.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: servicesapi.HTTPRouteSpec{
+			Rules: rules,
+		},
+	}
+	for _, hostname := range hostnames {
+		route.Spec.Hostnames = append(route.Spec.Hostnames, servicesapi.Hostname(hostname))
+	}
+	if _, err := SvcAPIClient.NetworkingV1alpha1().HTTPRoutes(namespace).Create(context.TODO(), route, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding HTTPRoute: %v", err)
+	}
+}
+
+func TeardownHTTPRoute(t *testing.T, name, namespace string) {
+	if err := SvcAPIClient.NetworkingV1alpha1().HTTPRoutes(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting HTTPRoute: %v", err)
+	}
+}
+
+func HTTPRouteForwardTo(svcName string, port, weight int32) servicesapi.HTTPRouteForwardTo {
+	return servicesapi.HTTPRouteForwardTo{
+		ServiceName: &svcName,
+		Port:        servicesapi.PortNumber(port),
+		Weight:      weight,
+	}
+}
+
+func getRouteAdmittedStatus(namespace, name, gwNamespace, gwName string) metav1.ConditionStatus {
+	route, err := SvcAPIClient.NetworkingV1alpha1().HTTPRoutes(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	for _, gwStatus := range route.Status.Gateways {
+		if gwStatus.GatewayRef.Name == gwName && gwStatus.GatewayRef.Namespace == gwNamespace {
+			for _, condition := range gwStatus.Conditions {
+				if condition.Type == string(servicesapi.ConditionRouteAdmitted) {
+					return condition.Status
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func getSvcApiL7Model(modelName string) *avinodes.AviVsNode {
+	found, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	if !found || aviModel == nil {
+		return nil
+	}
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[0]
+}
+
+func TestServicesAPIHTTPRoute(t *testing.T) {
+	// create gwclass, gw with an HTTP listener, 2 svcs and an httproute
+	// check the L7 VS, http policy, poolgroup weights and route status
+	g := gomega.NewGomegaWithT(t)
+
+	gwClassName, gatewayName, ns := "avi-lb", "my-http-gateway", "default"
+	modelName := "admin/" + lib.GetSvcApiL7VSName(gatewayName, ns)
+
+	SetupGatewayClass(t, gwClassName, lib.SvcApiAviGatewayController, "")
+	SetupRouteGateway(t, gatewayName, ns, gwClassName, []servicesapi.Listener{{
+		Port:     80,
+		Protocol: servicesapi.HTTPProtocolType,
+		Routes:   servicesapi.RouteBindingSelector{Kind: lib.HTTPRoute},
+	}})
+	integrationtest.CreateSVC(t, ns, "avisvc1", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, "avisvc1", false, false, "1.1.1")
+	integrationtest.CreateSVC(t, ns, "avisvc2", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, "avisvc2", false, false, "2.2.2")
+
+	SetupHTTPRoute(t, "foo-route", ns, []string{"foo.com"}, []servicesapi.HTTPRouteRule{{
+		Matches: []servicesapi.HTTPRouteMatch{{
+			Path: servicesapi.HTTPPathMatch{Type: servicesapi.PathMatchPrefix, Value: "/foo"},
+			Headers: &servicesapi.HTTPHeaderMatch{
+				Type:   servicesapi.HeaderMatchExact,
+				Values: map[string]string{"version": "v1"},
+			},
+		}},
+		Filters: []servicesapi.HTTPRouteFilter{{
+			Type: servicesapi.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: &servicesapi.HTTPRequestHeaderFilter{
+				Add:    map[string]string{"x-gateway": "avi"},
+				Remove: []string{"x-debug"},
+			},
+		}},
+		ForwardTo: []servicesapi.HTTPRouteForwardTo{
+			HTTPRouteForwardTo("avisvc1", 8080, 1),
+			HTTPRouteForwardTo("avisvc2", 8080, 3),
+		},
+	}})
+
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil && len(vsNode.HttpPolicyRefs) > 0 {
+			return len(vsNode.HttpPolicyRefs[0].HppMap)
+		}
+		return 0
+	}, 40*time.Second).Should(gomega.Equal(1))
+
+	vsNode := getSvcApiL7Model(modelName)
+	g.Expect(vsNode.SNIParent).To(gomega.BeTrue())
+	g.Expect(vsNode.ServiceMetadata.Gateway).To(gomega.Equal("default/my-http-gateway"))
+	g.Expect(vsNode.PortProto).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PortProto[0].Port).To(gomega.Equal(int32(80)))
+	g.Expect(vsNode.VSVIPRefs[0].FQDNs).To(gomega.ContainElement("foo.com"))
+	g.Expect(vsNode.PoolRefs).To(gomega.HaveLen(2))
+	g.Expect(vsNode.PoolRefs[0].Servers).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PoolGroupRefs).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PoolGroupRefs[0].Members).To(gomega.HaveLen(2))
+	g.Expect(*vsNode.PoolGroupRefs[0].Members[0].Ratio).To(gomega.Equal(int32(1)))
+	g.Expect(*vsNode.PoolGroupRefs[0].Members[1].Ratio).To(gomega.Equal(int32(3)))
+
+	// the objects built for the httproute carry the markers of the httproute, and not of the gateway
+	g.Expect(vsNode.AviMarkers.GatewayName).To(gomega.Equal(gatewayName))
+	g.Expect(vsNode.HttpPolicyRefs[0].AviMarkers.Namespace).To(gomega.Equal(ns))
+	g.Expect(vsNode.HttpPolicyRefs[0].AviMarkers.HTTPRouteName).To(gomega.Equal("foo-route"))
+	g.Expect(vsNode.HttpPolicyRefs[0].AviMarkers.GatewayName).To(gomega.BeEmpty())
+	g.Expect(vsNode.PoolGroupRefs[0].AviMarkers.HTTPRouteName).To(gomega.Equal("foo-route"))
+	g.Expect(vsNode.PoolGroupRefs[0].AviMarkers.GatewayName).To(gomega.BeEmpty())
+	for _, pool := range vsNode.PoolRefs {
+		g.Expect(pool.AviMarkers.HTTPRouteName).To(gomega.Equal("foo-route"))
+		g.Expect(pool.AviMarkers.GatewayName).To(gomega.BeEmpty())
+		g.Expect(pool.AviMarkers.Port).To(gomega.Equal("8080"))
+	}
+	g.Expect(vsNode.PoolRefs[0].AviMarkers.ServiceName).To(gomega.Equal("avisvc1"))
+
+	hppMap := vsNode.HttpPolicyRefs[0].HppMap[0]
+	g.Expect(hppMap.Path).To(gomega.Equal([]string{"/foo"}))
+	g.Expect(hppMap.MatchCriteria).To(gomega.Equal("BEGINS_WITH"))
+	g.Expect(hppMap.Port).To(gomega.Equal(uint32(80)))
+	g.Expect(hppMap.HostHeader.Values).To(gomega.Equal([]string{"foo.com"}))
+	g.Expect(hppMap.Headers).To(gomega.HaveLen(1))
+	g.Expect(hppMap.Headers[0].Name).To(gomega.Equal("version"))
+	g.Expect(hppMap.PoolGroup).To(gomega.Equal(vsNode.PoolGroupRefs[0].Name))
+	g.Expect(hppMap.RequestHeaders).To(gomega.HaveLen(2))
+	g.Expect(hppMap.RequestHeaders[0].Action).To(gomega.Equal("HTTP_ADD_HDR"))
+	g.Expect(hppMap.RequestHeaders[1].Action).To(gomega.Equal("HTTP_REMOVE_HDR"))
+
+	g.Eventually(func() metav1.ConditionStatus {
+		return getRouteAdmittedStatus(ns, "foo-route", ns, gatewayName)
+	}, 40*time.Second).Should(gomega.Equal(metav1.ConditionTrue))
+
+	g.Eventually(func() int {
+		gw, _ := SvcAPIClient.NetworkingV1alpha1().Gateways(ns).Get(context.TODO(), gatewayName, metav1.GetOptions{})
+		return len(gw.Status.Addresses)
+	}, 40*time.Second).Should(gomega.Equal(1))
+
+	// endpoint updates of the backend services are reflected in the pools.
+	integrationtest.ScaleCreateEP(t, ns, "avisvc1")
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil && len(vsNode.PoolRefs) > 0 {
+			return len(vsNode.PoolRefs[0].Servers)
+		}
+		return 0
+	}, 40*time.Second).Should(gomega.Equal(2))
+
+	TeardownHTTPRoute(t, "foo-route", ns)
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil {
+			return len(vsNode.HttpPolicyRefs)
+		}
+		return -1
+	}, 40*time.Second).Should(gomega.Equal(0))
+
+	TeardownGateway(t, gatewayName, ns)
+	VerifyGatewayVSNodeDeletion(g, modelName)
+	TeardownGatewayClass(t, gwClassName)
+	integrationtest.DelSVC(t, ns, "avisvc1")
+	integrationtest.DelEP(t, ns, "avisvc1")
+	integrationtest.DelSVC(t, ns, "avisvc2")
+	integrationtest.DelEP(t, ns, "avisvc2")
+}
+
+func TestServicesAPIHTTPRouteStatusAfterSync(t *testing.T) {
+	// the route is reported as admitted only after the gateway VS is created in the controller.
+	g := gomega.NewGomegaWithT(t)
+
+	gwClassName, gatewayName, ns := "avi-lb", "my-http-gateway", "default"
+	modelName := "admin/" + lib.GetSvcApiL7VSName(gatewayName, ns)
+
+	var failVS int32 = 1
+	integrationtest.AddMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && strings.Contains(r.URL.EscapedPath(), "virtualservice") && atomic.LoadInt32(&failVS) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "bad request"}`))
+			return
+		}
+		integrationtest.NormalControllerServer(w, r)
+	})
+	defer integrationtest.ResetMiddleware()
+
+	SetupGatewayClass(t, gwClassName, lib.SvcApiAviGatewayController, "")
+	SetupRouteGateway(t, gatewayName, ns, gwClassName, []servicesapi.Listener{{
+		Port:     80,
+		Protocol: servicesapi.HTTPProtocolType,
+		Routes:   servicesapi.RouteBindingSelector{Kind: lib.HTTPRoute},
+	}})
+	integrationtest.CreateSVC(t, ns, "avisvc1", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, "avisvc1", false, false, "1.1.1")
+	SetupHTTPRoute(t, "foo-route", ns, []string{"foo.com"}, []servicesapi.HTTPRouteRule{{
+		ForwardTo: []servicesapi.HTTPRouteForwardTo{HTTPRouteForwardTo("avisvc1", 8080, 0)},
+	}})
+
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil {
+			return len(vsNode.HttpPolicyRefs)
+		}
+		return 0
+	}, 40*time.Second).Should(gomega.Equal(1))
+	g.Consistently(func() metav1.ConditionStatus {
+		return getRouteAdmittedStatus(ns, "foo-route", ns, gatewayName)
+	}, 5*time.Second).Should(gomega.BeEmpty())
+
+	// the route is admitted once the gateway VS is synced with the next update.
+	atomic.StoreInt32(&failVS, 0)
+	integrationtest.ScaleCreateEP(t, ns, "avisvc1")
+	g.Eventually(func() metav1.ConditionStatus {
+		return getRouteAdmittedStatus(ns, "foo-route", ns, gatewayName)
+	}, 40*time.Second).Should(gomega.Equal(metav1.ConditionTrue))
+
+	// the gateway is removed from the route status once the route is no longer admitted.
+	TeardownGateway(t, gatewayName, ns)
+	VerifyGatewayVSNodeDeletion(g, modelName)
+	g.Eventually(func() metav1.ConditionStatus {
+		return getRouteAdmittedStatus(ns, "foo-route", ns, gatewayName)
+	}, 40*time.Second).Should(gomega.BeEmpty())
+
+	TeardownHTTPRoute(t, "foo-route", ns)
+	TeardownGatewayClass(t, gwClassName)
+	integrationtest.DelSVC(t, ns, "avisvc1")
+	integrationtest.DelEP(t, ns, "avisvc1")
+}
+
+func TestServicesAPIHTTPRouteStatusMultipleGateways(t *testing.T) {
+	// the status of a route admitted by two gateways is not updated again on the syncs of the gateways,
+	// as the order of the gateways in the status does not change.
+	g := gomega.NewGomegaWithT(t)
+
+	gwClassName, ns := "avi-lb", "default"
+	gatewayNames := []string{"my-http-gateway", "my-http-gateway2"}
+	var statusPatches, countPatches int32
+	SvcAPIClient.PrependReactor("patch", "httproutes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "status" && atomic.LoadInt32(&countPatches) == 1 {
+			atomic.AddInt32(&statusPatches, 1)
+		}
+		return false, nil, nil
+	})
+
+	SetupGatewayClass(t, gwClassName, lib.SvcApiAviGatewayController, "")
+	for _, gatewayName := range gatewayNames {
+		SetupRouteGateway(t, gatewayName, ns, gwClassName, []servicesapi.Listener{{
+			Port:     80,
+			Protocol: servicesapi.HTTPProtocolType,
+			Routes:   servicesapi.RouteBindingSelector{Kind: lib.HTTPRoute},
+		}})
+	}
+	integrationtest.CreateSVC(t, ns, "avisvc1", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, "avisvc1", false, false, "1.1.1")
+	SetupHTTPRoute(t, "foo-route", ns, []string{"foo.com"}, []servicesapi.HTTPRouteRule{{
+		ForwardTo: []servicesapi.HTTPRouteForwardTo{HTTPRouteForwardTo("avisvc1", 8080, 0)},
+	}})
+
+	for _, gatewayName := range gatewayNames {
+		g.Eventually(func() metav1.ConditionStatus {
+			return getRouteAdmittedStatus(ns, "foo-route", ns, gatewayName)
+		}, 40*time.Second).Should(gomega.Equal(metav1.ConditionTrue))
+	}
+	getGatewaysInStatus := func() []string {
+		route, _ := SvcAPIClient.NetworkingV1alpha1().HTTPRoutes(ns).Get(context.TODO(), "foo-route", metav1.GetOptions{})
+		var gateways []string
+		for _, gwStatus := range route.Status.Gateways {
+			gateways = append(gateways, gwStatus.GatewayRef.Name)
+		}
+		return gateways
+	}
+	gateways := getGatewaysInStatus()
+	g.Expect(gateways).To(gomega.HaveLen(2))
+
+	// both the gateways are synced with the update of the endpoints of the service.
+	atomic.StoreInt32(&countPatches, 1)
+	integrationtest.ScaleCreateEP(t, ns, "avisvc1")
+	for _, gatewayName := range gatewayNames {
+		modelName := "admin/" + lib.GetSvcApiL7VSName(gatewayName, ns)
+		g.Eventually(func() int {
+			if vsNode := getSvcApiL7Model(modelName); vsNode != nil && len(vsNode.PoolRefs) > 0 {
+				return len(vsNode.PoolRefs[0].Servers)
+			}
+			return 0
+		}, 40*time.Second).Should(gomega.Equal(2))
+	}
+	g.Consistently(func() int32 {
+		return atomic.LoadInt32(&statusPatches)
+	}, 5*time.Second).Should(gomega.Equal(int32(0)))
+	g.Expect(getGatewaysInStatus()).To(gomega.Equal(gateways))
+	atomic.StoreInt32(&countPatches, 0)
+
+	TeardownHTTPRoute(t, "foo-route", ns)
+	for _, gatewayName := range gatewayNames {
+		TeardownGateway(t, gatewayName, ns)
+		VerifyGatewayVSNodeDeletion(g, "admin/"+lib.GetSvcApiL7VSName(gatewayName, ns))
+	}
+	TeardownGatewayClass(t, gwClassName)
+	integrationtest.DelSVC(t, ns, "avisvc1")
+	integrationtest.DelEP(t, ns, "avisvc1")
+}
+
+func TestServicesAPIHTTPSRoute(t *testing.T) {
+	// create gw with an HTTPS listener referring to a secret, and an httproute
+	// check the SNI child with the certificate and the route rules
+	g := gomega.NewGomegaWithT(t)
+
+	gwClassName, gatewayName, ns := "avi-lb", "my-https-gateway", "default"
+	modelName := "admin/" + lib.GetSvcApiL7VSName(gatewayName, ns)
+
+	integrationtest.AddSecret("gw-cert", ns, "tlsCert", "tlsKey")
+	SetupGatewayClass(t, gwClassName, lib.SvcApiAviGatewayController, "")
+	SetupRouteGateway(t, gatewayName, ns, gwClassName, []servicesapi.Listener{{
+		Port:     443,
+		Protocol: servicesapi.HTTPSProtocolType,
+		TLS: &servicesapi.GatewayTLSConfig{
+			Mode:           servicesapi.TLSModeTerminate,
+			CertificateRef: servicesapi.LocalObjectReference{Kind: "Secret", Name: "gw-cert"},
+		},
+		Routes: servicesapi.RouteBindingSelector{Kind: lib.HTTPRoute},
+	}})
+	integrationtest.CreateSVC(t, ns, "avisvc1", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, "avisvc1", false, false, "1.1.1")
+
+	SetupHTTPRoute(t, "secure-route", ns, []string{"secure.com"}, []servicesapi.HTTPRouteRule{{
+		Matches: []servicesapi.HTTPRouteMatch{{
+			Path: servicesapi.HTTPPathMatch{Type: servicesapi.PathMatchExact, Value: "/login"},
+		}},
+		ForwardTo: []servicesapi.HTTPRouteForwardTo{HTTPRouteForwardTo("avisvc1", 8080, 0)},
+	}})
+
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil {
+			return len(vsNode.SniNodes)
+		}
+		return 0
+	}, 40*time.Second).Should(gomega.Equal(1))
+
+	vsNode := getSvcApiL7Model(modelName)
+	g.Expect(vsNode.PortProto).To(gomega.HaveLen(1))
+	g.Expect(vsNode.PortProto[0].Port).To(gomega.Equal(int32(443)))
+	g.Expect(vsNode.PortProto[0].EnableSSL).To(gomega.BeTrue())
+	sniNode := vsNode.SniNodes[0]
+	g.Expect(sniNode.VHDomainNames).To(gomega.Equal([]string{"secure.com"}))
+	g.Expect(sniNode.SSLKeyCertRefs).To(gomega.HaveLen(1))
+	g.Expect(sniNode.HttpPolicyRefs).To(gomega.HaveLen(1))
+	g.Expect(sniNode.HttpPolicyRefs[0].HppMap[0].Path).To(gomega.Equal([]string{"/login"}))
+	g.Expect(sniNode.HttpPolicyRefs[0].HppMap[0].MatchCriteria).To(gomega.Equal("EQUALS"))
+	g.Expect(sniNode.PoolGroupRefs[0].Members).To(gomega.HaveLen(1))
+	g.Expect(*sniNode.PoolGroupRefs[0].Members[0].Ratio).To(gomega.Equal(int32(1)))
+
+	// the listener is skipped without the certificate.
+	integrationtest.DeleteSecret("gw-cert", ns)
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil {
+			return len(vsNode.SniNodes)
+		}
+		return -1
+	}, 40*time.Second).Should(gomega.Equal(0))
+
+	TeardownHTTPRoute(t, "secure-route", ns)
+	TeardownGateway(t, gatewayName, ns)
+	VerifyGatewayVSNodeDeletion(g, modelName)
+	TeardownGatewayClass(t, gwClassName)
+	integrationtest.DelSVC(t, ns, "avisvc1")
+	integrationtest.DelEP(t, ns, "avisvc1")
+}
+
+func TestServicesAPIHTTPRouteNotAllowed(t *testing.T) {
+	// httproutes from other namespaces are not selected by default,
+	// and routes in the gateway namespace only bind if their hostnames match the listener.
+	g := gomega.NewGomegaWithT(t)
+
+	gwClassName, gatewayName, ns := "avi-lb", "my-http-gateway", "default"
+	modelName := "admin/" + lib.GetSvcApiL7VSName(gatewayName, ns)
+	hostname := servicesapi.Hostname("foo.com")
+
+	SetupGatewayClass(t, gwClassName, lib.SvcApiAviGatewayController, "")
+	SetupRouteGateway(t, gatewayName, ns, gwClassName, []servicesapi.Listener{{
+		Port:     80,
+		Protocol: servicesapi.HTTPProtocolType,
+		Hostname: &hostname,
+		Routes:   servicesapi.RouteBindingSelector{Kind: lib.HTTPRoute},
+	}})
+	integrationtest.CreateSVC(t, ns, "avisvc1", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, "avisvc1", false, false, "1.1.1")
+	integrationtest.CreateSVC(t, "red", "avisvc1", corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, "red", "avisvc1", false, false, "1.1.1")
+
+	rules := []servicesapi.HTTPRouteRule{{
+		ForwardTo: []servicesapi.HTTPRouteForwardTo{HTTPRouteForwardTo("avisvc1", 8080, 0)},
+	}}
+	SetupHTTPRoute(t, "other-ns-route", "red", []string{"foo.com"}, rules)
+	SetupHTTPRoute(t, "other-host-route", ns, []string{"bar.com"}, rules)
+	SetupHTTPRoute(t, "foo-route", ns, nil, rules)
+
+	g.Eventually(func() int {
+		if vsNode := getSvcApiL7Model(modelName); vsNode != nil {
+			return len(vsNode.HttpPolicyRefs)
+		}
+		return 0
+	}, 40*time.Second).Should(gomega.Equal(1))
+	g.Eventually(func() metav1.ConditionStatus {
+		return getRouteAdmittedStatus(ns, "foo-route", ns, gatewayName)
+	}, 40*time.Second).Should(gomega.Equal(metav1.ConditionTrue))
+
+	vsNode := getSvcApiL7Model(modelName)
+	g.Expect(vsNode.HttpPolicyRefs[0].HppMap).To(gomega.HaveLen(1))
+	g.Expect(vsNode.HttpPolicyRefs[0].HppMap[0].Path).To(gomega.Equal([]string{"/"}))
+	g.Expect(vsNode.HttpPolicyRefs[0].HppMap[0].HostHeader.Values).To(gomega.Equal([]string{"foo.com"}))
+	g.Expect(getRouteAdmittedStatus("red", "other-ns-route", ns, gatewayName)).To(gomega.BeEmpty())
+	g.Expect(getRouteAdmittedStatus(ns, "other-host-route", ns, gatewayName)).To(gomega.BeEmpty())
+
+	TeardownHTTPRoute(t, "other-ns-route", "red")
+	TeardownHTTPRoute(t, "other-host-route", ns)
+	TeardownHTTPRoute(t, "foo-route", ns)
+	TeardownGateway(t, gatewayName, ns)
+	VerifyGatewayVSNodeDeletion(g, modelName)
+	TeardownGatewayClass(t, gwClassName)
+	integrationtest.DelSVC(t, ns, "avisvc1")
+	integrationtest.DelEP(t, ns, "avisvc1")
+	integrationtest.DelSVC(t, "red", "avisvc1")
+	integrationtest.DelEP(t, "red", "avisvc1")
+}
+
+func TestServicesAPITCPRoute(t *testing.T) {
+	// create gw with a TCP listener selecting tcproutes, and a tcproute
+	// check the L4 VS listener is bound to the tcproute backend
+	g := gomega.NewGomegaWithT(t)
+
+	gwClassName, gatewayName, ns := "avi-lb", "my-tcp-gateway", "default"
+	modelName := "admin/cluster--default-my-tcp-gateway"
+	svcName := "avisvc1"
+
+	SetupGatewayClass(t, gwClassName, lib.SvcApiAviGatewayController, "")
+	SetupRouteGateway(t, gatewayName, ns, gwClassName, []servicesapi.Listener{{
+		Port:     9000,
+		Protocol: servicesapi.TCPProtocolType,
+		Routes:   servicesapi.RouteBindingSelector{Kind: lib.TCPRoute},
+	}})
+	integrationtest.CreateSVC(t, ns, svcName, corev1.ServiceTypeClusterIP, false)
+	integrationtest.CreateEP(t, ns, svcName, false, false, "1.1.1")
+
+	route := &servicesapi.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "tcp-route"},
+		Spec: servicesapi.TCPRouteSpec{
+			Rules: []servicesapi.TCPRouteRule{{
+				ForwardTo: []servicesapi.RouteForwardTo{{
+					ServiceName: &svcName,
+					Port:        8080,
+				}},
+			}},
+		},
+	}
+	if _, err := SvcAPIClient.NetworkingV1alpha1().TCPRoutes(ns).Create(context.TODO(), route, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding TCPRoute: %v", err)
+	}
+
+	g.Eventually(func() int {
+		found, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		if !found || aviModel == nil {
+			return 0
+		}
+		nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+		if len(nodes) == 0 || len(nodes[0].L4PolicyRefs) == 0 {
+			return 0
+		}
+		return len(nodes[0].L4PolicyRefs[0].PortPool)
+	}, 40*time.Second).Should(gomega.Equal(1))
+
+	_, aviModel := objects.SharedAviGraphLister().Get(modelName)
+	nodes := aviModel.(*avinodes.AviObjectGraph).GetAviVS()
+	g.Expect(nodes[0].PortProto[0].Port).To(gomega.Equal(int32(9000)))
+	g.Expect(nodes[0].L4PolicyRefs[0].PortPool[0].Port).To(gomega.Equal(uint32(9000)))
+	g.Expect(nodes[0].PoolRefs).To(gomega.HaveLen(1))
+	g.Expect(nodes[0].PoolRefs[0].Port).To(gomega.Equal(int32(8080)))
+	g.Expect(nodes[0].PoolRefs[0].Servers).To(gomega.HaveLen(1))
+
+	g.Eventually(func() int {
+		route, _ := SvcAPIClient.NetworkingV1alpha1().TCPRoutes(ns).Get(context.TODO(), "tcp-route", metav1.GetOptions{})
+		return len(route.Status.Gateways)
+	}, 40*time.Second).Should(gomega.Equal(1))
+
+	if err := SvcAPIClient.NetworkingV1alpha1().TCPRoutes(ns).Delete(context.TODO(), "tcp-route", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting TCPRoute: %v", err)
+	}
+	g.Eventually(func() int {
+		found, aviModel := objects.SharedAviGraphLister().Get(modelName)
+		if !found || aviModel == nil {
+			return -1
+		}
+		return len(aviModel.(*avinodes.AviObjectGraph).GetAviVS()[0].PoolRefs)
+	}, 40*time.Second).Should(gomega.Equal(0))
+
+	TeardownGateway(t, gatewayName, ns)
+	VerifyGatewayVSNodeDeletion(g, modelName)
+	TeardownGatewayClass(t, gwClassName)
+	integrationtest.DelSVC(t, ns, svcName)
+	integrationtest.DelEP(t, ns, svcName)
+}
@@ -0,0 +1,407 @@
+/*
+ * Copyright 2021 VMware, Inc.
+ * All Rights Reserved.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*   http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+*/
+
+package vrfpatchtests
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/cache"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/k8s"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/internal/lib"
+	crdfake "github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/client/v1alpha1/clientset/versioned/fake"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/pkg/utils"
+	"github.com/vmware/load-balancer-and-ingress-services-for-kubernetes/tests/integrationtest"
+
+	"github.com/onsi/gomega"
+	avimodels "github.com/vmware/alb-sdk/go/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var KubeClient *k8sfake.Clientset
+var CRDClient *crdfake.Clientset
+var ctrl *k8s.AviController
+
+const (
+	vrfName = "global"
+	vrfUUID = "vrfcontext-941fc441-4433-4827-b456-8f91bd85baf7"
+)
+
+// vrfRequest is a vrfcontext PATCH request made to the controller, with the route ids of the static routes in the request.
+type vrfRequest struct {
+	patchOp  string
+	routeIDs []string
+}
+
+var vrfRequests []vrfRequest
+
+// vrfStaticRoutes are the static routes of the vrf on the controller, the route not owned by the cluster
+// must be left untouched by the PATCH calls.
+var vrfStaticRoutes []interface{}
+var vrfLock sync.RWMutex
+
+func TestMain(m *testing.M) {
+	os.Setenv("VIP_NETWORK_LIST", `[{"networkName":"net123"}]`)
+	os.Setenv("CLUSTER_NAME", "cluster")
+	os.Setenv("CLOUD_NAME", "CLOUD_VCENTER")
+	os.Setenv("SEG_NAME", "Default-Group")
+	os.Setenv("NODE_NETWORK_LIST", `[{"networkName":"net123","cidrs":["10.79.168.0/22"]}]`)
+	os.Setenv("SERVICE_TYPE", "ClusterIP")
+	os.Setenv("AUTO_L4_FQDN", "disable")
+	os.Setenv("POD_NAMESPACE", utils.AKO_DEFAULT_NS)
+	os.Setenv("SHARD_VS_SIZE", "LARGE")
+
+	akoControlConfig := lib.AKOControlConfig()
+	KubeClient = k8sfake.NewSimpleClientset()
+	CRDClient = crdfake.NewSimpleClientset()
+	akoControlConfig.SetCRDClientset(CRDClient)
+	akoControlConfig.SetAKOInstanceFlag(true)
+	akoControlConfig.SetEventRecorder(lib.AKOEventComponent, KubeClient, true)
+	data := map[string][]byte{
+		"username": []byte("admin"),
+		"password": []byte("admin"),
+	}
+	object := metav1.ObjectMeta{Name: "avi-secret", Namespace: utils.GetAKONamespace()}
+	secret := &corev1.Secret{Data: data, ObjectMeta: object}
+	KubeClient.CoreV1().Secrets(utils.GetAKONamespace()).Create(context.TODO(), secret, metav1.CreateOptions{})
+
+	registeredInformers := []string{
+		utils.ServiceInformer,
+		utils.EndpointInformer,
+		utils.SecretInformer,
+		utils.NSInformer,
+		utils.NodeInformer,
+		utils.ConfigMapInformer,
+	}
+	utils.NewInformers(utils.KubeClientIntf{ClientSet: KubeClient}, registeredInformers)
+	informers := k8s.K8sinformers{Cs: KubeClient}
+	k8s.NewCRDInformers(CRDClient)
+
+	integrationtest.InitializeFakeAKOAPIServer()
+
+	integrationtest.NewAviFakeClientInstance(KubeClient)
+	defer integrationtest.AviFakeClientInstance.Close()
+
+	ctrl = k8s.SharedAviController()
+	stopCh := utils.SetupSignalHandler()
+	ctrlCh := make(chan struct{})
+	quickSyncCh := make(chan struct{})
+	waitGroupMap := make(map[string]*sync.WaitGroup)
+	wgIngestion := &sync.WaitGroup{}
+	waitGroupMap["ingestion"] = wgIngestion
+	wgFastRetry := &sync.WaitGroup{}
+	waitGroupMap["fastretry"] = wgFastRetry
+	wgSlowRetry := &sync.WaitGroup{}
+	waitGroupMap["slowretry"] = wgSlowRetry
+	wgGraph := &sync.WaitGroup{}
+	waitGroupMap["graph"] = wgGraph
+	wgStatus := &sync.WaitGroup{}
+	waitGroupMap["status"] = wgStatus
+
+	integrationtest.AddConfigMap(KubeClient)
+	ctrl.SetSEGroupCloudName()
+	integrationtest.PollForSyncStart(ctrl, 10)
+
+	ctrl.HandleConfigMap(informers, ctrlCh, stopCh, quickSyncCh)
+	integrationtest.KubeClient = KubeClient
+	integrationtest.AddDefaultIngressClass()
+
+	go ctrl.InitController(informers, registeredInformers, ctrlCh, stopCh, quickSyncCh, waitGroupMap)
+	os.Exit(m.Run())
+}
+
+// vrfControllerServer keeps the static routes of the vrf, applies the vrfcontext PATCH requests to them and
+// records the requests. All the other requests are handed over to the normal controller server.
+func vrfControllerServer(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.EscapedPath()
+	if r.Method == "GET" && strings.HasSuffix(url, "/api/vrfcontext") {
+		// The vrf collection is read while populating the cache during the bootup.
+		vrfLock.RLock()
+		defer vrfLock.RUnlock()
+		vrf := map[string]interface{}{"uuid": vrfUUID, "name": vrfName, "static_routes": vrfStaticRoutes}
+		data, _ := json.Marshal(map[string]interface{}{"count": 1, "results": []interface{}{vrf}})
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+		return
+	}
+	if !strings.Contains(url, "/api/vrfcontext/"+vrfUUID) || (r.Method != "GET" && r.Method != "PATCH") {
+		integrationtest.NormalControllerServer(w, r)
+		return
+	}
+	vrfLock.Lock()
+	defer vrfLock.Unlock()
+	if r.Method == "PATCH" {
+		var req map[string]map[string][]interface{}
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &req)
+		for op, payload := range req {
+			request := vrfRequest{patchOp: op}
+			for _, staticRoute := range payload["static_routes"] {
+				routeID := staticRoute.(map[string]interface{})["route_id"].(string)
+				request.routeIDs = append(request.routeIDs, routeID)
+				for i, vrfStaticRoute := range vrfStaticRoutes {
+					if vrfStaticRoute.(map[string]interface{})["route_id"] == routeID {
+						vrfStaticRoutes = append(vrfStaticRoutes[:i], vrfStaticRoutes[i+1:]...)
+						break
+					}
+				}
+				if op == utils.PatchAddOp {
+					vrfStaticRoutes = append(vrfStaticRoutes, staticRoute)
+				}
+			}
+			sort.Strings(request.routeIDs)
+			vrfRequests = append(vrfRequests, request)
+		}
+	}
+	resp := map[string]interface{}{
+		"uuid":          vrfUUID,
+		"name":          vrfName,
+		"static_routes": vrfStaticRoutes,
+	}
+	data, _ := json.Marshal(resp)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func setUpVrf() {
+	vrfLock.Lock()
+	vrfRequests = nil
+	vrfStaticRoutes = []interface{}{map[string]interface{}{
+		"route_id": "1",
+		"prefix": map[string]interface{}{
+			"mask":    24,
+			"ip_addr": map[string]interface{}{"type": "V4", "addr": "10.244.0.0"},
+		},
+		"next_hop": map[string]interface{}{"type": "V4", "addr": "10.52.2.23"},
+	}}
+	vrfLock.Unlock()
+	integrationtest.AddMiddleware(vrfControllerServer)
+}
+
+func getVrfRequests() []vrfRequest {
+	vrfLock.RLock()
+	defer vrfLock.RUnlock()
+	requests := make([]vrfRequest, len(vrfRequests))
+	copy(requests, vrfRequests)
+	return requests
+}
+
+func resetVrfRequests() {
+	vrfLock.Lock()
+	vrfRequests = nil
+	vrfLock.Unlock()
+}
+
+// getVrfRouteIDs returns the route ids of the static routes of the vrf on the controller.
+func getVrfRouteIDs() []string {
+	vrfLock.RLock()
+	defer vrfLock.RUnlock()
+	var routeIDs []string
+	for _, staticRoute := range vrfStaticRoutes {
+		routeIDs = append(routeIDs, staticRoute.(map[string]interface{})["route_id"].(string))
+	}
+	sort.Strings(routeIDs)
+	return routeIDs
+}
+
+// getVrfCacheRouteIDs returns the route ids of the static routes in the vrf cache.
+func getVrfCacheRouteIDs() []string {
+	vrfCache, found := cache.SharedAviObjCache().VrfCache.AviCacheGet(vrfName)
+	if !found {
+		return nil
+	}
+	routeIDs := []string{}
+	for routeID := range vrfCache.(*cache.AviVrfCache).StaticRoutes {
+		routeIDs = append(routeIDs, routeID)
+	}
+	sort.Strings(routeIDs)
+	return routeIDs
+}
+
+func TestStaticRoutesPatched(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpVrf()
+	defer integrationtest.ResetMiddleware()
+
+	node1Route := lib.GetStaticRouteID("testNode1", "10.244.1.0/24")
+	node2Route := lib.GetStaticRouteID("testNode2", "10.244.2.0/24")
+	g.Expect(node1Route).To(gomega.Equal("cluster-testNode1-10.244.1.0-24"))
+
+	// The route of a new node is added with a PATCH call carrying only its route.
+	node1 := (integrationtest.FakeNode{Name: "testNode1", PodCIDR: "10.244.1.0/24", Version: "1", NodeIP: "10.1.1.1"}).Node()
+	if _, err := KubeClient.CoreV1().Nodes().Create(context.TODO(), node1, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{node1Route}))
+	g.Expect(getVrfRequests()).To(gomega.Equal([]vrfRequest{{patchOp: utils.PatchAddOp, routeIDs: []string{node1Route}}}))
+
+	// Adding another node does not change the route of the first node.
+	resetVrfRequests()
+	node2 := (integrationtest.FakeNode{Name: "testNode2", PodCIDR: "10.244.2.0/24", Version: "1", NodeIP: "10.1.1.2"}).Node()
+	if _, err := KubeClient.CoreV1().Nodes().Create(context.TODO(), node2, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{node1Route, node2Route}))
+	g.Expect(getVrfRequests()).To(gomega.Equal([]vrfRequest{{patchOp: utils.PatchAddOp, routeIDs: []string{node2Route}}}))
+
+	// A pod CIDR change adds the new route of the node before deleting the old one.
+	resetVrfRequests()
+	node1Updated := lib.GetStaticRouteID("testNode1", "10.244.3.0/24")
+	node1.Spec.PodCIDR = "10.244.3.0/24"
+	node1.ResourceVersion = "2"
+	if _, err := KubeClient.CoreV1().Nodes().Update(context.TODO(), node1, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{node1Updated, node2Route}))
+	g.Expect(getVrfRequests()).To(gomega.Equal([]vrfRequest{
+		{patchOp: utils.PatchAddOp, routeIDs: []string{node1Updated}},
+		{patchOp: utils.PatchDeleteOp, routeIDs: []string{node1Route}},
+	}))
+
+	// A node IP change keeps the route id, the route is replaced in place without a delete.
+	resetVrfRequests()
+	node2.Status.Addresses[0].Address = "10.1.1.22"
+	node2.ResourceVersion = "2"
+	if _, err := KubeClient.CoreV1().Nodes().Update(context.TODO(), node2, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Node: %v", err)
+	}
+	g.Eventually(getVrfRequests, 10*time.Second).Should(gomega.Equal([]vrfRequest{{patchOp: utils.PatchAddOp, routeIDs: []string{node2Route}}}))
+	g.Consistently(getVrfRequests, 2*time.Second).Should(gomega.HaveLen(1))
+	g.Expect(getVrfRouteIDs()).To(gomega.Equal([]string{"1", node1Updated, node2Route}))
+
+	// Deleting a node deletes only its route, the route not owned by the cluster stays in the vrf.
+	resetVrfRequests()
+	if err := KubeClient.CoreV1().Nodes().Delete(context.TODO(), "testNode2", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{node1Updated}))
+	g.Expect(getVrfRequests()).To(gomega.Equal([]vrfRequest{{patchOp: utils.PatchDeleteOp, routeIDs: []string{node2Route}}}))
+	g.Expect(getVrfRouteIDs()).To(gomega.Equal([]string{"1", node1Updated}))
+
+	if err := KubeClient.CoreV1().Nodes().Delete(context.TODO(), "testNode1", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.BeEmpty())
+	g.Expect(getVrfRouteIDs()).To(gomega.Equal([]string{"1"}))
+}
+
+func TestStaticRouteMissingOnControllerRemovedFromCache(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpVrf()
+	defer integrationtest.ResetMiddleware()
+
+	nodeRoute := lib.GetStaticRouteID("testNode3", "10.244.4.0/24")
+	node := (integrationtest.FakeNode{Name: "testNode3", PodCIDR: "10.244.4.0/24", Version: "1", NodeIP: "10.1.1.3"}).Node()
+	if _, err := KubeClient.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{nodeRoute}))
+
+	// The route is removed from the vrf out of band, so there is nothing to delete when the node goes away.
+	vrfLock.Lock()
+	vrfStaticRoutes = vrfStaticRoutes[:1]
+	vrfLock.Unlock()
+	resetVrfRequests()
+	if err := KubeClient.CoreV1().Nodes().Delete(context.TODO(), "testNode3", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.BeEmpty())
+	g.Expect(getVrfRequests()).To(gomega.BeEmpty())
+}
+
+// buildStaticRoute builds the static route of the cluster with the route id, in the format of the routes of the vrf.
+func buildStaticRoute(routeID, prefix, nextHop string) interface{} {
+	addrType, mask := "V4", int32(24)
+	addr := strings.Split(prefix, "/")[0]
+	staticRoute := avimodels.StaticRoute{
+		RouteID: &routeID,
+		Prefix:  &avimodels.IPAddrPrefix{IPAddr: &avimodels.IPAddr{Addr: &addr, Type: &addrType}, Mask: &mask},
+		NextHop: &avimodels.IPAddr{Addr: &nextHop, Type: &addrType},
+		Labels:  lib.GetLabels(),
+	}
+	var staticRouteMap map[string]interface{}
+	data, _ := json.Marshal(staticRoute)
+	json.Unmarshal(data, &staticRouteMap)
+	return staticRouteMap
+}
+
+func TestStaticRoutesWithLegacyRouteIDs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	setUpVrf()
+	defer integrationtest.ResetMiddleware()
+
+	// The vrf has the routes created by an earlier release, numbered in the order of the nodes, when AKO boots up.
+	vrfLock.Lock()
+	vrfStaticRoutes = append(vrfStaticRoutes,
+		buildStaticRoute("cluster-1", "10.244.5.0/24", "10.1.1.5"),
+		buildStaticRoute("cluster-2", "10.244.6.0/24", "10.1.1.6"))
+	vrfLock.Unlock()
+	aviObjCache := cache.SharedAviObjCache()
+	if err := aviObjCache.AviObjVrfCachePopulate(cache.SharedAVIClients().AviClient[0], "CLOUD_VCENTER"); err != nil {
+		t.Fatalf("error in populating the vrf cache: %v", err)
+	}
+	g.Expect(getVrfCacheRouteIDs()).To(gomega.Equal([]string{"cluster-1", "cluster-2"}))
+
+	// The route of the node with the prefix of a legacy route keeps the legacy route id, so it is not added
+	// again. Only the route of the node that no longer exists is deleted.
+	resetVrfRequests()
+	node := (integrationtest.FakeNode{Name: "testNode5", PodCIDR: "10.244.5.0/24", Version: "1", NodeIP: "10.1.1.5"}).Node()
+	if _, err := KubeClient.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("error in adding Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{"cluster-1"}))
+	g.Consistently(getVrfRequests, 2*time.Second).Should(gomega.Equal([]vrfRequest{{patchOp: utils.PatchDeleteOp, routeIDs: []string{"cluster-2"}}}))
+	g.Expect(getVrfRouteIDs()).To(gomega.Equal([]string{"1", "cluster-1"}))
+
+	// A node IP change replaces the route in place with the legacy route id, without adding a route for the same prefix.
+	resetVrfRequests()
+	node.Status.Addresses[0].Address = "10.1.1.55"
+	node.ResourceVersion = "2"
+	if _, err := KubeClient.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Node: %v", err)
+	}
+	g.Eventually(getVrfRequests, 10*time.Second).Should(gomega.Equal([]vrfRequest{{patchOp: utils.PatchAddOp, routeIDs: []string{"cluster-1"}}}))
+	g.Expect(getVrfRouteIDs()).To(gomega.Equal([]string{"1", "cluster-1"}))
+
+	// A pod CIDR change moves the route of the node to the new route id.
+	resetVrfRequests()
+	nodeRoute := lib.GetStaticRouteID("testNode5", "10.244.7.0/24")
+	node.Spec.PodCIDR = "10.244.7.0/24"
+	node.ResourceVersion = "3"
+	if _, err := KubeClient.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error in updating Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.Equal([]string{nodeRoute}))
+	g.Expect(getVrfRequests()).To(gomega.Equal([]vrfRequest{
+		{patchOp: utils.PatchAddOp, routeIDs: []string{nodeRoute}},
+		{patchOp: utils.PatchDeleteOp, routeIDs: []string{"cluster-1"}},
+	}))
+
+	if err := KubeClient.CoreV1().Nodes().Delete(context.TODO(), "testNode5", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error in deleting Node: %v", err)
+	}
+	g.Eventually(getVrfCacheRouteIDs, 10*time.Second).Should(gomega.BeEmpty())
+	g.Expect(getVrfRouteIDs()).To(gomega.Equal([]string{"1"}))
+}